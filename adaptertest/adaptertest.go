@@ -0,0 +1,387 @@
+// Package adaptertest is a conformance test suite for game adapters built on the SDK.
+//
+// Run drives the tasks of an adapter through a real GameHandler against a nexustest.Server,
+// and checks the behaviors the rest of the SDK relies on: the tasks log in with the game data
+// of their account, send the adapter's headers, and return the errors of the game instead of
+// hiding them, so that retries, game data refreshes, ban detection, and maintenance detection
+// work for every adapter.
+//
+// # Example:
+//
+//	// adapter_test.go of a game adapter
+//	func TestConformance(t *testing.T) {
+//		adaptertest.Run(t, mygame.Adapter{})
+//	}
+package adaptertest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/handler"
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/nexustest"
+	"github.com/nexus-telegram/NexusSDK/tasks"
+	"github.com/nexus-telegram/NexusSDK/testutil"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils/retry"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// GameAdapter is a game adapter as exercised by Run.
+//
+// # Methods:
+//   - Game() string: Returns the name of the game, as sent to the Nexus API.
+//   - Tasks() []tasks.Task: Returns new instances of the tasks of the adapter. It is called once
+//     per handler created by the suite, so tasks keeping state start afresh.
+//   - Routes(server *nexustest.Server): Registers the answers of the game endpoints called by
+//     the tasks on the fake server (see nexustest.Server.Handle). Unregistered endpoints answer
+//     {"ok": true}.
+type GameAdapter interface {
+	Game() string
+	Tasks() []tasks.Task
+	Routes(server *nexustest.Server)
+}
+
+// Configurer is implemented by adapters that set up the handler beyond its tasks, e.g. named
+// base URLs, a decoder, or headers. Run calls Configure once the handler points to the fake
+// server and before the tasks are added.
+type Configurer interface {
+	Configure(gameHandler *handler.GameHandler) error
+}
+
+// HeaderBuilder is implemented by adapters whose requests carry headers of their own, e.g. the
+// origin of the game's web app or a device header. Run checks that every game request sent for
+// an account carries the headers returned for it.
+type HeaderBuilder interface {
+	Headers(account types.Account) map[string]string
+}
+
+// accountIds are the Telegram IDs of the accounts of the handlers created by the suite.
+var accountIds = []string{"100000001", "100000002"}
+
+// Run runs the conformance suite against an adapter, as subtests of t:
+//   - tasks: Every task has a unique name, and scheduled tasks reschedule themselves after
+//     their last execution.
+//   - login: Every task succeeds for every account against a server requiring the game data
+//     of the account, and every game request it sends is authenticated as that account.
+//   - headers: Every game request carries the headers of the adapter (see HeaderBuilder).
+//   - errors/expired: Every task whose game data is refused fails with the 401 of the game,
+//     after the handler logged in again through the Nexus API.
+//   - errors/maintenance: Every task sending requests while the game is under maintenance fails
+//     with the 503 of the game.
+//
+// The error checks run every task alone, one subtest per task; tasks sending no game request
+// are skipped.
+//
+// # Parameters:
+//   - t: The test the suite runs in.
+//   - adapter: The adapter under test.
+func Run(t *testing.T, adapter GameAdapter) {
+	t.Run("tasks", func(t *testing.T) { testTasks(t, adapter) })
+	t.Run("login", func(t *testing.T) { testLogin(t, adapter) })
+	t.Run("headers", func(t *testing.T) { testHeaders(t, adapter) })
+	t.Run("errors", func(t *testing.T) {
+		for i, task := range adapter.Tasks() {
+			name := taskName(task)
+			t.Run("expired/"+name, func(t *testing.T) { testExpired(t, adapter, i) })
+			t.Run("maintenance/"+name, func(t *testing.T) { testMaintenance(t, adapter, i) })
+		}
+	})
+}
+
+// taskName returns the name of a task, or its type when it has none.
+func taskName(task tasks.Task) string {
+	if named, ok := task.(interface{ GetName() string }); ok && named.GetName() != "" {
+		return named.GetName()
+	}
+	return fmt.Sprintf("%T", task)
+}
+
+// testTasks checks the tasks of the adapter without running them.
+func testTasks(t *testing.T, adapter GameAdapter) {
+	if adapter.Game() == "" {
+		t.Error("Game returns an empty name")
+	}
+	adapterTasks := adapter.Tasks()
+	if len(adapterTasks) == 0 {
+		t.Fatal("the adapter has no task")
+	}
+	names := make(map[string]bool, len(adapterTasks))
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, task := range adapterTasks {
+		named, ok := task.(interface{ GetName() string })
+		if !ok || named.GetName() == "" {
+			t.Errorf("task %T has no name, its executions cannot be told apart", task)
+			continue
+		}
+		name := named.GetName()
+		if names[name] {
+			t.Errorf("task %q is declared twice, the jobs of the two tasks collide", name)
+		}
+		names[name] = true
+		if scheduled, ok := task.(tasks.Scheduled); ok {
+			if next := scheduled.Next(now, now); !next.After(now) {
+				t.Errorf("task %q reschedules itself at %s after running at %s, it would run in a loop", name, next, now)
+			}
+		}
+	}
+}
+
+// testLogin checks that the tasks succeed and authenticate with the game data of the account.
+func testLogin(t *testing.T, adapter GameAdapter) {
+	h := newHarness(t, adapter, allTasks)
+	h.server.RequireAuth = true
+	h.run(t)
+	for _, result := range h.results() {
+		if result.Err != nil {
+			t.Errorf("task %q failed for account %s: %v", result.Task, result.Account, result.Err)
+		}
+	}
+	requests := h.gameRequests()
+	if len(requests) == 0 {
+		t.Error("the tasks sent no request to the game")
+	}
+	for _, request := range requests {
+		if request.Account == "" {
+			t.Errorf("%s %s does not carry the game data of its account", request.Method, request.Path)
+		}
+	}
+}
+
+// testHeaders checks that the game requests carry the headers of the adapter.
+func testHeaders(t *testing.T, adapter GameAdapter) {
+	builder, ok := adapter.(HeaderBuilder)
+	if !ok {
+		t.Skip("the adapter does not implement HeaderBuilder")
+	}
+	h := newHarness(t, adapter, allTasks)
+	h.run(t)
+	for _, request := range h.gameRequests() {
+		account, ok := h.account(request.Account)
+		if !ok {
+			continue
+		}
+		for key, want := range builder.Headers(account) {
+			if got := request.Header.Get(key); got != want {
+				t.Errorf("%s %s of account %s: header %s is %q, want %q", request.Method, request.Path, account.TelegramId, key, got, want)
+			}
+		}
+	}
+}
+
+// testExpired checks that the tasks fail with the status of the game when their game data is
+// refused, and that the handler logs in again before retrying.
+func testExpired(t *testing.T, adapter GameAdapter, task int) {
+	h := newHarness(t, adapter, task)
+	h.server.RequireAuth = true
+	h.server.ExpireTokens()
+	h.run(t)
+	h.checkFailures(t, http.StatusUnauthorized)
+	logins := make(map[string]int)
+	for _, request := range h.server.Requests() {
+		if request.Path == "/api/telegram/game-data" {
+			logins[request.Account]++
+		}
+	}
+	for _, id := range accountIds {
+		if h.sent(id) && logins[id] == 0 {
+			t.Errorf("account %s was not logged in again through the Nexus API after its game data was refused", id)
+		}
+	}
+}
+
+// testMaintenance checks that the tasks fail with the status of the game during a maintenance.
+func testMaintenance(t *testing.T, adapter GameAdapter, task int) {
+	h := newHarness(t, adapter, task)
+	h.server.SetMaintenance(true)
+	h.run(t)
+	h.checkFailures(t, http.StatusServiceUnavailable)
+}
+
+// harness is a GameHandler running the tasks of an adapter against a fake server.
+type harness struct {
+	server   *nexustest.Server
+	handler  *handler.GameHandler
+	clock    *testutil.FakeClock
+	accounts []types.Account
+	mu       sync.Mutex
+	executed []handler.TaskResult
+}
+
+// allTasks makes newHarness add every task of the adapter.
+const allTasks = -1
+
+// newHarness creates a handler with the task of the adapter at an index, or with every task,
+// and two accounts holding valid game data, pointing to a new fake server. Both are closed at
+// the end of the test.
+func newHarness(t *testing.T, adapter GameAdapter, only int) *harness {
+	t.Helper()
+	server := nexustest.NewServer()
+	t.Cleanup(server.Close)
+	adapter.Routes(server)
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	data, err := json.Marshal(types.Config{APIKey: "adaptertest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var accounts []types.Account
+	for _, id := range accountIds {
+		accounts = append(accounts, types.Account{
+			GameData:     server.IssueToken(id),
+			TelegramData: types.TelegramData{TelegramId: id, TdataStringSession: "adaptertest-session-" + id},
+		})
+	}
+	accountsPath := filepath.Join(dir, "accounts.json")
+	if err := handler.SaveAccounts(accountsPath, accounts); err != nil {
+		t.Fatal(err)
+	}
+	gameHandler, err := handler.NewGameHandler(configPath, accountsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = gameHandler.Close() })
+	gameHandler.GameName = adapter.Game()
+	gameHandler.SetBaseURL(server.GameURL())
+	gameHandler.SetNexusAPIURL(server.NexusAPIURL())
+	gameHandler.SetTaskRetryPolicy(retry.Policy{MaxAttempts: 2})
+	if configurer, ok := adapter.(Configurer); ok {
+		if err := configurer.Configure(gameHandler); err != nil {
+			t.Fatalf("Configure: %v", err)
+		}
+	}
+	h := &harness{server: server, handler: gameHandler, clock: testutil.NewFakeClock(time.Now()), accounts: gameHandler.GetAccounts()}
+	gameHandler.SetClock(h.clock)
+	gameHandler.AddResultListener(func(result handler.TaskResult) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.executed = append(h.executed, result)
+	})
+	for i, task := range adapter.Tasks() {
+		if only == allTasks || i == only {
+			gameHandler.AddTask(task)
+		}
+	}
+	return h
+}
+
+// run runs every task of the handler once for every account: the one-time tasks first, then
+// the scheduled tasks, once the clock of the handler reached the time they are due.
+func (h *harness) run(t *testing.T) {
+	t.Helper()
+	h.runOnce(t)
+	jobs, err := h.handler.Jobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) == 0 {
+		return
+	}
+	due := h.clock.Now()
+	for _, job := range jobs {
+		if job.Due.After(due) {
+			due = job.Due
+		}
+	}
+	h.clock.Set(due)
+	var scheduled []tasks.Task
+	for _, task := range h.handler.Tasks {
+		if _, ok := task.(tasks.Scheduled); ok {
+			scheduled = append(scheduled, task)
+		}
+	}
+	h.handler.Tasks = scheduled
+	h.runOnce(t)
+}
+
+// runOnce runs the due tasks of the handler once.
+func (h *harness) runOnce(t *testing.T) {
+	t.Helper()
+	if report := h.handler.RunOnce(context.Background(), time.Minute); report.Unstarted > 0 {
+		t.Fatalf("%d task executions did not start within a minute", report.Unstarted)
+	}
+}
+
+// results returns the executions of the tasks that were not skipped.
+func (h *harness) results() []handler.TaskResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var results []handler.TaskResult
+	for _, result := range h.executed {
+		if !result.Skipped {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// gameRequests returns the requests received by the fake game API.
+func (h *harness) gameRequests() []nexustest.Request {
+	var requests []nexustest.Request
+	for _, request := range h.server.Requests() {
+		if request.Path == "/game" || strings.HasPrefix(request.Path, "/game/") {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// account returns the account of the handler with a Telegram ID.
+func (h *harness) account(telegramId string) (types.Account, bool) {
+	for _, account := range h.accounts {
+		if account.TelegramId == telegramId {
+			return account, true
+		}
+	}
+	return types.Account{}, false
+}
+
+// sent reports whether the tasks sent game requests. Requests refused before their account
+// is known are not attributed to an account, so any game request counts for every account.
+func (h *harness) sent(telegramId string) bool {
+	for _, request := range h.gameRequests() {
+		if request.Account == telegramId || request.Account == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFailures checks that every execution failed with an error of the game with the status,
+// and skips the test when the tasks sent no game request.
+func (h *harness) checkFailures(t *testing.T, status int) {
+	t.Helper()
+	results := h.results()
+	if len(results) == 0 {
+		t.Fatal("no task was executed")
+	}
+	if len(h.gameRequests()) == 0 {
+		t.Skip("the tasks sent no request to the game")
+	}
+	for _, result := range results {
+		if result.Err == nil {
+			t.Errorf("task %q succeeded for account %s although the game answered %d; return the error of the request",
+				result.Task, result.Account, status)
+			continue
+		}
+		var statusErr *httpclient.StatusError
+		if !errors.As(result.Err, &statusErr) {
+			t.Errorf("task %q failed for account %s with %q, which does not wrap the *httpclient.StatusError of the game; wrap it with %%w",
+				result.Task, result.Account, result.Err)
+		} else if statusErr.StatusCode != status {
+			t.Errorf("task %q failed for account %s with status %d, want %d", result.Task, result.Account, statusErr.StatusCode, status)
+		}
+	}
+}
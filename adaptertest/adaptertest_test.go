@@ -0,0 +1,135 @@
+package adaptertest_test
+
+import (
+	"github.com/nexus-telegram/NexusSDK/adaptertest"
+	"github.com/nexus-telegram/NexusSDK/nexustest"
+	"github.com/nexus-telegram/NexusSDK/tasks"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// brokenAdapterEnv names the adapter of brokenAdapters run by TestBrokenAdapter.
+const brokenAdapterEnv = "ADAPTERTEST_BROKEN_ADAPTER"
+
+// adapter is a game adapter built from the stock tasks.
+type adapter struct {
+	tasks func() []tasks.Task
+}
+
+func (adapter) Game() string                    { return "stock" }
+func (adapter adapter) Tasks() []tasks.Task     { return adapter.tasks() }
+func (adapter) Routes(server *nexustest.Server) {}
+
+// headerAdapter is an adapter whose requests carry the game data in header.
+type headerAdapter struct {
+	adapter
+	header string
+}
+
+func (adapter headerAdapter) Headers(account types.Account) map[string]string {
+	return map[string]string{adapter.header: account.GameData}
+}
+
+// claimTask is a one-time task sending the game data in a header.
+func claimTask() tasks.Task {
+	task := tasks.NewOneTimeTask("claim", map[string]interface{}{"reward": "daily"})
+	task.AuthLocation = tasks.AuthHeader
+	return task
+}
+
+// farmTask is a recurrent task sending the game data in the payload.
+func farmTask(interval time.Duration) tasks.Task {
+	task := tasks.NewRecurrentTask("farm", nil, interval)
+	task.AuthLocation = tasks.AuthBody
+	return task
+}
+
+// swallowingTask sends the game data but ignores the answer of the game.
+type swallowingTask struct {
+	tasks.BaseTask
+}
+
+func (task *swallowingTask) Run(account types.Account, handler tasks.Handler) error {
+	url, err := tasks.WithGameData(handler.GetBaseURL(), account, "")
+	if err != nil {
+		return err
+	}
+	_, _ = handler.Get(url)
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		adapter adaptertest.GameAdapter
+	}{
+		{"stock tasks", adapter{func() []tasks.Task { return []tasks.Task{claimTask(), farmTask(time.Hour)} }}},
+		{"headers", headerAdapter{adapter{func() []tasks.Task {
+			farm := tasks.NewRecurrentTask("farm", nil, time.Hour)
+			farm.AuthLocation = tasks.AuthHeader
+			return []tasks.Task{claimTask(), farm}
+		}}, "Authorization"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) { adaptertest.Run(t, test.adapter) })
+	}
+}
+
+// brokenAdapters are adapters breaking the conformance suite, each with the subtest of Run
+// expected to fail.
+var brokenAdapters = map[string]struct {
+	adapter adaptertest.GameAdapter
+	failing string
+}{
+	"unnamed": {adapter{func() []tasks.Task {
+		return []tasks.Task{claimTask(), tasks.NewOneTimeTask("", nil)}
+	}}, "tasks"},
+	"duplicate": {adapter{func() []tasks.Task {
+		return []tasks.Task{farmTask(time.Hour), farmTask(time.Minute)}
+	}}, "tasks"},
+	"looping": {adapter{func() []tasks.Task {
+		return []tasks.Task{farmTask(0)}
+	}}, "tasks"},
+	"anonymous": {adapter{func() []tasks.Task {
+		return []tasks.Task{tasks.NewOneTimeTask("claim", nil)}
+	}}, "login"},
+	"missing header": {headerAdapter{adapter{func() []tasks.Task {
+		return []tasks.Task{claimTask()}
+	}}, "X-Device"}, "headers"},
+	"swallowed errors": {adapter{func() []tasks.Task {
+		return []tasks.Task{&swallowingTask{tasks.BaseTask{Name: "claim"}}}
+	}}, "errors/expired/claim"},
+}
+
+// TestBrokenAdapter runs the suite against the adapter of brokenAdapters named by
+// brokenAdapterEnv. It is run by TestRunFails in a child process, since it is meant to fail.
+func TestBrokenAdapter(t *testing.T) {
+	name := os.Getenv(brokenAdapterEnv)
+	if name == "" {
+		t.Skip("run by TestRunFails")
+	}
+	adaptertest.Run(t, brokenAdapters[name].adapter)
+}
+
+func TestRunFails(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the suite in child processes")
+	}
+	for name, broken := range brokenAdapters {
+		t.Run(name, func(t *testing.T) {
+			command := exec.Command(os.Args[0], "-test.run=^TestBrokenAdapter$", "-test.v")
+			command.Env = append(os.Environ(), brokenAdapterEnv+"="+name)
+			output, err := command.CombinedOutput()
+			if _, ok := err.(*exec.ExitError); !ok {
+				t.Fatalf("suite passed against the %s adapter (%v):\n%s", name, err, output)
+			}
+			if want := "--- FAIL: TestBrokenAdapter/" + broken.failing + " "; !strings.Contains(string(output), want) {
+				t.Errorf("subtest %s did not fail against the %s adapter:\n%s", broken.failing, name, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+// Package apikeys assigns Nexus API keys to games and accounts, for operators running farms
+// on behalf of several customers, and fails over to another key when one hits its quota.
+package apikeys
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/secrets"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils/clock"
+	"sync"
+	"time"
+)
+
+// DefaultQuotaCooldown is how long a key that hit its quota is left out of the rotation.
+const DefaultQuotaCooldown = time.Hour
+
+// DefaultKeyName is the name of the key of the api_key setting in a Pool created by FromConfig.
+const DefaultKeyName = "default"
+
+// ErrQuotaExceeded is returned when the Nexus API refuses a key because its quota is used up,
+// or when every key of an account is exhausted.
+var ErrQuotaExceeded = errors.New("nexus api key quota exceeded")
+
+// Key is a Nexus API key and the games and accounts it is reserved for.
+//
+// # Fields:
+//   - Name: The name of the key in logs and statistics (e.g., the customer name).
+//   - Value: The API key. Empty once the key is sealed.
+//   - Sealed: The encrypted API key, set by Pool.Seal (see Secret).
+//   - Games: The games the key is reserved for.
+//   - Accounts: The Telegram IDs of the accounts the key is reserved for.
+//   - Quota: The number of refreshes allowed per day, used to estimate the remaining quota when
+//     the Nexus API does not report it. Zero means unknown.
+type Key struct {
+	Name     string
+	Value    string
+	Sealed   *secrets.Sealed
+	Games    []string
+	Accounts []string
+	Quota    int
+}
+
+// Secret returns the API key, decrypting it if it is sealed.
+func (key Key) Secret() (string, error) {
+	if key.Sealed != nil {
+		return key.Sealed.Reveal()
+	}
+	return key.Value, nil
+}
+
+// Pool selects the API key of each refresh of game data.
+//
+// The keys of an account are the keys reserved for it; accounts without one use the keys
+// reserved for their game, and games without one use the keys reserved for nothing. Keys of
+// one customer are never used for the accounts of another. Within those keys, the first one
+// that has not hit its quota in the last QuotaCooldown is used, so refreshes fail over to the
+// next key in configuration order when the Nexus API reports an exhausted quota.
+//
+// # Fields:
+//   - QuotaCooldown: How long an exhausted key is skipped. Defaults to DefaultQuotaCooldown.
+//   - Clock: The clock of the cooldowns. Defaults to clock.Real.
+//
+// # Example:
+//
+//	pool := apikeys.New(
+//		apikeys.Key{Name: "alice", Value: "key-1", Games: []string{"hamster"}},
+//		apikeys.Key{Name: "bob", Value: "key-2", Accounts: []string{"987654321"}},
+//		apikeys.Key{Name: "shared", Value: "key-3"},
+//	)
+//	gameHandler.SetAPIKeyPool(pool)
+type Pool struct {
+	QuotaCooldown time.Duration
+	Clock         clock.Clock
+	mu            sync.Mutex
+	keys          []Key
+	exhausted     map[string]time.Time
+	usage         *Tracker
+}
+
+// New creates a pool of keys, in failover order.
+func New(keys ...Key) *Pool {
+	pool := &Pool{keys: keys, exhausted: make(map[string]time.Time), usage: NewTracker()}
+	for _, key := range keys {
+		if key.Quota > 0 {
+			pool.usage.SetQuota(key.Name, key.Quota)
+		}
+	}
+	return pool
+}
+
+// Tracker returns the usage tracker of the keys of the pool.
+func (pool *Pool) Tracker() *Tracker {
+	return pool.usage
+}
+
+// FromConfig creates a pool from the api_keys section of the configuration file, followed by
+// the api_key setting as a key named DefaultKeyName reserved for nothing. It returns nil when
+// api_keys is empty, in which case the handler uses api_key alone.
+func FromConfig(config types.Config) *Pool {
+	if len(config.APIKeys) == 0 {
+		return nil
+	}
+	keys := make([]Key, 0, len(config.APIKeys)+1)
+	for i, key := range config.APIKeys {
+		name := key.Name
+		if name == "" {
+			name = fmt.Sprintf("key-%d", i+1)
+		}
+		keys = append(keys, Key{Name: name, Value: key.Key, Games: key.Games, Accounts: key.Accounts, Quota: key.Quota})
+	}
+	if config.APIKey != "" {
+		keys = append(keys, Key{Name: DefaultKeyName, Value: config.APIKey})
+	}
+	pool := New(keys...)
+	if config.APIKeyCooldown > 0 {
+		pool.QuotaCooldown = time.Duration(config.APIKeyCooldown) * time.Minute
+	}
+	return pool
+}
+
+// Seal encrypts the values of the keys of the pool with sealer, so that they are only
+// decrypted when a refresh sends them (see secrets.Sealer). Keys sealed by another sealer are
+// sealed again; a nil sealer decrypts the keys back into their Value.
+//
+// # Returns:
+//   - error: An error if a sealed key cannot be decrypted. The pool is left unchanged then.
+func (pool *Pool) Seal(sealer *secrets.Sealer) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	values := make([]string, len(pool.keys))
+	for i, key := range pool.keys {
+		value, err := key.Secret()
+		if err != nil {
+			return fmt.Errorf("api key %s: %w", key.Name, err)
+		}
+		values[i] = value
+	}
+	for i := range pool.keys {
+		if sealer == nil {
+			pool.keys[i].Value, pool.keys[i].Sealed = values[i], nil
+		} else {
+			pool.keys[i].Value, pool.keys[i].Sealed = "", sealer.Seal(values[i])
+		}
+	}
+	return nil
+}
+
+// Rotate replaces the value of the key named name, e.g. before the old value expires.
+// Refreshes started afterwards use the new value; the key is no longer considered exhausted,
+// and its quota estimate starts over.
+//
+// # Parameters:
+//   - name: The name of the key.
+//   - value: The new API key.
+//   - sealer: The sealer the new value is encrypted with, nil to keep it in plain text.
+//
+// # Returns:
+//   - bool: Whether the pool has a key named name.
+//
+// # Example:
+//
+//	if !pool.Rotate("alice", newKey, nil) {
+//		log.Printf("No API key named alice")
+//	}
+func (pool *Pool) Rotate(name, value string, sealer *secrets.Sealer) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	found := false
+	for i := range pool.keys {
+		if pool.keys[i].Name != name {
+			continue
+		}
+		if sealer == nil {
+			pool.keys[i].Value, pool.keys[i].Sealed = value, nil
+		} else {
+			pool.keys[i].Value, pool.keys[i].Sealed = "", sealer.Seal(value)
+		}
+		found = true
+	}
+	if found {
+		delete(pool.exhausted, name)
+		pool.usage.Reset(name)
+	}
+	return found
+}
+
+// Keys returns the keys of the pool.
+func (pool *Pool) Keys() []Key {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return append([]Key(nil), pool.keys...)
+}
+
+// Candidates returns the keys usable by an account of game, in failover order, leaving out
+// the exhausted ones.
+//
+// # Parameters:
+//   - game: The name of the game.
+//   - account: The Telegram ID of the account.
+//
+// # Returns:
+//   - []Key: The usable keys.
+//   - error: ErrQuotaExceeded if the account has keys but all of them are exhausted, or an
+//     error if no key is assigned to the account.
+func (pool *Pool) Candidates(game, account string) ([]Key, error) {
+	now := pool.clock().Now()
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	assigned := pool.assigned(game, account)
+	if len(assigned) == 0 {
+		return nil, fmt.Errorf("no api key assigned to account %s of %s", account, game)
+	}
+	var usable []Key
+	for _, key := range assigned {
+		if until, ok := pool.exhausted[key.Name]; ok && now.Before(until) {
+			continue
+		}
+		usable = append(usable, key)
+	}
+	if len(usable) == 0 {
+		return nil, fmt.Errorf("every api key of account %s is exhausted: %w", account, ErrQuotaExceeded)
+	}
+	return usable, nil
+}
+
+// assigned returns the keys of the most specific assignment of an account. It must be called
+// with mu held.
+func (pool *Pool) assigned(game, account string) []Key {
+	var forAccount, forGame, shared []Key
+	for _, key := range pool.keys {
+		switch {
+		case contains(key.Accounts, account):
+			forAccount = append(forAccount, key)
+		case len(key.Accounts) > 0:
+		case contains(key.Games, game):
+			forGame = append(forGame, key)
+		case len(key.Games) == 0:
+			shared = append(shared, key)
+		}
+	}
+	switch {
+	case len(forAccount) > 0:
+		return forAccount
+	case len(forGame) > 0:
+		return forGame
+	default:
+		return shared
+	}
+}
+
+// MarkExhausted leaves the key named name out of the candidates for QuotaCooldown.
+func (pool *Pool) MarkExhausted(name string) {
+	now := pool.clock().Now()
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	cooldown := pool.QuotaCooldown
+	if cooldown <= 0 {
+		cooldown = DefaultQuotaCooldown
+	}
+	pool.exhausted[name] = now.Add(cooldown)
+}
+
+// Exhausted returns the keys that hit their quota and when they become usable again, keyed by
+// key name.
+func (pool *Pool) Exhausted() map[string]time.Time {
+	now := pool.clock().Now()
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	exhausted := make(map[string]time.Time)
+	for name, until := range pool.exhausted {
+		if now.Before(until) {
+			exhausted[name] = until
+		}
+	}
+	return exhausted
+}
+
+// clock returns the clock of the pool, defaulting to clock.Real.
+func (pool *Pool) clock() clock.Clock {
+	if pool.Clock == nil {
+		return clock.Real
+	}
+	return pool.Clock
+}
+
+// contains reports whether values contains value.
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
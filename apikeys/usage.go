@@ -0,0 +1,210 @@
+package apikeys
+
+import (
+	"github.com/nexus-telegram/NexusSDK/utils/clock"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultWarnBelow is the fraction of the quota of a key below which a Tracker reports it as
+// running low.
+const DefaultWarnBelow = 0.1
+
+// Usage is the consumption of an API key.
+//
+// # Fields:
+//   - Key: The name of the key.
+//   - Calls: The number of game data refreshes sent with the key since the start.
+//   - QuotaErrors: The number of refreshes refused because the quota was used up.
+//   - Limit: The quota of the key per period, reported by the Nexus API or configured. Zero
+//     when unknown.
+//   - Remaining: The refreshes left in the current period, -1 when unknown.
+//   - Estimated: Whether Remaining is counted locally rather than reported by the Nexus API.
+//   - Reset: When the quota resets, zero when unknown.
+//   - Updated: When the key was last used.
+type Usage struct {
+	Key         string    `json:"key"`
+	Calls       int       `json:"calls"`
+	QuotaErrors int       `json:"quota_errors"`
+	Limit       int       `json:"limit"`
+	Remaining   int       `json:"remaining"`
+	Estimated   bool      `json:"estimated"`
+	Reset       time.Time `json:"reset"`
+	Updated     time.Time `json:"updated"`
+}
+
+// keyUsage is the usage of a key together with the state of its estimate.
+type keyUsage struct {
+	Usage
+	quota  int  // Configured daily quota, used when the Nexus API reports none
+	since  int  // Calls since Remaining was last reported or the period started
+	warned bool // Whether the key was reported as running low in the current period
+}
+
+// Tracker counts the game data refreshes of API keys and estimates their remaining quota.
+//
+// The remaining quota is taken from the rate limit headers of the Nexus API responses
+// (X-RateLimit-* or X-Quota-*) when present, and counted down locally between them. Keys with
+// a configured daily quota and no headers are counted down from it, resetting at midnight UTC.
+//
+// # Fields:
+//   - WarnBelow: The fraction of the quota below which Record reports a key as running low.
+//     Defaults to DefaultWarnBelow.
+//   - Clock: The clock of the counters. Defaults to clock.Real.
+type Tracker struct {
+	WarnBelow float64
+	Clock     clock.Clock
+	mu        sync.Mutex
+	keys      map[string]*keyUsage
+}
+
+// NewTracker returns a tracker without usage.
+func NewTracker() *Tracker {
+	return &Tracker{keys: make(map[string]*keyUsage)}
+}
+
+// SetQuota sets the daily quota of the key named name, used when the Nexus API does not report
+// one.
+func (tracker *Tracker) SetQuota(name string, quota int) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.key(name).quota = quota
+}
+
+// Reset forgets the reported and estimated remaining quota of the key named name, e.g. after
+// its value was rotated. The call counts and the configured quota are kept.
+func (tracker *Tracker) Reset(name string) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	usage, ok := tracker.keys[name]
+	if !ok {
+		return
+	}
+	usage.Limit, usage.Remaining, usage.Estimated, usage.Reset = 0, -1, false, time.Time{}
+	usage.since, usage.warned = 0, false
+}
+
+// key returns the usage of the key named name, creating it if needed. It must be called with
+// mu held.
+func (tracker *Tracker) key(name string) *keyUsage {
+	usage, ok := tracker.keys[name]
+	if !ok {
+		usage = &keyUsage{Usage: Usage{Key: name, Remaining: -1}}
+		tracker.keys[name] = usage
+	}
+	return usage
+}
+
+// Record records a refresh sent with the key named name.
+//
+// # Parameters:
+//   - name: The name of the key.
+//   - header: The headers of the Nexus API response, nil if none was received.
+//   - quotaErr: Whether the refresh was refused because the quota was used up.
+//
+// # Returns:
+//   - Usage: The usage of the key after the refresh.
+//   - bool: Whether the key just started running low, i.e. its remaining quota fell below
+//     WarnBelow of its limit for the first time in the period.
+func (tracker *Tracker) Record(name string, header http.Header, quotaErr bool) (Usage, bool) {
+	now := tracker.clock().Now()
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	usage := tracker.key(name)
+	if !usage.Reset.IsZero() && !now.Before(usage.Reset) {
+		usage.Remaining, usage.Reset, usage.since, usage.warned = -1, time.Time{}, 0, false
+	}
+	usage.Calls++
+	usage.since++
+	usage.Updated = now
+	if quotaErr {
+		usage.QuotaErrors++
+		usage.Remaining, usage.since = 0, 0
+	} else if limit, remaining, reset, ok := parseQuota(header, now); ok {
+		usage.Limit, usage.Remaining, usage.Estimated, usage.since = limit, remaining, false, 0
+		if !reset.IsZero() {
+			usage.Reset = reset
+		}
+	} else if usage.Remaining > 0 {
+		usage.Remaining--
+		usage.Estimated = true
+	} else if usage.Remaining < 0 && usage.quota > 0 {
+		if usage.Reset.IsZero() {
+			usage.Reset = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		}
+		usage.Limit, usage.Remaining, usage.Estimated = usage.quota, max(usage.quota-usage.since, 0), true
+	} else if usage.Remaining == 0 && usage.quota == 0 {
+		usage.Remaining = -1
+	}
+	low := false
+	if usage.Limit > 0 && usage.Remaining >= 0 && !usage.warned && float64(usage.Remaining) < tracker.warnBelow()*float64(usage.Limit) {
+		usage.warned, low = true, true
+	}
+	return usage.Usage, low
+}
+
+// Usage returns the usage of every key, sorted by name.
+func (tracker *Tracker) Usage() []Usage {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	usages := make([]Usage, 0, len(tracker.keys))
+	for _, usage := range tracker.keys {
+		if usage.Calls > 0 {
+			usages = append(usages, usage.Usage)
+		}
+	}
+	sort.Slice(usages, func(i, j int) bool { return usages[i].Key < usages[j].Key })
+	return usages
+}
+
+// warnBelow returns the warning threshold, defaulting to DefaultWarnBelow.
+func (tracker *Tracker) warnBelow() float64 {
+	if tracker.WarnBelow <= 0 {
+		return DefaultWarnBelow
+	}
+	return tracker.WarnBelow
+}
+
+// clock returns the clock of the tracker, defaulting to clock.Real.
+func (tracker *Tracker) clock() clock.Clock {
+	if tracker.Clock == nil {
+		return clock.Real
+	}
+	return tracker.Clock
+}
+
+// parseQuota returns the quota reported by the rate limit headers of a response.
+func parseQuota(header http.Header, now time.Time) (limit, remaining int, reset time.Time, ok bool) {
+	for _, prefix := range []string{"X-RateLimit-", "X-Quota-"} {
+		remaining, err := strconv.Atoi(header.Get(prefix + "Remaining"))
+		if err != nil {
+			continue
+		}
+		limit, _ := strconv.Atoi(header.Get(prefix + "Limit"))
+		return limit, remaining, parseReset(header.Get(prefix+"Reset"), now), true
+	}
+	return 0, 0, time.Time{}, false
+}
+
+// parseReset parses the reset time of a rate limit header, given as a Unix timestamp, a number
+// of seconds from now, or an HTTP or RFC 3339 date.
+func parseReset(value string, now time.Time) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds > 1_000_000_000 {
+			return time.Unix(seconds, 0)
+		}
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+	for _, layout := range []string{http.TimeFormat, time.RFC3339} {
+		if reset, err := time.Parse(layout, value); err == nil {
+			return reset
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is a single record of the audit trail, describing one outgoing request.
+//
+// # Fields:
+//   - Time: The time the request was sent.
+//   - Account: The Telegram ID of the account the request was made for, if known.
+//   - RequestID: The X-Request-Id header of the request.
+//   - Method: The HTTP method of the request.
+//   - URL: The URL of the request.
+//   - PayloadHash: The hex-encoded SHA-256 hash of the request body (empty for no body).
+//   - Status: The HTTP status code of the response, or 0 if no response was received.
+//   - Error: The transport error, if the request failed before a response was received.
+//
+// # Example JSON line:
+//
+//	{"time":"2024-11-20T10:30:00Z","account":"987654321","request_id":"3f2a9c1e-7b4d-4e8a-9f01-2c6d8e5b7a13","method":"POST","url":"https://api.example.com/claim","payload_hash":"9f86d0...","status":200}
+type Entry struct {
+	Time        time.Time `json:"time"`
+	Account     string    `json:"account,omitempty"`
+	RequestID   string    `json:"request_id,omitempty"`
+	Method      string    `json:"method"`
+	URL         string    `json:"url"`
+	PayloadHash string    `json:"payload_hash,omitempty"`
+	Status      int       `json:"status"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Log is an append-only destination for audit entries.
+type Log interface {
+	Append(entry Entry) error
+	Close() error
+}
+
+// HashPayload returns the hex-encoded SHA-256 hash of a request body, or "" for an empty body.
+func HashPayload(payload []byte) string {
+	if len(payload) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// FileLog is a Log writing one JSON object per line to a file opened in append-only mode.
+//
+// # Fields:
+//   - file: The underlying file, opened with O_APPEND.
+//   - encoder: The JSON encoder writing to file.
+//   - mu: A mutex serializing appends.
+type FileLog struct {
+	file    *os.File
+	encoder *json.Encoder
+	mu      sync.Mutex
+}
+
+// OpenFileLog opens (or creates) an append-only audit log at the given path.
+//
+// # Parameters:
+//   - path: The path of the audit log file. Parent directories are created if needed.
+//
+// # Returns:
+//   - *FileLog: The audit log.
+//   - error: An error if the file cannot be opened.
+//
+// # Example:
+//
+//	auditLog, err := audit.OpenFileLog("logs/audit.jsonl")
+//	if err != nil {
+//		log.Fatalf("Failed to open audit log: %v", err)
+//	}
+//	handler.SetAuditLog(auditLog)
+func OpenFileLog(path string) (*FileLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileLog{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// Append writes an entry to the end of the log.
+func (log *FileLog) Append(entry Entry) error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	return log.encoder.Encode(entry)
+}
+
+// Close flushes and closes the log file.
+func (log *FileLog) Close() error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if err := log.file.Sync(); err != nil {
+		_ = log.file.Close()
+		return err
+	}
+	return log.file.Close()
+}
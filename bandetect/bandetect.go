@@ -0,0 +1,181 @@
+// Package bandetect recognizes the responses by which games ban or soft-ban accounts, so the
+// scheduler can stop farming with an account before a soft-ban turns into a permanent one.
+package bandetect
+
+import (
+	"bytes"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"strings"
+	"sync"
+)
+
+// maxInspectedBytes is the length of the response prefix searched for ban patterns.
+const maxInspectedBytes = 64 * 1024
+
+// DefaultZeroRewardStreak is the number of consecutive zero rewards considered a ban signal.
+const DefaultZeroRewardStreak = 3
+
+// ErrZeroReward is returned (or wrapped) by tasks whose action succeeded but earned nothing.
+//
+// Games often keep answering soft-banned accounts normally while silently crediting no reward,
+// so a streak of ErrZeroReward failures of one account is a ban signal (see Detector).
+//
+// # Example:
+//
+//	if claim.Reward == 0 {
+//		return fmt.Errorf("%w: claim returned 0 coins", bandetect.ErrZeroReward)
+//	}
+var ErrZeroReward = errors.New("zero reward")
+
+// Rule recognizes a ban signal in a response.
+//
+// # Fields:
+//   - Name: The name of the signal (e.g., "captcha").
+//   - Status: The status code the response must have. Zero matches any status.
+//   - Patterns: Lowercase substrings of the response body, one of which must be present.
+//     Empty matches any body.
+type Rule struct {
+	Name     string
+	Status   int
+	Patterns []string
+}
+
+// DefaultRules are the ban signals shared by most games.
+var DefaultRules = []Rule{
+	{Name: "forbidden", Status: 403, Patterns: []string{"banned", "blocked", "suspended", "blacklisted"}},
+	{Name: "captcha", Patterns: []string{"captcha", "cf-turnstile", "challenge-platform"}},
+	{Name: "suspicious_activity", Patterns: []string{"suspicious activity", "unusual activity", "bot detected", "cheating"}},
+}
+
+// Signal is a ban signal recognized by a Detector.
+//
+// # Fields:
+//   - Rule: The name of the rule that matched, or "zero_reward".
+//   - Status: The status code of the response, if any.
+type Signal struct {
+	Rule   string `json:"rule"`
+	Status int    `json:"status,omitempty"`
+}
+
+// Detector recognizes ban signals in the responses and task failures of accounts.
+//
+// Game adapters add the signals specific to their game with AddRule.
+//
+// # Fields:
+//   - Rules: The rules responses are matched against, in order.
+//   - ZeroRewardStreak: The number of consecutive ErrZeroReward task failures of an account
+//     considered a ban signal.
+//
+// # Example:
+//
+//	detector := bandetect.New(bandetect.DefaultRules, bandetect.DefaultZeroRewardStreak)
+//	detector.AddRule(bandetect.Rule{Name: "shadow_ban", Status: 200, Patterns: []string{`"restricted":true`}})
+//	handler.SetBanDetector(detector)
+type Detector struct {
+	Rules            []Rule
+	ZeroRewardStreak int
+	mu               sync.Mutex
+	zeroRewards      map[string]int
+}
+
+// New creates a detector matching rules and treating zeroRewardStreak consecutive zero rewards
+// as a ban signal.
+func New(rules []Rule, zeroRewardStreak int) *Detector {
+	if zeroRewardStreak <= 0 {
+		zeroRewardStreak = DefaultZeroRewardStreak
+	}
+	return &Detector{Rules: append([]Rule(nil), rules...), ZeroRewardStreak: zeroRewardStreak}
+}
+
+// AddRule adds a rule to the detector.
+func (detector *Detector) AddRule(rule Rule) {
+	detector.mu.Lock()
+	defer detector.mu.Unlock()
+	detector.Rules = append(detector.Rules, rule)
+}
+
+// Inspect matches a response against the rules of the detector.
+//
+// # Parameters:
+//   - status: The status code of the response.
+//   - body: The response body.
+//
+// # Returns:
+//   - Signal: The signal of the first matching rule.
+//   - bool: Whether a rule matched.
+func (detector *Detector) Inspect(status int, body []byte) (Signal, bool) {
+	if len(body) > maxInspectedBytes {
+		body = body[:maxInspectedBytes]
+	}
+	lower := bytes.ToLower(body)
+	detector.mu.Lock()
+	defer detector.mu.Unlock()
+	for _, rule := range detector.Rules {
+		if rule.Status != 0 && rule.Status != status {
+			continue
+		}
+		if len(rule.Patterns) == 0 {
+			return Signal{Rule: rule.Name, Status: status}, true
+		}
+		for _, pattern := range rule.Patterns {
+			if bytes.Contains(lower, []byte(pattern)) {
+				return Signal{Rule: rule.Name, Status: status}, true
+			}
+		}
+	}
+	return Signal{}, false
+}
+
+// InspectError looks for a ban signal in a failure of an account: a response rejected with an
+// *httpclient.StatusError matching a rule, or a streak of ErrZeroReward. Any other outcome,
+// including a nil error, resets the zero reward streak of the account.
+func (detector *Detector) InspectError(account string, err error) (Signal, bool) {
+	if errors.Is(err, ErrZeroReward) {
+		detector.mu.Lock()
+		defer detector.mu.Unlock()
+		if detector.zeroRewards == nil {
+			detector.zeroRewards = make(map[string]int)
+		}
+		detector.zeroRewards[account]++
+		if detector.zeroRewards[account] < detector.ZeroRewardStreak {
+			return Signal{}, false
+		}
+		delete(detector.zeroRewards, account)
+		return Signal{Rule: "zero_reward"}, true
+	}
+	detector.mu.Lock()
+	delete(detector.zeroRewards, account)
+	detector.mu.Unlock()
+	var statusErr *httpclient.StatusError
+	if errors.As(err, &statusErr) {
+		return detector.Inspect(statusErr.StatusCode, []byte(statusErr.Body))
+	}
+	return Signal{}, false
+}
+
+// FromConfig creates the detector described by a ban detection configuration.
+//
+// # Parameters:
+//   - config: The "ban_detection" section of config.json.
+//
+// # Returns:
+//   - *Detector: The detector, or nil when ban detection is disabled.
+func FromConfig(config types.BanDetectionConfig) *Detector {
+	if !config.Enabled {
+		return nil
+	}
+	var rules []Rule
+	if !config.DisableDefaultRules {
+		rules = append(rules, DefaultRules...)
+	}
+	for _, rule := range config.Rules {
+		patterns := make([]string, len(rule.Patterns))
+		for i, pattern := range rule.Patterns {
+			patterns[i] = strings.ToLower(pattern)
+		}
+		rules = append(rules, Rule{Name: rule.Name, Status: rule.Status, Patterns: patterns})
+	}
+	return New(rules, config.ZeroRewardStreak)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/handler"
+	"github.com/nexus-telegram/NexusSDK/importer"
+	"github.com/nexus-telegram/NexusSDK/proxypool"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	commands["clone-accounts"] = command{
+		summary: "create accounts.json entries from a template account and a list of sessions",
+		run:     runCloneAccounts,
+	}
+}
+
+// runCloneAccounts implements "nexusctl clone-accounts -template account.json -sessions path
+// [-proxies file] [-n count] [-label format] [-o accounts.json]".
+func runCloneAccounts(args []string) error {
+	flags := flag.NewFlagSet("clone-accounts", flag.ContinueOnError)
+	templatePath := flags.String("template", "", "read the template account from `file`, a JSON account object")
+	sessionsPath := flags.String("sessions", "", "read the sessions from `path`: a Telethon session folder, a CSV file, or a file of init data lines")
+	proxiesPath := flags.String("proxies", "", "spread the accounts over the countries of the proxies of `file`")
+	count := flags.Int("n", 0, "create `count` accounts from the first sessions instead of one per session")
+	labelFormat := flags.String("label", "", "label the accounts with the fmt `format` of their index, e.g. farm-%03d")
+	output := flags.String("o", "", "append the accounts to the accounts `file` instead of printing them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *templatePath == "" || *sessionsPath == "" || flags.NArg() != 0 {
+		return fmt.Errorf("usage: nexusctl clone-accounts -template account.json -sessions path [-proxies file] [-n count] [-label format] [-o accounts.json]")
+	}
+	data, err := os.ReadFile(*templatePath)
+	if err != nil {
+		return err
+	}
+	var template types.Account
+	if err := json.Unmarshal(data, &template); err != nil {
+		return fmt.Errorf("template %s: %w", *templatePath, err)
+	}
+	sessions, err := readSessions(*sessionsPath)
+	if err != nil {
+		return err
+	}
+	options := importer.CloneOptions{Count: *count, LabelFormat: *labelFormat}
+	if *proxiesPath != "" {
+		if options.Proxies, err = proxypool.LoadProxies(*proxiesPath); err != nil {
+			return err
+		}
+	}
+	accounts, err := importer.Clone(template, sessions, options)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		encoded, err := json.MarshalIndent(accounts, "", "\t")
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(append(encoded, '\n'))
+		return err
+	}
+	var existing []types.Account
+	if _, err := os.Stat(*output); err == nil {
+		if existing, err = handler.LoadAccounts(*output); err != nil {
+			return err
+		}
+	}
+	known := make(map[string]bool, len(existing))
+	for _, account := range existing {
+		known[account.TelegramId] = true
+	}
+	var duplicates []error
+	for _, account := range accounts {
+		if account.TelegramId != "" && known[account.TelegramId] {
+			duplicates = append(duplicates, fmt.Errorf("%s: Telegram account %s is already in %s", account.Label, account.TelegramId, *output))
+		}
+	}
+	if len(duplicates) > 0 {
+		return errors.Join(duplicates...)
+	}
+	if err := handler.SaveAccounts(*output, append(existing, accounts...)); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "added %d accounts to %s\n", len(accounts), *output)
+	return nil
+}
+
+// readSessions imports the sessions of a Telethon session folder, a CSV export, or a file of
+// init data lines.
+func readSessions(path string) ([]types.Account, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		sessions, err := importer.FromTelethonDir(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "some sessions were skipped:\n%v\n", err)
+		}
+		return sessions, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return importer.FromCSV(file)
+	}
+	return importer.FromLines(file)
+}
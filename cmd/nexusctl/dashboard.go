@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/metrics"
+	"os"
+)
+
+func init() {
+	commands["dashboard"] = command{
+		summary: "print an example Grafana dashboard of the farm metrics",
+		run:     runDashboard,
+	}
+}
+
+// runDashboard implements "nexusctl dashboard [-o file]".
+func runDashboard(args []string) error {
+	flags := flag.NewFlagSet("dashboard", flag.ContinueOnError)
+	output := flags.String("o", "", "write the dashboard to `file` instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("usage: nexusctl dashboard [-o file]")
+	}
+	if *output == "" {
+		_, err := os.Stdout.Write(metrics.GrafanaDashboard())
+		return err
+	}
+	return os.WriteFile(*output, metrics.GrafanaDashboard(), 0o644)
+}
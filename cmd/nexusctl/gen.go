@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/codegen"
+	"os"
+)
+
+func init() {
+	commands["gen"] = command{
+		summary: "generate a typed game API client and tasks from an OpenAPI description",
+		run:     runGen,
+	}
+}
+
+// runGen implements "nexusctl gen -spec api.json -package name [-o file]".
+func runGen(args []string) error {
+	flags := flag.NewFlagSet("gen", flag.ContinueOnError)
+	specPath := flags.String("spec", "", "read the OpenAPI description from `file`")
+	packageName := flags.String("package", os.Getenv("GOPACKAGE"), "the `name` of the generated package")
+	output := flags.String("o", "", "write the client to `file` instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *specPath == "" || *packageName == "" || flags.NArg() != 0 {
+		return fmt.Errorf("usage: nexusctl gen -spec api.json -package name [-o file]")
+	}
+	data, err := os.ReadFile(*specPath)
+	if err != nil {
+		return err
+	}
+	spec, err := codegen.ParseSpec(data)
+	if err != nil {
+		return err
+	}
+	source, err := codegen.Generate(spec, *packageName)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(source)
+		return err
+	}
+	return os.WriteFile(*output, source, 0o644)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/handler"
+	"github.com/nexus-telegram/NexusSDK/history"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func init() {
+	commands["history"] = command{
+		summary: "print the recent executions of a task from the history store of config.json",
+		run:     runHistory,
+	}
+}
+
+// runHistory implements "nexusctl history [-config file] [-account id] [-task name] [-run id]
+// [-n limit] [-json] game".
+func runHistory(args []string) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	configPath := flags.String("config", "config.json", "read the history store settings from `file`")
+	account := flags.String("account", "", "only print the executions of the account with this Telegram `id`")
+	task := flags.String("task", "", "only print the executions of the task with this `name`")
+	run := flags.String("run", "", "only print the executions of the run with this `id`")
+	limit := flags.Int("n", 20, "print at most `limit` executions")
+	asJSON := flags.Bool("json", false, "print the executions as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: nexusctl history [-config file] [-account id] [-task name] [-run id] [-n limit] [-json] game")
+	}
+	config, err := handler.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if config.History.Backend == "" || config.History.Backend == "memory" {
+		return fmt.Errorf("%s has no persistent history store, set history.backend to \"file\" or \"redis\"", *configPath)
+	}
+	store, err := history.FromConfig(config.History)
+	if err != nil {
+		return err
+	}
+	var executions []history.Execution
+	if *run == "" {
+		executions, err = store.List(context.Background(), flags.Arg(0), *account, *task, *limit)
+	} else if executions, err = store.List(context.Background(), flags.Arg(0), *account, *task, 0); err == nil {
+		executions = history.OfRun(executions, *run, *limit)
+	}
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(executions)
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "STARTED\tRUN\tACCOUNT\tTASK\tDURATION\tATTEMPTS\tRESULT")
+	for _, execution := range executions {
+		result := "ok"
+		if !execution.Success {
+			result = execution.Error
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", execution.Started.Format(time.RFC3339), execution.RunID,
+			execution.Account, execution.Task, execution.Duration.Round(time.Millisecond), execution.Attempts, result)
+	}
+	return writer.Flush()
+}
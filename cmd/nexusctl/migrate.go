@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/handler"
+	"os"
+)
+
+func init() {
+	commands["migrate-tasks"] = command{
+		summary: "upgrade a tasks.json file to the current version",
+		run:     runMigrateTasks,
+	}
+}
+
+// runMigrateTasks implements "nexusctl migrate-tasks [-w] tasks.json".
+func runMigrateTasks(args []string) error {
+	flags := flag.NewFlagSet("migrate-tasks", flag.ContinueOnError)
+	write := flags.Bool("w", false, "rewrite the file instead of printing the upgraded tasks")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: nexusctl migrate-tasks [-w] tasks.json")
+	}
+	path := flags.Arg(0)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	migrated, migrations, err := handler.MigrateTasks(data)
+	if err != nil {
+		return err
+	}
+	for _, migration := range migrations {
+		fmt.Fprintf(os.Stderr, "version %d -> %d: %s\n", migration.From, migration.From+1, migration.Description)
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, migrated, "", "\t"); err != nil {
+		return err
+	}
+	indented.WriteByte('\n')
+	if !*write {
+		_, err = os.Stdout.Write(indented.Bytes())
+		return err
+	}
+	if len(migrations) == 0 {
+		fmt.Fprintf(os.Stderr, "%s is up to date\n", path)
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	temporaryPath := path + ".tmp"
+	if err := os.WriteFile(temporaryPath, indented.Bytes(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, path)
+}
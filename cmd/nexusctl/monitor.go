@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nexus-telegram/NexusSDK/control"
+	"github.com/nexus-telegram/NexusSDK/handler"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// monitorChrome is the number of screen lines of the monitor that are not account rows.
+const monitorChrome = 8
+
+func init() {
+	commands["monitor"] = command{
+		summary: "show a live view of the accounts of a running farm through its control server",
+		run:     runMonitor,
+	}
+}
+
+// runMonitor implements "nexusctl monitor [-addr host:port] [-interval duration]".
+func runMonitor(args []string) error {
+	flags := flag.NewFlagSet("monitor", flag.ContinueOnError)
+	addr := flags.String("addr", "127.0.0.1:6060", "connect to the control server of the farm at `address`")
+	interval := flags.Duration("interval", 2*time.Second, "refresh the view every `interval`")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("usage: nexusctl monitor [-addr host:port] [-interval duration]")
+	}
+	model := &monitorModel{
+		client:   &controlClient{baseURL: "http://" + *addr, http: &http.Client{Timeout: 5 * time.Second}},
+		addr:     *addr,
+		interval: *interval,
+	}
+	_, err := tea.NewProgram(model, tea.WithAltScreen()).Run()
+	return err
+}
+
+// controlClient calls the control API of a running farm (see control.Server).
+type controlClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+// get decodes the JSON response of a GET request to path into v.
+func (client *controlClient) get(path string, v interface{}) error {
+	resp, err := client.http.Get(client.baseURL + path)
+	if err != nil {
+		return err
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+		}
+	}(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GET %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// post sends a POST request to path.
+func (client *controlClient) post(path string) error {
+	resp, err := client.http.Post(client.baseURL+path, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+		}
+	}(resp.Body)
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("POST %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// snapshotMsg is the state of the farm fetched from the control server.
+type snapshotMsg struct {
+	diagnostics control.DiagnosticsResponse
+	accounts    map[string][]handler.AccountInfo
+	fetched     time.Time
+	err         error
+}
+
+// tickMsg triggers a periodic refresh.
+type tickMsg time.Time
+
+// actionMsg is the outcome of a keyboard action sent to the control server.
+type actionMsg struct {
+	status string
+	err    error
+}
+
+// monitorModel is the bubbletea model of "nexusctl monitor".
+type monitorModel struct {
+	client   *controlClient
+	addr     string
+	interval time.Duration
+	snapshot snapshotMsg
+	games    []string
+	selected int
+	offset   int
+	width    int
+	height   int
+	status   string
+}
+
+// fetch returns the command fetching a snapshot of the farm.
+func (model *monitorModel) fetch() tea.Cmd {
+	client := model.client
+	return func() tea.Msg {
+		msg := snapshotMsg{fetched: time.Now()}
+		if msg.err = client.get("/diagnostics", &msg.diagnostics); msg.err != nil {
+			return msg
+		}
+		msg.err = client.get("/accounts", &msg.accounts)
+		return msg
+	}
+}
+
+// tick returns the command scheduling the next refresh.
+func (model *monitorModel) tick() tea.Cmd {
+	return tea.Tick(model.interval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// togglePause returns the command pausing the selected game, or resuming it if it is paused.
+func (model *monitorModel) togglePause() tea.Cmd {
+	if len(model.games) == 0 {
+		return nil
+	}
+	game := model.games[model.selected]
+	action := "pause"
+	if model.paused(game) {
+		action = "resume"
+	}
+	client := model.client
+	return func() tea.Msg {
+		if err := client.post("/" + action + "?game=" + url.QueryEscape(game)); err != nil {
+			return actionMsg{err: err}
+		}
+		return actionMsg{status: fmt.Sprintf("%sd %s", action, game)}
+	}
+}
+
+// paused reports whether game is paused in the last snapshot.
+func (model *monitorModel) paused(game string) bool {
+	for _, diagnostics := range model.snapshot.diagnostics.Handlers {
+		if diagnostics.Game == game {
+			return diagnostics.Paused
+		}
+	}
+	return false
+}
+
+func (model *monitorModel) Init() tea.Cmd {
+	return tea.Batch(model.fetch(), model.tick())
+}
+
+func (model *monitorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		model.width, model.height = msg.Width, msg.Height
+	case tickMsg:
+		return model, tea.Batch(model.fetch(), model.tick())
+	case snapshotMsg:
+		if msg.err != nil {
+			model.snapshot.err = msg.err
+			return model, nil
+		}
+		model.snapshot = msg
+		model.games = model.games[:0]
+		for game := range msg.accounts {
+			model.games = append(model.games, game)
+		}
+		sort.Strings(model.games)
+		if model.selected >= len(model.games) {
+			model.selected = max(len(model.games)-1, 0)
+		}
+	case actionMsg:
+		if msg.err != nil {
+			model.status = "error: " + msg.err.Error()
+			return model, nil
+		}
+		model.status = msg.status
+		return model, model.fetch()
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return model, tea.Quit
+		case "tab", "right", "l":
+			if len(model.games) > 0 {
+				model.selected = (model.selected + 1) % len(model.games)
+				model.offset = 0
+			}
+		case "shift+tab", "left", "h":
+			if len(model.games) > 0 {
+				model.selected = (model.selected + len(model.games) - 1) % len(model.games)
+				model.offset = 0
+			}
+		case "down", "j":
+			model.offset++
+		case "up", "k":
+			model.offset = max(model.offset-1, 0)
+		case "p":
+			return model, model.togglePause()
+		case "r":
+			model.status = "refreshing"
+			return model, model.fetch()
+		}
+	}
+	return model, nil
+}
+
+func (model *monitorModel) View() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "nexusctl monitor - %s", model.addr)
+	if !model.snapshot.fetched.IsZero() {
+		fmt.Fprintf(&builder, " - updated %s", model.snapshot.fetched.Format("15:04:05"))
+	}
+	builder.WriteString("\n\n")
+	for i, game := range model.games {
+		label := game
+		if model.paused(game) {
+			label += " (paused)"
+		}
+		if i == model.selected {
+			label = "[" + label + "]"
+		} else {
+			label = " " + label + " "
+		}
+		builder.WriteString(label + "  ")
+	}
+	builder.WriteString("\n\n")
+	if len(model.games) > 0 {
+		model.writeAccounts(&builder, model.snapshot.accounts[model.games[model.selected]])
+	} else if model.snapshot.err == nil {
+		builder.WriteString("Waiting for the control server...\n")
+	}
+	builder.WriteString("\n<-/-> switch game  up/down scroll  p pause/resume  r refresh  q quit\n")
+	if model.snapshot.err != nil {
+		builder.WriteString("error: " + model.snapshot.err.Error() + "\n")
+	} else if model.status != "" {
+		builder.WriteString(model.status + "\n")
+	}
+	return builder.String()
+}
+
+// writeAccounts renders the visible rows of the accounts table.
+func (model *monitorModel) writeAccounts(out io.Writer, accounts []handler.AccountInfo) {
+	rows := len(accounts)
+	if model.height > monitorChrome {
+		rows = model.height - monitorChrome
+	}
+	model.offset = min(model.offset, max(len(accounts)-rows, 0))
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "ACCOUNT\tLABEL\tSTATE\tLAST TASK\tLAST RUN\tRESULT\tSTREAK")
+	for _, account := range accounts[model.offset:min(model.offset+rows, len(accounts))] {
+		state := "active"
+		switch {
+		case !account.Enabled:
+			state = "disabled"
+		case account.CooldownUntil != nil:
+			state = "cooldown until " + account.CooldownUntil.Local().Format("Jan 2 15:04")
+		case account.WarmingUp:
+			state = "warming up"
+		}
+		lastRun, result := "-", "-"
+		if account.LastRun != nil {
+			lastRun = account.LastRun.Local().Format("15:04:05")
+			result = "ok"
+			if account.LastError != "" {
+				result = truncate(account.LastError, max(model.width/3, 20))
+			}
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%d\n", account.TelegramId, account.Label, state,
+			account.LastTask, lastRun, result, account.ErrorStreak)
+	}
+	_ = writer.Flush()
+	if len(accounts) > rows {
+		fmt.Fprintf(out, "(%d-%d of %d accounts)\n", model.offset+1, min(model.offset+rows, len(accounts)), len(accounts))
+	}
+}
+
+// truncate shortens text to at most width characters.
+func truncate(text string, width int) string {
+	text = strings.ReplaceAll(text, "\n", " ")
+	if len(text) <= width {
+		return text
+	}
+	return text[:width-3] + "..."
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/remote"
+	"github.com/nexus-telegram/NexusSDK/taskpack"
+	"os"
+	"text/tabwriter"
+)
+
+func init() {
+	commands["pack"] = command{
+		summary: "install, list, remove, or validate task packs",
+		run:     runPack,
+	}
+}
+
+// packUsage is the usage of the pack command.
+const packUsage = "usage: nexusctl pack install [-dir dir] [-index location] name|path|url\n" +
+	"       nexusctl pack list [-dir dir] [game]\n" +
+	"       nexusctl pack remove [-dir dir] game name\n" +
+	"       nexusctl pack validate path|url"
+
+// runPack implements "nexusctl pack <install|list|remove|validate> ...".
+func runPack(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(packUsage)
+	}
+	flags := flag.NewFlagSet("pack "+args[0], flag.ContinueOnError)
+	dir := flags.String("dir", "tasks.d", "the tasks.d `dir`ectory the packs are installed into")
+	index := flags.String("index", os.Getenv("NEXUS_PACK_INDEX"), "the pack index resolving pack names, defaults to $NEXUS_PACK_INDEX")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	switch {
+	case args[0] == "install" && flags.NArg() == 1:
+		pack, err := taskpack.Fetch(ctx, flags.Arg(0), *index, remote.DefaultOptions())
+		if err != nil {
+			return err
+		}
+		path, err := taskpack.Install(pack, *dir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("installed %s %s into %s\n", pack.Name, pack.Version, path)
+		if pack.Adapter.MinVersion != "" {
+			fmt.Printf("requires the game adapter %s %s or later\n", pack.Adapter.Module, pack.Adapter.MinVersion)
+		} else {
+			fmt.Printf("requires the game adapter %s\n", pack.Adapter.Module)
+		}
+		return nil
+	case args[0] == "list" && flags.NArg() <= 1:
+		packs, err := taskpack.Installed(*dir, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "GAME\tNAME\tVERSION\tADAPTER\tDESCRIPTION")
+		for _, pack := range packs {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", pack.Game, pack.Name, pack.Version, pack.Adapter.Module, pack.Description)
+		}
+		return writer.Flush()
+	case args[0] == "remove" && flags.NArg() == 2:
+		return taskpack.Uninstall(*dir, flags.Arg(0), flags.Arg(1))
+	case args[0] == "validate" && flags.NArg() == 1:
+		pack, err := taskpack.Fetch(ctx, flags.Arg(0), "", remote.DefaultOptions())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s %s is valid: %d one-time and %d recurrent tasks for %s\n", pack.Name, pack.Version,
+			len(pack.Tasks.OneTimeTasks), len(pack.Tasks.RecurrentTasks), pack.Game)
+		return nil
+	}
+	return fmt.Errorf(packUsage)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/handler"
+	"os"
+)
+
+func init() {
+	commands["revoke-sessions"] = command{
+		summary: "log out the Telegram sessions of accounts and purge them from the farm",
+		run:     runRevokeSessions,
+	}
+}
+
+// runRevokeSessions implements "nexusctl revoke-sessions [-config file] [-accounts file]
+// [-purge-only] game id...".
+func runRevokeSessions(args []string) error {
+	flags := flag.NewFlagSet("revoke-sessions", flag.ContinueOnError)
+	configPath := flags.String("config", "config.json", "read the settings of the game from `file`")
+	accountsPath := flags.String("accounts", "accounts.json", "remove the accounts from the accounts `file`")
+	purgeOnly := flags.Bool("purge-only", false, "purge the accounts without revoking their sessions, e.g. when they are already logged out")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		return fmt.Errorf("usage: nexusctl revoke-sessions [-config file] [-accounts file] [-purge-only] game id...")
+	}
+	gameHandler, err := handler.NewGameHandler(*configPath, *accountsPath)
+	if err != nil {
+		return err
+	}
+	gameHandler.GameName = flags.Arg(0)
+	removed, err := gameHandler.DecommissionAccounts(context.Background(), flags.Args()[1:], !*purgeOnly)
+	errs := []error{err}
+	if len(removed) > 0 {
+		ids := make(map[string]bool, len(removed))
+		for _, account := range removed {
+			ids[account.TelegramData.TelegramId] = true
+			fmt.Fprintf(os.Stderr, "decommissioned %s %s\n", account.TelegramData.TelegramId, account.Label)
+		}
+		errs = append(errs, removeAccounts(*accountsPath, ids))
+	}
+	return errors.Join(append(errs, gameHandler.Close())...)
+}
+
+// removeAccounts removes the accounts with the given Telegram IDs from an accounts file,
+// keeping the other entries as they are written, secret references included.
+func removeAccounts(path string, telegramIds map[string]bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	kept := make([]json.RawMessage, 0, len(entries))
+	for _, entry := range entries {
+		var account struct {
+			Telegram struct {
+				TelegramId string `json:"telegramId"`
+			} `json:"telegram"`
+		}
+		if err := json.Unmarshal(entry, &account); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if !telegramIds[account.Telegram.TelegramId] {
+			kept = append(kept, entry)
+		}
+	}
+	encoded, err := json.MarshalIndent(kept, "", "\t")
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	temporaryPath := path + ".tmp"
+	if err := os.WriteFile(temporaryPath, append(encoded, '\n'), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, path)
+}
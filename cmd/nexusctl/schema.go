@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/schema"
+	"os"
+)
+
+func init() {
+	commands["schema"] = command{
+		summary: "print the JSON Schema of config.json, accounts.json, or tasks.json",
+		run:     runSchema,
+	}
+}
+
+// runSchema implements "nexusctl schema [-o file] config|accounts|tasks".
+func runSchema(args []string) error {
+	flags := flag.NewFlagSet("schema", flag.ContinueOnError)
+	output := flags.String("o", "", "write the schema to `file` instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: nexusctl schema [-o file] config|accounts|tasks")
+	}
+	var document map[string]interface{}
+	switch flags.Arg(0) {
+	case "config":
+		document = schema.Config()
+	case "accounts":
+		document = schema.Accounts()
+	case "tasks":
+		document = schema.Tasks()
+	default:
+		return fmt.Errorf("unknown schema %q", flags.Arg(0))
+	}
+	data, err := schema.Marshal(document)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0o644)
+}
@@ -0,0 +1,194 @@
+// Package codec decodes game API responses.
+//
+// Most games answer in plain JSON, but some obfuscate their payloads (base64, XOR with a
+// static key) or use binary formats such as MessagePack or Protocol Buffers. A Decoder turns
+// a response body into a Go value whatever the wire format, and decoders can be chained to
+// undo several layers, e.g. Chain(Base64{}, XOR{Key: key}, JSON{}).
+package codec
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Decoder decodes a response body into v.
+//
+// # Methods:
+//   - Decode(data []byte, v interface{}) error: Decodes data into the value pointed to by v.
+type Decoder interface {
+	Decode(data []byte, v interface{}) error
+}
+
+// Transform is a decoding step that turns bytes into other bytes, such as Base64 or XOR.
+// Transforms are combined with a final Decoder using Chain.
+//
+// # Methods:
+//   - Transform(data []byte) ([]byte, error): Returns the decoded bytes.
+type Transform interface {
+	Transform(data []byte) ([]byte, error)
+}
+
+// JSON decodes JSON bodies. It is the default decoder.
+type JSON struct{}
+
+// Decode implements Decoder.
+func (JSON) Decode(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// Base64 decodes base64 bodies, in the standard encoding unless URL is set. Surrounding
+// whitespace and quotes are ignored, and padding is optional.
+type Base64 struct {
+	URL bool
+}
+
+// Transform implements Transform.
+func (codec Base64) Transform(data []byte) ([]byte, error) {
+	text := strings.Trim(strings.TrimSpace(string(data)), `"`)
+	encoding := base64.StdEncoding
+	if codec.URL {
+		encoding = base64.URLEncoding
+	}
+	return encoding.WithPadding(base64.NoPadding).DecodeString(strings.TrimRight(text, "="))
+}
+
+// Decode implements Decoder by decoding the base64 body as JSON.
+func (codec Base64) Decode(data []byte, v interface{}) error {
+	return Chain(codec, JSON{}).Decode(data, v)
+}
+
+// XOR decodes bodies XORed with a repeating key.
+type XOR struct {
+	Key []byte
+}
+
+// Transform implements Transform.
+func (codec XOR) Transform(data []byte) ([]byte, error) {
+	if len(codec.Key) == 0 {
+		return nil, errors.New("xor codec: empty key")
+	}
+	decoded := make([]byte, len(data))
+	for i, b := range data {
+		decoded[i] = b ^ codec.Key[i%len(codec.Key)]
+	}
+	return decoded, nil
+}
+
+// Decode implements Decoder by decoding the XORed body as JSON.
+func (codec XOR) Decode(data []byte, v interface{}) error {
+	return Chain(codec, JSON{}).Decode(data, v)
+}
+
+// chain applies transforms in order, then a final decoder.
+type chain struct {
+	transforms []Transform
+	decoder    Decoder
+}
+
+// Chain returns a decoder applying the given transforms in order and decoding the result
+// with the final decoder.
+//
+// # Example:
+//
+//	decoder := codec.Chain(codec.Base64{}, codec.XOR{Key: []byte("s3cr3t")}, codec.JSON{})
+func Chain(transforms ...interface{}) Decoder {
+	result := chain{}
+	for i, step := range transforms {
+		if i == len(transforms)-1 {
+			if decoder, ok := step.(Decoder); ok {
+				result.decoder = decoder
+				continue
+			}
+		}
+		transform, ok := step.(Transform)
+		if !ok {
+			panic(fmt.Sprintf("codec.Chain: step %d (%T) is not a Transform", i, step))
+		}
+		result.transforms = append(result.transforms, transform)
+	}
+	if result.decoder == nil {
+		result.decoder = JSON{}
+	}
+	return result
+}
+
+// Decode implements Decoder.
+func (codec chain) Decode(data []byte, v interface{}) error {
+	for _, transform := range codec.transforms {
+		var err error
+		data, err = transform.Transform(data)
+		if err != nil {
+			return err
+		}
+	}
+	return codec.decoder.Decode(data, v)
+}
+
+// Protobuf decodes Protocol Buffers bodies into generated messages implementing
+// Unmarshal([]byte) error, as produced by gogo/protobuf or vtprotobuf. Messages of other
+// generators can be decoded with Func and proto.Unmarshal.
+type Protobuf struct{}
+
+// Decode implements Decoder.
+func (Protobuf) Decode(data []byte, v interface{}) error {
+	message, ok := v.(interface{ Unmarshal([]byte) error })
+	if !ok {
+		return fmt.Errorf("protobuf codec: %T has no Unmarshal method", v)
+	}
+	return message.Unmarshal(data)
+}
+
+// Func adapts a function to a Decoder.
+//
+// # Example:
+//
+//	decoder := codec.Func(func(data []byte, v interface{}) error {
+//		return proto.Unmarshal(data, v.(proto.Message))
+//	})
+type Func func(data []byte, v interface{}) error
+
+// Decode implements Decoder.
+func (decode Func) Decode(data []byte, v interface{}) error {
+	return decode(data, v)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Decoder{
+		"json":     JSON{},
+		"base64":   Base64{},
+		"msgpack":  MsgPack{},
+		"protobuf": Protobuf{},
+	}
+)
+
+// Register makes a decoder available under a name, e.g. for selecting it from configuration.
+func Register(name string, decoder Decoder) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(name)] = decoder
+}
+
+// Lookup returns the decoder registered under a name.
+//
+// # Returns:
+//   - Decoder: The decoder.
+//   - error: An error listing the registered names if none is registered under name.
+func Lookup(name string) (Decoder, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	if decoder, ok := registry[strings.ToLower(name)]; ok {
+		return decoder, nil
+	}
+	names := make([]string, 0, len(registry))
+	for registered := range registry {
+		names = append(names, registered)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown codec %q (registered: %s)", name, strings.Join(names, ", "))
+}
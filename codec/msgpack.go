@@ -0,0 +1,206 @@
+package codec
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// errShortMsgPack is returned when a MessagePack body ends in the middle of a value.
+var errShortMsgPack = errors.New("msgpack codec: unexpected end of data")
+
+// MsgPack decodes MessagePack bodies.
+//
+// The body is decoded into generic values and converted through JSON, so v can be any type
+// accepted by encoding/json, with the same struct tags. Binary values become []byte and
+// extension values are decoded as their raw data.
+type MsgPack struct{}
+
+// Decode implements Decoder.
+func (MsgPack) Decode(data []byte, v interface{}) error {
+	reader := &msgpackReader{data: data}
+	value, err := reader.value()
+	if err != nil {
+		return err
+	}
+	intermediate, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("msgpack codec: %w", err)
+	}
+	return json.Unmarshal(intermediate, v)
+}
+
+// msgpackReader decodes MessagePack values from a byte slice.
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+func (reader *msgpackReader) next(n int) ([]byte, error) {
+	if n < 0 || reader.pos+n > len(reader.data) {
+		return nil, errShortMsgPack
+	}
+	bytes := reader.data[reader.pos : reader.pos+n]
+	reader.pos += n
+	return bytes, nil
+}
+
+func (reader *msgpackReader) uint(size int) (uint64, error) {
+	bytes, err := reader.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(bytes[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(bytes)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(bytes)), nil
+	default:
+		return binary.BigEndian.Uint64(bytes), nil
+	}
+}
+
+func (reader *msgpackReader) value() (interface{}, error) {
+	header, err := reader.next(1)
+	if err != nil {
+		return nil, err
+	}
+	b := header[0]
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b >= 0x80 && b <= 0x8f:
+		return reader.mapValue(int(b & 0x0f))
+	case b >= 0x90 && b <= 0x9f:
+		return reader.arrayValue(int(b & 0x0f))
+	case b >= 0xa0 && b <= 0xbf:
+		return reader.stringValue(int(b & 0x1f))
+	}
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		size, err := reader.uint(1 << (b - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return reader.bytesValue(int(size))
+	case 0xc7, 0xc8, 0xc9:
+		size, err := reader.uint(1 << (b - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := reader.next(1); err != nil {
+			return nil, err
+		}
+		return reader.bytesValue(int(size))
+	case 0xca:
+		bits, err := reader.uint(4)
+		return float64(math.Float32frombits(uint32(bits))), err
+	case 0xcb:
+		bits, err := reader.uint(8)
+		return math.Float64frombits(bits), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return reader.uint(1 << (b - 0xcc))
+	case 0xd0:
+		value, err := reader.uint(1)
+		return int64(int8(value)), err
+	case 0xd1:
+		value, err := reader.uint(2)
+		return int64(int16(value)), err
+	case 0xd2:
+		value, err := reader.uint(4)
+		return int64(int32(value)), err
+	case 0xd3:
+		value, err := reader.uint(8)
+		return int64(value), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		if _, err := reader.next(1); err != nil {
+			return nil, err
+		}
+		return reader.bytesValue(1 << (b - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		size, err := reader.uint(1 << (b - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return reader.stringValue(int(size))
+	case 0xdc, 0xdd:
+		size, err := reader.uint(2 << (b - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return reader.arrayValue(int(size))
+	case 0xde, 0xdf:
+		size, err := reader.uint(2 << (b - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return reader.mapValue(int(size))
+	}
+	return nil, fmt.Errorf("msgpack codec: invalid type byte 0x%02x", b)
+}
+
+func (reader *msgpackReader) bytesValue(size int) ([]byte, error) {
+	bytes, err := reader.next(size)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), bytes...), nil
+}
+
+func (reader *msgpackReader) stringValue(size int) (string, error) {
+	bytes, err := reader.next(size)
+	return string(bytes), err
+}
+
+func (reader *msgpackReader) arrayValue(size int) ([]interface{}, error) {
+	if size > len(reader.data)-reader.pos {
+		return nil, errShortMsgPack
+	}
+	values := make([]interface{}, 0, size)
+	for i := 0; i < size; i++ {
+		value, err := reader.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func (reader *msgpackReader) mapValue(size int) (map[string]interface{}, error) {
+	if size > len(reader.data)-reader.pos {
+		return nil, errShortMsgPack
+	}
+	values := make(map[string]interface{}, size)
+	for i := 0; i < size; i++ {
+		key, err := reader.value()
+		if err != nil {
+			return nil, err
+		}
+		value, err := reader.value()
+		if err != nil {
+			return nil, err
+		}
+		switch typed := key.(type) {
+		case string:
+			values[typed] = value
+		case []byte:
+			values[string(typed)] = value
+		default:
+			values[fmt.Sprint(typed)] = value
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,333 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Generate renders the Go source of a typed client for spec.
+//
+// The generated file contains:
+//   - A struct for every component schema and for every inline request and response body.
+//   - A function per POST operation sending the typed request through tasks.Handler.Post to
+//     the handler's base URL and decoding the typed response.
+//   - A task per POST operation whose Run decodes the task payload into the request and calls
+//     the function, ready to be registered with GameHandler.AddTask.
+//
+// Operations with other methods are listed in a comment and skipped, since tasks.Handler
+// only sends POST requests.
+//
+// # Parameters:
+//   - spec: The parsed OpenAPI document.
+//   - packageName: The package of the generated file.
+//
+// # Returns:
+//   - []byte: The formatted Go source.
+//   - error: An error if an operation has no usable name or the source cannot be formatted.
+func Generate(spec *Spec, packageName string) ([]byte, error) {
+	generator := &generator{spec: spec, declared: make(map[string]bool)}
+	names := make([]string, 0, len(spec.Components.Schemas))
+	for name := range spec.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		generator.declare(goName(name), spec.Components.Schemas[name])
+	}
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		methods := make([]string, 0, len(spec.Paths[path]))
+		for method := range spec.Paths[path] {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			if err := generator.operation(path, method, spec.Paths[path][method]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var source bytes.Buffer
+	source.WriteString("// Code generated by nexusctl gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&source, "package %s\n\n", packageName)
+	var imports []string
+	if generator.usesJSON {
+		imports = append(imports, "encoding/json")
+	}
+	if generator.usesTasks {
+		imports = append(imports, "github.com/nexus-telegram/NexusSDK/tasks", "github.com/nexus-telegram/NexusSDK/types")
+	}
+	if generator.usesPathParams {
+		imports = append(imports, "net/url")
+	}
+	if len(imports) > 0 {
+		source.WriteString("import (\n")
+		for _, path := range imports {
+			fmt.Fprintf(&source, "\t%q\n", path)
+		}
+		source.WriteString(")\n\n")
+	}
+	if len(generator.skipped) > 0 {
+		source.WriteString("// Skipped operations (tasks.Handler only sends POST requests):\n")
+		for _, skipped := range generator.skipped {
+			fmt.Fprintf(&source, "//   - %s\n", skipped)
+		}
+		source.WriteString("\n")
+	}
+	source.Write(generator.types.Bytes())
+	source.Write(generator.functions.Bytes())
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated source: %w", err)
+	}
+	return formatted, nil
+}
+
+// generator accumulates the declarations of a generated file.
+type generator struct {
+	spec           *Spec
+	types          bytes.Buffer
+	functions      bytes.Buffer
+	declared       map[string]bool
+	skipped        []string
+	usesJSON       bool
+	usesTasks      bool
+	usesPathParams bool
+}
+
+// declare writes a named type for a schema, a struct for objects with properties.
+func (generator *generator) declare(name string, schema *Schema) {
+	if generator.declared[name] || schema == nil {
+		return
+	}
+	generator.declared[name] = true
+	if len(schema.Properties) == 0 {
+		writeComment(&generator.types, name, schema.Description)
+		fmt.Fprintf(&generator.types, "type %s %s\n\n", name, generator.goType(name, schema))
+		return
+	}
+	required := make(map[string]bool, len(schema.Required))
+	for _, property := range schema.Required {
+		required[property] = true
+	}
+	properties := make([]string, 0, len(schema.Properties))
+	for property := range schema.Properties {
+		properties = append(properties, property)
+	}
+	sort.Strings(properties)
+	var fields bytes.Buffer
+	for _, property := range properties {
+		propertySchema := schema.Properties[property]
+		tag := property
+		if !required[property] {
+			tag += ",omitempty"
+		}
+		fieldType := generator.goType(name+goName(property), propertySchema)
+		comment := ""
+		if propertySchema.Description != "" {
+			comment = " // " + oneLine(propertySchema.Description)
+		}
+		fmt.Fprintf(&fields, "\t%s %s `json:%q`%s\n", goName(property), fieldType, tag, comment)
+	}
+	writeComment(&generator.types, name, schema.Description)
+	fmt.Fprintf(&generator.types, "type %s struct {\n%s}\n\n", name, fields.String())
+}
+
+// goType returns the Go type of a schema, declaring inline objects under hint.
+func (generator *generator) goType(hint string, schema *Schema) string {
+	if schema == nil {
+		return generator.rawMessage()
+	}
+	if schema.Ref != "" {
+		return goName(schema.Ref[strings.LastIndex(schema.Ref, "/")+1:])
+	}
+	switch schema.Type {
+	case "string":
+		return "string"
+	case "integer":
+		if schema.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if schema.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + generator.goType(hint+"Item", schema.Items)
+	case "object", "":
+		if len(schema.Properties) == 0 {
+			if schema.Type == "" {
+				return generator.rawMessage()
+			}
+			return "map[string]interface{}"
+		}
+		generator.declare(hint, schema)
+		return hint
+	}
+	return generator.rawMessage()
+}
+
+// rawMessage returns the Go type of a schema left untyped.
+func (generator *generator) rawMessage() string {
+	generator.usesJSON = true
+	return "json.RawMessage"
+}
+
+// operation writes the function and the task of an operation.
+func (generator *generator) operation(path, method string, operation *Operation) error {
+	if operation == nil {
+		return nil
+	}
+	name := goName(operation.OperationID)
+	if name == "" {
+		name = goName(method + " " + path)
+	}
+	if name == "" {
+		return fmt.Errorf("cannot name operation %s %s", strings.ToUpper(method), path)
+	}
+	if method != "post" {
+		generator.skipped = append(generator.skipped, fmt.Sprintf("%s %s (%s)", strings.ToUpper(method), path, name))
+		return nil
+	}
+	generator.usesJSON, generator.usesTasks = true, true
+	requestType := generator.bodyType(name+"Request", operation.requestSchema())
+	responseType := generator.bodyType(name+"Response", operation.successSchema())
+
+	var pathParams []string
+	for _, parameter := range operation.Parameters {
+		if parameter != nil && parameter.In == "path" {
+			pathParams = append(pathParams, parameter.Name)
+		}
+	}
+	urlExpression := fmt.Sprintf("handler.GetBaseURL() + %q", path)
+	var signature, arguments, payloadFields strings.Builder
+	if len(pathParams) > 0 {
+		generator.usesPathParams = true
+		segments := splitPath(path)
+		parts := make([]string, 0, len(segments))
+		for _, segment := range segments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				parts = append(parts, "url.PathEscape("+paramName(segment[1:len(segment)-1])+")")
+			} else {
+				parts = append(parts, fmt.Sprintf("%q", segment))
+			}
+		}
+		urlExpression = "handler.GetBaseURL() + " + strings.Join(parts, " + ")
+		for _, parameter := range pathParams {
+			fmt.Fprintf(&signature, "%s string, ", paramName(parameter))
+			fmt.Fprintf(&arguments, "task.%s, ", goName(parameter))
+			fmt.Fprintf(&payloadFields, "\t%s string // Value of the {%s} path parameter\n", goName(parameter), parameter)
+		}
+	}
+
+	summary := oneLine(operation.Summary)
+	if summary == "" {
+		summary = fmt.Sprintf("sends POST %s", path)
+	} else {
+		summary = "sends POST " + path + ": " + summary
+	}
+	fmt.Fprintf(&generator.functions, "// %s %s\n", name, summary)
+	fmt.Fprintf(&generator.functions, "func %s(handler tasks.Handler, %srequest %s) (*%s, error) {\n", name, signature.String(), requestType, responseType)
+	fmt.Fprintf(&generator.functions, "\tpayload, err := json.Marshal(request)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(&generator.functions, "\tbody, err := handler.Post(%s, payload)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n", urlExpression)
+	fmt.Fprintf(&generator.functions, "\tvar response %s\n\tif len(body) > 0 {\n\t\tif err := json.Unmarshal(body, &response); err != nil {\n\t\t\treturn nil, err\n\t\t}\n\t}\n\treturn &response, nil\n}\n\n", responseType)
+
+	fmt.Fprintf(&generator.functions, "// %sTask runs %s for every account. The task payload is decoded into the request.\n", name, name)
+	fmt.Fprintf(&generator.functions, "type %sTask struct {\n\ttasks.BaseTask\n%s}\n\n", name, payloadFields.String())
+	fmt.Fprintf(&generator.functions, "// Run calls %s with the request decoded from the task payload.\n", name)
+	fmt.Fprintf(&generator.functions, "func (task *%sTask) Run(account types.Account, handler tasks.Handler) error {\n", name)
+	fmt.Fprintf(&generator.functions, "\tvar request %s\n\tif task.Payload != nil {\n\t\tpayload, err := json.Marshal(task.Payload)\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n\t\tif err := json.Unmarshal(payload, &request); err != nil {\n\t\t\treturn err\n\t\t}\n\t}\n", requestType)
+	fmt.Fprintf(&generator.functions, "\t_, err := %s(handler, %srequest)\n\treturn err\n}\n\n", name, arguments.String())
+	return nil
+}
+
+// bodyType returns the Go type of a request or response body, declaring it under name if inline.
+func (generator *generator) bodyType(name string, schema *Schema) string {
+	if schema == nil {
+		return generator.rawMessage()
+	}
+	if schema.Ref != "" || len(schema.Properties) > 0 {
+		return generator.goType(name, schema)
+	}
+	generator.declare(name, schema)
+	return name
+}
+
+// goName converts an identifier such as "user_balance" or "claim-daily" to an exported Go name.
+func goName(identifier string) string {
+	var name strings.Builder
+	upper := true
+	for _, r := range identifier {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if name.Len() == 0 && unicode.IsDigit(r) {
+			name.WriteByte('N')
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		name.WriteRune(r)
+	}
+	return name.String()
+}
+
+// paramName converts a path parameter name to an unexported Go parameter name.
+func paramName(parameter string) string {
+	name := []rune(goName(parameter))
+	if len(name) == 0 {
+		return "param"
+	}
+	name[0] = unicode.ToLower(name[0])
+	return string(name) + "Param"
+}
+
+// splitPath splits a path into literal and {parameter} segments.
+func splitPath(path string) []string {
+	var segments []string
+	for len(path) > 0 {
+		start := strings.Index(path, "{")
+		if start < 0 {
+			segments = append(segments, path)
+			break
+		}
+		end := strings.Index(path[start:], "}")
+		if end < 0 {
+			segments = append(segments, path)
+			break
+		}
+		if start > 0 {
+			segments = append(segments, path[:start])
+		}
+		segments = append(segments, path[start:start+end+1])
+		path = path[start+end+1:]
+	}
+	return segments
+}
+
+// writeComment writes the doc comment of a generated type.
+func writeComment(buffer *bytes.Buffer, name, description string) {
+	fmt.Fprintf(buffer, "// %s is generated from the OpenAPI description.\n", name)
+	if description != "" {
+		fmt.Fprintf(buffer, "//\n// %s\n", oneLine(description))
+	}
+}
+
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
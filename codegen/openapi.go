@@ -0,0 +1,130 @@
+// Package codegen generates typed game API clients from OpenAPI descriptions.
+//
+// It backs the "nexusctl gen" command, meant to be run through go:generate in a game adapter:
+//
+//	//go:generate go run github.com/nexus-telegram/NexusSDK/cmd/nexusctl gen -spec api.json -package hamster -o api_gen.go
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Spec is the subset of an OpenAPI 3 document used by the generator.
+//
+// # Fields:
+//   - Paths: The operations of the API, keyed by path and then by lowercase HTTP method.
+//   - Components: The named schemas referenced by the operations.
+type Spec struct {
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+// Operation is an OpenAPI operation.
+//
+// # Fields:
+//   - OperationID: The name of the operation, used to name the generated function and task.
+//   - Summary: A one-line description copied into the generated doc comments.
+//   - Parameters: The path and query parameters of the operation.
+//   - RequestBody: The JSON request body of the operation, if any.
+//   - Responses: The responses of the operation, keyed by status code.
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Summary     string       `json:"summary"`
+	Parameters  []*Parameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema *Schema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]*struct {
+		Content map[string]struct {
+			Schema *Schema `json:"schema"`
+		} `json:"content"`
+	} `json:"responses"`
+}
+
+// Parameter is an OpenAPI operation parameter.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// Schema is the subset of a JSON Schema used by the generator.
+type Schema struct {
+	Ref         string             `json:"$ref"`
+	Type        string             `json:"type"`
+	Format      string             `json:"format"`
+	Description string             `json:"description"`
+	Properties  map[string]*Schema `json:"properties"`
+	Required    []string           `json:"required"`
+	Items       *Schema            `json:"items"`
+}
+
+// ParseSpec parses an OpenAPI 3 document in JSON.
+//
+// # Parameters:
+//   - data: The JSON document.
+//
+// # Returns:
+//   - *Spec: The parsed document.
+//   - error: An error if the document is not valid JSON or declares no paths.
+func ParseSpec(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("parse OpenAPI document: %w", err)
+	}
+	if len(spec.Paths) == 0 {
+		return nil, fmt.Errorf("OpenAPI document declares no paths")
+	}
+	return &spec, nil
+}
+
+// jsonSchema returns the application/json schema of a request body or response content.
+func jsonSchema(content map[string]struct {
+	Schema *Schema `json:"schema"`
+}) *Schema {
+	if media, ok := content["application/json"]; ok {
+		return media.Schema
+	}
+	keys := make([]string, 0, len(content))
+	for key := range content {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if strings.HasSuffix(key, "+json") {
+			return content[key].Schema
+		}
+	}
+	return nil
+}
+
+// successSchema returns the schema of the first 2xx JSON response of an operation.
+func (operation *Operation) successSchema() *Schema {
+	codes := make([]string, 0, len(operation.Responses))
+	for code := range operation.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") && operation.Responses[code] != nil {
+			return jsonSchema(operation.Responses[code].Content)
+		}
+	}
+	return nil
+}
+
+// requestSchema returns the JSON request body schema of an operation.
+func (operation *Operation) requestSchema() *Schema {
+	if operation.RequestBody == nil {
+		return nil
+	}
+	return jsonSchema(operation.RequestBody.Content)
+}
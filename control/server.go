@@ -0,0 +1,408 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/handler"
+	"github.com/nexus-telegram/NexusSDK/history"
+	"github.com/nexus-telegram/NexusSDK/jobqueue"
+	"github.com/nexus-telegram/NexusSDK/metrics"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Server exposes an optional HTTP control API for a running farm.
+//
+// The server serves a JSON diagnostics snapshot for every registered GameHandler and,
+// when enabled, the standard pprof handlers under /debug/pprof/.
+//
+// # Endpoints:
+//   - GET /diagnostics: Runtime and per-handler scheduling diagnostics.
+//   - GET /accounts: The accounts of every handler, keyed by game, including disabled ones.
+//   - GET /schedule?hours=24: The upcoming task executions of every handler, keyed by game.
+//   - GET /errors: The task failures of every handler grouped by category, keyed by game.
+//   - GET /latency: The p50/p95/p99 request latency of every (game, endpoint) pair.
+//   - GET /metrics: The metrics of metrics.DefaultRegistry in the Prometheus text format,
+//     labelled by game, task, account_cohort, and proxy_pool (see "nexusctl dashboard").
+//   - GET /jobs: The scheduled jobs of every handler, keyed by game.
+//   - POST /jobs/cancel?id=<job>: Cancels a scheduled job.
+//   - POST /jobs/resume?id=<job>: Resumes a cancelled job.
+//   - GET /history?account=<id>&task=<name>&run=<id>&limit=20: The recent task executions of
+//     every handler, keyed by game. The filters are optional; limit defaults to 20.
+//   - POST /pause?game=<name>: Pauses the task executions of a handler, or of every handler
+//     when game is empty.
+//   - POST /resume?game=<name>: Resumes the task executions paused with /pause.
+//   - POST /apikey?game=<name>: Rotates the API key of a handler, or of every handler when
+//     game is empty, e.g. {"key": "new-api-key"}. The key is validated before any handler is
+//     rotated; the handlers that fail to rotate are reported with their errors, keyed by game.
+//   - GET /log/level: The current log level of every module.
+//   - PUT /log/level: Changes a module's log level, e.g. {"module": "httpclient", "level": "debug"}.
+//
+// # Fields:
+//   - Addr: The address the server listens on (e.g., "127.0.0.1:6060").
+//   - EnablePprof: Whether the pprof handlers are registered.
+//   - handlers: The game handlers reported by the server.
+//   - mu: A mutex for thread-safe access to handlers.
+//
+// # Example:
+//
+//	server := control.NewServer("127.0.0.1:6060", true, gameHandler)
+//	go func() {
+//		if err := server.ListenAndServe(); err != nil {
+//			log.Printf("Control server stopped: %v", err)
+//		}
+//	}()
+//
+// # Notes:
+//   - The control API is unauthenticated; bind it to a loopback or private address only.
+type Server struct {
+	Addr        string
+	EnablePprof bool
+	handlers    []handler.Interface
+	mu          sync.Mutex
+}
+
+// RuntimeDiagnostics is the process-wide part of the diagnostics snapshot.
+type RuntimeDiagnostics struct {
+	Goroutines int    `json:"goroutines"`
+	HeapAlloc  uint64 `json:"heap_alloc"`
+	HeapInuse  uint64 `json:"heap_inuse"`
+	NumGC      uint32 `json:"num_gc"`
+}
+
+// DiagnosticsResponse is the payload served at /diagnostics.
+type DiagnosticsResponse struct {
+	Runtime  RuntimeDiagnostics    `json:"runtime"`
+	Handlers []handler.Diagnostics `json:"handlers"`
+}
+
+// NewServer creates a new control server for the given game handlers.
+//
+// # Parameters:
+//   - addr: The address the server listens on.
+//   - enablePprof: Whether the pprof handlers are registered.
+//   - handlers: The game handlers reported by the server.
+//
+// # Returns:
+//   - *Server: A pointer to the initialized Server instance.
+func NewServer(addr string, enablePprof bool, handlers ...handler.Interface) *Server {
+	return &Server{
+		Addr:        addr,
+		EnablePprof: enablePprof,
+		handlers:    handlers,
+	}
+}
+
+// AddHandler registers an additional game handler with the server.
+func (server *Server) AddHandler(gameHandler handler.Interface) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.handlers = append(server.handlers, gameHandler)
+}
+
+// Handler returns the http.Handler serving the control API.
+//
+// This allows the control API to be mounted into an existing HTTP server instead of
+// calling ListenAndServe.
+func (server *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/diagnostics", server.handleDiagnostics)
+	mux.HandleFunc("/log/level", server.handleLogLevel)
+	mux.HandleFunc("/accounts", server.handleAccounts)
+	mux.HandleFunc("/schedule", server.handleSchedule)
+	mux.HandleFunc("/errors", server.handleErrors)
+	mux.HandleFunc("/latency", server.handleLatency)
+	mux.Handle("/metrics", metrics.DefaultRegistry)
+	mux.HandleFunc("/jobs", server.handleJobs)
+	mux.HandleFunc("/history", server.handleHistory)
+	mux.HandleFunc("/jobs/cancel", server.handleJobAction)
+	mux.HandleFunc("/jobs/resume", server.handleJobAction)
+	mux.HandleFunc("/pause", server.handlePause)
+	mux.HandleFunc("/resume", server.handlePause)
+	mux.HandleFunc("/apikey", server.handleAPIKey)
+	if server.EnablePprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	return mux
+}
+
+// ListenAndServe starts serving the control API on server.Addr and blocks until it stops.
+func (server *Server) ListenAndServe() error {
+	return http.ListenAndServe(server.Addr, server.Handler())
+}
+
+func (server *Server) gameHandlers() []handler.Interface {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]handler.Interface(nil), server.handlers...)
+}
+
+func (server *Server) handleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	response := DiagnosticsResponse{
+		Runtime: RuntimeDiagnostics{
+			Goroutines: runtime.NumGoroutine(),
+			HeapAlloc:  memStats.HeapAlloc,
+			HeapInuse:  memStats.HeapInuse,
+			NumGC:      memStats.NumGC,
+		},
+	}
+	for _, gameHandler := range server.gameHandlers() {
+		response.Handlers = append(response.Handlers, gameHandler.Diagnostics())
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (server *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	response := make(map[string][]handler.AccountInfo)
+	for _, gameHandler := range server.gameHandlers() {
+		response[gameHandler.GetGameName()] = append(response[gameHandler.GetGameName()], gameHandler.ListAccounts()...)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (server *Server) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	hours := 24
+	if value := r.URL.Query().Get("hours"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "invalid hours: "+value, http.StatusBadRequest)
+			return
+		}
+		hours = parsed
+	}
+	response := make(map[string][]handler.ScheduledRun)
+	for _, gameHandler := range server.gameHandlers() {
+		response[gameHandler.GetGameName()] = append(response[gameHandler.GetGameName()], gameHandler.Schedule(time.Duration(hours)*time.Hour)...)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (server *Server) handleErrors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	response := make(map[string]handler.ErrorStats)
+	for _, gameHandler := range server.gameHandlers() {
+		response[gameHandler.GetGameName()] = gameHandler.ErrorStats()
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (server *Server) handleLatency(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, metrics.Default.Snapshot())
+}
+
+func (server *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	response := make(map[string][]jobqueue.Job)
+	for _, gameHandler := range server.gameHandlers() {
+		jobs, err := gameHandler.Jobs()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response[gameHandler.GetGameName()] = append(response[gameHandler.GetGameName()], jobs...)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (server *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	limit := 20
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "invalid limit: "+value, http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	run := query.Get("run")
+	response := make(map[string][]history.Execution)
+	for _, gameHandler := range server.gameHandlers() {
+		var executions []history.Execution
+		var err error
+		if run == "" {
+			executions, err = gameHandler.GetTaskHistory(query.Get("account"), query.Get("task"), limit)
+		} else if executions, err = gameHandler.GetTaskHistory(query.Get("account"), query.Get("task"), 0); err == nil {
+			executions = history.OfRun(executions, run, limit)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response[gameHandler.GetGameName()] = append(response[gameHandler.GetGameName()], executions...)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (server *Server) handleJobAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "missing job id", http.StatusBadRequest)
+		return
+	}
+	for _, gameHandler := range server.gameHandlers() {
+		var err error
+		if r.URL.Path == "/jobs/resume" {
+			err = gameHandler.ResumeJob(id)
+		} else {
+			err = gameHandler.CancelJob(id)
+		}
+		if errors.Is(err, jobqueue.ErrJobNotFound) {
+			continue
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	http.Error(w, jobqueue.ErrJobNotFound.Error()+": "+id, http.StatusNotFound)
+}
+
+func (server *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	game := r.URL.Query().Get("game")
+	found := false
+	for _, gameHandler := range server.gameHandlers() {
+		if game != "" && gameHandler.GetGameName() != game {
+			continue
+		}
+		found = true
+		if r.URL.Path == "/resume" {
+			gameHandler.Resume()
+		} else {
+			gameHandler.Pause()
+		}
+	}
+	if !found {
+		http.Error(w, "unknown game: "+game, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// APIKeyRequest is the body accepted by POST /apikey.
+type APIKeyRequest struct {
+	Key string `json:"key"`
+}
+
+func (server *Server) handleAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if request.Key == "" {
+		http.Error(w, "missing key", http.StatusBadRequest)
+		return
+	}
+	game := r.URL.Query().Get("game")
+	var targets []handler.Interface
+	for _, gameHandler := range server.gameHandlers() {
+		if game == "" || gameHandler.GetGameName() == game {
+			targets = append(targets, gameHandler)
+		}
+	}
+	if len(targets) == 0 {
+		http.Error(w, "unknown game: "+game, http.StatusNotFound)
+		return
+	}
+	// Every handler is rotated even if one fails, so that a failure does not leave the
+	// handlers after it on the old key unreported.
+	failures := make(map[string]string)
+	for _, gameHandler := range targets {
+		if err := gameHandler.SetAPIKey(request.Key); err != nil {
+			failures[gameHandler.GetGameName()] = err.Error()
+		}
+	}
+	if len(failures) > 0 {
+		writeJSON(w, http.StatusInternalServerError, failures)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogLevelRequest is the body accepted by PUT /log/level.
+//
+// An empty Module changes the default level. An empty Level removes the module's override.
+type LogLevelRequest struct {
+	Module string `json:"module"`
+	Level  string `json:"level"`
+}
+
+func (server *Server) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, utils.LogLevels())
+	case http.MethodPut, http.MethodPost:
+		var request LogLevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if request.Level == "" && request.Module != "" {
+			utils.ResetLogLevel(request.Module)
+		} else if err := utils.SetLogLevel(request.Module, request.Level); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, utils.LogLevels())
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,126 @@
+// Package device generates consistent device profiles for accounts.
+//
+// Games running as Telegram Mini Apps see the User-Agent and client hints of the Telegram
+// WebView. Sending Go's default User-Agent, or a different one on every run, is an easy
+// signal of automation, so each account gets a device profile derived from its Telegram ID
+// that is stored in the accounts file and applied to all of its requests.
+package device
+
+import (
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+)
+
+// androidDevice is a model of the Android catalog with its release.
+type androidDevice struct {
+	model    string
+	versions []string
+}
+
+// iosDevice is a model of the iOS catalog.
+type iosDevice struct {
+	model    string
+	versions []string
+}
+
+var androidDevices = []androidDevice{
+	{"SM-S918B", []string{"13", "14"}},
+	{"SM-S911B", []string{"13", "14"}},
+	{"SM-A546B", []string{"13", "14"}},
+	{"SM-A536B", []string{"12", "13", "14"}},
+	{"Pixel 7", []string{"13", "14"}},
+	{"Pixel 8", []string{"14"}},
+	{"2201117TG", []string{"12", "13"}},
+	{"23049PCD8G", []string{"13", "14"}},
+	{"CPH2449", []string{"13", "14"}},
+	{"RMX3771", []string{"13", "14"}},
+}
+
+var iosDevices = []iosDevice{
+	{"iPhone14,5", []string{"16_6", "17_2", "17_4"}},
+	{"iPhone14,7", []string{"16_6", "17_2", "17_4"}},
+	{"iPhone15,2", []string{"17_2", "17_4", "17_5"}},
+	{"iPhone15,3", []string{"17_2", "17_4", "17_5"}},
+	{"iPhone16,1", []string{"17_2", "17_4", "17_5"}},
+}
+
+var chromeVersions = []string{"122.0.6261.119", "123.0.6312.118", "124.0.6367.82", "125.0.6422.53"}
+
+var languages = []string{"en-US", "en-GB", "ru-RU", "es-ES", "pt-BR", "tr-TR", "uk-UA", "id-ID"}
+
+// Generate returns the device profile of a seed, typically the account's Telegram ID. The
+// same seed always yields the same profile. About one account in four gets an iPhone.
+//
+// # Example:
+//
+//	profile := device.Generate(account.TelegramData.TelegramId)
+//	fmt.Println(profile.UserAgent)
+func Generate(seed string) types.DeviceProfile {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(seed))
+	random := rand.New(rand.NewSource(int64(hash.Sum64())))
+	profile := types.DeviceProfile{Language: languages[random.Intn(len(languages))]}
+	if random.Intn(4) == 0 {
+		device := iosDevices[random.Intn(len(iosDevices))]
+		profile.Platform = "iOS"
+		profile.Model = device.model
+		profile.PlatformVersion = device.versions[random.Intn(len(device.versions))]
+		profile.UserAgent = fmt.Sprintf("Mozilla/5.0 (iPhone; CPU iPhone OS %s like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148",
+			profile.PlatformVersion)
+		return profile
+	}
+	device := androidDevices[random.Intn(len(androidDevices))]
+	profile.Platform = "Android"
+	profile.Model = device.model
+	profile.PlatformVersion = device.versions[random.Intn(len(device.versions))]
+	profile.BrowserVersion = chromeVersions[random.Intn(len(chromeVersions))]
+	profile.UserAgent = fmt.Sprintf("Mozilla/5.0 (Linux; Android %s; %s; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/%s Mobile Safari/537.36",
+		profile.PlatformVersion, profile.Model, profile.BrowserVersion)
+	return profile
+}
+
+// Headers returns the request headers of a device profile: the User-Agent, the
+// Accept-Language, and, on Android, the Sec-CH-UA client hints of the Chromium WebView.
+func Headers(profile types.DeviceProfile) map[string]string {
+	headers := map[string]string{
+		"User-Agent": profile.UserAgent,
+	}
+	if profile.Language != "" {
+		language := strings.SplitN(profile.Language, "-", 2)[0]
+		headers["Accept-Language"] = fmt.Sprintf("%s,%s;q=0.9", profile.Language, language)
+	}
+	if profile.Platform == "Android" && profile.BrowserVersion != "" {
+		major := strings.SplitN(profile.BrowserVersion, ".", 2)[0]
+		headers["Sec-CH-UA"] = fmt.Sprintf(`"Chromium";v="%s", "Android WebView";v="%s", "Not-A.Brand";v="99"`, major, major)
+		headers["Sec-CH-UA-Mobile"] = "?1"
+		headers["Sec-CH-UA-Platform"] = `"Android"`
+	}
+	return headers
+}
+
+// Ensure assigns a generated device profile to every account without one.
+//
+// # Returns:
+//   - bool: Whether any account was changed, i.e. whether the accounts should be saved.
+//
+// # Example:
+//
+//	if device.Ensure(accounts) {
+//		if err := handler.SaveAccounts("accounts.json", accounts); err != nil {
+//			log.Printf("Failed to persist device profiles: %v", err)
+//		}
+//	}
+func Ensure(accounts []types.Account) bool {
+	changed := false
+	for i := range accounts {
+		if accounts[i].Device == nil {
+			profile := Generate(accounts[i].TelegramData.TelegramId)
+			accounts[i].Device = &profile
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,81 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Elector elects one leader among the instances of a farm started with the same configuration.
+//
+// Only the leader runs tasks, so two instances never double-run one-time tasks for the same
+// accounts. The standby instances block in Campaign and take over when the leader dies.
+//
+// # Methods:
+//   - Campaign(ctx context.Context) error: Blocks until this instance is the leader or ctx is done.
+//   - Lost() <-chan struct{}: Returns a channel closed when the acquired leadership is lost.
+//   - Resign() error: Gives up the leadership.
+type Elector interface {
+	Campaign(ctx context.Context) error
+	Lost() <-chan struct{}
+	Resign() error
+}
+
+// FromConfig creates the elector described by an election configuration.
+//
+// # Parameters:
+//   - config: The "election" section of config.json.
+//
+// # Returns:
+//   - Elector: The elector, or nil when no backend is configured.
+//   - error: An error if the backend is unknown.
+//
+// # Example:
+//
+//	elector, err := election.FromConfig(config.Election)
+//	if err != nil {
+//		log.Fatalf("Failed to configure leader election: %v", err)
+//	}
+func FromConfig(config types.ElectionConfig) (Elector, error) {
+	ttl := time.Duration(config.TTLSeconds) * time.Second
+	switch config.Backend {
+	case "":
+		return nil, nil
+	case "file":
+		if config.Path == "" {
+			return nil, fmt.Errorf("election backend 'file' requires a path")
+		}
+		return NewFileLock(config.Path), nil
+	case "redis":
+		if config.Addr == "" {
+			return nil, fmt.Errorf("election backend 'redis' requires an address")
+		}
+		key := config.Key
+		if key == "" {
+			key = "nexus:leader"
+		}
+		return NewRedis(config.Addr, config.Password, key, ttl), nil
+	case "etcd":
+		if config.Addr == "" {
+			return nil, fmt.Errorf("election backend 'etcd' requires an address")
+		}
+		key := config.Key
+		if key == "" {
+			key = "nexus/leader"
+		}
+		elector := NewEtcd(config.Addr, key, ttl)
+		elector.Username, elector.Password = config.Username, config.Password
+		return elector, nil
+	default:
+		return nil, fmt.Errorf("unknown election backend: %s", config.Backend)
+	}
+}
+
+// instanceID identifies this process as a leadership candidate.
+func instanceID() string {
+	hostname, _ := os.Hostname()
+	return hostname + ":" + strconv.Itoa(os.Getpid()) + ":" + strconv.FormatInt(time.Now().UnixNano(), 36)
+}
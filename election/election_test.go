@@ -0,0 +1,107 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFromConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  types.ElectionConfig
+		want    string
+		wantErr bool
+	}{
+		{"none", types.ElectionConfig{}, "<nil>", false},
+		{"file", types.ElectionConfig{Backend: "file", Path: "/tmp/farm.lock"}, "*election.FileLock", false},
+		{"file without path", types.ElectionConfig{Backend: "file"}, "", true},
+		{"redis", types.ElectionConfig{Backend: "redis", Addr: "10.0.0.5:6379"}, "*election.Redis", false},
+		{"redis without address", types.ElectionConfig{Backend: "redis"}, "", true},
+		{"etcd", types.ElectionConfig{Backend: "etcd", Addr: "http://10.0.0.5:2379"}, "*election.Etcd", false},
+		{"etcd without address", types.ElectionConfig{Backend: "etcd"}, "", true},
+		{"unknown", types.ElectionConfig{Backend: "consul"}, "", true},
+	}
+	for _, test := range tests {
+		elector, err := FromConfig(test.config)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: FromConfig() error = %v, want error %t", test.name, err, test.wantErr)
+			continue
+		}
+		if got := fmt.Sprintf("%T", elector); !test.wantErr && got != test.want {
+			t.Errorf("%s: FromConfig() = %s, want %s", test.name, got, test.want)
+		}
+	}
+	redis, _ := FromConfig(types.ElectionConfig{Backend: "redis", Addr: "10.0.0.5:6379"})
+	etcd, _ := FromConfig(types.ElectionConfig{Backend: "etcd", Addr: "http://10.0.0.5:2379", Username: "farm"})
+	if redis.(*Redis).Key != "nexus:leader" || etcd.(*Etcd).Key != "nexus/leader" || etcd.(*Etcd).Username != "farm" {
+		t.Errorf("electors = %+v, %+v, want the default keys and the etcd user", redis, etcd)
+	}
+}
+
+// testFailover checks that only one of two electors leads at a time, and that the second one
+// takes over when the first resigns.
+func testFailover(t *testing.T, first, second Elector, wait time.Duration) {
+	t.Helper()
+	if err := first.Campaign(context.Background()); err != nil {
+		t.Fatalf("first Campaign failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	err := second.Campaign(ctx)
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second Campaign returned %v while the first elector leads, want the deadline", err)
+	}
+	lost := first.Lost()
+	select {
+	case <-lost:
+		t.Fatalf("leadership lost before Resign")
+	default:
+	}
+	if err := first.Resign(); err != nil {
+		t.Fatalf("Resign failed: %v", err)
+	}
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatalf("Lost() not closed by Resign")
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := second.Campaign(ctx); err != nil {
+		t.Fatalf("second Campaign failed after the first resigned: %v", err)
+	}
+	if err := second.Resign(); err != nil {
+		t.Fatalf("Resign failed: %v", err)
+	}
+	if err := second.Resign(); err != nil {
+		t.Errorf("second Resign returned %v", err)
+	}
+}
+
+func TestFileLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "farm.lock")
+	first, second := NewFileLock(path), NewFileLock(path)
+	second.PollInterval = 10 * time.Millisecond
+	testFailover(t, first, second, 50*time.Millisecond)
+
+	if err := NewFileLock(filepath.Join(t.TempDir(), "missing", "farm.lock")).Campaign(context.Background()); err == nil {
+		t.Errorf("Campaign() succeeded without a lock file")
+	}
+}
+
+func TestRedis(t *testing.T) {
+	addr := os.Getenv("NEXUS_TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("NEXUS_TEST_REDIS_ADDR not set")
+	}
+	key := "nexus:test:leader:" + instanceID()
+	password := os.Getenv("NEXUS_TEST_REDIS_PASSWORD")
+	first, second := NewRedis(addr, password, key, 300*time.Millisecond), NewRedis(addr, password, key, 300*time.Millisecond)
+	testFailover(t, first, second, 500*time.Millisecond)
+}
@@ -0,0 +1,277 @@
+package election
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Etcd is an Elector backed by an etcd key attached to a lease, for instances on different
+// hosts of a farm running etcd.
+//
+// It talks to the JSON API that etcd 3.4 and later serve on their client port (the gRPC
+// gateway under /v3/), so the SDK needs no gRPC client. The leader creates the key in a
+// transaction only if it does not exist, attached to a lease of TTL, and keeps the lease alive
+// every third of the TTL. When the leader dies, the lease expires, etcd deletes the key, and a
+// standby creates it within one TTL.
+//
+// # Fields:
+//   - Endpoint: The etcd client URL (e.g., "http://10.0.0.5:2379").
+//   - Username: The optional etcd user, authenticated with Password.
+//   - Password: The password of Username.
+//   - Key: The leadership key.
+//   - TTL: The lifetime of the lease without keep-alive. Defaults to 15 seconds; etcd rounds
+//     it up to whole seconds.
+//   - Client: The HTTP client of the requests. Defaults to http.DefaultClient.
+//   - id: The value identifying this instance in the key.
+//   - lease: The ID of the lease of the key while this instance is the leader.
+//   - token: The authentication token of Username.
+//   - lost: Closed when the leadership is lost or given up.
+//   - stop: Closed by Resign to stop the keep-alive loop.
+//   - mu: A mutex guarding lease, token, lost, and stop.
+//
+// # Example:
+//
+//	elector := election.NewEtcd("http://10.0.0.5:2379", "nexus/leader/hamster", 15*time.Second)
+//	gameHandler.SetElector(elector)
+type Etcd struct {
+	Endpoint string
+	Username string
+	Password string
+	Key      string
+	TTL      time.Duration
+	Client   *http.Client
+	id       string
+	lease    string
+	token    string
+	lost     chan struct{}
+	stop     chan struct{}
+	mu       sync.Mutex
+}
+
+// NewEtcd creates an etcd elector.
+func NewEtcd(endpoint, key string, ttl time.Duration) *Etcd {
+	return &Etcd{Endpoint: endpoint, Key: key, TTL: ttl, id: instanceID()}
+}
+
+// Campaign blocks until the key is created or ctx is done.
+func (elector *Etcd) Campaign(ctx context.Context) error {
+	ttl := elector.ttl()
+	for {
+		lease, err := elector.grant(ctx)
+		if err == nil {
+			var created bool
+			if created, err = elector.create(ctx, lease); err == nil && created {
+				elector.mu.Lock()
+				elector.lease = lease
+				elector.lost = make(chan struct{})
+				elector.stop = make(chan struct{})
+				go elector.renew(lease, elector.lost, elector.stop)
+				elector.mu.Unlock()
+				return nil
+			}
+			_ = elector.revoke(ctx, lease)
+		}
+		timer := time.NewTimer(ttl / 3)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Lost returns a channel closed when the lease could not be kept alive or Resign was called.
+func (elector *Etcd) Lost() <-chan struct{} {
+	elector.mu.Lock()
+	defer elector.mu.Unlock()
+	if elector.lost == nil {
+		elector.lost = make(chan struct{})
+	}
+	return elector.lost
+}
+
+// Resign stops keeping the lease alive and revokes it, which deletes the key.
+func (elector *Etcd) Resign() error {
+	elector.mu.Lock()
+	stop, lease := elector.stop, elector.lease
+	elector.stop, elector.lease = nil, ""
+	elector.mu.Unlock()
+	if stop == nil {
+		return nil
+	}
+	close(stop)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return elector.revoke(ctx, lease)
+}
+
+// renew keeps the lease alive every third of the TTL until stop is closed, closing lost when
+// the lease expired or could not be kept alive before it would.
+func (elector *Etcd) renew(lease string, lost, stop chan struct{}) {
+	defer close(lost)
+	ttl := elector.ttl()
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+		var reply struct {
+			Result struct {
+				TTL string `json:"TTL"`
+			} `json:"result"`
+		}
+		err := elector.call(ctx, "/v3/lease/keepalive", map[string]string{"ID": lease}, &reply)
+		cancel()
+		if err == nil {
+			if remaining, _ := strconv.ParseInt(reply.Result.TTL, 10, 64); remaining > 0 {
+				renewed = time.Now()
+				continue
+			}
+			return
+		}
+		if time.Since(renewed) >= ttl {
+			return
+		}
+	}
+}
+
+// grant creates a lease of the TTL and returns its ID.
+func (elector *Etcd) grant(ctx context.Context) (string, error) {
+	seconds := int64((elector.ttl() + time.Second - 1) / time.Second)
+	var reply struct {
+		ID string `json:"ID"`
+	}
+	if err := elector.call(ctx, "/v3/lease/grant", map[string]int64{"TTL": seconds}, &reply); err != nil {
+		return "", err
+	}
+	if reply.ID == "" {
+		return "", fmt.Errorf("etcd granted no lease")
+	}
+	return reply.ID, nil
+}
+
+// create creates the key attached to lease if it does not exist, and reports whether it did.
+func (elector *Etcd) create(ctx context.Context, lease string) (bool, error) {
+	key := base64.StdEncoding.EncodeToString([]byte(elector.Key))
+	request := map[string]interface{}{
+		"compare": []map[string]string{{"key": key, "result": "EQUAL", "target": "CREATE", "create_revision": "0"}},
+		"success": []map[string]interface{}{{"request_put": map[string]string{
+			"key":   key,
+			"value": base64.StdEncoding.EncodeToString([]byte(elector.id)),
+			"lease": lease,
+		}}},
+	}
+	var reply struct {
+		Succeeded bool `json:"succeeded"`
+	}
+	err := elector.call(ctx, "/v3/kv/txn", request, &reply)
+	return reply.Succeeded, err
+}
+
+// revoke revokes a lease, deleting the keys attached to it.
+func (elector *Etcd) revoke(ctx context.Context, lease string) error {
+	return elector.call(ctx, "/v3/lease/revoke", map[string]string{"ID": lease}, nil)
+}
+
+func (elector *Etcd) ttl() time.Duration {
+	if elector.TTL <= 0 {
+		return 15 * time.Second
+	}
+	return elector.TTL
+}
+
+// authenticate returns the authentication token of the elector's user, requesting one if
+// needed, or "" without a user.
+func (elector *Etcd) authenticate(ctx context.Context) (string, error) {
+	if elector.Username == "" {
+		return "", nil
+	}
+	elector.mu.Lock()
+	token := elector.token
+	elector.mu.Unlock()
+	if token != "" {
+		return token, nil
+	}
+	var reply struct {
+		Token string `json:"token"`
+	}
+	credentials := map[string]string{"name": elector.Username, "password": elector.Password}
+	if err := elector.post(ctx, "/v3/auth/authenticate", "", credentials, &reply); err != nil {
+		return "", fmt.Errorf("etcd authentication failed: %w", err)
+	}
+	elector.mu.Lock()
+	elector.token = reply.Token
+	elector.mu.Unlock()
+	return reply.Token, nil
+}
+
+// call sends a request of the etcd JSON API as the elector's user, authenticating again once
+// if the token expired.
+func (elector *Etcd) call(ctx context.Context, path string, request, reply interface{}) error {
+	token, err := elector.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+	err = elector.post(ctx, path, token, request, reply)
+	if token != "" && err != nil && strings.Contains(err.Error(), "invalid auth token") {
+		elector.mu.Lock()
+		elector.token = ""
+		elector.mu.Unlock()
+		if token, err = elector.authenticate(ctx); err != nil {
+			return err
+		}
+		err = elector.post(ctx, path, token, request, reply)
+	}
+	return err
+}
+
+// post sends a request of the etcd JSON API and decodes its reply.
+func (elector *Etcd) post(ctx context.Context, path, token string, request, reply interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(elector.Endpoint, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	client := elector.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("etcd %s returned %d: %s", path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if reply == nil {
+		return nil
+	}
+	return json.Unmarshal(data, reply)
+}
@@ -0,0 +1,172 @@
+package election
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEtcd serves the leases and the key transactions of the etcd JSON API used by Etcd.
+// Leases do not expire on their own; expire revokes them as etcd does once their TTL elapsed.
+type fakeEtcd struct {
+	mu        sync.Mutex
+	nextLease int
+	leases    map[string]bool
+	keys      map[string]string // Lease of each key
+	tokens    map[string]bool
+	users     map[string]string
+}
+
+func newFakeEtcd(t *testing.T, users map[string]string) (*fakeEtcd, *httptest.Server) {
+	t.Helper()
+	etcd := &fakeEtcd{leases: make(map[string]bool), keys: make(map[string]string), tokens: make(map[string]bool), users: users}
+	server := httptest.NewServer(etcd)
+	t.Cleanup(server.Close)
+	return etcd, server
+}
+
+func (etcd *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	etcd.mu.Lock()
+	defer etcd.mu.Unlock()
+	var request map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Path == "/v3/auth/authenticate" {
+		if password, ok := etcd.users[request["name"].(string)]; !ok || password != request["password"] {
+			http.Error(w, `{"error":"authentication failed, invalid user ID or password"}`, http.StatusBadRequest)
+			return
+		}
+		token := "token" + strconv.Itoa(len(etcd.tokens))
+		etcd.tokens[token] = true
+		reply(w, map[string]string{"token": token})
+		return
+	}
+	if etcd.users != nil && !etcd.tokens[r.Header.Get("Authorization")] {
+		http.Error(w, `{"error":"etcdserver: invalid auth token"}`, http.StatusUnauthorized)
+		return
+	}
+	switch r.URL.Path {
+	case "/v3/lease/grant":
+		etcd.nextLease++
+		lease := strconv.Itoa(etcd.nextLease)
+		etcd.leases[lease] = true
+		reply(w, map[string]string{"ID": lease, "TTL": fmt.Sprint(request["TTL"])})
+	case "/v3/lease/keepalive":
+		ttl := "0"
+		if etcd.leases[request["ID"].(string)] {
+			ttl = "1"
+		}
+		reply(w, map[string]interface{}{"result": map[string]string{"ID": request["ID"].(string), "TTL": ttl}})
+	case "/v3/lease/revoke":
+		etcd.revoke(request["ID"].(string))
+		reply(w, map[string]string{})
+	case "/v3/kv/txn":
+		put := request["success"].([]interface{})[0].(map[string]interface{})["request_put"].(map[string]interface{})
+		key, lease := put["key"].(string), put["lease"].(string)
+		if _, exists := etcd.keys[key]; exists {
+			reply(w, map[string]bool{"succeeded": false})
+			return
+		}
+		etcd.keys[key] = lease
+		reply(w, map[string]bool{"succeeded": true})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// revoke deletes a lease and its keys. It must be called with mu held.
+func (etcd *fakeEtcd) revoke(lease string) {
+	delete(etcd.leases, lease)
+	for key, owner := range etcd.keys {
+		if owner == lease {
+			delete(etcd.keys, key)
+		}
+	}
+}
+
+// expire revokes every lease, as if their holders stopped keeping them alive.
+func (etcd *fakeEtcd) expire() {
+	etcd.mu.Lock()
+	defer etcd.mu.Unlock()
+	for lease := range etcd.leases {
+		etcd.revoke(lease)
+	}
+}
+
+// invalidateTokens makes every authentication token invalid.
+func (etcd *fakeEtcd) invalidateTokens() {
+	etcd.mu.Lock()
+	defer etcd.mu.Unlock()
+	etcd.tokens = make(map[string]bool)
+}
+
+func reply(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestEtcd(t *testing.T) {
+	etcd, server := newFakeEtcd(t, nil)
+	first, second := NewEtcd(server.URL, "nexus/leader", 30*time.Millisecond), NewEtcd(server.URL, "nexus/leader", 30*time.Millisecond)
+	testFailover(t, first, second, 50*time.Millisecond)
+	etcd.mu.Lock()
+	defer etcd.mu.Unlock()
+	if len(etcd.keys) != 0 {
+		t.Errorf("etcd holds keys %v after both electors resigned", etcd.keys)
+	}
+}
+
+func TestEtcdLeaseExpiry(t *testing.T) {
+	etcd, server := newFakeEtcd(t, nil)
+	elector := NewEtcd(server.URL, "nexus/leader", 30*time.Millisecond)
+	if err := elector.Campaign(context.Background()); err != nil {
+		t.Fatalf("Campaign failed: %v", err)
+	}
+	defer elector.Resign()
+	etcd.expire()
+	select {
+	case <-elector.Lost():
+	case <-time.After(time.Second):
+		t.Fatalf("Lost() not closed once the lease expired")
+	}
+}
+
+func TestEtcdAuthentication(t *testing.T) {
+	etcd, server := newFakeEtcd(t, map[string]string{"farm": "secret"})
+	elector := NewEtcd(server.URL, "nexus/leader", 30*time.Millisecond)
+	elector.Username, elector.Password = "farm", "wrong"
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := elector.Campaign(ctx); err == nil {
+		t.Fatalf("Campaign() succeeded with a wrong password")
+	}
+
+	elector.Password = "secret"
+	if err := elector.Campaign(context.Background()); err != nil {
+		t.Fatalf("Campaign failed: %v", err)
+	}
+	etcd.invalidateTokens()
+	// The lease is kept alive with a new token.
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-elector.Lost():
+		t.Fatalf("leadership lost when the token expired")
+	default:
+	}
+	if err := elector.Resign(); err != nil {
+		t.Fatalf("Resign failed: %v", err)
+	}
+	etcd.mu.Lock()
+	defer etcd.mu.Unlock()
+	if len(etcd.keys) != 0 {
+		t.Errorf("etcd holds keys %v after the elector resigned", etcd.keys)
+	}
+}
@@ -0,0 +1,102 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileLock is an Elector backed by an exclusive lock on a file, for instances sharing one host
+// or a file system with working locks.
+//
+// The operating system releases the lock when the leader process dies, so a standby takes over
+// within one poll interval.
+//
+// # Fields:
+//   - Path: The lock file.
+//   - PollInterval: How often a standby tries to acquire the lock. Defaults to one second.
+//   - file: The open lock file while this instance is the leader.
+//   - lost: Closed when the leadership is given up.
+//   - mu: A mutex guarding file and lost.
+//
+// # Example:
+//
+//	elector := election.NewFileLock("/var/run/nexus-farm.lock")
+//	gameHandler.SetElector(elector)
+type FileLock struct {
+	Path         string
+	PollInterval time.Duration
+	file         *os.File
+	lost         chan struct{}
+	mu           sync.Mutex
+}
+
+// NewFileLock creates a file lock elector for path.
+func NewFileLock(path string) *FileLock {
+	return &FileLock{Path: path}
+}
+
+// Campaign blocks until the lock is acquired or ctx is done.
+func (lock *FileLock) Campaign(ctx context.Context) error {
+	interval := lock.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	for {
+		file, err := os.OpenFile(lock.Path, os.O_CREATE|os.O_RDWR, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open lock file: %w", err)
+		}
+		acquired, err := tryLock(file)
+		if err != nil {
+			_ = file.Close()
+			return fmt.Errorf("failed to lock %s: %w", lock.Path, err)
+		}
+		if acquired {
+			_ = file.Truncate(0)
+			_, _ = file.WriteAt([]byte(instanceID()+"\n"), 0)
+			lock.mu.Lock()
+			lock.file = file
+			lock.lost = make(chan struct{})
+			lock.mu.Unlock()
+			return nil
+		}
+		_ = file.Close()
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Lost returns a channel closed when the lock is released by Resign. A held file lock is only
+// lost when the process dies.
+func (lock *FileLock) Lost() <-chan struct{} {
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+	if lock.lost == nil {
+		lock.lost = make(chan struct{})
+	}
+	return lock.lost
+}
+
+// Resign releases the lock.
+func (lock *FileLock) Resign() error {
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+	if lock.file == nil {
+		return nil
+	}
+	err := unlock(lock.file)
+	if closeErr := lock.file.Close(); err == nil {
+		err = closeErr
+	}
+	lock.file = nil
+	close(lock.lost)
+	return err
+}
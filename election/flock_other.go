@@ -0,0 +1,19 @@
+//go:build !unix
+
+package election
+
+import (
+	"errors"
+	"os"
+)
+
+// errFileLockUnsupported is returned by the file lock elector on platforms without flock.
+var errFileLockUnsupported = errors.New("file lock election is not supported on this platform")
+
+func tryLock(*os.File) (bool, error) {
+	return false, errFileLockUnsupported
+}
+
+func unlock(*os.File) error {
+	return errFileLockUnsupported
+}
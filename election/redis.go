@@ -0,0 +1,138 @@
+package election
+
+import (
+	"context"
+	"github.com/nexus-telegram/NexusSDK/internal/resp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// renewScript extends the key's expiry only if this instance still holds it.
+const renewScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`
+
+// releaseScript deletes the key only if this instance still holds it.
+const releaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+
+// Redis is an Elector backed by a Redis key with an expiry, for instances on different hosts.
+//
+// The leader holds the key with SET NX PX and renews its expiry every third of the TTL. When
+// the leader dies, the key expires and a standby acquires it within one TTL.
+//
+// # Fields:
+//   - Addr: The Redis address (e.g., "10.0.0.5:6379").
+//   - Password: The optional Redis password.
+//   - Key: The leadership key.
+//   - TTL: The lifetime of the key without renewal. Defaults to 15 seconds.
+//   - id: The value identifying this instance in the key.
+//   - lost: Closed when the leadership is lost or given up.
+//   - stop: Closed by Resign to stop the renewal loop.
+//   - mu: A mutex guarding lost and stop.
+//
+// # Example:
+//
+//	elector := election.NewRedis("10.0.0.5:6379", "", "nexus:leader:hamster", 15*time.Second)
+//	gameHandler.SetElector(elector)
+type Redis struct {
+	Addr     string
+	Password string
+	Key      string
+	TTL      time.Duration
+	id       string
+	lost     chan struct{}
+	stop     chan struct{}
+	mu       sync.Mutex
+}
+
+// NewRedis creates a Redis elector.
+func NewRedis(addr, password, key string, ttl time.Duration) *Redis {
+	return &Redis{Addr: addr, Password: password, Key: key, TTL: ttl, id: instanceID()}
+}
+
+// Campaign blocks until the key is acquired or ctx is done.
+func (elector *Redis) Campaign(ctx context.Context) error {
+	ttl := elector.ttl()
+	for {
+		reply, err := elector.command(ctx, "SET", elector.Key, elector.id, "NX", "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+		if err == nil && reply == "OK" {
+			elector.mu.Lock()
+			elector.lost = make(chan struct{})
+			elector.stop = make(chan struct{})
+			go elector.renew(elector.lost, elector.stop)
+			elector.mu.Unlock()
+			return nil
+		}
+		timer := time.NewTimer(ttl / 3)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Lost returns a channel closed when the key could not be renewed or Resign was called.
+func (elector *Redis) Lost() <-chan struct{} {
+	elector.mu.Lock()
+	defer elector.mu.Unlock()
+	if elector.lost == nil {
+		elector.lost = make(chan struct{})
+	}
+	return elector.lost
+}
+
+// Resign stops renewing the key and deletes it if this instance still holds it.
+func (elector *Redis) Resign() error {
+	elector.mu.Lock()
+	stop := elector.stop
+	elector.stop = nil
+	elector.mu.Unlock()
+	if stop == nil {
+		return nil
+	}
+	close(stop)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := elector.command(ctx, "EVAL", releaseScript, "1", elector.Key, elector.id)
+	return err
+}
+
+// renew extends the key every third of the TTL until stop is closed, closing lost when the
+// key cannot be renewed before it expires.
+func (elector *Redis) renew(lost, stop chan struct{}) {
+	defer close(lost)
+	ttl := elector.ttl()
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+		reply, err := elector.command(ctx, "EVAL", renewScript, "1", elector.Key, elector.id, strconv.FormatInt(ttl.Milliseconds(), 10))
+		cancel()
+		if err == nil && reply == "1" {
+			renewed = time.Now()
+			continue
+		}
+		if err == nil || time.Since(renewed) >= ttl {
+			return
+		}
+	}
+}
+
+func (elector *Redis) ttl() time.Duration {
+	if elector.TTL <= 0 {
+		return 15 * time.Second
+	}
+	return elector.TTL
+}
+
+// command sends one command and returns its reply as a string.
+func (elector *Redis) command(ctx context.Context, args ...string) (string, error) {
+	return resp.Client{Addr: elector.Addr, Password: elector.Password}.Command(ctx, args...)
+}
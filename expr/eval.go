@@ -0,0 +1,544 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// node is a node of the syntax tree of an expression.
+type node interface {
+	eval(env Env) (interface{}, error)
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (n *literal) eval(Env) (interface{}, error) {
+	return n.value, nil
+}
+
+type variable struct {
+	name string
+}
+
+func (n *variable) eval(env Env) (interface{}, error) {
+	value, ok := env[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable %q", n.name)
+	}
+	return normalize(value), nil
+}
+
+type member struct {
+	object node
+	key    node
+}
+
+func (n *member) eval(env Env) (interface{}, error) {
+	value, _, err := n.lookup(env)
+	return value, err
+}
+
+// lookup returns the member and whether it exists.
+func (n *member) lookup(env Env) (interface{}, bool, error) {
+	object, err := n.object.eval(env)
+	if err != nil {
+		return nil, false, err
+	}
+	key, err := n.key.eval(env)
+	if err != nil {
+		return nil, false, err
+	}
+	switch typed := object.(type) {
+	case nil:
+		return nil, false, nil
+	case map[string]interface{}:
+		name, ok := key.(string)
+		if !ok {
+			return nil, false, fmt.Errorf("cannot index an object with a %s", typeName(key))
+		}
+		value, ok := typed[name]
+		return normalize(value), ok, nil
+	case []interface{}:
+		index, ok := key.(float64)
+		if !ok || index != math.Trunc(index) {
+			return nil, false, fmt.Errorf("cannot index a list with %v", key)
+		}
+		if index < 0 {
+			index += float64(len(typed))
+		}
+		if index < 0 || int(index) >= len(typed) {
+			return nil, false, nil
+		}
+		return normalize(typed[int(index)]), true, nil
+	case string:
+		index, ok := key.(float64)
+		if !ok || index != math.Trunc(index) {
+			return nil, false, fmt.Errorf("cannot index a string with %v", key)
+		}
+		if index < 0 || int(index) >= len(typed) {
+			return nil, false, nil
+		}
+		return typed[int(index) : int(index)+1], true, nil
+	}
+	return nil, false, fmt.Errorf("cannot read %v of a %s", key, typeName(object))
+}
+
+// presence is the has function, reporting whether a variable or member exists.
+type presence struct {
+	operand node
+}
+
+func (n *presence) eval(env Env) (interface{}, error) {
+	switch operand := n.operand.(type) {
+	case *variable:
+		_, ok := env[operand.name]
+		return ok, nil
+	case *member:
+		_, ok, err := operand.lookup(env)
+		return ok, err
+	}
+	return false, nil
+}
+
+type unary struct {
+	operator string
+	operand  node
+}
+
+func (n *unary) eval(env Env) (interface{}, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.operator == "!" {
+		condition, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("cannot negate a %s", typeName(value))
+		}
+		return !condition, nil
+	}
+	number, ok := value.(float64)
+	if !ok {
+		return nil, fmt.Errorf("cannot negate a %s", typeName(value))
+	}
+	return -number, nil
+}
+
+type binary struct {
+	operator string
+	left     node
+	right    node
+}
+
+func (n *binary) eval(env Env) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.operator == "&&" || n.operator == "||" {
+		condition, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s expects booleans, got a %s", n.operator, typeName(left))
+		}
+		if condition == (n.operator == "||") {
+			return condition, nil
+		}
+		right, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := right.(bool); !ok {
+			return nil, fmt.Errorf("%s expects booleans, got a %s", n.operator, typeName(right))
+		}
+		return right, nil
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.operator {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		return contains(right, left)
+	case "+":
+		switch typed := left.(type) {
+		case string:
+			return typed + text(right), nil
+		case []interface{}:
+			if other, ok := right.([]interface{}); ok {
+				return append(append([]interface{}(nil), typed...), other...), nil
+			}
+		}
+		if other, ok := right.(string); ok && left != nil {
+			return text(left) + other, nil
+		}
+	}
+	a, leftIsNumber := left.(float64)
+	b, rightIsNumber := right.(float64)
+	if !leftIsNumber || !rightIsNumber {
+		first, leftIsString := left.(string)
+		second, rightIsString := right.(string)
+		if leftIsString && rightIsString {
+			switch n.operator {
+			case "<":
+				return first < second, nil
+			case "<=":
+				return first <= second, nil
+			case ">":
+				return first > second, nil
+			case ">=":
+				return first >= second, nil
+			}
+		}
+		return nil, fmt.Errorf("cannot apply %s to a %s and a %s", n.operator, typeName(left), typeName(right))
+	}
+	switch n.operator {
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return a / b, nil
+	case "%":
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(a, b), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", n.operator)
+}
+
+type conditional struct {
+	condition node
+	then      node
+	otherwise node
+}
+
+func (n *conditional) eval(env Env) (interface{}, error) {
+	value, err := n.condition.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	condition, ok := value.(bool)
+	if !ok {
+		return nil, fmt.Errorf("the condition of ?: is a %s, not a boolean", typeName(value))
+	}
+	if condition {
+		return n.then.eval(env)
+	}
+	return n.otherwise.eval(env)
+}
+
+type list struct {
+	elements []node
+}
+
+func (n *list) eval(env Env) (interface{}, error) {
+	values := make([]interface{}, len(n.elements))
+	for i, element := range n.elements {
+		value, err := element.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+type object struct {
+	keys   []string
+	values []node
+}
+
+func (n *object) eval(env Env) (interface{}, error) {
+	values := make(map[string]interface{}, len(n.keys))
+	for i, key := range n.keys {
+		value, err := n.values[i].eval(env)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+type call struct {
+	name      string
+	function  function
+	arguments []node
+}
+
+func (n *call) eval(env Env) (interface{}, error) {
+	arguments := make([]interface{}, len(n.arguments))
+	for i, argument := range n.arguments {
+		value, err := argument.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		arguments[i] = value
+	}
+	value, err := n.function.call(env, arguments)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return value, nil
+}
+
+// function is a function callable from expressions.
+type function struct {
+	minArgs int
+	maxArgs int
+	call    func(env Env, arguments []interface{}) (interface{}, error)
+}
+
+// arity describes the number of arguments of the function.
+func (f function) arity() string {
+	if f.minArgs == f.maxArgs {
+		if f.minArgs == 1 {
+			return "1 argument"
+		}
+		return fmt.Sprintf("%d arguments", f.minArgs)
+	}
+	return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+}
+
+// functions are the functions callable from expressions. "has" is handled by the parser.
+var functions = map[string]function{
+	"len": {1, 1, func(env Env, arguments []interface{}) (interface{}, error) {
+		switch typed := arguments[0].(type) {
+		case string:
+			return float64(len([]rune(typed))), nil
+		case []interface{}:
+			return float64(len(typed)), nil
+		case map[string]interface{}:
+			return float64(len(typed)), nil
+		case nil:
+			return float64(0), nil
+		}
+		return nil, fmt.Errorf("cannot measure a %s", typeName(arguments[0]))
+	}},
+	"lower":      stringFunction(strings.ToLower),
+	"upper":      stringFunction(strings.ToUpper),
+	"trim":       stringFunction(strings.TrimSpace),
+	"startsWith": stringPredicate(strings.HasPrefix),
+	"endsWith":   stringPredicate(strings.HasSuffix),
+	"contains": {2, 2, func(env Env, arguments []interface{}) (interface{}, error) {
+		if text, ok := arguments[0].(string); ok {
+			part, ok := arguments[1].(string)
+			if !ok {
+				return nil, fmt.Errorf("cannot search a string for a %s", typeName(arguments[1]))
+			}
+			return strings.Contains(text, part), nil
+		}
+		return contains(arguments[0], arguments[1])
+	}},
+	"int": {1, 1, func(env Env, arguments []interface{}) (interface{}, error) {
+		number, err := toNumber(arguments[0])
+		return math.Trunc(number), err
+	}},
+	"float": {1, 1, func(env Env, arguments []interface{}) (interface{}, error) {
+		return toNumber(arguments[0])
+	}},
+	"string": {1, 1, func(env Env, arguments []interface{}) (interface{}, error) {
+		return text(arguments[0]), nil
+	}},
+	"now": {0, 0, func(env Env, _ []interface{}) (interface{}, error) {
+		now := time.Now
+		if clock, ok := env[NowKey].(func() time.Time); ok {
+			now = clock
+		}
+		return float64(now().UnixMilli()) / 1000, nil
+	}},
+	"has": {1, 1, nil},
+}
+
+func stringFunction(transform func(string) string) function {
+	return function{1, 1, func(env Env, arguments []interface{}) (interface{}, error) {
+		value, ok := arguments[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("expects a string, got a %s", typeName(arguments[0]))
+		}
+		return transform(value), nil
+	}}
+}
+
+func stringPredicate(predicate func(string, string) bool) function {
+	return function{2, 2, func(env Env, arguments []interface{}) (interface{}, error) {
+		value, ok := arguments[0].(string)
+		part, partOk := arguments[1].(string)
+		if !ok || !partOk {
+			return nil, fmt.Errorf("expects strings, got a %s and a %s", typeName(arguments[0]), typeName(arguments[1]))
+		}
+		return predicate(value, part), nil
+	}}
+}
+
+// contains reports whether a list holds an element, or an object a key.
+func contains(collection, element interface{}) (interface{}, error) {
+	switch typed := collection.(type) {
+	case []interface{}:
+		for _, candidate := range typed {
+			if equal(normalize(candidate), element) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		key, ok := element.(string)
+		if !ok {
+			return false, nil
+		}
+		_, ok = typed[key]
+		return ok, nil
+	case string:
+		part, ok := element.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot search a string for a %s", typeName(element))
+		}
+		return strings.Contains(typed, part), nil
+	case nil:
+		return false, nil
+	}
+	return nil, fmt.Errorf("cannot search a %s", typeName(collection))
+}
+
+// equal compares two values, numbers by value and lists and objects deeply.
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(normalizeDeep(a), normalizeDeep(b))
+}
+
+// toNumber converts a number, a numeric string, or a boolean to a number.
+func toNumber(value interface{}) (float64, error) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", typed)
+		}
+		return number, nil
+	case bool:
+		if typed {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("cannot convert a %s to a number", typeName(value))
+}
+
+// text returns the text of a value: strings as they are, other values as JSON.
+func text(value interface{}) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// normalize converts the numbers of other Go types read from the environment to float64.
+func normalize(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case int:
+		return float64(typed)
+	case int32:
+		return float64(typed)
+	case int64:
+		return float64(typed)
+	case uint:
+		return float64(typed)
+	case uint32:
+		return float64(typed)
+	case uint64:
+		return float64(typed)
+	case float32:
+		return float64(typed)
+	case json.Number:
+		number, err := typed.Float64()
+		if err != nil {
+			return typed.String()
+		}
+		return number
+	case map[string]string:
+		converted := make(map[string]interface{}, len(typed))
+		for key, text := range typed {
+			converted[key] = text
+		}
+		return converted
+	case []string:
+		converted := make([]interface{}, len(typed))
+		for i, text := range typed {
+			converted[i] = text
+		}
+		return converted
+	}
+	return value
+}
+
+// normalizeDeep normalizes a value and the elements of its lists and objects.
+func normalizeDeep(value interface{}) interface{} {
+	switch typed := normalize(value).(type) {
+	case []interface{}:
+		converted := make([]interface{}, len(typed))
+		for i, element := range typed {
+			converted[i] = normalizeDeep(element)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(typed))
+		for key, element := range typed {
+			converted[key] = normalizeDeep(element)
+		}
+		return converted
+	default:
+		return typed
+	}
+}
+
+// typeName returns the name of the type of a value in the language.
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,166 @@
+// Package expr evaluates the expressions of task configurations, such as the condition of a
+// conditional task ("resp.energy > 100") or the value a pipeline step extracts from a response
+// ("token = resp.data.accessToken").
+//
+// The language is small and safe to run on untrusted task files: expressions read the values
+// of their environment and call a fixed set of functions, they cannot assign, loop, or reach
+// the host. Values are those of decoded JSON: nil, bool, float64, string, []interface{}, and
+// map[string]interface{}.
+//
+// # Syntax:
+//   - Literals: 42, 1.5, "text", 'text', true, false, null, [1, 2], {"key": value}.
+//   - Variables and members: resp, resp.data.token, resp["data"], resp.items[0]. The member of
+//     a missing key or of null is null.
+//   - Operators, by increasing precedence: the conditional a ? b : c, ||, &&, == and !=,
+//     the comparisons and in, + and -, the products, and unary ! and -. "+" also concatenates
+//     strings and lists; "x in list" and "key in object" test membership.
+//   - Functions: len, lower, upper, trim, contains, startsWith, endsWith, int, float, string,
+//     now (Unix seconds, see NowKey), and has (whether a member exists, e.g. has(resp.data.token)).
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Env is the environment of an evaluation: the values of the variables an expression reads.
+type Env map[string]interface{}
+
+// NowKey is the key of the environment holding the clock read by now(), a func() time.Time,
+// e.g. the clock of the handler so that conditions follow a fake clock in tests. It is not a
+// variable name, so expressions cannot read it. Without it, now() reads the system clock.
+const NowKey = "$now"
+
+// Program is a compiled expression. A Program is immutable and safe for concurrent use.
+type Program struct {
+	source string
+	root   node
+}
+
+// Compile parses an expression.
+//
+// # Returns:
+//   - *Program: The compiled expression.
+//   - error: An error pointing at the invalid part of the expression.
+//
+// # Example:
+//
+//	condition, err := expr.Compile("resp.energy > 100 && !resp.boosted")
+//	if err != nil {
+//		log.Fatalf("Invalid condition: %v", err)
+//	}
+//	ok, err := condition.Bool(expr.Env{"resp": state})
+func Compile(source string) (*Program, error) {
+	root, err := parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{source: source, root: root}, nil
+}
+
+// MustCompile is like Compile but panics if the expression is invalid. It is meant for
+// expressions hardcoded in game adapters.
+func MustCompile(source string) *Program {
+	program, err := Compile(source)
+	if err != nil {
+		panic(err)
+	}
+	return program
+}
+
+// String returns the source of the expression.
+func (program *Program) String() string {
+	return program.source
+}
+
+// Eval evaluates the expression.
+//
+// # Returns:
+//   - interface{}: The value of the expression.
+//   - error: An error if the expression reads an unknown variable or applies an operator or
+//     function to values of the wrong type.
+func (program *Program) Eval(env Env) (interface{}, error) {
+	value, err := program.root.eval(env)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", program.source, err)
+	}
+	return value, nil
+}
+
+// Bool evaluates a condition.
+//
+// # Returns:
+//   - bool: The value of the condition.
+//   - error: An error if the evaluation fails or its value is not a boolean.
+func (program *Program) Bool(env Env) (bool, error) {
+	value, err := program.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q is a %s, not a condition", program.source, typeName(value))
+	}
+	return result, nil
+}
+
+// Eval compiles and evaluates an expression once.
+func Eval(source string, env Env) (interface{}, error) {
+	program, err := Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	return program.Eval(env)
+}
+
+// Assignment is an extraction of the form "name = expression", storing the value of the
+// expression into a variable.
+//
+// # Fields:
+//   - Name: The variable assigned.
+//   - Value: The expression of the value.
+type Assignment struct {
+	Name  string
+	Value *Program
+}
+
+// CompileAssignment parses an assignment such as "token = resp.data.accessToken".
+//
+// # Returns:
+//   - Assignment: The compiled assignment.
+//   - error: An error if the variable name or the expression is invalid.
+func CompileAssignment(source string) (Assignment, error) {
+	name, value, ok := strings.Cut(source, "=")
+	name = strings.TrimSpace(name)
+	if !ok || !isIdentifier(name) || strings.HasPrefix(value, "=") {
+		return Assignment{}, fmt.Errorf("invalid assignment %q, expected \"name = expression\"", source)
+	}
+	program, err := Compile(strings.TrimSpace(value))
+	if err != nil {
+		return Assignment{}, err
+	}
+	return Assignment{Name: name, Value: program}, nil
+}
+
+// Apply evaluates the expression of the assignment and stores its value into vars.
+func (assignment Assignment) Apply(env Env, vars map[string]interface{}) error {
+	value, err := assignment.Value.Eval(env)
+	if err != nil {
+		return err
+	}
+	vars[assignment.Name] = value
+	return nil
+}
+
+// isIdentifier reports whether name is a valid variable name.
+func isIdentifier(name string) bool {
+	if name == "" || keywords[name] {
+		return false
+	}
+	for i, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,192 @@
+package expr
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEval(t *testing.T) {
+	env := Env{
+		"resp": map[string]interface{}{
+			"energy":  float64(150),
+			"boosted": false,
+			"name":    "Alice",
+			"items":   []interface{}{"a", "b", "c"},
+			"data":    map[string]interface{}{"token": "t0k3n"},
+		},
+		"count": 3,
+	}
+	tests := []struct {
+		source string
+		want   interface{}
+	}{
+		{"42", float64(42)},
+		{"1.5", 1.5},
+		{`"text"`, "text"},
+		{"'text'", "text"},
+		{"null", nil},
+		{"[1, 2]", []interface{}{float64(1), float64(2)}},
+		{`{"key": 1}`, map[string]interface{}{"key": float64(1)}},
+		{"resp.energy > 100 && !resp.boosted", true},
+		{"resp.data.token", "t0k3n"},
+		{`resp["data"]["token"]`, "t0k3n"},
+		{"resp.items[0]", "a"},
+		{"resp.items[-1]", "c"},
+		{"resp.items[5]", nil},
+		{"resp.missing.member", nil},
+		{"count * 2", float64(6)},
+		{"1 + 2 * 3", float64(7)},
+		{"(1 + 2) * 3", float64(9)},
+		{"7 % 4", float64(3)},
+		{"-resp.energy", float64(-150)},
+		{`"a" + 1`, "a1"},
+		{"[1] + [2]", []interface{}{float64(1), float64(2)}},
+		{`"b" in resp.items`, true},
+		{`"token" in resp.data`, true},
+		{`"b" < "c"`, true},
+		{"resp.energy >= 150 ? 'full' : 'low'", "full"},
+		{"true || resp.missing.member > 1", true},
+		{"len(resp.items)", float64(3)},
+		{"len('héllo')", float64(5)},
+		{"lower(resp.name)", "alice"},
+		{"upper(resp.name)", "ALICE"},
+		{"trim('  x ')", "x"},
+		{"contains(resp.name, 'lic')", true},
+		{"startsWith(resp.name, 'Al')", true},
+		{"endsWith(resp.name, 'ce')", true},
+		{"int(3.7)", float64(3)},
+		{"float('2.5')", 2.5},
+		{"string(12)", "12"},
+		{"has(resp.data.token)", true},
+		{"has(resp.data.other)", false},
+		{"has(missing)", false},
+	}
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			got, err := Eval(test.source, env)
+			if err != nil {
+				t.Fatalf("Eval(%q) failed: %v", test.source, err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Eval(%q) = %#v, want %#v", test.source, got, test.want)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	env := Env{"resp": map[string]interface{}{"energy": float64(1)}}
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"missing", `unknown variable "missing"`},
+		{"1 / 0", "division by zero"},
+		{"1 % 0", "division by zero"},
+		{"!1", "cannot negate a number"},
+		{"1 && true", "&& expects booleans"},
+		{"resp - 1", "cannot apply -"},
+		{"resp.energy ? 1 : 2", "not a boolean"},
+		{"lower(1)", "lower: expects a string"},
+		{"resp.energy.value", "cannot read value of a number"},
+	}
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			_, err := Eval(test.source, env)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Eval(%q) error = %v, want %q", test.source, err, test.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, source := range []string{"", "1 +", "(1", "resp.", "unknown(1)", "len()", "len(1, 2)", `"open`, "1 2"} {
+		t.Run(source, func(t *testing.T) {
+			if _, err := Compile(source); err == nil {
+				t.Errorf("Compile(%q) succeeded, want an error", source)
+			}
+		})
+	}
+}
+
+func TestBool(t *testing.T) {
+	tests := []struct {
+		source  string
+		want    bool
+		wantErr bool
+	}{
+		{"1 < 2", true, false},
+		{"1 > 2", false, false},
+		{"1 + 2", false, true},
+	}
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			got, err := MustCompile(test.source).Bool(Env{})
+			if (err != nil) != test.wantErr || got != test.want {
+				t.Errorf("Bool(%q) = %t, %v, want %t, error %t", test.source, got, err, test.want, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompileAssignment(t *testing.T) {
+	tests := []struct {
+		source  string
+		name    string
+		want    interface{}
+		wantErr bool
+	}{
+		{"token = resp.token", "token", "abc", false},
+		{"  total=resp.count + 1 ", "total", float64(3), false},
+		{"resp.token", "", nil, true},
+		{"1x = 2", "", nil, true},
+		{"true = 1", "", nil, true},
+		{"same == 1", "", nil, true},
+	}
+	env := Env{"resp": map[string]interface{}{"token": "abc", "count": float64(2)}}
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			assignment, err := CompileAssignment(test.source)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("CompileAssignment(%q) succeeded, want an error", test.source)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CompileAssignment(%q) failed: %v", test.source, err)
+			}
+			vars := map[string]interface{}{}
+			if err := assignment.Apply(env, vars); err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+			if assignment.Name != test.name || !reflect.DeepEqual(vars[test.name], test.want) {
+				t.Errorf("CompileAssignment(%q) set %v, want %s = %v", test.source, vars, test.name, test.want)
+			}
+		})
+	}
+}
+
+func TestNow(t *testing.T) {
+	fixed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	got, err := Eval("now()", Env{NowKey: func() time.Time { return fixed }})
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	if got != float64(fixed.Unix()) {
+		t.Errorf("now() = %v, want the time of the clock of the environment, %d", got, fixed.Unix())
+	}
+	now, err := Eval("now()", Env{})
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	if seconds := now.(float64); time.Since(time.Unix(int64(seconds), 0)) > time.Minute {
+		t.Errorf("now() = %v without a clock, want the system time", seconds)
+	}
+	if _, err := Eval(NowKey, Env{NowKey: 1}); err == nil {
+		t.Errorf("Eval(%q) succeeded, want the clock to be unreadable", NowKey)
+	}
+}
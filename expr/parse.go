@@ -0,0 +1,426 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxDepth bounds the nesting of expressions, so that a hostile task file cannot exhaust the
+// stack of the parser.
+const maxDepth = 64
+
+// keywords are the identifiers reserved by the language.
+var keywords = map[string]bool{"true": true, "false": true, "null": true, "in": true}
+
+// tokenKind is the kind of a token.
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdentifier
+	tokenOperator
+)
+
+// token is a lexical token of an expression.
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+// operators are the operators and punctuation of the language, longest first.
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "!", "?", ":", ".", ",", "(", ")", "[", "]", "{", "}"}
+
+// lex splits an expression into tokens.
+func lex(source string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(source); {
+		c := source[pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pos++
+		case c >= '0' && c <= '9':
+			end := pos
+			for end < len(source) && (source[end] >= '0' && source[end] <= '9' || source[end] == '.' ||
+				source[end] == 'e' || source[end] == 'E' ||
+				(source[end] == '-' || source[end] == '+') && (source[end-1] == 'e' || source[end-1] == 'E')) {
+				end++
+			}
+			number, err := strconv.ParseFloat(source[pos:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", source[pos:end], pos)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: source[pos:end], value: number, pos: pos})
+			pos = end
+		case c == '"' || c == '\'':
+			text, end, err := lexString(source, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: source[pos:end], value: text, pos: pos})
+			pos = end
+		case isLetter(c):
+			end := pos
+			for end < len(source) && (isLetter(source[end]) || source[end] >= '0' && source[end] <= '9') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, text: source[pos:end], pos: pos})
+			pos = end
+		default:
+			matched := ""
+			for _, operator := range operators {
+				if strings.HasPrefix(source[pos:], operator) {
+					matched = operator
+					break
+				}
+			}
+			if matched == "" {
+				return nil, fmt.Errorf("unexpected character %q at %d", c, pos)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: matched, pos: pos})
+			pos += len(matched)
+		}
+	}
+	return append(tokens, token{kind: tokenEnd, pos: len(source)}), nil
+}
+
+// isLetter reports whether c may start an identifier.
+func isLetter(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// lexString reads the quoted string starting at pos. Strings use the escapes of JSON strings.
+func lexString(source string, pos int) (string, int, error) {
+	quote := source[pos]
+	var builder strings.Builder
+	for end := pos + 1; end < len(source); end++ {
+		c := source[end]
+		if c == quote {
+			return builder.String(), end + 1, nil
+		}
+		if c != '\\' {
+			builder.WriteByte(c)
+			continue
+		}
+		end++
+		if end == len(source) {
+			break
+		}
+		switch source[end] {
+		case 'n':
+			builder.WriteByte('\n')
+		case 't':
+			builder.WriteByte('\t')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'u':
+			if end+4 >= len(source) {
+				return "", 0, fmt.Errorf("invalid escape at %d", end-1)
+			}
+			code, err := strconv.ParseUint(source[end+1:end+5], 16, 16)
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid escape at %d", end-1)
+			}
+			builder.WriteRune(rune(code))
+			end += 4
+		default:
+			builder.WriteByte(source[end])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at %d", pos)
+}
+
+// parser is a recursive descent parser of expressions.
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// parse parses an expression into its syntax tree.
+func parse(source string) (node, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEnd {
+		return nil, fmt.Errorf("unexpected %q at %d", next.text, next.pos)
+	}
+	return root, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	current := p.tokens[p.pos]
+	if current.kind != tokenEnd {
+		p.pos++
+	}
+	return current
+}
+
+// accept consumes the next token if it is one of the operators.
+func (p *parser) accept(operators ...string) (string, bool) {
+	current := p.peek()
+	if current.kind != tokenOperator && !(current.kind == tokenIdentifier && current.text == "in") {
+		return "", false
+	}
+	for _, operator := range operators {
+		if current.text == operator {
+			p.pos++
+			return operator, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(operator string) error {
+	if _, ok := p.accept(operator); !ok {
+		current := p.peek()
+		if current.kind == tokenEnd {
+			return fmt.Errorf("expected %q at end of expression", operator)
+		}
+		return fmt.Errorf("expected %q at %d, found %q", operator, current.pos, current.text)
+	}
+	return nil
+}
+
+func (p *parser) ternary() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, fmt.Errorf("expression nested too deeply")
+	}
+	condition, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return condition, nil
+	}
+	then, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return &conditional{condition: condition, then: then, otherwise: otherwise}, nil
+}
+
+// precedence lists the binary operators by increasing precedence.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) binary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.accept(precedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if operator, ok := p.accept("!", "-"); ok {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxDepth {
+			return nil, fmt.Errorf("expression nested too deeply")
+		}
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{operator: operator, operand: operand}, nil
+	}
+	return p.postfix()
+}
+
+func (p *parser) postfix() (node, error) {
+	value, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("."); ok {
+			name := p.next()
+			if name.kind != tokenIdentifier {
+				return nil, fmt.Errorf("expected a member name at %d", name.pos)
+			}
+			value = &member{object: value, key: &literal{value: name.text}}
+			continue
+		}
+		if _, ok := p.accept("["); ok {
+			key, err := p.ternary()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			value = &member{object: value, key: key}
+			continue
+		}
+		return value, nil
+	}
+}
+
+func (p *parser) primary() (node, error) {
+	current := p.next()
+	switch current.kind {
+	case tokenNumber, tokenString:
+		return &literal{value: current.value}, nil
+	case tokenIdentifier:
+		switch current.text {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		case "null":
+			return &literal{value: nil}, nil
+		case "in":
+			return nil, fmt.Errorf("unexpected \"in\" at %d", current.pos)
+		}
+		if _, ok := p.accept("("); ok {
+			return p.call(current)
+		}
+		return &variable{name: current.text}, nil
+	case tokenOperator:
+		switch current.text {
+		case "(":
+			inner, err := p.ternary()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(")")
+		case "[":
+			return p.list()
+		case "{":
+			return p.object()
+		}
+		return nil, fmt.Errorf("unexpected %q at %d", current.text, current.pos)
+	}
+	return nil, fmt.Errorf("unexpected end of expression")
+}
+
+// call parses the arguments of a function call.
+func (p *parser) call(name token) (node, error) {
+	function, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at %d", name.text, name.pos)
+	}
+	arguments, err := p.elements(")")
+	if err != nil {
+		return nil, err
+	}
+	if name.text == "has" {
+		if len(arguments) != 1 {
+			return nil, fmt.Errorf("has expects 1 argument")
+		}
+		if _, isMember := arguments[0].(*member); !isMember {
+			if _, isVariable := arguments[0].(*variable); !isVariable {
+				return nil, fmt.Errorf("has expects a variable or member at %d", name.pos)
+			}
+		}
+		return &presence{operand: arguments[0]}, nil
+	}
+	if len(arguments) < function.minArgs || len(arguments) > function.maxArgs {
+		return nil, fmt.Errorf("%s expects %s", name.text, function.arity())
+	}
+	return &call{name: name.text, function: function, arguments: arguments}, nil
+}
+
+// elements parses comma-separated expressions up to the closing operator.
+func (p *parser) elements(closing string) ([]node, error) {
+	var elements []node
+	if _, ok := p.accept(closing); ok {
+		return elements, nil
+	}
+	for {
+		element, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+		if _, ok := p.accept(closing); ok {
+			return elements, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) list() (node, error) {
+	elements, err := p.elements("]")
+	if err != nil {
+		return nil, err
+	}
+	return &list{elements: elements}, nil
+}
+
+func (p *parser) object() (node, error) {
+	result := &object{}
+	if _, ok := p.accept("}"); ok {
+		return result, nil
+	}
+	for {
+		key := p.next()
+		if key.kind != tokenString && key.kind != tokenIdentifier {
+			return nil, fmt.Errorf("expected an object key at %d", key.pos)
+		}
+		name := key.text
+		if key.kind == tokenString {
+			name = key.value.(string)
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		result.keys = append(result.keys, name)
+		result.values = append(result.values, value)
+		if _, ok := p.accept("}"); ok {
+			return result, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"github.com/nexus-telegram/NexusSDK/device"
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/tasks"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+)
+
+// accountHandler scopes a GameHandler to a single task execution for one account.
+//
+// It is the value passed to tasks.Task.Run, so everything a task does through the
+// tasks.Handler interface can be attributed to the account and attempt being executed.
+//
+// # Fields:
+//   - GameHandler: The handler the execution belongs to.
+//   - ctx: The context of the execution, carrying its deadline (see tasks.TimeLimited).
+//   - account: The account the task is executed for.
+//   - task: The name of the task executed.
+//   - attempt: The 1-based attempt number of the execution.
+//   - run: The ID of the run of the execution, "" outside of a run (see RunReport).
+//   - gzip: Whether request bodies are gzip-compressed (see tasks.Compressed).
+type accountHandler struct {
+	*GameHandler
+	ctx     context.Context
+	account types.Account
+	task    string
+	attempt int
+	run     string
+	gzip    bool
+}
+
+var (
+	_ tasks.HeaderSender    = (*accountHandler)(nil)
+	_ tasks.ContextProvider = (*accountHandler)(nil)
+	_ tasks.ClockProvider   = (*accountHandler)(nil)
+	_ tasks.ProfileLocker   = (*accountHandler)(nil)
+)
+
+// newAccountHandler returns a view of the handler scoped to the given execution context,
+// account, task, attempt, and run.
+func (handler *GameHandler) newAccountHandler(ctx context.Context, account types.Account, task tasks.Task, attempt int, run string) *accountHandler {
+	compressed, _ := task.(tasks.Compressed)
+	return &accountHandler{
+		GameHandler: handler,
+		ctx:         ctx,
+		account:     account,
+		task:        taskName(task),
+		attempt:     attempt,
+		run:         run,
+		gzip:        compressed != nil && compressed.CompressRequests(),
+	}
+}
+
+// GetLogger returns the tasks module logger annotated with the account, attempt, and run_id
+// fields.
+func (view *accountHandler) GetLogger() *zap.Logger {
+	logger := utils.WithAccount(utils.ModuleLogger("tasks"), view.GameName, view.account).With(zap.Int("attempt", view.attempt))
+	if view.run != "" {
+		logger = logger.With(zap.String("run_id", view.run))
+	}
+	return logger
+}
+
+// Post sends a POST request attributed to the view's account.
+func (view *accountHandler) Post(url string, payload []byte) ([]byte, error) {
+	return view.post(view.context(), url, payload)
+}
+
+// PostWithHeaders sends a POST request attributed to the view's account with per-call headers.
+func (view *accountHandler) PostWithHeaders(url string, payload []byte, headers map[string]string) ([]byte, error) {
+	return view.post(httpclient.ContextWithHeaders(view.context(), headers), url, payload)
+}
+
+// Get sends a GET request attributed to the view's account, hedged through a second proxy
+// when a hedge policy is set (see SetHedgePolicy).
+func (view *accountHandler) Get(url string) ([]byte, error) {
+	return view.get(view.context(), url)
+}
+
+// GetWithHeaders sends a GET request attributed to the view's account with per-call headers.
+func (view *accountHandler) GetWithHeaders(url string, headers map[string]string) ([]byte, error) {
+	return view.get(httpclient.ContextWithHeaders(view.context(), headers), url)
+}
+
+// post sends a POST request bound to ctx through the account's client, unless its payload
+// exceeds the limit of the task (see SetPayloadLimits).
+func (view *accountHandler) post(ctx context.Context, url string, payload []byte) ([]byte, error) {
+	if err := view.checkPayload(url, payload); err != nil {
+		return nil, err
+	}
+	client, proxy, err := view.clientFor(view.account)
+	if err != nil {
+		return nil, err
+	}
+	body, err := postWith(ctx, client, url, payload)
+	view.reportProxyError(proxy, err)
+	view.inspectResponse(view.account, body, err)
+	view.chargeErrorBudget(view.account, err)
+	return body, err
+}
+
+// get sends a GET request bound to ctx through the account's client. See Get.
+func (view *accountHandler) get(ctx context.Context, url string) ([]byte, error) {
+	client, proxy, err := view.clientFor(view.account)
+	if err != nil {
+		return nil, err
+	}
+	view.mu.Lock()
+	policy, pool := view.hedge, view.ProxyPool
+	view.mu.Unlock()
+	var body []byte
+	if policy != nil && pool != nil && pool.Healthy() > 1 {
+		body, err = view.hedgedGet(ctx, policy, client, proxy, url)
+	} else {
+		body, err = getWith(ctx, client, url)
+		view.reportProxyError(proxy, err)
+	}
+	view.inspectResponse(view.account, body, err)
+	view.chargeErrorBudget(view.account, err)
+	return body, err
+}
+
+// Context returns the context of the execution, done once its deadline has passed.
+func (view *accountHandler) Context() context.Context {
+	return view.ctx
+}
+
+// context returns the context of the view's requests: the execution context with the account,
+// its device headers, and the compression setting.
+func (view *accountHandler) context() context.Context {
+	ctx := httpclient.ContextWithAccount(view.ctx, view.account.TelegramData.TelegramId)
+	if view.account.Device != nil {
+		ctx = httpclient.ContextWithHeaders(ctx, device.Headers(*view.account.Device))
+	}
+	if view.gzip {
+		ctx = httpclient.ContextWithGzip(ctx)
+	}
+	return ctx
+}
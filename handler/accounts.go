@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"time"
+)
+
+// AccountInfo is the listing view of an account, without its session secrets.
+//
+// # Fields:
+//   - TelegramId: The Telegram ID of the account.
+//   - Label: The account's human-readable name.
+//   - Notes: The operator notes of the account.
+//   - Enabled: Whether the account is processed by the scheduler.
+//   - CreatedAt: When the account was added to the farm, nil if unknown.
+//   - WarmingUp: Whether the account is in the warm-up period of the handler's WarmUpPolicy.
+//   - CooldownUntil: When the cooldown started by a ban signal ends, nil unless the account
+//     is in one.
+//   - LastTask: The task of the last execution of the account.
+//   - LastRun: When the last execution started, nil if the account has not run yet.
+//   - LastError: The error of the last execution, empty if it succeeded.
+//   - ErrorStreak: The number of consecutive failed executions of the account.
+type AccountInfo struct {
+	TelegramId    string     `json:"telegram_id"`
+	Label         string     `json:"label,omitempty"`
+	Notes         string     `json:"notes,omitempty"`
+	Enabled       bool       `json:"enabled"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+	WarmingUp     bool       `json:"warming_up,omitempty"`
+	CooldownUntil *time.Time `json:"cooldown_until,omitempty"`
+	LastTask      string     `json:"last_task,omitempty"`
+	LastRun       *time.Time `json:"last_run,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	ErrorStreak   int        `json:"error_streak,omitempty"`
+}
+
+// ListAccounts returns the listing view of every account of the handler, including disabled ones.
+//
+// # Example:
+//
+//	for _, account := range handler.ListAccounts() {
+//		fmt.Printf("%s %-12s enabled=%t %s\n", account.TelegramId, account.Label, account.Enabled, account.Notes)
+//	}
+func (handler *GameHandler) ListAccounts() []AccountInfo {
+	now := handler.getClock().Now()
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	accounts := make([]AccountInfo, 0, len(handler.Accounts))
+	for _, account := range handler.Accounts {
+		info := AccountInfo{
+			TelegramId: account.TelegramData.TelegramId,
+			Label:      account.Label,
+			Notes:      account.Notes,
+			Enabled:    account.IsEnabled(),
+			WarmingUp:  handler.warmUp.WarmingUp(account, now),
+		}
+		if !account.CreatedAt.IsZero() {
+			createdAt := account.CreatedAt
+			info.CreatedAt = &createdAt
+		}
+		if activity, ok := handler.activity[account.TelegramData.TelegramId]; ok {
+			info.LastTask, info.LastError, info.ErrorStreak = activity.lastTask, activity.lastError, activity.streak
+			if !activity.lastRun.IsZero() {
+				lastRun := activity.lastRun
+				info.LastRun = &lastRun
+			}
+		}
+		if cooldown, ok := handler.cooldowns[account.TelegramData.TelegramId]; ok && now.Before(cooldown.Until) {
+			until := cooldown.Until
+			info.CooldownUntil = &until
+		}
+		accounts = append(accounts, info)
+	}
+	return accounts
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/nexus-telegram/NexusSDK/testutil"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"testing"
+	"time"
+)
+
+func TestListAccountsOmitsUnsetTimes(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	account := func(id string) types.Account {
+		return types.Account{TelegramData: types.TelegramData{TelegramId: id}}
+	}
+	created := account("created")
+	created.CreatedAt = now.AddDate(0, -1, 0)
+	handler := &GameHandler{
+		Accounts: []types.Account{account("new"), created, account("ran"), account("cooling"), account("cooled")},
+		activity: map[string]accountActivity{
+			"ran": {lastRun: now.Add(-time.Hour), lastTask: "claim"},
+		},
+		cooldowns: map[string]Cooldown{
+			"cooling": {Until: now.Add(time.Hour)},
+			"cooled":  {Until: now.Add(-time.Hour)},
+		},
+	}
+	handler.SetClock(testutil.NewFakeClock(now))
+	tests := map[string]string{
+		"new":     `{"telegram_id":"new","enabled":true}`,
+		"created": `{"telegram_id":"created","enabled":true,"created_at":"2024-05-01T12:00:00Z"}`,
+		"ran":     `{"telegram_id":"ran","enabled":true,"last_task":"claim","last_run":"2024-06-01T11:00:00Z"}`,
+		"cooling": `{"telegram_id":"cooling","enabled":true,"cooldown_until":"2024-06-01T13:00:00Z"}`,
+		"cooled":  `{"telegram_id":"cooled","enabled":true}`,
+	}
+	accounts := handler.ListAccounts()
+	if len(accounts) != len(tests) {
+		t.Fatalf("ListAccounts() returned %d accounts, want %d", len(accounts), len(tests))
+	}
+	for _, info := range accounts {
+		encoded, err := json.Marshal(info)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if want := tests[info.TelegramId]; string(encoded) != want {
+			t.Errorf("account %s encodes as %s, want %s", info.TelegramId, encoded, want)
+		}
+	}
+}
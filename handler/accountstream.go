@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/remote"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// AccountStream decodes the accounts of an accounts.json file one at a time, so that a file of
+// any size is read with the memory of a single account.
+//
+// # Example:
+//
+//	stream, err := handler.OpenAccountStream("accounts.json")
+//	if err != nil {
+//		log.Fatalf("Failed to open accounts: %v", err)
+//	}
+//	defer stream.Close()
+//	for {
+//		account, err := stream.Next()
+//		if errors.Is(err, io.EOF) {
+//			break
+//		}
+//		if err != nil {
+//			log.Fatalf("Failed to read account: %v", err)
+//		}
+//		fmt.Println(account.TelegramData.TelegramId)
+//	}
+type AccountStream struct {
+	file    io.ReadCloser
+	decoder *json.Decoder
+	started bool
+	done    bool
+}
+
+// OpenAccountStream opens an accounts.json file for streaming.
+//
+// # Parameters:
+//   - filePath: The path to the accounts.json file, or a remote location (see SetRemoteOptions).
+//
+// # Returns:
+//   - *AccountStream: The stream, to close once read.
+//   - error: An error if the file cannot be opened.
+func OpenAccountStream(filePath string) (*AccountStream, error) {
+	file, err := openLocation(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountStream{file: file, decoder: json.NewDecoder(file)}, nil
+}
+
+// Next returns the next account of the file, with its secret references resolved and its
+// wallet checked like LoadAccounts does.
+//
+// # Returns:
+//   - types.Account: The account.
+//   - error: io.EOF after the last account, or an error if the file is not a JSON array of
+//     accounts, a secret cannot be resolved, or a wallet is invalid.
+func (stream *AccountStream) Next() (types.Account, error) {
+	account, err := stream.next()
+	if err != nil {
+		return account, err
+	}
+	return account, checkAccount(&account)
+}
+
+// next decodes the next account of the file as it is written.
+func (stream *AccountStream) next() (types.Account, error) {
+	var account types.Account
+	if stream.done {
+		return account, io.EOF
+	}
+	if !stream.started {
+		token, err := stream.decoder.Token()
+		if err != nil {
+			return account, err
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return account, errors.New("accounts file is not a JSON array")
+		}
+		stream.started = true
+	}
+	if !stream.decoder.More() {
+		if _, err := stream.decoder.Token(); err != nil {
+			return account, err
+		}
+		stream.done = true
+		return account, io.EOF
+	}
+	err := stream.decoder.Decode(&account)
+	return account, err
+}
+
+// offset returns the offset in the file of the end of the last account read.
+func (stream *AccountStream) offset() int64 {
+	return stream.decoder.InputOffset()
+}
+
+// Close closes the file of the stream.
+func (stream *AccountStream) Close() error {
+	return stream.file.Close()
+}
+
+// StreamAccounts calls fn with every account of an accounts.json file, in order, decoding them
+// one at a time (see AccountStream).
+//
+// # Returns:
+//   - error: The first error of the stream or of fn, which stops the iteration.
+//
+// # Example:
+//
+//	enabled := 0
+//	err := handler.StreamAccounts("accounts.json", func(account types.Account) error {
+//		if account.IsEnabled() {
+//			enabled++
+//		}
+//		return nil
+//	})
+func StreamAccounts(filePath string, fn func(account types.Account) error) error {
+	stream, err := OpenAccountStream(filePath)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	for {
+		account, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(account); err != nil {
+			return err
+		}
+	}
+}
+
+// checkAccount resolves the secret references of an account read from the accounts file and
+// checks its wallet.
+func checkAccount(account *types.Account) error {
+	accounts := []types.Account{*account}
+	if err := resolveAccountSecrets(accounts); err != nil {
+		return err
+	}
+	*account = accounts[0]
+	if account.Wallet != nil {
+		if err := account.Wallet.Validate(); err != nil {
+			return fmt.Errorf("account %s: %w", account.TelegramData.TelegramId, err)
+		}
+	}
+	return nil
+}
+
+// LazyAccounts holds the accounts of a large local accounts.json file without their game data
+// and session strings, which make up most of their size, and reads them back from the file
+// when an account runs a task or refreshes its game data (see Hydrate). A farm of 100k accounts then starts
+// with the memory of the small fields only.
+//
+// The file is indexed once when opened, streaming it, and again when it changed since, e.g.
+// after device profiles were added to it.
+//
+// # Example:
+//
+//	lazy, err := handler.OpenLazyAccounts("accounts.json")
+//	if err != nil {
+//		log.Fatalf("Failed to index accounts: %v", err)
+//	}
+//	gameHandler.SetLazyAccounts(lazy)
+type LazyAccounts struct {
+	path     string
+	mu       sync.Mutex
+	accounts []types.Account
+	offsets  map[string][2]int64 // Offsets of the entries in the file by Telegram ID, separators included
+	modTime  time.Time
+	size     int64
+}
+
+// OpenLazyAccounts indexes a local accounts.json file.
+//
+// # Returns:
+//   - *LazyAccounts: The accounts, without game data and session strings.
+//   - error: An error if the file is remote, cannot be read, or is not a JSON array of accounts.
+func OpenLazyAccounts(filePath string) (*LazyAccounts, error) {
+	if remote.IsRemote(filePath) {
+		return nil, fmt.Errorf("lazy accounts need a local file: %s", filePath)
+	}
+	lazy := &LazyAccounts{path: filePath}
+	if err := lazy.reindex(); err != nil {
+		return nil, err
+	}
+	return lazy, nil
+}
+
+// Accounts returns the accounts of the file without their game data and session strings, with
+// the wallets checked. Secret references are left unresolved until Hydrate.
+func (lazy *LazyAccounts) Accounts() []types.Account {
+	lazy.mu.Lock()
+	defer lazy.mu.Unlock()
+	return append([]types.Account(nil), lazy.accounts...)
+}
+
+// Hydrate reads an account back from the file with all its fields, its secret references
+// resolved.
+//
+// # Parameters:
+//   - telegramId: The Telegram ID of the account.
+//
+// # Returns:
+//   - types.Account: The account.
+//   - error: An error if the account is not in the file or cannot be read.
+func (lazy *LazyAccounts) Hydrate(telegramId string) (types.Account, error) {
+	var account types.Account
+	lazy.mu.Lock()
+	defer lazy.mu.Unlock()
+	info, err := os.Stat(lazy.path)
+	if err != nil {
+		return account, err
+	}
+	if !info.ModTime().Equal(lazy.modTime) || info.Size() != lazy.size {
+		if err := lazy.reindex(); err != nil {
+			return account, err
+		}
+	}
+	offsets, ok := lazy.offsets[telegramId]
+	if !ok {
+		return account, fmt.Errorf("account %s not found in %s", telegramId, lazy.path)
+	}
+	file, err := os.Open(lazy.path)
+	if err != nil {
+		return account, err
+	}
+	defer file.Close()
+	entry := make([]byte, offsets[1]-offsets[0])
+	if _, err := file.ReadAt(entry, offsets[0]); err != nil {
+		return account, err
+	}
+	if err := json.Unmarshal(bytes.TrimLeft(entry, "[, \t\r\n"), &account); err != nil {
+		return account, fmt.Errorf("account %s: %w", telegramId, err)
+	}
+	return account, checkAccount(&account)
+}
+
+// reindex reads the accounts and their offsets from the file. It must be called with mu held.
+func (lazy *LazyAccounts) reindex() error {
+	info, err := os.Stat(lazy.path)
+	if err != nil {
+		return err
+	}
+	stream, err := OpenAccountStream(lazy.path)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	var accounts []types.Account
+	offsets := make(map[string][2]int64)
+	for {
+		start := stream.offset()
+		account, err := stream.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if account.Wallet != nil {
+			if err := account.Wallet.Validate(); err != nil {
+				return fmt.Errorf("account %s: %w", account.TelegramData.TelegramId, err)
+			}
+		}
+		offsets[account.TelegramData.TelegramId] = [2]int64{start, stream.offset()}
+		account.GameData = ""
+		account.TelegramData.TdataStringSession = ""
+		accounts = append(accounts, account)
+	}
+	lazy.accounts, lazy.offsets = accounts, offsets
+	lazy.modTime, lazy.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// SetLazyAccounts makes the handler process the accounts of lazy, reading the game data of an
+// account from the accounts file before each task execution, and its session string when it
+// refreshes its game data, rather than keeping them in memory. It replaces the handler's
+// accounts.
+//
+// Handlers created by NewGameHandler with lazy_accounts set in their configuration use lazy
+// accounts when the accounts file is local.
+//
+// # Parameters:
+//   - lazy: The accounts, see OpenLazyAccounts. Nil keeps the current accounts and stops
+//     reading sessions from the file.
+func (handler *GameHandler) SetLazyAccounts(lazy *LazyAccounts) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.lazy = lazy
+	if lazy != nil {
+		handler.Accounts = lazy.Accounts()
+	}
+}
+
+// hydrateAccount returns an account with the game data read back from the accounts file if the
+// handler has lazy accounts and the account has none in memory, e.g. restored by RestoreState.
+func (handler *GameHandler) hydrateAccount(account types.Account) (types.Account, error) {
+	handler.mu.Lock()
+	lazy := handler.lazy
+	handler.mu.Unlock()
+	if lazy == nil || account.GameData != "" {
+		return account, nil
+	}
+	hydrated, err := lazy.Hydrate(account.TelegramData.TelegramId)
+	if err != nil {
+		return account, err
+	}
+	account.GameData = hydrated.GameData
+	return account, nil
+}
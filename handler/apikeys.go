@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/apikeys"
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+)
+
+// SetAPIKeyPool sets the Nexus API keys used to refresh the game data of the handler's
+// accounts, instead of the handler's single APIKey. Passing nil uses APIKey again.
+//
+// When the Nexus API reports that the quota of a key is used up, the refresh is retried with
+// the next key of the account, and the exhausted key is skipped for the pool's QuotaCooldown.
+// If the handler encrypts its secrets (see SetSecretMemory), the keys of the pool are sealed.
+//
+// # Parameters:
+//   - pool: The API key pool, see apikeys.New and apikeys.FromConfig.
+//
+// # Example:
+//
+//	gameHandler.SetAPIKeyPool(apikeys.New(
+//		apikeys.Key{Name: "alice", Value: "key-1", Accounts: []string{"987654321"}},
+//		apikeys.Key{Name: "shared", Value: "key-2"},
+//	))
+func (handler *GameHandler) SetAPIKeyPool(pool *apikeys.Pool) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if pool != nil && handler.sealer != nil {
+		// Sealing keys of plain values does not fail.
+		_ = pool.Seal(handler.sealer)
+	}
+	handler.apiKeys = pool
+}
+
+// SetAPIKey replaces the handler's APIKey, so that an expiring key can be rotated without
+// restarting the farm. Refreshes of game data started afterwards use the new key; refreshes in
+// flight finish with the old one. If an API key pool is set, its key named
+// apikeys.DefaultKeyName (the api_key setting) is replaced as well.
+//
+// # Parameters:
+//   - key: The new API key.
+//
+// # Returns:
+//   - error: An error if the key is empty.
+//
+// # Example:
+//
+//	if err := gameHandler.SetAPIKey(os.Getenv("NEXUS_API_KEY")); err != nil {
+//		log.Printf("Failed to rotate API key: %v", err)
+//	}
+func (handler *GameHandler) SetAPIKey(key string) error {
+	if key == "" {
+		return errors.New("api key is empty")
+	}
+	handler.mu.Lock()
+	if handler.sealer != nil {
+		handler.APIKey, handler.sealedKey = "", handler.sealer.Seal(key)
+	} else {
+		handler.APIKey, handler.sealedKey = key, nil
+	}
+	if handler.keyUsage != nil {
+		handler.keyUsage.Reset(apikeys.DefaultKeyName)
+	}
+	if handler.apiKeys != nil {
+		handler.apiKeys.Rotate(apikeys.DefaultKeyName, key, handler.sealer)
+	}
+	handler.mu.Unlock()
+	handler.GetLogger().Info("API key rotated")
+	return nil
+}
+
+// apiKeyPool returns the API key pool of the handler, or nil if none is set.
+func (handler *GameHandler) apiKeyPool() *apikeys.Pool {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return handler.apiKeys
+}
+
+// apiKeyTracker returns the usage tracker of the handler's APIKey, creating it if needed.
+func (handler *GameHandler) apiKeyTracker() *apikeys.Tracker {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.keyUsage == nil {
+		handler.keyUsage = apikeys.NewTracker()
+	}
+	return handler.keyUsage
+}
+
+// APIKeyUsage returns the number of game data refreshes sent with each API key of the handler
+// and the estimate of their remaining quota, sorted by key name. The handler's APIKey is
+// named apikeys.DefaultKeyName.
+//
+// # Example:
+//
+//	for _, usage := range gameHandler.APIKeyUsage() {
+//		fmt.Printf("%s: %d calls, %d remaining\n", usage.Key, usage.Calls, usage.Remaining)
+//	}
+func (handler *GameHandler) APIKeyUsage() []apikeys.Usage {
+	if keys := handler.apiKeyPool(); keys != nil {
+		return keys.Tracker().Usage()
+	}
+	return handler.apiKeyTracker().Usage()
+}
+
+// refreshWithKey refreshes the game data of an account with key and records the refresh in
+// tracker, warning when the key starts running low.
+func (handler *GameHandler) refreshWithKey(ctx context.Context, client *httpclient.HTTPClient, proxy types.Proxy, account types.Account, key apikeys.Key, tracker *apikeys.Tracker) error {
+	value, err := key.Secret()
+	if err != nil {
+		return err
+	}
+	telegram, err := handler.telegramData(account)
+	if err != nil {
+		return err
+	}
+	_, header, err := refreshGameData(ctx, client, handler.nexusAPIURL(), handler.GameName, value, telegram, proxy)
+	usage, low := tracker.Record(key.Name, header, errors.Is(err, apikeys.ErrQuotaExceeded))
+	if low {
+		utils.ModuleLogger("handler").Warn("API key quota running low",
+			zap.String("game", handler.GameName), zap.String("key", key.Name),
+			zap.Int("remaining", usage.Remaining), zap.Int("limit", usage.Limit), zap.Time("reset", usage.Reset))
+	}
+	return err
+}
@@ -1,15 +1,24 @@
 package handler
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/nexus-telegram/NexusSDK/apikeys"
 	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/secrets"
 	"github.com/nexus-telegram/NexusSDK/types"
 	"github.com/nexus-telegram/NexusSDK/utils"
 	"go.uber.org/zap"
 	"io"
+	"net/http"
 )
 
+// DefaultNexusAPIURL is the base URL of the Nexus API used to refresh game data.
+const DefaultNexusAPIURL = "http://34.95.182.203:1337/api"
+
 type GameDataRequest struct {
 	Game     string             `json:"game"`
 	Telegram types.TelegramData `json:"telegram"`
@@ -17,8 +26,9 @@ type GameDataRequest struct {
 	Proxy    types.Proxy        `json:"proxy"`
 }
 
-func refreshGameData(client *httpclient.HTTPClient, game string, apiKey string, telegram types.TelegramData, proxyConfig types.Proxy) ([]byte, error) {
-	var nexusApiBaseURL = "http://34.95.182.203:1337/api"
+// refreshGameData requests fresh game data for an account from the Nexus API, and returns the
+// body and headers of the response.
+func refreshGameData(ctx context.Context, client *httpclient.HTTPClient, nexusApiBaseURL string, game string, apiKey string, telegram types.TelegramData, proxyConfig types.Proxy) ([]byte, http.Header, error) {
 	url := fmt.Sprintf("%s/telegram/game-data", nexusApiBaseURL)
 	requestBody := GameDataRequest{
 		Game:     game,
@@ -28,13 +38,18 @@ func refreshGameData(client *httpclient.HTTPClient, game string, apiKey string,
 	}
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
-		log := utils.GetLogger()
+		log := utils.ModuleLogger("handler")
 		log.Error("Failed to marshal request body", zap.Error(err))
-		return nil, err
+		return nil, nil, err
+	}
+	defer secrets.Wipe(jsonData)
+	resp, err := client.PostContext(ctx, url, jsonData)
+	var status *httpclient.StatusError
+	if errors.As(err, &status) && quotaExceeded(status.StatusCode, []byte(status.Body)) {
+		return nil, nil, fmt.Errorf("%w: status %d: %w", apikeys.ErrQuotaExceeded, status.StatusCode, err)
 	}
-	resp, err := client.Post(url, jsonData)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -43,7 +58,20 @@ func refreshGameData(client *httpclient.HTTPClient, game string, apiKey string,
 	}(resp.Body)
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, resp.Header, err
+	}
+	return body, resp.Header, nil
+}
+
+// quotaExceeded reports whether a response of the Nexus API refuses the API key because its
+// quota is used up.
+func quotaExceeded(status int, body []byte) bool {
+	switch {
+	case status == http.StatusTooManyRequests || status == http.StatusPaymentRequired:
+		return true
+	case status >= http.StatusBadRequest:
+		return bytes.Contains(bytes.ToLower(body), []byte("quota"))
+	default:
+		return false
 	}
-	return body, nil
 }
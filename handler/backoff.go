@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"github.com/nexus-telegram/NexusSDK/jobqueue"
+	"github.com/nexus-telegram/NexusSDK/tasks"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// SetIntervalBackoff sets the interval backoff of the scheduled tasks of the handler: the
+// interval of a task failing for an account several times in a row is stretched, up to a cap,
+// and restored after its next success. Tasks with their own backoff (see tasks.BackingOff) use
+// it instead. Passing nil disables the backoff of the other tasks.
+//
+// Handlers created by NewGameHandler use the interval_backoff section of their configuration.
+//
+// # Example:
+//
+//	gameHandler.SetIntervalBackoff(&tasks.IntervalBackoff{Factor: 2, Max: 6 * time.Hour})
+func (handler *GameHandler) SetIntervalBackoff(backoff *tasks.IntervalBackoff) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.backoff = backoff
+}
+
+// newIntervalBackoff creates the interval backoff of the interval_backoff section of the
+// configuration file, or returns nil when it is disabled.
+func newIntervalBackoff(config types.BackoffConfig) *tasks.IntervalBackoff {
+	if config.Factor <= 1 {
+		return nil
+	}
+	return tasks.NewIntervalBackoff(&config)
+}
+
+// intervalBackoff returns the interval backoff of a task, or nil if it has none.
+func (handler *GameHandler) intervalBackoff(task tasks.Task) *tasks.IntervalBackoff {
+	if backingOff, ok := task.(tasks.BackingOff); ok {
+		if backoff := backingOff.FailureBackoff(); backoff != nil {
+			return backoff
+		}
+	}
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return handler.backoff
+}
+
+// recordStreak counts the failures in a row of a task for an account, and logs when its
+// interval starts being stretched and when it is restored.
+func (handler *GameHandler) recordStreak(account types.Account, task tasks.Task, err error) {
+	backoff := handler.intervalBackoff(task)
+	id := jobqueue.JobID(handler.GameName, account.TelegramData.TelegramId, taskName(task))
+	handler.mu.Lock()
+	failures := handler.streaks[id]
+	if err == nil {
+		delete(handler.streaks, id)
+	} else {
+		if handler.streaks == nil {
+			handler.streaks = make(map[string]int)
+		}
+		handler.streaks[id] = failures + 1
+	}
+	handler.mu.Unlock()
+	if backoff == nil {
+		return
+	}
+	logger := utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, account)
+	switch {
+	case err == nil && backoff.Stretches(failures):
+		logger.Info("Task succeeded, interval restored", zap.String("task", taskName(task)), zap.Int("failures", failures))
+	case err != nil && !backoff.Stretches(failures) && backoff.Stretches(failures+1):
+		logger.Warn("Task keeps failing, stretching its interval", zap.String("task", taskName(task)), zap.Int("failures", failures+1))
+	}
+}
+
+// stretchInterval stretches the interval between last and the next run of a scheduled task by
+// the interval backoff of the task, after the failures in a row of the task for the account.
+func (handler *GameHandler) stretchInterval(id string, task tasks.Task, last, next time.Time) time.Time {
+	if last.IsZero() || !next.After(last) {
+		return next
+	}
+	backoff := handler.intervalBackoff(task)
+	if backoff == nil {
+		return next
+	}
+	handler.mu.Lock()
+	failures := handler.streaks[id]
+	handler.mu.Unlock()
+	return last.Add(backoff.Stretch(next.Sub(last), failures))
+}
+
+// forgetStreaks drops the failure counts of the tasks of an account.
+func (handler *GameHandler) forgetStreaks(telegramId string) {
+	prefix := jobqueue.JobID(handler.GameName, telegramId, "")
+	for id := range handler.streaks {
+		if strings.HasPrefix(id, prefix) {
+			delete(handler.streaks, id)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"sort"
+	"time"
+)
+
+// maxBalanceSamples is the number of balances kept per account.
+const maxBalanceSamples = 256
+
+// balanceSample is a balance of an account at a point in time.
+type balanceSample struct {
+	at      time.Time
+	balance float64
+}
+
+// BalanceChange is the change of the balance of an account over a period.
+//
+// # Fields:
+//   - Account: The Telegram ID of the account.
+//   - Start: The balance at the start of the period, or the first balance recorded during it.
+//   - End: The last balance recorded before the end of the period.
+//   - Delta: End minus Start.
+type BalanceChange struct {
+	Account string  `json:"account"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Delta   float64 `json:"delta"`
+}
+
+// RecordBalance records the current in-game balance of an account, typically from the response
+// of a claim or profile request. The balances are used to compute the balance changes of the
+// daily summary report (see BalanceChanges).
+//
+// # Parameters:
+//   - telegramId: The Telegram ID of the account.
+//   - balance: The balance of the account.
+//
+// # Example:
+//
+//	var profile Profile
+//	if err := json.Unmarshal(body, &profile); err == nil {
+//		gameHandler.RecordBalance(account.TelegramData.TelegramId, profile.Balance)
+//	}
+func (handler *GameHandler) RecordBalance(telegramId string, balance float64) {
+	now := handler.getClock().Now()
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.balances == nil {
+		handler.balances = make(map[string][]balanceSample)
+	}
+	samples := append(handler.balances[telegramId], balanceSample{at: now, balance: balance})
+	if len(samples) > maxBalanceSamples {
+		samples = samples[len(samples)-maxBalanceSamples:]
+	}
+	handler.balances[telegramId] = samples
+}
+
+// BalanceChanges returns the balance change of every account with a balance recorded before to,
+// sorted by Telegram ID.
+//
+// The balance at from is the last one recorded before it; accounts without one start from
+// their first balance recorded during the period. Accounts without any balance recorded
+// before to are left out.
+//
+// # Parameters:
+//   - from: The start of the period.
+//   - to: The end of the period, excluded.
+func (handler *GameHandler) BalanceChanges(from, to time.Time) []BalanceChange {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	var changes []BalanceChange
+	for account, samples := range handler.balances {
+		var start, end *balanceSample
+		for i := range samples {
+			sample := &samples[i]
+			if !sample.at.Before(to) {
+				break
+			}
+			if start == nil || sample.at.Before(from) {
+				start = sample
+			}
+			end = sample
+		}
+		if end == nil {
+			continue
+		}
+		changes = append(changes, BalanceChange{Account: account, Start: start.balance, End: end.balance, Delta: end.balance - start.balance})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Account < changes[j].Account })
+	return changes
+}
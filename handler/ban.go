@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/bandetect"
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/metrics"
+	"github.com/nexus-telegram/NexusSDK/sink"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// DefaultCooldown is how long an account showing a ban signal stops running tasks, unless a
+// CooldownPolicy sets another duration.
+const DefaultCooldown = 12 * time.Hour
+
+// Cooldown is the state of an account that stopped running tasks after a ban signal, or after
+// exceeding its error budget (see SetErrorBudget).
+//
+// # Fields:
+//   - Account: The Telegram ID of the account.
+//   - Signal: The ban signal that started the cooldown.
+//   - Since: When the cooldown started.
+//   - Until: When the account runs tasks again.
+//   - Signals: The number of ban signals seen since the cooldown started.
+//   - Probes: The number of failed probes of the account (see CooldownPolicy).
+//   - NextProbe: When the account is probed next, if the CooldownPolicy probes accounts.
+type Cooldown struct {
+	Account   string           `json:"account"`
+	Signal    bandetect.Signal `json:"signal"`
+	Since     time.Time        `json:"since"`
+	Until     time.Time        `json:"until"`
+	Signals   int              `json:"signals"`
+	Probes    int              `json:"probes,omitempty"`
+	NextProbe time.Time        `json:"next_probe,omitempty"`
+}
+
+// SetBanDetector enables ban detection for the accounts of the handler. Passing nil disables it.
+//
+// Every response received by the tasks of an account, and every task failure, is matched
+// against the detector. When a ban signal is recognized, the account is put in cooldown: its
+// task executions are skipped until the cooldown ends (see SetCooldownPolicy).
+//
+// # Parameters:
+//   - detector: The ban detector, see bandetect.New.
+func (handler *GameHandler) SetBanDetector(detector *bandetect.Detector) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.bans = detector
+}
+
+// banDetector returns the ban detector of the handler, or nil if none is set.
+func (handler *GameHandler) banDetector() *bandetect.Detector {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return handler.bans
+}
+
+// Cooldowns returns the accounts of the handler currently in cooldown.
+func (handler *GameHandler) Cooldowns() []Cooldown {
+	now := handler.getClock().Now()
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	var cooldowns []Cooldown
+	for _, account := range handler.Accounts {
+		if cooldown, ok := handler.cooldowns[account.TelegramData.TelegramId]; ok && now.Before(cooldown.Until) {
+			cooldowns = append(cooldowns, cooldown)
+		}
+	}
+	return cooldowns
+}
+
+// ClearCooldown ends the cooldown of an account, so its tasks run again.
+//
+// # Parameters:
+//   - telegramId: The Telegram ID of the account.
+func (handler *GameHandler) ClearCooldown(telegramId string) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	delete(handler.cooldowns, telegramId)
+}
+
+// coolingDown returns the cooldown of an account, if it is in one at now.
+func (handler *GameHandler) coolingDown(telegramId string, now time.Time) (Cooldown, bool) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	cooldown, ok := handler.cooldowns[telegramId]
+	if ok && !now.Before(cooldown.Until) {
+		delete(handler.cooldowns, telegramId)
+		return Cooldown{}, false
+	}
+	return cooldown, ok
+}
+
+// inspectResponse looks for a ban signal in a response received by an account.
+func (handler *GameHandler) inspectResponse(account types.Account, body []byte, err error) {
+	detector := handler.banDetector()
+	if detector == nil {
+		return
+	}
+	var signal bandetect.Signal
+	var found bool
+	var statusErr *httpclient.StatusError
+	switch {
+	case err == nil:
+		signal, found = detector.Inspect(http.StatusOK, body)
+	case errors.As(err, &statusErr):
+		signal, found = detector.Inspect(statusErr.StatusCode, []byte(statusErr.Body))
+	}
+	if found {
+		handler.startCooldown(account, signal)
+	}
+}
+
+// inspectTaskError looks for a ban signal in the outcome of a task execution.
+func (handler *GameHandler) inspectTaskError(account types.Account, err error) {
+	detector := handler.banDetector()
+	if detector == nil {
+		return
+	}
+	if signal, found := detector.InspectError(account.TelegramData.TelegramId, err); found {
+		handler.startCooldown(account, signal)
+	}
+}
+
+// startCooldown puts an account in cooldown after a ban signal, extending its current cooldown
+// if it already is in one.
+func (handler *GameHandler) startCooldown(account types.Account, signal bandetect.Signal) {
+	now := handler.getClock().Now()
+	id := account.TelegramData.TelegramId
+	handler.mu.Lock()
+	if handler.cooldowns == nil {
+		handler.cooldowns = make(map[string]Cooldown)
+	}
+	cooldown, ok := handler.cooldowns[id]
+	if !ok || !now.Before(cooldown.Until) {
+		cooldown = Cooldown{Account: id, Since: now}
+	}
+	policy := handler.recovery
+	cooldown.Signal = signal
+	cooldown.Signals++
+	cooldown.Until = now.Add(policy.duration(signal.Rule))
+	if policy.probing() {
+		cooldown.NextProbe = now.Add(policy.ProbeAfter)
+	}
+	handler.cooldowns[id] = cooldown
+	handler.mu.Unlock()
+	utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, account).Warn("Ban signal detected, account cooling down",
+		zap.String("signal", signal.Rule), zap.Int("status", signal.Status), zap.Time("until", cooldown.Until))
+	_ = metrics.DefaultRegistry.Add("nexus_account_cooldowns_total", "Cooldowns started by ban signals.",
+		handler.MetricLabels(&account).With("signal", signal.Rule), 1)
+	handler.publishEvent(sink.Event{
+		Type:    sink.EventAccountCooldown,
+		Time:    now,
+		Account: id,
+		Data:    map[string]interface{}{"signal": signal.Rule, "status": signal.Status, "until": cooldown.Until},
+	})
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils/clock"
+	"golang.org/x/net/context"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExhausted is returned for requests refused by a Budget in skip mode.
+var ErrBudgetExhausted = errors.New("request budget exhausted")
+
+// Budget is a farm-wide cap on the number of requests per hour and the proxy bandwidth per day.
+//
+// A Budget is shared by every client of the handlers it is applied to (see Manager.SetBudget
+// and GameHandler.SetBudget). When the budget is exhausted, requests are either delayed until
+// the hour or day window resets, or refused with ErrBudgetExhausted when Skip is set, in which
+// case the scheduler skips the task execution.
+//
+// # Fields:
+//   - MaxRequestsPerHour: The maximum number of requests per clock hour. Zero means unlimited.
+//   - MaxBytesPerDay: The maximum number of bytes sent and received per UTC day. Zero means unlimited.
+//   - Skip: Whether requests are refused instead of delayed when the budget is exhausted.
+//   - Clock: The clock measuring the windows. Defaults to clock.Real.
+//   - mu: A mutex guarding the counters.
+//   - hour, requests: The current hour window and its request count.
+//   - day, bytes: The current day window and its byte count.
+//
+// # Example:
+//
+//	budget := handler.NewBudget(types.BudgetConfig{MaxRequestsPerHour: 5000, MaxBandwidthMBPerDay: 2048})
+//	manager := handler.NewManager(hamsterHandler, notcoinHandler)
+//	manager.SetBudget(budget)
+type Budget struct {
+	MaxRequestsPerHour int
+	MaxBytesPerDay     int64
+	Skip               bool
+	Clock              clock.Clock
+	mu                 sync.Mutex
+	hour               time.Time
+	requests           int
+	day                time.Time
+	bytes              int64
+}
+
+// BudgetUsage is a snapshot of the consumption of a Budget in its current windows.
+type BudgetUsage struct {
+	Requests           int   `json:"requests"`
+	MaxRequestsPerHour int   `json:"max_requests_per_hour"`
+	Bytes              int64 `json:"bytes"`
+	MaxBytesPerDay     int64 `json:"max_bytes_per_day"`
+}
+
+// NewBudget creates a budget from its configuration.
+func NewBudget(config types.BudgetConfig) *Budget {
+	return &Budget{
+		MaxRequestsPerHour: config.MaxRequestsPerHour,
+		MaxBytesPerDay:     int64(config.MaxBandwidthMBPerDay) * 1024 * 1024,
+		Skip:               config.Mode == "skip",
+	}
+}
+
+// Wait reserves one request, waiting for the budget to reset if it is exhausted.
+//
+// In skip mode, Wait returns ErrBudgetExhausted instead of waiting. It implements httpclient.Limiter.
+func (budget *Budget) Wait(ctx context.Context) error {
+	for {
+		resets, ok := budget.reserve()
+		if ok {
+			return nil
+		}
+		if budget.Skip {
+			return ErrBudgetExhausted
+		}
+		timer := budget.clock().NewTimer(resets.Sub(budget.clock().Now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		}
+	}
+}
+
+// Done records the bytes sent and received by a request. It implements httpclient.Limiter.
+func (budget *Budget) Done(bytes int64) {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+	budget.roll(budget.clock().Now())
+	budget.bytes += bytes
+}
+
+// Exhausted reports whether the budget currently refuses requests, and when it resets.
+func (budget *Budget) Exhausted() (bool, time.Time) {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+	resets, ok := budget.available(budget.clock().Now())
+	return !ok, resets
+}
+
+// Usage returns the consumption of the budget in its current windows.
+func (budget *Budget) Usage() BudgetUsage {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+	budget.roll(budget.clock().Now())
+	return BudgetUsage{
+		Requests:           budget.requests,
+		MaxRequestsPerHour: budget.MaxRequestsPerHour,
+		Bytes:              budget.bytes,
+		MaxBytesPerDay:     budget.MaxBytesPerDay,
+	}
+}
+
+// reserve counts one request if the budget allows it, or returns when the budget resets.
+func (budget *Budget) reserve() (time.Time, bool) {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+	resets, ok := budget.available(budget.clock().Now())
+	if ok {
+		budget.requests++
+	}
+	return resets, ok
+}
+
+// available reports whether a request may be sent at now, or when the budget resets. It must be
+// called with mu held.
+func (budget *Budget) available(now time.Time) (time.Time, bool) {
+	budget.roll(now)
+	if budget.MaxBytesPerDay > 0 && budget.bytes >= budget.MaxBytesPerDay {
+		return budget.day.Add(24 * time.Hour), false
+	}
+	if budget.MaxRequestsPerHour > 0 && budget.requests >= budget.MaxRequestsPerHour {
+		return budget.hour.Add(time.Hour), false
+	}
+	return now, true
+}
+
+// roll starts new windows when the hour or day of now differs from the current ones. It must be
+// called with mu held.
+func (budget *Budget) roll(now time.Time) {
+	now = now.UTC()
+	if hour := now.Truncate(time.Hour); !hour.Equal(budget.hour) {
+		budget.hour = hour
+		budget.requests = 0
+	}
+	if day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC); !day.Equal(budget.day) {
+		budget.day = day
+		budget.bytes = 0
+	}
+}
+
+// limiter returns the budget as an httpclient.Limiter, or nil for a nil budget.
+func (budget *Budget) limiter() httpclient.Limiter {
+	if budget == nil {
+		return nil
+	}
+	return budget
+}
+
+func (budget *Budget) clock() clock.Clock {
+	if budget.Clock == nil {
+		return clock.Real
+	}
+	return budget.Clock
+}
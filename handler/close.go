@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"io"
+	"time"
+)
+
+// closeTimeout bounds the state save of Close.
+const closeTimeout = 30 * time.Second
+
+// Close releases the resources of the handler, so that applications embedding the SDK can
+// start and stop handlers repeatedly without leaking connections, goroutines, or files.
+//
+// Close drains the handler (see Drain), which stops its schedulers and their tickers, then:
+//   - saves its state to its storage, if any (see SaveState);
+//   - closes its idempotency guard, task history, job queue, leader elector, and storage, those
+//     that can be closed, its audit log, and its event sink, publishing the buffered events;
+//   - closes its HTTP clients, the clients of the proxy pool included (see
+//     httpclient.HTTPClient.Close);
+//   - releases the proxies of its accounts in the proxy pool (see proxypool.Pool.Release);
+//   - wipes the key of its encrypted secrets (see SetSecretMemory);
+//   - removes its gauges from metrics.DefaultRegistry (see RegisterGauge).
+//
+// A closed handler runs no more tasks and its requests fail; create a new handler to start
+// again. Closing a closed handler does nothing.
+//
+// # Returns:
+//   - error: The errors of the steps that failed, joined. Every step runs regardless.
+//
+// # Example:
+//
+//	gameHandler, err := handler.NewGameHandler("config.json", "accounts.json")
+//	if err != nil {
+//		return err
+//	}
+//	defer gameHandler.Close()
+//
+// # Notes:
+//   - The storage, guard, history, queue, audit log, and event sink given through setters are
+//     closed too: do not share them between handlers closed independently.
+func (handler *GameHandler) Close() error {
+	handler.mu.Lock()
+	if handler.closed {
+		handler.mu.Unlock()
+		return nil
+	}
+	handler.closed = true
+	handler.mu.Unlock()
+	handler.Drain()
+
+	var errs []error
+	if handler.storageBackend() != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+		if err := handler.SaveState(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save state: %w", err))
+		}
+		cancel()
+	}
+
+	errs = append(errs, handler.release()...)
+	handler.GetLogger().Info("Handler closed")
+	return errors.Join(errs...)
+}
+
+// release closes the resources of the handler, its HTTP clients, its proxies, its secrets key,
+// and its gauges, the steps of Close after the state is saved. NewGameHandler calls it alone
+// when the configuration fails part way, so that the resources already opened do not leak.
+func (handler *GameHandler) release() []error {
+	handler.mu.Lock()
+	resources := []interface{}{handler.guard, handler.history, handler.queue, handler.elector, handler.backend, handler.auditLog, handler.events}
+	clients := make([]*httpclient.HTTPClient, 0, len(handler.clients)+1)
+	if handler.HttpClient != nil {
+		clients = append(clients, handler.HttpClient)
+	}
+	for key, client := range handler.clients {
+		clients = append(clients, client)
+		delete(handler.clients, key)
+	}
+	pool, sealer := handler.ProxyPool, handler.sealer
+	accounts := append([]types.Account(nil), handler.Accounts...)
+	handler.mu.Unlock()
+
+	var errs []error
+	for _, resource := range resources {
+		if closer, ok := resource.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for _, client := range clients {
+		if err := client.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if pool != nil {
+		for _, account := range accounts {
+			pool.Release(account.TelegramData.TelegramId)
+		}
+	}
+	if sealer != nil {
+		sealer.Close()
+	}
+	handler.unregisterGauges()
+	return errs
+}
@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/nexus-telegram/NexusSDK/types"
+	"io"
 	"os"
+	"strings"
 )
 
 // LoadConfig reads the configuration file (config.json) from the specified file path
@@ -13,7 +17,8 @@ import (
 // such as proxy details and API key, are properly loaded and ready for use.
 //
 // # Parameters:
-//   - filePath: The path to the configuration file (e.g., "config.json").
+//   - filePath: The path to the configuration file (e.g., "config.json"), or a remote
+//     location (https://, s3://, or gs:// URL, see SetRemoteOptions).
 //
 // # Returns:
 //   - types.Config: A struct containing the parsed configuration data.
@@ -44,27 +49,152 @@ import (
 // # Notes:
 //   - Ensure the file at the specified path exists and is properly formatted as JSON.
 //   - If the file contains invalid JSON or cannot be accessed, an error is returned.
+//   - Remote locations let fleets of worker VMs pull centrally managed configuration at startup,
+//     e.g. LoadConfig("s3://farm-config/prod/config.json").
+//   - Secret references (e.g., "api_key": "vault:secret/data/nexus#api_key") in the API key,
+//     the proxy credentials, and the Telegram log bot token are resolved (see SetSecretResolver).
 func LoadConfig(filePath string) (types.Config, error) {
 	var config types.Config
-	file, err := os.Open(filePath)
+	file, err := openLocation(filePath)
 	if err != nil {
 		return config, err
 	}
-	defer func(file *os.File) {
+	defer func(file io.ReadCloser) {
 		err := file.Close()
 		if err != nil {
 
 		}
 	}(file)
 	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&config)
+	if err := decoder.Decode(&config); err != nil {
+		return config, err
+	}
+	err = resolveConfigSecrets(&config)
 	return config, err
 }
 
+// LoadConfigProfile reads the configuration file and applies a named profile on top of it.
+//
+// Profiles are declared under the "profiles" key of the configuration file. Each profile is
+// a partial configuration deep-merged over the base configuration: nested objects are merged
+// key by key, and other values replace the base value. A profile may extend other profiles
+// through its "extends" key, which are applied first, so one file can drive several
+// deployments (dev/staging/prod) or per-game overrides.
+//
+// # Parameters:
+//   - filePath: The path or remote location of the configuration file (e.g., "config.json").
+//   - profile: The name of the profile to apply, or "" for the base configuration.
+//
+// # Returns:
+//   - types.Config: The merged configuration.
+//   - error: An error if the file cannot be read or parsed, or the profile does not exist.
+//
+// # Example config.json:
+//
+//	{
+//		"proxy": {"ip": "192.168.1.100", "port": 1080, "socksType": 5},
+//		"api_key": "your-api-key-here",
+//		"profiles": {
+//			"prod": {
+//				"log": {"level": "warn", "file": {"path": "/var/log/farm.log"}}
+//			},
+//			"prod-hamster": {
+//				"extends": "prod",
+//				"proxy": {"ip": "192.168.1.200"}
+//			}
+//		}
+//	}
+//
+// # Example Usage:
+//
+//	config, err := LoadConfigProfile("config.json", "prod-hamster")
+//	if err != nil {
+//		log.Fatalf("Failed to load configuration: %v", err)
+//	}
+//	fmt.Println(config.Proxy.Ip, config.Proxy.Port) // Output: 192.168.1.200 1080
+func LoadConfigProfile(filePath, profile string) (types.Config, error) {
+	var config types.Config
+	file, err := openLocation(filePath)
+	if err != nil {
+		return config, err
+	}
+	data, err := io.ReadAll(file)
+	_ = file.Close()
+	if err != nil {
+		return config, err
+	}
+	// Numbers are kept as written, so that large integers such as Telegram IDs survive the
+	// round trip through the merged map instead of being rounded to float64.
+	var raw map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return config, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return config, fmt.Errorf("invalid character after top-level value in %s", filePath)
+	}
+	profiles, _ := raw["profiles"].(map[string]interface{})
+	delete(raw, "profiles")
+	if profile != "" {
+		layers, err := resolveProfile(profiles, profile, nil)
+		if err != nil {
+			return config, err
+		}
+		for _, layer := range layers {
+			raw = deepMerge(raw, layer)
+		}
+	}
+	merged, err := json.Marshal(raw)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(merged, &config); err != nil {
+		return config, err
+	}
+	err = resolveConfigSecrets(&config)
+	return config, err
+}
+
+// resolveProfile returns the layers of a profile in application order, parents first.
+func resolveProfile(profiles map[string]interface{}, name string, visiting []string) ([]map[string]interface{}, error) {
+	for _, visited := range visiting {
+		if visited == name {
+			return nil, fmt.Errorf("config profile cycle: %s -> %s", strings.Join(visiting, " -> "), name)
+		}
+	}
+	profile, ok := profiles[name].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config profile not found: %s", name)
+	}
+	profile = deepCopy(profile).(map[string]interface{})
+	var parents []string
+	switch extends := profile["extends"].(type) {
+	case string:
+		parents = []string{extends}
+	case []interface{}:
+		for _, parent := range extends {
+			if parentName, ok := parent.(string); ok {
+				parents = append(parents, parentName)
+			}
+		}
+	}
+	delete(profile, "extends")
+	var layers []map[string]interface{}
+	for _, parent := range parents {
+		parentLayers, err := resolveProfile(profiles, parent, append(visiting, name))
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, parentLayers...)
+	}
+	return append(layers, profile), nil
+}
+
 // LoadAccounts reads the accounts.json file and parses it into a slice of Account structs.
 //
 // # Parameters:
-//   - filePath: The path to the accounts.json file.
+//   - filePath: The path to the accounts.json file, or a remote location (see SetRemoteOptions).
 //
 // # Returns:
 //   - []types.Account: A slice of Account structs parsed from the file.
@@ -100,43 +230,91 @@ func LoadConfig(filePath string) (types.Config, error) {
 //		log.Fatalf("Failed to load accounts: %v", err)
 //	}
 //	fmt.Println(accounts[0].GameData) // Output: user=%7B%22id%22%3A78894796...
+//
+// # Notes:
+//   - Secret references in "tdataStringSession" and "appHash" (e.g., "awssm:nexus/sessions#987654321")
+//     are resolved (see SetSecretResolver).
+//   - The wallet addresses of the accounts are checked (see types.Wallet.Validate).
+//   - The accounts are decoded one at a time (see StreamAccounts); for files too large to
+//     hold in memory, see OpenLazyAccounts.
 func LoadAccounts(filePath string) ([]types.Account, error) {
 	var accounts []types.Account
-	file, err := os.Open(filePath)
+	err := StreamAccounts(filePath, func(account types.Account) error {
+		accounts = append(accounts, account)
+		return nil
+	})
+	return accounts, err
+}
+
+// SaveAccounts writes accounts to an accounts.json file, replacing its previous content.
+//
+// The file is written to a temporary file first and renamed into place, so a crash while
+// saving never leaves a truncated accounts file behind.
+//
+// # Parameters:
+//   - filePath: The path to the accounts.json file.
+//   - accounts: The accounts to write.
+//
+// # Returns:
+//   - error: An error if the accounts cannot be encoded or the file cannot be written.
+//
+// # Example Usage:
+//
+//	file, err := os.Open("accounts.csv")
+//	if err != nil {
+//		log.Fatalf("Failed to open CSV: %v", err)
+//	}
+//	accounts, err := importer.FromCSV(file)
+//	if err != nil {
+//		log.Fatalf("Failed to import accounts: %v", err)
+//	}
+//	if err := SaveAccounts("accounts.json", accounts); err != nil {
+//		log.Fatalf("Failed to save accounts: %v", err)
+//	}
+func SaveAccounts(filePath string, accounts []types.Account) error {
+	data, err := json.MarshalIndent(accounts, "", "\t")
 	if err != nil {
-		return accounts, err
+		return err
 	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-		}
-	}(file)
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&accounts)
-	return accounts, err
+	temporaryPath := filePath + ".tmp"
+	if err := os.WriteFile(temporaryPath, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, filePath)
 }
 
 // LoadTasks reads the tasks.json file and parses it into a TaskCollection struct.
 //
+// Tasks extending a template are returned with the template applied (see
+// types.TaskCollection.ResolveTemplates). Files of an older version are upgraded to
+// types.TasksVersion first (see MigrateTasks), with a warning listing the migrations applied.
+// The files listed in "include" are merged before the tasks of the file (see
+// types.TaskCollection.Merge and LoadTaskDir).
+//
 // # Parameters:
-//   - filePath: The path to the tasks.json file.
+//   - filePath: The path to the tasks.json file, or a remote location (see SetRemoteOptions).
 //
 // # Returns:
 //   - types.TaskCollection: A struct containing the parsed task data.
-//   - error: An error if the file cannot be opened, read, or parsed.
+//   - error: An error if the file or an included file cannot be opened, read, or parsed, files
+//     include each other in a cycle, a task extends an unknown template, or the file is newer
+//     than the SDK.
 //
 // # Example tasks.json:
 //
 //	{
-//		"tasks": [
+//		"version": 2,
+//		"one_time_tasks": [
 //			{
 //				"name": "Task 1",
-//				"payload": "Payload for Task 1"
-//			},
+//				"payload": {"quest_id": 1}
+//			}
+//		],
+//		"recurrent_tasks": [
 //			{
 //				"name": "Task 2",
-//				"payload": "Payload for Task 2",
-//				"interval": "Interval for Task 2"
+//				"payload": {"quest_id": 2},
+//				"interval_minutes": 30
 //			}
 //		]
 //	}
@@ -147,20 +325,27 @@ func LoadAccounts(filePath string) ([]types.Account, error) {
 //	if err != nil {
 //		log.Fatalf("Failed to load tasks: %v", err)
 //	}
-//	fmt.Println(tasks.Tasks[0].Name) // Output: Task 1
+//	fmt.Println(tasks.OneTimeTasks[0].Name) // Output: Task 1
+//
+// # Example tasks.json with templates:
+//
+//	{
+//		"templates": {
+//			"quest": {
+//				"payload": {"type": "quest", "meta": {"source": "farm"}},
+//				"headers": {"X-Requested-With": "org.telegram.messenger"},
+//				"retry": {"max_attempts": 3, "min_delay_ms": 500}
+//			}
+//		},
+//		"one_time_tasks": [
+//			{"name": "Join channel", "extends": "quest", "payload": {"quest_id": 12}},
+//			{"name": "Follow X", "extends": "quest", "payload": {"quest_id": 13, "meta": {"source": null}}}
+//		]
+//	}
 func LoadTasks(filePath string) (types.TaskCollection, error) {
-	var tasks types.TaskCollection
-	file, err := os.Open(filePath)
+	tasks, err := loadTaskFile(filePath, nil)
 	if err != nil {
 		return tasks, err
 	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-
-		}
-	}(file)
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&tasks)
-	return tasks, err
+	return tasks, tasks.ResolveTemplates()
 }
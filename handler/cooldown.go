@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/tasks"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+	"time"
+)
+
+// CooldownPolicy configures how long accounts stay in cooldown after a ban signal and how they
+// are resumed.
+//
+// Without a probe, an account resumes when its cooldown duration is over. With ProbeAfter and
+// Probe set, the next task execution of the account after ProbeAfter runs Probe first: if it
+// succeeds without a new ban signal the account is restored right away, otherwise its cooldown
+// restarts and the next probe is due ProbeAfter later.
+//
+// # Fields:
+//   - Duration: The cooldown after a ban signal. Defaults to DefaultCooldown.
+//   - Durations: The cooldown after the signals of specific rules, keyed by rule name.
+//   - ProbeAfter: How long after the start of a cooldown (or the last failed probe) the account
+//     is probed. Zero disables probing.
+//   - Probe: The light task run to probe the account, e.g. NewProbeTask.
+//
+// # Example:
+//
+//	handler.SetCooldownPolicy(&handler.CooldownPolicy{
+//		Duration:   24 * time.Hour,
+//		Durations:  map[string]time.Duration{"captcha": 2 * time.Hour},
+//		ProbeAfter: 6 * time.Hour,
+//		Probe:      handler.NewProbeTask("https://api.game.com/user/me"),
+//	})
+type CooldownPolicy struct {
+	Duration   time.Duration
+	Durations  map[string]time.Duration
+	ProbeAfter time.Duration
+	Probe      tasks.Task
+}
+
+// NewCooldownPolicy creates a cooldown policy from the cooldown section of the ban_detection
+// configuration.
+func NewCooldownPolicy(config types.CooldownConfig) *CooldownPolicy {
+	policy := &CooldownPolicy{
+		Duration:   time.Duration(config.Minutes) * time.Minute,
+		ProbeAfter: time.Duration(config.ProbeAfterMinutes) * time.Minute,
+	}
+	if len(config.SignalMinutes) > 0 {
+		policy.Durations = make(map[string]time.Duration, len(config.SignalMinutes))
+		for rule, minutes := range config.SignalMinutes {
+			policy.Durations[rule] = time.Duration(minutes) * time.Minute
+		}
+	}
+	if policy.ProbeAfter > 0 {
+		policy.Probe = NewProbeTask(config.ProbeURL)
+	}
+	return policy
+}
+
+// duration returns the cooldown after a signal of rule.
+func (policy *CooldownPolicy) duration(rule string) time.Duration {
+	if policy == nil {
+		return DefaultCooldown
+	}
+	if duration, ok := policy.Durations[rule]; ok && duration > 0 {
+		return duration
+	}
+	if policy.Duration > 0 {
+		return policy.Duration
+	}
+	return DefaultCooldown
+}
+
+// probing reports whether the policy resumes accounts by probing them.
+func (policy *CooldownPolicy) probing() bool {
+	return policy != nil && policy.ProbeAfter > 0 && policy.Probe != nil
+}
+
+// probeTask is a task sending a single GET request.
+type probeTask struct {
+	tasks.BaseTask
+	url string
+}
+
+// NewProbeTask returns a task sending a single GET request to url, resolved with
+// tasks.ResolveURL (the game's base URL when url is empty). It is meant as the Probe of a
+// CooldownPolicy.
+func NewProbeTask(url string) tasks.Task {
+	return &probeTask{BaseTask: tasks.BaseTask{Name: "probe"}, url: url}
+}
+
+// Run sends the probe request.
+func (task *probeTask) Run(account types.Account, handler tasks.Handler) error {
+	url, err := tasks.ResolveURL(handler, task.url)
+	if err != nil {
+		return fmt.Errorf("probe of account %s failed: %w", account.TelegramData.TelegramId, err)
+	}
+	if _, err := handler.Get(url); err != nil {
+		return fmt.Errorf("probe of account %s failed: %w", account.TelegramData.TelegramId, err)
+	}
+	return nil
+}
+
+// SetCooldownPolicy sets the cooldown durations and the resume policy of the accounts showing
+// ban signals (see SetBanDetector). Passing nil restores the default DefaultCooldown without probing.
+//
+// # Parameters:
+//   - policy: The cooldown policy.
+func (handler *GameHandler) SetCooldownPolicy(policy *CooldownPolicy) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.recovery = policy
+}
+
+// getCooldownPolicy returns the cooldown policy of the handler, or nil if none is set.
+func (handler *GameHandler) getCooldownPolicy() *CooldownPolicy {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return handler.recovery
+}
+
+// claimProbe reserves the due probe of an account in cooldown, so that concurrent executions of
+// the account do not probe it twice. It returns the cooldown as it was before the claim.
+func (handler *GameHandler) claimProbe(telegramId string, now time.Time) (Cooldown, bool) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	policy := handler.recovery
+	cooldown, ok := handler.cooldowns[telegramId]
+	if !ok || !policy.probing() || cooldown.NextProbe.IsZero() || now.Before(cooldown.NextProbe) {
+		return Cooldown{}, false
+	}
+	claimed := cooldown
+	claimed.NextProbe = now.Add(policy.ProbeAfter)
+	handler.cooldowns[telegramId] = claimed
+	return claimed, true
+}
+
+// probe runs the probe of an account in cooldown when it is due. It reports whether the account
+// was restored.
+func (handler *GameHandler) probe(account types.Account) bool {
+	id := account.TelegramData.TelegramId
+	claimed, ok := handler.claimProbe(id, handler.getClock().Now())
+	if !ok {
+		return false
+	}
+	policy := handler.getCooldownPolicy()
+	log := utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, account)
+	err := policy.Probe.Run(account, handler.newAccountHandler(context.Background(), account, policy.Probe, 1, ""))
+	now := handler.getClock().Now()
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	current, ok := handler.cooldowns[id]
+	if !ok {
+		return true
+	}
+	if err == nil && current.Signals == claimed.Signals {
+		delete(handler.cooldowns, id)
+		log.Info("Probe succeeded, account restored", zap.Int("probes", current.Probes+1))
+		return true
+	}
+	current.Probes++
+	current.Until = now.Add(policy.duration(current.Signal.Rule))
+	current.NextProbe = now.Add(policy.ProbeAfter)
+	handler.cooldowns[id] = current
+	log.Info("Probe failed, account stays in cooldown", zap.Int("probes", current.Probes),
+		zap.Time("next_probe", current.NextProbe), zap.Error(err))
+	return false
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/device"
+	"github.com/nexus-telegram/NexusSDK/remote"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+	"io"
+	"os"
+)
+
+// ensureDevices assigns a device profile to the accounts without one and, when the accounts
+// file is local, stores the new profiles in it so they survive changes to the generator.
+func ensureDevices(filePath string, accounts []types.Account) {
+	if !device.Ensure(accounts) || remote.IsRemote(filePath) {
+		return
+	}
+	if err := persistDevices(filePath, accounts); err != nil {
+		utils.ModuleLogger("handler").Warn("Failed to persist device profiles", zap.String("file", filePath), zap.Error(err))
+	}
+}
+
+// persistDevices adds the device profiles of accounts to the entries of the accounts file that
+// have none. The file is patched rather than rewritten from accounts, so secret references
+// resolved at load time are kept as references: it is streamed one entry at a time, the
+// entries that already have a profile are copied as they are, and the others get a "device"
+// field appended.
+func persistDevices(filePath string, accounts []types.Account) error {
+	profiles := make(map[string]*types.DeviceProfile, len(accounts))
+	for _, account := range accounts {
+		profiles[account.TelegramData.TelegramId] = account.Device
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	temporaryPath := filePath + ".tmp"
+	temporary, err := os.OpenFile(temporaryPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	changed, err := patchDevices(file, temporary, profiles)
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || !changed {
+		_ = os.Remove(temporaryPath)
+		return err
+	}
+	return os.Rename(temporaryPath, filePath)
+}
+
+// patchDevices copies the accounts array read from r to w, appending the profile of their
+// account to the entries without a "device" field, and reports whether an entry was patched.
+func patchDevices(r io.Reader, w io.Writer, profiles map[string]*types.DeviceProfile) (bool, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return false, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return false, errors.New("accounts file is not a JSON array")
+	}
+	out := bufio.NewWriter(w)
+	out.WriteString("[")
+	changed := false
+	for i := 0; decoder.More(); i++ {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return false, err
+		}
+		var entry struct {
+			Device   json.RawMessage `json:"device"`
+			Telegram struct {
+				TelegramId string `json:"telegramId"`
+			} `json:"telegram"`
+		}
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return false, err
+		}
+		if profile := profiles[entry.Telegram.TelegramId]; entry.Device == nil && profile != nil {
+			device, err := json.MarshalIndent(profile, "\t\t", "\t")
+			if err != nil {
+				return false, err
+			}
+			end := bytes.LastIndexByte(raw, '}')
+			body := bytes.TrimRight(raw[:end], " \t\r\n")
+			patched := append(append([]byte(nil), body...), ",\n\t\t\"device\": "...)
+			raw = append(append(patched, device...), "\n\t}"...)
+			changed = true
+		}
+		if i > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString("\n\t")
+		out.Write(raw)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return false, err
+	}
+	out.WriteString("\n]\n")
+	return changed, out.Flush()
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPersistDevices(t *testing.T) {
+	profile := &types.DeviceProfile{Platform: "Android", PlatformVersion: "14", Model: "Pixel 8", Language: "en-US", UserAgent: "Mozilla/5.0"}
+	accounts := []types.Account{
+		{TelegramData: types.TelegramData{TelegramId: "1"}, Device: profile},
+		{TelegramData: types.TelegramData{TelegramId: "2"}, Device: profile},
+	}
+	tests := []struct {
+		name        string
+		file        string
+		wantChanged bool
+		wantDevices map[string]string
+	}{
+		{
+			name:        "indented",
+			file:        "[\n\t{\n\t\t\"game-data\": \"a\",\n\t\t\"telegram\": {\"telegramId\": \"1\"},\n\t\t\"referral_id\": 9007199254740993\n\t}\n]\n",
+			wantChanged: true,
+			wantDevices: map[string]string{"1": "Pixel 8"},
+		},
+		{
+			name:        "compact",
+			file:        `[{"telegram":{"telegramId":"1"},"game-data":"a"},{"telegram":{"telegramId":"2"},"stats":{"points":123456789012345678901234567890}}]`,
+			wantChanged: true,
+			wantDevices: map[string]string{"1": "Pixel 8", "2": "Pixel 8"},
+		},
+		{
+			name:        "existing profile kept",
+			file:        `[{"telegram":{"telegramId":"1"},"device":{"model":"iPhone"}},{"telegram":{"telegramId":"2"},"device":null}]`,
+			wantDevices: map[string]string{"1": "iPhone", "2": ""},
+		},
+		{
+			name:        "unknown account",
+			file:        `[{"telegram":{"telegramId":"3"},"ids":[9007199254740993]}]`,
+			wantDevices: map[string]string{"3": ""},
+		},
+		{
+			name: "empty",
+			file: `[]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "accounts.json")
+			if err := os.WriteFile(path, []byte(test.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := persistDevices(path, accounts); err != nil {
+				t.Fatalf("persistDevices failed: %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed := string(data) != test.file; changed != test.wantChanged {
+				t.Fatalf("file changed: %t, want %t:\n%s", changed, test.wantChanged, data)
+			}
+			for _, number := range []string{"9007199254740993", "123456789012345678901234567890"} {
+				if strings.Contains(test.file, number) && !strings.Contains(string(data), number) {
+					t.Errorf("number %s not kept as it was:\n%s", number, data)
+				}
+			}
+			var entries []struct {
+				Telegram struct {
+					TelegramId string `json:"telegramId"`
+				} `json:"telegram"`
+				Device *types.DeviceProfile `json:"device"`
+			}
+			if err := json.Unmarshal(data, &entries); err != nil {
+				t.Fatalf("patched file is not valid JSON: %v\n%s", err, data)
+			}
+			devices := make(map[string]string)
+			for _, entry := range entries {
+				devices[entry.Telegram.TelegramId] = ""
+				if entry.Device != nil {
+					devices[entry.Telegram.TelegramId] = entry.Device.Model
+				}
+			}
+			for id, want := range test.wantDevices {
+				if devices[id] != want {
+					t.Errorf("account %s has device %q, want %q", id, devices[id], want)
+				}
+			}
+			if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+				t.Errorf("temporary file left behind: %v", err)
+			}
+		})
+	}
+}
+
+func TestPersistDevicesRejectsInvalidFiles(t *testing.T) {
+	for _, file := range []string{`{}`, `[{"telegram":`, `[1]`} {
+		path := filepath.Join(t.TempDir(), "accounts.json")
+		if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := persistDevices(path, nil); err == nil {
+			t.Errorf("persistDevices of %s succeeded, want an error", file)
+		}
+		if data, _ := os.ReadFile(path); string(data) != file {
+			t.Errorf("invalid file %s rewritten as %s", file, data)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"github.com/nexus-telegram/NexusSDK/apikeys"
+	"github.com/nexus-telegram/NexusSDK/proxypool"
+	"sync"
+	"time"
+)
+
+// Diagnostics is a point-in-time snapshot of the scheduling state of a GameHandler.
+//
+// It is intended for debugging long-running farms, in particular the goroutine growth
+// that can occur when many recurrent tasks are scheduled for many accounts.
+//
+// # Fields:
+//   - Game: The name of the game managed by the handler.
+//   - Accounts: The number of accounts loaded into the handler.
+//   - Tasks: The number of tasks registered on the handler.
+//   - Goroutines: The number of scheduling goroutines currently running per account (keyed by Telegram ID).
+//   - Tickers: The number of tickers currently active across all accounts.
+//   - QueueDepths: The number of tasks per account that are waiting to be started (keyed by Telegram ID).
+//   - Throttle: The adjustments of the adaptive throttle, if one is set.
+//   - Paused: Whether task executions are paused (see Pause).
+//   - Maintenance: The end of the current maintenance of the game, nil when it is not under
+//     maintenance (see UnderMaintenance).
+//   - APIKeys: The game data refreshes and remaining quota of each API key (see APIKeyUsage).
+//   - Proxies: The recent requests, failures, and accounts of each proxy of the pool.
+type Diagnostics struct {
+	Game        string                 `json:"game"`
+	Accounts    int                    `json:"accounts"`
+	Tasks       int                    `json:"tasks"`
+	Goroutines  map[string]int         `json:"goroutines"`
+	Tickers     int                    `json:"tickers"`
+	QueueDepths map[string]int         `json:"queue_depths"`
+	Throttle    *ThrottleState         `json:"throttle,omitempty"`
+	Paused      bool                   `json:"paused"`
+	Maintenance *time.Time             `json:"maintenance_until,omitempty"`
+	APIKeys     []apikeys.Usage        `json:"api_keys,omitempty"`
+	Proxies     []proxypool.ProxyStats `json:"proxies,omitempty"`
+}
+
+// diagnostics holds the live counters backing Diagnostics snapshots.
+type diagnostics struct {
+	mu          sync.Mutex
+	goroutines  map[string]int
+	tickers     int
+	queueDepths map[string]int
+}
+
+func (diag *diagnostics) addGoroutine(account string, delta int) {
+	diag.mu.Lock()
+	defer diag.mu.Unlock()
+	if diag.goroutines == nil {
+		diag.goroutines = make(map[string]int)
+	}
+	diag.goroutines[account] += delta
+	if diag.goroutines[account] <= 0 {
+		delete(diag.goroutines, account)
+	}
+}
+
+func (diag *diagnostics) addTicker(delta int) {
+	diag.mu.Lock()
+	defer diag.mu.Unlock()
+	diag.tickers += delta
+}
+
+func (diag *diagnostics) setQueueDepth(account string, depth int) {
+	diag.mu.Lock()
+	defer diag.mu.Unlock()
+	if diag.queueDepths == nil {
+		diag.queueDepths = make(map[string]int)
+	}
+	if depth <= 0 {
+		delete(diag.queueDepths, account)
+		return
+	}
+	diag.queueDepths[account] = depth
+}
+
+// Diagnostics returns a snapshot of the handler's current scheduling state.
+//
+// The snapshot is safe to call concurrently with RunTasks and is cheap enough to be
+// polled periodically from a diagnostics endpoint.
+//
+// # Example:
+//
+//	snapshot := handler.Diagnostics()
+//	fmt.Println(snapshot.Tickers)
+func (handler *GameHandler) Diagnostics() Diagnostics {
+	handler.mu.Lock()
+	snapshot := Diagnostics{
+		Game:     handler.GameName,
+		Accounts: len(handler.Accounts),
+		Tasks:    len(handler.Tasks),
+		Paused:   handler.paused,
+	}
+	throttle, pool := handler.throttle, handler.ProxyPool
+	handler.mu.Unlock()
+	if until, ok := handler.UnderMaintenance(); ok {
+		snapshot.Maintenance = &until
+	}
+	if pool != nil {
+		snapshot.Proxies = pool.Stats()
+	}
+	if throttle != nil {
+		state := throttle.State()
+		snapshot.Throttle = &state
+	}
+	snapshot.APIKeys = handler.APIKeyUsage()
+
+	handler.diag.mu.Lock()
+	defer handler.diag.mu.Unlock()
+	snapshot.Goroutines = make(map[string]int, len(handler.diag.goroutines))
+	for account, count := range handler.diag.goroutines {
+		snapshot.Goroutines[account] = count
+	}
+	snapshot.QueueDepths = make(map[string]int, len(handler.diag.queueDepths))
+	for account, depth := range handler.diag.queueDepths {
+		snapshot.QueueDepths[account] = depth
+	}
+	snapshot.Tickers = handler.diag.tickers
+	return snapshot
+}
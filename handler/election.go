@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"github.com/nexus-telegram/NexusSDK/election"
+	"go.uber.org/zap"
+)
+
+// SetElector enables leader election between instances started with the same configuration.
+//
+// With an elector, RunTasks and RunTasksContext first wait until this instance is elected, so
+// a standby instance never double-runs one-time tasks for the same accounts. When the
+// leadership is lost, scheduling stops as if the handler was drained for that run. Passing nil
+// disables election.
+//
+// # Parameters:
+//   - elector: The leader elector, e.g. election.NewFileLock, election.NewRedis, or election.NewEtcd.
+//
+// # Example:
+//
+//	handler.SetElector(election.NewFileLock("/var/run/nexus-farm.lock"))
+//	handler.RunTasks() // Blocks as a standby until the leader dies
+func (handler *GameHandler) SetElector(elector election.Elector) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.elector = elector
+}
+
+// lead waits until this instance is elected and returns a context cancelled when the leadership
+// is lost, along with the function resigning it. It returns a nil context if the handler is
+// drained or ctx is done before the election is won.
+func (handler *GameHandler) lead(ctx context.Context, draining <-chan struct{}, elector election.Elector) (context.Context, func()) {
+	campaignCtx, cancelCampaign := context.WithCancel(ctx)
+	defer cancelCampaign()
+	go func() {
+		select {
+		case <-draining:
+			cancelCampaign()
+		case <-campaignCtx.Done():
+		}
+	}()
+	handler.GetLogger().Info("Waiting for leadership")
+	if err := elector.Campaign(campaignCtx); err != nil {
+		handler.GetLogger().Info("Stopped waiting for leadership", zap.Error(err))
+		return nil, func() {}
+	}
+	handler.GetLogger().Info("Elected leader")
+	leaderCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-elector.Lost():
+			handler.GetLogger().Warn("Leadership lost, stopping scheduling")
+			cancel()
+		case <-leaderCtx.Done():
+		}
+	}()
+	return leaderCtx, func() {
+		cancel()
+		if err := elector.Resign(); err != nil {
+			handler.GetLogger().Warn("Failed to resign leadership", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/bandetect"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// ErrorBudgetRule is the rule of the cooldown signal of accounts that exceeded their error
+// budget (see Cooldown).
+const ErrorBudgetRule = "error_budget"
+
+// DefaultErrorBudgetBackoff is how long an account that exceeded its error budget stops running
+// tasks, unless the ErrorBudget sets another duration.
+const DefaultErrorBudgetBackoff = time.Hour
+
+// ErrorBudget caps the failed requests of every account of a handler.
+//
+// When an account has more than MaxFailures failed requests within Window, it is put in
+// backoff: like after a ban signal, its task executions are skipped and its retries stopped
+// until Backoff is over (see Cooldowns). This protects proxies and sessions from retry storms
+// caused by one broken game endpoint.
+//
+// # Fields:
+//   - MaxFailures: The number of failed requests of an account allowed within Window.
+//   - Window: The sliding window of the failures. Defaults to one hour.
+//   - Backoff: How long an account over its budget stops running tasks. Defaults to
+//     DefaultErrorBudgetBackoff.
+//
+// # Example:
+//
+//	handler.SetErrorBudget(&handler.ErrorBudget{MaxFailures: 20, Window: time.Hour, Backoff: 2 * time.Hour})
+type ErrorBudget struct {
+	MaxFailures int
+	Window      time.Duration
+	Backoff     time.Duration
+	mu          sync.Mutex
+	failures    map[string][]time.Time
+}
+
+// NewErrorBudget creates an error budget from its configuration.
+func NewErrorBudget(config types.ErrorBudgetConfig) *ErrorBudget {
+	return &ErrorBudget{
+		MaxFailures: config.MaxFailuresPerHour,
+		Window:      time.Hour,
+		Backoff:     time.Duration(config.BackoffMinutes) * time.Minute,
+	}
+}
+
+// fail records a failed request of an account at now. It reports whether the account exceeded
+// its budget, in which case its failures are forgotten so that it starts afresh after its backoff.
+func (budget *ErrorBudget) fail(telegramId string, now time.Time) bool {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+	window := budget.Window
+	if window <= 0 {
+		window = time.Hour
+	}
+	if budget.failures == nil {
+		budget.failures = make(map[string][]time.Time)
+	}
+	failures := budget.failures[telegramId]
+	for len(failures) > 0 && !failures[0].After(now.Add(-window)) {
+		failures = failures[1:]
+	}
+	failures = append(failures, now)
+	if len(failures) > budget.MaxFailures {
+		delete(budget.failures, telegramId)
+		return true
+	}
+	budget.failures[telegramId] = failures
+	return false
+}
+
+// backoff returns how long an account over its budget stops running tasks.
+func (budget *ErrorBudget) backoff() time.Duration {
+	if budget.Backoff <= 0 {
+		return DefaultErrorBudgetBackoff
+	}
+	return budget.Backoff
+}
+
+// SetErrorBudget sets the cap on the failed requests of every account of the handler. Passing
+// nil removes it.
+//
+// # Parameters:
+//   - budget: The error budget.
+func (handler *GameHandler) SetErrorBudget(budget *ErrorBudget) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.errorBudget = budget
+}
+
+// getErrorBudget returns the error budget of the handler, or nil if none is set.
+func (handler *GameHandler) getErrorBudget() *ErrorBudget {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return handler.errorBudget
+}
+
+// chargeErrorBudget records a failed request of an account and puts the account in backoff
+// when it exceeds its error budget. Requests refused by the request budget or cancelled are
+// not counted.
+func (handler *GameHandler) chargeErrorBudget(account types.Account, err error) {
+	budget := handler.getErrorBudget()
+	if budget == nil || budget.MaxFailures <= 0 || err == nil ||
+		errors.Is(err, ErrBudgetExhausted) || errors.Is(err, context.Canceled) {
+		return
+	}
+	now := handler.getClock().Now()
+	id := account.TelegramData.TelegramId
+	if !budget.fail(id, now) {
+		return
+	}
+	until := now.Add(budget.backoff())
+	handler.mu.Lock()
+	if handler.cooldowns == nil {
+		handler.cooldowns = make(map[string]Cooldown)
+	}
+	cooldown, ok := handler.cooldowns[id]
+	if !ok || !now.Before(cooldown.Until) {
+		cooldown = Cooldown{Account: id, Since: now}
+	}
+	cooldown.Signal = bandetect.Signal{Rule: ErrorBudgetRule}
+	cooldown.Signals++
+	if until.After(cooldown.Until) {
+		cooldown.Until = until
+	}
+	handler.cooldowns[id] = cooldown
+	handler.mu.Unlock()
+	utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, account).Warn("Error budget exceeded, account backing off",
+		zap.Int("max_failures", budget.MaxFailures), zap.Time("until", cooldown.Until))
+}
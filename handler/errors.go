@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCategoryCauses is the number of causes kept per category in ErrorStats.
+const maxCategoryCauses = 5
+
+// ErrorCategory is the normalized cause of a task failure.
+type ErrorCategory string
+
+const (
+	ErrorAuth        ErrorCategory = "auth"        // The game data or API key was rejected
+	ErrorProxy       ErrorCategory = "proxy"       // The proxy could not be reached or refused the connection
+	ErrorRateLimit   ErrorCategory = "rate_limit"  // The game or the request budget throttled the account
+	ErrorMaintenance ErrorCategory = "maintenance" // The game is down or under maintenance
+	ErrorUnknown     ErrorCategory = "unknown"     // Any other failure
+)
+
+// ClassifyError returns the category of a task failure.
+//
+// Response status codes are used when the error wraps an *httpclient.StatusError; otherwise the
+// error chain and message are inspected for network and well-known game errors.
+//
+// # Parameters:
+//   - err: The error to classify.
+//
+// # Returns:
+//   - ErrorCategory: The category of err, ErrorUnknown if it cannot be determined.
+//
+// # Example:
+//
+//	if handler.ClassifyError(err) == handler.ErrorAuth {
+//		log.Printf("Account %s needs a new session", account.TelegramData.TelegramId)
+//	}
+func ClassifyError(err error) ErrorCategory {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, ErrBudgetExhausted) {
+		return ErrorRateLimit
+	}
+	var statusErr *httpclient.StatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden:
+			return ErrorAuth
+		case statusErr.StatusCode == http.StatusProxyAuthRequired:
+			return ErrorProxy
+		case statusErr.StatusCode == http.StatusTooManyRequests:
+			return ErrorRateLimit
+		case statusErr.StatusCode == http.StatusBadGateway || statusErr.StatusCode == http.StatusServiceUnavailable:
+			return ErrorMaintenance
+		}
+	}
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "maintenance"):
+		return ErrorMaintenance
+	case strings.Contains(message, "too many requests") || strings.Contains(message, "rate limit"):
+		return ErrorRateLimit
+	case strings.Contains(message, "unauthorized") || strings.Contains(message, "invalid token") ||
+		strings.Contains(message, "expired") || strings.Contains(message, "game data refresh failed"):
+		return ErrorAuth
+	case strings.Contains(message, "proxy") || strings.Contains(message, "socks"):
+		return ErrorProxy
+	}
+	var opErr *net.OpError
+	if (errors.As(err, &opErr) && opErr.Op == "dial") || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorProxy
+	}
+	return ErrorUnknown
+}
+
+// ErrorStats is a snapshot of the task failures of a GameHandler grouped by category.
+//
+// # Fields:
+//   - Total: The number of failures recorded.
+//   - Categories: The failures of each category.
+type ErrorStats struct {
+	Total      int                             `json:"total"`
+	Categories map[ErrorCategory]CategoryStats `json:"categories"`
+}
+
+// CategoryStats are the failures of one ErrorCategory in ErrorStats.
+//
+// # Fields:
+//   - Count: The number of failures of the category.
+//   - Accounts: The number of distinct accounts with a failure of the category.
+//   - LastSeen: When the last failure of the category was recorded.
+//   - Causes: The most frequent normalized causes of the category, most frequent first.
+type CategoryStats struct {
+	Count    int          `json:"count"`
+	Accounts int          `json:"accounts"`
+	LastSeen time.Time    `json:"last_seen"`
+	Causes   []ErrorCount `json:"causes"`
+}
+
+// ErrorAggregator groups task failures by category and normalized cause across accounts.
+//
+// It turns thousands of identical log lines into a handful of counters. Every GameHandler
+// records the failures of its tasks into its own aggregator (see GameHandler.ErrorStats).
+//
+// # Example:
+//
+//	aggregator := handler.NewErrorAggregator()
+//	aggregator.Add("987654321", err)
+//	stats := aggregator.Snapshot()
+//	fmt.Println(stats.Categories[handler.ErrorProxy].Count)
+type ErrorAggregator struct {
+	mu         sync.Mutex
+	total      int
+	categories map[ErrorCategory]*categoryCounter
+}
+
+type categoryCounter struct {
+	count    int
+	accounts map[string]struct{}
+	lastSeen time.Time
+	causes   map[string]*ErrorCount
+}
+
+// NewErrorAggregator creates an empty ErrorAggregator.
+func NewErrorAggregator() *ErrorAggregator {
+	return &ErrorAggregator{categories: make(map[ErrorCategory]*categoryCounter)}
+}
+
+// Add records a failure of an account. Nil errors are ignored.
+func (aggregator *ErrorAggregator) Add(account string, err error) {
+	if err == nil {
+		return
+	}
+	category := ClassifyError(err)
+	cause := normalizeError(err)
+	aggregator.mu.Lock()
+	defer aggregator.mu.Unlock()
+	counter, ok := aggregator.categories[category]
+	if !ok {
+		counter = &categoryCounter{accounts: make(map[string]struct{}), causes: make(map[string]*ErrorCount)}
+		aggregator.categories[category] = counter
+	}
+	aggregator.total++
+	counter.count++
+	counter.accounts[account] = struct{}{}
+	counter.lastSeen = time.Now()
+	errorCount, ok := counter.causes[cause]
+	if !ok {
+		errorCount = &ErrorCount{Type: cause, Category: category, Example: err.Error()}
+		counter.causes[cause] = errorCount
+	}
+	errorCount.Count++
+}
+
+// Snapshot returns the failures recorded so far.
+func (aggregator *ErrorAggregator) Snapshot() ErrorStats {
+	aggregator.mu.Lock()
+	defer aggregator.mu.Unlock()
+	stats := ErrorStats{Total: aggregator.total, Categories: make(map[ErrorCategory]CategoryStats, len(aggregator.categories))}
+	for category, counter := range aggregator.categories {
+		causes := make([]ErrorCount, 0, len(counter.causes))
+		for _, errorCount := range counter.causes {
+			causes = append(causes, *errorCount)
+		}
+		stats.Categories[category] = CategoryStats{
+			Count:    counter.count,
+			Accounts: len(counter.accounts),
+			LastSeen: counter.lastSeen,
+			Causes:   topErrors(causes, maxCategoryCauses),
+		}
+	}
+	return stats
+}
+
+// Reset discards the failures recorded so far.
+func (aggregator *ErrorAggregator) Reset() {
+	aggregator.mu.Lock()
+	defer aggregator.mu.Unlock()
+	aggregator.total = 0
+	aggregator.categories = make(map[ErrorCategory]*categoryCounter)
+}
+
+// topErrors sorts counts, most frequent first, and keeps at most limit of them.
+func topErrors(counts []ErrorCount, limit int) []ErrorCount {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Type < counts[j].Type
+	})
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts
+}
+
+// ErrorStats returns the task failures of the handler grouped by category and cause.
+//
+// # Example:
+//
+//	stats := handler.ErrorStats()
+//	for category, categoryStats := range stats.Categories {
+//		fmt.Printf("%s: %d failures on %d accounts\n", category, categoryStats.Count, categoryStats.Accounts)
+//	}
+func (handler *GameHandler) ErrorStats() ErrorStats {
+	return handler.errorAggregator().Snapshot()
+}
+
+// errorAggregator returns the aggregator of the handler, creating it on first use.
+func (handler *GameHandler) errorAggregator() *ErrorAggregator {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.errors == nil {
+		handler.errors = NewErrorAggregator()
+	}
+	return handler.errors
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"github.com/nexus-telegram/NexusSDK/sink"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+)
+
+// SetEventSink sets the message queues the task results and events of the handler are
+// published to (see sink.Event for the published events). The events are published in the
+// background through a buffer of sink.DefaultBuffer events, so that a slow or unreachable
+// broker does not slow the tasks down; the events that cannot be published are logged and
+// dropped. Passing nil stops the publication.
+//
+// Handlers created by NewGameHandler publish to the sinks of the sinks section of their
+// configuration (see sink.FromConfig).
+//
+// # Parameters:
+//   - events: The sink, e.g. &sink.NATS{Addr: "10.0.0.5:4222", Prefix: "nexus"}, or a
+//     sink.Multi publishing to several brokers.
+//
+// # Example:
+//
+//	gameHandler.SetEventSink(sink.Multi{
+//		&sink.NATS{Addr: "10.0.0.5:4222", Prefix: "nexus"},
+//		&sink.KafkaREST{URL: "http://10.0.0.5:8082", Topic: "farm-events"},
+//	})
+//
+// # Notes:
+//   - The sink replaced, if any, is closed after its buffered events are published.
+func (handler *GameHandler) SetEventSink(events sink.Sink) {
+	var async sink.Sink
+	if events != nil {
+		logger := utils.ModuleLogger("handler")
+		async = sink.NewAsync(events, sink.DefaultBuffer, func(event sink.Event, err error) {
+			logger.Warn("Failed to publish event", zap.String("game", event.Game), zap.String("event", event.Type),
+				zap.String("account", event.Account), zap.Error(err))
+		})
+	}
+	handler.mu.Lock()
+	previous := handler.events
+	handler.events = async
+	handler.mu.Unlock()
+	if previous != nil {
+		_ = previous.Close()
+	}
+}
+
+// publishEvent publishes an event of the handler to its event sink, if any, without waiting.
+func (handler *GameHandler) publishEvent(event sink.Event) {
+	handler.mu.Lock()
+	events := handler.events
+	handler.mu.Unlock()
+	if events == nil {
+		return
+	}
+	event.Game = handler.GameName
+	if event.Time.IsZero() {
+		event.Time = handler.getClock().Now()
+	}
+	_ = events.Publish(context.Background(), event)
+}
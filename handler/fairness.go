@@ -0,0 +1,63 @@
+package handler
+
+// fairQueue holds the task executions waiting for a slot of a bounded worker pool, one FIFO
+// queue per account, and hands the slots out round-robin across the accounts.
+//
+// An account with many due tasks therefore gets one slot per turn like every other waiting
+// account, instead of filling the pool in the order its executions arrived and starving the
+// accounts with few tasks.
+//
+// # Fields:
+//   - waiting: The waiting executions of each account, in arrival order. Each is granted its
+//     slot by closing its channel.
+//   - order: The accounts with waiting executions, in the order they are served.
+//   - next: The index in order of the account served next.
+type fairQueue struct {
+	waiting map[string][]chan struct{}
+	order   []string
+	next    int
+}
+
+// push queues an execution of account and returns the channel closed when it is granted.
+func (queue *fairQueue) push(account string) chan struct{} {
+	if queue.waiting == nil {
+		queue.waiting = make(map[string][]chan struct{})
+	}
+	granted := make(chan struct{})
+	if len(queue.waiting[account]) == 0 {
+		queue.order = append(queue.order, account)
+	}
+	queue.waiting[account] = append(queue.waiting[account], granted)
+	return granted
+}
+
+// pop removes the first waiting execution of the account whose turn it is, and returns its
+// channel, or nil when nothing is waiting.
+func (queue *fairQueue) pop() chan struct{} {
+	if len(queue.order) == 0 {
+		return nil
+	}
+	if queue.next >= len(queue.order) {
+		queue.next = 0
+	}
+	account := queue.order[queue.next]
+	waiting := queue.waiting[account]
+	granted := waiting[0]
+	if len(waiting) == 1 {
+		delete(queue.waiting, account)
+		queue.order = append(queue.order[:queue.next], queue.order[queue.next+1:]...)
+	} else {
+		queue.waiting[account] = waiting[1:]
+		queue.next++
+	}
+	return granted
+}
+
+// len returns the number of waiting executions.
+func (queue *fairQueue) len() int {
+	count := 0
+	for _, waiting := range queue.waiting {
+		count += len(waiting)
+	}
+	return count
+}
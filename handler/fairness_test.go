@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFairQueue(t *testing.T) {
+	// Each step either pushes an execution of an account ("+a") or pops one and expects the
+	// execution it names ("a2" is the second execution pushed for a, "-" is an empty queue).
+	tests := []struct {
+		name  string
+		steps string
+	}{
+		{"empty", "- -"},
+		{"single account in order", "+a +a +a a1 a2 a3 -"},
+		{"round robin", "+a +a +a +b +c +c a1 b1 c1 a2 c2 a3 -"},
+		{"account rejoins at the end", "+a +b a1 +a b1 a2 -"},
+		{"late account joins the rotation", "+a +a +a a1 +b b1 a2 a3 -"},
+		{"drained account leaves the rotation", "+a +b +b +c a1 b1 c1 b2 -"},
+		{"pop after drain resets the turn", "+a +b a1 b1 - +c +a c1 a2 -"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var queue fairQueue
+			names := make(map[chan struct{}]string)
+			pushed := make(map[string]int)
+			waiting := 0
+			for _, step := range strings.Fields(test.steps) {
+				if account, ok := strings.CutPrefix(step, "+"); ok {
+					pushed[account]++
+					names[queue.push(account)] = fmt.Sprintf("%s%d", account, pushed[account])
+					waiting++
+				} else {
+					got := "-"
+					if granted := queue.pop(); granted != nil {
+						got = names[granted]
+						waiting--
+					}
+					if got != step {
+						t.Fatalf("pop() granted %s, want %s", got, step)
+					}
+				}
+				if queue.len() != waiting {
+					t.Fatalf("len() = %d after %s, want %d", queue.len(), step, waiting)
+				}
+			}
+		})
+	}
+}
+
+func TestThrottleServesAccountsRoundRobin(t *testing.T) {
+	throttle := &Throttle{Window: time.Minute, MaxConcurrency: 1, limit: 1}
+	release := throttle.acquire("busy")
+
+	var mu sync.Mutex
+	var served []string
+	var wg sync.WaitGroup
+	for queued, account := range []string{"a", "a", "a", "b", "c", "c"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done := throttle.acquire(account)
+			mu.Lock()
+			served = append(served, account)
+			mu.Unlock()
+			done()
+		}()
+		// Wait for the execution to be queued, so the arrival order is known.
+		for deadline := time.Now().Add(time.Second); throttle.State().Waiting <= queued; time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("execution %d of %s never queued", queued+1, account)
+			}
+		}
+	}
+	release()
+	wg.Wait()
+	if got, want := strings.Join(served, " "), "a b c a c a"; got != want {
+		t.Errorf("executions served in order %s, want %s", got, want)
+	}
+	if state := throttle.State(); state.Running != 0 || state.Waiting != 0 {
+		t.Errorf("State() = %+v after all executions, want none running or waiting", state)
+	}
+}
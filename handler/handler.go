@@ -1,11 +1,31 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/apikeys"
+	"github.com/nexus-telegram/NexusSDK/audit"
+	"github.com/nexus-telegram/NexusSDK/bandetect"
+	"github.com/nexus-telegram/NexusSDK/codec"
+	"github.com/nexus-telegram/NexusSDK/election"
+	"github.com/nexus-telegram/NexusSDK/history"
 	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/idempotency"
+	"github.com/nexus-telegram/NexusSDK/jobqueue"
+	"github.com/nexus-telegram/NexusSDK/metrics"
+	"github.com/nexus-telegram/NexusSDK/proxypool"
+	"github.com/nexus-telegram/NexusSDK/remote"
+	"github.com/nexus-telegram/NexusSDK/secrets"
+	"github.com/nexus-telegram/NexusSDK/sink"
+	"github.com/nexus-telegram/NexusSDK/storage"
 	"github.com/nexus-telegram/NexusSDK/tasks"
 	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"github.com/nexus-telegram/NexusSDK/utils/clock"
+	"github.com/nexus-telegram/NexusSDK/utils/retry"
+	"go.uber.org/zap"
 	"io"
-	"log"
 	"sync"
 	"time"
 )
@@ -18,26 +38,155 @@ import (
 //
 // # Fields:
 //   - BaseURL: The base API URL for the specific game.
+//   - BaseURLs: The named base URLs of the game's other hosts (e.g., "cdn" and "auth"),
+//     referenced by tasks as "@name/path" (see tasks.ResolveURL). Set with SetNamedBaseURL.
 //   - Proxy: The proxy configuration for all requests.
 //   - APIKey: The API key used for authentication.
+//   - NexusAPIURL: The base URL of the Nexus API refreshing game data. Defaults to DefaultNexusAPIURL.
 //   - Accounts: A list of accounts to process.
 //   - Tasks: A list of tasks, both one-time and recurrent.
 //   - HttpClient: The HTTP client used for sending requests.
+//   - ProxyPool: An optional pool of proxies distributed across accounts instead of Proxy.
 //   - mu: A mutex for thread-safe operations.
+//   - diag: Live scheduling counters exposed through Diagnostics.
+//   - clients: HTTP clients for the proxies of ProxyPool, keyed by proxy.
+//   - auditLog: The audit log applied to every HTTP client of the handler.
+//   - profiles: The typed game-specific profiles of the accounts, keyed by Telegram ID.
+//   - profileLocks: The per-account mutexes serializing profile updates, keyed by Telegram ID.
+//   - taskRetry: The retry policy of failed task executions, see SetTaskRetryPolicy.
+//   - clock: The clock used by the scheduler, see SetClock.
+//   - sequential: Whether the tasks of one account never run concurrently, see SetSequentialAccounts.
+//   - accountLocks: The per-account mutexes used when sequential is set, keyed by Telegram ID.
+//   - budget: The request budget applied to every HTTP client of the handler, see SetBudget.
+//   - draining: Closed by Drain to stop scheduling new task executions.
+//   - active: The running RunTasks and RunTasksContext calls, awaited by Drain.
+//   - elector: The optional leader elector, see SetElector.
+//   - sealer: The optional encryption of the API keys and session strings, see SetSecretMemory.
+//   - parallelism: The optional sizing of the task dispatch from the farm size, see SetParallelism.
+//   - lazy: The optional accounts file the game data and session strings are read from on use, see SetLazyAccounts.
+//   - events: The optional message queues the task results and events are published to, see SetEventSink.
+//   - poolName: The value of the proxy_pool label of the metrics, see SetProxyPoolName.
+//   - gauges: The gauges of the handler in metrics.DefaultRegistry, see RegisterGauge.
+//   - preconnect: The optional connection warm-up run when the tasks start, see SetPreconnect.
+//   - contentTypes: The default content types applied to every client, see SetContentTypes.
+//   - payloadLimit: The optional limits of the request bodies sent by tasks, see SetPayloadLimits.
+//   - maintenance: The optional maintenance windows and detection of the game, see SetMaintenance.
+//   - downUntil: The end of the maintenance detected or started with StartMaintenance.
+//   - backoff: The optional interval backoff of the failing scheduled tasks, see SetIntervalBackoff.
+//   - streaks: The failures in a row of the scheduled tasks, keyed by job ID.
+//   - tracer: The optional tracer of the task executions, see SetTracer.
+//   - closed: Whether Close was called.
 type GameHandler struct {
-	GameName   string                 // Name of the game
-	BaseURL    string                 // Base API URL for the specific game
-	Proxy      types.Proxy            // Proxy configuration for all requests
-	APIKey     string                 // API key for authentication
-	Accounts   []types.Account        // List of accounts to process
-	Tasks      []tasks.Task           // List of tasks (both one-time and recurrent)
-	HttpClient *httpclient.HTTPClient // HTTP client for sending requests
-	mu         sync.Mutex             // Mutex for thread-safe operations
+	GameName     string                            // Name of the game
+	BaseURL      string                            // Base API URL for the specific game
+	BaseURLs     map[string]string                 // Named base URLs of the game's other hosts
+	Proxy        types.Proxy                       // Proxy configuration for all requests
+	APIKey       string                            // API key for authentication
+	NexusAPIURL  string                            // Base URL of the Nexus API
+	Accounts     []types.Account                   // List of accounts to process
+	Tasks        []tasks.Task                      // List of tasks (both one-time and recurrent)
+	HttpClient   *httpclient.HTTPClient            // HTTP client for sending requests
+	ProxyPool    *proxypool.Pool                   // Optional pool of per-account proxies
+	mu           sync.Mutex                        // Mutex for thread-safe operations
+	diag         diagnostics                       // Live counters exposed through Diagnostics
+	clients      map[string]*httpclient.HTTPClient // HTTP clients of the pool proxies
+	auditLog     audit.Log                         // Audit log applied to every client
+	profiles     sync.Map                          // Typed per-account profiles
+	profileLocks sync.Map                          // Per-account profile update mutexes
+	taskRetry    *retry.Policy                     // Retry policy of failed task executions
+	clock        clock.Clock                       // Clock used by the scheduler
+	sequential   bool                              // Whether the tasks of one account run one at a time
+	accountLocks sync.Map                          // Per-account task mutexes
+	budget       *Budget                           // Request budget applied to every client
+	draining     chan struct{}                     // Closed when the handler is drained
+	active       sync.WaitGroup                    // Running RunTasks calls
+	elector      election.Elector                  // Optional leader elector
+	errors       *ErrorAggregator                  // Task failures grouped by category
+	hedge        *HedgePolicy                      // Optional hedging of GET requests
+	tlsOptions   httpclient.TLSOptions             // TLS options applied to every client
+	network      httpclient.NetworkOptions         // Local binding applied to every client
+	flights      *httpclient.FlightGroup           // Optional deduplication of identical GET requests
+	decoder      codec.Decoder                     // Decoder of the game's responses
+	guard        idempotency.Guard                 // Successful non-idempotent task executions
+	queue        jobqueue.Queue                    // Queue of the scheduled task executions
+	owner        string                            // Lease owner of the handler in the queue
+	history      history.Store                     // Recent task executions
+	throttle     *Throttle                         // Optional adaptive throttling of task dispatch
+	warmUp       *WarmUpPolicy                     // Optional warm-up of newly added accounts
+	bans         *bandetect.Detector               // Optional detection of ban signals
+	cooldowns    map[string]Cooldown               // Accounts in cooldown, keyed by Telegram ID
+	recovery     *CooldownPolicy                   // Cooldown durations and resume probes
+	rotation     RotationStrategy                  // Order in which accounts are served
+	activity     map[string]accountActivity        // Last executions of the accounts, keyed by Telegram ID
+	listeners    []func(result TaskResult)         // Listeners of the task results
+	paused       bool                              // Whether task executions are paused
+	balances     map[string][]balanceSample        // Recorded balances, keyed by Telegram ID
+	apiKeys      *apikeys.Pool                     // Optional API keys reserved for games or accounts
+	keyUsage     *apikeys.Tracker                  // Usage of APIKey when no key pool is set
+	backend      storage.Storage                   // Optional backend of the persisted state
+	backlog      map[string]int                    // Missed executions left to replay, keyed by job ID
+	errorBudget  *ErrorBudget                      // Optional cap on the failed requests of every account
+	proxyEvents  []func(proxypool.Replacement)     // Listeners of the retired proxies
+	proxySource  proxypool.Provider                // Optional provider the proxy list is refreshed from
+	proxyRefresh time.Duration                     // Refresh interval of the proxy list
+	sealer       *secrets.Sealer                   // Optional encryption of the secrets in memory
+	sealedKey    *secrets.Sealed                   // APIKey, when encrypted
+	sessions     map[string]*secrets.Sealed        // Encrypted session strings, keyed by Telegram ID
+	parallelism  *Parallelism                      // Optional sizing of the task dispatch from the farm size
+	lazy         *LazyAccounts                     // Optional file the game data and session strings are read from on use
+	events       sink.Sink                         // Optional message queues the task results and events are published to
+	poolName     string                            // Value of the proxy_pool label of the metrics
+	gauges       map[string]metrics.Labels         // Gauges registered in metrics.DefaultRegistry, by name
+	preconnect   *Preconnect                       // Optional connection warm-up run when the tasks start
+	contentTypes *httpclient.ContentTypes          // Default content types applied to every client, if changed
+	payloadLimit *PayloadLimits                    // Optional limits of the request bodies sent by tasks
+	maintenance  *Maintenance                      // Optional maintenance windows and detection of the game
+	downUntil    time.Time                         // End of the maintenance detected or started with StartMaintenance
+	backoff      *tasks.IntervalBackoff            // Optional interval backoff of the failing scheduled tasks
+	streaks      map[string]int                    // Failures in a row of the scheduled tasks, keyed by job ID
+	tracer       Tracer                            // Optional tracer of the task executions
+	closed       bool                              // Whether Close was called
 }
 
 // Post sends a POST request using the HTTP client.
 func (handler *GameHandler) Post(url string, payload []byte) ([]byte, error) {
-	resp, err := handler.HttpClient.Post(url, payload)
+	return postWith(context.Background(), handler.HttpClient, url, payload)
+}
+
+// Get sends a GET request using the HTTP client.
+func (handler *GameHandler) Get(url string) ([]byte, error) {
+	return getWith(context.Background(), handler.HttpClient, url)
+}
+
+// PostWithHeaders sends a POST request using the HTTP client with per-call headers merged over
+// the client's headers (see httpclient.DoRequestWithHeaders).
+func (handler *GameHandler) PostWithHeaders(url string, payload []byte, headers map[string]string) ([]byte, error) {
+	return postWith(httpclient.ContextWithHeaders(context.Background(), headers), handler.HttpClient, url, payload)
+}
+
+// GetWithHeaders sends a GET request using the HTTP client with per-call headers merged over
+// the client's headers (see httpclient.DoRequestWithHeaders).
+func (handler *GameHandler) GetWithHeaders(url string, headers map[string]string) ([]byte, error) {
+	return getWith(httpclient.ContextWithHeaders(context.Background(), headers), handler.HttpClient, url)
+}
+
+// getWith sends a GET request bound to ctx through client and returns the response body.
+func getWith(ctx context.Context, client *httpclient.HTTPClient, url string) ([]byte, error) {
+	resp, err := client.GetContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+		}
+	}(resp.Body)
+	return io.ReadAll(resp.Body)
+}
+
+// postWith sends a POST request bound to ctx through client and returns the response body.
+func postWith(ctx context.Context, client *httpclient.HTTPClient, url string, payload []byte) ([]byte, error) {
+	resp, err := client.PostContext(ctx, url, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -53,16 +202,61 @@ func (handler *GameHandler) Post(url string, payload []byte) ([]byte, error) {
 	return body, nil
 }
 
+// GetGameName returns the name of the game.
+func (handler *GameHandler) GetGameName() string {
+	return handler.GameName
+}
+
 // GetBaseURL returns the base URL.
 func (handler *GameHandler) GetBaseURL() string {
 	return handler.BaseURL
 }
 
+// GetNamedBaseURL returns the base URL of a name, and whether the handler has one. The empty
+// name is the handler's BaseURL. It implements tasks.BaseURLProvider.
+func (handler *GameHandler) GetNamedBaseURL(name string) (string, bool) {
+	if name == "" {
+		return handler.BaseURL, true
+	}
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	url, ok := handler.BaseURLs[name]
+	return url, ok
+}
+
 // GetAccounts returns the list of accounts.
 func (handler *GameHandler) GetAccounts() []types.Account {
 	return handler.Accounts
 }
 
+// GetLogger returns the handler module logger annotated with the game name.
+func (handler *GameHandler) GetLogger() *zap.Logger {
+	return utils.ModuleLogger("handler").With(zap.String("game", handler.GameName))
+}
+
+// LoadProfile returns the game-specific profile stored for an account.
+//
+// Prefer the typed tasks.GetProfile helper over calling this method directly.
+func (handler *GameHandler) LoadProfile(telegramId string) (interface{}, bool) {
+	return handler.profiles.Load(telegramId)
+}
+
+// StoreProfile stores the game-specific profile of an account.
+//
+// Prefer the typed tasks.SetProfile helper over calling this method directly.
+func (handler *GameHandler) StoreProfile(telegramId string, profile interface{}) {
+	handler.profiles.Store(telegramId, profile)
+}
+
+// LockProfile locks the profile of an account until unlock is called, serializing the
+// updates of tasks.UpdateProfile (see tasks.ProfileLocker).
+func (handler *GameHandler) LockProfile(telegramId string) (unlock func()) {
+	lock, _ := handler.profileLocks.LoadOrStore(telegramId, &sync.Mutex{})
+	mutex := lock.(*sync.Mutex)
+	mutex.Lock()
+	return mutex.Unlock
+}
+
 // SetBaseURL sets the base API URL for the handler.
 //
 // This method updates the BaseURL field of the GameHandler with the provided URL.
@@ -77,6 +271,218 @@ func (handler *GameHandler) SetBaseURL(url string) {
 	handler.BaseURL = url
 }
 
+// SetNamedBaseURL sets the base URL of a name, for games whose endpoints are split across
+// several hosts. Tasks reference it as "@name/path" (see tasks.ResolveURL). An empty URL
+// removes the name.
+//
+// Handlers created by NewGameHandler start with the base_urls section of their configuration.
+//
+// # Example:
+//
+//	handler.SetBaseURL("https://api.example.com")
+//	handler.SetNamedBaseURL("auth", "https://auth.example.com/v2")
+//	handler.SetNamedBaseURL("cdn", "https://cdn.example.com")
+func (handler *GameHandler) SetNamedBaseURL(name, url string) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	urls := make(map[string]string, len(handler.BaseURLs)+1)
+	for key, value := range handler.BaseURLs {
+		urls[key] = value
+	}
+	if url == "" {
+		delete(urls, name)
+	} else {
+		urls[name] = url
+	}
+	handler.BaseURLs = urls
+}
+
+// SetNexusAPIURL sets the base URL of the Nexus API used to refresh game data.
+//
+// # Parameters:
+//   - url: The new base URL, e.g. the URL of a nexustest.Server in integration tests.
+//
+// # Example:
+//
+//	handler.SetNexusAPIURL(server.NexusAPIURL())
+func (handler *GameHandler) SetNexusAPIURL(url string) {
+	handler.NexusAPIURL = url
+}
+
+// nexusAPIURL returns the Nexus API base URL, defaulting to DefaultNexusAPIURL.
+func (handler *GameHandler) nexusAPIURL() string {
+	if handler.NexusAPIURL == "" {
+		return DefaultNexusAPIURL
+	}
+	return handler.NexusAPIURL
+}
+
+// SetAuditLog enables the audit trail of all outgoing requests made by the handler.
+//
+// Each request is recorded with its method, URL, payload hash, account, and response
+// status. Passing nil disables auditing.
+//
+// # Parameters:
+//   - auditLog: The append-only audit log receiving the entries.
+//
+// # Example:
+//
+//	auditLog, err := audit.OpenFileLog("logs/audit.jsonl")
+//	if err != nil {
+//		log.Fatalf("Failed to open audit log: %v", err)
+//	}
+//	handler.SetAuditLog(auditLog)
+func (handler *GameHandler) SetAuditLog(auditLog audit.Log) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.auditLog = auditLog
+	handler.HttpClient.SetAuditLog(auditLog)
+	for _, client := range handler.clients {
+		client.SetAuditLog(auditLog)
+	}
+}
+
+// SetBudget applies a request budget to all outgoing requests made by the handler.
+//
+// The same budget can be shared by several handlers to enforce a farm-wide cap, see
+// Manager.SetBudget. In skip mode, task executions are skipped while the budget is exhausted.
+// Passing nil removes the budget.
+//
+// # Parameters:
+//   - budget: The request budget.
+func (handler *GameHandler) SetBudget(budget *Budget) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.budget = budget
+	handler.HttpClient.SetLimiter(budget.limiter())
+	for _, client := range handler.clients {
+		client.SetLimiter(budget.limiter())
+	}
+}
+
+// SetTLSOptions applies custom root CAs, a client certificate, and certificate pins to all
+// outgoing requests made by the handler, including the game data refreshes sent to the Nexus API.
+//
+// # Parameters:
+//   - options: The TLS options (see httpclient.TLSOptions).
+//
+// # Returns:
+//   - error: An error if the certificates cannot be loaded.
+//
+// # Example:
+//
+//	err := handler.SetTLSOptions(httpclient.TLSOptions{RootCAFiles: []string{"/etc/nexus/ca.pem"}})
+//	if err != nil {
+//		log.Fatalf("Failed to configure TLS: %v", err)
+//	}
+func (handler *GameHandler) SetTLSOptions(options httpclient.TLSOptions) error {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if err := handler.HttpClient.SetTLSOptions(options); err != nil {
+		return err
+	}
+	for _, client := range handler.clients {
+		if err := client.SetTLSOptions(options); err != nil {
+			return err
+		}
+	}
+	handler.tlsOptions = options
+	return nil
+}
+
+// SetNetworkOptions binds the connections of all HTTP clients of the handler to a local address
+// or interface, optionally makes them prefer IPv6, and sets their dial and handshake timeouts.
+// The game data refreshes sent to the Nexus API use the bound address directly; the clients of
+// proxies use it to reach their proxy.
+//
+// # Parameters:
+//   - options: The network options (see httpclient.NetworkOptions).
+//
+// # Returns:
+//   - error: An error if the local address is invalid or the interface has no usable address.
+//
+// # Example:
+//
+//	err := handler.SetNetworkOptions(httpclient.NetworkOptions{LocalAddr: "203.0.113.7"})
+//	if err != nil {
+//		log.Fatalf("Failed to bind handler: %v", err)
+//	}
+func (handler *GameHandler) SetNetworkOptions(options httpclient.NetworkOptions) error {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if err := handler.HttpClient.SetNetworkOptions(options); err != nil {
+		return err
+	}
+	for _, client := range handler.clients {
+		if err := client.SetNetworkOptions(options); err != nil {
+			return err
+		}
+	}
+	handler.network = options
+	return nil
+}
+
+// SetContentTypes sets the Content-Type and Accept headers added by all HTTP clients of the
+// handler to the requests that do not set them (see httpclient.ContentTypes). Tasks override
+// them with their own headers, e.g. "headers": {"Content-Type": "text/plain"}.
+//
+// Handlers created by NewGameHandler use the content_types section of their configuration, and
+// httpclient.DefaultContentTypes without one.
+//
+// # Example:
+//
+//	handler.SetContentTypes(httpclient.ContentTypes{ContentType: "application/json; charset=utf-8"})
+func (handler *GameHandler) SetContentTypes(contentTypes httpclient.ContentTypes) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.HttpClient.SetContentTypes(contentTypes)
+	for _, client := range handler.clients {
+		client.SetContentTypes(contentTypes)
+	}
+	handler.contentTypes = &contentTypes
+}
+
+// SetSingleFlight enables or disables the deduplication of identical in-flight GET requests
+// across the accounts of the handler (see httpclient.FlightGroup).
+//
+// # Example:
+//
+//	handler.SetSingleFlight(true) // one request for the game config, whatever the number of accounts
+func (handler *GameHandler) SetSingleFlight(enabled bool) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.flights = nil
+	if enabled {
+		handler.flights = httpclient.NewFlightGroup()
+	}
+	handler.HttpClient.SetFlightGroup(handler.flights)
+	for _, client := range handler.clients {
+		client.SetFlightGroup(handler.flights)
+	}
+}
+
+// SetDecoder sets the decoder of the game's responses, used by tasks through
+// tasks.BaseTask.Decode unless they set their own. Passing nil restores JSON.
+//
+// # Example:
+//
+//	handler.SetDecoder(codec.Chain(codec.Base64{}, codec.XOR{Key: []byte("s3cr3t")}, codec.JSON{}))
+func (handler *GameHandler) SetDecoder(decoder codec.Decoder) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.decoder = decoder
+}
+
+// GetDecoder returns the decoder of the game's responses, JSON by default.
+func (handler *GameHandler) GetDecoder() codec.Decoder {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.decoder == nil {
+		return codec.JSON{}
+	}
+	return handler.decoder
+}
+
 // AddTask adds a new task to the handler.
 //
 // This method locks the handler's mutex to ensure thread-safe access to the tasks slice,
@@ -97,84 +503,439 @@ func (handler *GameHandler) AddTask(task tasks.Task) {
 
 // RunTasks executes all tasks for all accounts.
 //
-// This method iterates over all accounts and tasks, executing each task in a separate goroutine.
-// It uses a WaitGroup to ensure all tasks are completed before returning. One-time tasks are
-// executed immediately, while recurrent tasks are executed at regular intervals specified by
-// the task's interval.
+// This method iterates over all accounts and tasks, executing the tasks of each account in a
+// separate goroutine. It uses a WaitGroup to ensure all tasks are completed before returning.
+// One-time tasks are executed immediately, while scheduled tasks (see tasks.Scheduled) each get
+// their own loop and are executed at the times their schedule returns, measured with the
+// handler's clock (see SetClock).
 //
 // # Notes:
+//   - Disabled accounts (see types.Account.IsEnabled) are skipped.
 //   - One-time tasks are executed once per account.
 //   - Recurrent tasks executes at regular intervals until the program stops.
+//   - Daily tasks execute once per game day, in their window after the reset, until the program stops.
 //   - Errors during task execution do not stop the execution of other tasks.
+//   - Tasks of the same account may overlap unless SetSequentialAccounts is enabled.
+//
+// # Returns:
+//   - *RunReport: The summary of the run (see RunReport).
 //
 // # Example:
 //
-//	handler.RunTasks()
-func (handler *GameHandler) RunTasks() {
+//	report := handler.RunTasks()
+//	fmt.Println(report)
+func (handler *GameHandler) RunTasks() *RunReport {
+	return handler.RunTasksContext(context.Background())
+}
+
+// RunTasksContext executes all tasks for all accounts until ctx is done or the handler is drained.
+//
+// It behaves like RunTasks, except that scheduled tasks stop being scheduled when ctx is done or
+// Drain is called. Executions already running, and the one-time tasks already queued for an
+// account, still finish before RunTasksContext returns. Scheduled tasks run through the
+// handler's job queue (see SetJobQueue).
+//
+// # Parameters:
+//   - ctx: The context whose cancellation stops scheduling.
+//
+// # Returns:
+//   - *RunReport: The summary of the run (see RunReport).
+//
+// # Example:
+//
+//	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+//	defer stop()
+//	report := handler.RunTasksContext(ctx)
+//	fmt.Println(report)
+func (handler *GameHandler) RunTasksContext(ctx context.Context) *RunReport {
+	recorder := newRunRecorder(handler.GameName, RunModeDaemon, time.Now())
+	handler.mu.Lock()
+	draining := handler.drainChannel()
+	select {
+	case <-draining:
+		handler.mu.Unlock()
+		return recorder.finish(time.Now())
+	default:
+	}
+	handler.active.Add(1)
+	elector := handler.elector
+	handler.mu.Unlock()
+	defer handler.active.Done()
+
+	if elector != nil {
+		leaderCtx, resign := handler.lead(ctx, draining, elector)
+		defer resign()
+		if leaderCtx == nil {
+			return recorder.finish(time.Now())
+		}
+		ctx = leaderCtx
+	}
+	handler.tuneParallelism()
+	handler.warmUpConnections(ctx)
+
+	scheduled := handler.scheduledJobs()
+	refreshCtx, stopRefresh := context.WithCancel(ctx)
+	defer stopRefresh()
+	go handler.refreshProxies(refreshCtx, draining)
 	var wg sync.WaitGroup
-	for _, account := range handler.Accounts {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.runQueue(ctx, draining, recorder, scheduled)
+	}()
+	for _, account := range handler.rotateAccounts(handler.Accounts) {
+		if !account.IsEnabled() {
+			continue
+		}
 		wg.Add(1)
 		go func(account types.Account) {
 			defer wg.Done()
-			for _, task := range handler.Tasks {
-				switch t := task.(type) {
-				case *tasks.OneTimeTask:
-					if err := handler.runTaskWithRetry(account, t); err != nil {
-						log.Printf("Error executing one-time task for account %s: %v\n", account.TelegramData.TelegramId, err)
-					}
-				case *tasks.RecurrentTask:
-					func(task *tasks.RecurrentTask) {
-						ticker := time.NewTicker(task.Interval)
-						defer ticker.Stop()
-						for {
-							select {
-							case <-ticker.C:
-								if err := handler.runTaskWithRetry(account, task); err != nil {
-									log.Printf("Error executing recurrent task for account %s: %v\n", account.TelegramData.TelegramId, err)
-								}
-							}
-						}
-					}(t)
+			id := account.TelegramData.TelegramId
+			handler.diag.addGoroutine(id, 1)
+			defer handler.diag.addGoroutine(id, -1)
+			for i, task := range handler.Tasks {
+				handler.diag.setQueueDepth(id, len(handler.Tasks)-i-1)
+				if _, ok := task.(tasks.Scheduled); ok {
+					continue
+				}
+				if err := handler.runTaskWithRetry(recorder, account, task); err != nil {
+					utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, account).Error("Error executing one-time task",
+						zap.String("run_id", recorder.runID()), zap.Error(err))
 				}
 			}
 		}(account)
 	}
 	wg.Wait()
+	return recorder.finish(time.Now())
 }
 
-// runTaskWithRetry attempts to run a task for a given account, retrying once if it fails.
+// Drain stops scheduling new task executions and waits for the running ones to finish.
 //
-// This method first tries to execute the task. If the task fails, it attempts to refresh
-// the game data and retries the task once. If the retry also fails, the error is ignored.
+// Scheduled tasks are no longer started, while executions already running and the one-time
+// tasks already queued for an account finish normally. Drain returns once every RunTasks and
+// RunTasksContext call of the handler has returned, which allows rolling deployments of farm
+// workers without dropping executions. Once drained, the handler does not run tasks anymore.
+//
+// # Example:
+//
+//	go handler.RunTasks()
+//	<-sigterm
+//	handler.Drain()
+func (handler *GameHandler) Drain() {
+	handler.mu.Lock()
+	draining := handler.drainChannel()
+	select {
+	case <-draining:
+	default:
+		close(draining)
+	}
+	handler.mu.Unlock()
+	handler.GetLogger().Info("Draining task executions")
+	handler.active.Wait()
+}
+
+// Draining reports whether Drain was called.
+func (handler *GameHandler) Draining() bool {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	select {
+	case <-handler.drainChannel():
+		return true
+	default:
+		return false
+	}
+}
+
+// drainChannel returns the channel closed by Drain. It must be called with mu held.
+func (handler *GameHandler) drainChannel() chan struct{} {
+	if handler.draining == nil {
+		handler.draining = make(chan struct{})
+	}
+	return handler.draining
+}
+
+// SetSequentialAccounts guarantees that no two tasks run concurrently for the same account.
+//
+// When enabled, a task of an account (including its retries) waits for the account's running
+// task to finish, while different accounts still run in parallel. Concurrent requests from one
+// session are a ban trigger in several games.
 //
 // # Parameters:
+//   - sequential: Whether the tasks of one account run one at a time.
+//
+// # Example:
+//
+//	handler.SetSequentialAccounts(true)
+//	handler.RunTasks()
+func (handler *GameHandler) SetSequentialAccounts(sequential bool) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.sequential = sequential
+}
+
+// lockAccount locks the account's task mutex when sequential execution is enabled and returns
+// the function unlocking it, or nil when it is disabled.
+func (handler *GameHandler) lockAccount(telegramId string) func() {
+	handler.mu.Lock()
+	sequential := handler.sequential
+	handler.mu.Unlock()
+	if !sequential {
+		return nil
+	}
+	lock, _ := handler.accountLocks.LoadOrStore(telegramId, &sync.Mutex{})
+	accountLock := lock.(*sync.Mutex)
+	accountLock.Lock()
+	return accountLock.Unlock
+}
+
+// SetClock sets the clock used by the scheduler.
+//
+// The scheduler uses clock.Real by default. Tests can pass a testutil.FakeClock to drive
+// scheduled tasks without real sleeps.
+//
+// # Parameters:
+//   - schedulerClock: The clock used to time scheduled tasks.
+//
+// # Example:
+//
+//	fake := testutil.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	handler.SetClock(fake)
+func (handler *GameHandler) SetClock(schedulerClock clock.Clock) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.clock = schedulerClock
+}
+
+// Clock returns the clock of the handler (see SetClock), read by the now() function of the
+// expressions of tasks (see tasks.ClockProvider).
+func (handler *GameHandler) Clock() clock.Clock {
+	return handler.getClock()
+}
+
+// getClock returns the scheduler clock, defaulting to clock.Real.
+func (handler *GameHandler) getClock() clock.Clock {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.clock == nil {
+		return clock.Real
+	}
+	return handler.clock
+}
+
+// runTaskWithRetry attempts to run a task for a given account, retrying if it fails.
+//
+// The task is retried according to the handler's task retry policy (see SetTaskRetryPolicy),
+// which by default retries once, immediately. Before every retry, the game data of the
+// account is refreshed.
+//
+// # Parameters:
+//   - recorder: The recorder of the run the execution belongs to, or nil.
 //   - account: The account for which the task is being executed.
 //   - task: The task to be executed.
 //
 // # Returns:
-//   - error: An error if the task fails on both the initial attempt and the retry.
+//   - error: The error of the last attempt if every attempt fails.
 //
 // # Notes:
 //   - Errors during the initial task execution trigger a refresh of the game data.
-//   - If the refresh fails, the method returns without retrying the task.
-//   - If the retry fails, the method returns without further action.
-func (handler *GameHandler) runTaskWithRetry(account types.Account, task tasks.Task) error {
-	if err := task.Run(account, handler); err != nil {
-		refreshErr, _ := refreshGameData(
-			handler.HttpClient,
-			handler.GameName,
-			handler.APIKey,
-			account.TelegramData,
-			handler.Proxy,
-		)
-		if refreshErr != nil {
+//   - If the refresh fails, the method returns the task error without retrying.
+func (handler *GameHandler) runTaskWithRetry(recorder *runRecorder, account types.Account, task tasks.Task) (err error) {
+	result := newTaskResult(handler.GameName, account, task)
+	result.RunID = recorder.runID()
+	logger := utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, account)
+	if result.RunID != "" {
+		logger = logger.With(zap.String("run_id", result.RunID))
+	}
+	defer func() {
+		result.Err = err
+		handler.emitResult(result)
+	}()
+	if unlock := handler.lockAccount(account.TelegramData.TelegramId); unlock != nil {
+		defer unlock()
+	}
+	if throttle := handler.getThrottle(); throttle != nil {
+		defer throttle.acquire(account.TelegramData.TelegramId)()
+	}
+	handler.mu.Lock()
+	budget, paused := handler.budget, handler.paused
+	handler.mu.Unlock()
+	if paused {
+		logger.Debug("Skipping task, handler is paused",
+			zap.String("task", taskName(task)))
+		recorder.skip()
+		result.Skipped = true
+		return nil
+	}
+	if until, ok := handler.UnderMaintenance(); ok {
+		logger.Debug("Skipping task, game is under maintenance",
+			zap.String("task", taskName(task)), zap.Time("until", until))
+		recorder.skip()
+		result.Skipped = true
+		return nil
+	}
+	if budget != nil && budget.Skip {
+		if exhausted, resets := budget.Exhausted(); exhausted {
+			logger.Warn("Skipping task, request budget exhausted",
+				zap.Time("resets", resets))
+			recorder.skip()
+			result.Skipped = true
 			return nil
 		}
-		if retryErr := task.Run(account, handler); retryErr != nil {
+	}
+	if cooldown, ok := handler.coolingDown(account.TelegramData.TelegramId, handler.getClock().Now()); ok && !handler.probe(account) {
+		logger.Debug("Skipping task, account is cooling down",
+			zap.String("task", taskName(task)), zap.String("signal", cooldown.Signal.Rule), zap.Time("until", cooldown.Until))
+		recorder.skip()
+		result.Skipped = true
+		return nil
+	}
+	if warmUp := handler.warmUpPolicy(); warmUp.WarmingUp(account, handler.getClock().Now()) && !warmUp.Allows(taskName(task)) {
+		logger.Debug("Skipping task, account is warming up",
+			zap.String("task", taskName(task)))
+		recorder.skip()
+		result.Skipped = true
+		return nil
+	}
+	replayKey, protected := handler.replayKey(account, task)
+	if protected {
+		seen, err := handler.idempotencyGuard().Seen(replayKey)
+		if err != nil {
+			return fmt.Errorf("failed to check idempotency guard: %w", err)
+		}
+		if seen {
+			logger.Info("Skipping non-idempotent task, it already succeeded today",
+				zap.String("task", taskName(task)))
+			recorder.skip()
+			result.Skipped = true
 			return nil
 		}
 	}
-	return nil
+	if account, err = handler.hydrateAccount(account); err != nil {
+		return fmt.Errorf("failed to read account from accounts file: %w", err)
+	}
+	ctx, span := handler.startSpan(httpclient.ContextWithAccount(context.Background(), account.TelegramData.TelegramId), account, taskName(task))
+	defer func() { span.End(err) }()
+	result.TraceID = span.TraceID()
+	var timeout time.Duration
+	if limited, ok := task.(tasks.TimeLimited); ok && limited.ExecutionTimeout() > 0 {
+		timeout = limited.ExecutionTimeout()
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	policy := handler.taskRetryPolicy()
+	onRetry := policy.OnRetry
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		logger.Warn("Task failed, refreshing game data before retrying",
+			zap.Int("attempt", attempt), zap.Duration("delay", delay), zap.Error(err))
+		if onRetry != nil {
+			onRetry(attempt, err, delay)
+		}
+	}
+	var lastErr error
+	attempts := 0
+	started := time.Now()
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		attempt := retry.Attempt(ctx)
+		if attempt > 1 {
+			err := handler.refreshAccount(ctx, account)
+			recorder.refresh(err)
+			if err != nil {
+				return retry.Permanent(fmt.Errorf("%w (game data refresh failed: %v)", lastErr, err))
+			}
+		}
+		attempts = attempt
+		lastErr = task.Run(account, handler.newAccountHandler(ctx, account, task, attempt, result.RunID))
+		if errors.Is(lastErr, ErrBudgetExhausted) || errors.Is(lastErr, ErrPayloadTooLarge) || handler.detectMaintenance(lastErr) {
+			return retry.Permanent(lastErr)
+		}
+		if _, cooling := handler.coolingDown(account.TelegramData.TelegramId, handler.getClock().Now()); cooling && lastErr != nil {
+			return retry.Permanent(lastErr)
+		}
+		return lastErr
+	}, policy)
+	if err != nil && timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		if errors.Is(err, context.DeadlineExceeded) && lastErr != nil && !errors.Is(lastErr, context.DeadlineExceeded) {
+			err = lastErr
+		}
+		err = fmt.Errorf("task timed out after %s: %w", timeout, err)
+	}
+	result.Started, result.Duration, result.Attempts = started, time.Since(started), attempts
+	recorder.execution(taskName(task), attempts, result.Duration, err)
+	handler.recordActivity(account.TelegramData.TelegramId, taskName(task), started, err)
+	handler.recordStreak(account, task, err)
+	result.Err = err
+	handler.recordHistory(account, result)
+	handler.errorAggregator().Add(account.TelegramData.TelegramId, err)
+	handler.inspectTaskError(account, err)
+	if err == nil && protected {
+		if err := handler.idempotencyGuard().Record(replayKey, handler.getClock().Now()); err != nil {
+			logger.Error("Failed to record non-idempotent task",
+				zap.String("task", taskName(task)), zap.Error(err))
+		}
+	}
+	return err
+}
+
+// refreshAccount refreshes the game data of an account through the Nexus API.
+func (handler *GameHandler) refreshAccount(ctx context.Context, account types.Account) error {
+	client, proxy, err := handler.clientFor(account)
+	if err != nil {
+		return err
+	}
+	keys := handler.apiKeyPool()
+	if keys == nil {
+		return handler.refreshWithKey(ctx, client, proxy, account, handler.defaultAPIKey(), handler.apiKeyTracker())
+	}
+	candidates, err := keys.Candidates(handler.GameName, account.TelegramData.TelegramId)
+	if err != nil {
+		return err
+	}
+	for _, key := range candidates {
+		err = handler.refreshWithKey(ctx, client, proxy, account, key, keys.Tracker())
+		if !errors.Is(err, apikeys.ErrQuotaExceeded) {
+			return err
+		}
+		keys.MarkExhausted(key.Name)
+		utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, account).Warn("API key quota exceeded, failing over",
+			zap.String("key", key.Name), zap.Error(err))
+	}
+	return err
+}
+
+// SetTaskRetryPolicy sets how failed task executions are retried.
+//
+// Every error returned by a task is retried unless the policy's Classify function says
+// otherwise, and the game data of the account is refreshed before each retry.
+//
+// # Parameters:
+//   - policy: The retry policy. MaxAttempts counts the first execution.
+//
+// # Example:
+//
+//	handler.SetTaskRetryPolicy(retry.Policy{
+//		MaxAttempts:  3,
+//		InitialDelay: 5 * time.Second,
+//		Jitter:       retry.JitterEqual,
+//	})
+func (handler *GameHandler) SetTaskRetryPolicy(policy retry.Policy) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.taskRetry = &policy
+}
+
+// taskRetryPolicy returns the task retry policy, defaulting to a single immediate retry.
+func (handler *GameHandler) taskRetryPolicy() retry.Policy {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.taskRetry == nil {
+		return retry.Policy{MaxAttempts: 2, Classify: retry.Always}
+	}
+	policy := *handler.taskRetry
+	if policy.Classify == nil {
+		policy.Classify = retry.Always
+	}
+	return policy
 }
 
 // NewGameHandler creates a new instance of GameHandler by loading the necessary configuration
@@ -203,25 +964,229 @@ func (handler *GameHandler) runTaskWithRetry(account types.Account, task tasks.T
 //   - Ensure the provided file paths are valid and accessible.
 //   - The `BaseURL` field of the GameHandler is left empty and should be set manually
 //     before making API requests.
-func NewGameHandler(configPath, gameDataPath string) (*GameHandler, error) {
+//   - Accounts without a device profile get one (see device.Generate), which is written back
+//     to a local accounts file, and every account request carries its User-Agent and client hints.
+func NewGameHandler(configPath, gameDataPath string) (_ *GameHandler, err error) {
 	config, err := LoadConfig(configPath)
 	if err != nil {
 		return nil, err
 	}
-	accounts, err := LoadAccounts(gameDataPath)
-	if err != nil {
+	var lazy *LazyAccounts
+	var accounts []types.Account
+	if config.LazyAccounts && !remote.IsRemote(gameDataPath) {
+		if lazy, err = OpenLazyAccounts(gameDataPath); err != nil {
+			return nil, err
+		}
+		accounts = lazy.Accounts()
+	} else if accounts, err = LoadAccounts(gameDataPath); err != nil {
 		return nil, err
 	}
+	ensureDevices(gameDataPath, accounts)
 	httpClient, err := httpclient.NewHTTPClient(config.Proxy)
 	if err != nil {
 		return nil, err
 	}
-	handler := &GameHandler{
-		BaseURL:    "",
-		Proxy:      config.Proxy,
-		APIKey:     config.APIKey,
-		Accounts:   accounts,
-		HttpClient: httpClient,
+	var handler *GameHandler
+	defer func() {
+		// Release what was opened when the configuration fails part way.
+		if err == nil {
+			return
+		}
+		if handler != nil {
+			handler.release()
+		} else {
+			httpClient.Close()
+		}
+	}()
+	var pool *proxypool.Pool
+	provider, err := proxypool.ProviderFromConfig(config.ProxyPool.Provider)
+	if err != nil {
+		return nil, err
+	}
+	var source proxypool.Provider
+	if config.ProxyPool.File != "" || provider != nil {
+		var static proxypool.List
+		if config.ProxyPool.File != "" {
+			if static, err = proxypool.LoadProxies(config.ProxyPool.File); err != nil {
+				return nil, err
+			}
+		}
+		source = bandwidthDefault{source: proxypool.Join(static, provider), bytesPerSecond: config.ProxyPool.BytesPerSecond}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		proxies, err := source.Fetch(ctx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch proxies: %w", err)
+		}
+		if provider == nil {
+			source = nil
+		}
+		ttl := time.Duration(config.ProxyPool.StickyTTLMinutes) * time.Minute
+		pool, err = proxypool.NewPool(proxies, proxypool.Strategy(config.ProxyPool.Strategy), ttl)
+		if err != nil {
+			return nil, err
+		}
+		pool.SetHealthPolicy(proxypool.HealthPolicy{
+			Threshold:   config.ProxyPool.FailureThreshold,
+			MinRequests: config.ProxyPool.MinRequests,
+			Window:      time.Duration(config.ProxyPool.WindowMinutes) * time.Minute,
+		})
+		mode, err := proxypool.ParseCountryMode(config.ProxyPool.CountryMode)
+		if err != nil {
+			return nil, err
+		}
+		pool.SetCountryMode(mode)
+		pool.SetSessionTTL(time.Duration(config.ProxyPool.SessionTTLMinutes) * time.Minute)
+	}
+	handler = &GameHandler{
+		BaseURL:      "",
+		BaseURLs:     config.BaseURLs,
+		Proxy:        config.Proxy,
+		APIKey:       config.APIKey,
+		Accounts:     accounts,
+		HttpClient:   httpClient,
+		ProxyPool:    pool,
+		sequential:   config.SequentialAccounts,
+		hedge:        NewHedgePolicy(config.Hedging),
+		throttle:     NewThrottle(config.AdaptiveThrottle),
+		warmUp:       NewWarmUpPolicy(config.WarmUp),
+		bans:         bandetect.FromConfig(config.BanDetection),
+		recovery:     NewCooldownPolicy(config.BanDetection.Cooldown),
+		apiKeys:      apikeys.FromConfig(config),
+		proxySource:  source,
+		proxyRefresh: time.Duration(config.ProxyPool.Provider.RefreshMinutes) * time.Minute,
+		lazy:         lazy,
+		poolName:     config.ProxyPool.Name,
+		preconnect:   NewPreconnect(config.Preconnect),
+		payloadLimit: NewPayloadLimits(config.PayloadLimits),
+		backoff:      newIntervalBackoff(config.IntervalBackoff),
+	}
+	if handler.maintenance, err = NewMaintenance(config.Maintenance); err != nil {
+		return nil, err
+	}
+	httpClient.SetObserver(latencyObserver{handler: handler})
+	tlsOptions := httpclient.TLSOptions{
+		RootCAFiles: config.TLS.CAFiles,
+		CertFile:    config.TLS.CertFile,
+		KeyFile:     config.TLS.KeyFile,
+		ServerName:  config.TLS.ServerName,
+		Pins:        config.TLS.Pins,
+	}
+	if !tlsOptions.IsZero() {
+		if err := handler.SetTLSOptions(tlsOptions); err != nil {
+			return nil, err
+		}
+	}
+	networkOptions := httpclient.NetworkOptions{
+		LocalAddr:           config.Network.LocalAddress,
+		Interface:           config.Network.Interface,
+		PreferIPv6:          config.Network.PreferIPv6,
+		DialTimeout:         time.Duration(config.Network.DialTimeoutMs) * time.Millisecond,
+		KeepAlive:           time.Duration(config.Network.KeepAliveSeconds) * time.Second,
+		FallbackDelay:       time.Duration(config.Network.FallbackDelayMs) * time.Millisecond,
+		TLSHandshakeTimeout: time.Duration(config.Network.TLSHandshakeTimeoutMs) * time.Millisecond,
+	}
+	if !networkOptions.IsZero() {
+		if err := handler.SetNetworkOptions(networkOptions); err != nil {
+			return nil, err
+		}
+	}
+	if config.ContentTypes.Disabled {
+		handler.SetContentTypes(httpclient.ContentTypes{})
+	} else if config.ContentTypes.ContentType != "" || config.ContentTypes.Accept != "" {
+		contentTypes := httpclient.DefaultContentTypes
+		if config.ContentTypes.ContentType != "" {
+			contentTypes.ContentType = config.ContentTypes.ContentType
+		}
+		if config.ContentTypes.Accept != "" {
+			contentTypes.Accept = config.ContentTypes.Accept
+		}
+		handler.SetContentTypes(contentTypes)
+	}
+	if config.Codec != "" {
+		decoder, err := codec.Lookup(config.Codec)
+		if err != nil {
+			return nil, err
+		}
+		handler.decoder = decoder
+	}
+	if config.Rotation != "" {
+		rotation, err := ParseRotationStrategy(config.Rotation)
+		if err != nil {
+			return nil, err
+		}
+		handler.rotation = rotation
+	}
+	backend, err := storage.FromConfig(config.Storage)
+	if err != nil {
+		return nil, err
+	}
+	handler.backend = backend
+	if config.IdempotencyFile != "" {
+		guard, err := idempotency.OpenFileGuard(config.IdempotencyFile, 2)
+		if err != nil {
+			return nil, err
+		}
+		handler.guard = guard
+	} else if backend != nil {
+		handler.guard = idempotency.NewStoredGuard(backend)
+	}
+	if config.SingleFlight {
+		handler.SetSingleFlight(true)
+	}
+	if config.AuditLog != "" {
+		auditLog, err := audit.OpenFileLog(config.AuditLog)
+		if err != nil {
+			return nil, err
+		}
+		handler.SetAuditLog(auditLog)
+	}
+	elector, err := election.FromConfig(config.Election)
+	if err != nil {
+		return nil, err
+	}
+	handler.elector = elector
+	queue, err := jobqueue.FromConfig(config.JobQueue)
+	if err != nil {
+		return nil, err
+	}
+	handler.queue = queue
+	if queue == nil && backend != nil {
+		handler.queue = jobqueue.NewStored(backend)
+	}
+	store, err := history.FromConfig(config.History)
+	if err != nil {
+		return nil, err
+	}
+	handler.history = store
+	if store == nil && backend != nil {
+		handler.history = history.NewStored(backend, config.History.Limit)
+	}
+	if config.Budget.MaxRequestsPerHour > 0 || config.Budget.MaxBandwidthMBPerDay > 0 {
+		handler.SetBudget(NewBudget(config.Budget))
+	}
+	if config.ErrorBudget.MaxFailuresPerHour > 0 {
+		handler.SetErrorBudget(NewErrorBudget(config.ErrorBudget))
+	}
+	if parallelism := NewParallelism(config.Parallelism); parallelism != nil {
+		handler.SetParallelism(parallelism)
+	}
+	events, err := sink.FromConfig(config.Sinks)
+	if err != nil {
+		return nil, err
+	}
+	if events != nil {
+		handler.SetEventSink(events)
+	}
+	handler.registerMetrics()
+	if config.SecretMemory != "" {
+		mode, err := secrets.ParseMemoryMode(config.SecretMemory)
+		if err != nil {
+			return nil, err
+		}
+		if err := handler.SetSecretMemory(mode); err != nil {
+			return nil, err
+		}
 	}
 	return handler, nil
 }
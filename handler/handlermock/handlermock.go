@@ -0,0 +1,514 @@
+package handlermock
+
+import (
+	"context"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/codec"
+	"github.com/nexus-telegram/NexusSDK/handler"
+	"github.com/nexus-telegram/NexusSDK/history"
+	"github.com/nexus-telegram/NexusSDK/jobqueue"
+	"github.com/nexus-telegram/NexusSDK/tasks"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// PostCall is a call to Handler.Post recorded by the mock.
+type PostCall struct {
+	URL     string
+	Payload []byte
+	Headers map[string]string
+}
+
+// TaskRun is a task execution recorded by Handler.RunTasks.
+type TaskRun struct {
+	Account  types.Account
+	Task     tasks.Task
+	Err      error
+	Started  time.Time
+	Duration time.Duration
+	RunID    string
+}
+
+// Handler is an in-memory implementation of handler.Interface for unit tests.
+//
+// It sends no HTTP requests and reads no files: Post and Get answer through PostFunc and GetFunc,
+// and every call is recorded. RunTasks runs each task once for every enabled account,
+// sequentially, with the mock itself as the tasks.Handler, so task logic can be tested without
+// real scheduling.
+//
+// # Fields:
+//   - GameName: The name returned by GetGameName.
+//   - BaseURL: The URL returned by GetBaseURL.
+//   - BaseURLs: The named base URLs returned by GetNamedBaseURL.
+//   - Accounts: The accounts returned by GetAccounts and used by RunTasks.
+//   - Tasks: The tasks added with AddTask.
+//   - PostFunc: Answers Post calls. When nil, Post returns an empty JSON object.
+//   - GetFunc: Answers Get calls. When nil, Get returns an empty JSON object.
+//   - Logger: The logger returned by GetLogger. Defaults to a no-op logger.
+//   - Decoder: The decoder returned by GetDecoder. Defaults to codec.JSON.
+//
+// # Example:
+//
+//	mock := &handlermock.Handler{
+//		Accounts: []types.Account{{TelegramData: types.TelegramData{TelegramId: "1"}}},
+//		PostFunc: func(url string, payload []byte) ([]byte, error) {
+//			return []byte(`{"balance": 100}`), nil
+//		},
+//	}
+//	mock.AddTask(myTask)
+//	mock.RunTasks()
+//	if len(mock.PostCalls()) != 1 {
+//		t.Fatalf("expected one request")
+//	}
+type Handler struct {
+	GameName string
+	BaseURL  string
+	BaseURLs map[string]string
+	Accounts []types.Account
+	Tasks    []tasks.Task
+	PostFunc func(url string, payload []byte) ([]byte, error)
+	GetFunc  func(url string) ([]byte, error)
+	Logger   *zap.Logger
+	Decoder  codec.Decoder
+	mu       sync.Mutex
+	posts    []PostCall
+	gets     []string
+	runs     []TaskRun
+	profiles map[string]interface{}
+	locks    sync.Map
+	draining bool
+	paused   bool
+	apiKey   string
+	closed   bool
+}
+
+var (
+	_ handler.Interface   = (*Handler)(nil)
+	_ tasks.HeaderSender  = (*Handler)(nil)
+	_ tasks.ProfileLocker = (*Handler)(nil)
+)
+
+// Post records the call and answers it through PostFunc.
+func (mock *Handler) Post(url string, payload []byte) ([]byte, error) {
+	mock.mu.Lock()
+	mock.posts = append(mock.posts, PostCall{URL: url, Payload: append([]byte(nil), payload...)})
+	postFunc := mock.PostFunc
+	mock.mu.Unlock()
+	if postFunc == nil {
+		return []byte("{}"), nil
+	}
+	return postFunc(url, payload)
+}
+
+// PostWithHeaders records the call with its headers and answers it through PostFunc.
+func (mock *Handler) PostWithHeaders(url string, payload []byte, headers map[string]string) ([]byte, error) {
+	mock.mu.Lock()
+	mock.posts = append(mock.posts, PostCall{URL: url, Payload: append([]byte(nil), payload...), Headers: headers})
+	postFunc := mock.PostFunc
+	mock.mu.Unlock()
+	if postFunc == nil {
+		return []byte("{}"), nil
+	}
+	return postFunc(url, payload)
+}
+
+// PostCalls returns the Post calls recorded so far.
+func (mock *Handler) PostCalls() []PostCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]PostCall(nil), mock.posts...)
+}
+
+// Get records the call and answers it through GetFunc.
+func (mock *Handler) Get(url string) ([]byte, error) {
+	mock.mu.Lock()
+	mock.gets = append(mock.gets, url)
+	getFunc := mock.GetFunc
+	mock.mu.Unlock()
+	if getFunc == nil {
+		return []byte("{}"), nil
+	}
+	return getFunc(url)
+}
+
+// GetWithHeaders records the call and answers it through GetFunc. The headers are ignored.
+func (mock *Handler) GetWithHeaders(url string, headers map[string]string) ([]byte, error) {
+	return mock.Get(url)
+}
+
+// GetCalls returns the URLs of the Get calls recorded so far.
+func (mock *Handler) GetCalls() []string {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]string(nil), mock.gets...)
+}
+
+// TaskRuns returns the task executions recorded by RunTasks so far.
+func (mock *Handler) TaskRuns() []TaskRun {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]TaskRun(nil), mock.runs...)
+}
+
+// GetGameName returns GameName.
+func (mock *Handler) GetGameName() string {
+	return mock.GameName
+}
+
+// GetBaseURL returns BaseURL.
+func (mock *Handler) GetBaseURL() string {
+	return mock.BaseURL
+}
+
+// SetBaseURL sets BaseURL.
+func (mock *Handler) SetBaseURL(url string) {
+	mock.BaseURL = url
+}
+
+// GetNamedBaseURL returns the URL of a name in BaseURLs, or BaseURL for the empty name.
+func (mock *Handler) GetNamedBaseURL(name string) (string, bool) {
+	if name == "" {
+		return mock.BaseURL, true
+	}
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	url, ok := mock.BaseURLs[name]
+	return url, ok
+}
+
+// SetNamedBaseURL sets the URL of a name in BaseURLs, or removes the name when url is empty.
+func (mock *Handler) SetNamedBaseURL(name, url string) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	if url == "" {
+		delete(mock.BaseURLs, name)
+		return
+	}
+	if mock.BaseURLs == nil {
+		mock.BaseURLs = make(map[string]string)
+	}
+	mock.BaseURLs[name] = url
+}
+
+// GetAccounts returns Accounts.
+func (mock *Handler) GetAccounts() []types.Account {
+	return mock.Accounts
+}
+
+// GetDecoder returns Decoder, codec.JSON if it is nil.
+func (mock *Handler) GetDecoder() codec.Decoder {
+	if mock.Decoder == nil {
+		return codec.JSON{}
+	}
+	return mock.Decoder
+}
+
+// GetLogger returns Logger, or a no-op logger.
+func (mock *Handler) GetLogger() *zap.Logger {
+	if mock.Logger == nil {
+		return zap.NewNop()
+	}
+	return mock.Logger
+}
+
+// LoadProfile returns the profile stored for an account.
+func (mock *Handler) LoadProfile(telegramId string) (interface{}, bool) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	profile, ok := mock.profiles[telegramId]
+	return profile, ok
+}
+
+// StoreProfile stores the profile of an account.
+func (mock *Handler) StoreProfile(telegramId string, profile interface{}) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	if mock.profiles == nil {
+		mock.profiles = make(map[string]interface{})
+	}
+	mock.profiles[telegramId] = profile
+}
+
+// LockProfile locks the profile of an account until unlock is called, like
+// handler.GameHandler.LockProfile.
+func (mock *Handler) LockProfile(telegramId string) (unlock func()) {
+	lock, _ := mock.locks.LoadOrStore(telegramId, &sync.Mutex{})
+	mutex := lock.(*sync.Mutex)
+	mutex.Lock()
+	return mutex.Unlock
+}
+
+// AddTask appends a task to Tasks.
+func (mock *Handler) AddTask(task tasks.Task) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	mock.Tasks = append(mock.Tasks, task)
+}
+
+// RunTasks runs every task once for every enabled account, records the executions, and
+// returns a report of them.
+func (mock *Handler) RunTasks() *handler.RunReport {
+	return mock.RunTasksContext(context.Background())
+}
+
+// RunTasksContext behaves like RunTasks, stopping before the next execution when ctx is done
+// or the mock is drained. Executions are skipped while the mock is paused.
+func (mock *Handler) RunTasksContext(ctx context.Context) *handler.RunReport {
+	started := time.Now()
+	report := &handler.RunReport{RunID: handler.NewRunID(started), Mode: handler.RunModeDaemon, Game: mock.GameName, Started: started,
+		Tasks: make(map[string]*handler.TaskStats)}
+	defer func() {
+		report.Finished = time.Now()
+		report.Duration = report.Finished.Sub(report.Started)
+	}()
+	mock.mu.Lock()
+	taskList := append([]tasks.Task(nil), mock.Tasks...)
+	mock.mu.Unlock()
+	for _, account := range mock.Accounts {
+		if !account.IsEnabled() {
+			continue
+		}
+		for _, task := range taskList {
+			if ctx.Err() != nil || mock.Draining() {
+				return report
+			}
+			if mock.Paused() {
+				report.Skipped++
+				continue
+			}
+			started := time.Now()
+			err := task.Run(account, mock)
+			mock.mu.Lock()
+			mock.runs = append(mock.runs, TaskRun{Account: account, Task: task, Err: err, Started: started, Duration: time.Since(started), RunID: report.RunID})
+			mock.mu.Unlock()
+			recordRun(report, task, time.Since(started), err)
+		}
+	}
+	return report
+}
+
+// RunOnce behaves like RunTasksContext, stopping before the next execution once maxDuration
+// has elapsed. The executions left are counted in the report's Unstarted.
+func (mock *Handler) RunOnce(ctx context.Context, maxDuration time.Duration) *handler.RunReport {
+	if maxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, maxDuration)
+		defer cancel()
+	}
+	report := mock.RunTasksContext(ctx)
+	report.Mode = handler.RunModeOnce
+	if ctx.Err() != nil {
+		mock.mu.Lock()
+		taskCount := len(mock.Tasks)
+		mock.mu.Unlock()
+		total := 0
+		for _, account := range mock.Accounts {
+			if account.IsEnabled() {
+				total += taskCount
+			}
+		}
+		for _, stats := range report.Tasks {
+			total -= stats.Runs
+		}
+		report.Unstarted = max(total-report.Skipped, 0)
+	}
+	return report
+}
+
+// recordRun adds a task execution to report, grouping errors by message.
+func recordRun(report *handler.RunReport, task tasks.Task, duration time.Duration, err error) {
+	name := taskName(task)
+	stats, ok := report.Tasks[name]
+	if !ok {
+		stats = &handler.TaskStats{}
+		report.Tasks[name] = stats
+	}
+	stats.Runs++
+	stats.Duration += duration
+	if err == nil {
+		stats.Successes++
+		return
+	}
+	stats.Failures++
+	for i := range report.Errors {
+		if report.Errors[i].Type == err.Error() {
+			report.Errors[i].Count++
+			return
+		}
+	}
+	report.Errors = append(report.Errors, handler.ErrorCount{Type: err.Error(), Category: handler.ClassifyError(err), Count: 1, Example: err.Error()})
+}
+
+// taskName returns the name of a task, or its type when it has no name.
+func taskName(task tasks.Task) string {
+	if named, ok := task.(interface{ GetName() string }); ok {
+		return named.GetName()
+	}
+	return fmt.Sprintf("%T", task)
+}
+
+// Drain makes subsequent and running RunTasks calls stop before their next execution.
+func (mock *Handler) Drain() {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	mock.draining = true
+}
+
+// Draining reports whether Drain was called.
+func (mock *Handler) Draining() bool {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return mock.draining
+}
+
+// Close drains the mock and records that it was closed, reported by Closed.
+func (mock *Handler) Close() error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	mock.draining = true
+	mock.closed = true
+	return nil
+}
+
+// Closed reports whether Close was called.
+func (mock *Handler) Closed() bool {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return mock.closed
+}
+
+// Pause records that task executions are paused.
+func (mock *Handler) Pause() {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	mock.paused = true
+}
+
+// Resume undoes Pause.
+func (mock *Handler) Resume() {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	mock.paused = false
+}
+
+// Paused reports whether Pause was called without a later Resume.
+func (mock *Handler) Paused() bool {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return mock.paused
+}
+
+// SetAPIKey records the API key, returned by APIKey.
+func (mock *Handler) SetAPIKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("api key is empty")
+	}
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	mock.apiKey = key
+	return nil
+}
+
+// APIKey returns the API key last set with SetAPIKey.
+func (mock *Handler) APIKey() string {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return mock.apiKey
+}
+
+// Diagnostics returns a snapshot with the game, account, and task counts.
+func (mock *Handler) Diagnostics() handler.Diagnostics {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return handler.Diagnostics{
+		Game:        mock.GameName,
+		Accounts:    len(mock.Accounts),
+		Tasks:       len(mock.Tasks),
+		Goroutines:  map[string]int{},
+		QueueDepths: map[string]int{},
+		Paused:      mock.paused,
+	}
+}
+
+// ErrorStats returns the failures of the recorded task executions grouped by category.
+func (mock *Handler) ErrorStats() handler.ErrorStats {
+	aggregator := handler.NewErrorAggregator()
+	for _, run := range mock.TaskRuns() {
+		aggregator.Add(run.Account.TelegramData.TelegramId, run.Err)
+	}
+	return aggregator.Snapshot()
+}
+
+// Jobs returns no jobs: the mock runs scheduled tasks once, without a job queue.
+func (mock *Handler) Jobs() ([]jobqueue.Job, error) {
+	return nil, nil
+}
+
+// CancelJob returns jobqueue.ErrJobNotFound.
+func (mock *Handler) CancelJob(id string) error {
+	return jobqueue.ErrJobNotFound
+}
+
+// ResumeJob returns jobqueue.ErrJobNotFound.
+func (mock *Handler) ResumeJob(id string) error {
+	return jobqueue.ErrJobNotFound
+}
+
+// GetTaskHistory returns the recorded task executions matching account and task, most recent
+// first. See handler.GameHandler.GetTaskHistory.
+func (mock *Handler) GetTaskHistory(account, task string, limit int) ([]history.Execution, error) {
+	var executions []history.Execution
+	runs := mock.TaskRuns()
+	for i := len(runs) - 1; i >= 0 && (limit <= 0 || len(executions) < limit); i-- {
+		run := runs[i]
+		name := taskName(run.Task)
+		if (account != "" && run.Account.TelegramData.TelegramId != account) || (task != "" && name != task) {
+			continue
+		}
+		execution := history.Execution{
+			Game:     mock.GameName,
+			Account:  run.Account.TelegramData.TelegramId,
+			Task:     name,
+			Started:  run.Started,
+			Duration: run.Duration,
+			Attempts: 1,
+			Success:  run.Err == nil,
+			RunID:    run.RunID,
+		}
+		if run.Err != nil {
+			execution.Error = run.Err.Error()
+		}
+		executions = append(executions, execution)
+	}
+	return executions, nil
+}
+
+// ListAccounts returns the listing view of Accounts.
+func (mock *Handler) ListAccounts() []handler.AccountInfo {
+	accounts := make([]handler.AccountInfo, 0, len(mock.Accounts))
+	for _, account := range mock.Accounts {
+		info := handler.AccountInfo{
+			TelegramId: account.TelegramData.TelegramId,
+			Label:      account.Label,
+			Notes:      account.Notes,
+			Enabled:    account.IsEnabled(),
+		}
+		if !account.CreatedAt.IsZero() {
+			createdAt := account.CreatedAt
+			info.CreatedAt = &createdAt
+		}
+		accounts = append(accounts, info)
+	}
+	return accounts
+}
+
+// Schedule returns the executions of Tasks for Accounts within horizon from now. See
+// handler.PreviewSchedule.
+func (mock *Handler) Schedule(horizon time.Duration) []handler.ScheduledRun {
+	mock.mu.Lock()
+	taskList := append([]tasks.Task(nil), mock.Tasks...)
+	mock.mu.Unlock()
+	return handler.PreviewSchedule(mock.Accounts, taskList, time.Now(), horizon)
+}
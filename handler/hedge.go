@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/metrics"
+	"github.com/nexus-telegram/NexusSDK/proxypool"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"go.uber.org/zap"
+	"time"
+)
+
+// minHedgeSamples is the number of latency samples needed before the percentile of an
+// endpoint is trusted as a hedging delay; MaxDelay is used until then.
+const minHedgeSamples = 20
+
+// HedgePolicy configures hedged GET requests.
+//
+// When a GET request sent through a proxy of the pool has not been answered after the
+// endpoint's latency percentile, a second copy is sent through another proxy and whichever
+// answers first is used. The slower request is cancelled. This cuts the tail latency of
+// unreliable residential pools at the cost of a few duplicate requests.
+//
+// # Fields:
+//   - Percentile: The latency percentile (0 < Percentile <= 1) after which the second request is sent.
+//   - MinDelay: The lower bound of the hedging delay.
+//   - MaxDelay: The upper bound of the hedging delay, also used while the endpoint has too few samples.
+//
+// # Notes:
+//   - Only GET requests are hedged, since they are idempotent. POST requests are never duplicated.
+//   - Hedging requires a proxy pool with at least two healthy proxies.
+type HedgePolicy struct {
+	Percentile float64
+	MinDelay   time.Duration
+	MaxDelay   time.Duration
+}
+
+// NewHedgePolicy creates a hedge policy from the hedging section of the configuration file,
+// or returns nil when hedging is disabled.
+func NewHedgePolicy(config types.HedgingConfig) *HedgePolicy {
+	if !config.Enabled {
+		return nil
+	}
+	policy := &HedgePolicy{
+		Percentile: config.Percentile / 100,
+		MinDelay:   time.Duration(config.MinDelayMs) * time.Millisecond,
+		MaxDelay:   time.Duration(config.MaxDelayMs) * time.Millisecond,
+	}
+	if policy.Percentile <= 0 || policy.Percentile > 1 {
+		policy.Percentile = 0.95
+	}
+	if policy.MinDelay <= 0 {
+		policy.MinDelay = 50 * time.Millisecond
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = 5 * time.Second
+	}
+	return policy
+}
+
+// delay returns the hedging delay of an endpoint from its recent latencies.
+func (policy *HedgePolicy) delay(game, endpoint string) time.Duration {
+	delay, samples := metrics.Default.Percentile(game, endpoint, policy.Percentile)
+	if samples < minHedgeSamples || delay > policy.MaxDelay {
+		return policy.MaxDelay
+	}
+	if delay < policy.MinDelay {
+		return policy.MinDelay
+	}
+	return delay
+}
+
+// SetHedgePolicy enables hedged GET requests for the accounts of the handler. Passing nil
+// disables hedging.
+//
+// # Example:
+//
+//	handler.SetHedgePolicy(&handler.HedgePolicy{Percentile: 0.9, MinDelay: 100 * time.Millisecond, MaxDelay: 3 * time.Second})
+func (handler *GameHandler) SetHedgePolicy(policy *HedgePolicy) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.hedge = policy
+}
+
+// hedgeResult is the outcome of one copy of a hedged request.
+type hedgeResult struct {
+	body  []byte
+	err   error
+	proxy types.Proxy
+}
+
+// hedgedGet sends a GET request bound to ctx through client and, if it is still pending after the
+// hedging delay, a second copy through another proxy of the pool.
+func (view *accountHandler) hedgedGet(ctx context.Context, policy *HedgePolicy, client *httpclient.HTTPClient, proxy types.Proxy, url string) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan hedgeResult, 2)
+	send := func(client *httpclient.HTTPClient, proxy types.Proxy) {
+		go func() {
+			body, err := getWith(ctx, client, url)
+			results <- hedgeResult{body: body, err: err, proxy: proxy}
+		}()
+	}
+	send(client, proxy)
+	timer := view.getClock().NewTimer(policy.delay(view.GameName, metrics.Endpoint("GET", url)))
+	defer timer.Stop()
+	pending, hedged := 1, false
+	for {
+		select {
+		case <-timer.C():
+			hedged = true
+			alternate, alternateClient, err := view.alternateClient(proxy, view.account)
+			if err != nil {
+				continue
+			}
+			view.GetLogger().Debug("Hedging slow request", zap.String("url", url))
+			send(alternateClient, alternate)
+			pending++
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				return result.body, nil
+			}
+			view.reportProxyError(result.proxy, result.err)
+			if pending == 0 || !hedged {
+				return nil, result.err
+			}
+		}
+	}
+}
+
+// alternateClient returns a proxy of the pool other than proxy, of the account's country, and
+// the account's HTTP client for it.
+func (handler *GameHandler) alternateClient(proxy types.Proxy, account types.Account) (types.Proxy, *httpclient.HTTPClient, error) {
+	handler.mu.Lock()
+	pool := handler.ProxyPool
+	handler.mu.Unlock()
+	if pool == nil {
+		return proxy, nil, proxypool.ErrNoHealthyProxy
+	}
+	alternate, err := pool.AlternateIn(proxy, account.Country)
+	if err != nil {
+		return alternate, nil, err
+	}
+	client, err := handler.sessionClient(pool, account, alternate)
+	return alternate, client, err
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"github.com/nexus-telegram/NexusSDK/history"
+	"github.com/nexus-telegram/NexusSDK/sink"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+)
+
+// SetHistoryStore sets the store recording every task execution of the handler. Without a
+// store, the last history.DefaultLimit executions of every task are kept in memory.
+//
+// # Parameters:
+//   - store: The history store, e.g. history.OpenFile or history.NewRedis.
+func (handler *GameHandler) SetHistoryStore(store history.Store) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.history = store
+}
+
+// historyStore returns the history store of the handler, creating an in-memory one if needed.
+func (handler *GameHandler) historyStore() history.Store {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.history == nil {
+		handler.history = history.NewMemory(history.DefaultLimit)
+	}
+	return handler.history
+}
+
+// GetTaskHistory returns the recent executions of a task for an account, most recent first.
+//
+// # Parameters:
+//   - account: The Telegram ID of the account, or "" for every account.
+//   - task: The name of the task, or "" for every task.
+//   - limit: The maximum number of executions returned, or 0 for all the stored ones.
+//
+// # Returns:
+//   - []history.Execution: The executions with their start time, duration, and result.
+//   - error: An error if the history store cannot be read.
+//
+// # Example:
+//
+//	executions, err := handler.GetTaskHistory("987654321", "claim", 10)
+//	if err != nil {
+//		log.Fatalf("Failed to read task history: %v", err)
+//	}
+//	for _, execution := range executions {
+//		fmt.Println(execution.Started, execution.Duration, execution.Success)
+//	}
+func (handler *GameHandler) GetTaskHistory(account, task string, limit int) ([]history.Execution, error) {
+	return handler.historyStore().List(context.Background(), handler.GameName, account, task, limit)
+}
+
+// recordHistory adds a finished task execution to the history store and the task metrics, with
+// the ID of its trace, if any, and publishes its result to the event sink.
+func (handler *GameHandler) recordHistory(account types.Account, result TaskResult) {
+	task, err := result.Task, result.Err
+	execution := history.Execution{
+		Game:     handler.GameName,
+		Account:  account.TelegramData.TelegramId,
+		Task:     task,
+		Started:  result.Started,
+		Duration: result.Duration,
+		Attempts: result.Attempts,
+		Success:  err == nil,
+		RunID:    result.RunID,
+	}
+	if err != nil {
+		execution.Error = err.Error()
+	}
+	if err := handler.historyStore().Append(context.Background(), execution); err != nil {
+		utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, account).Warn("Failed to record task history",
+			zap.String("task", task), zap.Error(err))
+	}
+	handler.observeTask(account, task, execution.Duration, result.TraceID, err)
+	handler.publishEvent(sink.Event{
+		Type:     sink.EventTaskResult,
+		Time:     execution.Started.Add(execution.Duration),
+		Account:  execution.Account,
+		Task:     task,
+		Duration: execution.Duration,
+		Attempts: execution.Attempts,
+		Success:  execution.Success,
+		Error:    execution.Error,
+		RunID:    execution.RunID,
+	})
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/nexus-telegram/NexusSDK/idempotency"
+	"github.com/nexus-telegram/NexusSDK/tasks"
+	"github.com/nexus-telegram/NexusSDK/types"
+)
+
+// SetIdempotencyGuard sets the guard refusing to run again the non-idempotent tasks (see
+// tasks.ReplayProtected) that already succeeded for an account on the same day with the same
+// payload. Without a guard, an in-memory one is used, which does not survive restarts.
+//
+// # Parameters:
+//   - guard: The guard recording the successful executions, e.g. an idempotency.FileGuard.
+//
+// # Example:
+//
+//	guard, err := idempotency.OpenFileGuard("state/idempotency.jsonl", 2)
+//	if err != nil {
+//		log.Fatalf("Failed to open idempotency guard: %v", err)
+//	}
+//	handler.SetIdempotencyGuard(guard)
+func (handler *GameHandler) SetIdempotencyGuard(guard idempotency.Guard) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.guard = guard
+}
+
+// idempotencyGuard returns the guard of the handler, creating an in-memory one if needed.
+func (handler *GameHandler) idempotencyGuard() idempotency.Guard {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.guard == nil {
+		handler.guard = idempotency.NewMemoryGuard()
+	}
+	return handler.guard
+}
+
+// replayKey returns the idempotency key of a task execution for account today, or false if
+// the task may be run again.
+func (handler *GameHandler) replayKey(account types.Account, task tasks.Task) (string, bool) {
+	protected, ok := task.(tasks.ReplayProtected)
+	if !ok || !protected.ReplayProtected() {
+		return "", false
+	}
+	var payload []byte
+	if withPayload, ok := task.(interface{ GetPayload() map[string]interface{} }); ok {
+		payload, _ = json.Marshal(withPayload.GetPayload())
+	}
+	return idempotency.Key(account.TelegramData.TelegramId, taskName(task), payload, handler.getClock().Now()), true
+}
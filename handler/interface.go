@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"github.com/nexus-telegram/NexusSDK/history"
+	"github.com/nexus-telegram/NexusSDK/jobqueue"
+	"github.com/nexus-telegram/NexusSDK/tasks"
+	"time"
+)
+
+// Interface is the public behavior of a GameHandler.
+//
+// Application code built on the SDK should depend on Interface rather than *GameHandler, so it
+// can be unit-tested with handlermock.Handler instead of real HTTP requests and files. Tasks
+// depend on the narrower tasks.Handler, which Interface embeds.
+//
+// # Example:
+//
+//	func startFarm(ctx context.Context, gameHandler handler.Interface) {
+//		gameHandler.AddTask(tasks.NewOneTimeTask("claim", payload))
+//		gameHandler.RunTasksContext(ctx)
+//	}
+type Interface interface {
+	tasks.Handler
+	GetGameName() string
+	SetBaseURL(url string)
+	SetNamedBaseURL(name, url string)
+	AddTask(task tasks.Task)
+	RunTasks() *RunReport
+	RunTasksContext(ctx context.Context) *RunReport
+	RunOnce(ctx context.Context, maxDuration time.Duration) *RunReport
+	Drain()
+	Draining() bool
+	Close() error
+	Pause()
+	Resume()
+	Paused() bool
+	SetAPIKey(key string) error
+	Diagnostics() Diagnostics
+	ErrorStats() ErrorStats
+	ListAccounts() []AccountInfo
+	Schedule(horizon time.Duration) []ScheduledRun
+	Jobs() ([]jobqueue.Job, error)
+	CancelJob(id string) error
+	ResumeJob(id string) error
+	GetTaskHistory(account, task string, limit int) ([]history.Execution, error)
+}
+
+var _ Interface = (*GameHandler)(nil)
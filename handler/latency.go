@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"github.com/nexus-telegram/NexusSDK/metrics"
+	"time"
+)
+
+// latencyObserver records the latency of the requests of a handler into metrics.Default,
+// keyed by the game name of the handler, and feeds their outcome to the handler's throttle.
+type latencyObserver struct {
+	handler *GameHandler
+}
+
+func (observer latencyObserver) ObserveRequest(method, url string, duration time.Duration, err error) {
+	metrics.Default.Observe(observer.handler.GameName, metrics.Endpoint(method, url), duration, err)
+	observer.handler.observeBackpressure(err)
+}
+
+// LatencyStats returns the latency percentiles of the endpoints requested by the handler.
+//
+// # Example:
+//
+//	for _, stats := range handler.LatencyStats() {
+//		fmt.Printf("%s p50=%s p95=%s p99=%s\n", stats.Endpoint, stats.P50, stats.P95, stats.P99)
+//	}
+func (handler *GameHandler) LatencyStats() []metrics.LatencyStats {
+	var stats []metrics.LatencyStats
+	for _, endpoint := range metrics.Default.Snapshot() {
+		if endpoint.Game == handler.GameName {
+			stats = append(stats, endpoint)
+		}
+	}
+	return stats
+}
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/metrics"
+	"github.com/nexus-telegram/NexusSDK/sink"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaintenancePause is how long a detected maintenance pauses a game.
+const DefaultMaintenancePause = 15 * time.Minute
+
+// DefaultMaintenancePatterns are the substrings of the maintenance pages of most games.
+var DefaultMaintenancePatterns = []string{"maintenance", "technical work", "technical break"}
+
+// weekdays maps the accepted day names of maintenance windows to days of the week.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// MaintenanceWindow is a period during which a game is known to be down: either a recurring
+// window, starting Start after midnight on Days, or a one-off window from From to Until.
+//
+// # Fields:
+//   - Days: The days of the week of a recurring window. Empty means every day.
+//   - Start: The start of a recurring window, as an offset from midnight.
+//   - Duration: The length of a recurring window.
+//   - Location: The time zone of Start. Nil means UTC.
+//   - From: The start of a one-off window.
+//   - Until: The end of a one-off window. A window with an Until is a one-off window.
+//
+// # Example:
+//
+//	moscow, _ := time.LoadLocation("Europe/Moscow")
+//	window := handler.MaintenanceWindow{Days: []time.Weekday{time.Tuesday}, Start: 3 * time.Hour,
+//		Duration: 90 * time.Minute, Location: moscow}
+type MaintenanceWindow struct {
+	Days     []time.Weekday
+	Start    time.Duration
+	Duration time.Duration
+	Location *time.Location
+	From     time.Time
+	Until    time.Time
+}
+
+// End returns the end of the window if now is within it.
+func (window MaintenanceWindow) End(now time.Time) (time.Time, bool) {
+	if !window.Until.IsZero() {
+		return window.Until, !now.Before(window.From) && now.Before(window.Until)
+	}
+	if window.Duration <= 0 {
+		return time.Time{}, false
+	}
+	location := window.Location
+	if location == nil {
+		location = time.UTC
+	}
+	local := now.In(location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	// A window may have started on one of the previous days and still be running.
+	for back := 0; back <= int((window.Start+window.Duration)/(24*time.Hour)); back++ {
+		day := midnight.AddDate(0, 0, -back)
+		if !window.on(day.Weekday()) {
+			continue
+		}
+		start := day.Add(window.Start)
+		if end := start.Add(window.Duration); !now.Before(start) && now.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// on reports whether a recurring window starts on a day of the week.
+func (window MaintenanceWindow) on(day time.Weekday) bool {
+	if len(window.Days) == 0 {
+		return true
+	}
+	for _, d := range window.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Maintenance pauses the scheduled tasks of a game while it is under maintenance, so that a
+// farm does not send thousands of requests failing with the game's maintenance page:
+//   - during its known Windows;
+//   - when Detect is set, for Pause after any response of the game matching Statuses and
+//     Patterns.
+//
+// Executions falling due during a maintenance are postponed to its end; task executions
+// started outside the scheduler (e.g., one-time tasks) are skipped.
+//
+// # Fields:
+//   - Windows: The known maintenance windows of the game.
+//   - Detect: Whether maintenance responses pause the game.
+//   - Statuses: The status codes of the maintenance responses; 0 matches any status.
+//   - Patterns: Lowercase substrings of the body of the maintenance responses, one of which
+//     must be present. Empty matches any body.
+//   - Pause: How long a detected maintenance pauses the game.
+//
+// # Example:
+//
+//	gameHandler.SetMaintenance(&handler.Maintenance{
+//		Windows:  []handler.MaintenanceWindow{{Start: 4 * time.Hour, Duration: 30 * time.Minute}},
+//		Detect:   true,
+//		Statuses: []int{503},
+//		Patterns: handler.DefaultMaintenancePatterns,
+//		Pause:    10 * time.Minute,
+//	})
+type Maintenance struct {
+	Windows  []MaintenanceWindow
+	Detect   bool
+	Statuses []int
+	Patterns []string
+	Pause    time.Duration
+}
+
+// NewMaintenance creates the maintenance settings of the maintenance section of the
+// configuration file, or returns nil when it has no window and detection is off.
+//
+// # Returns:
+//   - *Maintenance: The maintenance settings.
+//   - error: An error if a window has an invalid day, start time, time zone, or length.
+func NewMaintenance(config types.MaintenanceConfig) (*Maintenance, error) {
+	if len(config.Windows) == 0 && !config.Detect {
+		return nil, nil
+	}
+	maintenance := &Maintenance{
+		Detect:   config.Detect,
+		Statuses: config.Statuses,
+		Patterns: config.Patterns,
+		Pause:    time.Duration(config.PauseMinutes) * time.Minute,
+	}
+	if len(maintenance.Statuses) == 0 {
+		maintenance.Statuses = []int{503}
+	}
+	if len(maintenance.Patterns) == 0 {
+		maintenance.Patterns = DefaultMaintenancePatterns
+	}
+	if maintenance.Pause <= 0 {
+		maintenance.Pause = DefaultMaintenancePause
+	}
+	for i, section := range config.Windows {
+		window, err := newMaintenanceWindow(section)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance window %d: %w", i+1, err)
+		}
+		maintenance.Windows = append(maintenance.Windows, window)
+	}
+	return maintenance, nil
+}
+
+// newMaintenanceWindow creates the maintenance window of a window of the maintenance section.
+func newMaintenanceWindow(section types.MaintenanceWindowConfig) (MaintenanceWindow, error) {
+	if !section.Until.IsZero() {
+		if !section.From.Before(section.Until) {
+			return MaintenanceWindow{}, errors.New("from must be before until")
+		}
+		return MaintenanceWindow{From: section.From, Until: section.Until}, nil
+	}
+	hour, minute, ok := strings.Cut(section.Start, ":")
+	h, hourErr := strconv.Atoi(hour)
+	m, minuteErr := strconv.Atoi(minute)
+	if !ok || hourErr != nil || minuteErr != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return MaintenanceWindow{}, fmt.Errorf("invalid start %q, expected HH:MM", section.Start)
+	}
+	if section.DurationMinutes <= 0 {
+		return MaintenanceWindow{}, errors.New("duration_minutes must be positive")
+	}
+	window := MaintenanceWindow{
+		Start:    time.Duration(h)*time.Hour + time.Duration(m)*time.Minute,
+		Duration: time.Duration(section.DurationMinutes) * time.Minute,
+		Location: time.UTC,
+	}
+	if section.Timezone != "" {
+		location, err := time.LoadLocation(section.Timezone)
+		if err != nil {
+			return MaintenanceWindow{}, err
+		}
+		window.Location = location
+	}
+	for _, name := range section.Days {
+		name = strings.ToLower(name)
+		day, ok := weekdays[name]
+		if !ok && len(name) > 3 {
+			day, ok = weekdays[name[:3]]
+			ok = ok && strings.EqualFold(name, day.String())
+		}
+		if !ok {
+			return MaintenanceWindow{}, fmt.Errorf("invalid day %q", name)
+		}
+		window.Days = append(window.Days, day)
+	}
+	return window, nil
+}
+
+// Window returns the end of the known maintenance windows containing now, the latest one if
+// several overlap.
+func (maintenance *Maintenance) Window(now time.Time) (time.Time, bool) {
+	if maintenance == nil {
+		return time.Time{}, false
+	}
+	var until time.Time
+	for _, window := range maintenance.Windows {
+		if end, ok := window.End(now); ok && end.After(until) {
+			until = end
+		}
+	}
+	return until, !until.IsZero()
+}
+
+// Matches reports whether a response of the game is a maintenance response.
+func (maintenance *Maintenance) Matches(status int, body []byte) bool {
+	if maintenance == nil || !maintenance.Detect {
+		return false
+	}
+	statusMatches := false
+	for _, code := range maintenance.Statuses {
+		statusMatches = statusMatches || code == 0 || code == status
+	}
+	if !statusMatches {
+		return false
+	}
+	if len(maintenance.Patterns) == 0 {
+		return true
+	}
+	body = bytes.ToLower(body)
+	for _, pattern := range maintenance.Patterns {
+		if bytes.Contains(body, []byte(strings.ToLower(pattern))) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMaintenance sets the maintenance windows and the maintenance detection of the game of the
+// handler. Passing nil disables both; a maintenance already detected or started with
+// StartMaintenance still runs to its end.
+//
+// Handlers created by NewGameHandler use the maintenance section of their configuration.
+func (handler *GameHandler) SetMaintenance(maintenance *Maintenance) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.maintenance = maintenance
+}
+
+// StartMaintenance pauses the game of the handler until a time, e.g. when its adapter learns
+// of an update from a status endpoint. Passing a time in the past ends the maintenance started
+// by StartMaintenance or detected; it does not end known maintenance windows.
+func (handler *GameHandler) StartMaintenance(until time.Time) {
+	handler.mu.Lock()
+	handler.downUntil = until
+	handler.mu.Unlock()
+	if until.After(handler.getClock().Now()) {
+		handler.GetLogger().Warn("Game under maintenance, tasks paused", zap.Time("until", until))
+	}
+}
+
+// UnderMaintenance reports whether the game of the handler is under maintenance, within one of
+// its known windows or after a maintenance response, and when the maintenance ends.
+func (handler *GameHandler) UnderMaintenance() (time.Time, bool) {
+	now := handler.getClock().Now()
+	handler.mu.Lock()
+	maintenance, until := handler.maintenance, handler.downUntil
+	handler.mu.Unlock()
+	if end, ok := maintenance.Window(now); ok && end.After(until) {
+		until = end
+	}
+	return until, until.After(now)
+}
+
+// detectMaintenance pauses the game when err is a maintenance response of the game, and
+// reports whether it is.
+func (handler *GameHandler) detectMaintenance(err error) bool {
+	var status *httpclient.StatusError
+	if !errors.As(err, &status) {
+		return false
+	}
+	handler.mu.Lock()
+	maintenance := handler.maintenance
+	handler.mu.Unlock()
+	if !maintenance.Matches(status.StatusCode, []byte(status.Body)) {
+		return false
+	}
+	now := handler.getClock().Now()
+	until := now.Add(maintenance.Pause)
+	handler.mu.Lock()
+	started := !handler.downUntil.After(now)
+	if until.After(handler.downUntil) {
+		handler.downUntil = until
+	}
+	handler.mu.Unlock()
+	if started {
+		handler.GetLogger().Warn("Maintenance response received, tasks paused", zap.Int("status", status.StatusCode), zap.Time("until", until))
+		_ = metrics.DefaultRegistry.Add("nexus_maintenance_detected_total", "Maintenances detected from the responses of the game.",
+			handler.MetricLabels(nil), 1)
+		handler.publishEvent(sink.Event{
+			Type: sink.EventMaintenance,
+			Time: now,
+			Data: map[string]interface{}{"status": status.StatusCode, "until": until},
+		})
+	}
+	return true
+}
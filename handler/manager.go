@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Manager runs several game handlers as one farm and enforces farm-wide limits across them.
+//
+// # Fields:
+//   - handlers: The managed game handlers.
+//   - budget: The request budget shared by every handler, if any.
+//   - mu: A mutex for thread-safe access to handlers and budget.
+//
+// # Example:
+//
+//	manager := handler.NewManager(hamsterHandler, notcoinHandler)
+//	manager.SetBudget(handler.NewBudget(config.Budget))
+//	manager.RunTasks()
+type Manager struct {
+	handlers []*GameHandler
+	budget   *Budget
+	mu       sync.Mutex
+}
+
+// NewManager creates a manager for the given game handlers.
+func NewManager(handlers ...*GameHandler) *Manager {
+	return &Manager{handlers: handlers}
+}
+
+// Add registers an additional game handler, applying the manager's budget to it.
+func (manager *Manager) Add(gameHandler *GameHandler) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	manager.handlers = append(manager.handlers, gameHandler)
+	if manager.budget != nil {
+		gameHandler.SetBudget(manager.budget)
+	}
+}
+
+// Handlers returns the managed game handlers.
+func (manager *Manager) Handlers() []*GameHandler {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	return append([]*GameHandler(nil), manager.handlers...)
+}
+
+// SetBudget applies a farm-wide request budget to every managed handler. Passing nil removes it.
+func (manager *Manager) SetBudget(budget *Budget) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	manager.budget = budget
+	for _, gameHandler := range manager.handlers {
+		gameHandler.SetBudget(budget)
+	}
+}
+
+// Budget returns the farm-wide request budget, or nil if none is set.
+func (manager *Manager) Budget() *Budget {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	return manager.budget
+}
+
+// SetAPIKey rotates the API key of every managed handler. See GameHandler.SetAPIKey.
+func (manager *Manager) SetAPIKey(key string) error {
+	for _, gameHandler := range manager.Handlers() {
+		if err := gameHandler.SetAPIKey(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunTasks runs the tasks of every managed handler concurrently, waits for all of them, and
+// returns their reports in the order of Handlers.
+func (manager *Manager) RunTasks() []*RunReport {
+	handlers := manager.Handlers()
+	reports := make([]*RunReport, len(handlers))
+	var wg sync.WaitGroup
+	for i, gameHandler := range handlers {
+		wg.Add(1)
+		go func(i int, gameHandler *GameHandler) {
+			defer wg.Done()
+			reports[i] = gameHandler.RunTasks()
+		}(i, gameHandler)
+	}
+	wg.Wait()
+	return reports
+}
+
+// RunOnce runs the due tasks of every managed handler once, concurrently, and returns their
+// reports in the order of Handlers. See GameHandler.RunOnce.
+func (manager *Manager) RunOnce(ctx context.Context, maxDuration time.Duration) []*RunReport {
+	handlers := manager.Handlers()
+	reports := make([]*RunReport, len(handlers))
+	var wg sync.WaitGroup
+	for i, gameHandler := range handlers {
+		wg.Add(1)
+		go func(i int, gameHandler *GameHandler) {
+			defer wg.Done()
+			reports[i] = gameHandler.RunOnce(ctx, maxDuration)
+		}(i, gameHandler)
+	}
+	wg.Wait()
+	return reports
+}
+
+// Close closes every managed handler concurrently (see GameHandler.Close) and returns their
+// errors, joined.
+func (manager *Manager) Close() error {
+	handlers := manager.Handlers()
+	errs := make([]error, len(handlers))
+	var wg sync.WaitGroup
+	for i, gameHandler := range handlers {
+		wg.Add(1)
+		go func(i int, gameHandler *GameHandler) {
+			defer wg.Done()
+			if err := gameHandler.Close(); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", gameHandler.GameName, err)
+			}
+		}(i, gameHandler)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Drain drains every managed handler concurrently and returns once all of them are drained.
+// See GameHandler.Drain.
+func (manager *Manager) Drain() {
+	var wg sync.WaitGroup
+	for _, gameHandler := range manager.Handlers() {
+		wg.Add(1)
+		go func(gameHandler *GameHandler) {
+			defer wg.Done()
+			gameHandler.Drain()
+		}(gameHandler)
+	}
+	wg.Wait()
+}
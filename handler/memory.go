@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"github.com/nexus-telegram/NexusSDK/apikeys"
+	"github.com/nexus-telegram/NexusSDK/secrets"
+	"github.com/nexus-telegram/NexusSDK/types"
+)
+
+// SetSecretMemory sets how the handler keeps its secrets in memory: the API keys and the
+// Telegram session strings of the accounts.
+//
+// With secrets.MemoryEncrypted or secrets.MemoryLocked, the secrets are encrypted with a key
+// generated for the process and only decrypted while a refresh of game data sends them, so a
+// memory dump of a worker does not leak them in plain text. APIKey, the values of the API key
+// pool, and the TdataStringSession of the accounts are cleared; GetAccounts returns the
+// accounts without their session strings. Setting secrets.MemoryPlain restores them.
+//
+// # Parameters:
+//   - mode: The memory mode, see secrets.MemoryMode.
+//
+// # Returns:
+//   - error: An error if the mode is locked and the platform or the memory lock limit does
+//     not allow it. The handler is left unchanged then.
+//
+// # Example:
+//
+//	if err := gameHandler.SetSecretMemory(secrets.MemoryLocked); err != nil {
+//		log.Fatalf("Failed to lock secrets in memory: %v", err)
+//	}
+//
+// # Notes:
+//   - The configuration and accounts the handler was created from still hold the secrets;
+//     drop them once the handler is created.
+//   - Locked memory counts against RLIMIT_MEMLOCK (ulimit -l); each sealed secret decrypted at
+//     the same time takes one page.
+//   - The expanded encryption key stays in ordinary heap memory (see secrets.Sealer), so the
+//     secrets are hidden from memory scans and dumps, not from an attacker reading the memory
+//     of the process.
+func (handler *GameHandler) SetSecretMemory(mode secrets.MemoryMode) (err error) {
+	var sealer *secrets.Sealer
+	if mode != secrets.MemoryPlain && mode != "" {
+		if sealer, err = secrets.NewSealer(mode); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				sealer.Close()
+			}
+		}()
+	}
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	previous := handler.sealer
+	apiKey, err := handler.sealedKey.Reveal()
+	if err != nil {
+		return err
+	}
+	if handler.sealedKey == nil {
+		apiKey = handler.APIKey
+	}
+	sessions := make(map[string]string, len(handler.Accounts))
+	for _, account := range handler.Accounts {
+		session, err := handler.sessions[account.TelegramData.TelegramId].Reveal()
+		if err != nil {
+			return err
+		}
+		if handler.sessions[account.TelegramData.TelegramId] == nil {
+			session = account.TelegramData.TdataStringSession
+		}
+		sessions[account.TelegramData.TelegramId] = session
+	}
+	if handler.apiKeys != nil {
+		if err := handler.apiKeys.Seal(sealer); err != nil {
+			return err
+		}
+	}
+	handler.sealer, handler.sealedKey, handler.sessions = sealer, nil, nil
+	handler.APIKey = apiKey
+	for i := range handler.Accounts {
+		handler.Accounts[i].TelegramData.TdataStringSession = sessions[handler.Accounts[i].TelegramData.TelegramId]
+	}
+	if sealer != nil {
+		handler.sealedKey = sealer.Seal(apiKey)
+		handler.APIKey = ""
+		handler.sessions = make(map[string]*secrets.Sealed, len(handler.Accounts))
+		for i := range handler.Accounts {
+			telegram := &handler.Accounts[i].TelegramData
+			handler.sessions[telegram.TelegramId] = sealer.Seal(telegram.TdataStringSession)
+			telegram.TdataStringSession = ""
+		}
+	}
+	if previous != nil {
+		previous.Close()
+	}
+	return nil
+}
+
+// defaultAPIKey returns the handler's APIKey as a key of the default name, sealed if the
+// handler encrypts its secrets.
+func (handler *GameHandler) defaultAPIKey() apikeys.Key {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return apikeys.Key{Name: apikeys.DefaultKeyName, Value: handler.APIKey, Sealed: handler.sealedKey}
+}
+
+// telegramData returns the Telegram data of an account with its session string, decrypted if
+// the handler encrypts its secrets, or read from the accounts file if the handler has lazy
+// accounts.
+func (handler *GameHandler) telegramData(account types.Account) (types.TelegramData, error) {
+	handler.mu.Lock()
+	sealed, lazy := handler.sessions[account.TelegramData.TelegramId], handler.lazy
+	handler.mu.Unlock()
+	telegram := account.TelegramData
+	if sealed == nil && lazy != nil && telegram.TdataStringSession == "" {
+		hydrated, err := lazy.Hydrate(telegram.TelegramId)
+		return hydrated.TelegramData, err
+	}
+	if sealed == nil {
+		return telegram, nil
+	}
+	session, err := sealed.Reveal()
+	if err != nil {
+		return telegram, err
+	}
+	telegram.TdataStringSession = session
+	return telegram, nil
+}
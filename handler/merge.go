@@ -0,0 +1,41 @@
+package handler
+
+// deepMerge merges override into base and returns base.
+//
+// Nested objects are merged recursively; any other value in override (including arrays)
+// replaces the value in base.
+func deepMerge(base, override map[string]interface{}) map[string]interface{} {
+	if base == nil {
+		base = make(map[string]interface{}, len(override))
+	}
+	for key, value := range override {
+		overrideObject, overrideIsObject := value.(map[string]interface{})
+		baseObject, baseIsObject := base[key].(map[string]interface{})
+		if overrideIsObject && baseIsObject {
+			base[key] = deepMerge(baseObject, overrideObject)
+			continue
+		}
+		base[key] = deepCopy(value)
+	}
+	return base
+}
+
+// deepCopy returns a copy of a decoded JSON value that shares no maps or slices with it.
+func deepCopy(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(typed))
+		for key, element := range typed {
+			copied[key] = deepCopy(element)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(typed))
+		for i, element := range typed {
+			copied[i] = deepCopy(element)
+		}
+		return copied
+	default:
+		return value
+	}
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"github.com/nexus-telegram/NexusSDK/metrics"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"time"
+)
+
+// AccountCohort returns the value of the account_cohort label of the metrics of an account: its
+// Cohort, or the month it was added to the farm (e.g., "2024-10"), or "none".
+func AccountCohort(account types.Account) string {
+	switch {
+	case account.Cohort != "":
+		return account.Cohort
+	case !account.CreatedAt.IsZero():
+		return account.CreatedAt.UTC().Format("2006-01")
+	}
+	return "none"
+}
+
+// SetProxyPoolName sets the value of the proxy_pool label of the metrics of the handler.
+// Handlers without a name use "default" when they have a proxy pool, and "none" otherwise.
+//
+// Handlers created by NewGameHandler use the name of the proxy_pool section of their
+// configuration. The gauges registered before keep the name they were registered with.
+func (handler *GameHandler) SetProxyPoolName(name string) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.poolName = name
+}
+
+// MetricLabels returns the labels of the metrics of the handler, game and proxy_pool, for the
+// custom metrics of game adapters to be grouped with the metrics of the SDK.
+//
+// # Parameters:
+//   - account: The optional account the metric concerns, adding its account_cohort label.
+//
+// # Example:
+//
+//	metrics.DefaultRegistry.Add("blum_points_claimed_total", "Points claimed.",
+//		gameHandler.MetricLabels(&account).With(metrics.LabelTask, "Claim"), points)
+func (handler *GameHandler) MetricLabels(account *types.Account) metrics.Labels {
+	handler.mu.Lock()
+	pool := handler.poolName
+	if pool == "" {
+		pool = "none"
+		if handler.ProxyPool != nil {
+			pool = "default"
+		}
+	}
+	handler.mu.Unlock()
+	labels := metrics.Labels{metrics.LabelGame: handler.GameName, metrics.LabelProxyPool: pool}
+	if account != nil {
+		labels[metrics.LabelCohort] = AccountCohort(*account)
+	}
+	return labels
+}
+
+// RegisterGauge registers a farm-level gauge of a game adapter in metrics.DefaultRegistry,
+// labelled with the game and proxy pool of the handler, e.g. the total balance of the farm.
+// The gauge is read every time the metrics are scraped, and removed when the handler is closed.
+//
+// # Parameters:
+//   - name: The name of the gauge, e.g. "farm_balance_ton".
+//   - help: The description of the gauge.
+//   - read: The function returning the value of the gauge. It must not block.
+//
+// # Returns:
+//   - error: An error if the name is invalid or already used by a counter.
+//
+// # Example:
+//
+//	err := gameHandler.RegisterGauge("farm_balance_ton", "Total TON balance of the farm.", func() float64 {
+//		return balances.Total()
+//	})
+func (handler *GameHandler) RegisterGauge(name, help string, read func() float64) error {
+	labels := handler.MetricLabels(nil)
+	if err := metrics.DefaultRegistry.RegisterGauge(name, help, labels, read); err != nil {
+		return err
+	}
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.gauges == nil {
+		handler.gauges = make(map[string]metrics.Labels)
+	}
+	handler.gauges[name] = labels
+	return nil
+}
+
+// unregisterGauges removes the gauges of the handler from metrics.DefaultRegistry.
+func (handler *GameHandler) unregisterGauges() {
+	handler.mu.Lock()
+	gauges := handler.gauges
+	handler.gauges = nil
+	handler.mu.Unlock()
+	for name, labels := range gauges {
+		metrics.DefaultRegistry.Unregister(name, labels)
+	}
+}
+
+// registerMetrics registers the built-in gauges of the handler: its enabled accounts, its
+// accounts in cooldown, and the healthy proxies of its pool.
+func (handler *GameHandler) registerMetrics() {
+	_ = handler.RegisterGauge("nexus_accounts_enabled", "Enabled accounts.", func() float64 {
+		handler.mu.Lock()
+		defer handler.mu.Unlock()
+		enabled := 0
+		for _, account := range handler.Accounts {
+			if account.IsEnabled() {
+				enabled++
+			}
+		}
+		return float64(enabled)
+	})
+	_ = handler.RegisterGauge("nexus_accounts_cooling_down", "Accounts in cooldown after a ban signal.", func() float64 {
+		return float64(len(handler.Cooldowns()))
+	})
+	_ = handler.RegisterGauge("nexus_proxies_healthy", "Healthy proxies of the pool.", func() float64 {
+		handler.mu.Lock()
+		pool := handler.ProxyPool
+		handler.mu.Unlock()
+		if pool == nil {
+			return 0
+		}
+		return float64(pool.Healthy())
+	})
+}
+
+// observeTask records a finished task execution in the task metrics of the handler, with the
+// ID of its trace as exemplar when it was traced (see SetTracer). The duration is recorded in
+// the nexus_task_execution_seconds histogram, whose buckets carry the exemplars of the slow
+// executions for the latency panels.
+func (handler *GameHandler) observeTask(account types.Account, task string, duration time.Duration, traceID string, err error) {
+	labels := handler.MetricLabels(&account).With(metrics.LabelTask, task)
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	_ = metrics.DefaultRegistry.Add("nexus_task_duration_seconds_total", "Time spent in task executions, retries included.",
+		labels, duration.Seconds())
+	if traceID == "" {
+		_ = metrics.DefaultRegistry.Add("nexus_task_executions_total", "Finished task executions, retries included, by result.",
+			labels.With("result", result), 1)
+		_ = metrics.DefaultRegistry.Observe("nexus_task_execution_seconds", "Duration of task executions, retries included.",
+			labels, nil, duration.Seconds())
+		return
+	}
+	trace := metrics.Labels{metrics.LabelTraceID: traceID}
+	now := time.Now()
+	_ = metrics.DefaultRegistry.AddExemplar("nexus_task_executions_total", "Finished task executions, retries included, by result.",
+		labels.With("result", result), 1, metrics.Exemplar{Labels: trace, Value: 1, Time: now})
+	_ = metrics.DefaultRegistry.ObserveExemplar("nexus_task_execution_seconds", "Duration of task executions, retries included.",
+		labels, nil, duration.Seconds(), metrics.Exemplar{Labels: trace, Value: duration.Seconds(), Time: now})
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"io"
+	"strconv"
+	"time"
+)
+
+// TaskMigration upgrades a tasks.json document from one version to the next.
+//
+// # Fields:
+//   - From: The version the migration upgrades from. It produces version From+1.
+//   - Description: What the migration changes, reported to the operator.
+//   - Apply: Rewrites the decoded document in place. Its numbers are json.Number values, so
+//     the payloads of the tasks keep the precision of their large integers.
+type TaskMigration struct {
+	From        int
+	Description string
+	Apply       func(document map[string]interface{}) error
+}
+
+// taskMigrations are the migrations of tasks.json, one per version below types.TasksVersion.
+var taskMigrations = []TaskMigration{
+	{
+		From:        1,
+		Description: `split the "tasks" list into "one_time_tasks" and "recurrent_tasks"`,
+		Apply:       splitLegacyTasks,
+	},
+}
+
+// MigrateTasks upgrades a tasks.json document to types.TasksVersion. Documents without a
+// "version" field are version 1.
+//
+// LoadTasks migrates task files on load, so older files keep working after an SDK upgrade;
+// "nexusctl migrate-tasks" rewrites them.
+//
+// # Parameters:
+//   - data: The JSON document.
+//
+// # Returns:
+//   - []byte: The upgraded document, data itself if it is already current.
+//   - []TaskMigration: The migrations applied, in order.
+//   - error: An error if the document is invalid, newer than the SDK, or cannot be migrated.
+func MigrateTasks(data []byte) ([]byte, []TaskMigration, error) {
+	var document map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, nil, errors.New("invalid character after top-level value in tasks file")
+	}
+	version := 1
+	if raw, ok := document["version"]; ok {
+		number, isNumber := raw.(json.Number)
+		parsed, err := number.Float64()
+		if !isNumber || err != nil || parsed != float64(int(parsed)) || parsed < 1 {
+			return nil, nil, fmt.Errorf("invalid tasks file version: %v", raw)
+		}
+		version = int(parsed)
+	}
+	if version > types.TasksVersion {
+		return nil, nil, fmt.Errorf("tasks file version %d is newer than the supported version %d, upgrade the SDK", version, types.TasksVersion)
+	}
+	if version == types.TasksVersion {
+		return data, nil, nil
+	}
+	var applied []TaskMigration
+	for _, migration := range taskMigrations {
+		if migration.From != version {
+			continue
+		}
+		if err := migration.Apply(document); err != nil {
+			return nil, applied, fmt.Errorf("failed to migrate tasks file from version %d: %w", version, err)
+		}
+		applied = append(applied, migration)
+		version++
+	}
+	if version != types.TasksVersion {
+		return nil, applied, fmt.Errorf("no migration of tasks files from version %d", version)
+	}
+	document["version"] = version
+	migrated, err := json.Marshal(document)
+	return migrated, applied, err
+}
+
+// splitLegacyTasks migrates version 1 documents, whose tasks were listed in one "tasks" array
+// with an optional "interval", to the one_time_tasks and recurrent_tasks arrays. Documents
+// already using the two arrays are left unchanged.
+func splitLegacyTasks(document map[string]interface{}) error {
+	raw, ok := document["tasks"]
+	if !ok {
+		return nil
+	}
+	legacy, ok := raw.([]interface{})
+	if !ok {
+		return fmt.Errorf(`"tasks" is not an array`)
+	}
+	oneTime, _ := document["one_time_tasks"].([]interface{})
+	recurrent, _ := document["recurrent_tasks"].([]interface{})
+	for i, entry := range legacy {
+		task, ok := entry.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("task %d is not an object", i)
+		}
+		interval, hasInterval := task["interval"]
+		delete(task, "interval")
+		minutes, err := legacyIntervalMinutes(interval)
+		if err != nil {
+			return fmt.Errorf("task %v: %w", task["name"], err)
+		}
+		if !hasInterval || minutes == 0 {
+			oneTime = append(oneTime, task)
+			continue
+		}
+		task["interval_minutes"] = minutes
+		recurrent = append(recurrent, task)
+	}
+	delete(document, "tasks")
+	document["one_time_tasks"] = oneTime
+	document["recurrent_tasks"] = recurrent
+	return nil
+}
+
+// legacyIntervalMinutes parses the interval of a version 1 task: a number of minutes, either
+// as a number or a string, or a duration such as "1h30m".
+func legacyIntervalMinutes(interval interface{}) (int, error) {
+	switch typed := interval.(type) {
+	case nil:
+		return 0, nil
+	case json.Number:
+		minutes, err := typed.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("invalid interval %s", typed)
+		}
+		return int(minutes), nil
+	case string:
+		if typed == "" {
+			return 0, nil
+		}
+		if minutes, err := strconv.Atoi(typed); err == nil {
+			return minutes, nil
+		}
+		duration, err := time.ParseDuration(typed)
+		if err != nil {
+			return 0, fmt.Errorf("invalid interval %q", typed)
+		}
+		return int(duration / time.Minute), nil
+	default:
+		return 0, fmt.Errorf("invalid interval %v", interval)
+	}
+}
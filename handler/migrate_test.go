@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"strings"
+	"testing"
+)
+
+func TestMigrateTasks(t *testing.T) {
+	current := fmt.Sprintf(`{"version":%d,"one_time_tasks":[]}`, types.TasksVersion)
+	tests := []struct {
+		name           string
+		document       string
+		wantMigrations int
+		want           string
+		wantErr        bool
+	}{
+		{
+			name:           "legacy tasks",
+			document:       `{"tasks":[{"name":"claim"},{"name":"farm","interval":30},{"name":"spin","interval":"15"},{"name":"quest","interval":"1h30m"},{"name":"once","interval":0}]}`,
+			wantMigrations: 1,
+			want: fmt.Sprintf(`{"one_time_tasks":[{"name":"claim"},{"name":"once"}],"recurrent_tasks":[{"interval_minutes":30,"name":"farm"},`+
+				`{"interval_minutes":15,"name":"spin"},{"interval_minutes":90,"name":"quest"}],"version":%d}`, types.TasksVersion),
+		},
+		{
+			name:           "large payload numbers",
+			document:       `{"version":1,"tasks":[{"name":"refer","payload":{"referrer":9007199254740993,"amount":0.30000000000000004}}]}`,
+			wantMigrations: 1,
+			want: fmt.Sprintf(`{"one_time_tasks":[{"name":"refer","payload":{"amount":0.30000000000000004,"referrer":9007199254740993}}],`+
+				`"recurrent_tasks":null,"version":%d}`, types.TasksVersion),
+		},
+		{name: "current", document: current, want: current},
+		{name: "newer", document: fmt.Sprintf(`{"version":%d}`, types.TasksVersion+1), wantErr: true},
+		{name: "fractional version", document: `{"version":1.5}`, wantErr: true},
+		{name: "string version", document: `{"version":"1"}`, wantErr: true},
+		{name: "invalid interval", document: `{"tasks":[{"name":"farm","interval":"soon"}]}`, wantErr: true},
+		{name: "tasks not an array", document: `{"tasks":{}}`, wantErr: true},
+		{name: "trailing data", document: current + `}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			migrated, migrations, err := MigrateTasks([]byte(test.document))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("MigrateTasks() error = %v, want error %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(migrations) != test.wantMigrations {
+				t.Errorf("MigrateTasks() applied %d migrations, want %d", len(migrations), test.wantMigrations)
+			}
+			if string(migrated) != test.want {
+				t.Errorf("MigrateTasks() = %s, want %s", migrated, test.want)
+			}
+		})
+	}
+}
+
+func TestMigrateTasksLoads(t *testing.T) {
+	migrated, _, err := MigrateTasks([]byte(`{"tasks":[{"name":"farm","interval":"5","payload":{"id":9007199254740993}}]}`))
+	if err != nil {
+		t.Fatalf("MigrateTasks failed: %v", err)
+	}
+	var collection types.TaskCollection
+	if err := json.Unmarshal(migrated, &collection); err != nil {
+		t.Fatalf("migrated document %s does not load: %v", migrated, err)
+	}
+	if !strings.Contains(string(migrated), "9007199254740993") {
+		t.Errorf("migrated document %s rounded the payload", migrated)
+	}
+	if len(collection.RecurrentTasks) != 1 || collection.Version != types.TasksVersion {
+		t.Errorf("migrated document loads as %+v, want one recurrent task of the current version", collection)
+	}
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"github.com/nexus-telegram/NexusSDK/jobqueue"
+	"github.com/nexus-telegram/NexusSDK/tasks"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// RunOnce executes the due tasks of every account once and returns, so that the farm can be
+// driven by an external scheduler (cron, a Kubernetes CronJob) instead of running as a daemon.
+//
+// One-time tasks run for every enabled account. Scheduled tasks run when their job is due in
+// the handler's job queue (see SetJobQueue), and are then rescheduled there, so consecutive
+// invocations pick up where the previous one left off; without a persistent queue, every
+// scheduled task is due on each invocation. The tasks of an account run one after the other,
+// while different accounts run in parallel.
+//
+// Once maxDuration has elapsed or ctx is done, no further execution is started: the due
+// executions left are counted in the report's Unstarted and their jobs stay due for the next
+// invocation. Executions already running finish normally. The due jobs are also counted in
+// Unstarted when the job queue fails to lease them.
+//
+// # Parameters:
+//   - ctx: The context whose cancellation stops starting executions.
+//   - maxDuration: The time after which no further execution is started, unlimited when zero.
+//
+// # Returns:
+//   - *RunReport: The summary of the run (see RunReport).
+//
+// # Example:
+//
+//	// */15 * * * * /usr/local/bin/farm
+//	report := gameHandler.RunOnce(context.Background(), 10*time.Minute)
+//	fmt.Println(report)
+//	if report.Unstarted > 0 {
+//		os.Exit(2)
+//	}
+//
+// # Notes:
+//   - Leave a margin between maxDuration and the deadline of the external scheduler, since a
+//     running execution and its retries may outlast maxDuration.
+//   - Non-idempotent one-time tasks should be guarded (see SetIdempotencyGuard), otherwise they
+//     run again on each invocation.
+func (handler *GameHandler) RunOnce(ctx context.Context, maxDuration time.Duration) *RunReport {
+	recorder := newRunRecorder(handler.GameName, RunModeOnce, time.Now())
+	if maxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, maxDuration)
+		defer cancel()
+	}
+	handler.mu.Lock()
+	draining := handler.drainChannel()
+	select {
+	case <-draining:
+		handler.mu.Unlock()
+		return recorder.finish(time.Now())
+	default:
+	}
+	handler.active.Add(1)
+	elector := handler.elector
+	handler.mu.Unlock()
+	defer handler.active.Done()
+
+	if elector != nil {
+		leaderCtx, resign := handler.lead(ctx, draining, elector)
+		defer resign()
+		if leaderCtx == nil {
+			return recorder.finish(time.Now())
+		}
+		ctx = leaderCtx
+	}
+	handler.tuneParallelism()
+	handler.warmUpConnections(ctx)
+	stopped := func() bool {
+		select {
+		case <-ctx.Done():
+			return true
+		case <-draining:
+			return true
+		default:
+			return false
+		}
+	}
+
+	queue := handler.jobQueue()
+	owner := handler.queueOwner()
+	due := make(map[string][]jobqueue.Job)
+	byID := handler.addJobs(ctx, queue, handler.scheduledJobs())
+	if len(byID) > 0 {
+		now := handler.getClock().Now()
+		leased, err := queue.Lease(ctx, handler.GameName, owner, jobIDs(byID), now, jobqueue.DefaultLeaseTTL)
+		if err != nil {
+			handler.GetLogger().Warn("Failed to lease due jobs", zap.Error(err))
+			for i := handler.dueJobs(ctx, queue, byID, now); i > 0; i-- {
+				recorder.unstarted()
+			}
+		}
+		for _, lease := range leased {
+			due[lease.Account] = append(due[lease.Account], lease)
+		}
+	}
+
+	refreshCtx, stopRefresh := context.WithCancel(ctx)
+	defer stopRefresh()
+	go handler.refreshProxies(refreshCtx, draining)
+	var wg sync.WaitGroup
+	served := make(map[string]bool)
+	for _, account := range handler.rotateAccounts(handler.Accounts) {
+		if !account.IsEnabled() {
+			continue
+		}
+		served[account.TelegramData.TelegramId] = true
+		wg.Add(1)
+		go func(account types.Account) {
+			defer wg.Done()
+			id := account.TelegramData.TelegramId
+			handler.diag.addGoroutine(id, 1)
+			defer handler.diag.addGoroutine(id, -1)
+			log := utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, account).With(zap.String("run_id", recorder.runID()))
+			for _, task := range handler.Tasks {
+				if _, ok := task.(tasks.Scheduled); ok {
+					continue
+				}
+				if stopped() {
+					recorder.unstarted()
+					continue
+				}
+				if err := handler.runTaskWithRetry(recorder, account, task); err != nil {
+					log.Error("Error executing one-time task", zap.Error(err))
+				}
+			}
+			for _, lease := range due[id] {
+				if stopped() {
+					recorder.unstarted()
+					handler.releaseJob(queue, owner, lease)
+					continue
+				}
+				handler.runJob(recorder, queue, owner, lease, byID[lease.ID])
+			}
+		}(account)
+	}
+	// The jobs of accounts disabled or removed since their jobs were leased are not run.
+	for id, leases := range due {
+		if !served[id] {
+			for _, lease := range leases {
+				handler.releaseJob(queue, owner, lease)
+			}
+		}
+	}
+	wg.Wait()
+	report := recorder.finish(time.Now())
+	handler.GetLogger().Info("One-shot run finished", zap.Duration("duration", report.Duration),
+		zap.Int("skipped", report.Skipped), zap.Int("unstarted", report.Unstarted))
+	return report
+}
+
+// dueJobs returns the number of jobs of byID due at now, or all of them if the queue cannot
+// list its jobs.
+func (handler *GameHandler) dueJobs(ctx context.Context, queue jobqueue.Queue, byID map[string]scheduledJob, now time.Time) int {
+	jobs, err := queue.List(ctx, handler.GameName)
+	if err != nil {
+		return len(byID)
+	}
+	count := 0
+	for _, job := range jobs {
+		if _, ok := byID[job.ID]; ok && !job.Cancelled && !job.Due.After(now) {
+			count++
+		}
+	}
+	return count
+}
+
+// releaseJob releases a leased job without running it, keeping it due.
+func (handler *GameHandler) releaseJob(queue jobqueue.Queue, owner string, lease jobqueue.Job) {
+	if err := queue.Complete(context.Background(), lease.ID, owner, lease.Last, lease.Due); err != nil {
+		handler.GetLogger().Warn("Failed to release job", zap.String("job", lease.ID), zap.Error(err))
+	}
+}
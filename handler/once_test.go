@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/jobqueue"
+	"github.com/nexus-telegram/NexusSDK/tasks"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"testing"
+	"time"
+)
+
+// leaseHookQueue is a job queue calling onLease before leasing, and failing with its error.
+type leaseHookQueue struct {
+	jobqueue.Queue
+	onLease func() error
+}
+
+func (queue *leaseHookQueue) Lease(ctx context.Context, game, owner string, ids []string, now time.Time, ttl time.Duration) ([]jobqueue.Job, error) {
+	if err := queue.onLease(); err != nil {
+		return nil, err
+	}
+	return queue.Queue.Lease(ctx, game, owner, ids, now, ttl)
+}
+
+func TestRunOnceLeftJobs(t *testing.T) {
+	tests := []struct {
+		name          string
+		onLease       func(handler *GameHandler) error
+		wantUnstarted int
+	}{
+		{"lease failure", func(*GameHandler) error { return errors.New("queue unavailable") }, 2},
+		{"account disabled once leased", func(handler *GameHandler) error {
+			disabled := false
+			handler.Accounts[0].Enabled = &disabled
+			return nil
+		}, 0},
+		{"account removed once leased", func(handler *GameHandler) error {
+			handler.Accounts = nil
+			return nil
+		}, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := &GameHandler{
+				GameName: "game",
+				Accounts: []types.Account{{TelegramData: types.TelegramData{TelegramId: "1"}}},
+				Tasks: []tasks.Task{
+					tasks.NewRecurrentTask("farm", nil, time.Hour),
+					tasks.NewRecurrentTask("spin", nil, time.Hour),
+				},
+			}
+			queue := jobqueue.NewLocal()
+			for _, task := range handler.Tasks {
+				job := jobqueue.Job{ID: jobqueue.JobID("game", "1", taskName(task)), Game: "game", Account: "1",
+					Task: taskName(task), Due: time.Now().Add(-time.Minute)}
+				if _, err := queue.Add(context.Background(), job); err != nil {
+					t.Fatalf("Add failed: %v", err)
+				}
+			}
+			handler.SetJobQueue(&leaseHookQueue{queue, func() error { return test.onLease(handler) }})
+			report := handler.RunOnce(context.Background(), 0)
+			if report.Unstarted != test.wantUnstarted {
+				t.Errorf("Unstarted = %d, want %d", report.Unstarted, test.wantUnstarted)
+			}
+			jobs, err := queue.List(context.Background(), "game")
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if len(jobs) != 2 {
+				t.Fatalf("queue holds %d jobs, want 2", len(jobs))
+			}
+			for _, job := range jobs {
+				if job.LeaseOwner != "" {
+					t.Errorf("job %s still leased by %s", job.ID, job.LeaseOwner)
+				}
+			}
+		})
+	}
+}
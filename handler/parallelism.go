@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// Parallelism sizes the task dispatch of a handler from the size of its farm, so that the
+// concurrency does not have to be tuned by hand for every number of proxies and accounts.
+//
+// The number of task executions allowed to run at once is WorkersPerProxy per healthy proxy of
+// the pool (one proxy without a pool), but no more than the enabled accounts, clamped between
+// MinWorkers and MaxWorkers. It is recomputed when a run starts, when the proxy list is
+// refreshed, and when a proxy is marked as dead. Every proxy sends at most RequestsPerMinute
+// requests per minute to each host, so the request rate of the farm grows with its proxies.
+//
+// The concurrency is enforced by the handler's throttle (see Throttle): with adaptive
+// throttling enabled, Parallelism sets its MaxConcurrency, which backpressure then lowers;
+// otherwise, a throttle that never slows down is set.
+//
+// # Fields:
+//   - RequestsPerMinute: The requests per minute each proxy sends to each host. Zero means
+//     unlimited.
+//   - WorkersPerProxy: The task executions allowed to run at once per healthy proxy.
+//   - MinWorkers: The minimum number of task executions allowed to run at once.
+//   - MaxWorkers: The maximum number of task executions allowed to run at once. Zero means
+//     unlimited.
+//
+// # Example:
+//
+//	gameHandler.SetParallelism(&handler.Parallelism{RequestsPerMinute: 20, WorkersPerProxy: 2, MinWorkers: 1})
+type Parallelism struct {
+	RequestsPerMinute float64
+	WorkersPerProxy   int
+	MinWorkers        int
+	MaxWorkers        int
+}
+
+// NewParallelism creates the parallelism of the parallelism section of the configuration file,
+// or returns nil when its mode is not "auto".
+func NewParallelism(config types.ParallelismConfig) *Parallelism {
+	if !strings.EqualFold(config.Mode, "auto") {
+		return nil
+	}
+	parallelism := &Parallelism{
+		RequestsPerMinute: config.RequestsPerMinute,
+		WorkersPerProxy:   config.WorkersPerProxy,
+		MinWorkers:        config.MinWorkers,
+		MaxWorkers:        config.MaxWorkers,
+	}
+	if parallelism.RequestsPerMinute <= 0 {
+		parallelism.RequestsPerMinute = 30
+	}
+	if parallelism.WorkersPerProxy <= 0 {
+		parallelism.WorkersPerProxy = 2
+	}
+	if parallelism.MinWorkers <= 0 {
+		parallelism.MinWorkers = 1
+	}
+	return parallelism
+}
+
+// Workers returns the number of task executions allowed to run at once for a farm of proxies
+// healthy proxies and accounts enabled accounts.
+func (parallelism *Parallelism) Workers(proxies, accounts int) int {
+	workers := max(proxies, 1) * max(parallelism.WorkersPerProxy, 1)
+	if accounts > 0 {
+		workers = min(workers, accounts)
+	}
+	if parallelism.MaxWorkers > 0 {
+		workers = min(workers, parallelism.MaxWorkers)
+	}
+	return max(workers, parallelism.MinWorkers, 1)
+}
+
+// rateLimit returns a new per-host rate limit of a proxy, or nil when the requests are not
+// limited.
+func (parallelism *Parallelism) rateLimit() *httpclient.HostRateLimit {
+	if parallelism == nil || parallelism.RequestsPerMinute <= 0 {
+		return nil
+	}
+	return httpclient.NewHostRateLimit(parallelism.RequestsPerMinute)
+}
+
+// SetParallelism sets the sizing of the handler's task dispatch and request rates from the
+// size of its farm, and applies it. Passing nil stops the tuning; the current concurrency and
+// rate limits are left in place.
+//
+// # Parameters:
+//   - parallelism: The parallelism, see NewParallelism.
+func (handler *GameHandler) SetParallelism(parallelism *Parallelism) {
+	handler.mu.Lock()
+	handler.parallelism = parallelism
+	if parallelism != nil {
+		if handler.HttpClient != nil {
+			handler.HttpClient.SetHostRateLimit(parallelism.rateLimit())
+		}
+		for _, client := range handler.clients {
+			client.SetHostRateLimit(parallelism.rateLimit())
+		}
+	}
+	handler.mu.Unlock()
+	handler.tuneParallelism()
+}
+
+// tuneParallelism sets the concurrency of the task dispatch from the current number of healthy
+// proxies and enabled accounts, if the handler has a parallelism.
+func (handler *GameHandler) tuneParallelism() {
+	handler.mu.Lock()
+	parallelism, pool, throttle := handler.parallelism, handler.ProxyPool, handler.throttle
+	if parallelism == nil {
+		handler.mu.Unlock()
+		return
+	}
+	if throttle == nil {
+		throttle = newFixedThrottle()
+		handler.throttle = throttle
+	}
+	accounts := 0
+	for _, account := range handler.Accounts {
+		if account.IsEnabled() {
+			accounts++
+		}
+	}
+	handler.mu.Unlock()
+	proxies := 1
+	if pool != nil {
+		proxies = pool.Healthy()
+	}
+	workers := parallelism.Workers(proxies, accounts)
+	if previous := throttle.setMaxConcurrency(workers); previous != workers {
+		handler.GetLogger().Info("Tuned task concurrency to the farm size", zap.Int("workers", workers),
+			zap.Int("proxies", proxies), zap.Int("accounts", accounts))
+	}
+}
+
+// newFixedThrottle creates a throttle that only bounds the concurrency of the task dispatch:
+// its threshold cannot be reached, so it never widens intervals nor lowers the concurrency.
+func newFixedThrottle() *Throttle {
+	return &Throttle{Window: time.Minute, Threshold: 2, MinSamples: 1, MaxFactor: 1}
+}
@@ -0,0 +1,33 @@
+package handler
+
+// Pause stops the handler from starting task executions until Resume is called.
+//
+// Unlike Drain, Pause is temporary: scheduled tasks stay scheduled, and the executions that
+// fall due while the handler is paused are skipped. Executions already running finish normally.
+//
+// # Example:
+//
+//	handler.Pause()
+//	defer handler.Resume()
+//	rotateProxies()
+func (handler *GameHandler) Pause() {
+	handler.mu.Lock()
+	handler.paused = true
+	handler.mu.Unlock()
+	handler.GetLogger().Info("Task executions paused")
+}
+
+// Resume undoes Pause.
+func (handler *GameHandler) Resume() {
+	handler.mu.Lock()
+	handler.paused = false
+	handler.mu.Unlock()
+	handler.GetLogger().Info("Task executions resumed")
+}
+
+// Paused reports whether the handler is paused.
+func (handler *GameHandler) Paused() bool {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return handler.paused
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/metrics"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+)
+
+// ErrPayloadTooLarge is returned for the requests of a task whose body exceeds the limit of
+// the handler's PayloadLimits. The task execution fails without being retried.
+var ErrPayloadTooLarge = errors.New("request payload too large")
+
+// PayloadLimits caps the size of the request bodies sent by tasks, so that a templating bug
+// generating a megabyte payload fails the task instead of wasting proxy bandwidth on every
+// account and tripping the firewall of the game.
+//
+// # Fields:
+//   - MaxBytes: The largest body sent by any task, in bytes. Zero means unlimited.
+//   - Tasks: The limits of specific tasks, keyed by task name, overriding MaxBytes. Zero
+//     means unlimited.
+//
+// # Example:
+//
+//	handler.SetPayloadLimits(&handler.PayloadLimits{MaxBytes: 64 << 10, Tasks: map[string]int{"SyncTaps": 512 << 10}})
+type PayloadLimits struct {
+	MaxBytes int
+	Tasks    map[string]int
+}
+
+// NewPayloadLimits creates the payload limits of the payload_limits section of the
+// configuration file, or returns nil when no limit is configured.
+func NewPayloadLimits(config types.PayloadLimitConfig) *PayloadLimits {
+	if config.MaxBytes <= 0 && len(config.Tasks) == 0 {
+		return nil
+	}
+	return &PayloadLimits{MaxBytes: config.MaxBytes, Tasks: config.Tasks}
+}
+
+// Limit returns the largest body a task may send, zero when unlimited.
+func (limits *PayloadLimits) Limit(task string) int {
+	if limits == nil {
+		return 0
+	}
+	if limit, ok := limits.Tasks[task]; ok {
+		return limit
+	}
+	return limits.MaxBytes
+}
+
+// SetPayloadLimits sets the limits of the request bodies sent by tasks. Passing nil removes
+// them. The sizes of the bodies are recorded in the metrics of the handler either way.
+func (handler *GameHandler) SetPayloadLimits(limits *PayloadLimits) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.payloadLimit = limits
+}
+
+// checkPayload records the size of a request body of the view's task, and returns an error
+// wrapping ErrPayloadTooLarge when it exceeds the task's limit.
+func (view *accountHandler) checkPayload(url string, payload []byte) error {
+	view.mu.Lock()
+	limit := view.payloadLimit.Limit(view.task)
+	view.mu.Unlock()
+	labels := view.MetricLabels(nil).With(metrics.LabelTask, view.task)
+	_ = metrics.DefaultRegistry.Add("nexus_request_payloads_total", "Request bodies of tasks, refused ones included.", labels, 1)
+	_ = metrics.DefaultRegistry.Add("nexus_request_payload_bytes_total", "Bytes of the request bodies of tasks before compression, refused ones included.",
+		labels, float64(len(payload)))
+	if limit <= 0 || len(payload) <= limit {
+		return nil
+	}
+	_ = metrics.DefaultRegistry.Add("nexus_request_payloads_rejected_total", "Request bodies refused for exceeding their limit.", labels, 1)
+	utils.WithAccount(utils.ModuleLogger("handler"), view.GameName, view.account).Warn("Request payload exceeds its limit",
+		zap.String("task", view.task), zap.String("url", url), zap.Int("bytes", len(payload)), zap.Int("limit", limit))
+	return fmt.Errorf("%w: task %s sent %d bytes to %s, over its limit of %d bytes", ErrPayloadTooLarge, view.task, len(payload), url, limit)
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/proxypool"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// Preconnect warms up the connections of a handler before its first scheduled tick: when a run
+// starts, a TLS connection to every game host is opened through every healthy proxy of the pool
+// (or the handler's client without a pool), so that the first requests of the accounts do not
+// all wait on cold handshakes through slow proxies at once.
+//
+// # Fields:
+//   - Hosts: URLs of the hosts connected to. Defaults to the base URLs of the handler.
+//   - Concurrency: How many proxies are connected through at once.
+//   - Timeout: How long the warm-up may take before the run starts anyway. Zero means no limit.
+//
+// # Example:
+//
+//	gameHandler.SetPreconnect(&handler.Preconnect{Hosts: []string{"https://api.example.com", "https://cdn.example.com"},
+//		Concurrency: 16, Timeout: 15 * time.Second})
+type Preconnect struct {
+	Hosts       []string
+	Concurrency int
+	Timeout     time.Duration
+}
+
+// NewPreconnect creates the warm-up of the preconnect section of the configuration file, or
+// returns nil when it is disabled.
+func NewPreconnect(config types.PreconnectConfig) *Preconnect {
+	if !config.Enabled {
+		return nil
+	}
+	preconnect := &Preconnect{
+		Hosts:       config.Hosts,
+		Concurrency: config.Concurrency,
+		Timeout:     time.Duration(config.TimeoutSeconds) * time.Second,
+	}
+	if preconnect.Concurrency <= 0 {
+		preconnect.Concurrency = 16
+	}
+	if preconnect.Timeout <= 0 {
+		preconnect.Timeout = 10 * time.Second
+	}
+	return preconnect
+}
+
+// SetPreconnect sets the connection warm-up run when the tasks of the handler start. Passing nil
+// disables it.
+//
+// Handlers created by NewGameHandler warm up their connections when the preconnect section of
+// their configuration is enabled.
+func (handler *GameHandler) SetPreconnect(preconnect *Preconnect) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.preconnect = preconnect
+}
+
+// Preconnect opens a connection to every game host through every healthy proxy of the pool, or
+// through the handler's client without a pool, with the hosts and concurrency of the handler's
+// Preconnect (its base URL and named base URLs, and 16 proxies at once, without one). See
+// httpclient.HTTPClient.Preconnect.
+//
+// # Returns:
+//   - error: The errors of the proxies that could not connect, joined.
+func (handler *GameHandler) Preconnect(ctx context.Context) error {
+	handler.mu.Lock()
+	preconnect, pool := handler.preconnect, handler.ProxyPool
+	var hosts []string
+	if handler.BaseURL != "" {
+		hosts = append(hosts, handler.BaseURL)
+	}
+	for _, url := range handler.BaseURLs {
+		hosts = append(hosts, url)
+	}
+	handler.mu.Unlock()
+	concurrency := 16
+	if preconnect != nil {
+		if len(preconnect.Hosts) > 0 {
+			hosts = preconnect.Hosts
+		}
+		concurrency = max(preconnect.Concurrency, 1)
+	}
+	if len(hosts) == 0 {
+		return errors.New("no host to preconnect to, set a base URL or preconnect hosts")
+	}
+
+	clients := []*httpclient.HTTPClient{handler.HttpClient}
+	var clientErrs []error
+	if pool != nil {
+		clients, clientErrs = handler.poolClients(pool)
+	}
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	errs := clientErrs
+	for _, client := range clients {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+		wg.Add(1)
+		go func(client *httpclient.HTTPClient) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := client.Preconnect(ctx, hosts...); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(client)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// poolClients returns the clients of the healthy proxies of pool.
+func (handler *GameHandler) poolClients(pool *proxypool.Pool) ([]*httpclient.HTTPClient, []error) {
+	dead := make(map[string]bool)
+	for _, proxy := range pool.Dead() {
+		dead[proxypool.Key(proxy)] = true
+	}
+	var clients []*httpclient.HTTPClient
+	var errs []error
+	for _, proxy := range pool.Proxies() {
+		if dead[proxypool.Key(proxy)] {
+			continue
+		}
+		client, err := handler.clientForProxy(proxy)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		clients = append(clients, client)
+	}
+	return clients, errs
+}
+
+// warmUpConnections runs the connection warm-up of the handler, if any, within its timeout.
+func (handler *GameHandler) warmUpConnections(ctx context.Context) {
+	handler.mu.Lock()
+	preconnect := handler.preconnect
+	handler.mu.Unlock()
+	if preconnect == nil {
+		return
+	}
+	if preconnect.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, preconnect.Timeout)
+		defer cancel()
+	}
+	started := time.Now()
+	if err := handler.Preconnect(ctx); err != nil {
+		handler.GetLogger().Warn("Some connections could not be warmed up", zap.Duration("duration", time.Since(started)), zap.Error(err))
+		return
+	}
+	handler.GetLogger().Info("Connections warmed up", zap.Duration("duration", time.Since(started)))
+}
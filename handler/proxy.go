@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/metrics"
+	"github.com/nexus-telegram/NexusSDK/proxypool"
+	"github.com/nexus-telegram/NexusSDK/sink"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"go.uber.org/zap"
+	"time"
+)
+
+// SetProxyPool sets the pool of proxies distributed across the handler's accounts.
+//
+// When a pool is set, every account request is sent through the proxy assigned by the
+// pool's strategy instead of the handler's single Proxy. Proxies that fail to connect, or
+// whose error rate exceeds the threshold of the pool's proxypool.HealthPolicy, are marked as
+// dead and their accounts are reassigned to healthy proxies (see AddProxyListener).
+//
+// # Parameters:
+//   - pool: The proxy pool, or nil to use the handler's single Proxy again.
+//
+// # Example:
+//
+//	proxies, err := proxypool.LoadProxies("proxies.txt")
+//	if err != nil {
+//		log.Fatalf("Failed to load proxies: %v", err)
+//	}
+//	pool, err := proxypool.NewPool(proxies, proxypool.StrategyPerAccount, 0)
+//	if err != nil {
+//		log.Fatalf("Failed to create proxy pool: %v", err)
+//	}
+//	handler.SetProxyPool(pool)
+func (handler *GameHandler) SetProxyPool(pool *proxypool.Pool) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.ProxyPool = pool
+}
+
+// clientFor returns the HTTP client and proxy to use for the next request of an account.
+func (handler *GameHandler) clientFor(account types.Account) (*httpclient.HTTPClient, types.Proxy, error) {
+	handler.mu.Lock()
+	pool := handler.ProxyPool
+	handler.mu.Unlock()
+	if pool == nil {
+		return handler.HttpClient, handler.Proxy, nil
+	}
+	proxy, err := pool.AcquireIn(account.TelegramData.TelegramId, account.Country)
+	if err != nil {
+		return nil, proxy, err
+	}
+	client, err := handler.sessionClient(pool, account, proxy)
+	return client, proxy, err
+}
+
+// sessionClient returns the HTTP client of an account for a proxy of the pool, through the
+// account's session when the proxy is a rotating gateway (see proxypool.Session). The clients
+// of expired sessions are closed.
+func (handler *GameHandler) sessionClient(pool *proxypool.Pool, account types.Account, proxy types.Proxy) (*httpclient.HTTPClient, error) {
+	ttl := time.Duration(account.SessionTTLMinutes) * time.Minute
+	exit, expired := pool.Session(account.TelegramData.TelegramId, proxy, ttl)
+	if len(expired) > 0 {
+		handler.mu.Lock()
+		for _, key := range expired {
+			if client, ok := handler.clients[key]; ok {
+				client.CloseIdleConnections()
+				delete(handler.clients, key)
+			}
+		}
+		handler.mu.Unlock()
+	}
+	return handler.clientForProxy(exit)
+}
+
+// clientForProxy returns the cached HTTP client for a proxy, creating it if needed.
+func (handler *GameHandler) clientForProxy(proxy types.Proxy) (*httpclient.HTTPClient, error) {
+	key := proxypool.Key(proxy)
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if client, ok := handler.clients[key]; ok {
+		return client, nil
+	}
+	if proxy.Timeout == 0 {
+		proxy.Timeout = handler.Proxy.Timeout
+	}
+	client, err := httpclient.NewHTTPClient(proxy)
+	if err != nil {
+		return nil, err
+	}
+	if !handler.tlsOptions.IsZero() {
+		if err := client.SetTLSOptions(handler.tlsOptions); err != nil {
+			return nil, err
+		}
+	}
+	if !handler.network.IsZero() {
+		if err := client.SetNetworkOptions(handler.network); err != nil {
+			return nil, err
+		}
+	}
+	if handler.contentTypes != nil {
+		client.SetContentTypes(*handler.contentTypes)
+	}
+	client.SetAuditLog(handler.auditLog)
+	client.SetLimiter(handler.budget.limiter())
+	client.SetObserver(latencyObserver{handler: handler})
+	client.SetFlightGroup(handler.flights)
+	client.SetHostRateLimit(handler.parallelism.rateLimit())
+	if handler.clients == nil {
+		handler.clients = make(map[string]*httpclient.HTTPClient)
+	}
+	handler.clients[key] = client
+	return client, nil
+}
+
+// AddProxyListener adds a function called when a proxy of the pool is retired, because it
+// could not be reached or because its error rate exceeded the threshold of the pool's
+// proxypool.HealthPolicy, so that the operator can replace it. The accounts of the proxy are
+// already moved to healthy proxies when the listener is called.
+//
+// Listeners are called synchronously from the goroutine of the request, so they must return
+// quickly.
+//
+// # Example:
+//
+//	handler.AddProxyListener(func(replacement proxypool.Replacement) {
+//		_ = notifier.Notify(context.Background(), fmt.Sprintf("Replace proxy %s (%d accounts moved)",
+//			replacement.Address, len(replacement.Accounts)))
+//	})
+func (handler *GameHandler) AddProxyListener(listener func(replacement proxypool.Replacement)) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.proxyEvents = append(handler.proxyEvents, listener)
+}
+
+// reportProxyError records the outcome of a request sent through a proxy of the pool, and
+// retires the proxy when err shows that it could not be reached or when its error rate is too
+// high. Error responses of the game server and cancelled requests do not count as failures.
+func (handler *GameHandler) reportProxyError(proxy types.Proxy, err error) {
+	handler.mu.Lock()
+	pool := handler.ProxyPool
+	handler.mu.Unlock()
+	if pool == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrBudgetExhausted) {
+		return
+	}
+	var statusErr *httpclient.StatusError
+	replacement, retired := pool.Report(proxy, err != nil && !errors.As(err, &statusErr))
+	if !retired && proxypool.IsProxyError(err) {
+		replacement, retired = pool.Retire(proxy)
+	}
+	if !retired {
+		return
+	}
+	handler.GetLogger().Warn("Proxy marked as dead",
+		zap.String("proxy", replacement.Address),
+		zap.Int("healthy", pool.Healthy()),
+		zap.Int("requests", replacement.Requests),
+		zap.Int("failures", replacement.Failures),
+		zap.Int("reassigned", len(replacement.Accounts)),
+		zap.Error(err),
+	)
+	event := sink.Event{
+		Type: sink.EventProxyDead,
+		Data: map[string]interface{}{"proxy": replacement.Address, "healthy": pool.Healthy(), "reassigned": len(replacement.Accounts)},
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	handler.publishEvent(event)
+	_ = metrics.DefaultRegistry.Add("nexus_proxies_dead_total", "Proxies of the pool marked as dead.", handler.MetricLabels(nil), 1)
+	handler.tuneParallelism()
+	handler.mu.Lock()
+	listeners := handler.proxyEvents
+	handler.mu.Unlock()
+	for _, listener := range listeners {
+		listener(replacement)
+	}
+}
+
+// SetProxyProvider sets the provider the proxy list of the pool is refreshed from while tasks
+// run, so that proxies bought or replaced at the provider reach the pool without a manual
+// export. The pool must be set (see SetProxyPool). Passing nil stops the refreshes.
+//
+// Handlers created by NewGameHandler with a provider in their proxy_pool section fetch the
+// initial list from the provider and refresh it every refresh_minutes.
+//
+// # Parameters:
+//   - provider: The provider, e.g. &proxypool.Webshare{APIKey: key}.
+//   - interval: How often the list is fetched again. Defaults to proxypool.DefaultRefreshInterval.
+//
+// # Example:
+//
+//	handler.SetProxyProvider(proxypool.Join(proxypool.List(fileProxies), &proxypool.Proxy6{APIKey: key}), time.Hour)
+func (handler *GameHandler) SetProxyProvider(provider proxypool.Provider, interval time.Duration) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.proxySource = provider
+	handler.proxyRefresh = interval
+}
+
+// RefreshProxies fetches the proxy list from the provider of the handler and replaces the
+// proxies of the pool with it (see proxypool.Pool.Update).
+//
+// # Returns:
+//   - error: An error if no provider or pool is set, the fetch fails, or the list is empty. The
+//     pool is left unchanged then.
+func (handler *GameHandler) RefreshProxies(ctx context.Context) error {
+	handler.mu.Lock()
+	source, pool := handler.proxySource, handler.ProxyPool
+	handler.mu.Unlock()
+	if source == nil || pool == nil {
+		return errors.New("no proxy provider configured")
+	}
+	proxies, err := source.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if err := pool.Update(proxies); err != nil {
+		return err
+	}
+	handler.GetLogger().Info("Proxy list refreshed", zap.Int("proxies", len(proxies)), zap.Int("healthy", pool.Healthy()))
+	handler.tuneParallelism()
+	return nil
+}
+
+// refreshProxies refreshes the proxy list every interval of the provider until ctx is done or
+// the handler is drained. It returns immediately if no provider is set.
+func (handler *GameHandler) refreshProxies(ctx context.Context, draining <-chan struct{}) {
+	handler.mu.Lock()
+	source, interval := handler.proxySource, handler.proxyRefresh
+	handler.mu.Unlock()
+	if source == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = proxypool.DefaultRefreshInterval
+	}
+	ticker := handler.getClock().NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-draining:
+			return
+		case <-ticker.C():
+			if err := handler.RefreshProxies(ctx); err != nil && ctx.Err() == nil {
+				handler.GetLogger().Warn("Failed to refresh proxy list, keeping the current one", zap.Error(err))
+			}
+		}
+	}
+}
+
+// bandwidthDefault is a proxy provider giving its proxies without a bandwidth limit the
+// default limit of the proxy pool configuration.
+type bandwidthDefault struct {
+	source         proxypool.Provider
+	bytesPerSecond int64
+}
+
+func (provider bandwidthDefault) Fetch(ctx context.Context) ([]types.Proxy, error) {
+	proxies, err := provider.source.Fetch(ctx)
+	for i := range proxies {
+		if proxies[i].BytesPerSecond == 0 {
+			proxies[i].BytesPerSecond = provider.bytesPerSecond
+		}
+	}
+	return proxies, err
+}
@@ -0,0 +1,369 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/jobqueue"
+	"github.com/nexus-telegram/NexusSDK/tasks"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ClockJumpThreshold is how far the wall-clock time elapsed between two polls of the job queue
+// may differ from the poll interval before the scheduler assumes that the clock jumped, e.g.
+// after a VM suspend, a laptop sleep, or a manual clock change.
+const ClockJumpThreshold = time.Minute
+
+// scheduledJob is a scheduled task of an account, run by the queue dispatcher.
+type scheduledJob struct {
+	account  types.Account
+	task     tasks.Task
+	schedule tasks.Scheduled
+}
+
+// scheduledJobs returns the scheduled tasks of the enabled accounts.
+func (handler *GameHandler) scheduledJobs() []scheduledJob {
+	var scheduled []scheduledJob
+	for _, account := range handler.Accounts {
+		if !account.IsEnabled() {
+			continue
+		}
+		for _, task := range handler.Tasks {
+			if schedule, ok := task.(tasks.Scheduled); ok {
+				scheduled = append(scheduled, scheduledJob{account: account, task: task, schedule: schedule})
+			}
+		}
+	}
+	return scheduled
+}
+
+// SetJobQueue sets the queue storing the scheduled task executions of the handler.
+//
+// The scheduler adds one job per scheduled task and account, polls the queue for due jobs,
+// and leases them while they run, so scheduled work survives restarts with a persistent queue
+// and can be inspected and cancelled individually (see Jobs and CancelJob). Without a queue,
+// an in-memory one is used.
+//
+// # Parameters:
+//   - queue: The job queue, e.g. jobqueue.OpenLocal, jobqueue.OpenBolt, or jobqueue.NewRedis.
+//
+// # Example:
+//
+//	queue, err := jobqueue.OpenLocal("state/jobs.json")
+//	if err != nil {
+//		log.Fatalf("Failed to open job queue: %v", err)
+//	}
+//	handler.SetJobQueue(queue)
+func (handler *GameHandler) SetJobQueue(queue jobqueue.Queue) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.queue = queue
+}
+
+// jobQueue returns the job queue of the handler, creating an in-memory one if needed.
+func (handler *GameHandler) jobQueue() jobqueue.Queue {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.queue == nil {
+		handler.queue = jobqueue.NewLocal()
+	}
+	return handler.queue
+}
+
+// Jobs returns the scheduled jobs of the handler's game, ordered by ID.
+func (handler *GameHandler) Jobs() ([]jobqueue.Job, error) {
+	return handler.jobQueue().List(context.Background(), handler.GameName)
+}
+
+// CancelJob stops a scheduled job from running until ResumeJob is called. An execution
+// already running finishes normally.
+//
+// # Parameters:
+//   - id: The job ID, as returned by Jobs.
+//
+// # Returns:
+//   - error: jobqueue.ErrJobNotFound if no job has the ID.
+func (handler *GameHandler) CancelJob(id string) error {
+	return handler.jobQueue().Cancel(context.Background(), id)
+}
+
+// ResumeJob undoes CancelJob.
+func (handler *GameHandler) ResumeJob(id string) error {
+	return handler.jobQueue().Resume(context.Background(), id)
+}
+
+// queueOwner returns the lease owner identifying the handler in the job queue.
+func (handler *GameHandler) queueOwner() string {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.owner == "" {
+		handler.owner = jobqueue.NewOwner()
+	}
+	return handler.owner
+}
+
+// runQueue adds the scheduled jobs to the job queue and runs them when they are due, until
+// ctx is done or draining is closed. It returns once the running executions have finished.
+func (handler *GameHandler) runQueue(ctx context.Context, draining <-chan struct{}, recorder *runRecorder, jobs []scheduledJob) {
+	if len(jobs) == 0 {
+		return
+	}
+	queue := handler.jobQueue()
+	owner := handler.queueOwner()
+	schedulerClock := handler.getClock()
+	log := handler.GetLogger()
+	byID := handler.addJobs(ctx, queue, jobs)
+	ids := jobIDs(byID)
+	handler.diag.addTicker(len(byID))
+	defer handler.diag.addTicker(-len(byID))
+
+	var running sync.WaitGroup
+	defer running.Wait()
+	var mu sync.Mutex
+	active := make(map[string]bool)
+	for {
+		leased, err := queue.Lease(ctx, handler.GameName, owner, ids, schedulerClock.Now(), jobqueue.DefaultLeaseTTL)
+		if err != nil {
+			log.Warn("Failed to lease due jobs", zap.Error(err))
+		}
+		accounts := make([]string, len(leased))
+		for i, lease := range leased {
+			accounts[i] = lease.Account
+		}
+		for _, i := range handler.rotate(accounts) {
+			lease := leased[i]
+			mu.Lock()
+			busy := active[lease.ID]
+			active[lease.ID] = true
+			mu.Unlock()
+			if busy {
+				continue
+			}
+			running.Add(1)
+			go func(lease jobqueue.Job, job scheduledJob) {
+				defer running.Done()
+				defer func() {
+					mu.Lock()
+					delete(active, lease.ID)
+					mu.Unlock()
+				}()
+				handler.runJob(recorder, queue, owner, lease, job)
+			}(lease, byID[lease.ID])
+		}
+		polled := schedulerClock.Now()
+		timer := schedulerClock.NewTimer(jobqueue.DefaultPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-draining:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+		jump := wallElapsed(polled, schedulerClock.Now()) - jobqueue.DefaultPollInterval
+		if jump > ClockJumpThreshold || jump < -ClockJumpThreshold {
+			handler.recomputeSchedules(ctx, queue, byID, jump)
+		}
+	}
+}
+
+// jobIDs returns the IDs of the jobs of the handler, which are the only ones it leases.
+func jobIDs(byID map[string]scheduledJob) []string {
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// addJobs adds the scheduled jobs to the job queue, keeping the due times of the jobs already
+// stored, applies the catch-up policy of their task to the jobs missed during a downtime, and
+// returns the jobs keyed by job ID.
+func (handler *GameHandler) addJobs(ctx context.Context, queue jobqueue.Queue, jobs []scheduledJob) map[string]scheduledJob {
+	log := handler.GetLogger()
+	byID := make(map[string]scheduledJob, len(jobs))
+	now := handler.getClock().Now()
+	for _, job := range jobs {
+		id := jobqueue.JobID(handler.GameName, job.account.TelegramData.TelegramId, taskName(job.task))
+		byID[id] = job
+		stored, err := queue.Add(ctx, jobqueue.Job{
+			ID:      id,
+			Game:    handler.GameName,
+			Account: job.account.TelegramData.TelegramId,
+			Task:    taskName(job.task),
+			Due:     job.schedule.Next(time.Time{}, now),
+		})
+		if err != nil {
+			log.Error("Failed to add scheduled job", zap.String("job", id), zap.Error(err))
+			continue
+		}
+		if !stored.Cancelled && stored.LeaseOwner == "" {
+			handler.catchUp(ctx, queue, id, job, stored.Due, now)
+		}
+	}
+	return byID
+}
+
+// wallElapsed returns the wall-clock time elapsed between since and now. Unlike now.Sub(since),
+// it ignores the monotonic clock readings, which do not advance while the machine sleeps.
+func wallElapsed(since, now time.Time) time.Duration {
+	return now.Round(0).Sub(since.Round(0))
+}
+
+// recomputeSchedules reschedules the jobs of the handler after the clock jumped by jump, so
+// that the process does not fire every tick it missed at once when it wakes up.
+//
+// After a forward jump, the catch-up policy of their task is applied to the jobs that became
+// due while the clock jumped (see catchUp). After a backward jump, the jobs are moved back by
+// the jump, keeping their interval from now.
+func (handler *GameHandler) recomputeSchedules(ctx context.Context, queue jobqueue.Queue, byID map[string]scheduledJob, jump time.Duration) {
+	log := handler.GetLogger()
+	now := handler.getClock().Now()
+	log.Warn("Clock jump detected, recomputing schedules", zap.Duration("jump", jump))
+	jobs, err := queue.List(ctx, handler.GameName)
+	if err != nil {
+		log.Warn("Failed to list jobs after clock jump", zap.Error(err))
+		return
+	}
+	for _, job := range jobs {
+		scheduled, ok := byID[job.ID]
+		if !ok || job.Cancelled {
+			continue
+		}
+		switch {
+		case jump > 0 && job.Due.Before(now):
+			handler.catchUp(ctx, queue, job.ID, scheduled, job.Due, now)
+		case jump < 0:
+			if err := queue.Reschedule(ctx, job.ID, job.Due.Add(jump), now); err != nil {
+				log.Warn("Failed to reschedule job after clock jump", zap.String("job", job.ID), zap.Error(err))
+			}
+		}
+	}
+}
+
+// catchUpPolicy returns the catch-up policy of a task, tasks.CatchUpOnce when it has none.
+func catchUpPolicy(task tasks.Task) tasks.CatchUpPolicy {
+	if catching, ok := task.(tasks.CatchingUp); ok {
+		return catching.MissedRunPolicy()
+	}
+	return tasks.CatchUpOnce
+}
+
+// catchUp applies the catch-up policy of its task to a job whose execution due at due was
+// missed during a downtime. Executions due less than ClockJumpThreshold ago are not missed;
+// they run normally.
+//
+// A job left due runs once at the next poll. With tasks.CatchUpSkip, the job is rescheduled at
+// the next time its task returns from now; with tasks.CatchUpAll, the other missed executions
+// are run one after the other by runJob.
+func (handler *GameHandler) catchUp(ctx context.Context, queue jobqueue.Queue, id string, job scheduledJob, due, now time.Time) {
+	if now.Sub(due) <= ClockJumpThreshold {
+		return
+	}
+	policy := catchUpPolicy(job.task)
+	missed := tasks.MissedRuns(job.schedule, due, now)
+	log := utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, job.account)
+	log.Info("Missed scheduled executions", zap.String("task", taskName(job.task)), zap.Int("missed", missed),
+		zap.String("policy", string(policy)))
+	switch policy {
+	case tasks.CatchUpSkip:
+		if err := queue.Reschedule(ctx, id, job.schedule.Next(time.Time{}, now), now); err != nil {
+			log.Warn("Failed to skip missed executions", zap.String("job", id), zap.Error(err))
+		}
+	case tasks.CatchUpAll:
+		handler.mu.Lock()
+		if handler.backlog == nil {
+			handler.backlog = make(map[string]int)
+		}
+		handler.backlog[id] = missed - 1
+		handler.mu.Unlock()
+	}
+}
+
+// replayMissed reports whether a missed execution of the job is left to replay, and counts it
+// as replayed.
+func (handler *GameHandler) replayMissed(id string) bool {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.backlog[id] <= 0 {
+		delete(handler.backlog, id)
+		return false
+	}
+	handler.backlog[id]--
+	return true
+}
+
+// runJob runs a leased job and reschedules it at the next time returned by its task, later
+// while the task keeps failing for the account (see SetIntervalBackoff). Jobs falling due
+// while the game is under maintenance are postponed to the end of the maintenance (see
+// SetMaintenance). The lease of the job is extended while it runs.
+func (handler *GameHandler) runJob(recorder *runRecorder, queue jobqueue.Queue, owner string, lease jobqueue.Job, job scheduledJob) {
+	id := lease.ID
+	if until, ok := handler.UnderMaintenance(); ok {
+		recorder.skip()
+		if err := queue.Complete(context.Background(), id, owner, lease.Last, until); err != nil {
+			handler.GetLogger().Warn("Failed to postpone job", zap.String("job", id), zap.Error(err))
+		}
+		return
+	}
+	stop := handler.keepLeased(queue, owner, id)
+	defer stop()
+	schedulerClock := handler.getClock()
+	last := schedulerClock.Now()
+	if err := handler.runTaskWithRetry(recorder, job.account, job.task); err != nil {
+		utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, job.account).Error("Error executing scheduled task",
+			zap.String("run_id", recorder.runID()), zap.Error(err))
+	}
+	next := handler.stretchInterval(id, job.task, last, job.schedule.Next(last, schedulerClock.Now()))
+	if handler.replayMissed(id) {
+		next = schedulerClock.Now()
+	}
+	if warmUp := handler.warmUpPolicy(); warmUp.WarmingUp(job.account, last) {
+		next = warmUp.widen(last, next)
+	}
+	if throttle := handler.getThrottle(); throttle != nil {
+		next = throttle.widen(schedulerClock.Now(), next)
+	}
+	if until, ok := handler.UnderMaintenance(); ok && next.Before(until) {
+		next = until
+	}
+	if err := queue.Complete(context.Background(), id, owner, last, next); err != nil {
+		utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, job.account).Warn("Failed to reschedule job",
+			zap.String("job", id), zap.Error(err))
+	}
+}
+
+// keepLeased extends the lease of a running job every third of jobqueue.DefaultLeaseTTL, so
+// that an execution outliving the lease, e.g. through retries and refreshes, is not leased and
+// run again by another instance. It stops when the returned function is called or the lease
+// is lost.
+func (handler *GameHandler) keepLeased(queue jobqueue.Queue, owner, id string) (stop func()) {
+	schedulerClock := handler.getClock()
+	ticker := schedulerClock.NewTicker(jobqueue.DefaultLeaseTTL / 3)
+	done := make(chan struct{})
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C():
+			}
+			err := queue.Extend(context.Background(), id, owner, schedulerClock.Now().Add(jobqueue.DefaultLeaseTTL))
+			if errors.Is(err, jobqueue.ErrLeaseLost) || errors.Is(err, jobqueue.ErrJobNotFound) {
+				handler.GetLogger().Warn("Lease of running job lost", zap.String("job", id), zap.Error(err))
+				return
+			}
+			if err != nil {
+				handler.GetLogger().Warn("Failed to extend job lease", zap.String("job", id), zap.Error(err))
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"github.com/nexus-telegram/NexusSDK/remote"
+	"io"
+	"os"
+	"sync"
+)
+
+var (
+	remoteMu      sync.RWMutex
+	remoteOptions = remote.DefaultOptions()
+)
+
+// SetRemoteOptions configures how LoadConfig, LoadConfigProfile, LoadAccounts, and LoadTasks
+// fetch remote locations (https://, s3://, and gs:// URLs).
+//
+// The options default to remote.DefaultOptions, which reads credentials from the environment.
+//
+// # Parameters:
+//   - options: The authentication and caching options.
+//
+// # Example:
+//
+//	options := remote.DefaultOptions()
+//	options.CacheDir = "/var/cache/nexus"
+//	handler.SetRemoteOptions(options)
+//	config, err := handler.LoadConfig("s3://farm-config/prod/config.json")
+func SetRemoteOptions(options remote.Options) {
+	remoteMu.Lock()
+	defer remoteMu.Unlock()
+	remoteOptions = options
+}
+
+// openLocation opens a local file, or fetches a remote document when location is a URL.
+func openLocation(location string) (io.ReadCloser, error) {
+	if !remote.IsRemote(location) {
+		return os.Open(location)
+	}
+	remoteMu.RLock()
+	options := remoteOptions
+	remoteMu.RUnlock()
+	return remote.Open(context.Background(), location, options)
+}
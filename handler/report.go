@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxReportErrors is the number of error types kept in a RunReport.
+const maxReportErrors = 10
+
+// The modes of the runs in a RunReport.
+const (
+	RunModeDaemon = "daemon" // A RunTasks or RunTasksContext call.
+	RunModeOnce   = "once"   // A RunOnce call.
+)
+
+// RunReport summarizes a RunTasks, RunTasksContext, or RunOnce call.
+//
+// # Fields:
+//   - RunID: The ID of the run, assigned when it starts and never changed, e.g.
+//     "20241120T103000-9f86d081". The executions of the run carry it in their logs (run_id),
+//     results (see TaskResult), events (see sink.Event), and history (see history.Execution),
+//     so that overlapping or repeated runs can be told apart.
+//   - Mode: How the run was started, RunModeDaemon or RunModeOnce.
+//   - Host: The host name of the machine the run ran on.
+//   - Game: The name of the game.
+//   - Started: When the run started.
+//   - Finished: When the run returned.
+//   - Duration: The total duration of the run.
+//   - Tasks: The execution statistics of each task, keyed by task name.
+//   - Refreshes: The number of game data refreshes before retries.
+//   - RefreshFailures: The number of game data refreshes that failed.
+//   - Skipped: The number of executions skipped because the request budget was exhausted.
+//   - Unstarted: The number of due executions RunOnce left for the next run because its
+//     maximum duration elapsed or their jobs could not be leased.
+//   - Errors: The most frequent error types of failed executions, most frequent first.
+//
+// # Example:
+//
+//	report := handler.RunTasks()
+//	fmt.Println(report)
+type RunReport struct {
+	RunID           string                `json:"run_id"`
+	Mode            string                `json:"mode"`
+	Host            string                `json:"host,omitempty"`
+	Game            string                `json:"game"`
+	Started         time.Time             `json:"started"`
+	Finished        time.Time             `json:"finished"`
+	Duration        time.Duration         `json:"duration"`
+	Tasks           map[string]*TaskStats `json:"tasks"`
+	Refreshes       int                   `json:"refreshes"`
+	RefreshFailures int                   `json:"refresh_failures"`
+	Skipped         int                   `json:"skipped"`
+	Unstarted       int                   `json:"unstarted,omitempty"`
+	Errors          []ErrorCount          `json:"errors"`
+}
+
+// TaskStats are the execution statistics of one task in a RunReport.
+//
+// # Fields:
+//   - Runs: The number of executions, retries excluded.
+//   - Successes: The number of executions that eventually succeeded.
+//   - Failures: The number of executions that failed on every attempt.
+//   - Retries: The number of retried attempts.
+//   - Duration: The total time spent executing the task, retries included.
+type TaskStats struct {
+	Runs      int           `json:"runs"`
+	Successes int           `json:"successes"`
+	Failures  int           `json:"failures"`
+	Retries   int           `json:"retries"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// ErrorCount is the number of failures sharing an error type in a RunReport or ErrorStats.
+//
+// # Fields:
+//   - Type: The normalized error message, with numbers replaced by "N".
+//   - Category: The category of the error (see ClassifyError).
+//   - Count: The number of failed executions with this error type.
+//   - Example: One full error message of this type.
+type ErrorCount struct {
+	Type     string        `json:"type"`
+	Category ErrorCategory `json:"category"`
+	Count    int           `json:"count"`
+	Example  string        `json:"example"`
+}
+
+// String renders the report as a human-readable summary.
+func (report *RunReport) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Run %s of %s finished in %s\n", report.RunID, report.Game, report.Duration.Round(time.Millisecond))
+	names := make([]string, 0, len(report.Tasks))
+	for name := range report.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		stats := report.Tasks[name]
+		fmt.Fprintf(&builder, "  %s: %d runs, %d ok, %d failed, %d retries, %s\n",
+			name, stats.Runs, stats.Successes, stats.Failures, stats.Retries, stats.Duration.Round(time.Millisecond))
+	}
+	fmt.Fprintf(&builder, "  Refreshes: %d (%d failed), skipped: %d\n", report.Refreshes, report.RefreshFailures, report.Skipped)
+	if report.Unstarted > 0 {
+		fmt.Fprintf(&builder, "  Not started: %d\n", report.Unstarted)
+	}
+	if len(report.Errors) > 0 {
+		builder.WriteString("  Top errors:\n")
+		for _, errorCount := range report.Errors {
+			fmt.Fprintf(&builder, "    %5d  [%s] %s\n", errorCount.Count, errorCount.Category, errorCount.Type)
+		}
+	}
+	return builder.String()
+}
+
+// runRecorder collects the statistics of one run into a RunReport.
+type runRecorder struct {
+	mu     sync.Mutex
+	report RunReport
+	errors map[string]*ErrorCount
+}
+
+func newRunRecorder(game, mode string, started time.Time) *runRecorder {
+	host, _ := os.Hostname()
+	return &runRecorder{
+		report: RunReport{RunID: NewRunID(started), Mode: mode, Host: host, Game: game, Started: started, Tasks: make(map[string]*TaskStats)},
+		errors: make(map[string]*ErrorCount),
+	}
+}
+
+// NewRunID returns a new run ID (see RunReport): the UTC start time of the run, so that IDs
+// sort by start, followed by random characters telling apart the runs started in the same
+// second.
+func NewRunID(started time.Time) string {
+	var random [4]byte
+	_, _ = rand.Read(random[:])
+	return started.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(random[:])
+}
+
+// runID returns the ID of the run, or "" for executions outside of a run.
+func (recorder *runRecorder) runID() string {
+	if recorder == nil {
+		return ""
+	}
+	return recorder.report.RunID
+}
+
+// execution records a finished task execution.
+func (recorder *runRecorder) execution(task string, attempts int, duration time.Duration, err error) {
+	if recorder == nil {
+		return
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	stats, ok := recorder.report.Tasks[task]
+	if !ok {
+		stats = &TaskStats{}
+		recorder.report.Tasks[task] = stats
+	}
+	stats.Runs++
+	if attempts > 1 {
+		stats.Retries += attempts - 1
+	}
+	stats.Duration += duration
+	if err == nil {
+		stats.Successes++
+		return
+	}
+	stats.Failures++
+	errorType := normalizeError(err)
+	errorCount, ok := recorder.errors[errorType]
+	if !ok {
+		errorCount = &ErrorCount{Type: errorType, Category: ClassifyError(err), Example: err.Error()}
+		recorder.errors[errorType] = errorCount
+	}
+	errorCount.Count++
+}
+
+// refresh records a game data refresh.
+func (recorder *runRecorder) refresh(err error) {
+	if recorder == nil {
+		return
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.report.Refreshes++
+	if err != nil {
+		recorder.report.RefreshFailures++
+	}
+}
+
+// skip records an execution skipped because of the request budget.
+func (recorder *runRecorder) skip() {
+	if recorder == nil {
+		return
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.report.Skipped++
+}
+
+// unstarted records a due execution left for the next run.
+func (recorder *runRecorder) unstarted() {
+	if recorder == nil {
+		return
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.report.Unstarted++
+}
+
+// finish returns the report of the run.
+func (recorder *runRecorder) finish(finished time.Time) *RunReport {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	report := recorder.report
+	report.Finished = finished
+	report.Duration = finished.Sub(report.Started)
+	report.Errors = nil
+	for _, errorCount := range recorder.errors {
+		report.Errors = append(report.Errors, *errorCount)
+	}
+	report.Errors = topErrors(report.Errors, maxReportErrors)
+	return &report
+}
+
+var numberPattern = regexp.MustCompile(`\d+`)
+
+// normalizeError returns the message of the root cause of err with numbers replaced, so that
+// errors differing only by account or amount are grouped together.
+func normalizeError(err error) string {
+	for {
+		unwrapped := errors.Unwrap(err)
+		if unwrapped == nil {
+			break
+		}
+		err = unwrapped
+	}
+	message := numberPattern.ReplaceAllString(strings.TrimSpace(err.Error()), "N")
+	if len(message) > 120 {
+		message = message[:120] + "..."
+	}
+	return message
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"github.com/nexus-telegram/NexusSDK/progress"
+	"github.com/nexus-telegram/NexusSDK/tasks"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"time"
+)
+
+// TaskResult is the outcome of one task execution of an account, delivered to the listeners
+// added with AddResultListener.
+//
+// # Fields:
+//   - Game: The name of the game.
+//   - Account: The Telegram ID of the account.
+//   - Task: The name of the task.
+//   - Scheduled: Whether the task is scheduled, as opposed to a one-time task.
+//   - Skipped: Whether the execution was skipped (budget, cooldown, warm-up, or replay protection).
+//   - Started: When the execution started.
+//   - Duration: How long the execution took, retries included.
+//   - Attempts: How many times the task ran.
+//   - Err: The error of the execution, nil if it succeeded or was skipped.
+//   - TraceID: The ID of the trace of the execution, if it was traced (see SetTracer).
+//   - RunID: The ID of the run of the execution (see RunReport), "" for executions outside of
+//     a run, e.g. resume probes.
+type TaskResult struct {
+	Game      string
+	Account   string
+	Task      string
+	Scheduled bool
+	Skipped   bool
+	Started   time.Time
+	Duration  time.Duration
+	Attempts  int
+	Err       error
+	TraceID   string
+	RunID     string
+}
+
+// newTaskResult returns the result of an execution of task for account, before it runs.
+func newTaskResult(game string, account types.Account, task tasks.Task) TaskResult {
+	_, scheduled := task.(tasks.Scheduled)
+	return TaskResult{Game: game, Account: account.TelegramData.TelegramId, Task: taskName(task), Scheduled: scheduled}
+}
+
+// AddResultListener adds a function called with the result of every task execution of the
+// handler, including skipped ones.
+//
+// Listeners are called synchronously from the goroutine of the execution, so they must return
+// quickly; slow consumers should hand the results over to a channel.
+//
+// # Example:
+//
+//	handler.AddResultListener(func(result handler.TaskResult) {
+//		if result.Err != nil {
+//			failures.Add(1)
+//		}
+//	})
+func (handler *GameHandler) AddResultListener(listener func(result TaskResult)) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.listeners = append(handler.listeners, listener)
+}
+
+// emitResult delivers a result to the listeners of the handler.
+func (handler *GameHandler) emitResult(result TaskResult) {
+	handler.mu.Lock()
+	listeners := handler.listeners
+	handler.mu.Unlock()
+	for _, listener := range listeners {
+		listener(result)
+	}
+}
+
+// TrackProgress reports the one-time task executions of a batch run on reporter.
+//
+// The executions of every one-time task on every enabled account are added to the total of
+// the reporter, and each of their results is recorded as it arrives. It is meant to be called
+// once before a RunTasks call; call reporter.Finish once the run returns.
+//
+// # Parameters:
+//   - reporter: The progress reporter, see progress.New.
+//
+// # Example:
+//
+//	reporter := progress.New(os.Stderr, "claiming welcome bonus", 0)
+//	gameHandler.TrackProgress(reporter)
+//	gameHandler.RunTasks()
+//	reporter.Finish()
+func (handler *GameHandler) TrackProgress(reporter *progress.Reporter) {
+	handler.mu.Lock()
+	oneTime := 0
+	for _, task := range handler.Tasks {
+		if _, scheduled := task.(tasks.Scheduled); !scheduled {
+			oneTime++
+		}
+	}
+	accounts := 0
+	for _, account := range handler.Accounts {
+		if account.IsEnabled() {
+			accounts++
+		}
+	}
+	handler.mu.Unlock()
+	reporter.AddTotal(oneTime * accounts)
+	handler.AddResultListener(func(result TaskResult) {
+		switch {
+		case result.Scheduled:
+		case result.Skipped:
+			reporter.Add(progress.Skipped)
+		case result.Err != nil:
+			reporter.Add(progress.Failed)
+		default:
+			reporter.Add(progress.Completed)
+		}
+	})
+}
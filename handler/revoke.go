@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/history"
+	"github.com/nexus-telegram/NexusSDK/httpclient"
+	"github.com/nexus-telegram/NexusSDK/jobqueue"
+	"github.com/nexus-telegram/NexusSDK/secrets"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+)
+
+// revokeSession asks the Nexus API to log out a Telegram session, through the proxy the
+// session is used with.
+func revokeSession(ctx context.Context, client *httpclient.HTTPClient, nexusApiBaseURL string, game string, apiKey string, telegram types.TelegramData, proxyConfig types.Proxy) error {
+	url := fmt.Sprintf("%s/telegram/logout", nexusApiBaseURL)
+	jsonData, err := json.Marshal(GameDataRequest{
+		Game:     game,
+		Telegram: telegram,
+		APIKey:   apiKey,
+		Proxy:    proxyConfig,
+	})
+	if err != nil {
+		return err
+	}
+	defer secrets.Wipe(jsonData)
+	resp, err := client.PostContext(ctx, url, jsonData)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// RevokeSession logs out the Telegram session of an account through the Nexus API, so that a
+// burned or sold account cannot be used with the session anymore. The account is left in the
+// handler; see DecommissionAccounts to remove it as well.
+//
+// # Parameters:
+//   - ctx: The context of the request.
+//   - account: The account whose session is revoked.
+//
+// # Returns:
+//   - error: An error if the account has no Telegram session, no API key is usable for it, or
+//     the Nexus API refuses the logout.
+func (handler *GameHandler) RevokeSession(ctx context.Context, account types.Account) error {
+	telegram, err := handler.telegramData(account)
+	if err != nil {
+		return err
+	}
+	if telegram.TdataStringSession == "" {
+		return fmt.Errorf("account %s has no Telegram session", account.TelegramData.TelegramId)
+	}
+	client, proxy, err := handler.clientFor(account)
+	if err != nil {
+		return err
+	}
+	key := handler.defaultAPIKey()
+	if keys := handler.apiKeyPool(); keys != nil {
+		candidates, err := keys.Candidates(handler.GameName, account.TelegramData.TelegramId)
+		if err != nil {
+			return err
+		}
+		key = candidates[0]
+	}
+	value, err := key.Secret()
+	if err != nil {
+		return err
+	}
+	if err := revokeSession(ctx, client, handler.nexusAPIURL(), handler.GameName, value, telegram, proxy); err != nil {
+		return fmt.Errorf("failed to revoke the Telegram session of %s: %w", account.TelegramData.TelegramId, err)
+	}
+	utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, account).Info("Telegram session revoked")
+	return nil
+}
+
+// DecommissionAccounts retires accounts for good: their Telegram sessions are revoked (see
+// RevokeSession), then they are removed from the handler and everything the handler keeps
+// about them is purged:
+//   - their scheduled jobs, deleted from the job queue (see jobqueue.Remover), or cancelled
+//     when the queue cannot delete jobs;
+//   - their task history, when the history store can delete it (see history.Purger);
+//   - their profiles, cooldowns, activity, failure streaks, recorded balances, encrypted
+//     sessions, and proxy assignments;
+//   - their game data in the saved state, which is saved again when the handler has a
+//     storage (see SaveState).
+//
+// Executions of the accounts already running finish normally. The accounts file is not
+// changed: remove the returned accounts from it (see SaveAccounts), as "nexusctl
+// revoke-sessions" does.
+//
+// # Parameters:
+//   - ctx: The context of the revocations and storage operations.
+//   - telegramIds: The Telegram IDs of the accounts.
+//   - revoke: Whether the sessions are revoked first. An account whose session cannot be
+//     revoked is kept, so that the revocation can be retried.
+//
+// # Returns:
+//   - []types.Account: The accounts removed from the handler.
+//   - error: The errors of the accounts that are unknown or could not be revoked, and of the
+//     purge steps that failed, joined. The returned accounts are removed even when the error
+//     is not nil.
+//
+// # Example:
+//
+//	removed, err := gameHandler.DecommissionAccounts(ctx, []string{"987654321"}, true)
+//	if err != nil {
+//		log.Printf("Some accounts could not be decommissioned: %v", err)
+//	}
+//
+// # Notes:
+//   - Idempotency records are keyed by a hash and expire on their own; they are not purged.
+func (handler *GameHandler) DecommissionAccounts(ctx context.Context, telegramIds []string, revoke bool) ([]types.Account, error) {
+	handler.mu.Lock()
+	byId := make(map[string]types.Account, len(handler.Accounts))
+	for _, account := range handler.Accounts {
+		byId[account.TelegramData.TelegramId] = account
+	}
+	handler.mu.Unlock()
+
+	var errs []error
+	var removed []types.Account
+	retired := make(map[string]bool, len(telegramIds))
+	for _, telegramId := range telegramIds {
+		account, ok := byId[telegramId]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("account %s not found in %s", telegramId, handler.GameName))
+			continue
+		case retired[telegramId]:
+			continue
+		}
+		if revoke {
+			if err := handler.RevokeSession(ctx, account); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		retired[telegramId] = true
+		removed = append(removed, account)
+	}
+	if len(removed) == 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	handler.mu.Lock()
+	accounts := make([]types.Account, 0, len(handler.Accounts))
+	for _, account := range handler.Accounts {
+		if !retired[account.TelegramData.TelegramId] {
+			accounts = append(accounts, account)
+		}
+	}
+	handler.Accounts = accounts
+	for telegramId := range retired {
+		delete(handler.cooldowns, telegramId)
+		delete(handler.activity, telegramId)
+		delete(handler.balances, telegramId)
+		delete(handler.sessions, telegramId)
+		handler.forgetStreaks(telegramId)
+	}
+	pool, store := handler.ProxyPool, handler.history
+	handler.mu.Unlock()
+	for telegramId := range retired {
+		handler.profiles.Delete(telegramId)
+		handler.accountLocks.Delete(telegramId)
+		handler.profileLocks.Delete(telegramId)
+		if pool != nil {
+			pool.Release(telegramId)
+		}
+	}
+
+	queue := handler.jobQueue()
+	jobs, err := queue.List(ctx, handler.GameName)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to list jobs: %w", err))
+	}
+	remover, canRemove := queue.(jobqueue.Remover)
+	for _, job := range jobs {
+		if !retired[job.Account] {
+			continue
+		}
+		if canRemove {
+			err = remover.Remove(ctx, job.ID)
+		} else {
+			err = queue.Cancel(ctx, job.ID)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove job %s: %w", job.ID, err))
+		}
+	}
+	if purger, ok := store.(history.Purger); ok {
+		for telegramId := range retired {
+			if err := purger.Purge(ctx, handler.GameName, telegramId); err != nil {
+				errs = append(errs, fmt.Errorf("failed to purge the history of %s: %w", telegramId, err))
+			}
+		}
+	}
+	if handler.storageBackend() != nil {
+		if err := handler.SaveState(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save state: %w", err))
+		}
+	}
+	for _, account := range removed {
+		utils.WithAccount(utils.ModuleLogger("handler"), handler.GameName, account).Info("Account decommissioned", zap.Bool("revoked", revoke))
+	}
+	return removed, errors.Join(errs...)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// RotationStrategy is the order in which the accounts of a handler are served in each
+// scheduling cycle.
+type RotationStrategy string
+
+const (
+	RotationFixed       RotationStrategy = "fixed"        // The order of the accounts file
+	RotationShuffle     RotationStrategy = "shuffle"      // A new random order every cycle
+	RotationLastSuccess RotationStrategy = "last_success" // Accounts whose last success is the oldest first
+	RotationLeastRecent RotationStrategy = "least_recent" // Accounts whose last execution is the oldest first
+)
+
+// ParseRotationStrategy returns the rotation strategy named name. An empty name is RotationFixed.
+func ParseRotationStrategy(name string) (RotationStrategy, error) {
+	switch strategy := RotationStrategy(name); strategy {
+	case "":
+		return RotationFixed, nil
+	case RotationFixed, RotationShuffle, RotationLastSuccess, RotationLeastRecent:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown rotation strategy: %s", name)
+	}
+}
+
+// accountActivity is the last task execution of an account and its consecutive failures.
+type accountActivity struct {
+	lastRun     time.Time
+	lastSuccess time.Time
+	lastTask    string
+	lastError   string
+	streak      int
+}
+
+// SetRotationStrategy sets the order in which accounts start their one-time tasks and in which
+// the scheduled tasks that are due at the same time are started.
+//
+// Serving accounts in the same order every cycle creates synchronized request patterns across
+// a farm and always delays the same accounts; the other strategies spread the load.
+//
+// # Parameters:
+//   - strategy: The rotation strategy. Defaults to RotationFixed.
+//
+// # Example:
+//
+//	handler.SetRotationStrategy(handler.RotationLeastRecent)
+func (handler *GameHandler) SetRotationStrategy(strategy RotationStrategy) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.rotation = strategy
+}
+
+// recordActivity records an execution of a task of an account.
+func (handler *GameHandler) recordActivity(telegramId, task string, started time.Time, err error) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.activity == nil {
+		handler.activity = make(map[string]accountActivity)
+	}
+	activity := handler.activity[telegramId]
+	activity.lastRun = started
+	activity.lastTask = task
+	if err == nil {
+		activity.lastSuccess = started
+		activity.lastError = ""
+		activity.streak = 0
+	} else {
+		activity.lastError = err.Error()
+		activity.streak++
+	}
+	handler.activity[telegramId] = activity
+}
+
+// rotateAccounts returns accounts in the order of the handler's rotation strategy.
+func (handler *GameHandler) rotateAccounts(accounts []types.Account) []types.Account {
+	ids := make([]string, len(accounts))
+	for i, account := range accounts {
+		ids[i] = account.TelegramData.TelegramId
+	}
+	rotated := make([]types.Account, 0, len(accounts))
+	for _, i := range handler.rotate(ids) {
+		rotated = append(rotated, accounts[i])
+	}
+	return rotated
+}
+
+// rotate returns the order in which the accounts with the given Telegram IDs are served, as
+// indexes into ids.
+func (handler *GameHandler) rotate(ids []string) []int {
+	order := make([]int, len(ids))
+	for i := range order {
+		order[i] = i
+	}
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	switch handler.rotation {
+	case RotationShuffle:
+		rand.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	case RotationLastSuccess:
+		sort.SliceStable(order, func(i, j int) bool {
+			return handler.activity[ids[order[i]]].lastSuccess.Before(handler.activity[ids[order[j]]].lastSuccess)
+		})
+	case RotationLeastRecent:
+		sort.SliceStable(order, func(i, j int) bool {
+			return handler.activity[ids[order[i]]].lastRun.Before(handler.activity[ids[order[j]]].lastRun)
+		})
+	}
+	return order
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/tasks"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"sort"
+	"time"
+)
+
+// maxPreviewRuns bounds the number of previewed executions of one task for one account.
+const maxPreviewRuns = 10000
+
+// ScheduledRun is an upcoming task execution computed by Schedule.
+type ScheduledRun struct {
+	Account string    `json:"account"`
+	Task    string    `json:"task"`
+	Time    time.Time `json:"time"`
+}
+
+// Schedule returns the executions the scheduler would start within horizon from now.
+//
+// It lets operators sanity-check interval, daily reset, and window configurations before
+// letting them run. The preview is computed as if RunTasks started now: one-time tasks run
+// immediately and scheduled tasks at the times their Next method returns.
+//
+// # Parameters:
+//   - horizon: How far ahead executions are computed (e.g., 24 * time.Hour).
+//
+// # Returns:
+//   - []ScheduledRun: The executions sorted by time.
+//
+// # Notes:
+//   - Tasks with randomized schedules, such as DailyTask windows, show one possible draw.
+//   - Disabled accounts are left out.
+//
+// # Example:
+//
+//	for _, run := range handler.Schedule(24 * time.Hour) {
+//		fmt.Printf("%s %s %s\n", run.Time.Format(time.RFC3339), run.Account, run.Task)
+//	}
+func (handler *GameHandler) Schedule(horizon time.Duration) []ScheduledRun {
+	handler.mu.Lock()
+	taskList := append([]tasks.Task(nil), handler.Tasks...)
+	handler.mu.Unlock()
+	return PreviewSchedule(handler.Accounts, taskList, handler.getClock().Now(), horizon)
+}
+
+// PreviewSchedule computes the executions of taskList for accounts within horizon from now.
+// See GameHandler.Schedule.
+func PreviewSchedule(accounts []types.Account, taskList []tasks.Task, now time.Time, horizon time.Duration) []ScheduledRun {
+	end := now.Add(horizon)
+	var runs []ScheduledRun
+	for _, account := range accounts {
+		if !account.IsEnabled() {
+			continue
+		}
+		for _, task := range taskList {
+			run := ScheduledRun{Account: account.TelegramData.TelegramId, Task: taskName(task)}
+			schedule, ok := task.(tasks.Scheduled)
+			if !ok {
+				run.Time = now
+				runs = append(runs, run)
+				continue
+			}
+			var last time.Time
+			current := now
+			for i := 0; i < maxPreviewRuns; i++ {
+				next := schedule.Next(last, current)
+				if next.After(end) || (!last.IsZero() && !next.After(last)) {
+					break
+				}
+				if next.Before(current) {
+					next = current
+				}
+				run.Time = next
+				runs = append(runs, run)
+				last, current = next, next
+			}
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].Time.Before(runs[j].Time)
+	})
+	return runs
+}
+
+// taskName returns the name of a task, or its type when it has none.
+func taskName(task tasks.Task) string {
+	if named, ok := task.(interface{ GetName() string }); ok {
+		return named.GetName()
+	}
+	return fmt.Sprintf("%T", task)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"github.com/nexus-telegram/NexusSDK/secrets"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"sync"
+)
+
+var (
+	secretsMu      sync.RWMutex
+	secretResolver = secrets.DefaultResolver()
+)
+
+// SetSecretResolver configures the resolver used by LoadConfig, LoadConfigProfile, and
+// LoadAccounts to replace secret references with their values.
+//
+// The resolver defaults to secrets.DefaultResolver, which supports "env:" references and,
+// when configured through the environment, "vault:" and "awssm:" references.
+//
+// # Parameters:
+//   - resolver: The secret resolver, or nil to keep secret references as plain values.
+//
+// # Example:
+//
+//	resolver := secrets.NewResolver()
+//	resolver.Register("vault", &secrets.Vault{Address: "https://vault.internal:8200", Token: token})
+//	handler.SetSecretResolver(resolver)
+//	config, err := handler.LoadConfig("config.json") // "api_key": "vault:secret/data/nexus#api_key"
+func SetSecretResolver(resolver *secrets.Resolver) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secretResolver = resolver
+}
+
+func currentSecretResolver() *secrets.Resolver {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	return secretResolver
+}
+
+// resolveConfigSecrets resolves the API keys, the proxy credentials, the proxy provider key, the
+// Telegram log bot token, and the election password.
+func resolveConfigSecrets(config *types.Config) error {
+	resolver := currentSecretResolver()
+	if resolver == nil {
+		return nil
+	}
+	values := []*string{
+		&config.APIKey,
+		&config.Proxy.Username,
+		&config.Proxy.Password,
+		&config.ProxyPool.Provider.APIKey,
+		&config.Log.Telegram.BotToken,
+		&config.Election.Password,
+	}
+	for i := range config.APIKeys {
+		values = append(values, &config.APIKeys[i].Key)
+	}
+	return resolver.ResolveAll(context.Background(), values...)
+}
+
+// resolveAccountSecrets resolves the Telegram session and app hash of every account.
+func resolveAccountSecrets(accounts []types.Account) error {
+	resolver := currentSecretResolver()
+	if resolver == nil {
+		return nil
+	}
+	for i := range accounts {
+		err := resolver.ResolveAll(context.Background(),
+			&accounts[i].TelegramData.TdataStringSession,
+			&accounts[i].TelegramData.AppHash,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/idempotency"
+	"github.com/nexus-telegram/NexusSDK/jobqueue"
+	"go.uber.org/zap"
+	"io"
+	"path"
+	"sort"
+	"time"
+)
+
+// StateVersion is the version of the state archives written by Manager.ExportState.
+const StateVersion = 1
+
+// State is the runtime state of a handler that is not part of its configuration and accounts
+// files, exported to migrate a running farm to another machine.
+//
+// # Fields:
+//   - Game: The name of the game.
+//   - Accounts: The game data (session token) and scheduling status of every account.
+//   - Profiles: The game-specific profiles of the accounts, keyed by Telegram ID.
+//   - Cooldowns: The accounts in cooldown after a ban signal.
+//   - Jobs: The scheduled jobs with their due and last run times, without their lease.
+//   - Idempotency: The day of every successful non-idempotent task execution, keyed by
+//     idempotency key. Empty when the guard cannot list its entries (see idempotency.Lister).
+type State struct {
+	Game        string                     `json:"game"`
+	Accounts    []AccountState             `json:"accounts"`
+	Profiles    map[string]json.RawMessage `json:"profiles,omitempty"`
+	Cooldowns   []Cooldown                 `json:"cooldowns,omitempty"`
+	Jobs        []jobqueue.Job             `json:"jobs,omitempty"`
+	Idempotency map[string]string          `json:"idempotency,omitempty"`
+}
+
+// AccountState is the state of one account in a State.
+//
+// # Fields:
+//   - TelegramId: The Telegram ID of the account.
+//   - GameData: The game data of the account, including its session token.
+//   - Enabled: Whether the account is scheduled; nil means enabled.
+type AccountState struct {
+	TelegramId string `json:"telegram_id"`
+	GameData   string `json:"game_data"`
+	Enabled    *bool  `json:"enabled,omitempty"`
+}
+
+// stateManifest is the first entry of a state archive.
+type stateManifest struct {
+	Version  int       `json:"version"`
+	Exported time.Time `json:"exported"`
+	Games    []string  `json:"games"`
+}
+
+// ExportState returns a snapshot of the runtime state of the handler.
+//
+// Profiles are encoded as JSON, so their types must be JSON-serializable. Profiles that are
+// not are left out of the snapshot.
+//
+// # Returns:
+//   - *State: The state of the handler.
+//   - error: An error if the job queue cannot be read.
+func (handler *GameHandler) ExportState() (*State, error) {
+	jobs, err := handler.Jobs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	state := &State{Game: handler.GameName, Profiles: make(map[string]json.RawMessage), Cooldowns: handler.Cooldowns()}
+	for _, job := range jobs {
+		job.LeaseOwner, job.LeaseUntil = "", time.Time{}
+		state.Jobs = append(state.Jobs, job)
+	}
+	if lister, ok := handler.idempotencyGuard().(idempotency.Lister); ok {
+		state.Idempotency = lister.Entries()
+	}
+	handler.mu.Lock()
+	for _, account := range handler.Accounts {
+		state.Accounts = append(state.Accounts, AccountState{
+			TelegramId: account.TelegramData.TelegramId,
+			GameData:   account.GameData,
+			Enabled:    account.Enabled,
+		})
+	}
+	handler.mu.Unlock()
+	handler.profiles.Range(func(key, value interface{}) bool {
+		profile, err := json.Marshal(value)
+		if err != nil {
+			handler.GetLogger().Warn("Skipping profile that cannot be exported", zap.String("account", key.(string)), zap.Error(err))
+			return true
+		}
+		state.Profiles[key.(string)] = profile
+		return true
+	})
+	return state, nil
+}
+
+// ImportState restores a state exported by ExportState on another machine. It must be called
+// before the handler runs its tasks.
+//
+// The state of accounts that are not in the handler's accounts is ignored, and jobs that
+// already exist in the handler's queue keep their due time.
+//
+// # Parameters:
+//   - state: The state of the handler.
+//
+// # Returns:
+//   - error: An error if the state belongs to another game, or if a job or idempotency entry
+//     cannot be stored.
+func (handler *GameHandler) ImportState(state *State) error {
+	if state.Game != handler.GameName {
+		return fmt.Errorf("state of %s cannot be imported into %s", state.Game, handler.GameName)
+	}
+	accounts := make(map[string]AccountState, len(state.Accounts))
+	for _, account := range state.Accounts {
+		accounts[account.TelegramId] = account
+	}
+	known := make(map[string]bool, len(handler.Accounts))
+	handler.mu.Lock()
+	for i := range handler.Accounts {
+		account := &handler.Accounts[i]
+		known[account.TelegramData.TelegramId] = true
+		if saved, ok := accounts[account.TelegramData.TelegramId]; ok {
+			account.GameData, account.Enabled = saved.GameData, saved.Enabled
+		}
+	}
+	for _, cooldown := range state.Cooldowns {
+		if known[cooldown.Account] {
+			if handler.cooldowns == nil {
+				handler.cooldowns = make(map[string]Cooldown)
+			}
+			handler.cooldowns[cooldown.Account] = cooldown
+		}
+	}
+	handler.mu.Unlock()
+	for telegramId, profile := range state.Profiles {
+		if known[telegramId] {
+			handler.StoreProfile(telegramId, profile)
+		}
+	}
+	queue := handler.jobQueue()
+	for _, job := range state.Jobs {
+		if !known[job.Account] {
+			continue
+		}
+		job.LeaseOwner, job.LeaseUntil = "", time.Time{}
+		if _, err := queue.Add(context.Background(), job); err != nil {
+			return fmt.Errorf("failed to restore job %s: %w", job.ID, err)
+		}
+	}
+	guard := handler.idempotencyGuard()
+	for key, day := range state.Idempotency {
+		recorded, err := time.Parse(idempotency.DayFormat, day)
+		if err != nil {
+			continue
+		}
+		if err := guard.Record(key, recorded); err != nil {
+			return fmt.Errorf("failed to restore idempotency entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// ExportState writes the runtime state of every managed handler to w as a gzip-compressed tar
+// archive, with one JSON file per game. The archive contains session tokens and must be
+// stored as securely as the accounts file.
+//
+// Drain the handlers first for a consistent snapshot; the state of executions running
+// during the export may be missing.
+//
+// # Parameters:
+//   - w: The destination of the archive.
+//
+// # Returns:
+//   - error: An error if a handler state cannot be exported or the archive cannot be written.
+//
+// # Example:
+//
+//	manager.Drain()
+//	file, err := os.Create("farm-state.tar.gz")
+//	if err != nil {
+//		log.Fatalf("Failed to create state archive: %v", err)
+//	}
+//	defer file.Close()
+//	if err := manager.ExportState(file); err != nil {
+//		log.Fatalf("Failed to export farm state: %v", err)
+//	}
+func (manager *Manager) ExportState(w io.Writer) error {
+	handlers := manager.Handlers()
+	manifest := stateManifest{Version: StateVersion, Exported: time.Now().UTC()}
+	states := make([]*State, 0, len(handlers))
+	for _, gameHandler := range handlers {
+		state, err := gameHandler.ExportState()
+		if err != nil {
+			return fmt.Errorf("failed to export state of %s: %w", gameHandler.GameName, err)
+		}
+		states = append(states, state)
+		manifest.Games = append(manifest.Games, gameHandler.GameName)
+	}
+	compressed := gzip.NewWriter(w)
+	archive := tar.NewWriter(compressed)
+	if err := writeStateEntry(archive, "manifest.json", manifest, manifest.Exported); err != nil {
+		return err
+	}
+	for _, state := range states {
+		if err := writeStateEntry(archive, path.Join("games", state.Game+".json"), state, manifest.Exported); err != nil {
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
+
+// writeStateEntry adds value encoded as JSON to archive under name.
+func writeStateEntry(archive *tar.Writer, name string, value interface{}, modified time.Time) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: modified}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = archive.Write(data)
+	return err
+}
+
+// ImportState restores an archive written by ExportState into the managed handlers of the
+// same games. It must be called before the handlers run their tasks.
+//
+// # Parameters:
+//   - r: The archive.
+//
+// # Returns:
+//   - error: An error if the archive is invalid or from a newer version, if a game of the
+//     archive has no managed handler, or if a handler state cannot be imported.
+//
+// # Example:
+//
+//	file, err := os.Open("farm-state.tar.gz")
+//	if err != nil {
+//		log.Fatalf("Failed to open state archive: %v", err)
+//	}
+//	defer file.Close()
+//	if err := manager.ImportState(file); err != nil {
+//		log.Fatalf("Failed to import farm state: %v", err)
+//	}
+//	manager.RunTasks()
+func (manager *Manager) ImportState(r io.Reader) error {
+	compressed, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("invalid state archive: %w", err)
+	}
+	handlers := make(map[string]*GameHandler)
+	for _, gameHandler := range manager.Handlers() {
+		handlers[gameHandler.GameName] = gameHandler
+	}
+	archive := tar.NewReader(compressed)
+	var manifest *stateManifest
+	var states []*State
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid state archive: %w", err)
+		}
+		decoder := json.NewDecoder(archive)
+		switch {
+		case header.Name == "manifest.json":
+			manifest = &stateManifest{}
+			if err := decoder.Decode(manifest); err != nil {
+				return fmt.Errorf("invalid state manifest: %w", err)
+			}
+			if manifest.Version > StateVersion {
+				return fmt.Errorf("unsupported state archive version %d", manifest.Version)
+			}
+		case path.Dir(header.Name) == "games":
+			state := &State{}
+			if err := decoder.Decode(state); err != nil {
+				return fmt.Errorf("invalid state of %s: %w", header.Name, err)
+			}
+			if handlers[state.Game] == nil {
+				return fmt.Errorf("no handler for game %s of the state archive", state.Game)
+			}
+			states = append(states, state)
+		}
+	}
+	if manifest == nil {
+		return errors.New("invalid state archive: missing manifest")
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Game < states[j].Game })
+	for _, state := range states {
+		if err := handlers[state.Game].ImportState(state); err != nil {
+			return fmt.Errorf("failed to import state of %s: %w", state.Game, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/storage"
+)
+
+// SetStorage sets the backend storing the state of the handler (see SaveState and LoadState).
+//
+// Handlers created by NewGameHandler with a storage section in their configuration use the
+// configured backend, which also stores their scheduled jobs, idempotency records, and task
+// history unless these have their own section.
+//
+// # Parameters:
+//   - backend: The storage, e.g. storage.OpenFile or a backend opened with storage.FromConfig.
+func (handler *GameHandler) SetStorage(backend storage.Storage) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.backend = backend
+}
+
+// storageBackend returns the storage of the handler, nil if none is set.
+func (handler *GameHandler) storageBackend() storage.Storage {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return handler.backend
+}
+
+// SaveState stores the state of the handler (see ExportState), including the session tokens
+// of its accounts, in storage.BucketState under the name of the game.
+//
+// # Parameters:
+//   - ctx: The context of the storage operation.
+//
+// # Returns:
+//   - error: An error if no storage is set or the state cannot be exported or stored.
+//
+// # Example:
+//
+//	gameHandler.Drain()
+//	if err := gameHandler.SaveState(context.Background()); err != nil {
+//		log.Printf("Failed to save state: %v", err)
+//	}
+func (handler *GameHandler) SaveState(ctx context.Context) error {
+	backend := handler.storageBackend()
+	if backend == nil {
+		return errors.New("no storage configured")
+	}
+	state, err := handler.ExportState()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return backend.Put(ctx, storage.BucketState, handler.GameName, data)
+}
+
+// LoadState restores the state saved by SaveState (see ImportState). It must be called before
+// the handler runs its tasks.
+//
+// # Parameters:
+//   - ctx: The context of the storage operation.
+//
+// # Returns:
+//   - bool: Whether a saved state was found.
+//   - error: An error if no storage is set or the state cannot be read or imported.
+func (handler *GameHandler) LoadState(ctx context.Context) (bool, error) {
+	backend := handler.storageBackend()
+	if backend == nil {
+		return false, errors.New("no storage configured")
+	}
+	data, err := backend.Get(ctx, storage.BucketState, handler.GameName)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return false, fmt.Errorf("failed to parse saved state of %s: %w", handler.GameName, err)
+	}
+	return true, handler.ImportState(&state)
+}
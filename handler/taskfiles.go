@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/remote"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"go.uber.org/zap"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// LoadTaskDir loads the tasks of a game from a tasks.d directory, so that the tasks of many
+// games do not have to share one monolithic tasks.json file.
+//
+// The *.json files of the directory are merged in lexical order, then those of its
+// subdirectory named after the game, if any, which override the shared tasks (see
+// types.TaskCollection.Merge). Every file is loaded like LoadTasks, includes included, and the
+// templates are applied once all files are merged.
+//
+// # Parameters:
+//   - dir: The tasks directory (e.g., "tasks.d").
+//   - game: The name of the game, selecting its override subdirectory. Empty loads the shared
+//     files only.
+//
+// # Returns:
+//   - types.TaskCollection: The merged tasks.
+//   - error: An error if a file cannot be loaded or a task extends an unknown template.
+//
+// # Example layout:
+//
+//	tasks.d/
+//		00-templates.json   # Templates shared by every game
+//		10-daily.json       # Tasks common to every game
+//		hamster/
+//			quests.json     # Tasks of the hamster game
+//			overrides.json  # {"remove": ["Daily spin"], "one_time_tasks": [...]}
+//
+// # Example Usage:
+//
+//	tasks, err := handler.LoadTaskDir("tasks.d", gameHandler.GameName)
+//	if err != nil {
+//		log.Fatalf("Failed to load tasks: %v", err)
+//	}
+func LoadTaskDir(dir, game string) (types.TaskCollection, error) {
+	tasks := types.TaskCollection{Version: types.TasksVersion}
+	dirs := []string{dir}
+	if game != "" {
+		dirs = append(dirs, filepath.Join(dir, game))
+	}
+	for _, current := range dirs {
+		files, err := filepath.Glob(filepath.Join(current, "*.json"))
+		if err != nil {
+			return tasks, err
+		}
+		for _, file := range files {
+			loaded, err := loadTaskFile(file, nil)
+			if err != nil {
+				return tasks, err
+			}
+			tasks.Merge(loaded)
+		}
+	}
+	return tasks, tasks.ResolveTemplates()
+}
+
+// loadTaskFile reads a task file and merges the files it includes before its own tasks. The
+// removals of the file are kept, to apply to the files merged before it. chain holds the files
+// being loaded, to detect include cycles. Templates are not applied.
+func loadTaskFile(filePath string, chain []string) (types.TaskCollection, error) {
+	for _, pending := range chain {
+		if pending == filePath {
+			return types.TaskCollection{}, fmt.Errorf("task files include each other in a cycle: %s -> %s", strings.Join(chain, " -> "), filePath)
+		}
+	}
+	own, err := readTaskFile(filePath)
+	if err != nil || len(own.Include) == 0 {
+		return own, err
+	}
+	tasks := types.TaskCollection{Version: own.Version}
+	for _, pattern := range own.Include {
+		locations, err := includeLocations(filePath, pattern)
+		if err != nil {
+			return tasks, err
+		}
+		for _, location := range locations {
+			included, err := loadTaskFile(location, append(chain, filePath))
+			if err != nil {
+				return tasks, err
+			}
+			tasks.Merge(included)
+		}
+	}
+	tasks.Merge(own)
+	tasks.Remove = own.Remove
+	return tasks, nil
+}
+
+// readTaskFile reads and migrates a single task file.
+func readTaskFile(filePath string) (types.TaskCollection, error) {
+	var tasks types.TaskCollection
+	file, err := openLocation(filePath)
+	if err != nil {
+		return tasks, err
+	}
+	defer func(file io.ReadCloser) {
+		err := file.Close()
+		if err != nil {
+
+		}
+	}(file)
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return tasks, err
+	}
+	data, migrations, err := MigrateTasks(data)
+	if err != nil {
+		return tasks, fmt.Errorf("%s: %w", filePath, err)
+	}
+	for _, migration := range migrations {
+		utils.ModuleLogger("handler").Warn("Migrated tasks file, run nexusctl migrate-tasks to upgrade it",
+			zap.String("file", filePath), zap.Int("from_version", migration.From), zap.String("change", migration.Description))
+	}
+	if err := json.Unmarshal(data, &tasks); err != nil {
+		return tasks, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return tasks, nil
+}
+
+// includeLocations returns the files an include pattern of the task file at base refers to.
+// Local patterns are glob patterns relative to the directory of base; remote task files include
+// single locations relative to their URL.
+func includeLocations(base, pattern string) ([]string, error) {
+	if remote.IsRemote(base) || remote.IsRemote(pattern) {
+		baseURL, err := url.Parse(base)
+		if err != nil {
+			return nil, err
+		}
+		reference, err := url.Parse(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return []string{baseURL.ResolveReference(reference).String()}, nil
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(base), pattern)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid include %q: %w", base, pattern, err)
+	}
+	if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+		return nil, fmt.Errorf("%s: included file %s does not exist", base, pattern)
+	}
+	return matches, nil
+}
@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"github.com/nexus-telegram/NexusSDK/utils/clock"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// Throttle adapts the task dispatch of a handler to the backpressure reported by the HTTP layer.
+//
+// Every request sent by the handler is counted in windows of Window. When at least MinSamples
+// requests were sent in a window and the share of them that were rate limited (HTTP 429) or
+// failed at the proxy reaches Threshold, the throttle doubles the factor by which the intervals
+// of scheduled tasks are widened, up to MaxFactor, and halves the number of task executions
+// allowed to run at once. Each calm window then shrinks the factor and allows one more execution,
+// until the configured rates are restored.
+//
+// Executions waiting for one of the MaxConcurrency slots are served round-robin across
+// accounts, so an account with many due tasks cannot starve the accounts with few.
+//
+// # Fields:
+//   - Window: The length of the windows over which requests are counted.
+//   - Threshold: The share (0 < Threshold <= 1) of throttled requests considered as backpressure.
+//   - MinSamples: The number of requests a window needs before it can trigger throttling.
+//   - MaxFactor: The maximum factor applied to the intervals of scheduled tasks.
+//   - MaxConcurrency: The number of task executions allowed to run at once without backpressure.
+//     Zero means unlimited.
+//   - Clock: The clock of the windows. Defaults to clock.Real.
+//
+// # Example:
+//
+//	throttle := handler.NewThrottle(types.ThrottleConfig{Enabled: true, MaxConcurrency: 20})
+//	gameHandler.SetThrottle(throttle)
+type Throttle struct {
+	Window         time.Duration
+	Threshold      float64
+	MinSamples     int
+	MaxFactor      float64
+	MaxConcurrency int
+	Clock          clock.Clock
+	mu             sync.Mutex
+	waiting        fairQueue
+	windowStart    time.Time
+	samples        int
+	throttled      int
+	factor         float64
+	limit          int
+	ceiling        int
+	running        int
+}
+
+// ThrottleState is a snapshot of the adjustments currently applied by a Throttle.
+//
+// # Fields:
+//   - Factor: The factor applied to the intervals of scheduled tasks, 1 without backpressure.
+//   - ConcurrencyLimit: The number of task executions allowed to run at once, 0 for unlimited.
+//   - Running: The number of task executions currently running.
+//   - Waiting: The number of task executions waiting for a slot.
+type ThrottleState struct {
+	Factor           float64 `json:"factor"`
+	ConcurrencyLimit int     `json:"concurrency_limit"`
+	Running          int     `json:"running"`
+	Waiting          int     `json:"waiting"`
+}
+
+// NewThrottle creates a throttle from the adaptive_throttle section of the configuration file,
+// or returns nil when adaptive throttling is disabled.
+func NewThrottle(config types.ThrottleConfig) *Throttle {
+	if !config.Enabled {
+		return nil
+	}
+	throttle := &Throttle{
+		Window:         time.Duration(config.WindowSeconds) * time.Second,
+		Threshold:      config.Threshold,
+		MinSamples:     config.MinSamples,
+		MaxFactor:      config.MaxFactor,
+		MaxConcurrency: config.MaxConcurrency,
+	}
+	if throttle.Window <= 0 {
+		throttle.Window = time.Minute
+	}
+	if throttle.Threshold <= 0 || throttle.Threshold > 1 {
+		throttle.Threshold = 0.1
+	}
+	if throttle.MinSamples <= 0 {
+		throttle.MinSamples = 10
+	}
+	if throttle.MaxFactor < 1 {
+		throttle.MaxFactor = 8
+	}
+	return throttle
+}
+
+// State returns the adjustments currently applied by the throttle.
+func (throttle *Throttle) State() ThrottleState {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	throttle.roll(throttle.clock().Now())
+	return throttle.state()
+}
+
+// state returns the adjustments of the throttle. It must be called with mu held.
+func (throttle *Throttle) state() ThrottleState {
+	return ThrottleState{Factor: throttle.factor, ConcurrencyLimit: throttle.limit, Running: throttle.running, Waiting: throttle.waiting.len()}
+}
+
+// observe counts a request and its outcome. It returns the new state and true when the window
+// of the request closed and changed the adjustments.
+func (throttle *Throttle) observe(err error) (ThrottleState, bool) {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	changed := throttle.roll(throttle.clock().Now())
+	throttle.samples++
+	if pressured(err) {
+		throttle.throttled++
+	}
+	return throttle.state(), changed
+}
+
+// pressured reports whether a request error shows that the game or the proxies are saturated.
+// Requests refused by the handler's own Budget are not backpressure.
+func pressured(err error) bool {
+	if err == nil || errors.Is(err, ErrBudgetExhausted) {
+		return false
+	}
+	category := ClassifyError(err)
+	return category == ErrorRateLimit || category == ErrorProxy
+}
+
+// roll closes the current window when it is over and adjusts the factor and the concurrency
+// limit from its requests. Windows without any request count as calm. It must be called with mu
+// held and reports whether the adjustments changed.
+func (throttle *Throttle) roll(now time.Time) bool {
+	if throttle.factor < 1 {
+		throttle.factor = 1
+		throttle.limit = throttle.MaxConcurrency
+	}
+	if throttle.windowStart.IsZero() {
+		throttle.windowStart = now
+		return false
+	}
+	if now.Sub(throttle.windowStart) < throttle.Window {
+		return false
+	}
+	factor, limit := throttle.factor, throttle.limit
+	if throttle.samples >= throttle.MinSamples && float64(throttle.throttled) >= throttle.Threshold*float64(throttle.samples) {
+		throttle.tighten()
+	} else if float64(throttle.throttled) < throttle.Threshold*float64(throttle.samples)/2 || throttle.samples == 0 {
+		throttle.relax()
+	}
+	throttle.windowStart = now
+	throttle.samples = 0
+	throttle.throttled = 0
+	return factor != throttle.factor || limit != throttle.limit
+}
+
+// tighten widens the intervals and halves the concurrency limit. It must be called with mu held.
+func (throttle *Throttle) tighten() {
+	throttle.factor *= 2
+	if throttle.factor > throttle.MaxFactor {
+		throttle.factor = throttle.MaxFactor
+	}
+	if throttle.ceiling == 0 {
+		throttle.ceiling = throttle.limit
+		if throttle.ceiling == 0 {
+			throttle.ceiling = max(throttle.running, 1)
+		}
+		throttle.limit = throttle.ceiling
+	}
+	throttle.limit = max(throttle.limit/2, 1)
+}
+
+// relax shrinks the factor and allows one more concurrent execution, restoring the configured
+// limit once the ceiling recorded by tighten is reached. It must be called with mu held.
+func (throttle *Throttle) relax() {
+	throttle.factor /= 1.5
+	if throttle.factor < 1.05 {
+		throttle.factor = 1
+	}
+	if throttle.ceiling == 0 {
+		return
+	}
+	throttle.limit++
+	if throttle.limit >= throttle.ceiling && throttle.factor == 1 {
+		throttle.limit = throttle.MaxConcurrency
+		throttle.ceiling = 0
+	} else if throttle.limit > throttle.ceiling {
+		throttle.limit = throttle.ceiling
+	}
+	throttle.dispatch()
+}
+
+// acquire waits until one more task execution of account is allowed to run and returns the
+// function releasing it. Waiting executions are served round-robin across accounts.
+func (throttle *Throttle) acquire(account string) func() {
+	throttle.mu.Lock()
+	throttle.roll(throttle.clock().Now())
+	var granted chan struct{}
+	if throttle.waiting.len() == 0 && throttle.free() {
+		throttle.running++
+	} else {
+		granted = throttle.waiting.push(account)
+	}
+	throttle.mu.Unlock()
+	if granted != nil {
+		<-granted
+	}
+	return func() {
+		throttle.mu.Lock()
+		defer throttle.mu.Unlock()
+		throttle.running--
+		throttle.dispatch()
+	}
+}
+
+// setMaxConcurrency sets MaxConcurrency and returns its previous value. A concurrency lowered
+// by backpressure stays at most the new value and is restored up to it.
+func (throttle *Throttle) setMaxConcurrency(concurrency int) int {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	throttle.roll(throttle.clock().Now())
+	previous := throttle.MaxConcurrency
+	throttle.MaxConcurrency = concurrency
+	if throttle.ceiling == 0 {
+		throttle.limit = concurrency
+	} else if concurrency > 0 {
+		throttle.ceiling = concurrency
+		throttle.limit = min(throttle.limit, concurrency)
+	}
+	throttle.dispatch()
+	return previous
+}
+
+// maxConcurrency returns MaxConcurrency.
+func (throttle *Throttle) maxConcurrency() int {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	return throttle.MaxConcurrency
+}
+
+// free reports whether one more execution is allowed to run. It must be called with mu held.
+func (throttle *Throttle) free() bool {
+	return throttle.limit <= 0 || throttle.running < throttle.limit
+}
+
+// dispatch grants the free slots to the waiting executions. It must be called with mu held.
+func (throttle *Throttle) dispatch() {
+	for throttle.free() {
+		granted := throttle.waiting.pop()
+		if granted == nil {
+			return
+		}
+		throttle.running++
+		close(granted)
+	}
+}
+
+// widen stretches the delay between now and the next run of a scheduled task by the current
+// factor.
+func (throttle *Throttle) widen(now, next time.Time) time.Time {
+	if next.IsZero() || !next.After(now) {
+		return next
+	}
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	throttle.roll(now)
+	return now.Add(time.Duration(float64(next.Sub(now)) * throttle.factor))
+}
+
+func (throttle *Throttle) clock() clock.Clock {
+	if throttle.Clock == nil {
+		return clock.Real
+	}
+	return throttle.Clock
+}
+
+// SetThrottle enables adaptive throttling of the handler's task dispatch. Passing nil disables it.
+//
+// # Parameters:
+//   - throttle: The throttle, see NewThrottle.
+func (handler *GameHandler) SetThrottle(throttle *Throttle) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.throttle = throttle
+}
+
+// getThrottle returns the throttle of the handler, or nil if none is set.
+func (handler *GameHandler) getThrottle() *Throttle {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return handler.throttle
+}
+
+// observeBackpressure feeds the outcome of a request to the handler's throttle and logs the
+// adjustments it makes.
+func (handler *GameHandler) observeBackpressure(err error) {
+	throttle := handler.getThrottle()
+	if throttle == nil {
+		return
+	}
+	state, changed := throttle.observe(err)
+	if !changed {
+		return
+	}
+	log := utils.ModuleLogger("handler").With(zap.String("game", handler.GameName))
+	if state.Factor == 1 && state.ConcurrencyLimit == throttle.maxConcurrency() {
+		log.Info("Backpressure cleared, task dispatch restored")
+		return
+	}
+	log.Warn("Adjusting task dispatch to backpressure",
+		zap.Float64("interval_factor", state.Factor), zap.Int("concurrency_limit", state.ConcurrencyLimit))
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"github.com/nexus-telegram/NexusSDK/types"
+)
+
+// Tracer starts the spans of the task executions of a handler, so that they can be traced by
+// a tracing library such as OpenTelemetry, which the SDK does not depend on.
+//
+// The context returned by StartTask is the context of the requests sent by the execution, so
+// their spans, if any, are children of the span of the execution.
+//
+// # Example:
+//
+//	type otelTracer struct{ tracer trace.Tracer }
+//
+//	func (t otelTracer) StartTask(ctx context.Context, game, account, task string) (context.Context, handler.Span) {
+//		ctx, span := t.tracer.Start(ctx, task, trace.WithAttributes(
+//			attribute.String("game", game), attribute.String("account", account)))
+//		return ctx, otelSpan{span}
+//	}
+type Tracer interface {
+	StartTask(ctx context.Context, game, account, task string) (context.Context, Span)
+}
+
+// Span is the span of a task execution started by a Tracer.
+//
+// # Methods:
+//   - TraceID() string: Returns the ID of the trace of the span, or "" when the span is not
+//     recorded, e.g. not sampled.
+//   - End(err error): Ends the span, with the error of the execution, nil on success.
+type Span interface {
+	TraceID() string
+	End(err error)
+}
+
+// SetTracer sets the tracer of the task executions of the handler. Passing nil stops tracing.
+//
+// When the span of an execution has a trace ID, the ID is:
+//   - attached as an exemplar, labelled trace_id, to the task metrics of the execution
+//     (nexus_task_executions_total and the bucket of nexus_task_execution_seconds its
+//     duration falls in), so that Grafana links a latency spike to the traces of the
+//     executions behind it (see metrics.Registry.WriteOpenMetrics);
+//   - set as the TraceID of the result of the execution (see AddResultListener).
+//
+// Skipped executions are not traced.
+func (handler *GameHandler) SetTracer(tracer Tracer) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.tracer = tracer
+}
+
+// noSpan is the span of the executions of the handlers without a tracer.
+type noSpan struct{}
+
+func (noSpan) TraceID() string { return "" }
+func (noSpan) End(error)       {}
+
+// startSpan starts the span of a task execution with the tracer of the handler, if any.
+func (handler *GameHandler) startSpan(ctx context.Context, account types.Account, task string) (context.Context, Span) {
+	handler.mu.Lock()
+	tracer := handler.tracer
+	handler.mu.Unlock()
+	if tracer == nil {
+		return ctx, noSpan{}
+	}
+	return tracer.StartTask(ctx, handler.GameName, account.TelegramData.TelegramId, task)
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/secrets"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// taskVariable matches the variable references of task files, e.g. "${env:CLAIM_CODE}", and the
+// escaped "$${" standing for a literal "${".
+var taskVariable = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z0-9_]+):([^}]*)\}`)
+
+// LoadTasksWithConfig reads the tasks.json file like LoadTasks and replaces the variable
+// references of the task payloads and headers, and of the URLs, payloads, and headers of
+// pipeline steps, so that secrets and deployment-specific values are not hardcoded into task
+// files.
+//
+// # References:
+//   - ${config:<key>}: The value of a config.json setting, by JSON name, with nested settings
+//     separated by dots (e.g., "${config:api_key}", "${config:proxy_pool.country_mode}").
+//   - ${env:<name>}: The value of an environment variable.
+//   - ${<scheme>:<reference>}: A secret of the secret resolver (see SetSecretResolver), e.g.
+//     "${vault:secret/data/nexus#claim_code}".
+//
+// A reference may end with ":-<default>", used when the value is missing or empty. A string
+// made of a single config reference takes the type of the setting (number, boolean, or object);
+// other references are replaced by text. "$${" stands for a literal "${".
+//
+// # Parameters:
+//   - filePath: The path to the tasks.json file, or a remote location (see SetRemoteOptions).
+//   - config: The configuration the config references read, e.g. the result of LoadConfig.
+//     Config references fail when nil.
+//
+// # Returns:
+//   - types.TaskCollection: The tasks with their references replaced.
+//   - error: An error if the tasks cannot be loaded or a reference cannot be resolved.
+//
+// # Example tasks.json:
+//
+//	{
+//		"one_time_tasks": [
+//			{
+//				"name": "Redeem code",
+//				"payload": {"code": "${env:PROMO_CODE}", "limit": "${config:max_claims:-3}"},
+//				"headers": {"X-Api-Key": "${config:api_key}"}
+//			}
+//		]
+//	}
+func LoadTasksWithConfig(filePath string, config *types.Config) (types.TaskCollection, error) {
+	tasks, err := LoadTasks(filePath)
+	if err != nil {
+		return tasks, err
+	}
+	variables := &taskVariables{config: config}
+	for i := range tasks.OneTimeTasks {
+		task := &tasks.OneTimeTasks[i]
+		if err := variables.substituteTask(task.Payload, task.Headers); err != nil {
+			return tasks, fmt.Errorf("task %s: %w", task.Name, err)
+		}
+		if err := variables.substituteSteps(task.Steps); err != nil {
+			return tasks, fmt.Errorf("task %s: %w", task.Name, err)
+		}
+	}
+	for i := range tasks.RecurrentTasks {
+		task := &tasks.RecurrentTasks[i]
+		if err := variables.substituteTask(task.Payload, task.Headers); err != nil {
+			return tasks, fmt.Errorf("task %s: %w", task.Name, err)
+		}
+		if err := variables.substituteSteps(task.Steps); err != nil {
+			return tasks, fmt.Errorf("task %s: %w", task.Name, err)
+		}
+	}
+	return tasks, nil
+}
+
+// taskVariables resolves the variable references of task files.
+//
+// # Fields:
+//   - config: The configuration read by config references.
+//   - settings: The JSON encoding of config, decoded on first use.
+type taskVariables struct {
+	config   *types.Config
+	settings map[string]interface{}
+}
+
+// substituteTask replaces the references of a task's payload and headers in place.
+func (variables *taskVariables) substituteTask(payload map[string]interface{}, headers map[string]string) error {
+	for key, value := range payload {
+		substituted, err := variables.substitute(value)
+		if err != nil {
+			return err
+		}
+		payload[key] = substituted
+	}
+	for key, value := range headers {
+		substituted, err := variables.substituteString(value)
+		if err != nil {
+			return err
+		}
+		headers[key] = fmt.Sprint(substituted)
+	}
+	return nil
+}
+
+// substituteSteps replaces the references of the URLs, payloads, and headers of pipeline steps
+// in place.
+func (variables *taskVariables) substituteSteps(steps []types.TaskStepConfig) error {
+	for i := range steps {
+		step := &steps[i]
+		url, err := variables.substituteString(step.URL)
+		if err != nil {
+			return err
+		}
+		step.URL = fmt.Sprint(url)
+		if err := variables.substituteTask(step.Payload, step.Headers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// substitute returns value with its references replaced, walking nested objects and arrays.
+func (variables *taskVariables) substitute(value interface{}) (interface{}, error) {
+	switch typed := value.(type) {
+	case string:
+		return variables.substituteString(typed)
+	case map[string]interface{}:
+		for key, nested := range typed {
+			substituted, err := variables.substitute(nested)
+			if err != nil {
+				return nil, err
+			}
+			typed[key] = substituted
+		}
+	case []interface{}:
+		for i, nested := range typed {
+			substituted, err := variables.substitute(nested)
+			if err != nil {
+				return nil, err
+			}
+			typed[i] = substituted
+		}
+	}
+	return value, nil
+}
+
+// substituteString replaces the references of a string. A string made of a single config
+// reference is replaced by the setting itself.
+func (variables *taskVariables) substituteString(value string) (interface{}, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+	if match := taskVariable.FindStringSubmatchIndex(value); match != nil && match[0] == 0 && match[1] == len(value) && match[2] >= 0 &&
+		value[match[2]:match[3]] == "config" {
+		return variables.resolve("config", value[match[4]:match[5]])
+	}
+	var resolveErr error
+	substituted := taskVariable.ReplaceAllStringFunc(value, func(reference string) string {
+		if reference == "$${" || resolveErr != nil {
+			return "${"
+		}
+		parts := taskVariable.FindStringSubmatch(reference)
+		resolved, err := variables.resolve(parts[1], parts[2])
+		if err != nil {
+			resolveErr = err
+			return ""
+		}
+		if text, ok := resolved.(string); ok {
+			return text
+		}
+		encoded, _ := json.Marshal(resolved)
+		return string(encoded)
+	})
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+	return substituted, nil
+}
+
+// resolve returns the value a reference of scheme points to, or its default when the value is
+// missing or empty.
+func (variables *taskVariables) resolve(scheme, reference string) (interface{}, error) {
+	name, fallback, hasDefault := strings.Cut(reference, ":-")
+	var value interface{}
+	var err error
+	if scheme == "config" {
+		value, err = variables.setting(name)
+	} else {
+		value, err = resolveSecretVariable(scheme, name)
+	}
+	if hasDefault && (err != nil || value == "") {
+		return fallback, nil
+	}
+	return value, err
+}
+
+// setting returns the config.json setting at a dotted path of JSON names.
+func (variables *taskVariables) setting(path string) (interface{}, error) {
+	if variables.config == nil {
+		return nil, fmt.Errorf("config reference '%s' requires a configuration", path)
+	}
+	if variables.settings == nil {
+		data, err := json.Marshal(variables.config)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &variables.settings); err != nil {
+			return nil, err
+		}
+	}
+	var value interface{} = variables.settings
+	for _, segment := range strings.Split(path, ".") {
+		switch typed := value.(type) {
+		case map[string]interface{}:
+			nested, ok := typed[segment]
+			if !ok {
+				return nil, fmt.Errorf("unknown config setting '%s'", path)
+			}
+			value = nested
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(typed) {
+				return nil, fmt.Errorf("unknown config setting '%s'", path)
+			}
+			value = typed[index]
+		default:
+			return nil, fmt.Errorf("unknown config setting '%s'", path)
+		}
+	}
+	if value == nil {
+		return nil, fmt.Errorf("config setting '%s' is not set", path)
+	}
+	return value, nil
+}
+
+// resolveSecretVariable resolves a reference through the secret resolver. Environment
+// references are resolved even without a resolver.
+func resolveSecretVariable(scheme, reference string) (string, error) {
+	resolver := currentSecretResolver()
+	if (resolver == nil || !resolver.Supports(scheme)) && scheme == "env" {
+		return secrets.Env{}.Resolve(context.Background(), reference)
+	}
+	if resolver == nil || !resolver.Supports(scheme) {
+		return "", fmt.Errorf("unknown variable scheme '%s'", scheme)
+	}
+	return resolver.Resolve(context.Background(), scheme+":"+reference)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"github.com/nexus-telegram/NexusSDK/types"
+	"time"
+)
+
+// WarmUpPolicy eases newly added accounts into farming.
+//
+// Fresh accounts that farm at full speed right away are banned quickly, so during the first
+// Days after their CreatedAt date, accounts only run the tasks listed in Tasks, and the
+// intervals of their scheduled tasks are multiplied by IntervalFactor.
+//
+// # Fields:
+//   - Days: The length of the warm-up period in days.
+//   - IntervalFactor: The factor applied to the intervals of scheduled tasks during warm-up.
+//   - Tasks: The names of the tasks run during warm-up. Empty means every task.
+//
+// # Notes:
+//   - Accounts without a CreatedAt date are considered established and never warm up.
+//
+// # Example:
+//
+//	handler.SetWarmUpPolicy(&handler.WarmUpPolicy{Days: 3, IntervalFactor: 4, Tasks: []string{"daily-checkin"}})
+type WarmUpPolicy struct {
+	Days           int
+	IntervalFactor float64
+	Tasks          []string
+}
+
+// NewWarmUpPolicy creates a warm-up policy from the warm_up section of the configuration file,
+// or returns nil when no warm-up period is configured.
+func NewWarmUpPolicy(config types.WarmUpConfig) *WarmUpPolicy {
+	if config.Days <= 0 {
+		return nil
+	}
+	policy := &WarmUpPolicy{
+		Days:           config.Days,
+		IntervalFactor: config.IntervalFactor,
+		Tasks:          config.Tasks,
+	}
+	if policy.IntervalFactor < 1 {
+		policy.IntervalFactor = 3
+	}
+	return policy
+}
+
+// WarmingUp reports whether account is still in its warm-up period at now.
+func (policy *WarmUpPolicy) WarmingUp(account types.Account, now time.Time) bool {
+	if policy == nil || account.CreatedAt.IsZero() {
+		return false
+	}
+	return now.Before(account.CreatedAt.AddDate(0, 0, policy.Days))
+}
+
+// Allows reports whether the task named task runs during warm-up.
+func (policy *WarmUpPolicy) Allows(task string) bool {
+	if len(policy.Tasks) == 0 {
+		return true
+	}
+	for _, name := range policy.Tasks {
+		if name == task {
+			return true
+		}
+	}
+	return false
+}
+
+// widen stretches the interval between last and the next run of a scheduled task by
+// IntervalFactor.
+func (policy *WarmUpPolicy) widen(last, next time.Time) time.Time {
+	if last.IsZero() || next.IsZero() || !next.After(last) {
+		return next
+	}
+	return last.Add(time.Duration(float64(next.Sub(last)) * policy.IntervalFactor))
+}
+
+// SetWarmUpPolicy sets the warm-up policy of the handler's newly added accounts. Passing nil
+// disables warm-up.
+//
+// # Parameters:
+//   - policy: The warm-up policy, see NewWarmUpPolicy.
+func (handler *GameHandler) SetWarmUpPolicy(policy *WarmUpPolicy) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.warmUp = policy
+}
+
+// warmUpPolicy returns the warm-up policy of the handler, or nil if none is set.
+func (handler *GameHandler) warmUpPolicy() *WarmUpPolicy {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return handler.warmUp
+}
@@ -0,0 +1,284 @@
+// Package history stores the recent task executions of a farm, for the CLI and dashboards.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLimit is how many executions are kept per game, account, and task.
+const DefaultLimit = 100
+
+// Execution is a finished task execution, including its retries.
+//
+// # Fields:
+//   - Game: The name of the game.
+//   - Account: The Telegram ID of the account.
+//   - Task: The name of the task.
+//   - Started: When the execution started.
+//   - Duration: How long the execution took, retries included.
+//   - Attempts: How many times the task ran.
+//   - Success: Whether the last attempt succeeded.
+//   - Error: The error of the last attempt, if it failed.
+//   - RunID: The ID of the run the execution belongs to (see handler.RunReport), if any.
+type Execution struct {
+	Game     string        `json:"game"`
+	Account  string        `json:"account"`
+	Task     string        `json:"task"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Attempts int           `json:"attempts"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
+	RunID    string        `json:"run_id,omitempty"`
+}
+
+// Store records task executions and returns the most recent ones.
+//
+// # Methods:
+//   - Append(ctx context.Context, execution Execution) error: Records an execution.
+//   - List(ctx context.Context, game, account, task string, limit int) ([]Execution, error):
+//     Returns at most limit executions of game, most recent first. An empty account or task
+//     matches every account or task.
+type Store interface {
+	Append(ctx context.Context, execution Execution) error
+	List(ctx context.Context, game, account, task string, limit int) ([]Execution, error)
+}
+
+// Purger is implemented by the stores that can delete the executions of an account, so that
+// nothing of a decommissioned account is kept (see handler.GameHandler.DecommissionAccounts).
+//
+// # Methods:
+//   - Purge(ctx context.Context, game, account string) error: Deletes the executions of the
+//     account in game.
+type Purger interface {
+	Purge(ctx context.Context, game, account string) error
+}
+
+// key returns the series an execution belongs to.
+func key(game, account, task string) string {
+	return game + "/" + account + "/" + task
+}
+
+// matches reports whether execution belongs to game and, when set, to account and task.
+func matches(execution Execution, game, account, task string) bool {
+	return execution.Game == game && (account == "" || execution.Account == account) && (task == "" || execution.Task == task)
+}
+
+// OfRun returns the executions of a run (see Execution.RunID), keeping at most limit of them.
+// List the executions without a limit before filtering them, so that the executions of an
+// older run are not cut off by those of later runs.
+//
+// # Example:
+//
+//	executions, err := store.List(ctx, "Blum", "", "", 0)
+//	if err != nil {
+//		return err
+//	}
+//	executions = history.OfRun(executions, report.RunID, 50)
+func OfRun(executions []Execution, runID string, limit int) []Execution {
+	var run []Execution
+	for _, execution := range executions {
+		if execution.RunID == runID {
+			run = append(run, execution)
+		}
+	}
+	if limit > 0 && len(run) > limit {
+		run = run[:limit]
+	}
+	return run
+}
+
+// newest sorts executions most recent first and keeps at most limit of them.
+func newest(executions []Execution, limit int) []Execution {
+	sort.SliceStable(executions, func(i, j int) bool { return executions[i].Started.After(executions[j].Started) })
+	if limit > 0 && len(executions) > limit {
+		executions = executions[:limit]
+	}
+	return executions
+}
+
+// Memory is a Store keeping the last Limit executions of every game, account, and task in
+// memory. When opened with OpenFile, executions are also appended to a JSON lines file and
+// loaded back on start.
+//
+// # Example:
+//
+//	store, err := history.OpenFile("state/history.jsonl", history.DefaultLimit)
+//	if err != nil {
+//		log.Fatalf("Failed to open task history: %v", err)
+//	}
+//	handler.SetHistoryStore(store)
+type Memory struct {
+	Limit  int
+	series map[string][]Execution
+	file   *os.File
+	mu     sync.Mutex
+}
+
+// NewMemory returns an empty in-memory store keeping limit executions per series.
+func NewMemory(limit int) *Memory {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return &Memory{Limit: limit, series: make(map[string][]Execution)}
+}
+
+// OpenFile opens (or creates) the history file at path and loads its executions.
+//
+// # Parameters:
+//   - path: The path of the JSON lines file. Parent directories are created if needed.
+//   - limit: How many executions are kept in memory per game, account, and task.
+//
+// # Returns:
+//   - *Memory: The store.
+//   - error: An error if the file cannot be read or opened.
+func OpenFile(path string, limit int) (*Memory, error) {
+	store := NewMemory(limit)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var execution Execution
+		if json.Unmarshal(scanner.Bytes(), &execution) == nil {
+			store.add(execution)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to read task history %s: %w", path, err)
+	}
+	store.file = file
+	return store, nil
+}
+
+// add appends an execution to its series, dropping the oldest beyond Limit. It must be called
+// with mu held or before the store is shared.
+func (store *Memory) add(execution Execution) {
+	series := key(execution.Game, execution.Account, execution.Task)
+	executions := append(store.series[series], execution)
+	if len(executions) > store.Limit {
+		executions = executions[len(executions)-store.Limit:]
+	}
+	store.series[series] = executions
+}
+
+// Append records an execution, writing it to the file if the store has one.
+func (store *Memory) Append(ctx context.Context, execution Execution) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.add(execution)
+	if store.file == nil {
+		return nil
+	}
+	line, err := json.Marshal(execution)
+	if err != nil {
+		return err
+	}
+	_, err = store.file.Write(append(line, '\n'))
+	return err
+}
+
+// List returns at most limit executions of game, most recent first.
+func (store *Memory) List(ctx context.Context, game, account, task string, limit int) ([]Execution, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	var executions []Execution
+	for _, series := range store.series {
+		for _, execution := range series {
+			if matches(execution, game, account, task) {
+				executions = append(executions, execution)
+			}
+		}
+	}
+	return newest(executions, limit), nil
+}
+
+// Purge deletes the executions of the account in game. The history file, if any, is rewritten
+// with the executions kept in memory.
+func (store *Memory) Purge(ctx context.Context, game, account string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for series, executions := range store.series {
+		if len(executions) > 0 && matches(executions[0], game, account, "") {
+			delete(store.series, series)
+		}
+	}
+	if store.file == nil {
+		return nil
+	}
+	var executions []Execution
+	for _, series := range store.series {
+		executions = append(executions, series...)
+	}
+	sort.SliceStable(executions, func(i, j int) bool { return executions[i].Started.Before(executions[j].Started) })
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	for _, execution := range executions {
+		if err := encoder.Encode(execution); err != nil {
+			return err
+		}
+	}
+	if err := store.file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := store.file.Write(buffer.Bytes())
+	return err
+}
+
+// Close closes the history file, if any.
+func (store *Memory) Close() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.file == nil {
+		return nil
+	}
+	return store.file.Close()
+}
+
+// FromConfig creates the store described by a history configuration.
+//
+// # Parameters:
+//   - config: The "history" section of config.json.
+//
+// # Returns:
+//   - Store: The store, or nil when no backend is configured.
+//   - error: An error if the backend is unknown or cannot be opened.
+func FromConfig(config types.HistoryConfig) (Store, error) {
+	switch config.Backend {
+	case "":
+		return nil, nil
+	case "memory":
+		return NewMemory(config.Limit), nil
+	case "file":
+		if config.Path == "" {
+			return nil, fmt.Errorf("history backend 'file' requires a path")
+		}
+		return OpenFile(config.Path, config.Limit)
+	case "redis":
+		if config.Addr == "" {
+			return nil, fmt.Errorf("history backend 'redis' requires an address")
+		}
+		prefix := config.Key
+		if prefix == "" {
+			prefix = "nexus:history"
+		}
+		return NewRedis(config.Addr, config.Password, prefix, config.Limit), nil
+	default:
+		return nil, fmt.Errorf("unknown history backend: %s", config.Backend)
+	}
+}
@@ -0,0 +1,123 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/internal/resp"
+	"strconv"
+)
+
+// Redis is a Store keeping the executions of every game, account, and task in a Redis list
+// "<Prefix>:<game>/<account>/<task>", trimmed to Limit entries.
+//
+// # Fields:
+//   - Addr: The Redis address (e.g., "10.0.0.5:6379").
+//   - Password: The optional Redis password.
+//   - Prefix: The prefix of the list keys.
+//   - Limit: How many executions are kept per list. Defaults to DefaultLimit.
+type Redis struct {
+	Addr     string
+	Password string
+	Prefix   string
+	Limit    int
+}
+
+// NewRedis creates a Redis store.
+func NewRedis(addr, password, prefix string, limit int) *Redis {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return &Redis{Addr: addr, Password: password, Prefix: prefix, Limit: limit}
+}
+
+func (store *Redis) client() resp.Client {
+	return resp.Client{Addr: store.Addr, Password: store.Password}
+}
+
+// Append records an execution at the head of its list and trims the list.
+func (store *Redis) Append(ctx context.Context, execution Execution) error {
+	data, err := json.Marshal(execution)
+	if err != nil {
+		return err
+	}
+	listKey := store.Prefix + ":" + key(execution.Game, execution.Account, execution.Task)
+	if _, err := store.client().Command(ctx, "LPUSH", listKey, string(data)); err != nil {
+		return err
+	}
+	_, err = store.client().Command(ctx, "LTRIM", listKey, "0", strconv.Itoa(store.Limit-1))
+	return err
+}
+
+// List returns at most limit executions of game, most recent first.
+func (store *Redis) List(ctx context.Context, game, account, task string, limit int) ([]Execution, error) {
+	keys := []string{store.Prefix + ":" + key(game, account, task)}
+	if account == "" || task == "" {
+		var err error
+		if keys, err = store.scan(ctx, store.Prefix+":"+key(game, orAny(account), orAny(task))); err != nil {
+			return nil, err
+		}
+	}
+	stop := "-1"
+	if limit > 0 {
+		stop = strconv.Itoa(limit - 1)
+	}
+	var executions []Execution
+	for _, listKey := range keys {
+		values, err := store.client().Strings(ctx, "LRANGE", listKey, "0", stop)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			var execution Execution
+			if json.Unmarshal([]byte(value), &execution) == nil && matches(execution, game, account, task) {
+				executions = append(executions, execution)
+			}
+		}
+	}
+	return newest(executions, limit), nil
+}
+
+// Purge deletes the lists of the account in game.
+func (store *Redis) Purge(ctx context.Context, game, account string) error {
+	keys, err := store.scan(ctx, store.Prefix+":"+key(game, account, "*"))
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+	_, err = store.client().Command(ctx, append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+// scan returns the keys matching pattern.
+func (store *Redis) scan(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := store.client().Do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", "100")
+		if err != nil {
+			return nil, err
+		}
+		values, ok := reply.([]interface{})
+		if !ok || len(values) != 2 {
+			return nil, errors.New("unexpected redis SCAN reply")
+		}
+		cursor, _ = values[0].(string)
+		batch, _ := values[1].([]interface{})
+		for _, value := range batch {
+			if listKey, ok := value.(string); ok {
+				keys = append(keys, listKey)
+			}
+		}
+		if cursor == "0" {
+			return keys, nil
+		}
+	}
+}
+
+// orAny returns value, or the glob matching any value when it is empty.
+func orAny(value string) string {
+	if value == "" {
+		return "*"
+	}
+	return value
+}
@@ -0,0 +1,96 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/storage"
+	"sync"
+)
+
+// Stored is a Store keeping the last Limit executions of every game, account, and task in a
+// shared storage backend, one document per series in storage.BucketHistory.
+//
+// # Example:
+//
+//	backend, err := storage.FromConfig(config.Storage)
+//	if err != nil {
+//		log.Fatalf("Failed to open storage: %v", err)
+//	}
+//	handler.SetHistoryStore(history.NewStored(backend, history.DefaultLimit))
+type Stored struct {
+	Limit   int
+	backend storage.Storage
+	mu      sync.Mutex
+}
+
+// NewStored returns a store keeping limit executions per series in backend.
+func NewStored(backend storage.Storage, limit int) *Stored {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return &Stored{Limit: limit, backend: backend}
+}
+
+// Append records an execution, dropping the oldest of its series beyond Limit.
+func (store *Stored) Append(ctx context.Context, execution Execution) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	series := key(execution.Game, execution.Account, execution.Task)
+	var executions []Execution
+	data, err := store.backend.Get(ctx, storage.BucketHistory, series)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &executions); err != nil {
+			return err
+		}
+	case !errors.Is(err, storage.ErrNotFound):
+		return err
+	}
+	executions = append(executions, execution)
+	if len(executions) > store.Limit {
+		executions = executions[len(executions)-store.Limit:]
+	}
+	data, err = json.Marshal(executions)
+	if err != nil {
+		return err
+	}
+	return store.backend.Put(ctx, storage.BucketHistory, series, data)
+}
+
+// List returns at most limit executions of game, most recent first.
+func (store *Stored) List(ctx context.Context, game, account, task string, limit int) ([]Execution, error) {
+	documents, err := store.backend.List(ctx, storage.BucketHistory, game+"/")
+	if err != nil {
+		return nil, err
+	}
+	var executions []Execution
+	for _, data := range documents {
+		var series []Execution
+		if err := json.Unmarshal(data, &series); err != nil {
+			return nil, err
+		}
+		for _, execution := range series {
+			if matches(execution, game, account, task) {
+				executions = append(executions, execution)
+			}
+		}
+	}
+	return newest(executions, limit), nil
+}
+
+// Purge deletes the series of the account in game.
+func (store *Stored) Purge(ctx context.Context, game, account string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	documents, err := store.backend.List(ctx, storage.BucketHistory, key(game, account, ""))
+	if err != nil {
+		return err
+	}
+	for series := range documents {
+		if err := store.backend.Delete(ctx, storage.BucketHistory, series); err != nil {
+			return err
+		}
+	}
+	return nil
+}
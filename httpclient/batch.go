@@ -0,0 +1,100 @@
+package httpclient
+
+import (
+	"golang.org/x/net/context"
+	"io"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of requests of a batch sent at the same time when
+// no concurrency is given.
+const DefaultBatchConcurrency = 4
+
+// Request is one request of a batch.
+//
+// # Fields:
+//   - Method: The HTTP method (e.g., http.MethodGet).
+//   - URL: The URL of the request.
+//   - Body: The request body, nil for none.
+type Request struct {
+	Method string
+	URL    string
+	Body   []byte
+}
+
+// Result is the outcome of one request of a batch.
+//
+// # Fields:
+//   - Body: The response body, read completely.
+//   - Err: The error of the request, e.g. a *StatusError for a non-2xx response.
+type Result struct {
+	Body []byte
+	Err  error
+}
+
+// Batch sends a set of requests with bounded concurrency and returns their results in the
+// order of the requests.
+//
+// # Parameters:
+//   - requests: The requests to send.
+//   - concurrency: The maximum number of requests in flight, DefaultBatchConcurrency if not positive.
+//
+// # Returns:
+//   - []Result: The result of each request, at the index of the request.
+//
+// # Example:
+//
+//	requests := make([]httpclient.Request, 0, len(questIDs))
+//	for _, id := range questIDs {
+//		requests = append(requests, httpclient.Request{Method: http.MethodGet, URL: baseURL + "/quests/" + id})
+//	}
+//	for i, result := range httpClient.Batch(requests, 8) {
+//		if result.Err != nil {
+//			log.Printf("Quest %s failed: %v", questIDs[i], result.Err)
+//		}
+//	}
+func (httpClient *HTTPClient) Batch(requests []Request, concurrency int) []Result {
+	return httpClient.BatchContext(context.Background(), requests, concurrency)
+}
+
+// BatchContext behaves like Batch with every request bound to ctx. Requests not started when
+// ctx is done fail with the context error.
+func (httpClient *HTTPClient) BatchContext(ctx context.Context, requests []Request, concurrency int) []Result {
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	results := make([]Result, len(requests))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, request Request) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = httpClient.send(ctx, request)
+		}(i, request)
+	}
+	wg.Wait()
+	return results
+}
+
+// send sends one request of a batch and reads its body.
+func (httpClient *HTTPClient) send(ctx context.Context, request Request) Result {
+	resp, err := httpClient.DoRequestContext(ctx, request.Method, request.URL, request.Body)
+	if err != nil {
+		return Result{Err: err}
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+		}
+	}(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	return Result{Body: body, Err: err}
+}
@@ -5,12 +5,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/nexus-telegram/NexusSDK/audit"
+	"github.com/nexus-telegram/NexusSDK/codec"
 	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"github.com/nexus-telegram/NexusSDK/utils/retry"
+	"go.uber.org/zap"
 	"golang.org/x/net/context"
 	"golang.org/x/net/proxy"
 	"io"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,7 +29,17 @@ import (
 // # Fields:
 //   - client: A pointer to a `http.Client` instance used to perform HTTP requests.
 //   - proxy: A `types.Proxy` struct containing proxy configuration details.
-//   - headers: A `map[string]string` to store custom headers as key-value pairs.
+//   - headers: A `map[string]string` to store custom headers as key-value pairs. The map is
+//     replaced, never modified, by SetHeader and DeleteHeader.
+//   - headersMu: A mutex guarding headers.
+//   - auditLog: An optional audit log recording every request sent by the client.
+//   - retryPolicy: An optional policy retrying transient failures of every request.
+//   - limiter: An optional limiter throttling every request, see SetLimiter.
+//   - dialer: The dialer of the client's connections, see SetNetworkOptions.
+//   - rateLimit: An optional limit of the request rate to each host, see SetHostRateLimit.
+//   - contentTypes: The Content-Type and Accept headers of the requests without one, see
+//     SetContentTypes.
+//   - closed: Whether Close was called.
 //
 // # Example:
 //
@@ -53,9 +72,67 @@ import (
 //   - Returns an error if an invalid SOCKS type is specified.
 //   - Returns an error if a SOCKS dialer cannot be created (e.g., invalid proxy address or credentials).
 type HTTPClient struct {
-	client  *http.Client
-	proxy   types.Proxy
-	headers map[string]string
+	client         *http.Client
+	proxy          types.Proxy
+	headers        map[string]string
+	headersMu      sync.RWMutex
+	auditLog       audit.Log
+	retryPolicy    *retry.Policy
+	limiter        Limiter
+	observer       Observer
+	redirectPolicy RedirectPolicy
+	flights        *FlightGroup
+	dialer         *baseDialer
+	rateLimit      *HostRateLimit
+	contentTypes   ContentTypes
+	closed         atomic.Bool
+}
+
+// ErrClientClosed is returned for the requests sent through an HTTPClient after Close.
+var ErrClientClosed = errors.New("http client is closed")
+
+// accountContextKey is the context key under which the account Telegram ID is stored.
+type accountContextKey struct{}
+
+// ContextWithAccount returns a copy of ctx carrying the Telegram ID of the account a request is made for.
+//
+// The account is attached to audit entries and log fields of requests sent with the returned context.
+func ContextWithAccount(ctx context.Context, telegramId string) context.Context {
+	return context.WithValue(ctx, accountContextKey{}, telegramId)
+}
+
+// AccountFromContext returns the account Telegram ID stored in ctx by ContextWithAccount, if any.
+func AccountFromContext(ctx context.Context) string {
+	telegramId, _ := ctx.Value(accountContextKey{}).(string)
+	return telegramId
+}
+
+// headersContextKey is the context key under which per-request headers are stored.
+type headersContextKey struct{}
+
+// ContextWithHeaders returns a copy of ctx whose requests carry the given headers, on top of
+// (and overriding) the client's headers and the headers already stored in ctx. It is used for
+// per-account headers such as the User-Agent of the account's device profile, and for
+// per-call headers (see DoRequestWithHeaders).
+func ContextWithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	if len(headers) == 0 {
+		return ctx
+	}
+	parent := HeadersFromContext(ctx)
+	merged := make(map[string]string, len(parent)+len(headers))
+	for key, value := range parent {
+		merged[key] = value
+	}
+	for key, value := range headers {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, headersContextKey{}, merged)
+}
+
+// HeadersFromContext returns the headers stored in ctx by ContextWithHeaders, if any.
+func HeadersFromContext(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersContextKey{}).(map[string]string)
+	return headers
 }
 
 // NewHTTPClient initializes and returns a new HTTP client, optionally configured to use a SOCKS proxy.
@@ -102,12 +179,15 @@ type HTTPClient struct {
 //   - Ensure the proxy server is reachable and properly configured when using a proxy.
 //   - Timeout is set to 10 seconds by default but can be adjusted using the `Timeout` field in `proxyConfig`.
 //   - SOCKS4 proxies are not supported in this implementation.
+//   - When `BytesPerSecond` is set, every connection of the client is throttled by a shared
+//     bandwidth limit (see NewThrottle).
 //
 // # Errors:
 //   - Returns an error if an invalid SOCKS type is specified.
 //   - Returns an error if a SOCKS dialer cannot be created (e.g., invalid proxy address or credentials).
 func NewHTTPClient(proxyConfig types.Proxy) (*HTTPClient, error) {
 	var transport *http.Transport
+	baseDialer := newBaseDialer()
 	if proxyConfig.Ip != "" && proxyConfig.Port > 0 {
 		proxyAddress := fmt.Sprintf("%s:%d", proxyConfig.Ip, proxyConfig.Port)
 		var dialer proxy.Dialer
@@ -118,9 +198,9 @@ func NewHTTPClient(proxyConfig types.Proxy) (*HTTPClient, error) {
 					User:     proxyConfig.Username,
 					Password: proxyConfig.Password,
 				}
-				dialer, err = proxy.SOCKS5("tcp", proxyAddress, &auth, proxy.Direct)
+				dialer, err = proxy.SOCKS5("tcp", proxyAddress, &auth, baseDialer)
 			} else {
-				dialer, err = proxy.SOCKS5("tcp", proxyAddress, nil, proxy.Direct)
+				dialer, err = proxy.SOCKS5("tcp", proxyAddress, nil, baseDialer)
 			}
 		} else if proxyConfig.SocksType == 4 {
 			return nil, fmt.Errorf("SOCKS4 proxy is not supported in this implementation")
@@ -132,11 +212,14 @@ func NewHTTPClient(proxyConfig types.Proxy) (*HTTPClient, error) {
 		}
 		transport = &http.Transport{
 			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				return dialer.Dial(network, addr)
+				return baseDialer.throughProxy(ctx, dialer, network, addr)
 			},
 		}
 	} else {
-		transport = &http.Transport{}
+		transport = &http.Transport{DialContext: baseDialer.DialContext}
+	}
+	if proxyConfig.BytesPerSecond > 0 {
+		throttleTransport(transport, NewThrottle(proxyConfig.BytesPerSecond))
 	}
 	timeout := 10 * time.Second
 	if proxyConfig.Timeout > 0 {
@@ -147,25 +230,160 @@ func NewHTTPClient(proxyConfig types.Proxy) (*HTTPClient, error) {
 			Transport: transport,
 			Timeout:   timeout,
 		},
+		dialer:       baseDialer,
+		contentTypes: DefaultContentTypes,
 	}, nil
 }
 
 // TODO: Add random headers to the HTTP client
 
+// SetAuditLog enables the audit trail for the client.
+//
+// Every request sent afterwards is recorded with its method, URL, payload hash, account,
+// and response status. Passing nil disables auditing.
+//
+// # Parameters:
+//   - auditLog: The audit log receiving the entries.
+func (httpClient *HTTPClient) SetAuditLog(auditLog audit.Log) {
+	httpClient.auditLog = auditLog
+}
+
+// SetRetryPolicy enables retries of transient request failures.
+//
+// Requests failing with a network error, a 429 Too Many Requests, or a 5xx status are sent
+// again according to the policy. Passing nil disables retries.
+//
+// # Parameters:
+//   - policy: The retry policy, e.g. retry.DefaultPolicy().
+//
+// # Example:
+//
+//	policy := retry.DefaultPolicy()
+//	httpClient.SetRetryPolicy(&policy)
+func (httpClient *HTTPClient) SetRetryPolicy(policy *retry.Policy) {
+	httpClient.retryPolicy = policy
+}
+
+// CloseIdleConnections closes the idle connections of the client, e.g. once the proxy session
+// it was created for expired. Connections in use are left open.
+func (httpClient *HTTPClient) CloseIdleConnections() {
+	httpClient.client.CloseIdleConnections()
+}
+
+// Close closes the idle connections of the client and makes its further requests fail with
+// ErrClientClosed. Requests in flight complete normally. Closing a closed client does nothing.
+func (httpClient *HTTPClient) Close() error {
+	httpClient.closed.Store(true)
+	httpClient.client.CloseIdleConnections()
+	return nil
+}
+
 // DoRequest sends an HTTP request with the specified method, URL, body, and additional headers.
 func (httpClient *HTTPClient) DoRequest(method, url string, body []byte) (*http.Response, error) {
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+	return httpClient.DoRequestContext(context.Background(), method, url, body)
+}
+
+// DoRequestContext sends an HTTP request bound to ctx with the specified method, URL, and body.
+//
+// The request is cancelled when ctx is done. If ctx carries an account (see ContextWithAccount),
+// it is attached to the request's log fields and audit entry. If ctx was returned by
+// ContextWithGzip, the body is gzip-compressed. When a retry policy is set
+// (see SetRetryPolicy), transient failures are retried. When a flight group is set (see
+// SetFlightGroup), identical in-flight GET requests are sent once. Every request carries a
+// unique X-Request-Id header, unless one was set by the caller, which is also logged and
+// returned in errors (see RequestError).
+//
+// A non-2xx response is returned together with a *StatusError wrapped in a *RequestError, so
+// that its headers (e.g., Retry-After) and body can be inspected. Its body is already read and
+// can be read again; closing it is optional. Other errors return a nil response.
+func (httpClient *HTTPClient) DoRequestContext(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	if httpClient.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if httpClient.flights != nil && method == http.MethodGet && len(body) == 0 {
+		return httpClient.flights.do(ctx, flightKey(url, httpClient.defaultHeaders(), HeadersFromContext(ctx)), func(ctx context.Context) (*http.Response, error) {
+			return httpClient.doRequest(ctx, method, url, body)
+		})
+	}
+	return httpClient.doRequest(ctx, method, url, body)
+}
+
+// doRequest sends an HTTP request, retrying it according to the retry policy. See DoRequestContext.
+func (httpClient *HTTPClient) doRequest(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	if httpClient.retryPolicy == nil {
+		return httpClient.doRequestOnce(ctx, method, url, body)
+	}
+	var resp *http.Response
+	err := retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = httpClient.doRequestOnce(ctx, method, url, body)
+		return err
+	}, *httpClient.retryPolicy)
+	return resp, err
+}
+
+// doRequestOnce sends a single HTTP request bound to ctx. See DoRequestContext.
+func (httpClient *HTTPClient) doRequestOnce(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	sentBody := body
+	compressed := len(body) > 0 && gzipFromContext(ctx)
+	if compressed {
+		var err error
+		sentBody, err = gzipBody(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(sentBody))
 	if err != nil {
 		return nil, err
 	}
-	for key, value := range httpClient.headers {
+	for key, value := range httpClient.defaultHeaders() {
 		req.Header.Set(key, value)
 	}
+	for key, value := range HeadersFromContext(ctx) {
+		req.Header.Set(key, value)
+	}
+	httpClient.negotiateContent(req.Header, body)
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	requestId := req.Header.Get(RequestIDHeader)
+	if requestId == "" {
+		requestId = NewRequestID()
+		req.Header.Set(RequestIDHeader, requestId)
+	}
+	account := AccountFromContext(ctx)
+	log := utils.ModuleLogger("httpclient").With(zap.String("method", method), zap.String("url", url), zap.String("request_id", requestId))
+	if account != "" {
+		log = log.With(zap.String("account", account))
+	}
+	if err := httpClient.waitRateLimit(ctx, url); err != nil {
+		return nil, err
+	}
+	if httpClient.limiter != nil {
+		if err := httpClient.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	log.Debug("Sending request", zap.Int("body_size", len(body)), zap.Int("sent_size", len(sentBody)))
+	started := time.Now()
 	resp, err := httpClient.client.Do(req)
+	httpClient.audit(started, account, requestId, method, url, body, resp, err)
+	if httpClient.observer != nil {
+		httpClient.observer.ObserveRequest(method, url, time.Since(started), err)
+	}
 	if err != nil {
-		return nil, err
+		log.Debug("Request failed", zap.Duration("duration", time.Since(started)), zap.Error(err))
+		if httpClient.limiter != nil {
+			httpClient.limiter.Done(int64(len(sentBody)))
+		}
+		return nil, &RequestError{RequestID: requestId, Err: err}
 	}
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+	if httpClient.limiter != nil {
+		resp.Body = &countingBody{ReadCloser: resp.Body, limiter: httpClient.limiter, bytes: int64(len(sentBody))}
+	}
+	log.Debug("Received response", zap.Int("status", resp.StatusCode), zap.Duration("duration", time.Since(started)))
+	if (resp.StatusCode < 200 || resp.StatusCode >= 300) && !(httpClient.redirectPolicy.DontFollow && isRedirect(resp.StatusCode)) {
 		defer func(Body io.ReadCloser) {
 			err := Body.Close()
 			if err != nil {
@@ -173,11 +391,109 @@ func (httpClient *HTTPClient) DoRequest(method, url string, body []byte) (*http.
 			}
 		}(resp.Body)
 		responseBody, _ := io.ReadAll(resp.Body)
-		return nil, errors.New(string(responseBody))
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Header: resp.Header.Clone(), Body: string(responseBody)}
+		resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+		return resp, &RequestError{RequestID: requestId, Err: statusErr}
 	}
 	return resp, nil
 }
 
+// StatusError is the error returned for a non-2xx response. Its message is the response body.
+//
+// # Fields:
+//   - StatusCode: The HTTP status code of the response.
+//   - Header: The headers of the response.
+//   - Body: The response body.
+//
+// # Example:
+//
+//	var statusErr *httpclient.StatusError
+//	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized {
+//		// refresh the game data
+//	}
+//	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests {
+//		time.Sleep(statusErr.RetryAfter())
+//	}
+type StatusError struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+func (err *StatusError) Error() string {
+	return err.Body
+}
+
+// Temporary reports whether the status is worth retrying (429 or 5xx), see retry.IsRetryable.
+func (err *StatusError) Temporary() bool {
+	return err.StatusCode == http.StatusTooManyRequests || err.StatusCode >= 500
+}
+
+// RetryAfter returns the delay requested by the Retry-After header of the response, given in
+// seconds or as an HTTP date, or 0 when the header is missing or invalid.
+func (err *StatusError) RetryAfter() time.Duration {
+	value := strings.TrimSpace(err.Header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, parseErr := strconv.Atoi(value); parseErr == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, parseErr := http.ParseTime(value); parseErr == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// RateLimitReset returns when the rate limit of the response resets, from its X-RateLimit-Reset
+// header given as a Unix timestamp, a number of seconds from now, or an HTTP date, or the zero
+// time when the header is missing or invalid.
+func (err *StatusError) RateLimitReset() time.Time {
+	value := strings.TrimSpace(err.Header.Get("X-RateLimit-Reset"))
+	if value == "" {
+		return time.Time{}
+	}
+	if seconds, parseErr := strconv.ParseInt(value, 10, 64); parseErr == nil {
+		if seconds > 1_000_000_000 {
+			return time.Unix(seconds, 0)
+		}
+		return time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	if date, parseErr := http.ParseTime(value); parseErr == nil {
+		return date
+	}
+	return time.Time{}
+}
+
+// Decode decodes the JSON body of the response into v, e.g. the structured error of a game.
+func (err *StatusError) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(err.Body), v)
+}
+
+// audit records a request in the audit log, if one is configured.
+func (httpClient *HTTPClient) audit(started time.Time, account, requestId, method, url string, body []byte, resp *http.Response, err error) {
+	if httpClient.auditLog == nil {
+		return
+	}
+	entry := audit.Entry{
+		Time:        started,
+		Account:     account,
+		RequestID:   requestId,
+		Method:      method,
+		URL:         url,
+		PayloadHash: audit.HashPayload(body),
+	}
+	if resp != nil {
+		entry.Status = resp.StatusCode
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if appendErr := httpClient.auditLog.Append(entry); appendErr != nil {
+		utils.ModuleLogger("httpclient").Warn("Failed to append audit entry", zap.Error(appendErr))
+	}
+}
+
 // Get performs a GET request to the specified URL with optional headers.
 //
 // This method sends an HTTP GET request to the provided URL. Additional headers can be
@@ -187,12 +503,18 @@ func (httpClient *HTTPClient) DoRequest(method, url string, body []byte) (*http.
 //   - url: The URL to which the GET request will be sent.
 //
 // # Returns:
-//   - *http.Response: The HTTP response received from the server.
+//   - *http.Response: The HTTP response received from the server, also returned with the
+//     *StatusError of a non-2xx response.
 //   - error: An error if the request fails or the response status code indicates a failure.
 func (httpClient *HTTPClient) Get(url string) (*http.Response, error) {
 	return httpClient.DoRequest(http.MethodGet, url, nil)
 }
 
+// GetContext performs a GET request bound to ctx. See Get and DoRequestContext.
+func (httpClient *HTTPClient) GetContext(ctx context.Context, url string) (*http.Response, error) {
+	return httpClient.DoRequestContext(ctx, http.MethodGet, url, nil)
+}
+
 // Post performs a POST request to the specified URL with a body and optional headers.
 //
 // This method sends an HTTP POST request to the provided URL with the specified body.
@@ -204,12 +526,99 @@ func (httpClient *HTTPClient) Get(url string) (*http.Response, error) {
 //   - headers: A map of additional headers to include in the request.
 //
 // # Returns:
-//   - *http.Response: The HTTP response received from the server.
+//   - *http.Response: The HTTP response received from the server, also returned with the
+//     *StatusError of a non-2xx response.
 //   - error: An error if the request fails or the response status code indicates a failure.
 func (httpClient *HTTPClient) Post(url string, body []byte) (*http.Response, error) {
 	return httpClient.DoRequest(http.MethodPost, url, body)
 }
 
+// PostContext performs a POST request bound to ctx. See Post and DoRequestContext.
+func (httpClient *HTTPClient) PostContext(ctx context.Context, url string, body []byte) (*http.Response, error) {
+	return httpClient.DoRequestContext(ctx, http.MethodPost, url, body)
+}
+
+// DoRequestWithHeaders sends an HTTP request bound to ctx with headers set for this request only.
+//
+// The headers are merged over the client's headers and those stored in ctx (see
+// ContextWithHeaders), so a task can send an Authorization or X-Request-Id header for a single
+// request without changing the client shared by other accounts.
+//
+// # Parameters:
+//   - ctx: The context of the request.
+//   - method: The HTTP method.
+//   - url: The URL of the request.
+//   - body: The request body, or nil.
+//   - headers: The headers of this request, overriding the defaults with the same name.
+//
+// # Returns:
+//   - *http.Response: The HTTP response received from the server, also returned with the
+//     *StatusError of a non-2xx response.
+//   - error: An error if the request fails or the response status code indicates a failure.
+func (httpClient *HTTPClient) DoRequestWithHeaders(ctx context.Context, method, url string, body []byte, headers map[string]string) (*http.Response, error) {
+	return httpClient.DoRequestContext(ContextWithHeaders(ctx, headers), method, url, body)
+}
+
+// GetWithHeaders performs a GET request with per-call headers. See DoRequestWithHeaders.
+func (httpClient *HTTPClient) GetWithHeaders(url string, headers map[string]string) (*http.Response, error) {
+	return httpClient.DoRequestWithHeaders(context.Background(), http.MethodGet, url, nil, headers)
+}
+
+// PostWithHeaders performs a POST request with per-call headers. See DoRequestWithHeaders.
+func (httpClient *HTTPClient) PostWithHeaders(url string, body []byte, headers map[string]string) (*http.Response, error) {
+	return httpClient.DoRequestWithHeaders(context.Background(), http.MethodPost, url, body, headers)
+}
+
+// GetJSONWithHeaders performs a GET request with per-call headers and decodes the JSON
+// response body into v.
+//
+// # Parameters:
+//   - url: The URL to which the GET request will be sent.
+//   - headers: The headers of this request, overriding the defaults with the same name.
+//   - v: A pointer to the variable where the decoded response will be stored.
+//
+// # Returns:
+//   - error: An error if the request fails, the response status code indicates a failure,
+//     or the body is not valid JSON.
+//
+// # Example:
+//
+//	var balance Balance
+//	err := httpClient.GetJSONWithHeaders(url, map[string]string{"Authorization": "Bearer " + token}, &balance)
+func (httpClient *HTTPClient) GetJSONWithHeaders(url string, headers map[string]string, v interface{}) error {
+	resp, err := httpClient.GetWithHeaders(url, headers)
+	if err != nil {
+		return err
+	}
+	return DecodeResponseBody(resp, codec.JSON{}, v)
+}
+
+// DecodeResponseBody reads the response body, closes it, and decodes it into v with decoder.
+//
+// Unlike ReadResponseBody, the body can be in any format understood by the decoder, e.g.
+// codec.MsgPack{} or an obfuscation chain built with codec.Chain.
+//
+// # Parameters:
+//   - resp: The HTTP response from which the body will be read.
+//   - decoder: The decoder of the body.
+//   - v: A pointer to the variable where the decoded value will be stored.
+//
+// # Returns:
+//   - error: An error if reading or decoding the body fails.
+func DecodeResponseBody(resp *http.Response, decoder codec.Decoder, v interface{}) error {
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+
+		}
+	}(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(body, v)
+}
+
 // ReadResponseBody reads and returns the response body parsed as a JSON object or as a string if unmarshalling fails.
 //
 // This function reads the HTTP response body and tries to unmarshal it into the provided
@@ -0,0 +1,214 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils/retry"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient creates a client without proxy.
+func newTestClient(t *testing.T) *HTTPClient {
+	t.Helper()
+	client, err := NewHTTPClient(types.Proxy{})
+	if err != nil {
+		t.Fatalf("NewHTTPClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// statusServer answers with the statuses in order, then with 200 OK.
+func statusServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit := int(hits.Add(1))
+		if hit <= len(statuses) {
+			w.WriteHeader(statuses[hit-1])
+			_, _ = io.WriteString(w, "failure "+http.StatusText(statuses[hit-1]))
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := retry.Policy{MaxAttempts: 3, InitialDelay: time.Millisecond, Multiplier: 1, Jitter: retry.JitterNone}
+	tests := []struct {
+		name       string
+		statuses   []int
+		wantHits   int32
+		wantStatus int
+	}{
+		{"success", nil, 1, http.StatusOK},
+		{"transient failures", []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}, 3, http.StatusOK},
+		{"attempts exhausted", []int{500, 502, 503, 504}, 3, http.StatusServiceUnavailable},
+		{"client error", []int{http.StatusNotFound}, 1, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, hits := statusServer(t, test.statuses...)
+			client := newTestClient(t)
+			client.SetRetryPolicy(&policy)
+			resp, err := client.Get(server.URL)
+			if hits.Load() != test.wantHits {
+				t.Errorf("server received %d requests, want %d", hits.Load(), test.wantHits)
+			}
+			if resp == nil || resp.StatusCode != test.wantStatus {
+				t.Fatalf("Get() = %v, %v, want status %d", resp, err, test.wantStatus)
+			}
+			if (err != nil) != (test.wantStatus != http.StatusOK) {
+				t.Errorf("Get() error = %v", err)
+			}
+		})
+	}
+}
+
+// recordingLimiter is a Limiter recording its calls, failing Wait with err.
+type recordingLimiter struct {
+	err   error
+	waits atomic.Int32
+	done  []int64
+}
+
+func (limiter *recordingLimiter) Wait(ctx context.Context) error {
+	limiter.waits.Add(1)
+	return limiter.err
+}
+
+func (limiter *recordingLimiter) Done(bytes int64) {
+	limiter.done = append(limiter.done, bytes)
+}
+
+func TestLimiter(t *testing.T) {
+	server, hits := statusServer(t)
+	client := newTestClient(t)
+	limiter := &recordingLimiter{}
+	client.SetLimiter(limiter)
+	resp, err := client.Post(server.URL, []byte("payload"))
+	if err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	if len(limiter.done) != 0 {
+		t.Errorf("Done called before the body was closed")
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	_ = resp.Body.Close()
+	if limiter.waits.Load() != 1 || len(limiter.done) != 1 || limiter.done[0] != int64(len("payload")+len("ok")) {
+		t.Errorf("limiter got %d waits and Done calls %v, want 1 wait and Done(%d)", limiter.waits.Load(), limiter.done, len("payload")+len("ok"))
+	}
+
+	rejected := errors.New("budget exhausted")
+	client.SetLimiter(&recordingLimiter{err: rejected})
+	if _, err := client.Get(server.URL); !errors.Is(err, rejected) {
+		t.Errorf("Get() error = %v, want the error of the limiter", err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("request rejected by the limiter was sent")
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	var requestId string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId = r.Header.Get(RequestIDHeader)
+		w.Header().Set("Retry-After", "30")
+		w.Header().Set("X-RateLimit-Reset", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"error":"slow down"}`)
+	}))
+	defer server.Close()
+	client := newTestClient(t)
+	resp, err := client.Get(server.URL)
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Get() returned response %v, want the 429 response", resp)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != `{"error":"slow down"}` {
+		t.Errorf("response body = %q, want the body of the response", body)
+	}
+	var requestErr *RequestError
+	if !errors.As(err, &requestErr) || requestErr.RequestID != requestId || RequestIDFromError(err) != requestId {
+		t.Fatalf("Get() error = %#v, want a *RequestError with request ID %s", err, requestId)
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Get() error = %v, want a *StatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusTooManyRequests || !statusErr.Temporary() {
+		t.Errorf("status error = %d, temporary %t, want a temporary 429", statusErr.StatusCode, statusErr.Temporary())
+	}
+	if statusErr.RetryAfter() != 30*time.Second {
+		t.Errorf("RetryAfter() = %s, want 30s", statusErr.RetryAfter())
+	}
+	if reset := time.Until(statusErr.RateLimitReset()); reset < 50*time.Second || reset > 60*time.Second {
+		t.Errorf("RateLimitReset() is in %s, want in 60s", reset)
+	}
+	var decoded struct{ Error string }
+	if err := statusErr.Decode(&decoded); err != nil || decoded.Error != "slow down" {
+		t.Errorf("Decode() = %v, %+v, want the error of the body", err, decoded)
+	}
+	if !strings.Contains(err.Error(), requestId) {
+		t.Errorf("error %q does not mention the request ID", err)
+	}
+}
+
+func TestRequestError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+	client := newTestClient(t)
+	resp, err := client.Get(url)
+	if resp != nil {
+		t.Errorf("Get() returned a response for a failed request")
+	}
+	var requestErr *RequestError
+	if !errors.As(err, &requestErr) || requestErr.RequestID == "" {
+		t.Fatalf("Get() error = %#v, want a *RequestError with a request ID", err)
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		t.Errorf("Get() error = %v, want no *StatusError", err)
+	}
+	if !retry.IsRetryable(err) {
+		t.Errorf("refused connection is not retryable: %v", err)
+	}
+	if _, err := client.Get("://invalid"); RequestIDFromError(err) != "" {
+		t.Errorf("request never sent has request ID %s", RequestIDFromError(err))
+	}
+}
+
+func TestStatusErrorHeaders(t *testing.T) {
+	date := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	tests := []struct {
+		name           string
+		header         http.Header
+		wantRetryAfter time.Duration
+		wantReset      time.Time
+	}{
+		{"none", http.Header{}, 0, time.Time{}},
+		{"unix timestamp", http.Header{"X-Ratelimit-Reset": {"1700000000"}}, 0, time.Unix(1700000000, 0)},
+		{"http dates", http.Header{"Retry-After": {"Thu, 01 Jan 1970 00:00:00 GMT"}, "X-Ratelimit-Reset": {date.Format(http.TimeFormat)}}, 0, date},
+		{"negative", http.Header{"Retry-After": {"-5"}}, 0, time.Time{}},
+		{"invalid", http.Header{"Retry-After": {"soon"}, "X-Ratelimit-Reset": {"later"}}, 0, time.Time{}},
+	}
+	for _, test := range tests {
+		err := &StatusError{StatusCode: http.StatusTooManyRequests, Header: test.header}
+		if got := err.RetryAfter(); got != test.wantRetryAfter {
+			t.Errorf("%s: RetryAfter() = %s, want %s", test.name, got, test.wantRetryAfter)
+		}
+		if got := err.RateLimitReset(); !got.Equal(test.wantReset) {
+			t.Errorf("%s: RateLimitReset() = %s, want %s", test.name, got, test.wantReset)
+		}
+	}
+}
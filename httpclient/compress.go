@@ -0,0 +1,42 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"golang.org/x/net/context"
+)
+
+// gzipContextKey is the context key marking requests whose body is gzip-compressed.
+type gzipContextKey struct{}
+
+// ContextWithGzip returns a copy of ctx whose requests are sent with a gzip-compressed body
+// and the "Content-Encoding: gzip" header.
+//
+// Some game backends reject large uncompressed sync payloads; requests without a body are
+// sent unchanged.
+//
+// # Example:
+//
+//	resp, err := httpClient.PostContext(httpclient.ContextWithGzip(ctx), url, syncPayload)
+func ContextWithGzip(ctx context.Context) context.Context {
+	return context.WithValue(ctx, gzipContextKey{}, true)
+}
+
+// gzipFromContext reports whether ctx was returned by ContextWithGzip.
+func gzipFromContext(ctx context.Context) bool {
+	enabled, _ := ctx.Value(gzipContextKey{}).(bool)
+	return enabled
+}
+
+// gzipBody compresses a request body.
+func gzipBody(body []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
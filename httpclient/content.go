@@ -0,0 +1,61 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ContentTypes are the Content-Type and Accept headers a client adds to the requests that do
+// not set them, through the client's headers, ContextWithHeaders, or the headers of a task.
+// Many game backends reject bodies sent without a Content-Type.
+//
+// # Fields:
+//   - ContentType: The Content-Type of the requests with a JSON body. Bodies that are not
+//     valid JSON (e.g., a form) are sent without one unless the caller sets it. Empty adds none.
+//   - Accept: The Accept header of every request. Empty adds none.
+//
+// # Example:
+//
+//	httpClient.SetContentTypes(httpclient.ContentTypes{
+//		ContentType: "application/json; charset=utf-8",
+//		Accept:      "application/json",
+//	})
+//
+// # Notes:
+//   - A header set to an empty value (e.g., "headers": {"Accept": ""} in a task) is removed
+//     from the request rather than replaced by the default.
+type ContentTypes struct {
+	ContentType string
+	Accept      string
+}
+
+// DefaultContentTypes are the content types of the clients created by NewHTTPClient.
+var DefaultContentTypes = ContentTypes{
+	ContentType: "application/json",
+	Accept:      "application/json, text/plain, */*",
+}
+
+// SetContentTypes sets the Content-Type and Accept headers added to the requests that do not
+// set them. Passing ContentTypes{} sends the requests with the caller's headers only.
+func (httpClient *HTTPClient) SetContentTypes(contentTypes ContentTypes) {
+	httpClient.contentTypes = contentTypes
+}
+
+// negotiateContent adds the default content types of the client to the headers of a request
+// with body, and removes the headers the caller set to an empty value.
+func (httpClient *HTTPClient) negotiateContent(header http.Header, body []byte) {
+	contentType := ""
+	if len(body) > 0 && json.Valid(body) {
+		contentType = httpClient.contentTypes.ContentType
+	}
+	defaults := map[string]string{"Accept": httpClient.contentTypes.Accept, "Content-Type": contentType}
+	for key, value := range defaults {
+		values, set := header[key]
+		switch {
+		case set && (len(values) == 0 || values[0] == ""):
+			header.Del(key)
+		case !set && value != "":
+			header.Set(key, value)
+		}
+	}
+}
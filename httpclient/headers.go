@@ -0,0 +1,90 @@
+package httpclient
+
+// SetHeader sets a header sent with every request of the client, replacing any value it had.
+//
+// It is safe to call while requests are being sent; requests already started keep the
+// headers they were sent with.
+//
+// # Example:
+//
+//	httpClient.SetHeader("Authorization", "Bearer "+token)
+func (httpClient *HTTPClient) SetHeader(key, value string) {
+	httpClient.headersMu.Lock()
+	defer httpClient.headersMu.Unlock()
+	headers := make(map[string]string, len(httpClient.headers)+1)
+	for k, v := range httpClient.headers {
+		headers[k] = v
+	}
+	headers[key] = value
+	httpClient.headers = headers
+}
+
+// DeleteHeader removes a header set with SetHeader or CloneWithHeaders.
+func (httpClient *HTTPClient) DeleteHeader(key string) {
+	httpClient.headersMu.Lock()
+	defer httpClient.headersMu.Unlock()
+	if _, ok := httpClient.headers[key]; !ok {
+		return
+	}
+	headers := make(map[string]string, len(httpClient.headers))
+	for k, v := range httpClient.headers {
+		if k != key {
+			headers[k] = v
+		}
+	}
+	httpClient.headers = headers
+}
+
+// Headers returns a copy of the headers sent with every request of the client.
+func (httpClient *HTTPClient) Headers() map[string]string {
+	current := httpClient.defaultHeaders()
+	headers := make(map[string]string, len(current))
+	for key, value := range current {
+		headers[key] = value
+	}
+	return headers
+}
+
+// CloneWithHeaders returns a client sending the client's headers merged with the given ones.
+//
+// The clone shares the connections, proxy, limiter, audit log, and every other setting of the
+// client, so it is cheap to create, e.g. once per account with its own Authorization header.
+// Setters changing the transport (SetTLSOptions, SetNetworkOptions, SetRedirectPolicy) apply to
+// both clients; SetHeader and DeleteHeader apply only to the client they are called on.
+//
+// # Parameters:
+//   - headers: The headers added to (or overriding) the client's headers.
+//
+// # Returns:
+//   - *HTTPClient: The new client.
+func (httpClient *HTTPClient) CloneWithHeaders(headers map[string]string) *HTTPClient {
+	current := httpClient.defaultHeaders()
+	merged := make(map[string]string, len(current)+len(headers))
+	for key, value := range current {
+		merged[key] = value
+	}
+	for key, value := range headers {
+		merged[key] = value
+	}
+	return &HTTPClient{
+		client:         httpClient.client,
+		proxy:          httpClient.proxy,
+		headers:        merged,
+		auditLog:       httpClient.auditLog,
+		retryPolicy:    httpClient.retryPolicy,
+		limiter:        httpClient.limiter,
+		observer:       httpClient.observer,
+		redirectPolicy: httpClient.redirectPolicy,
+		flights:        httpClient.flights,
+		dialer:         httpClient.dialer,
+		contentTypes:   httpClient.contentTypes,
+	}
+}
+
+// defaultHeaders returns the current headers of the client. The map is never modified after
+// it is stored, so it can be read without holding the lock.
+func (httpClient *HTTPClient) defaultHeaders() map[string]string {
+	httpClient.headersMu.RLock()
+	defer httpClient.headersMu.RUnlock()
+	return httpClient.headers
+}
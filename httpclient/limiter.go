@@ -0,0 +1,63 @@
+package httpclient
+
+import (
+	"golang.org/x/net/context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Limiter throttles the requests sent by an HTTPClient, e.g. a farm-wide request budget.
+//
+// # Methods:
+//   - Wait(ctx context.Context) error: Called before every request. It blocks until the request
+//     may be sent, or returns an error to abort the request.
+//   - Done(bytes int64): Called once per request with the number of bytes sent and received,
+//     after the response body is closed.
+type Limiter interface {
+	Wait(ctx context.Context) error
+	Done(bytes int64)
+}
+
+// SetLimiter sets the limiter applied to every request sent by the client. Passing nil
+// disables limiting.
+func (httpClient *HTTPClient) SetLimiter(limiter Limiter) {
+	httpClient.limiter = limiter
+}
+
+// Observer receives the latency of every request sent by an HTTPClient.
+//
+// # Methods:
+//   - ObserveRequest(method, url string, duration time.Duration, err error): Called once per
+//     request when the response headers arrive, or with the error if no response was received.
+type Observer interface {
+	ObserveRequest(method, url string, duration time.Duration, err error)
+}
+
+// SetObserver sets the observer notified of every request sent by the client. Passing nil
+// disables observing.
+func (httpClient *HTTPClient) SetObserver(observer Observer) {
+	httpClient.observer = observer
+}
+
+// countingBody is a response body reporting the bytes read from it to a Limiter when closed.
+type countingBody struct {
+	io.ReadCloser
+	limiter Limiter
+	bytes   int64
+	once    sync.Once
+}
+
+func (body *countingBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	body.bytes += int64(n)
+	return n, err
+}
+
+func (body *countingBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.once.Do(func() {
+		body.limiter.Done(body.bytes)
+	})
+	return err
+}
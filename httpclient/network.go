@@ -0,0 +1,264 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/context"
+	"golang.org/x/net/proxy"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// The dial settings of a client without network options, those of http.DefaultTransport.
+const (
+	DefaultDialTimeout = 30 * time.Second
+	DefaultKeepAlive   = 30 * time.Second
+)
+
+// NetworkOptions configures the local end of the connections opened by an HTTPClient, and how
+// they are dialed.
+//
+// Hosts with several routable addresses can spread the non-proxied traffic (e.g., the Nexus API
+// calls) across them by giving each handler its own local address. For a proxied client, the
+// options apply to the connection to the proxy.
+//
+// The dial and handshake timeouts are separate from the timeout of the client, which bounds a
+// whole request: with a short DialTimeout, a slow or dead proxy fails fast instead of eating the
+// time budget of the request before it is even sent.
+//
+// # Fields:
+//   - LocalAddr: The local IP address connections are bound to (e.g., "203.0.113.7" or "2001:db8::7").
+//   - Interface: The network interface whose address connections are bound to (e.g., "eth1").
+//     Ignored when LocalAddr is set.
+//   - PreferIPv6: Whether IPv6 addresses of the destination are tried before IPv4 ones.
+//   - DialTimeout: How long opening a connection may take: the TCP connection, plus the SOCKS
+//     handshake for a proxied client. Defaults to DefaultDialTimeout.
+//   - KeepAlive: The period of the TCP keep-alive probes of the connections. Defaults to
+//     DefaultKeepAlive. Negative disables them.
+//   - FallbackDelay: How long a dual-stack connection waits on the preferred address family
+//     before racing the other one ("Happy Eyeballs", RFC 6555). Zero uses the 300ms of the
+//     net package. Negative disables the race: the other family is only tried once the
+//     preferred one failed.
+//   - TLSHandshakeTimeout: How long the TLS handshake with the destination may take. Zero means
+//     no limit other than the timeout of the client.
+//
+// # Example:
+//
+//	err := httpClient.SetNetworkOptions(httpclient.NetworkOptions{Interface: "eth1", PreferIPv6: true,
+//		DialTimeout: 3 * time.Second, TLSHandshakeTimeout: 5 * time.Second})
+//	if err != nil {
+//		log.Fatalf("Failed to bind client: %v", err)
+//	}
+type NetworkOptions struct {
+	LocalAddr           string
+	Interface           string
+	PreferIPv6          bool
+	DialTimeout         time.Duration
+	KeepAlive           time.Duration
+	FallbackDelay       time.Duration
+	TLSHandshakeTimeout time.Duration
+}
+
+// IsZero reports whether the options leave the default network behaviour unchanged.
+func (options NetworkOptions) IsZero() bool {
+	return options.LocalAddr == "" && options.Interface == "" && !options.PreferIPv6 && options.DialTimeout == 0 &&
+		options.KeepAlive == 0 && options.FallbackDelay == 0 && options.TLSHandshakeTimeout == 0
+}
+
+// localIP returns the IP address connections are bound to, or nil when none is configured.
+func (options NetworkOptions) localIP() (net.IP, error) {
+	if options.LocalAddr != "" {
+		ip := net.ParseIP(options.LocalAddr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid local address: %s", options.LocalAddr)
+		}
+		return ip, nil
+	}
+	if options.Interface == "" {
+		return nil, nil
+	}
+	iface, err := net.InterfaceByName(options.Interface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interface %s: %w", options.Interface, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses of interface %s: %w", options.Interface, err)
+	}
+	var ipv4, ipv6 net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			if ipv4 == nil {
+				ipv4 = ipNet.IP
+			}
+		} else if ipv6 == nil {
+			ipv6 = ipNet.IP
+		}
+	}
+	switch {
+	case ipv6 != nil && (options.PreferIPv6 || ipv4 == nil):
+		return ipv6, nil
+	case ipv4 != nil:
+		return ipv4, nil
+	default:
+		return nil, fmt.Errorf("interface %s has no routable address", options.Interface)
+	}
+}
+
+// baseDialer opens the TCP connections of a client, either to the destination or to its proxy.
+//
+// It is shared by the transport and the SOCKS dialer so that SetNetworkOptions can rebind a
+// client after it was created.
+type baseDialer struct {
+	mu         sync.RWMutex
+	dialer     net.Dialer
+	preferIPv6 bool
+}
+
+// newBaseDialer returns a dialer with the timeouts of http.DefaultTransport.
+func newBaseDialer() *baseDialer {
+	return &baseDialer{dialer: net.Dialer{Timeout: DefaultDialTimeout, KeepAlive: DefaultKeepAlive}}
+}
+
+// Dial connects to addr. It implements proxy.Dialer.
+func (dialer *baseDialer) Dial(network, addr string) (net.Conn, error) {
+	return dialer.DialContext(context.Background(), network, addr)
+}
+
+// DialContext connects to addr, trying IPv6 first when IPv6 is preferred.
+func (dialer *baseDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer.mu.RLock()
+	netDialer := dialer.dialer
+	preferIPv6 := dialer.preferIPv6
+	dialer.mu.RUnlock()
+	if !preferIPv6 || network != "tcp" {
+		return netDialer.DialContext(ctx, network, addr)
+	}
+	if netDialer.FallbackDelay < 0 {
+		conn, err := netDialer.DialContext(ctx, "tcp6", addr)
+		if err == nil || ctx.Err() != nil {
+			return conn, err
+		}
+		return netDialer.DialContext(ctx, "tcp4", addr)
+	}
+	return raceIPv6(ctx, &netDialer, addr)
+}
+
+// raceIPv6 connects to addr over IPv6, and over IPv4 as well once the IPv6 connection failed or
+// is not established within the fallback delay of netDialer. The first connection established
+// is returned, and the other one closed.
+func raceIPv6(ctx context.Context, netDialer *net.Dialer, addr string) (net.Conn, error) {
+	delay := netDialer.FallbackDelay
+	if delay == 0 {
+		delay = 300 * time.Millisecond
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	results := make(chan result, 2)
+	dial := func(network string) {
+		conn, err := netDialer.DialContext(ctx, network, addr)
+		results <- result{conn, err}
+	}
+	go dial("tcp6")
+	fallback := time.NewTimer(delay)
+	defer fallback.Stop()
+	pending, fellBack := 1, false
+	var errs []error
+	for {
+		select {
+		case <-fallback.C:
+		case outcome := <-results:
+			pending--
+			if outcome.err == nil {
+				if pending > 0 {
+					go func() {
+						if late := <-results; late.conn != nil {
+							_ = late.conn.Close()
+						}
+					}()
+				}
+				return outcome.conn, nil
+			}
+			errs = append(errs, outcome.err)
+			if fellBack && pending == 0 {
+				return nil, errors.Join(errs...)
+			}
+		}
+		if !fellBack {
+			fellBack = true
+			pending++
+			go dial("tcp4")
+		}
+	}
+}
+
+// throughProxy connects to addr through proxyDialer, the SOCKS dialer of a client, within the
+// dial timeout: it bounds both the connection to the proxy and the SOCKS handshake.
+func (dialer *baseDialer) throughProxy(ctx context.Context, proxyDialer proxy.Dialer, network, addr string) (net.Conn, error) {
+	dialer.mu.RLock()
+	timeout := dialer.dialer.Timeout
+	dialer.mu.RUnlock()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if contextDialer, ok := proxyDialer.(proxy.ContextDialer); ok {
+		return contextDialer.DialContext(ctx, network, addr)
+	}
+	return proxyDialer.Dial(network, addr)
+}
+
+// SetNetworkOptions binds the connections opened by the client afterwards to a local address
+// or interface, optionally makes them prefer IPv6, and sets their dial and handshake timeouts.
+//
+// # Parameters:
+//   - options: The network options (see NetworkOptions).
+//
+// # Returns:
+//   - error: An error if the local address is invalid, the interface has no usable address, or
+//     a TLS handshake timeout is set on a client whose transport does not support it. The client
+//     is left unchanged then.
+func (httpClient *HTTPClient) SetNetworkOptions(options NetworkOptions) error {
+	ip, err := options.localIP()
+	if err != nil {
+		return err
+	}
+	var localAddr net.Addr
+	if ip != nil {
+		localAddr = &net.TCPAddr{IP: ip}
+	}
+	transport, ok := httpClient.client.Transport.(*http.Transport)
+	if !ok && options.TLSHandshakeTimeout != 0 {
+		return errors.New("client transport does not support a TLS handshake timeout")
+	}
+	timeout, keepAlive := options.DialTimeout, options.KeepAlive
+	if timeout <= 0 {
+		timeout = DefaultDialTimeout
+	}
+	if keepAlive == 0 {
+		keepAlive = DefaultKeepAlive
+	}
+	httpClient.dialer.mu.Lock()
+	httpClient.dialer.dialer.LocalAddr = localAddr
+	httpClient.dialer.dialer.Timeout = timeout
+	httpClient.dialer.dialer.KeepAlive = keepAlive
+	httpClient.dialer.dialer.FallbackDelay = options.FallbackDelay
+	httpClient.dialer.preferIPv6 = options.PreferIPv6
+	httpClient.dialer.mu.Unlock()
+	if ok {
+		transport.TLSHandshakeTimeout = max(options.TLSHandshakeTimeout, 0)
+	}
+	httpClient.client.CloseIdleConnections()
+	return nil
+}
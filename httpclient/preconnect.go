@@ -0,0 +1,78 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Preconnect opens a connection to the host of every URL, through the proxy of the client and
+// with its TLS handshake, and leaves it idle in the client's pool for the next requests to the
+// host. Farms use it before their first scheduled requests, so that the handshakes of every
+// proxy are not all made at once when the tasks start.
+//
+// The connection is opened with a HEAD request to the root of the host, sent without the
+// client's headers and without going through its audit log, limiter, rate limit, and observer.
+// Any response counts as a success.
+//
+// # Parameters:
+//   - ctx: The context bounding the connections.
+//   - urls: URLs of the hosts, e.g. the base URL of the game. Only their scheme and host are used.
+//
+// # Returns:
+//   - error: The errors of the hosts that could not be reached, joined.
+//
+// # Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := httpClient.Preconnect(ctx, "https://api.example.com"); err != nil {
+//		log.Printf("Failed to preconnect: %v", err)
+//	}
+func (httpClient *HTTPClient) Preconnect(ctx context.Context, urls ...string) error {
+	if httpClient.closed.Load() {
+		return ErrClientClosed
+	}
+	origins := make(map[string]bool, len(urls))
+	for _, rawURL := range urls {
+		parsed, err := url.Parse(rawURL)
+		if err != nil || parsed.Host == "" {
+			return fmt.Errorf("invalid preconnect URL %q", rawURL)
+		}
+		origins[parsed.Scheme+"://"+parsed.Host+"/"] = true
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for origin := range origins {
+		wg.Add(1)
+		go func(origin string) {
+			defer wg.Done()
+			if err := httpClient.preconnect(ctx, origin); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", origin, err))
+				mu.Unlock()
+			}
+		}(origin)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// preconnect sends the HEAD request opening a connection to origin.
+func (httpClient *HTTPClient) preconnect(ctx context.Context, origin string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, origin, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
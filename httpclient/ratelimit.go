@@ -0,0 +1,110 @@
+package httpclient
+
+import (
+	"golang.org/x/net/context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// HostRateLimit limits the rate of the requests sent to each host, with a token bucket per
+// host holding at most one second of requests (and at least one request), so requests are
+// spread evenly rather than sent in bursts.
+//
+// # Example:
+//
+//	httpClient.SetHostRateLimit(httpclient.NewHostRateLimit(30)) // 30 requests/min per host
+type HostRateLimit struct {
+	mu        sync.Mutex
+	perMinute float64
+	buckets   map[string]*hostBucket
+}
+
+// hostBucket is the token bucket of a host.
+type hostBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewHostRateLimit creates a limit of perMinute requests per minute to each host.
+func NewHostRateLimit(perMinute float64) *HostRateLimit {
+	return &HostRateLimit{perMinute: perMinute, buckets: make(map[string]*hostBucket)}
+}
+
+// SetRate changes the rate of the limit to perMinute requests per minute to each host. Zero
+// or less lifts the limit.
+func (limit *HostRateLimit) SetRate(perMinute float64) {
+	limit.mu.Lock()
+	defer limit.mu.Unlock()
+	limit.perMinute = perMinute
+}
+
+// Rate returns the rate of the limit, in requests per minute to each host.
+func (limit *HostRateLimit) Rate() float64 {
+	limit.mu.Lock()
+	defer limit.mu.Unlock()
+	return limit.perMinute
+}
+
+// Wait blocks until a request may be sent to host, or returns the error of ctx if it is done
+// first.
+func (limit *HostRateLimit) Wait(ctx context.Context, host string) error {
+	limit.mu.Lock()
+	if limit.perMinute <= 0 {
+		limit.mu.Unlock()
+		return nil
+	}
+	rate := limit.perMinute / 60
+	capacity := max(rate, 1)
+	now := time.Now()
+	bucket, ok := limit.buckets[host]
+	if !ok {
+		bucket = &hostBucket{tokens: capacity, last: now}
+		limit.buckets[host] = bucket
+	}
+	bucket.tokens = min(bucket.tokens+now.Sub(bucket.last).Seconds()*rate, capacity)
+	bucket.last = now
+	bucket.tokens--
+	var wait time.Duration
+	if bucket.tokens < 0 {
+		wait = time.Duration(-bucket.tokens / rate * float64(time.Second))
+	}
+	limit.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		limit.mu.Lock()
+		bucket.tokens++
+		limit.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// SetHostRateLimit sets the limit of the rate of the requests the client sends to each host.
+// Passing nil lifts the limit.
+//
+// # Parameters:
+//   - limit: The limit, see NewHostRateLimit. A limit shared by several clients limits their
+//     requests together.
+func (httpClient *HTTPClient) SetHostRateLimit(limit *HostRateLimit) {
+	httpClient.rateLimit = limit
+}
+
+// waitRateLimit waits for the host rate limit of the client, if any, before a request to
+// rawURL.
+func (httpClient *HTTPClient) waitRateLimit(ctx context.Context, rawURL string) error {
+	if httpClient.rateLimit == nil {
+		return nil
+	}
+	host := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil {
+		host = parsed.Host
+	}
+	return httpClient.rateLimit.Wait(ctx, host)
+}
@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrCrossHostRedirect is returned when a redirect to another host is refused by the
+// redirect policy of the client.
+var ErrCrossHostRedirect = errors.New("cross-host redirect refused")
+
+// RedirectPolicy configures how an HTTPClient handles redirects.
+//
+// # Fields:
+//   - MaxRedirects: The maximum number of redirects followed per request. Zero means 10,
+//     the net/http default.
+//   - SameHostOnly: Whether redirects to another host fail with ErrCrossHostRedirect.
+//   - DontFollow: Whether redirects are not followed at all. The 3xx response is then returned
+//     to the caller as a successful response, so its Location header can be read.
+//
+// # Example:
+//
+//	httpClient.SetRedirectPolicy(httpclient.RedirectPolicy{DontFollow: true})
+//	resp, err := httpClient.Get(authURL)
+//	if err != nil {
+//		log.Fatalf("Auth request failed: %v", err)
+//	}
+//	callback := resp.Header.Get("Location")
+type RedirectPolicy struct {
+	MaxRedirects int
+	SameHostOnly bool
+	DontFollow   bool
+}
+
+// SetRedirectPolicy sets the redirect policy of the client, replacing the net/http default
+// of following up to 10 redirects to any host.
+func (httpClient *HTTPClient) SetRedirectPolicy(policy RedirectPolicy) {
+	httpClient.redirectPolicy = policy
+	httpClient.client.CheckRedirect = policy.check
+}
+
+// check implements http.Client.CheckRedirect for the policy.
+func (policy RedirectPolicy) check(req *http.Request, via []*http.Request) error {
+	if policy.DontFollow {
+		return http.ErrUseLastResponse
+	}
+	maxRedirects := policy.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = 10
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if policy.SameHostOnly && req.URL.Host != via[0].URL.Host {
+		return fmt.Errorf("%w: %s to %s", ErrCrossHostRedirect, via[0].URL.Host, req.URL.Host)
+	}
+	return nil
+}
+
+// isRedirect reports whether a response status is a redirect.
+func isRedirect(status int) bool {
+	return status >= 300 && status < 400
+}
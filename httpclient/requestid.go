@@ -0,0 +1,61 @@
+package httpclient
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// RequestIDHeader is the header carrying the ID of every request sent by an HTTPClient.
+const RequestIDHeader = "X-Request-Id"
+
+// NewRequestID returns a random request ID formatted as a version 4 UUID.
+func NewRequestID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	hexId := hex.EncodeToString(id[:])
+	return hexId[0:8] + "-" + hexId[8:12] + "-" + hexId[12:16] + "-" + hexId[16:20] + "-" + hexId[20:]
+}
+
+// RequestError is the error returned for a request that was sent, carrying its request ID so
+// that a failed task can be correlated with the SDK logs and the game vendor's logs.
+//
+// It wraps the transport error or the StatusError of the response, so errors.As and errors.Is
+// see through it.
+//
+// # Fields:
+//   - RequestID: The value of the request's X-Request-Id header.
+//   - Err: The underlying error.
+//
+// # Example:
+//
+//	if requestId := httpclient.RequestIDFromError(err); requestId != "" {
+//		log.Printf("Claim failed, request ID %s", requestId)
+//	}
+type RequestError struct {
+	RequestID string
+	Err       error
+}
+
+// Error returns the underlying error message followed by the request ID.
+func (err *RequestError) Error() string {
+	return fmt.Sprintf("%v (request %s)", err.Err, err.RequestID)
+}
+
+// Unwrap returns the underlying error.
+func (err *RequestError) Unwrap() error {
+	return err.Err
+}
+
+// RequestIDFromError returns the request ID carried by err, or "" if err does not come from a
+// sent request.
+func RequestIDFromError(err error) string {
+	var requestErr *RequestError
+	if errors.As(err, &requestErr) {
+		return requestErr.RequestID
+	}
+	return ""
+}
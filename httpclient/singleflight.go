@@ -0,0 +1,164 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FlightGroup deduplicates identical in-flight GET requests.
+//
+// While a GET request is in flight, identical requests (same URL and headers, including the
+// per-account headers of ContextWithHeaders) sent through any client sharing the group wait
+// for it and receive a copy of its response instead of being sent again. This is useful when
+// hundreds of accounts fetch the same public game configuration at startup.
+//
+// # Example:
+//
+//	flights := httpclient.NewFlightGroup()
+//	for _, client := range clients {
+//		client.SetFlightGroup(flights)
+//	}
+//
+// # Notes:
+//   - Only GET requests without a body are deduplicated.
+//   - The request is sent, audited, and counted once, on behalf of the first caller. Every
+//     caller receives its response and error, so the request ID of a *RequestError (see
+//     RequestIDFromError) is the ID of that single request, the one in the logs and the audit
+//     trail, for the first caller and the callers that joined it alike.
+//   - The request is not cancelled when its callers are, but it keeps the latest deadline of
+//     its callers: it is cancelled once every caller's deadline passed, and never when one of
+//     them has no deadline. A caller returns at its own deadline regardless.
+type FlightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+// flight is an in-flight request and, once done, its buffered response.
+type flight struct {
+	done      chan struct{}
+	resp      *http.Response
+	body      []byte
+	err       error
+	cancel    context.CancelFunc // Cancels the request
+	deadline  time.Time          // Latest deadline of the callers
+	unbounded bool               // Whether a caller has no deadline
+	timer     *time.Timer        // Cancels the request at deadline
+}
+
+// NewFlightGroup creates an empty FlightGroup.
+func NewFlightGroup() *FlightGroup {
+	return &FlightGroup{calls: make(map[string]*flight)}
+}
+
+// SetFlightGroup makes the client deduplicate identical in-flight GET requests with the
+// other clients of the group. Passing nil disables deduplication.
+func (httpClient *HTTPClient) SetFlightGroup(group *FlightGroup) {
+	httpClient.flights = group
+}
+
+// do sends the request through send unless an identical one is in flight, and returns a
+// private copy of the response.
+func (group *FlightGroup) do(ctx context.Context, key string, send func(ctx context.Context) (*http.Response, error)) (*http.Response, error) {
+	group.mu.Lock()
+	current, ok := group.calls[key]
+	if !ok {
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		current = &flight{done: make(chan struct{}), cancel: cancel}
+		group.calls[key] = current
+		go group.run(flightCtx, key, current, send)
+	}
+	current.extend(ctx)
+	group.mu.Unlock()
+	select {
+	case <-current.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if current.resp == nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, current.err
+	}
+	resp := *current.resp
+	resp.Header = current.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(current.body))
+	return &resp, current.err
+}
+
+// run sends the request of a flight and buffers its response.
+func (group *FlightGroup) run(ctx context.Context, key string, current *flight, send func(ctx context.Context) (*http.Response, error)) {
+	resp, err := send(ctx)
+	if resp != nil {
+		body, readErr := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err == nil && readErr != nil {
+			err = readErr
+		} else {
+			current.body, current.resp = body, resp
+		}
+	}
+	current.err = err
+	group.mu.Lock()
+	delete(group.calls, key)
+	if current.timer != nil {
+		current.timer.Stop()
+	}
+	group.mu.Unlock()
+	current.cancel()
+	close(current.done)
+}
+
+// extend makes the request of a flight last until the deadline of a caller joining it, if
+// later than the deadlines of the others. It must be called with the group's mu held.
+func (current *flight) extend(ctx context.Context) {
+	if current.unbounded {
+		return
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		current.unbounded = true
+		if current.timer != nil {
+			current.timer.Stop()
+		}
+		return
+	}
+	if !deadline.After(current.deadline) {
+		return
+	}
+	current.deadline = deadline
+	if current.timer == nil {
+		current.timer = time.AfterFunc(time.Until(deadline), current.cancel)
+	} else {
+		current.timer.Reset(time.Until(deadline))
+	}
+}
+
+// flightKey returns the deduplication key of a GET request sent with the client headers and
+// the headers of its context.
+func flightKey(url string, clientHeaders, contextHeaders map[string]string) string {
+	headers := make(map[string]string, len(clientHeaders)+len(contextHeaders))
+	for key, value := range clientHeaders {
+		headers[http.CanonicalHeaderKey(key)] = value
+	}
+	for key, value := range contextHeaders {
+		headers[http.CanonicalHeaderKey(key)] = value
+	}
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var builder strings.Builder
+	builder.WriteString(url)
+	for _, key := range keys {
+		builder.WriteString("\n" + key + ": " + headers[key])
+	}
+	return builder.String()
+}
@@ -0,0 +1,220 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// joiningContext is a context reporting to joined when a caller joins a flight with it, which
+// reads its deadline.
+type joiningContext struct {
+	context.Context
+	joined *sync.WaitGroup
+}
+
+func (ctx joiningContext) Deadline() (time.Time, bool) {
+	ctx.joined.Done()
+	return ctx.Context.Deadline()
+}
+
+func TestFlightGroupDeduplicatesRequests(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Header().Set("X-Path", r.URL.Path)
+		_, _ = io.WriteString(w, "config of "+r.Header.Get("X-Game"))
+	}))
+	defer server.Close()
+
+	flights := NewFlightGroup()
+	clients := make([]*HTTPClient, 2)
+	for i := range clients {
+		client, err := NewHTTPClient(types.Proxy{})
+		if err != nil {
+			t.Fatalf("NewHTTPClient failed: %v", err)
+		}
+		client.SetHeader("X-Game", "hamster")
+		client.SetFlightGroup(flights)
+		clients[i] = client
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const callers = 6
+	var joined, done sync.WaitGroup
+	joined.Add(callers)
+	bodies := make([]string, callers)
+	for i := 0; i < callers; i++ {
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			resp, err := clients[i%2].GetContext(joiningContext{ctx, &joined}, server.URL+"/config")
+			if err != nil {
+				t.Errorf("GetContext failed: %v", err)
+				return
+			}
+			resp.Header.Set("X-Path", "changed")
+			body, _ := io.ReadAll(resp.Body)
+			bodies[i] = string(body)
+		}(i)
+	}
+	joined.Wait()
+	close(release)
+	done.Wait()
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server received %d requests, want 1", got)
+	}
+	for i, body := range bodies {
+		if body != "config of hamster" {
+			t.Errorf("caller %d read %q, want the shared response", i, body)
+		}
+	}
+
+	tests := []struct {
+		name string
+		send func(client *HTTPClient) (*http.Response, error)
+	}{
+		{"other headers", func(client *HTTPClient) (*http.Response, error) {
+			return client.GetWithHeaders(server.URL+"/config", map[string]string{"X-Game": "notcoin"})
+		}},
+		{"post", func(client *HTTPClient) (*http.Response, error) {
+			return client.Post(server.URL+"/config", []byte("{}"))
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := hits.Load()
+			if _, err := test.send(clients[0]); err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if hits.Load() != before+1 {
+				t.Errorf("request was not sent")
+			}
+		})
+	}
+}
+
+func TestFlightGroupDeadlines(t *testing.T) {
+	tests := []struct {
+		name string
+		// first joins the flight, then second; the flight is released once first returned.
+		first, second func() (context.Context, context.CancelFunc)
+		wantCancelled bool
+	}{
+		{"later deadline joins",
+			func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 20*time.Millisecond)
+			},
+			func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Second)
+			},
+			false},
+		{"caller without deadline joins",
+			func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 20*time.Millisecond)
+			},
+			func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			false},
+		{"every deadline passed",
+			func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 20*time.Millisecond)
+			},
+			func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			group := NewFlightGroup()
+			started, release := make(chan struct{}), make(chan struct{})
+			cancelled := make(chan bool, 1)
+			send := func(ctx context.Context) (*http.Response, error) {
+				close(started)
+				select {
+				case <-release:
+				case <-ctx.Done():
+				}
+				cancelled <- ctx.Err() != nil
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+			}
+
+			firstCtx, cancelFirst := test.first()
+			defer cancelFirst()
+			firstDone := make(chan error, 1)
+			go func() {
+				_, err := group.do(firstCtx, "key", send)
+				firstDone <- err
+			}()
+			<-started
+			secondCtx, cancelSecond := test.second()
+			defer cancelSecond()
+			var joined sync.WaitGroup
+			joined.Add(1)
+			secondDone := make(chan error, 1)
+			go func() {
+				resp, err := group.do(joiningContext{secondCtx, &joined}, "key", send)
+				if err == nil {
+					body, _ := io.ReadAll(resp.Body)
+					if string(body) != "ok" {
+						err = errors.New("unexpected body " + string(body))
+					}
+				}
+				secondDone <- err
+			}()
+			joined.Wait()
+
+			if err := <-firstDone; !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("first caller returned %v, want its deadline", err)
+			}
+			// Leave the flight time to be cancelled at the deadline of the first caller.
+			time.Sleep(30 * time.Millisecond)
+			close(release)
+			if got := <-cancelled; got != test.wantCancelled {
+				t.Errorf("request cancelled = %t, want %t", got, test.wantCancelled)
+			}
+			err := <-secondDone
+			if test.wantCancelled && !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("second caller returned %v, want its deadline", err)
+			}
+			if !test.wantCancelled && err != nil {
+				t.Errorf("second caller returned %v, want the response", err)
+			}
+		})
+	}
+}
+
+func TestFlightKey(t *testing.T) {
+	base := flightKey("https://game/config", map[string]string{"x-game": "hamster", "Accept": "*/*"}, nil)
+	tests := []struct {
+		name           string
+		clientHeaders  map[string]string
+		contextHeaders map[string]string
+		same           bool
+	}{
+		{"canonical names", map[string]string{"Accept": "*/*", "X-Game": "hamster"}, nil, true},
+		{"context headers", map[string]string{"Accept": "*/*"}, map[string]string{"X-GAME": "hamster"}, true},
+		{"context overrides", map[string]string{"Accept": "*/*", "X-Game": "notcoin"}, map[string]string{"X-Game": "hamster"}, true},
+		{"other value", map[string]string{"Accept": "*/*", "X-Game": "notcoin"}, nil, false},
+		{"extra header", map[string]string{"Accept": "*/*", "X-Game": "hamster"}, map[string]string{"User-Agent": "a"}, false},
+	}
+	for _, test := range tests {
+		if same := flightKey("https://game/config", test.clientHeaders, test.contextHeaders) == base; same != test.same {
+			t.Errorf("%s: same key = %t, want %t", test.name, same, test.same)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package httpclient
+
+import (
+	"golang.org/x/net/context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Throttle is a token bucket limiting the bandwidth of the connections of a proxy.
+//
+// Many residential proxy plans bill per gigabyte, so a throttle caps how fast a runaway task
+// can burn the monthly quota. Reads and writes of every connection sharing the throttle draw
+// from the same bucket, which holds at most one second of traffic.
+//
+// # Example:
+//
+//	throttle := httpclient.NewThrottle(256 * 1024) // 256 KiB/s
+//	conn = throttle.Conn(conn)
+type Throttle struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+// NewThrottle creates a throttle allowing bytesPerSecond bytes per second.
+func NewThrottle(bytesPerSecond int64) *Throttle {
+	return &Throttle{rate: float64(bytesPerSecond), tokens: float64(bytesPerSecond), last: time.Now()}
+}
+
+// Conn wraps conn so that its reads and writes are throttled.
+func (throttle *Throttle) Conn(conn net.Conn) net.Conn {
+	return &throttledConn{Conn: conn, throttle: throttle}
+}
+
+// take consumes n bytes from the bucket and blocks until they are paid for.
+func (throttle *Throttle) take(n int) {
+	throttle.mu.Lock()
+	now := time.Now()
+	throttle.tokens += now.Sub(throttle.last).Seconds() * throttle.rate
+	if throttle.tokens > throttle.rate {
+		throttle.tokens = throttle.rate
+	}
+	throttle.last = now
+	throttle.tokens -= float64(n)
+	var wait time.Duration
+	if throttle.tokens < 0 {
+		wait = time.Duration(-throttle.tokens / throttle.rate * float64(time.Second))
+	}
+	throttle.mu.Unlock()
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// chunk returns the largest transfer size that keeps bursts below one second of traffic.
+func (throttle *Throttle) chunk(n int) int {
+	if limit := int(throttle.rate); limit > 0 && n > limit {
+		return limit
+	}
+	return n
+}
+
+// throttledConn is a connection whose reads and writes draw from a Throttle.
+type throttledConn struct {
+	net.Conn
+	throttle *Throttle
+}
+
+func (conn *throttledConn) Read(p []byte) (int, error) {
+	n, err := conn.Conn.Read(p[:conn.throttle.chunk(len(p))])
+	if n > 0 {
+		conn.throttle.take(n)
+	}
+	return n, err
+}
+
+func (conn *throttledConn) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		size := conn.throttle.chunk(len(p) - written)
+		conn.throttle.take(size)
+		n, err := conn.Conn.Write(p[written : written+size])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// throttleTransport makes every connection dialed by transport draw from throttle.
+func throttleTransport(transport *http.Transport, throttle *Throttle) {
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return throttle.Conn(conn), nil
+	}
+}
@@ -0,0 +1,169 @@
+package httpclient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// TLSOptions configures the TLS settings of an HTTPClient.
+//
+// # Fields:
+//   - RootCAFiles: PEM files of the certificate authorities trusted in addition to the
+//     system roots, e.g. the CA of a corporate MITM proxy or of a private PKI.
+//   - RootCAPEM: PEM-encoded certificate authorities trusted in addition to RootCAFiles.
+//   - ExcludeSystemRoots: Whether only the configured authorities are trusted.
+//   - CertFile: The PEM file of the client certificate presented to servers requiring mutual TLS.
+//   - KeyFile: The PEM file of the private key of the client certificate.
+//   - ServerName: Overrides the server name used to verify certificates, when connecting by IP.
+//   - Pins: The SPKI pins of each host, keyed by host name ("api.example.com" or
+//     "*.example.com"). A pin is the base64 SHA-256 hash of a certificate's public key, with
+//     or without the "sha256/" prefix (see SPKIPin). Connections to a pinned host fail with
+//     ErrPinMismatch unless a certificate of the verified chain matches one of its pins.
+//
+// # Example:
+//
+//	err := httpClient.SetTLSOptions(httpclient.TLSOptions{
+//		RootCAFiles: []string{"/etc/nexus/ca.pem"},
+//		CertFile:    "/etc/nexus/client.pem",
+//		KeyFile:     "/etc/nexus/client-key.pem",
+//	})
+//	if err != nil {
+//		log.Fatalf("Failed to configure TLS: %v", err)
+//	}
+type TLSOptions struct {
+	RootCAFiles        []string
+	RootCAPEM          []byte
+	ExcludeSystemRoots bool
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	Pins               map[string][]string
+}
+
+// IsZero reports whether the options leave the default TLS settings unchanged.
+func (options TLSOptions) IsZero() bool {
+	return len(options.RootCAFiles) == 0 && len(options.RootCAPEM) == 0 && !options.ExcludeSystemRoots &&
+		options.CertFile == "" && options.KeyFile == "" && options.ServerName == "" && len(options.Pins) == 0
+}
+
+// Config builds the tls.Config described by the options.
+//
+// # Returns:
+//   - *tls.Config: The TLS configuration.
+//   - error: An error if a file cannot be read, a PEM block cannot be parsed, or only one of
+//     CertFile and KeyFile is set.
+func (options TLSOptions) Config() (*tls.Config, error) {
+	config := &tls.Config{ServerName: options.ServerName}
+	if len(options.RootCAFiles) > 0 || len(options.RootCAPEM) > 0 || options.ExcludeSystemRoots {
+		pool := x509.NewCertPool()
+		if !options.ExcludeSystemRoots {
+			systemPool, err := x509.SystemCertPool()
+			if err == nil {
+				pool = systemPool
+			}
+		}
+		for _, file := range options.RootCAFiles {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("no certificate found in %s", file)
+			}
+		}
+		if len(options.RootCAPEM) > 0 && !pool.AppendCertsFromPEM(options.RootCAPEM) {
+			return nil, errors.New("no certificate found in RootCAPEM")
+		}
+		config.RootCAs = pool
+	}
+	if options.CertFile != "" || options.KeyFile != "" {
+		if options.CertFile == "" || options.KeyFile == "" {
+			return nil, errors.New("client certificate requires both CertFile and KeyFile")
+		}
+		certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	if len(options.Pins) > 0 {
+		pins := make(map[string]map[string]bool, len(options.Pins))
+		for host, hostPins := range options.Pins {
+			pins[strings.ToLower(host)] = make(map[string]bool, len(hostPins))
+			for _, pin := range hostPins {
+				pins[strings.ToLower(host)][strings.TrimPrefix(pin, "sha256/")] = true
+			}
+		}
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyPins(pins, state)
+		}
+	}
+	return config, nil
+}
+
+// ErrPinMismatch is returned when no certificate presented by a pinned host matches its pins.
+var ErrPinMismatch = errors.New("certificate pin mismatch")
+
+// SPKIPin returns the pin of a certificate: the base64 SHA-256 hash of its public key,
+// prefixed with "sha256/".
+//
+// # Example:
+//
+//	pin := httpclient.SPKIPin(resp.TLS.PeerCertificates[0])
+func SPKIPin(certificate *x509.Certificate) string {
+	sum := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// verifyPins checks the verified chain of a connection against the pins of its host.
+func verifyPins(pins map[string]map[string]bool, state tls.ConnectionState) error {
+	host := strings.ToLower(state.ServerName)
+	hostPins, ok := pins[host]
+	if !ok {
+		if dot := strings.Index(host, "."); dot >= 0 {
+			hostPins, ok = pins["*"+host[dot:]]
+		}
+	}
+	if !ok {
+		return nil
+	}
+	certificates := state.PeerCertificates
+	for _, chain := range state.VerifiedChains {
+		certificates = append(certificates, chain...)
+	}
+	for _, certificate := range certificates {
+		if hostPins[strings.TrimPrefix(SPKIPin(certificate), "sha256/")] {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w for %s", ErrPinMismatch, host)
+}
+
+// SetTLSOptions applies custom root CAs, a client certificate, and certificate pins to the
+// client's transport.
+//
+// # Parameters:
+//   - options: The TLS options (see TLSOptions).
+//
+// # Returns:
+//   - error: An error if the options cannot be loaded. The client is left unchanged then.
+func (httpClient *HTTPClient) SetTLSOptions(options TLSOptions) error {
+	config, err := options.Config()
+	if err != nil {
+		return err
+	}
+	transport, ok := httpClient.client.Transport.(*http.Transport)
+	if !ok {
+		return errors.New("client transport does not support TLS options")
+	}
+	transport.TLSClientConfig = config
+	transport.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,213 @@
+// Package idempotency guards non-idempotent task actions, such as purchases, against being
+// sent twice on the same day, e.g. when a task is retried after a crash recovery.
+package idempotency
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DayFormat is the layout of the day of a guard entry.
+const DayFormat = "2006-01-02"
+
+// Guard records the actions that succeeded and reports whether an action was already recorded.
+//
+// # Methods:
+//   - Seen(key string) (bool, error): Reports whether the action with the given key succeeded.
+//   - Record(key string, day time.Time) error: Records that the action succeeded on day.
+type Guard interface {
+	Seen(key string) (bool, error)
+	Record(key string, day time.Time) error
+}
+
+// Lister is implemented by the guards that can list their entries, so that they are included
+// in farm state exports (see handler.Manager.ExportState).
+//
+// # Methods:
+//   - Entries() map[string]string: Returns the day (in DayFormat) of every recorded action,
+//     keyed by action key.
+type Lister interface {
+	Entries() map[string]string
+}
+
+// Key returns the hex-encoded SHA-256 hash identifying an action of an account on a day.
+//
+// # Parameters:
+//   - account: The Telegram ID of the account.
+//   - task: The name of the task.
+//   - payload: The payload of the action, e.g. the JSON-encoded task payload.
+//   - day: The day of the action. Only its UTC date is used.
+//
+// # Returns:
+//   - string: The key of the action.
+func Key(account, task string, payload []byte, day time.Time) string {
+	hash := sha256.New()
+	for _, part := range [][]byte{[]byte(account), []byte(task), payload, []byte(day.UTC().Format(DayFormat))} {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// MemoryGuard is a Guard keeping its entries in memory. It protects against double sends
+// within one process only; use a FileGuard to survive restarts.
+type MemoryGuard struct {
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+// NewMemoryGuard returns an empty in-memory guard.
+func NewMemoryGuard() *MemoryGuard {
+	return &MemoryGuard{keys: make(map[string]string)}
+}
+
+// Seen reports whether the action with the given key was recorded.
+func (guard *MemoryGuard) Seen(key string) (bool, error) {
+	guard.mu.Lock()
+	defer guard.mu.Unlock()
+	_, ok := guard.keys[key]
+	return ok, nil
+}
+
+// Record records the action with the given key.
+func (guard *MemoryGuard) Record(key string, day time.Time) error {
+	guard.mu.Lock()
+	defer guard.mu.Unlock()
+	guard.keys[key] = day.UTC().Format(DayFormat)
+	return nil
+}
+
+// Entries returns the day of every recorded action, keyed by action key.
+func (guard *MemoryGuard) Entries() map[string]string {
+	guard.mu.Lock()
+	defer guard.mu.Unlock()
+	entries := make(map[string]string, len(guard.keys))
+	for key, day := range guard.keys {
+		entries[key] = day
+	}
+	return entries
+}
+
+// entry is a line of a FileGuard file.
+type entry struct {
+	Key string `json:"key"`
+	Day string `json:"day"`
+}
+
+// FileGuard is a Guard appending its entries to a file, one JSON object per line, so that
+// the actions that succeeded before a crash are not sent again after a restart.
+//
+// # Example:
+//
+//	guard, err := idempotency.OpenFileGuard("state/idempotency.jsonl", 2)
+//	if err != nil {
+//		log.Fatalf("Failed to open idempotency guard: %v", err)
+//	}
+//	handler.SetIdempotencyGuard(guard)
+type FileGuard struct {
+	memory *MemoryGuard
+	file   *os.File
+	mu     sync.Mutex
+}
+
+// OpenFileGuard opens (or creates) the guard file at path and loads its entries.
+//
+// Entries older than retainDays days are dropped and the file is rewritten without them, so
+// the file does not grow forever.
+//
+// # Parameters:
+//   - path: The path of the guard file. Parent directories are created if needed.
+//   - retainDays: How many days entries are kept. Values below 1 keep the current day only.
+//
+// # Returns:
+//   - *FileGuard: The guard.
+//   - error: An error if the file cannot be read or written.
+func OpenFileGuard(path string, retainDays int) (*FileGuard, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if retainDays < 1 {
+		retainDays = 1
+	}
+	oldest := time.Now().UTC().AddDate(0, 0, 1-retainDays).Format(DayFormat)
+	memory := NewMemoryGuard()
+	if file, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var line entry
+			if json.Unmarshal(scanner.Bytes(), &line) == nil && line.Day >= oldest {
+				memory.keys[line.Key] = line.Day
+			}
+		}
+		err = scanner.Err()
+		_ = file.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	temp := path + ".tmp"
+	file, err := os.OpenFile(temp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	encoder := json.NewEncoder(file)
+	for key, day := range memory.keys {
+		if err := encoder.Encode(entry{Key: key, Day: day}); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(temp, path); err != nil {
+		return nil, err
+	}
+	file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileGuard{memory: memory, file: file}, nil
+}
+
+// Seen reports whether the action with the given key was recorded.
+func (guard *FileGuard) Seen(key string) (bool, error) {
+	return guard.memory.Seen(key)
+}
+
+// Record appends the action with the given key to the file and syncs it to disk.
+func (guard *FileGuard) Record(key string, day time.Time) error {
+	guard.mu.Lock()
+	defer guard.mu.Unlock()
+	line, err := json.Marshal(entry{Key: key, Day: day.UTC().Format(DayFormat)})
+	if err != nil {
+		return err
+	}
+	if _, err := guard.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := guard.file.Sync(); err != nil {
+		return err
+	}
+	return guard.memory.Record(key, day)
+}
+
+// Entries returns the day of every recorded action, keyed by action key.
+func (guard *FileGuard) Entries() map[string]string {
+	return guard.memory.Entries()
+}
+
+// Close closes the guard file.
+func (guard *FileGuard) Close() error {
+	guard.mu.Lock()
+	defer guard.mu.Unlock()
+	return guard.file.Close()
+}
@@ -0,0 +1,63 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/storage"
+	"time"
+)
+
+// StoredGuard is a Guard keeping its entries in a shared storage backend, in
+// storage.BucketIdempotency, so that several farm instances and restarts see the same actions.
+//
+// # Example:
+//
+//	backend, err := storage.FromConfig(config.Storage)
+//	if err != nil {
+//		log.Fatalf("Failed to open storage: %v", err)
+//	}
+//	handler.SetIdempotencyGuard(idempotency.NewStoredGuard(backend))
+type StoredGuard struct {
+	backend storage.Storage
+}
+
+// NewStoredGuard returns a guard keeping its entries in backend.
+func NewStoredGuard(backend storage.Storage) *StoredGuard {
+	return &StoredGuard{backend: backend}
+}
+
+// Seen reports whether the action with the given key was recorded.
+func (guard *StoredGuard) Seen(key string) (bool, error) {
+	_, err := guard.backend.Get(context.Background(), storage.BucketIdempotency, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Record records the action with the given key.
+func (guard *StoredGuard) Record(key string, day time.Time) error {
+	data, err := json.Marshal(day.UTC().Format(DayFormat))
+	if err != nil {
+		return err
+	}
+	return guard.backend.Put(context.Background(), storage.BucketIdempotency, key, data)
+}
+
+// Entries returns the day of every recorded action, keyed by action key. Entries that cannot
+// be read are left out.
+func (guard *StoredGuard) Entries() map[string]string {
+	entries := make(map[string]string)
+	documents, err := guard.backend.List(context.Background(), storage.BucketIdempotency, "")
+	if err != nil {
+		return entries
+	}
+	for key, data := range documents {
+		var day string
+		if json.Unmarshal(data, &day) == nil {
+			entries[key] = day
+		}
+	}
+	return entries
+}
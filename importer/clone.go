@@ -0,0 +1,157 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/device"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"strings"
+	"time"
+)
+
+// CloneOptions configures how Clone derives accounts from a template.
+//
+// # Fields:
+//   - Count: How many accounts to create, from the first sessions. Zero means one per session.
+//   - Proxies: The proxies the accounts are spread over. Every account gets the country of the
+//     next proxy with a country, round-robin, so that the accounts follow the countries of the
+//     pool (see types.Account.Country). Empty keeps the country of the template.
+//   - LabelFormat: The fmt format of the labels of the accounts, given their 1-based index
+//     (e.g., "farm-%03d"). Empty keeps the labels of the sessions, and names the accounts
+//     without one after the label of the template.
+//   - CreatedAt: The creation date of the accounts, which starts their warm-up (see
+//     types.WarmUpConfig). Zero means now.
+type CloneOptions struct {
+	Count       int
+	Proxies     []types.Proxy
+	LabelFormat string
+	CreatedAt   time.Time
+}
+
+// Clone creates accounts from a template account and a list of sessions, such as the accounts
+// returned by FromTelethonDir or FromTData, instead of copying the template by hand in
+// accounts.json.
+//
+// Every account is a copy of the template with the Telegram data, the game data, and the
+// label of its session, a device profile generated from its Telegram ID (see device.Generate),
+// and the country of its proxy (see CloneOptions). The device and the wallet of the template
+// are not copied: games link accounts sharing either.
+//
+// # Parameters:
+//   - template: The account copied, typically an entry of accounts.json with the notes,
+//     cohort, session TTL, and game-specific fields shared by the new accounts.
+//   - sessions: The sessions of the new accounts.
+//   - options: The options of the copies.
+//
+// # Returns:
+//   - []types.Account: The accounts, in the order of the sessions.
+//   - error: An error if there are fewer sessions than options.Count, a session has neither a
+//     string session nor game data, or two sessions belong to the same Telegram account.
+//
+// # Example:
+//
+//	sessions, err := importer.FromTelethonDir("sessions")
+//	if err != nil {
+//		log.Printf("Some sessions were skipped: %v", err)
+//	}
+//	proxies, err := proxypool.LoadProxies("proxies.txt")
+//	if err != nil {
+//		log.Fatalf("Failed to load proxies: %v", err)
+//	}
+//	accounts, err := importer.Clone(template, sessions, importer.CloneOptions{Proxies: proxies, LabelFormat: "farm-%03d"})
+//	if err != nil {
+//		log.Fatalf("Failed to clone accounts: %v", err)
+//	}
+func Clone(template types.Account, sessions []types.Account, options CloneOptions) ([]types.Account, error) {
+	count := options.Count
+	switch {
+	case count < 0:
+		return nil, fmt.Errorf("invalid account count %d", count)
+	case count == 0:
+		count = len(sessions)
+	case count > len(sessions):
+		return nil, fmt.Errorf("%d accounts requested but only %d sessions given", count, len(sessions))
+	}
+	createdAt := options.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC().Truncate(time.Second)
+	}
+	var countries []string
+	for _, proxy := range options.Proxies {
+		if proxy.Country != "" {
+			countries = append(countries, strings.ToUpper(proxy.Country))
+		}
+	}
+
+	accounts := make([]types.Account, 0, count)
+	seen := make(map[string]int, count)
+	var errs []error
+	for i, session := range sessions[:count] {
+		account := cloneAccount(template)
+		account.TelegramData = session.TelegramData
+		account.GameData = session.GameData
+		if account.TelegramId == "" {
+			account.TelegramId = TelegramIdFromGameData(account.GameData)
+		}
+		account.Label = cloneLabel(template, session, options.LabelFormat, i)
+		if account.TdataStringSession == "" && account.GameData == "" {
+			errs = append(errs, fmt.Errorf("session %d (%s): no string session or game data", i+1, account.Label))
+			continue
+		}
+		if account.TelegramId != "" {
+			if first, ok := seen[account.TelegramId]; ok {
+				errs = append(errs, fmt.Errorf("session %d (%s): Telegram account %s already used by session %d",
+					i+1, account.Label, account.TelegramId, first))
+				continue
+			}
+			seen[account.TelegramId] = i + 1
+		}
+		seed := account.TelegramId
+		if seed == "" {
+			seed = account.Label
+		}
+		profile := device.Generate(seed)
+		account.Device = &profile
+		if len(countries) > 0 {
+			account.Country = countries[i%len(countries)]
+		}
+		account.CreatedAt = createdAt
+		accounts = append(accounts, account)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return accounts, nil
+}
+
+// cloneAccount returns a copy of template with its own Enabled and Extra, without its device
+// and wallet.
+func cloneAccount(template types.Account) types.Account {
+	account := template
+	account.Device = nil
+	account.Wallet = nil
+	if template.Enabled != nil {
+		enabled := *template.Enabled
+		account.Enabled = &enabled
+	}
+	if template.Extra != nil {
+		account.Extra = make(map[string]interface{}, len(template.Extra))
+		for name, value := range template.Extra {
+			account.Extra[name] = value
+		}
+	}
+	return account
+}
+
+// cloneLabel returns the label of the index-th account cloned from template.
+func cloneLabel(template, session types.Account, format string, index int) string {
+	switch {
+	case format != "":
+		return fmt.Sprintf(format, index+1)
+	case session.Label != "":
+		return session.Label
+	case template.Label != "":
+		return fmt.Sprintf("%s-%03d", template.Label, index+1)
+	}
+	return fmt.Sprintf("account-%03d", index+1)
+}
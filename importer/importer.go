@@ -0,0 +1,239 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// csvColumns maps the accepted (normalized) CSV header names to account fields.
+var csvColumns = map[string]string{
+	"game_data":            "game_data",
+	"gamedata":             "game_data",
+	"init_data":            "game_data",
+	"initdata":             "game_data",
+	"query_id":             "game_data",
+	"query":                "game_data",
+	"tdata_string_session": "session",
+	"string_session":       "session",
+	"session":              "session",
+	"app_id":               "app_id",
+	"api_id":               "app_id",
+	"app_hash":             "app_hash",
+	"api_hash":             "app_hash",
+	"telegram_id":          "telegram_id",
+	"user_id":              "telegram_id",
+	"id":                   "telegram_id",
+	"label":                "label",
+	"name":                 "label",
+	"phone":                "label",
+	"notes":                "notes",
+	"enabled":              "enabled",
+}
+
+// FromCSV converts a CSV export into accounts.
+//
+// The first row must be a header. Column names are matched case-insensitively, with '-'
+// and ' ' treated as '_', and common aliases used by other farm bots are accepted
+// (e.g., "query_id" or "init_data" for the game data, "api_id"/"api_hash" for the app
+// credentials, and "phone" or "name" for the label). Unknown columns are ignored.
+//
+// # Parameters:
+//   - reader: The CSV data.
+//
+// # Returns:
+//   - []types.Account: The imported accounts.
+//   - error: An error if the CSV is malformed or no known column is present.
+//
+// # Example accounts.csv:
+//
+//	telegram_id,api_id,api_hash,string_session,query_id,label
+//	987654321,123456,abcdef123456,1BVtsOKABu...,query_id=AAH...,main-01
+func FromCSV(reader io.Reader) ([]types.Account, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	fields := make([]string, len(header))
+	known := 0
+	for i, name := range header {
+		normalized := strings.NewReplacer("-", "_", " ", "_").Replace(strings.ToLower(strings.TrimSpace(name)))
+		fields[i] = csvColumns[strings.TrimPrefix(normalized, "\ufeff")]
+		if fields[i] != "" {
+			known++
+		}
+	}
+	if known == 0 {
+		return nil, errors.New("CSV header contains no known account column")
+	}
+	var accounts []types.Account
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var account types.Account
+		for i, value := range record {
+			if i >= len(fields) {
+				break
+			}
+			value = strings.TrimSpace(value)
+			switch fields[i] {
+			case "game_data":
+				account.GameData = value
+			case "session":
+				account.TdataStringSession = value
+			case "app_id":
+				account.AppId = value
+			case "app_hash":
+				account.AppHash = value
+			case "telegram_id":
+				account.TelegramId = value
+			case "label":
+				account.Label = value
+			case "notes":
+				account.Notes = value
+			case "enabled":
+				if value != "" {
+					enabled, err := strconv.ParseBool(value)
+					if err != nil {
+						return nil, fmt.Errorf("invalid enabled value %q", value)
+					}
+					account.Enabled = &enabled
+				}
+			}
+		}
+		if account.TelegramId == "" {
+			account.TelegramId = TelegramIdFromGameData(account.GameData)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// FromLines converts a list of Telegram WebApp init data strings, one per line, into accounts.
+//
+// This is the "data.txt"/"query.txt" format used by most Python farm bots. The Telegram ID
+// of each account is extracted from the "user" parameter of its init data. Empty lines and
+// lines starting with '#' are ignored.
+//
+// # Example data.txt:
+//
+//	query_id=AAH...&user=%7B%22id%22%3A987654321%2C...%7D&auth_date=1732000000&hash=...
+//	user=%7B%22id%22%3A123456789%2C...%7D&chat_instance=...&auth_date=1732000000&hash=...
+func FromLines(reader io.Reader) ([]types.Account, error) {
+	var accounts []types.Account
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		account := types.Account{GameData: line}
+		account.TelegramId = TelegramIdFromGameData(line)
+		accounts = append(accounts, account)
+	}
+	return accounts, scanner.Err()
+}
+
+// telethonMetadata is the JSON sidecar shipped next to Telethon .session files by account sellers.
+type telethonMetadata struct {
+	AppId         json.Number `json:"app_id"`
+	ApiId         json.Number `json:"api_id"`
+	AppHash       string      `json:"app_hash"`
+	ApiHash       string      `json:"api_hash"`
+	Id            json.Number `json:"id"`
+	UserId        json.Number `json:"user_id"`
+	Phone         string      `json:"phone"`
+	StringSession string      `json:"string_session"`
+	Session       string      `json:"session"`
+}
+
+// FromTelethonDir converts a folder of Telethon sessions into accounts.
+//
+// Every "<name>.session" file must come with a "<name>.json" metadata file, as delivered by
+// most account sellers, providing the app credentials, the user ID, and the string session
+// ("string_session" or "session"). Telethon .session files are SQLite databases and are not
+// read directly.
+//
+// # Returns:
+//   - []types.Account: The accounts that could be imported.
+//   - error: A joined error describing every session that was skipped, or nil if none was.
+//     The returned accounts are valid even when the error is not nil.
+func FromTelethonDir(dir string) ([]types.Account, error) {
+	sessions, err := filepath.Glob(filepath.Join(dir, "*.session"))
+	if err != nil {
+		return nil, err
+	}
+	var accounts []types.Account
+	var skipped []error
+	for _, sessionPath := range sessions {
+		name := strings.TrimSuffix(filepath.Base(sessionPath), ".session")
+		data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("%s: missing metadata file: %w", name, err))
+			continue
+		}
+		var metadata telethonMetadata
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			skipped = append(skipped, fmt.Errorf("%s: invalid metadata file: %w", name, err))
+			continue
+		}
+		session := firstNonEmpty(metadata.StringSession, metadata.Session)
+		if session == "" {
+			skipped = append(skipped, fmt.Errorf("%s: metadata contains no string session", name))
+			continue
+		}
+		account := types.Account{
+			TelegramData: types.TelegramData{
+				TdataStringSession: session,
+				AppId:              firstNonEmpty(metadata.AppId.String(), metadata.ApiId.String()),
+				AppHash:            firstNonEmpty(metadata.AppHash, metadata.ApiHash),
+				TelegramId:         firstNonEmpty(metadata.Id.String(), metadata.UserId.String()),
+			},
+			Label: firstNonEmpty(metadata.Phone, name),
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, errors.Join(skipped...)
+}
+
+// TelegramIdFromGameData extracts the Telegram user ID from WebApp init data, or returns "".
+func TelegramIdFromGameData(gameData string) string {
+	values, err := url.ParseQuery(strings.TrimPrefix(gameData, "#tgWebAppData="))
+	if err != nil {
+		return ""
+	}
+	var user struct {
+		Id json.Number `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(values.Get("user")), &user); err != nil {
+		return ""
+	}
+	return user.Id.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,371 @@
+package importer
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Telegram Desktop application credentials, used by sessions extracted from tdata folders.
+const (
+	TelegramDesktopAppId   = "2040"
+	TelegramDesktopAppHash = "b18441a1ff607e10a989891a5462e627"
+)
+
+// dbiMtpAuthorization is the block ID of the MTProto authorization in a tdata account file.
+const dbiMtpAuthorization = 0x4B
+
+// telegramDataCenters maps Telegram production data center IDs to their addresses.
+var telegramDataCenters = map[int32]string{
+	1: "149.154.175.53",
+	2: "149.154.167.51",
+	3: "149.154.175.100",
+	4: "149.154.167.91",
+	5: "91.108.56.130",
+}
+
+// FromTData extracts the sessions of a Telegram Desktop tdata folder into accounts.
+//
+// Every account logged into the Telegram Desktop installation is returned, with
+// TdataStringSession set to a Telethon-compatible string session built from the account's
+// main data center authorization key, TelegramId set to the account's user ID, and the
+// official Telegram Desktop app credentials.
+//
+// # Parameters:
+//   - dir: The path of the tdata folder (the folder containing "key_datas").
+//   - passcode: The local passcode of the installation, or "" if none is set.
+//
+// # Returns:
+//   - []types.Account: The accounts found in the folder.
+//   - error: An error if the folder cannot be read or decrypted (e.g., wrong passcode).
+//
+// # Example:
+//
+//	accounts, err := importer.FromTData("/path/to/tdata", "")
+//	if err != nil {
+//		log.Fatalf("Failed to import tdata: %v", err)
+//	}
+//	fmt.Println(accounts[0].TelegramId)
+func FromTData(dir string, passcode string) ([]types.Account, error) {
+	keyData, err := readTDataFile(dir, "key_data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key data: %w", err)
+	}
+	reader := &qtReader{data: keyData}
+	salt := reader.byteArray()
+	keyEncrypted := reader.byteArray()
+	infoEncrypted := reader.byteArray()
+	if reader.err != nil {
+		return nil, fmt.Errorf("invalid key data: %w", reader.err)
+	}
+
+	passcodeKey := createLocalKey([]byte(passcode), salt)
+	keyInner, err := decryptLocal(keyEncrypted, passcodeKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt local key (wrong passcode?): %w", err)
+	}
+	if len(keyInner) < 256 {
+		return nil, errors.New("invalid local key length")
+	}
+	localKey := keyInner[:256]
+
+	info, err := decryptLocal(infoEncrypted, localKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt accounts info: %w", err)
+	}
+	reader = &qtReader{data: info}
+	count := reader.int32()
+	var indices []int32
+	for i := int32(0); i < count && reader.err == nil; i++ {
+		indices = append(indices, reader.int32())
+	}
+	if reader.err != nil {
+		return nil, fmt.Errorf("invalid accounts info: %w", reader.err)
+	}
+
+	var accounts []types.Account
+	for _, index := range indices {
+		account, err := readTDataAccount(dir, index, localKey)
+		if err != nil {
+			return accounts, fmt.Errorf("failed to read account %d: %w", index, err)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// readTDataAccount reads the MTProto authorization of the account at index.
+func readTDataAccount(dir string, index int32, localKey []byte) (types.Account, error) {
+	dataName := "data"
+	if index > 0 {
+		dataName += "#" + strconv.Itoa(int(index)+1)
+	}
+	fileData, err := readTDataFile(dir, toFilePart(dataName))
+	if err != nil {
+		return types.Account{}, err
+	}
+	encrypted := (&qtReader{data: fileData}).byteArray()
+	decrypted, err := decryptLocal(encrypted, localKey)
+	if err != nil {
+		return types.Account{}, err
+	}
+	reader := &qtReader{data: decrypted}
+	if blockId := reader.int32(); blockId != dbiMtpAuthorization {
+		return types.Account{}, fmt.Errorf("unexpected block ID 0x%X", blockId)
+	}
+	reader = &qtReader{data: reader.byteArray()}
+	var userId uint64
+	legacyUserId, mainDcId := reader.int32(), reader.int32()
+	if legacyUserId == -1 && mainDcId == -1 {
+		userId = reader.uint64()
+		mainDcId = reader.int32()
+	} else {
+		userId = uint64(uint32(legacyUserId))
+	}
+	var authKey []byte
+	keyCount := reader.int32()
+	for i := int32(0); i < keyCount && reader.err == nil; i++ {
+		dcId := reader.int32()
+		key := reader.raw(256)
+		if dcId == mainDcId {
+			authKey = key
+		}
+	}
+	if reader.err != nil {
+		return types.Account{}, fmt.Errorf("invalid authorization data: %w", reader.err)
+	}
+	if authKey == nil {
+		return types.Account{}, fmt.Errorf("no authorization key for main DC %d", mainDcId)
+	}
+	session, err := telethonStringSession(mainDcId, authKey)
+	if err != nil {
+		return types.Account{}, err
+	}
+	return types.Account{
+		TelegramData: types.TelegramData{
+			TdataStringSession: session,
+			AppId:              TelegramDesktopAppId,
+			AppHash:            TelegramDesktopAppHash,
+			TelegramId:         strconv.FormatUint(userId, 10),
+		},
+		Label: "tdata-" + dataName,
+	}, nil
+}
+
+// telethonStringSession encodes an authorization key as a Telethon string session.
+func telethonStringSession(dcId int32, authKey []byte) (string, error) {
+	address, ok := telegramDataCenters[dcId]
+	if !ok {
+		return "", fmt.Errorf("unknown data center %d", dcId)
+	}
+	var buffer bytes.Buffer
+	buffer.WriteByte(byte(dcId))
+	buffer.Write(net.ParseIP(address).To4())
+	_ = binary.Write(&buffer, binary.BigEndian, uint16(443))
+	buffer.Write(authKey)
+	return "1" + base64.URLEncoding.EncodeToString(buffer.Bytes()), nil
+}
+
+// readTDataFile reads and verifies a "TDF$" file, trying the suffixes used by Telegram Desktop.
+// When none is valid, the error is that of the last file found.
+func readTDataFile(dir, name string) ([]byte, error) {
+	var lastErr error = os.ErrNotExist
+	for _, suffix := range []string{"s", "1", "0"} {
+		content, err := os.ReadFile(filepath.Join(dir, name+suffix))
+		if err != nil {
+			// A missing fallback file must not hide why an existing one was rejected.
+			if errors.Is(lastErr, os.ErrNotExist) {
+				lastErr = err
+			}
+			continue
+		}
+		if len(content) < 8+16 || string(content[:4]) != "TDF$" {
+			lastErr = errors.New("invalid TDF header")
+			continue
+		}
+		version := content[4:8]
+		data := content[8 : len(content)-16]
+		checksum := md5.New()
+		checksum.Write(data)
+		var size [4]byte
+		binary.LittleEndian.PutUint32(size[:], uint32(len(data)))
+		checksum.Write(size[:])
+		checksum.Write(version)
+		checksum.Write([]byte("TDF$"))
+		if !bytes.Equal(checksum.Sum(nil), content[len(content)-16:]) {
+			lastErr = errors.New("TDF checksum mismatch")
+			continue
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("%s: %w", name, lastErr)
+}
+
+// toFilePart returns the tdata file name derived from a data name.
+func toFilePart(dataName string) string {
+	sum := md5.Sum([]byte(dataName))
+	const digits = "0123456789ABCDEF"
+	result := make([]byte, 0, 16)
+	for _, b := range sum[:8] {
+		result = append(result, digits[b&0x0F], digits[b>>4])
+	}
+	return string(result)
+}
+
+// createLocalKey derives the key protecting the local key from the passcode.
+func createLocalKey(passcode, salt []byte) []byte {
+	hash := sha512.New()
+	hash.Write(salt)
+	hash.Write(passcode)
+	hash.Write(salt)
+	iterations := 1
+	if len(passcode) > 0 {
+		iterations = 100000
+	}
+	return pbkdf2SHA512(hash.Sum(nil), salt, iterations, 256)
+}
+
+// decryptLocal decrypts data encrypted by Telegram Desktop with a local key.
+func decryptLocal(encrypted, key []byte) ([]byte, error) {
+	if len(encrypted) <= 16 || len(encrypted)%16 != 0 {
+		return nil, fmt.Errorf("invalid encrypted size %d", len(encrypted))
+	}
+	msgKey := encrypted[:16]
+	aesKey, aesIV := prepareAESOldMTP(key, msgKey)
+	decrypted, err := aesIGEDecrypt(encrypted[16:], aesKey, aesIV)
+	if err != nil {
+		return nil, err
+	}
+	checksum := sha1.Sum(decrypted)
+	if !bytes.Equal(checksum[:16], msgKey) {
+		return nil, errors.New("checksum mismatch")
+	}
+	dataLen := binary.LittleEndian.Uint32(decrypted[:4])
+	if int(dataLen) > len(decrypted) || int(dataLen) <= len(decrypted)-16 || dataLen < 4 {
+		return nil, fmt.Errorf("invalid decrypted length %d", dataLen)
+	}
+	return decrypted[4:dataLen], nil
+}
+
+// prepareAESOldMTP derives the AES key and IV for local decryption (MTProto 1.0 scheme).
+func prepareAESOldMTP(authKey, msgKey []byte) ([]byte, []byte) {
+	const x = 8
+	concat := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	sha1A := sha1.Sum(concat(msgKey, authKey[x:x+32]))
+	sha1B := sha1.Sum(concat(authKey[32+x:48+x], msgKey, authKey[48+x:64+x]))
+	sha1C := sha1.Sum(concat(authKey[64+x:96+x], msgKey))
+	sha1D := sha1.Sum(concat(msgKey, authKey[96+x:128+x]))
+	aesKey := concat(sha1A[:8], sha1B[8:20], sha1C[4:16])
+	aesIV := concat(sha1A[8:20], sha1B[:8], sha1C[16:20], sha1D[:8])
+	return aesKey, aesIV
+}
+
+// aesIGEDecrypt decrypts data with AES-256 in Infinite Garble Extension mode.
+func aesIGEDecrypt(data, key, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	previousCipher := append([]byte(nil), iv[:16]...)
+	previousPlain := append([]byte(nil), iv[16:32]...)
+	plain := make([]byte, len(data))
+	buffer := make([]byte, 16)
+	for offset := 0; offset < len(data); offset += 16 {
+		cipherBlock := data[offset : offset+16]
+		for i := 0; i < 16; i++ {
+			buffer[i] = cipherBlock[i] ^ previousPlain[i]
+		}
+		block.Decrypt(buffer, buffer)
+		for i := 0; i < 16; i++ {
+			plain[offset+i] = buffer[i] ^ previousCipher[i]
+		}
+		previousCipher = cipherBlock
+		previousPlain = plain[offset : offset+16]
+	}
+	return plain, nil
+}
+
+// pbkdf2SHA512 implements PBKDF2 (RFC 8018) with HMAC-SHA512.
+func pbkdf2SHA512(password, salt []byte, iterations, keyLength int) []byte {
+	prf := hmac.New(sha512.New, password)
+	hashLength := prf.Size()
+	blocks := (keyLength + hashLength - 1) / hashLength
+	derived := make([]byte, 0, blocks*hashLength)
+	var counter [4]byte
+	u := make([]byte, hashLength)
+	for blockIndex := 1; blockIndex <= blocks; blockIndex++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(counter[:], uint32(blockIndex))
+		prf.Write(counter[:])
+		derived = prf.Sum(derived)
+		t := derived[len(derived)-hashLength:]
+		copy(u, t)
+		for n := 2; n <= iterations; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for i := range u {
+				t[i] ^= u[i]
+			}
+		}
+	}
+	return derived[:keyLength]
+}
+
+// qtReader reads big-endian values serialized with Qt's QDataStream.
+type qtReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (reader *qtReader) raw(n int) []byte {
+	if reader.err != nil {
+		return nil
+	}
+	if n < 0 || reader.offset+n > len(reader.data) {
+		reader.err = errors.New("unexpected end of data")
+		return nil
+	}
+	value := reader.data[reader.offset : reader.offset+n]
+	reader.offset += n
+	return value
+}
+
+func (reader *qtReader) int32() int32 {
+	value := reader.raw(4)
+	if value == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(value))
+}
+
+func (reader *qtReader) uint64() uint64 {
+	value := reader.raw(8)
+	if value == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(value)
+}
+
+// byteArray reads a QByteArray (a 32-bit length followed by the bytes; 0xFFFFFFFF is null).
+func (reader *qtReader) byteArray() []byte {
+	length := reader.int32()
+	if reader.err != nil || length == -1 {
+		return nil
+	}
+	return reader.raw(int(length))
+}
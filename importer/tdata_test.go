@@ -0,0 +1,398 @@
+package importer
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPBKDF2SHA512(t *testing.T) {
+	tests := []struct {
+		password   string
+		salt       string
+		iterations int
+		length     int
+		want       string
+	}{
+		{"password", "salt", 1, 64, "867f70cf1ade02cff3752599a3a53dc4af34c7a669815ae5d513554e1c8cf252c02d470a285a0501bad999bfe943c08f050235d7d68b1da55e63f73b60a57fce"},
+		{"password", "salt", 2, 64, "e1d9c16aa681708a45f5c7c4e215ceb66e011a2e9f0040713f18aefdb866d53cf76cab2868a39b9f7840edce4fef5a82be67335c77a6068e04112754f27ccf4e"},
+		{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, 80, "8c0511f4c6e597c6ac6315d8f0362e225f3c501495ba23b868c005174dc4ee71115b59f9e60cd9532fa33e0f75aefe30225c583a186cd82bd4daea9724a3d3b804f75bdd41494fa324cab24bcc680fb3"},
+	}
+	for _, test := range tests {
+		got := hex.EncodeToString(pbkdf2SHA512([]byte(test.password), []byte(test.salt), test.iterations, test.length))
+		if got != test.want {
+			t.Errorf("pbkdf2SHA512(%q, %q, %d) = %s, want %s", test.password, test.salt, test.iterations, got, test.want)
+		}
+	}
+}
+
+func TestToFilePart(t *testing.T) {
+	tests := []struct {
+		dataName string
+		want     string
+	}{
+		{"data", "D877F783D5D3EF8C"},
+		{"data#2", toFilePartReference("data#2")},
+	}
+	for _, test := range tests {
+		if got := toFilePart(test.dataName); got != test.want {
+			t.Errorf("toFilePart(%q) = %s, want %s", test.dataName, got, test.want)
+		}
+	}
+}
+
+// toFilePartReference is toFilePart written after the Telegram Desktop sources: the hex digits
+// of the first 8 bytes of the MD5 sum, low nibble first.
+func toFilePartReference(dataName string) string {
+	sum := md5.Sum([]byte(dataName))
+	var builder strings.Builder
+	for _, b := range sum[:8] {
+		builder.WriteString(strings.ToUpper(hex.EncodeToString([]byte{b<<4 | b>>4})))
+	}
+	return builder.String()
+}
+
+func TestQtReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		read    func(reader *qtReader) interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"int32", []byte{0xFF, 0xFF, 0xFF, 0xFE}, func(r *qtReader) interface{} { return r.int32() }, int32(-2), false},
+		{"uint64", []byte{0, 0, 0, 0, 0, 0, 1, 0}, func(r *qtReader) interface{} { return r.uint64() }, uint64(256), false},
+		{"byte array", []byte{0, 0, 0, 2, 'h', 'i'}, func(r *qtReader) interface{} { return string(r.byteArray()) }, "hi", false},
+		{"null byte array", []byte{0xFF, 0xFF, 0xFF, 0xFF}, func(r *qtReader) interface{} { return r.byteArray() == nil }, true, false},
+		{"truncated int32", []byte{0, 0}, func(r *qtReader) interface{} { return r.int32() }, int32(0), true},
+		{"truncated byte array", []byte{0, 0, 0, 5, 'h'}, func(r *qtReader) interface{} { return r.byteArray() == nil }, true, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := &qtReader{data: test.data}
+			if got := test.read(reader); got != test.want {
+				t.Errorf("read %v, want %v", got, test.want)
+			}
+			if (reader.err != nil) != test.wantErr {
+				t.Errorf("error = %v, want error %t", reader.err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestTelethonStringSession(t *testing.T) {
+	authKey := bytes.Repeat([]byte{0xAB}, 256)
+	session, err := telethonStringSession(2, authKey)
+	if err != nil {
+		t.Fatalf("telethonStringSession failed: %v", err)
+	}
+	if !strings.HasPrefix(session, "1") {
+		t.Fatalf("session %q does not start with the version 1", session)
+	}
+	decoded, err := base64.URLEncoding.DecodeString(session[1:])
+	if err != nil {
+		t.Fatalf("session is not base64: %v", err)
+	}
+	want := append([]byte{2, 149, 154, 167, 51, 0x01, 0xBB}, authKey...)
+	if !bytes.Equal(decoded, want) {
+		t.Errorf("session decodes to %x, want %x", decoded, want)
+	}
+	if _, err := telethonStringSession(9, authKey); err == nil {
+		t.Errorf("telethonStringSession succeeded for an unknown data center")
+	}
+}
+
+func TestDecryptLocal(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 256)
+	encrypted := encryptLocal([]byte("payload"), key)
+	tests := []struct {
+		name      string
+		encrypted []byte
+		key       []byte
+		want      string
+		wantErr   bool
+	}{
+		{"valid", encrypted, key, "payload", false},
+		{"wrong key", encrypted, bytes.Repeat([]byte{8}, 256), "", true},
+		{"too short", encrypted[:16], key, "", true},
+		{"unaligned", encrypted[:len(encrypted)-1], key, "", true},
+		{"tampered", append(append([]byte(nil), encrypted[:len(encrypted)-1]...), encrypted[len(encrypted)-1]^1), key, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := decryptLocal(test.encrypted, test.key)
+			if (err != nil) != test.wantErr || string(got) != test.want {
+				t.Errorf("decryptLocal = %q, %v, want %q, error %t", got, err, test.want, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadTDataFile(t *testing.T) {
+	valid := tdfFile([]byte("content"))
+	corrupted := append([]byte(nil), valid...)
+	corrupted[10] ^= 1
+	tests := []struct {
+		name    string
+		files   map[string][]byte
+		want    string
+		wantErr string
+	}{
+		{"s suffix", map[string][]byte{"key_datas": valid}, "content", ""},
+		{"fallback suffix", map[string][]byte{"key_datas": corrupted, "key_data1": valid}, "content", ""},
+		{"checksum mismatch", map[string][]byte{"key_datas": corrupted}, "", "checksum mismatch"},
+		{"invalid header", map[string][]byte{"key_datas": append([]byte("XXXX"), valid[4:]...)}, "", "invalid TDF header"},
+		{"missing", nil, "", "no such file"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range test.files {
+				writeFile(t, filepath.Join(dir, name), content)
+			}
+			got, err := readTDataFile(dir, "key_data")
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("readTDataFile error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != test.want {
+				t.Errorf("readTDataFile = %q, %v, want %q", got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestFromTData(t *testing.T) {
+	tests := []struct {
+		name       string
+		passcode   string
+		opened     string
+		accounts   []tdataAccount
+		wantIds    []string
+		wantLabels []string
+		wantErr    string
+	}{
+		{
+			name:       "legacy user ID",
+			accounts:   []tdataAccount{{index: 0, userId: 123456789, legacy: true, mainDc: 2}},
+			wantIds:    []string{"123456789"},
+			wantLabels: []string{"tdata-data"},
+		},
+		{
+			name: "several accounts",
+			accounts: []tdataAccount{
+				{index: 0, userId: 5000000001, mainDc: 4},
+				{index: 1, userId: 42, mainDc: 1},
+			},
+			wantIds:    []string{"5000000001", "42"},
+			wantLabels: []string{"tdata-data", "tdata-data#2"},
+		},
+		{
+			name:     "passcode",
+			passcode: "secret",
+			opened:   "secret",
+			accounts: []tdataAccount{{index: 0, userId: 7, mainDc: 5}},
+			wantIds:  []string{"7"},
+		},
+		{
+			name:     "wrong passcode",
+			passcode: "secret",
+			opened:   "guess",
+			accounts: []tdataAccount{{index: 0, userId: 7, mainDc: 5}},
+			wantErr:  "wrong passcode",
+		},
+		{
+			name:     "no key of the main data center",
+			accounts: []tdataAccount{{index: 0, userId: 7, mainDc: 3, keyDc: 1}},
+			wantErr:  "no authorization key for main DC 3",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTData(t, dir, test.passcode, test.accounts)
+			opened := test.opened
+			if opened == "" {
+				opened = test.passcode
+			}
+			accounts, err := FromTData(dir, opened)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("FromTData error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromTData failed: %v", err)
+			}
+			if len(accounts) != len(test.wantIds) {
+				t.Fatalf("FromTData returned %d accounts, want %d", len(accounts), len(test.wantIds))
+			}
+			for i, account := range accounts {
+				if account.TelegramId != test.wantIds[i] {
+					t.Errorf("account %d has ID %s, want %s", i, account.TelegramId, test.wantIds[i])
+				}
+				if test.wantLabels != nil && account.Label != test.wantLabels[i] {
+					t.Errorf("account %d has label %s, want %s", i, account.Label, test.wantLabels[i])
+				}
+				if account.AppId != TelegramDesktopAppId || account.AppHash != TelegramDesktopAppHash {
+					t.Errorf("account %d has app %s/%s, want the Telegram Desktop app", i, account.AppId, account.AppHash)
+				}
+				want, _ := telethonStringSession(test.accounts[i].mainDc, authKeyOf(test.accounts[i].mainDc))
+				if account.TdataStringSession != want {
+					t.Errorf("account %d has session %s, want the session of its main data center", i, account.TdataStringSession)
+				}
+			}
+		})
+	}
+}
+
+// tdataAccount is an account written by writeTData.
+//
+// # Fields:
+//   - index: The index of the account in the installation.
+//   - userId: The Telegram ID of the account.
+//   - legacy: Whether the user ID is written in the 32-bit format of older versions.
+//   - mainDc: The main data center of the account.
+//   - keyDc: The data center of the only authorization key written. Zero writes keys for
+//     mainDc and another data center.
+type tdataAccount struct {
+	index  int32
+	userId uint64
+	legacy bool
+	mainDc int32
+	keyDc  int32
+}
+
+// authKeyOf returns the authorization key writeTData writes for a data center.
+func authKeyOf(dc int32) []byte {
+	return bytes.Repeat([]byte{byte(dc)}, 256)
+}
+
+// writeTData writes a tdata folder with the accounts, protected by passcode.
+func writeTData(t *testing.T, dir, passcode string, accounts []tdataAccount) {
+	t.Helper()
+	salt := bytes.Repeat([]byte{0x5A}, 32)
+	localKey := bytes.Repeat([]byte{0x33}, 256)
+	var info qtWriter
+	info.int32(int32(len(accounts)))
+	for _, account := range accounts {
+		info.int32(account.index)
+	}
+	var keyData qtWriter
+	keyData.byteArray(salt)
+	keyData.byteArray(encryptLocal(localKey, createLocalKey([]byte(passcode), salt)))
+	keyData.byteArray(encryptLocal(info.Bytes(), localKey))
+	writeFile(t, filepath.Join(dir, "key_datas"), tdfFile(keyData.Bytes()))
+
+	for _, account := range accounts {
+		var authorization qtWriter
+		if account.legacy {
+			authorization.int32(int32(account.userId))
+			authorization.int32(account.mainDc)
+		} else {
+			authorization.int32(-1)
+			authorization.int32(-1)
+			authorization.uint64(account.userId)
+			authorization.int32(account.mainDc)
+		}
+		keys := []int32{account.keyDc}
+		if account.keyDc == 0 {
+			keys = []int32{account.mainDc%5 + 1, account.mainDc}
+		}
+		authorization.int32(int32(len(keys)))
+		for _, dc := range keys {
+			authorization.int32(dc)
+			authorization.Write(authKeyOf(dc))
+		}
+		var block qtWriter
+		block.int32(dbiMtpAuthorization)
+		block.byteArray(authorization.Bytes())
+		var file qtWriter
+		file.byteArray(encryptLocal(block.Bytes(), localKey))
+		dataName := "data"
+		if account.index > 0 {
+			dataName = "data#" + string(rune('1'+account.index))
+		}
+		writeFile(t, filepath.Join(dir, toFilePart(dataName)+"s"), tdfFile(file.Bytes()))
+	}
+}
+
+// qtWriter writes big-endian values like Qt's QDataStream, the format qtReader reads.
+type qtWriter struct {
+	bytes.Buffer
+}
+
+func (writer *qtWriter) int32(value int32) {
+	_ = binary.Write(writer, binary.BigEndian, value)
+}
+
+func (writer *qtWriter) uint64(value uint64) {
+	_ = binary.Write(writer, binary.BigEndian, value)
+}
+
+func (writer *qtWriter) byteArray(value []byte) {
+	writer.int32(int32(len(value)))
+	writer.Write(value)
+}
+
+// tdfFile wraps data into a "TDF$" file with its checksum.
+func tdfFile(data []byte) []byte {
+	version := []byte{1, 2, 3, 4}
+	checksum := md5.New()
+	checksum.Write(data)
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(data)))
+	checksum.Write(size[:])
+	checksum.Write(version)
+	checksum.Write([]byte("TDF$"))
+	return bytes.Join([][]byte{[]byte("TDF$"), version, data, checksum.Sum(nil)}, nil)
+}
+
+// encryptLocal encrypts data with a local key like Telegram Desktop, the reverse of
+// decryptLocal.
+func encryptLocal(data, key []byte) []byte {
+	plain := make([]byte, 4, 4+len(data)+15)
+	binary.LittleEndian.PutUint32(plain, uint32(4+len(data)))
+	plain = append(plain, data...)
+	for len(plain)%16 != 0 {
+		plain = append(plain, 0xEE)
+	}
+	checksum := sha1.Sum(plain)
+	msgKey := checksum[:16]
+	aesKey, aesIV := prepareAESOldMTP(key, msgKey)
+	block, _ := aes.NewCipher(aesKey)
+	previousCipher := append([]byte(nil), aesIV[:16]...)
+	previousPlain := append([]byte(nil), aesIV[16:32]...)
+	encrypted := make([]byte, len(plain))
+	buffer := make([]byte, 16)
+	for offset := 0; offset < len(plain); offset += 16 {
+		for i := 0; i < 16; i++ {
+			buffer[i] = plain[offset+i] ^ previousCipher[i]
+		}
+		block.Encrypt(buffer, buffer)
+		for i := 0; i < 16; i++ {
+			encrypted[offset+i] = buffer[i] ^ previousPlain[i]
+		}
+		previousCipher = encrypted[offset : offset+16]
+		previousPlain = plain[offset : offset+16]
+	}
+	return append(append([]byte(nil), msgKey...), encrypted...)
+}
+
+func writeFile(t *testing.T, path string, content []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,147 @@
+// Package resp is a minimal Redis client speaking the RESP protocol, one connection per command.
+package resp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Client sends commands to a Redis server.
+//
+// # Fields:
+//   - Addr: The Redis address (e.g., "10.0.0.5:6379").
+//   - Password: The optional Redis password.
+type Client struct {
+	Addr     string
+	Password string
+}
+
+// Command sends one command and returns its reply as a string. A nil reply is returned as "".
+func (client Client) Command(ctx context.Context, args ...string) (string, error) {
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	switch reply := reply.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return reply, nil
+	default:
+		return "", errors.New("unexpected redis array reply")
+	}
+}
+
+// Strings sends one command whose reply is an array and returns its elements as strings.
+// Nil elements are returned as "".
+func (client Client) Strings(ctx context.Context, args ...string) ([]string, error) {
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, nil
+	}
+	values, ok := reply.([]interface{})
+	if !ok {
+		return nil, errors.New("unexpected redis reply, expected an array")
+	}
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i], _ = value.(string)
+	}
+	return result, nil
+}
+
+// Do sends one command on a new connection and returns its reply: a string for simple strings,
+// integers, and bulk strings, a []interface{} for arrays, or nil for nil replies.
+func (client Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", client.Addr)
+	if err != nil {
+		return nil, err
+	}
+	defer func(conn net.Conn) {
+		err := conn.Close()
+		if err != nil {
+		}
+	}(conn)
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	}
+	reader := bufio.NewReader(conn)
+	if client.Password != "" {
+		if _, err := roundTrip(conn, reader, "AUTH", client.Password); err != nil {
+			return nil, fmt.Errorf("redis AUTH failed: %w", err)
+		}
+	}
+	return roundTrip(conn, reader, args...)
+}
+
+// roundTrip writes a RESP command and reads its reply.
+func roundTrip(conn net.Conn, reader *bufio.Reader, args ...string) (interface{}, error) {
+	command := "*" + strconv.Itoa(len(args)) + "\r\n"
+	for _, arg := range args {
+		command += "$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n"
+	}
+	if _, err := io.WriteString(conn, command); err != nil {
+		return nil, err
+	}
+	return readReply(reader)
+}
+
+// readReply reads a RESP simple string, error, integer, bulk string, or array reply.
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, errors.New("malformed redis reply")
+	}
+	payload := line[1 : len(line)-2]
+	switch line[0] {
+	case '+', ':':
+		return payload, nil
+	case '-':
+		return nil, errors.New(payload)
+	case '$':
+		length, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		data := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:length]), nil
+	case '*':
+		length, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		values := make([]interface{}, length)
+		for i := range values {
+			if values[i], err = readReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported redis reply type: %q", line[0])
+	}
+}
@@ -0,0 +1,244 @@
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// boltBucket is the bucket of the jobs in a BoltDB file.
+var boltBucket = []byte("jobs")
+
+// Bolt is a Queue stored in a BoltDB file, one key per job holding its JSON encoding. Every
+// change is a transaction writing only the jobs it touches, so large queues are not rewritten
+// after each execution like with Local. BoltDB locks its file, so the queue suits a single farm
+// instance; instances on different hosts share a Redis queue instead.
+//
+// # Example:
+//
+//	queue, err := jobqueue.OpenBolt("state/jobs.db")
+//	if err != nil {
+//		log.Fatalf("Failed to open job queue: %v", err)
+//	}
+//	defer queue.Close()
+//	handler.SetJobQueue(queue)
+type Bolt struct {
+	db *bbolt.DB
+}
+
+// OpenBolt opens the queue stored in the BoltDB file at path, creating it if needed.
+//
+// # Parameters:
+//   - path: The path of the database file. Parent directories are created if needed.
+//
+// # Returns:
+//   - *Bolt: The queue, to close once the handler stopped.
+//   - error: An error if the file cannot be opened, e.g. when another process holds it.
+func OpenBolt(path string) (*Bolt, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Bolt{db: db}, nil
+}
+
+// Close closes the database file.
+func (queue *Bolt) Close() error {
+	return queue.db.Close()
+}
+
+// getJob returns the job with the given ID in the bucket, or ErrJobNotFound.
+func getJob(bucket *bbolt.Bucket, id string) (Job, error) {
+	data := bucket.Get([]byte(id))
+	if data == nil {
+		return Job{}, ErrJobNotFound
+	}
+	var job Job
+	err := json.Unmarshal(data, &job)
+	return job, err
+}
+
+// putJob stores a job in the bucket.
+func putJob(bucket *bbolt.Bucket, job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(job.ID), data)
+}
+
+// update runs fn on the job with the given ID in a read-write transaction and stores the job
+// it returns.
+func (queue *Bolt) update(id string, fn func(job Job) (Job, error)) error {
+	return queue.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		job, err := getJob(bucket, id)
+		if err != nil {
+			return err
+		}
+		if job, err = fn(job); err != nil {
+			return err
+		}
+		return putJob(bucket, job)
+	})
+}
+
+// Add stores job unless a job with its ID exists and returns the stored job.
+func (queue *Bolt) Add(ctx context.Context, job Job) (Job, error) {
+	stored := job
+	err := queue.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		existing, err := getJob(bucket, job.ID)
+		if err == nil {
+			stored = existing
+			return nil
+		}
+		if !errors.Is(err, ErrJobNotFound) {
+			return err
+		}
+		return putJob(bucket, job)
+	})
+	return stored, err
+}
+
+// Lease reserves the due jobs of game among ids for owner until now+ttl.
+func (queue *Bolt) Lease(ctx context.Context, game, owner string, ids []string, now time.Time, ttl time.Duration) ([]Job, error) {
+	var leased []Job
+	err := queue.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for _, id := range ids {
+			job, err := getJob(bucket, id)
+			if errors.Is(err, ErrJobNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if !job.leasable(game, now) {
+				continue
+			}
+			job.LeaseOwner = owner
+			job.LeaseUntil = now.Add(ttl)
+			if err := putJob(bucket, job); err != nil {
+				return err
+			}
+			leased = append(leased, job)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortJobs(leased)
+	return leased, nil
+}
+
+// Extend extends the lease of a job leased by owner to until.
+func (queue *Bolt) Extend(ctx context.Context, id, owner string, until time.Time) error {
+	return queue.update(id, func(job Job) (Job, error) {
+		if job.LeaseOwner != owner {
+			return job, ErrLeaseLost
+		}
+		job.LeaseUntil = until
+		return job, nil
+	})
+}
+
+// Complete records an execution of a job leased by owner and reschedules it at next.
+func (queue *Bolt) Complete(ctx context.Context, id, owner string, last, next time.Time) error {
+	return queue.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		job, err := getJob(bucket, id)
+		if err != nil {
+			return err
+		}
+		if job.LeaseOwner != owner {
+			return ErrLeaseLost
+		}
+		if next.IsZero() {
+			return bucket.Delete([]byte(id))
+		}
+		job.Last = last
+		job.Due = next
+		job.LeaseOwner = ""
+		job.LeaseUntil = time.Time{}
+		return putJob(bucket, job)
+	})
+}
+
+// Reschedule moves the next execution of a job that is not leased at now to due.
+func (queue *Bolt) Reschedule(ctx context.Context, id string, due, now time.Time) error {
+	return queue.update(id, func(job Job) (Job, error) {
+		if !job.leased(now) {
+			job.Due = due
+		}
+		return job, nil
+	})
+}
+
+// Cancel stops the job from being leased.
+func (queue *Bolt) Cancel(ctx context.Context, id string) error {
+	return queue.setCancelled(id, true)
+}
+
+// Resume undoes Cancel.
+func (queue *Bolt) Resume(ctx context.Context, id string) error {
+	return queue.setCancelled(id, false)
+}
+
+// Remove deletes a job.
+func (queue *Bolt) Remove(ctx context.Context, id string) error {
+	return queue.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete([]byte(id))
+	})
+}
+
+func (queue *Bolt) setCancelled(id string, cancelled bool) error {
+	return queue.update(id, func(job Job) (Job, error) {
+		job.Cancelled = cancelled
+		return job, nil
+	})
+}
+
+// List returns the jobs of game, or of every game when game is empty, ordered by ID.
+func (queue *Bolt) List(ctx context.Context, game string) ([]Job, error) {
+	prefix := ""
+	if game != "" {
+		prefix = game + "/"
+	}
+	jobs := make([]Job, 0)
+	err := queue.db.View(func(tx *bbolt.Tx) error {
+		cursor := tx.Bucket(boltBucket).Cursor()
+		for key, data := cursor.Seek([]byte(prefix)); key != nil && strings.HasPrefix(string(key), prefix); key, data = cursor.Next() {
+			var job Job
+			if err := json.Unmarshal(data, &job); err != nil {
+				return err
+			}
+			if game == "" || job.Game == game {
+				jobs = append(jobs, job)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
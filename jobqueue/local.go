@@ -0,0 +1,225 @@
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Local is a Queue kept in memory and, when opened with OpenLocal, persisted to a JSON file
+// rewritten atomically after every change. It suits a single farm instance; instances on
+// different hosts share a Redis queue instead.
+//
+// # Example:
+//
+//	queue, err := jobqueue.OpenLocal("state/jobs.json")
+//	if err != nil {
+//		log.Fatalf("Failed to open job queue: %v", err)
+//	}
+//	handler.SetJobQueue(queue)
+type Local struct {
+	path string
+	jobs map[string]Job
+	mu   sync.Mutex
+}
+
+// NewLocal returns an empty in-memory queue.
+func NewLocal() *Local {
+	return &Local{jobs: make(map[string]Job)}
+}
+
+// OpenLocal opens the queue persisted at path, creating it on the first change if needed.
+//
+// # Parameters:
+//   - path: The path of the JSON file. Parent directories are created if needed.
+//
+// # Returns:
+//   - *Local: The queue.
+//   - error: An error if the file cannot be read or parsed.
+func OpenLocal(path string) (*Local, error) {
+	queue := &Local{path: path, jobs: make(map[string]Job)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return queue, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var jobs []Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to parse job queue %s: %w", path, err)
+	}
+	for _, job := range jobs {
+		queue.jobs[job.ID] = job
+	}
+	return queue, nil
+}
+
+// Add stores job unless a job with its ID exists and returns the stored job.
+func (queue *Local) Add(ctx context.Context, job Job) (Job, error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	if existing, ok := queue.jobs[job.ID]; ok {
+		return existing, nil
+	}
+	queue.jobs[job.ID] = job
+	return job, queue.save()
+}
+
+// Lease reserves the due jobs of game among ids for owner until now+ttl.
+func (queue *Local) Lease(ctx context.Context, game, owner string, ids []string, now time.Time, ttl time.Duration) ([]Job, error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	var leased []Job
+	for _, id := range ids {
+		job, ok := queue.jobs[id]
+		if !ok || !job.leasable(game, now) {
+			continue
+		}
+		job.LeaseOwner = owner
+		job.LeaseUntil = now.Add(ttl)
+		queue.jobs[id] = job
+		leased = append(leased, job)
+	}
+	if len(leased) == 0 {
+		return nil, nil
+	}
+	sortJobs(leased)
+	return leased, queue.save()
+}
+
+// Extend extends the lease of a job leased by owner to until.
+func (queue *Local) Extend(ctx context.Context, id, owner string, until time.Time) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	job, ok := queue.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if job.LeaseOwner != owner {
+		return ErrLeaseLost
+	}
+	job.LeaseUntil = until
+	queue.jobs[id] = job
+	return queue.save()
+}
+
+// Complete records an execution of a job leased by owner and reschedules it at next.
+func (queue *Local) Complete(ctx context.Context, id, owner string, last, next time.Time) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	job, ok := queue.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if job.LeaseOwner != owner {
+		return ErrLeaseLost
+	}
+	if next.IsZero() {
+		delete(queue.jobs, id)
+		return queue.save()
+	}
+	job.Last = last
+	job.Due = next
+	job.LeaseOwner = ""
+	job.LeaseUntil = time.Time{}
+	queue.jobs[id] = job
+	return queue.save()
+}
+
+// Reschedule moves the next execution of a job that is not leased at now to due.
+func (queue *Local) Reschedule(ctx context.Context, id string, due, now time.Time) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	job, ok := queue.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if job.leased(now) {
+		return nil
+	}
+	job.Due = due
+	queue.jobs[id] = job
+	return queue.save()
+}
+
+// Cancel stops the job from being leased.
+func (queue *Local) Cancel(ctx context.Context, id string) error {
+	return queue.setCancelled(id, true)
+}
+
+// Resume undoes Cancel.
+func (queue *Local) Resume(ctx context.Context, id string) error {
+	return queue.setCancelled(id, false)
+}
+
+// Remove deletes a job.
+func (queue *Local) Remove(ctx context.Context, id string) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	if _, ok := queue.jobs[id]; !ok {
+		return nil
+	}
+	delete(queue.jobs, id)
+	return queue.save()
+}
+
+func (queue *Local) setCancelled(id string, cancelled bool) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	job, ok := queue.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	job.Cancelled = cancelled
+	queue.jobs[id] = job
+	return queue.save()
+}
+
+// List returns the jobs of game, or of every game when game is empty, ordered by ID.
+func (queue *Local) List(ctx context.Context, game string) ([]Job, error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	jobs := make([]Job, 0, len(queue.jobs))
+	for _, job := range queue.jobs {
+		if game == "" || job.Game == game {
+			jobs = append(jobs, job)
+		}
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+// save writes the jobs to the queue's file, if any. It must be called with mu held.
+func (queue *Local) save() error {
+	if queue.path == "" {
+		return nil
+	}
+	jobs := make([]Job, 0, len(queue.jobs))
+	for _, job := range queue.jobs {
+		jobs = append(jobs, job)
+	}
+	sortJobs(jobs)
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(queue.path), 0o755); err != nil {
+		return err
+	}
+	temp := queue.path + ".tmp"
+	if err := os.WriteFile(temp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(temp, queue.path)
+}
+
+// sortJobs orders jobs by ID.
+func sortJobs(jobs []Job) {
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+}
@@ -0,0 +1,161 @@
+// Package jobqueue stores the scheduled task executions of a farm, so that scheduled work
+// survives restarts and can be inspected and cancelled individually.
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultPollInterval is how often due jobs are leased by the scheduler.
+const DefaultPollInterval = time.Second
+
+// DefaultLeaseTTL is how long a leased job is reserved for the instance running it. The
+// lease of a running job is extended every third of it, so that an execution outliving it is
+// not leased again by another instance.
+const DefaultLeaseTTL = 10 * time.Minute
+
+// ErrJobNotFound is returned when a job ID is not in the queue.
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrLeaseLost is returned by Complete and Extend when the job is leased by another instance.
+var ErrLeaseLost = errors.New("job lease lost")
+
+// Job is the next execution of a scheduled task for an account.
+//
+// # Fields:
+//   - ID: The job ID, "<game>/<account>/<task>" (see JobID).
+//   - Game: The name of the game.
+//   - Account: The Telegram ID of the account.
+//   - Task: The name of the task.
+//   - Due: When the job runs next.
+//   - Last: When the job last ran, zero before its first execution.
+//   - Cancelled: Whether the job was cancelled; cancelled jobs are never leased.
+//   - LeaseOwner: The instance running the job, empty when it is not leased.
+//   - LeaseUntil: When the lease expires, after which another instance may run the job.
+type Job struct {
+	ID         string    `json:"id"`
+	Game       string    `json:"game"`
+	Account    string    `json:"account"`
+	Task       string    `json:"task"`
+	Due        time.Time `json:"due"`
+	Last       time.Time `json:"last"`
+	Cancelled  bool      `json:"cancelled,omitempty"`
+	LeaseOwner string    `json:"lease_owner,omitempty"`
+	LeaseUntil time.Time `json:"lease_until"`
+}
+
+// leased reports whether the job is leased at now.
+func (job Job) leased(now time.Time) bool {
+	return job.LeaseOwner != "" && job.LeaseUntil.After(now)
+}
+
+// leasable reports whether the job of game can be leased at now.
+func (job Job) leasable(game string, now time.Time) bool {
+	return job.Game == game && !job.Cancelled && !job.Due.After(now) &&
+		!job.leased(now)
+}
+
+// Queue is a persistent store of scheduled jobs, polled by the scheduler for due jobs.
+//
+// # Methods:
+//   - Add(ctx context.Context, job Job) (Job, error): Stores job unless a job with its ID
+//     exists, and returns the stored job, so that a restarted scheduler keeps the due times.
+//   - Lease(ctx context.Context, game, owner string, ids []string, now time.Time, ttl time.Duration) ([]Job, error):
+//     Reserves the due jobs of game among ids that are not cancelled nor leased, so that an
+//     instance never holds the jobs of accounts it does not run, e.g. those of other instances
+//     sharing the queue.
+//   - Extend(ctx context.Context, id, owner string, until time.Time) error: Extends the lease
+//     of a job leased by owner to until, while the job runs. Returns ErrLeaseLost if the job
+//     is not leased by owner.
+//   - Complete(ctx context.Context, id, owner string, last, next time.Time) error: Records an
+//     execution of a job leased by owner, reschedules it at next, and releases it. A zero next
+//     removes the job.
+//   - Reschedule(ctx context.Context, id string, due, now time.Time) error: Moves the next
+//     execution of a job that is not leased at now to due, e.g. after a clock jump. Leased jobs
+//     are left as they are; they are rescheduled when they complete.
+//   - Cancel(ctx context.Context, id string) error: Stops the job from being leased.
+//   - Resume(ctx context.Context, id string) error: Undoes Cancel.
+//   - List(ctx context.Context, game string) ([]Job, error): Returns the jobs of game, or of
+//     every game when game is empty, ordered by ID.
+type Queue interface {
+	Add(ctx context.Context, job Job) (Job, error)
+	Lease(ctx context.Context, game, owner string, ids []string, now time.Time, ttl time.Duration) ([]Job, error)
+	Extend(ctx context.Context, id, owner string, until time.Time) error
+	Complete(ctx context.Context, id, owner string, last, next time.Time) error
+	Reschedule(ctx context.Context, id string, due, now time.Time) error
+	Cancel(ctx context.Context, id string) error
+	Resume(ctx context.Context, id string) error
+	List(ctx context.Context, game string) ([]Job, error)
+}
+
+// Remover is implemented by the queues that can delete jobs, so that the jobs of
+// decommissioned accounts do not stay in the queue (see handler.GameHandler.DecommissionAccounts).
+//
+// # Methods:
+//   - Remove(ctx context.Context, id string) error: Deletes a job, leased or not. Removing a
+//     missing job is not an error.
+type Remover interface {
+	Remove(ctx context.Context, id string) error
+}
+
+// JobID returns the ID of the job running task for account in game.
+func JobID(game, account, task string) string {
+	return game + "/" + account + "/" + task
+}
+
+// NewOwner returns a lease owner identifying this process.
+func NewOwner() string {
+	hostname, _ := os.Hostname()
+	return hostname + ":" + strconv.Itoa(os.Getpid()) + ":" + strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+// FromConfig creates the queue described by a job queue configuration.
+//
+// # Parameters:
+//   - config: The "job_queue" section of config.json.
+//
+// # Returns:
+//   - Queue: The queue, or nil when no backend is configured.
+//   - error: An error if the backend is unknown or cannot be opened.
+//
+// # Example:
+//
+//	queue, err := jobqueue.FromConfig(config.JobQueue)
+//	if err != nil {
+//		log.Fatalf("Failed to open job queue: %v", err)
+//	}
+func FromConfig(config types.JobQueueConfig) (Queue, error) {
+	switch config.Backend {
+	case "":
+		return nil, nil
+	case "memory":
+		return NewLocal(), nil
+	case "file":
+		if config.Path == "" {
+			return nil, fmt.Errorf("job queue backend 'file' requires a path")
+		}
+		return OpenLocal(config.Path)
+	case "bolt":
+		if config.Path == "" {
+			return nil, fmt.Errorf("job queue backend 'bolt' requires a path")
+		}
+		return OpenBolt(config.Path)
+	case "redis":
+		if config.Addr == "" {
+			return nil, fmt.Errorf("job queue backend 'redis' requires an address")
+		}
+		key := config.Key
+		if key == "" {
+			key = "nexus:jobs"
+		}
+		return NewRedis(config.Addr, config.Password, key), nil
+	default:
+		return nil, fmt.Errorf("unknown job queue backend: %s", config.Backend)
+	}
+}
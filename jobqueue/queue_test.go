@@ -0,0 +1,321 @@
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/storage"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// backends returns the queues under test, each opened on a fresh store. The Redis queue is
+// tested when NEXUS_TEST_REDIS_ADDR names a server that may be written to.
+func backends(t *testing.T) map[string]func(t *testing.T) Queue {
+	queues := map[string]func(t *testing.T) Queue{
+		"memory": func(t *testing.T) Queue { return NewLocal() },
+		"file": func(t *testing.T) Queue {
+			queue, err := OpenLocal(filepath.Join(t.TempDir(), "jobs.json"))
+			if err != nil {
+				t.Fatalf("OpenLocal failed: %v", err)
+			}
+			return queue
+		},
+		"stored": func(t *testing.T) Queue { return NewStored(storage.NewMemory()) },
+		"bolt": func(t *testing.T) Queue {
+			queue, err := OpenBolt(filepath.Join(t.TempDir(), "jobs.db"))
+			if err != nil {
+				t.Fatalf("OpenBolt failed: %v", err)
+			}
+			t.Cleanup(func() { queue.Close() })
+			return queue
+		},
+	}
+	if addr := os.Getenv("NEXUS_TEST_REDIS_ADDR"); addr != "" {
+		queues["redis"] = func(t *testing.T) Queue {
+			return NewRedis(addr, os.Getenv("NEXUS_TEST_REDIS_PASSWORD"), "nexus:test:"+t.Name()+":"+NewOwner())
+		}
+	}
+	return queues
+}
+
+// job returns a job of game for account and task, due at due.
+func job(game, account, task string, due time.Time) Job {
+	return Job{ID: JobID(game, account, task), Game: game, Account: account, Task: task, Due: due}
+}
+
+// add adds jobs to queue, failing the test on error.
+func add(t *testing.T, queue Queue, jobs ...Job) {
+	t.Helper()
+	for _, current := range jobs {
+		if _, err := queue.Add(context.Background(), current); err != nil {
+			t.Fatalf("Add(%s) failed: %v", current.ID, err)
+		}
+	}
+}
+
+// ids returns the IDs of jobs.
+func ids(jobs []Job) []string {
+	result := make([]string, 0, len(jobs))
+	for _, current := range jobs {
+		result = append(result, current.ID)
+	}
+	return result
+}
+
+func equalIDs(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	due := job("blum", "1", "Claim", start)
+	later := job("blum", "2", "Claim", start.Add(time.Hour))
+	other := job("hamster", "1", "Tap", start)
+	tests := []struct {
+		name string
+		run  func(t *testing.T, queue Queue)
+	}{
+		{"add keeps the existing job", func(t *testing.T, queue Queue) {
+			add(t, queue, due)
+			moved := due
+			moved.Due = start.Add(24 * time.Hour)
+			stored, err := queue.Add(ctx, moved)
+			if err != nil || !stored.Due.Equal(start) {
+				t.Errorf("Add returned %v due %v, want the existing job due %v", err, stored.Due, start)
+			}
+		}},
+		{"lease takes the due jobs of the game among the ids", func(t *testing.T, queue Queue) {
+			add(t, queue, due, later, other, job("blum", "3", "Claim", start))
+			leased, err := queue.Lease(ctx, "blum", "a", []string{due.ID, later.ID, other.ID, "blum/9/Claim"}, start, time.Minute)
+			if err != nil {
+				t.Fatalf("Lease failed: %v", err)
+			}
+			if got := ids(leased); !equalIDs(got, []string{due.ID}) {
+				t.Errorf("Lease returned %v, want only the due job of blum among the ids", got)
+			}
+			if leased[0].LeaseOwner != "a" || !leased[0].LeaseUntil.Equal(start.Add(time.Minute)) {
+				t.Errorf("leased job has owner %q until %v", leased[0].LeaseOwner, leased[0].LeaseUntil)
+			}
+		}},
+		{"leased jobs are not leased again before they expire", func(t *testing.T, queue Queue) {
+			add(t, queue, due)
+			if _, err := queue.Lease(ctx, "blum", "a", []string{due.ID}, start, time.Minute); err != nil {
+				t.Fatalf("Lease failed: %v", err)
+			}
+			if leased, _ := queue.Lease(ctx, "blum", "b", []string{due.ID}, start.Add(30*time.Second), time.Minute); len(leased) != 0 {
+				t.Errorf("job leased twice: %v", ids(leased))
+			}
+			if leased, _ := queue.Lease(ctx, "blum", "b", []string{due.ID}, start.Add(2*time.Minute), time.Minute); len(leased) != 1 {
+				t.Errorf("expired lease not taken over, got %v", ids(leased))
+			}
+		}},
+		{"extend keeps the lease of its owner", func(t *testing.T, queue Queue) {
+			add(t, queue, due)
+			if _, err := queue.Lease(ctx, "blum", "a", []string{due.ID}, start, time.Minute); err != nil {
+				t.Fatalf("Lease failed: %v", err)
+			}
+			if err := queue.Extend(ctx, due.ID, "a", start.Add(10*time.Minute)); err != nil {
+				t.Fatalf("Extend failed: %v", err)
+			}
+			if leased, _ := queue.Lease(ctx, "blum", "b", []string{due.ID}, start.Add(5*time.Minute), time.Minute); len(leased) != 0 {
+				t.Errorf("extended job leased by another owner")
+			}
+			if err := queue.Extend(ctx, due.ID, "b", start.Add(time.Hour)); !errors.Is(err, ErrLeaseLost) {
+				t.Errorf("Extend by another owner returned %v, want ErrLeaseLost", err)
+			}
+			if err := queue.Extend(ctx, "blum/9/Claim", "a", start); !errors.Is(err, ErrJobNotFound) {
+				t.Errorf("Extend of a missing job returned %v, want ErrJobNotFound", err)
+			}
+		}},
+		{"complete reschedules and releases the job", func(t *testing.T, queue Queue) {
+			add(t, queue, due)
+			if _, err := queue.Lease(ctx, "blum", "a", []string{due.ID}, start, time.Minute); err != nil {
+				t.Fatalf("Lease failed: %v", err)
+			}
+			if err := queue.Complete(ctx, due.ID, "b", start, start.Add(time.Hour)); !errors.Is(err, ErrLeaseLost) {
+				t.Errorf("Complete by another owner returned %v, want ErrLeaseLost", err)
+			}
+			if err := queue.Complete(ctx, due.ID, "a", start, start.Add(time.Hour)); err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+			jobs, _ := queue.List(ctx, "blum")
+			if len(jobs) != 1 || !jobs[0].Due.Equal(start.Add(time.Hour)) || !jobs[0].Last.Equal(start) || jobs[0].LeaseOwner != "" {
+				t.Errorf("completed job is %+v", jobs)
+			}
+		}},
+		{"complete without a next execution removes the job", func(t *testing.T, queue Queue) {
+			add(t, queue, due)
+			if _, err := queue.Lease(ctx, "blum", "a", []string{due.ID}, start, time.Minute); err != nil {
+				t.Fatalf("Lease failed: %v", err)
+			}
+			if err := queue.Complete(ctx, due.ID, "a", start, time.Time{}); err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+			if jobs, _ := queue.List(ctx, ""); len(jobs) != 0 {
+				t.Errorf("job not removed: %v", ids(jobs))
+			}
+		}},
+		{"reschedule skips leased jobs", func(t *testing.T, queue Queue) {
+			add(t, queue, due, later)
+			if _, err := queue.Lease(ctx, "blum", "a", []string{due.ID}, start, time.Minute); err != nil {
+				t.Fatalf("Lease failed: %v", err)
+			}
+			for _, id := range []string{due.ID, later.ID} {
+				if err := queue.Reschedule(ctx, id, start.Add(3*time.Hour), start); err != nil {
+					t.Fatalf("Reschedule(%s) failed: %v", id, err)
+				}
+			}
+			jobs, _ := queue.List(ctx, "blum")
+			if len(jobs) != 2 || !jobs[0].Due.Equal(start) || !jobs[1].Due.Equal(start.Add(3*time.Hour)) {
+				t.Errorf("rescheduled jobs are %+v, want only the job that is not leased moved", jobs)
+			}
+		}},
+		{"cancelled jobs are not leased until resumed", func(t *testing.T, queue Queue) {
+			add(t, queue, due)
+			if err := queue.Cancel(ctx, due.ID); err != nil {
+				t.Fatalf("Cancel failed: %v", err)
+			}
+			if leased, _ := queue.Lease(ctx, "blum", "a", []string{due.ID}, start, time.Minute); len(leased) != 0 {
+				t.Errorf("cancelled job leased")
+			}
+			if err := queue.Resume(ctx, due.ID); err != nil {
+				t.Fatalf("Resume failed: %v", err)
+			}
+			if leased, _ := queue.Lease(ctx, "blum", "a", []string{due.ID}, start, time.Minute); len(leased) != 1 {
+				t.Errorf("resumed job not leased")
+			}
+			if err := queue.Cancel(ctx, "blum/9/Claim"); !errors.Is(err, ErrJobNotFound) {
+				t.Errorf("Cancel of a missing job returned %v, want ErrJobNotFound", err)
+			}
+		}},
+		{"list filters by game and orders by ID", func(t *testing.T, queue Queue) {
+			add(t, queue, other, later, due)
+			blum, _ := queue.List(ctx, "blum")
+			all, _ := queue.List(ctx, "")
+			if got := ids(blum); !equalIDs(got, []string{due.ID, later.ID}) {
+				t.Errorf("List(blum) = %v", got)
+			}
+			if got := ids(all); !equalIDs(got, []string{due.ID, later.ID, other.ID}) {
+				t.Errorf("List() = %v", got)
+			}
+		}},
+		{"remove deletes leased jobs and ignores missing ones", func(t *testing.T, queue Queue) {
+			remover, ok := queue.(Remover)
+			if !ok {
+				t.Skip("queue cannot remove jobs")
+			}
+			add(t, queue, due)
+			if _, err := queue.Lease(ctx, "blum", "a", []string{due.ID}, start, time.Minute); err != nil {
+				t.Fatalf("Lease failed: %v", err)
+			}
+			if err := remover.Remove(ctx, due.ID); err != nil {
+				t.Fatalf("Remove failed: %v", err)
+			}
+			if err := remover.Remove(ctx, due.ID); err != nil {
+				t.Errorf("Remove of a missing job returned %v", err)
+			}
+			if jobs, _ := queue.List(ctx, ""); len(jobs) != 0 {
+				t.Errorf("job not removed: %v", ids(jobs))
+			}
+		}},
+	}
+	for name, open := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, test := range tests {
+				t.Run(test.name, func(t *testing.T) {
+					test.run(t, open(t))
+				})
+			}
+		})
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	tests := []struct {
+		name string
+		open func(path string) (Queue, func(), error)
+	}{
+		{"file", func(path string) (Queue, func(), error) {
+			queue, err := OpenLocal(path)
+			return queue, func() {}, err
+		}},
+		{"bolt", func(path string) (Queue, func(), error) {
+			queue, err := OpenBolt(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			return queue, func() { queue.Close() }, nil
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state", "jobs")
+			queue, closeQueue, err := test.open(path)
+			if err != nil {
+				t.Fatalf("open failed: %v", err)
+			}
+			add(t, queue, job("blum", "1", "Claim", start))
+			closeQueue()
+			reopened, closeReopened, err := test.open(path)
+			if err != nil {
+				t.Fatalf("reopen failed: %v", err)
+			}
+			defer closeReopened()
+			jobs, err := reopened.List(context.Background(), "")
+			if err != nil || len(jobs) != 1 || !jobs[0].Due.Equal(start) {
+				t.Errorf("reopened queue has %+v, %v, want the job added before", jobs, err)
+			}
+		})
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		config  types.JobQueueConfig
+		want    string
+		wantErr bool
+	}{
+		{types.JobQueueConfig{}, "", false},
+		{types.JobQueueConfig{Backend: "memory"}, "*jobqueue.Local", false},
+		{types.JobQueueConfig{Backend: "file", Path: filepath.Join(dir, "jobs.json")}, "*jobqueue.Local", false},
+		{types.JobQueueConfig{Backend: "bolt", Path: filepath.Join(dir, "jobs.db")}, "*jobqueue.Bolt", false},
+		{types.JobQueueConfig{Backend: "redis", Addr: "127.0.0.1:6379"}, "*jobqueue.Redis", false},
+		{types.JobQueueConfig{Backend: "file"}, "", true},
+		{types.JobQueueConfig{Backend: "bolt"}, "", true},
+		{types.JobQueueConfig{Backend: "redis"}, "", true},
+		{types.JobQueueConfig{Backend: "etcd"}, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.config.Backend, func(t *testing.T) {
+			queue, err := FromConfig(test.config)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("FromConfig error = %v, want error %t", err, test.wantErr)
+			}
+			if closer, ok := queue.(*Bolt); ok {
+				defer closer.Close()
+			}
+			got := ""
+			if queue != nil {
+				got = fmt.Sprintf("%T", queue)
+			}
+			if got != test.want {
+				t.Errorf("FromConfig returned %s, want %s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/nexus-telegram/NexusSDK/internal/resp"
+	"strconv"
+	"time"
+)
+
+// leaseScript leases the due jobs of a game stored in a hash among the given IDs. ARGV: game,
+// owner, now (ms), ttl (ms), IDs.
+const leaseScript = `local leased = {}
+local now = tonumber(ARGV[3])
+for i = 5, #ARGV do
+	local raw = redis.call("HGET", KEYS[1], ARGV[i])
+	if raw then
+		local job = cjson.decode(raw)
+		local owner = job.lease_owner or ""
+		if job.game == ARGV[1] and not job.cancelled and job.due <= now and (owner == "" or job.lease_until <= now) then
+			job.lease_owner = ARGV[2]
+			job.lease_until = now + tonumber(ARGV[4])
+			local encoded = cjson.encode(job)
+			redis.call("HSET", KEYS[1], ARGV[i], encoded)
+			table.insert(leased, encoded)
+		end
+	end
+end
+return leased`
+
+// extendScript extends the lease of a job leased by an owner. ARGV: id, owner, until (ms).
+const extendScript = `local raw = redis.call("HGET", KEYS[1], ARGV[1])
+if not raw then return -1 end
+local job = cjson.decode(raw)
+if (job.lease_owner or "") ~= ARGV[2] then return -2 end
+job.lease_until = tonumber(ARGV[3])
+redis.call("HSET", KEYS[1], ARGV[1], cjson.encode(job))
+return 1`
+
+// completeScript reschedules a job leased by an owner. ARGV: id, owner, last (ms), next (ms).
+const completeScript = `local raw = redis.call("HGET", KEYS[1], ARGV[1])
+if not raw then return -1 end
+local job = cjson.decode(raw)
+if (job.lease_owner or "") ~= ARGV[2] then return -2 end
+if ARGV[4] == "0" then return redis.call("HDEL", KEYS[1], ARGV[1]) end
+job.last = tonumber(ARGV[3])
+job.due = tonumber(ARGV[4])
+job.lease_owner = ""
+job.lease_until = 0
+redis.call("HSET", KEYS[1], ARGV[1], cjson.encode(job))
+return 1`
+
+// rescheduleScript moves a job that is not leased. ARGV: id, due (ms), now (ms).
+const rescheduleScript = `local raw = redis.call("HGET", KEYS[1], ARGV[1])
+if not raw then return -1 end
+local job = cjson.decode(raw)
+if (job.lease_owner or "") ~= "" and job.lease_until > tonumber(ARGV[3]) then return 0 end
+job.due = tonumber(ARGV[2])
+redis.call("HSET", KEYS[1], ARGV[1], cjson.encode(job))
+return 1`
+
+// cancelScript sets the cancelled flag of a job. ARGV: id, "1" or "0".
+const cancelScript = `local raw = redis.call("HGET", KEYS[1], ARGV[1])
+if not raw then return -1 end
+local job = cjson.decode(raw)
+job.cancelled = ARGV[2] == "1"
+redis.call("HSET", KEYS[1], ARGV[1], cjson.encode(job))
+return 1`
+
+// Redis is a Queue stored in a Redis hash, shared by the instances of a farm on different hosts.
+//
+// Every job is a field of the hash holding its JSON encoding. Leases are taken by a Lua script,
+// so two instances never lease the same job at the same time.
+//
+// # Fields:
+//   - Addr: The Redis address (e.g., "10.0.0.5:6379").
+//   - Password: The optional Redis password.
+//   - Key: The key of the hash.
+//
+// # Example:
+//
+//	queue := jobqueue.NewRedis("10.0.0.5:6379", "", "nexus:jobs")
+//	handler.SetJobQueue(queue)
+type Redis struct {
+	Addr     string
+	Password string
+	Key      string
+}
+
+// NewRedis creates a Redis queue.
+func NewRedis(addr, password, key string) *Redis {
+	return &Redis{Addr: addr, Password: password, Key: key}
+}
+
+// redisJob is the encoding of a Job in Redis, with times in Unix milliseconds so that the
+// Lua scripts can compare them.
+type redisJob struct {
+	ID         string `json:"id"`
+	Game       string `json:"game"`
+	Account    string `json:"account"`
+	Task       string `json:"task"`
+	Due        int64  `json:"due"`
+	Last       int64  `json:"last"`
+	Cancelled  bool   `json:"cancelled"`
+	LeaseOwner string `json:"lease_owner"`
+	LeaseUntil int64  `json:"lease_until"`
+}
+
+func toMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func fromMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+func encodeJob(job Job) (string, error) {
+	data, err := json.Marshal(redisJob{
+		ID:         job.ID,
+		Game:       job.Game,
+		Account:    job.Account,
+		Task:       job.Task,
+		Due:        toMillis(job.Due),
+		Last:       toMillis(job.Last),
+		Cancelled:  job.Cancelled,
+		LeaseOwner: job.LeaseOwner,
+		LeaseUntil: toMillis(job.LeaseUntil),
+	})
+	return string(data), err
+}
+
+func decodeJob(data string) (Job, error) {
+	var job redisJob
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return Job{}, err
+	}
+	return Job{
+		ID:         job.ID,
+		Game:       job.Game,
+		Account:    job.Account,
+		Task:       job.Task,
+		Due:        fromMillis(job.Due),
+		Last:       fromMillis(job.Last),
+		Cancelled:  job.Cancelled,
+		LeaseOwner: job.LeaseOwner,
+		LeaseUntil: fromMillis(job.LeaseUntil),
+	}, nil
+}
+
+func (queue *Redis) client() resp.Client {
+	return resp.Client{Addr: queue.Addr, Password: queue.Password}
+}
+
+// Add stores job unless a job with its ID exists and returns the stored job.
+func (queue *Redis) Add(ctx context.Context, job Job) (Job, error) {
+	encoded, err := encodeJob(job)
+	if err != nil {
+		return Job{}, err
+	}
+	if _, err := queue.client().Command(ctx, "HSETNX", queue.Key, job.ID, encoded); err != nil {
+		return Job{}, err
+	}
+	stored, err := queue.client().Command(ctx, "HGET", queue.Key, job.ID)
+	if err != nil {
+		return Job{}, err
+	}
+	return decodeJob(stored)
+}
+
+// Lease reserves the due jobs of game among ids for owner until now+ttl.
+func (queue *Redis) Lease(ctx context.Context, game, owner string, ids []string, now time.Time, ttl time.Duration) ([]Job, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := append([]string{"EVAL", leaseScript, "1", queue.Key, game, owner,
+		strconv.FormatInt(now.UnixMilli(), 10), strconv.FormatInt(ttl.Milliseconds(), 10)}, ids...)
+	replies, err := queue.client().Strings(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]Job, 0, len(replies))
+	for _, reply := range replies {
+		job, err := decodeJob(reply)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+// Extend extends the lease of a job leased by owner to until.
+func (queue *Redis) Extend(ctx context.Context, id, owner string, until time.Time) error {
+	reply, err := queue.client().Command(ctx, "EVAL", extendScript, "1", queue.Key, id, owner,
+		strconv.FormatInt(toMillis(until), 10))
+	if err != nil {
+		return err
+	}
+	switch reply {
+	case "-1":
+		return ErrJobNotFound
+	case "-2":
+		return ErrLeaseLost
+	default:
+		return nil
+	}
+}
+
+// Complete records an execution of a job leased by owner and reschedules it at next.
+func (queue *Redis) Complete(ctx context.Context, id, owner string, last, next time.Time) error {
+	reply, err := queue.client().Command(ctx, "EVAL", completeScript, "1", queue.Key, id, owner,
+		strconv.FormatInt(toMillis(last), 10), strconv.FormatInt(toMillis(next), 10))
+	if err != nil {
+		return err
+	}
+	switch reply {
+	case "-1":
+		return ErrJobNotFound
+	case "-2":
+		return ErrLeaseLost
+	default:
+		return nil
+	}
+}
+
+// Reschedule moves the next execution of a job that is not leased at now to due.
+func (queue *Redis) Reschedule(ctx context.Context, id string, due, now time.Time) error {
+	reply, err := queue.client().Command(ctx, "EVAL", rescheduleScript, "1", queue.Key, id,
+		strconv.FormatInt(toMillis(due), 10), strconv.FormatInt(toMillis(now), 10))
+	if err != nil {
+		return err
+	}
+	if reply == "-1" {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// Cancel stops the job from being leased.
+func (queue *Redis) Cancel(ctx context.Context, id string) error {
+	return queue.setCancelled(ctx, id, "1")
+}
+
+// Resume undoes Cancel.
+func (queue *Redis) Resume(ctx context.Context, id string) error {
+	return queue.setCancelled(ctx, id, "0")
+}
+
+// Remove deletes a job.
+func (queue *Redis) Remove(ctx context.Context, id string) error {
+	_, err := queue.client().Command(ctx, "HDEL", queue.Key, id)
+	return err
+}
+
+func (queue *Redis) setCancelled(ctx context.Context, id, cancelled string) error {
+	reply, err := queue.client().Command(ctx, "EVAL", cancelScript, "1", queue.Key, id, cancelled)
+	if err != nil {
+		return err
+	}
+	if reply == "-1" {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// List returns the jobs of game, or of every game when game is empty, ordered by ID.
+func (queue *Redis) List(ctx context.Context, game string) ([]Job, error) {
+	replies, err := queue.client().Strings(ctx, "HVALS", queue.Key)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]Job, 0, len(replies))
+	for _, reply := range replies {
+		job, err := decodeJob(reply)
+		if err != nil {
+			return nil, err
+		}
+		if game == "" || job.Game == game {
+			jobs = append(jobs, job)
+		}
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
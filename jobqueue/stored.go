@@ -0,0 +1,206 @@
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/storage"
+	"sync"
+	"time"
+)
+
+// Stored is a Queue keeping one document per job in a shared storage backend, in
+// storage.BucketJobs. Leases are taken under a process-wide lock, so the queue suits a single
+// farm instance even on a shared backend; instances on different hosts share a Redis queue
+// instead.
+//
+// # Example:
+//
+//	backend, err := storage.FromConfig(config.Storage)
+//	if err != nil {
+//		log.Fatalf("Failed to open storage: %v", err)
+//	}
+//	handler.SetJobQueue(jobqueue.NewStored(backend))
+type Stored struct {
+	backend storage.Storage
+	mu      sync.Mutex
+}
+
+// NewStored returns a queue keeping its jobs in backend.
+func NewStored(backend storage.Storage) *Stored {
+	return &Stored{backend: backend}
+}
+
+// get returns the job with the given ID, or ErrJobNotFound.
+func (queue *Stored) get(ctx context.Context, id string) (Job, error) {
+	data, err := queue.backend.Get(ctx, storage.BucketJobs, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return Job{}, ErrJobNotFound
+	}
+	if err != nil {
+		return Job{}, err
+	}
+	var job Job
+	err = json.Unmarshal(data, &job)
+	return job, err
+}
+
+// put stores a job.
+func (queue *Stored) put(ctx context.Context, job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return queue.backend.Put(ctx, storage.BucketJobs, job.ID, data)
+}
+
+// Add stores job unless a job with its ID exists and returns the stored job.
+func (queue *Stored) Add(ctx context.Context, job Job) (Job, error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	existing, err := queue.get(ctx, job.ID)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, ErrJobNotFound) {
+		return Job{}, err
+	}
+	return job, queue.put(ctx, job)
+}
+
+// Lease reserves the due jobs of game among ids for owner until now+ttl.
+func (queue *Stored) Lease(ctx context.Context, game, owner string, ids []string, now time.Time, ttl time.Duration) ([]Job, error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	var leased []Job
+	for _, id := range ids {
+		job, err := queue.get(ctx, id)
+		if errors.Is(err, ErrJobNotFound) {
+			continue
+		}
+		if err != nil {
+			return leased, err
+		}
+		if !job.leasable(game, now) {
+			continue
+		}
+		job.LeaseOwner = owner
+		job.LeaseUntil = now.Add(ttl)
+		if err := queue.put(ctx, job); err != nil {
+			return leased, err
+		}
+		leased = append(leased, job)
+	}
+	sortJobs(leased)
+	return leased, nil
+}
+
+// Extend extends the lease of a job leased by owner to until.
+func (queue *Stored) Extend(ctx context.Context, id, owner string, until time.Time) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	job, err := queue.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if job.LeaseOwner != owner {
+		return ErrLeaseLost
+	}
+	job.LeaseUntil = until
+	return queue.put(ctx, job)
+}
+
+// Complete records an execution of a job leased by owner and reschedules it at next.
+func (queue *Stored) Complete(ctx context.Context, id, owner string, last, next time.Time) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	job, err := queue.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if job.LeaseOwner != owner {
+		return ErrLeaseLost
+	}
+	if next.IsZero() {
+		return queue.backend.Delete(ctx, storage.BucketJobs, id)
+	}
+	job.Last = last
+	job.Due = next
+	job.LeaseOwner = ""
+	job.LeaseUntil = time.Time{}
+	return queue.put(ctx, job)
+}
+
+// Reschedule moves the next execution of a job that is not leased at now to due.
+func (queue *Stored) Reschedule(ctx context.Context, id string, due, now time.Time) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	job, err := queue.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if job.leased(now) {
+		return nil
+	}
+	job.Due = due
+	return queue.put(ctx, job)
+}
+
+// Cancel stops the job from being leased.
+func (queue *Stored) Cancel(ctx context.Context, id string) error {
+	return queue.setCancelled(ctx, id, true)
+}
+
+// Resume undoes Cancel.
+func (queue *Stored) Resume(ctx context.Context, id string) error {
+	return queue.setCancelled(ctx, id, false)
+}
+
+// Remove deletes a job.
+func (queue *Stored) Remove(ctx context.Context, id string) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	return queue.backend.Delete(ctx, storage.BucketJobs, id)
+}
+
+func (queue *Stored) setCancelled(ctx context.Context, id string, cancelled bool) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	job, err := queue.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	job.Cancelled = cancelled
+	return queue.put(ctx, job)
+}
+
+// List returns the jobs of game, or of every game when game is empty, ordered by ID.
+func (queue *Stored) List(ctx context.Context, game string) ([]Job, error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	return queue.list(ctx, game)
+}
+
+// list returns the jobs of game, ordered by ID. It must be called with mu held.
+func (queue *Stored) list(ctx context.Context, game string) ([]Job, error) {
+	prefix := ""
+	if game != "" {
+		prefix = game + "/"
+	}
+	documents, err := queue.backend.List(ctx, storage.BucketJobs, prefix)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]Job, 0, len(documents))
+	for _, data := range documents {
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil {
+			return nil, err
+		}
+		if game == "" || job.Game == game {
+			jobs = append(jobs, job)
+		}
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
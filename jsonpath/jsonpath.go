@@ -0,0 +1,322 @@
+// Package jsonpath selects values of decoded JSON documents with JSONPath expressions, such as
+// "$.data.balance" or "$.quests[*].id".
+//
+// # Syntax:
+//   - $: The root of the document. It may be omitted ("data.balance").
+//   - .name, ['name']: A member of an object.
+//   - [0], [-1]: An element of a list, negative indexes counting from the end.
+//   - [1:3]: A slice of a list.
+//   - [*], .*: Every member of an object or element of a list.
+//   - ..name: Every member called name, at any depth.
+//
+// Paths with a wildcard, slice, or recursive descent select several values and return them as
+// a list; other paths select at most one value.
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path is a compiled JSONPath expression. A Path is immutable and safe for concurrent use.
+type Path struct {
+	source   string
+	segments []segment
+	multiple bool
+}
+
+// segment is a step of a path.
+//
+// # Fields:
+//   - recursive: Whether the step applies at any depth below the current values.
+//   - name: The member selected, when set.
+//   - index: The element selected, when isIndex is set.
+//   - wildcard: Whether every member or element is selected.
+//   - slice: Whether the elements from start to end are selected.
+type segment struct {
+	recursive bool
+	name      string
+	isIndex   bool
+	index     int
+	wildcard  bool
+	slice     bool
+	start     *int
+	end       *int
+}
+
+// Compile parses a JSONPath expression.
+//
+// # Returns:
+//   - *Path: The compiled path.
+//   - error: An error if the expression is invalid.
+func Compile(source string) (*Path, error) {
+	path := &Path{source: source}
+	rest := strings.TrimSpace(source)
+	rest = strings.TrimPrefix(rest, "$")
+	if rest != "" && rest[0] != '.' && rest[0] != '[' {
+		rest = "." + rest
+	}
+	for rest != "" {
+		var current segment
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			current.recursive = true
+			rest = rest[2:]
+			if strings.HasPrefix(rest, "[") {
+				break
+			}
+			name, remaining := readName(rest)
+			if name == "" {
+				return nil, fmt.Errorf("invalid JSONPath %q: expected a name after ..", source)
+			}
+			current.name, current.wildcard, rest = name, name == "*", remaining
+			path.add(current)
+			continue
+		case rest[0] == '.':
+			name, remaining := readName(rest[1:])
+			if name == "" {
+				return nil, fmt.Errorf("invalid JSONPath %q: expected a name after .", source)
+			}
+			current.name, current.wildcard, rest = name, name == "*", remaining
+			path.add(current)
+			continue
+		case rest[0] != '[':
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", source, rest[0])
+		}
+		closing := closingBracket(rest)
+		if closing < 0 {
+			return nil, fmt.Errorf("invalid JSONPath %q: unterminated [", source)
+		}
+		selector := strings.TrimSpace(rest[1:closing])
+		rest = rest[closing+1:]
+		if err := parseSelector(selector, &current); err != nil {
+			return nil, fmt.Errorf("invalid JSONPath %q: %w", source, err)
+		}
+		path.add(current)
+	}
+	return path, nil
+}
+
+// MustCompile is like Compile but panics if the expression is invalid.
+func MustCompile(source string) *Path {
+	path, err := Compile(source)
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
+
+// String returns the source of the path.
+func (path *Path) String() string {
+	return path.source
+}
+
+// add appends a segment to the path.
+func (path *Path) add(current segment) {
+	if current.recursive || current.wildcard || current.slice {
+		path.multiple = true
+	}
+	if current.name == "*" {
+		current.name = ""
+	}
+	path.segments = append(path.segments, current)
+}
+
+// Get returns the value the path selects in a decoded JSON document.
+//
+// # Returns:
+//   - interface{}: The value, or the list of values when the path may select several.
+//   - bool: Whether the path selects a value. Paths selecting several values always do, with
+//     an empty list when nothing matches.
+//
+// # Example:
+//
+//	var document interface{}
+//	if err := json.Unmarshal(body, &document); err != nil {
+//		return err
+//	}
+//	balance, ok := jsonpath.MustCompile("$.data.balance").Get(document)
+func (path *Path) Get(document interface{}) (interface{}, bool) {
+	values := []interface{}{document}
+	for _, current := range path.segments {
+		var next []interface{}
+		for _, value := range values {
+			if current.recursive {
+				for _, nested := range descendants(value) {
+					next = append(next, current.apply(nested)...)
+				}
+				continue
+			}
+			next = append(next, current.apply(value)...)
+		}
+		values = next
+	}
+	if path.multiple {
+		if values == nil {
+			values = []interface{}{}
+		}
+		return values, true
+	}
+	if len(values) == 0 {
+		return nil, false
+	}
+	return values[0], true
+}
+
+// Lookup compiles a path and returns the value it selects in a document. See Path.Get.
+func Lookup(document interface{}, source string) (interface{}, bool, error) {
+	path, err := Compile(source)
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok := path.Get(document)
+	return value, ok, nil
+}
+
+// apply returns the values a segment selects in a value.
+func (current segment) apply(value interface{}) []interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if current.wildcard {
+			keys := make([]string, 0, len(typed))
+			for key := range typed {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			values := make([]interface{}, len(keys))
+			for i, key := range keys {
+				values[i] = typed[key]
+			}
+			return values
+		}
+		if current.name != "" {
+			if nested, ok := typed[current.name]; ok {
+				return []interface{}{nested}
+			}
+		}
+	case []interface{}:
+		switch {
+		case current.wildcard:
+			return append([]interface{}(nil), typed...)
+		case current.isIndex:
+			index := current.index
+			if index < 0 {
+				index += len(typed)
+			}
+			if index >= 0 && index < len(typed) {
+				return []interface{}{typed[index]}
+			}
+		case current.slice:
+			start, end := 0, len(typed)
+			if current.start != nil {
+				start = clamp(*current.start, len(typed))
+			}
+			if current.end != nil {
+				end = clamp(*current.end, len(typed))
+			}
+			if start < end {
+				return append([]interface{}(nil), typed[start:end]...)
+			}
+		}
+	}
+	return nil
+}
+
+// descendants returns a value and every value nested in it, depth first.
+func descendants(value interface{}) []interface{} {
+	values := []interface{}{value}
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			values = append(values, descendants(typed[key])...)
+		}
+	case []interface{}:
+		for _, nested := range typed {
+			values = append(values, descendants(nested)...)
+		}
+	}
+	return values
+}
+
+// clamp bounds a slice index, negative indexes counting from the end.
+func clamp(index, length int) int {
+	if index < 0 {
+		index += length
+	}
+	if index < 0 {
+		return 0
+	}
+	if index > length {
+		return length
+	}
+	return index
+}
+
+// readName reads a member name up to the next "." or "[".
+func readName(rest string) (string, string) {
+	end := strings.IndexAny(rest, ".[")
+	if end < 0 {
+		end = len(rest)
+	}
+	return strings.TrimSpace(rest[:end]), rest[end:]
+}
+
+// closingBracket returns the index of the "]" closing the selector that rest starts with,
+// skipping quoted names.
+func closingBracket(rest string) int {
+	var quote byte
+	for i := 1; i < len(rest); i++ {
+		switch {
+		case quote != 0 && rest[i] == '\\':
+			i++
+		case quote != 0 && rest[i] == quote:
+			quote = 0
+		case quote == 0 && (rest[i] == '\'' || rest[i] == '"'):
+			quote = rest[i]
+		case quote == 0 && rest[i] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseSelector parses the content of a bracket selector.
+func parseSelector(selector string, current *segment) error {
+	switch {
+	case selector == "*":
+		current.wildcard = true
+	case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+		name := selector[1 : len(selector)-1]
+		name = strings.ReplaceAll(name, `\`+string(selector[0]), string(selector[0]))
+		current.name = strings.ReplaceAll(name, `\\`, `\`)
+	case strings.Contains(selector, ":"):
+		current.slice = true
+		startText, endText, _ := strings.Cut(selector, ":")
+		for _, bound := range []struct {
+			text   string
+			target **int
+		}{{startText, &current.start}, {endText, &current.end}} {
+			if text := strings.TrimSpace(bound.text); text != "" {
+				index, err := strconv.Atoi(text)
+				if err != nil {
+					return fmt.Errorf("invalid slice [%s]", selector)
+				}
+				*bound.target = &index
+			}
+		}
+	default:
+		index, err := strconv.Atoi(selector)
+		if err != nil {
+			return fmt.Errorf("invalid selector [%s]", selector)
+		}
+		current.isIndex, current.index = true, index
+	}
+	return nil
+}
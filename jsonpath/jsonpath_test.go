@@ -0,0 +1,93 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const document = `{
+	"data": {"balance": 12.5, "user": {"id": 7, "name": "alice"}},
+	"quests": [
+		{"id": "q1", "done": true},
+		{"id": "q2", "done": false},
+		{"id": "q3", "done": true}
+	],
+	"odd.key": "dotted",
+	"empty": []
+}`
+
+func TestGet(t *testing.T) {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(document), &decoded); err != nil {
+		t.Fatalf("invalid test document: %v", err)
+	}
+	tests := []struct {
+		path   string
+		want   interface{}
+		wantOk bool
+	}{
+		{"$.data.balance", 12.5, true},
+		{"data.balance", 12.5, true},
+		{"$['data']['user'].name", "alice", true},
+		{`$["odd.key"]`, "dotted", true},
+		{"$.quests[0].id", "q1", true},
+		{"$.quests[-1].id", "q3", true},
+		{"$.quests[3].id", nil, false},
+		{"$.missing", nil, false},
+		{"$.data.balance.value", nil, false},
+		{"$.quests[*].id", []interface{}{"q1", "q2", "q3"}, true},
+		{"$.quests.*.done", []interface{}{true, false, true}, true},
+		{"$.quests[1:].id", []interface{}{"q2", "q3"}, true},
+		{"$.quests[:-2].id", []interface{}{"q1"}, true},
+		{"$.quests[5:9]", []interface{}{}, true},
+		{"$.data.user.*", []interface{}{float64(7), "alice"}, true},
+		{"$..id", []interface{}{float64(7), "q1", "q2", "q3"}, true},
+		{"$..user.name", []interface{}{"alice"}, true},
+		{"$.empty[*]", []interface{}{}, true},
+		{"$..nothing", []interface{}{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			got, ok := MustCompile(test.path).Get(decoded)
+			if ok != test.wantOk {
+				t.Fatalf("Get(%q) ok = %t, want %t", test.path, ok, test.wantOk)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Get(%q) = %#v, want %#v", test.path, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, source := range []string{"$.", "$..", "$[", "$['open", "$[a]", "$[1:x]"} {
+		t.Run(source, func(t *testing.T) {
+			if _, err := Compile(source); err == nil {
+				t.Errorf("Compile(%q) succeeded, want an error", source)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	decoded := map[string]interface{}{"a": map[string]interface{}{"b": "c"}}
+	tests := []struct {
+		source  string
+		want    interface{}
+		wantOk  bool
+		wantErr bool
+	}{
+		{"$.a.b", "c", true, false},
+		{"$.a.x", nil, false, false},
+		{"$.a[", nil, false, true},
+	}
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			got, ok, err := Lookup(decoded, test.source)
+			if (err != nil) != test.wantErr || ok != test.wantOk || got != test.want {
+				t.Errorf("Lookup(%q) = %v, %t, %v, want %v, %t, error %t", test.source, got, ok, err, test.want, test.wantOk, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// LabelTraceID is the label of the exemplars linking a series to a trace, the name Grafana
+// looks up to link an exemplar to its trace by default.
+const LabelTraceID = "trace_id"
+
+// maxExemplarRunes is the longest label set of an exemplar allowed by OpenMetrics, names and
+// values included.
+const maxExemplarRunes = 128
+
+// Exemplar is a sample of a counter increment or a histogram observation pointing to where it comes from, typically the
+// trace of the task execution that incremented the counter, so that an operator can jump from
+// a spike on a dashboard to the exact execution.
+//
+// # Fields:
+//   - Labels: The labels of the exemplar, e.g. {trace_id="4bf92f3577b34da6a3ce929d0e0e4736"}.
+//     Names and values together are limited to 128 characters.
+//   - Value: The value of the sample, e.g. the duration of the execution in seconds.
+//   - Time: The time of the sample. Zero writes the exemplar without a timestamp.
+type Exemplar struct {
+	Labels Labels
+	Value  float64
+	Time   time.Time
+}
+
+// AddExemplar adds delta to a counter, like Add, and sets the exemplar of the series, written
+// by WriteOpenMetrics until the next exemplar of the series replaces it.
+//
+// # Parameters:
+//   - name: The name of the counter, ending in "_total" by convention.
+//   - help: The description of the counter, used when it is created.
+//   - labels: The labels of the series.
+//   - delta: The value added.
+//   - exemplar: The exemplar of the increment.
+//
+// # Returns:
+//   - error: An error if a name is invalid, delta is negative, name is a gauge, or the labels
+//     of the exemplar are too long.
+//
+// # Example:
+//
+//	metrics.DefaultRegistry.AddExemplar("blum_claim_seconds_total", "Time spent claiming.", labels,
+//		duration.Seconds(), metrics.Exemplar{Labels: metrics.Labels{metrics.LabelTraceID: traceID},
+//			Value: duration.Seconds(), Time: time.Now()})
+func (registry *Registry) AddExemplar(name, help string, labels Labels, delta float64, exemplar Exemplar) error {
+	if err := checkExemplar(name, exemplar); err != nil {
+		return err
+	}
+	return registry.add(name, help, labels, delta, &exemplar)
+}
+
+// checkExemplar checks the labels of an exemplar of the metric name.
+func checkExemplar(name string, exemplar Exemplar) error {
+	runes := 0
+	for label, value := range exemplar.Labels {
+		if !labelName.MatchString(label) {
+			return fmt.Errorf("invalid exemplar label name %q of metric %s", label, name)
+		}
+		runes += utf8.RuneCountInString(label) + utf8.RuneCountInString(value)
+	}
+	if runes > maxExemplarRunes {
+		return fmt.Errorf("exemplar labels of metric %s exceed %d characters", name, maxExemplarRunes)
+	}
+	return nil
+}
+
+// WriteOpenMetrics writes the metrics in the OpenMetrics text format (version 1.0.0), with the
+// exemplars of the counters and of the histogram buckets, sorted by name and labels. The gauge functions are called without
+// the registry locked.
+//
+// Counters whose name ends in "_total" are written as OpenMetrics counters, the others, which
+// cannot carry exemplars in OpenMetrics, with the unknown type.
+//
+// # Notes:
+//   - Prometheus scrapes the OpenMetrics format on its own, but only stores the exemplars with
+//     the exemplar-storage feature enabled (--enable-feature=exemplar-storage).
+//   - Only the last exemplar of a series, or of a histogram bucket, is written: executions
+//     between two scrapes are only linked through the last of them.
+func (registry *Registry) WriteOpenMetrics(w io.Writer) error {
+	buffered := bufio.NewWriter(w)
+	for _, metric := range registry.collect() {
+		family, kind := metric.name, metric.kind
+		if kind == TypeCounter {
+			if strings.HasSuffix(family, "_total") {
+				family = strings.TrimSuffix(family, "_total")
+			} else {
+				kind = "unknown"
+			}
+		}
+		if metric.help != "" {
+			fmt.Fprintf(buffered, "# HELP %s %s\n", family, strings.ReplaceAll(escapeHelp(metric.help), `"`, `\"`))
+		}
+		fmt.Fprintf(buffered, "# TYPE %s %s\n", family, kind)
+		for _, line := range metric.lines {
+			fmt.Fprintf(buffered, "%s%s%s %s", metric.name, line.suffix, line.labels, formatValue(line.current()))
+			if exemplar := line.exemplar; exemplar != nil && (kind == TypeCounter || kind == TypeHistogram) {
+				labels := exemplar.Labels.key()
+				if labels == "" {
+					labels = "{}"
+				}
+				fmt.Fprintf(buffered, " # %s %s", labels, formatValue(exemplar.Value))
+				if !exemplar.Time.IsZero() {
+					fmt.Fprintf(buffered, " %.3f", float64(exemplar.Time.UnixMilli())/1000)
+				}
+			}
+			buffered.WriteByte('\n')
+		}
+	}
+	buffered.WriteString("# EOF\n")
+	return buffered.Flush()
+}
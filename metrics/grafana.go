@@ -0,0 +1,19 @@
+package metrics
+
+import _ "embed"
+
+//go:embed dashboard.json
+var dashboard []byte
+
+// GrafanaDashboard returns an example Grafana dashboard of the metrics of DefaultRegistry, to
+// import in Grafana with a Prometheus data source scraping the /metrics endpoint of the control
+// server. Its variables filter every panel by game, account_cohort, and proxy_pool.
+//
+// # Example:
+//
+//	if err := os.WriteFile("nexus-dashboard.json", metrics.GrafanaDashboard(), 0o644); err != nil {
+//		log.Fatalf("Failed to write dashboard: %v", err)
+//	}
+func GrafanaDashboard() []byte {
+	return append([]byte(nil), dashboard...)
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets of the histograms observed
+// without buckets of their own, spanning the durations of task executions from quick requests
+// to executions retried for minutes.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// histogram is the state of a histogram series: the observations of each bucket (not
+// cumulative, the last one above every bound), their sum, and the exemplar of the last
+// observation of each bucket.
+type histogram struct {
+	counts    []uint64
+	sum       float64
+	exemplars []*Exemplar
+}
+
+// Observe records a value in a histogram, e.g. the duration of a task execution in seconds.
+//
+// # Parameters:
+//   - name: The name of the histogram, ending in a unit by convention (e.g., "_seconds").
+//   - help: The description of the histogram, used when it is created.
+//   - labels: The labels of the series. The "le" label is reserved for the buckets.
+//   - buckets: The increasing upper bounds of the buckets, used when the histogram is
+//     created. Nil means DefaultBuckets.
+//   - value: The value observed.
+//
+// # Returns:
+//   - error: An error if a name is invalid, the buckets are not increasing, or name is not a
+//     histogram.
+func (registry *Registry) Observe(name, help string, labels Labels, buckets []float64, value float64) error {
+	return registry.observe(name, help, labels, buckets, value, nil)
+}
+
+// ObserveExemplar records a value in a histogram, like Observe, and sets the exemplar of the
+// bucket of the value, written by WriteOpenMetrics until the next exemplar of the bucket
+// replaces it. Latency panels built on the buckets then link their spikes to the traces of the
+// slow observations.
+//
+// # Example:
+//
+//	metrics.DefaultRegistry.ObserveExemplar("blum_claim_seconds", "Claim latency.", labels, nil,
+//		duration.Seconds(), metrics.Exemplar{Labels: metrics.Labels{metrics.LabelTraceID: traceID},
+//			Value: duration.Seconds(), Time: time.Now()})
+func (registry *Registry) ObserveExemplar(name, help string, labels Labels, buckets []float64, value float64, exemplar Exemplar) error {
+	if err := checkExemplar(name, exemplar); err != nil {
+		return err
+	}
+	return registry.observe(name, help, labels, buckets, value, &exemplar)
+}
+
+// observe records a value in a histogram, and replaces the exemplar of its bucket if exemplar
+// is not nil.
+func (registry *Registry) observe(name, help string, labels Labels, buckets []float64, value float64, exemplar *Exemplar) error {
+	if _, ok := labels["le"]; ok {
+		return fmt.Errorf("label le of histogram %s is reserved", name)
+	}
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return fmt.Errorf("buckets of histogram %s are not increasing", name)
+		}
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	current, err := registry.family(name, help, TypeHistogram, labels)
+	if err != nil {
+		return err
+	}
+	if current.buckets == nil {
+		current.buckets = append([]float64(nil), buckets...)
+	}
+	key := labels.key()
+	if current.series[key] == nil {
+		current.series[key] = &metricSeries{histogram: &histogram{
+			counts:    make([]uint64, len(current.buckets)+1),
+			exemplars: make([]*Exemplar, len(current.buckets)+1),
+		}}
+	}
+	state := current.series[key].histogram
+	bucket := sort.SearchFloat64s(current.buckets, value)
+	state.counts[bucket]++
+	state.sum += value
+	if exemplar != nil {
+		state.exemplars[bucket] = exemplar
+	}
+	return nil
+}
+
+// histogramLines returns the lines of a histogram series: its cumulative buckets with their
+// exemplars, its sum, and its count.
+func histogramLines(labels string, bounds []float64, state *histogram) []expositionLine {
+	lines := make([]expositionLine, 0, len(state.counts)+2)
+	var cumulative uint64
+	for i, count := range state.counts {
+		cumulative += count
+		bound := math.Inf(1)
+		if i < len(bounds) {
+			bound = bounds[i]
+		}
+		lines = append(lines, expositionLine{suffix: "_bucket", labels: withLabel(labels, "le", formatBound(bound)),
+			value: float64(cumulative), exemplar: state.exemplars[i]})
+	}
+	return append(lines,
+		expositionLine{suffix: "_sum", labels: labels, value: state.sum},
+		expositionLine{suffix: "_count", labels: labels, value: float64(cumulative)})
+}
+
+// withLabel returns the labels of a series in the text format with a label added last.
+func withLabel(labels, name, value string) string {
+	if labels == "" {
+		return "{" + name + `="` + value + `"}`
+	}
+	return strings.TrimSuffix(labels, "}") + "," + name + `="` + value + `"}`
+}
+
+// formatBound formats the upper bound of a bucket for the le label.
+func formatBound(bound float64) string {
+	if math.IsInf(bound, 1) {
+		return "+Inf"
+	}
+	return formatValue(bound)
+}
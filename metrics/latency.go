@@ -0,0 +1,208 @@
+package metrics
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWindow is the number of recent samples kept per endpoint by NewLatency(0).
+const DefaultWindow = 1024
+
+// Default is the latency registry fed by every GameHandler.
+var Default = NewLatency(DefaultWindow)
+
+// LatencyStats are the latency percentiles of one (game, endpoint) pair.
+//
+// Percentiles are computed over the most recent samples only (see NewLatency), so they
+// follow a degrading proxy pool or a game starting to tarpit clients within minutes.
+//
+// # Fields:
+//   - Game: The name of the game.
+//   - Endpoint: The normalized endpoint (see Endpoint).
+//   - Count: The number of requests observed since the registry was created or reset.
+//   - Errors: The number of those requests that failed without a response.
+//   - P50, P95, P99: The latency percentiles of the recent samples.
+//   - Max: The highest latency of the recent samples.
+type LatencyStats struct {
+	Game     string        `json:"game"`
+	Endpoint string        `json:"endpoint"`
+	Count    int64         `json:"count"`
+	Errors   int64         `json:"errors"`
+	P50      time.Duration `json:"p50"`
+	P95      time.Duration `json:"p95"`
+	P99      time.Duration `json:"p99"`
+	Max      time.Duration `json:"max"`
+}
+
+// Latency records request latencies per (game, endpoint) pair.
+//
+// # Example:
+//
+//	latency := metrics.NewLatency(0)
+//	latency.Observe("hamster", metrics.Endpoint("POST", "https://api.example.com/clicker/tap"), 120*time.Millisecond, nil)
+//	for _, stats := range latency.Snapshot() {
+//		fmt.Printf("%s %s p95=%s\n", stats.Game, stats.Endpoint, stats.P95)
+//	}
+type Latency struct {
+	window int
+	mu     sync.Mutex
+	series map[seriesKey]*series
+}
+
+type seriesKey struct {
+	game     string
+	endpoint string
+}
+
+// series is a ring buffer of the most recent samples of one endpoint.
+type series struct {
+	samples []time.Duration
+	next    int
+	count   int64
+	errors  int64
+}
+
+// NewLatency creates a latency registry keeping the given number of recent samples per
+// endpoint, DefaultWindow if window is not positive.
+func NewLatency(window int) *Latency {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Latency{window: window, series: make(map[seriesKey]*series)}
+}
+
+// Observe records the latency of a request. A non-nil err counts the request as failed;
+// its latency is recorded all the same, since timeouts are the latency worth seeing.
+func (latency *Latency) Observe(game, endpoint string, duration time.Duration, err error) {
+	latency.mu.Lock()
+	defer latency.mu.Unlock()
+	key := seriesKey{game: game, endpoint: endpoint}
+	current, ok := latency.series[key]
+	if !ok {
+		current = &series{samples: make([]time.Duration, 0, latency.window)}
+		latency.series[key] = current
+	}
+	current.count++
+	if err != nil {
+		current.errors++
+	}
+	if len(current.samples) < latency.window {
+		current.samples = append(current.samples, duration)
+		return
+	}
+	current.samples[current.next] = duration
+	current.next = (current.next + 1) % latency.window
+}
+
+// Snapshot returns the latency percentiles of every endpoint, sorted by game and endpoint.
+func (latency *Latency) Snapshot() []LatencyStats {
+	latency.mu.Lock()
+	defer latency.mu.Unlock()
+	snapshot := make([]LatencyStats, 0, len(latency.series))
+	for key, current := range latency.series {
+		sorted := append([]time.Duration(nil), current.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		stats := LatencyStats{
+			Game:     key.game,
+			Endpoint: key.endpoint,
+			Count:    current.count,
+			Errors:   current.errors,
+			P50:      percentile(sorted, 0.50),
+			P95:      percentile(sorted, 0.95),
+			P99:      percentile(sorted, 0.99),
+		}
+		if len(sorted) > 0 {
+			stats.Max = sorted[len(sorted)-1]
+		}
+		snapshot = append(snapshot, stats)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Game != snapshot[j].Game {
+			return snapshot[i].Game < snapshot[j].Game
+		}
+		return snapshot[i].Endpoint < snapshot[j].Endpoint
+	})
+	return snapshot
+}
+
+// Percentile returns the p-th percentile (0 < p <= 1) of the recent latencies of an endpoint,
+// together with the number of samples it is computed from.
+func (latency *Latency) Percentile(game, endpoint string, p float64) (time.Duration, int) {
+	latency.mu.Lock()
+	current, ok := latency.series[seriesKey{game: game, endpoint: endpoint}]
+	var sorted []time.Duration
+	if ok {
+		sorted = append(sorted, current.samples...)
+	}
+	latency.mu.Unlock()
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return percentile(sorted, p), len(sorted)
+}
+
+// Reset discards every recorded sample.
+func (latency *Latency) Reset() {
+	latency.mu.Lock()
+	defer latency.mu.Unlock()
+	latency.series = make(map[seriesKey]*series)
+}
+
+// percentile returns the nearest-rank percentile of sorted samples.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// Endpoint returns the normalized endpoint of a request, its method and path without the
+// query string, with numeric and long hexadecimal path segments replaced by ":id" so that
+// per-user URLs share one series.
+//
+// # Example:
+//
+//	metrics.Endpoint("GET", "https://api.example.com/users/123456/balance?x=1") // "GET /users/:id/balance"
+func Endpoint(method, rawURL string) string {
+	path := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil {
+		path = parsed.Path
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if isIdentifier(segment) {
+			segments[i] = ":id"
+		}
+	}
+	path = strings.Join(segments, "/")
+	if path == "" {
+		path = "/"
+	}
+	return method + " " + path
+}
+
+// isIdentifier reports whether a path segment looks like an ID rather than a route name.
+func isIdentifier(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	digits, hex := true, len(segment) >= 16
+	for _, r := range segment {
+		switch {
+		case r >= '0' && r <= '9':
+		case r >= 'a' && r <= 'f', r >= 'A' && r <= 'F', r == '-':
+			digits = false
+		default:
+			return false
+		}
+	}
+	return digits || hex
+}
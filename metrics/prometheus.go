@@ -0,0 +1,344 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The labels shared by the metrics of the SDK, so that dashboards filter and group every
+// metric of a farm the same way. Custom metrics of game adapters should use them too (see
+// handler.GameHandler.MetricLabels).
+const (
+	LabelGame      = "game"           // The name of the game.
+	LabelTask      = "task"           // The name of the task.
+	LabelCohort    = "account_cohort" // The cohort of the account (see handler.AccountCohort).
+	LabelProxyPool = "proxy_pool"     // The name of the proxy pool of the handler.
+)
+
+// The types of the metrics of a Registry.
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultRegistry is the registry fed by every GameHandler, with the latencies of Default.
+var DefaultRegistry = NewRegistry(Default)
+
+var (
+	metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelName  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Labels are the labels of a metric series, by name.
+type Labels map[string]string
+
+// With returns a copy of the labels with name set to value.
+func (labels Labels) With(name, value string) Labels {
+	copied := make(Labels, len(labels)+1)
+	for key, current := range labels {
+		copied[key] = current
+	}
+	copied[name] = value
+	return copied
+}
+
+// key returns the labels in the text format, sorted by name, e.g. `{game="blum",task="Claim"}`.
+func (labels Labels) key() string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var builder strings.Builder
+	builder.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(name)
+		builder.WriteString(`="`)
+		builder.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[name]))
+		builder.WriteByte('"')
+	}
+	builder.WriteByte('}')
+	return builder.String()
+}
+
+// Registry holds counters, gauges, and histograms by name and labels, and writes them in the Prometheus
+// text exposition format, along with the request latencies of a Latency registry.
+//
+// The latencies are written as the gauges nexus_request_latency_seconds (with a "quantile"
+// label of 0.5, 0.95, or 0.99) and the counters nexus_requests_total and
+// nexus_request_errors_total, labelled by game and endpoint.
+//
+// # Example:
+//
+//	metrics.DefaultRegistry.RegisterGauge("farm_balance_ton", "Total TON balance of the farm.",
+//		metrics.Labels{metrics.LabelGame: "blum"}, func() float64 { return farm.Balance() })
+//	http.Handle("/metrics", metrics.DefaultRegistry)
+type Registry struct {
+	latency  *Latency
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// family is a metric of a Registry with its series, and the bounds of its buckets if it is a
+// histogram.
+type family struct {
+	help    string
+	kind    string
+	series  map[string]*metricSeries
+	buckets []float64
+}
+
+// metricSeries is a series of a metric: a value, or a function read when the metrics are
+// written, and the exemplar of its last increment, if any. The series of a histogram holds its
+// buckets instead.
+type metricSeries struct {
+	value     float64
+	read      func() float64
+	exemplar  *Exemplar
+	histogram *histogram
+}
+
+// NewRegistry creates an empty registry writing the latencies of latency, if not nil.
+func NewRegistry(latency *Latency) *Registry {
+	return &Registry{latency: latency, families: make(map[string]*family)}
+}
+
+// family returns the metric name of type kind, created if needed. It must be called with mu
+// held.
+func (registry *Registry) family(name, help, kind string, labels Labels) (*family, error) {
+	if !metricName.MatchString(name) {
+		return nil, fmt.Errorf("invalid metric name %q", name)
+	}
+	for label := range labels {
+		if !labelName.MatchString(label) || strings.HasPrefix(label, "__") {
+			return nil, fmt.Errorf("invalid label name %q of metric %s", label, name)
+		}
+	}
+	current, ok := registry.families[name]
+	if !ok {
+		current = &family{help: help, kind: kind, series: make(map[string]*metricSeries)}
+		registry.families[name] = current
+	}
+	if current.kind != kind {
+		return nil, fmt.Errorf("metric %s is a %s, not a %s", name, current.kind, kind)
+	}
+	return current, nil
+}
+
+// Add adds delta to a counter. Counters only go up: a negative delta is an error.
+//
+// # Parameters:
+//   - name: The name of the counter, ending in "_total" by convention.
+//   - help: The description of the counter, used when it is created.
+//   - labels: The labels of the series.
+//   - delta: The value added.
+func (registry *Registry) Add(name, help string, labels Labels, delta float64) error {
+	return registry.add(name, help, labels, delta, nil)
+}
+
+// add adds delta to a counter, and replaces the exemplar of the series if exemplar is not nil.
+func (registry *Registry) add(name, help string, labels Labels, delta float64, exemplar *Exemplar) error {
+	if delta < 0 {
+		return fmt.Errorf("counter %s cannot decrease", name)
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	current, err := registry.family(name, help, TypeCounter, labels)
+	if err != nil {
+		return err
+	}
+	key := labels.key()
+	if current.series[key] == nil {
+		current.series[key] = &metricSeries{}
+	}
+	current.series[key].value += delta
+	if exemplar != nil {
+		current.series[key].exemplar = exemplar
+	}
+	return nil
+}
+
+// Set sets the value of a gauge.
+func (registry *Registry) Set(name, help string, labels Labels, value float64) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	current, err := registry.family(name, help, TypeGauge, labels)
+	if err != nil {
+		return err
+	}
+	current.series[labels.key()] = &metricSeries{value: value}
+	return nil
+}
+
+// RegisterGauge registers a gauge whose value is read from read every time the metrics are
+// written, e.g. the total balance of a farm computed by a game adapter. Registering a gauge
+// again with the same name and labels replaces its function.
+//
+// # Parameters:
+//   - name: The name of the gauge.
+//   - help: The description of the gauge, used when it is created.
+//   - labels: The labels of the series.
+//   - read: The function returning the value of the gauge. It must not block.
+//
+// # Returns:
+//   - error: An error if a name is invalid or name is not a gauge.
+func (registry *Registry) RegisterGauge(name, help string, labels Labels, read func() float64) error {
+	if read == nil {
+		return errors.New("gauge function is nil")
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	current, err := registry.family(name, help, TypeGauge, labels)
+	if err != nil {
+		return err
+	}
+	current.series[labels.key()] = &metricSeries{read: read}
+	return nil
+}
+
+// Unregister removes the series of a metric with labels, and the metric once it has no series.
+func (registry *Registry) Unregister(name string, labels Labels) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	current, ok := registry.families[name]
+	if !ok {
+		return
+	}
+	delete(current.series, labels.key())
+	if len(current.series) == 0 {
+		delete(registry.families, name)
+	}
+}
+
+// exposition is a metric as written by WritePrometheus.
+type exposition struct {
+	name  string
+	help  string
+	kind  string
+	lines []expositionLine
+}
+
+// expositionLine is a series of a metric as written by WritePrometheus: the suffix of the name
+// of a histogram series (e.g., "_bucket"), its labels, its value or the function returning it,
+// and its exemplar.
+type expositionLine struct {
+	suffix   string
+	labels   string
+	value    float64
+	read     func() float64
+	exemplar *Exemplar
+}
+
+// collect returns the metrics of the registry and the latencies, sorted by name and labels.
+func (registry *Registry) collect() []exposition {
+	registry.mu.Lock()
+	metrics := make([]exposition, 0, len(registry.families)+3)
+	for name, current := range registry.families {
+		metric := exposition{name: name, help: current.help, kind: current.kind}
+		keys := make([]string, 0, len(current.series))
+		for labels := range current.series {
+			keys = append(keys, labels)
+		}
+		sort.Strings(keys)
+		for _, labels := range keys {
+			series := current.series[labels]
+			if series.histogram != nil {
+				metric.lines = append(metric.lines, histogramLines(labels, current.buckets, series.histogram)...)
+				continue
+			}
+			metric.lines = append(metric.lines, expositionLine{labels: labels, value: series.value, read: series.read, exemplar: series.exemplar})
+		}
+		metrics = append(metrics, metric)
+	}
+	registry.mu.Unlock()
+	if registry.latency != nil {
+		metrics = append(metrics, latencyMetrics(registry.latency.Snapshot())...)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].name < metrics[j].name })
+	return metrics
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format (version 0.0.4),
+// sorted by name and labels. The gauge functions are called without the registry locked.
+//
+// The format has no exemplars; see WriteOpenMetrics.
+func (registry *Registry) WritePrometheus(w io.Writer) error {
+	buffered := bufio.NewWriter(w)
+	for _, metric := range registry.collect() {
+		if metric.help != "" {
+			fmt.Fprintf(buffered, "# HELP %s %s\n", metric.name, escapeHelp(metric.help))
+		}
+		fmt.Fprintf(buffered, "# TYPE %s %s\n", metric.name, metric.kind)
+		for _, line := range metric.lines {
+			fmt.Fprintf(buffered, "%s%s%s %s\n", metric.name, line.suffix, line.labels, formatValue(line.current()))
+		}
+	}
+	return buffered.Flush()
+}
+
+// current returns the value of a series, reading it from its function if it has one.
+func (line expositionLine) current() float64 {
+	if line.read != nil {
+		return line.read()
+	}
+	return line.value
+}
+
+// escapeHelp escapes the description of a metric for the text formats.
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// formatValue formats the value of a series for the text formats.
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// latencyMetrics returns the request latencies of a Latency registry as metrics, labelled by
+// game and endpoint.
+func latencyMetrics(stats []LatencyStats) []exposition {
+	latencies := exposition{name: "nexus_request_latency_seconds", help: "Request latency percentiles over the recent requests.", kind: TypeGauge}
+	requests := exposition{name: "nexus_requests_total", help: "Requests sent.", kind: TypeCounter}
+	failures := exposition{name: "nexus_request_errors_total", help: "Requests that failed without a response.", kind: TypeCounter}
+	for _, endpoint := range stats {
+		labels := Labels{LabelGame: endpoint.Game, "endpoint": endpoint.Endpoint}
+		latencies.lines = append(latencies.lines,
+			expositionLine{labels: labels.With("quantile", "0.5").key(), value: endpoint.P50.Seconds()},
+			expositionLine{labels: labels.With("quantile", "0.95").key(), value: endpoint.P95.Seconds()},
+			expositionLine{labels: labels.With("quantile", "0.99").key(), value: endpoint.P99.Seconds()})
+		requests.lines = append(requests.lines, expositionLine{labels: labels.key(), value: float64(endpoint.Count)})
+		failures.lines = append(failures.lines, expositionLine{labels: labels.key(), value: float64(endpoint.Errors)})
+	}
+	return []exposition{latencies, requests, failures}
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format, or in the OpenMetrics
+// format, with exemplars, to scrapers accepting it (see WriteOpenMetrics).
+func (registry *Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text") {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		_ = registry.WriteOpenMetrics(w)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = registry.WritePrometheus(w)
+}
@@ -0,0 +1,282 @@
+package nexustest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RouteFunc answers a game request with a status code and a value encoded as the JSON response.
+//
+// # Parameters:
+//   - account: The Telegram ID of the authenticated account, or "" when the request carries no token.
+//   - body: The request body.
+type RouteFunc func(account string, body []byte) (int, interface{})
+
+// Request is a request received by a Server.
+type Request struct {
+	Time    time.Time
+	Method  string
+	Path    string
+	Header  http.Header
+	Account string
+	Body    []byte
+	Status  int
+}
+
+// Server is an in-process fake of the Nexus API and a game API, for integration tests of
+// game adapters and task configurations without network access.
+//
+// The server simulates:
+//   - Auth: POST /api/telegram/game-data issues a game token for the account, like the Nexus
+//     API does, and rejects unknown API keys with 401.
+//   - Token expiry: Game requests carrying an expired or unknown token are rejected with 401.
+//   - Rate limiting: Accounts sending more than RateLimit requests per RateWindow get 429.
+//   - Maintenance: While enabled, every game request gets 503.
+//
+// Game requests are authenticated by the token in the Authorization header ("Bearer <token>"
+// or the bare token), in the "initData" query parameter, or in the "game-data" or "initData"
+// field of a JSON body, so tasks using any location of the game data (see tasks.Authorize)
+// with its default name are recognized.
+//
+// # Fields:
+//   - Server: The underlying httptest server.
+//   - APIKey: The API key accepted by the auth endpoint. Empty accepts any key.
+//   - RequireAuth: Whether game requests without a valid token are rejected.
+//   - TokenTTL: How long issued tokens stay valid. Zero means forever.
+//   - RateLimit: The maximum number of game requests per account per RateWindow. Zero disables rate limiting.
+//   - RateWindow: The rate limiting window. Defaults to one minute.
+//
+// # Example:
+//
+//	server := nexustest.NewServer()
+//	defer server.Close()
+//	server.Handle("/claim", func(account string, body []byte) (int, interface{}) {
+//		return http.StatusOK, map[string]interface{}{"balance": 100}
+//	})
+//	gameHandler.SetBaseURL(server.GameURL() + "/claim")
+//	gameHandler.SetNexusAPIURL(server.NexusAPIURL())
+type Server struct {
+	*httptest.Server
+	APIKey      string
+	RequireAuth bool
+	TokenTTL    time.Duration
+	RateLimit   int
+	RateWindow  time.Duration
+	mu          sync.Mutex
+	now         func() time.Time
+	maintenance bool
+	routes      map[string]RouteFunc
+	tokens      map[string]token
+	rates       map[string][]time.Time
+	requests    []Request
+	issued      int
+}
+
+// token is a game token issued by the auth endpoint.
+type token struct {
+	account string
+	expires time.Time
+}
+
+// NewServer starts a fake server. Call Close when done.
+func NewServer() *Server {
+	server := &Server{
+		now:    time.Now,
+		routes: make(map[string]RouteFunc),
+		tokens: make(map[string]token),
+		rates:  make(map[string][]time.Time),
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	return server
+}
+
+// NexusAPIURL returns the base URL of the fake Nexus API, for GameHandler.SetNexusAPIURL.
+func (server *Server) NexusAPIURL() string {
+	return server.URL + "/api"
+}
+
+// GameURL returns the base URL of the fake game API; routes registered with Handle are below it.
+// Requests to the base URL itself are game requests too, answered by the route "/".
+func (server *Server) GameURL() string {
+	return server.URL + "/game"
+}
+
+// Handle registers the answer of a game route, e.g. "/claim" for GameURL() + "/claim".
+//
+// Unregistered routes answer 200 with {"ok": true}.
+func (server *Server) Handle(path string, route RouteFunc) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.routes["/"+strings.TrimPrefix(path, "/")] = route
+}
+
+// SetNow replaces the time source of the server, e.g. with the Now method of a testutil.FakeClock.
+func (server *Server) SetNow(now func() time.Time) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.now = now
+}
+
+// SetMaintenance enables or disables the maintenance mode.
+func (server *Server) SetMaintenance(enabled bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.maintenance = enabled
+}
+
+// IssueToken issues a valid token for an account without going through the auth endpoint,
+// e.g. to use as the initial "game-data" of a test account.
+func (server *Server) IssueToken(account string) string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.issueToken(account)
+}
+
+// ExpireTokens invalidates every issued token, as if they all expired.
+func (server *Server) ExpireTokens() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.tokens = make(map[string]token)
+}
+
+// Requests returns the requests received so far, auth requests included.
+func (server *Server) Requests() []Request {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]Request(nil), server.requests...)
+}
+
+func (server *Server) issueToken(account string) string {
+	server.issued++
+	value := fmt.Sprintf("token-%s-%d", account, server.issued)
+	issued := token{account: account}
+	if server.TokenTTL > 0 {
+		issued.expires = server.now().Add(server.TokenTTL)
+	}
+	server.tokens[value] = issued
+	return value
+}
+
+func (server *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	server.mu.Lock()
+	request := Request{Time: server.now(), Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body}
+	var status int
+	var response interface{}
+	switch {
+	case r.URL.Path == "/api/telegram/game-data":
+		request.Account, status, response = server.serveAuth(body)
+	case r.URL.Path == "/game" || strings.HasPrefix(r.URL.Path, "/game/"):
+		var route RouteFunc
+		request.Account, route, status, response = server.serveGame(r, body)
+		if route != nil {
+			server.mu.Unlock()
+			status, response = route(request.Account, body)
+			server.mu.Lock()
+		}
+	default:
+		status, response = http.StatusNotFound, map[string]interface{}{"error": "not found"}
+	}
+	request.Status = status
+	server.requests = append(server.requests, request)
+	server.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// serveAuth answers the Nexus API game data endpoint. It must be called with mu held.
+func (server *Server) serveAuth(body []byte) (string, int, interface{}) {
+	var request struct {
+		APIKey   string `json:"api-key"`
+		Telegram struct {
+			TelegramId string `json:"telegramId"`
+		} `json:"telegram"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return "", http.StatusBadRequest, map[string]interface{}{"error": "invalid request body"}
+	}
+	account := request.Telegram.TelegramId
+	if server.APIKey != "" && request.APIKey != server.APIKey {
+		return account, http.StatusUnauthorized, map[string]interface{}{"error": "invalid api key"}
+	}
+	return account, http.StatusOK, map[string]interface{}{"game-data": server.issueToken(account)}
+}
+
+// serveGame applies maintenance, auth, and rate limiting to a game request and returns the
+// route answering it, if the request passed. It must be called with mu held.
+func (server *Server) serveGame(r *http.Request, body []byte) (string, RouteFunc, int, interface{}) {
+	if server.maintenance {
+		return "", nil, http.StatusServiceUnavailable, map[string]interface{}{"error": "maintenance"}
+	}
+	account := ""
+	if value := requestToken(r, body); value != "" {
+		if issued, ok := server.tokens[value]; ok {
+			if !issued.expires.IsZero() && !server.now().Before(issued.expires) {
+				return issued.account, nil, http.StatusUnauthorized, map[string]interface{}{"error": "token expired"}
+			}
+			account = issued.account
+		}
+	}
+	if account == "" && server.RequireAuth {
+		return "", nil, http.StatusUnauthorized, map[string]interface{}{"error": "unauthorized"}
+	}
+	if server.RateLimit > 0 {
+		key := account
+		if key == "" {
+			key = r.RemoteAddr
+		}
+		window := server.RateWindow
+		if window <= 0 {
+			window = time.Minute
+		}
+		now := server.now()
+		recent := server.rates[key][:0]
+		for _, sent := range server.rates[key] {
+			if now.Sub(sent) < window {
+				recent = append(recent, sent)
+			}
+		}
+		if len(recent) >= server.RateLimit {
+			server.rates[key] = recent
+			return account, nil, http.StatusTooManyRequests, map[string]interface{}{"error": "too many requests"}
+		}
+		server.rates[key] = append(recent, now)
+	}
+	route, ok := server.routes["/"+strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/game"), "/")]
+	if !ok {
+		route = func(string, []byte) (int, interface{}) {
+			return http.StatusOK, map[string]interface{}{"ok": true}
+		}
+	}
+	return account, route, 0, nil
+}
+
+// requestToken returns the token of a game request, from its Authorization header, query, or
+// body.
+func requestToken(r *http.Request, body []byte) string {
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		return strings.TrimPrefix(authorization, "Bearer ")
+	}
+	if initData := r.URL.Query().Get("initData"); initData != "" {
+		return initData
+	}
+	var payload struct {
+		GameData string `json:"game-data"`
+		InitData string `json:"initData"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		if payload.GameData != "" {
+			return payload.GameData
+		}
+		return payload.InitData
+	}
+	return ""
+}
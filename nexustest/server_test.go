@@ -0,0 +1,206 @@
+package nexustest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/nexus-telegram/NexusSDK/nexustest"
+	"github.com/nexus-telegram/NexusSDK/testutil"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// send sends a request to the server and returns the status and decoded body of the response.
+func send(t *testing.T, method, rawURL string, header http.Header, body []byte) (int, map[string]interface{}) {
+	t.Helper()
+	request, err := http.NewRequest(method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("response %q is not a JSON object: %v", data, err)
+	}
+	return response.StatusCode, decoded
+}
+
+// login requests game data for account from the auth endpoint.
+func login(t *testing.T, server *nexustest.Server, apiKey, account string) (int, string) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]interface{}{"api-key": apiKey, "telegram": map[string]string{"telegramId": account}})
+	status, response := send(t, http.MethodPost, server.NexusAPIURL()+"/telegram/game-data", nil, body)
+	gameData, _ := response["game-data"].(string)
+	return status, gameData
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		serverKey  string
+		apiKey     string
+		wantStatus int
+	}{
+		{"any key accepted", "", "whatever", http.StatusOK},
+		{"matching key", "secret", "secret", http.StatusOK},
+		{"wrong key", "secret", "guess", http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := nexustest.NewServer()
+			defer server.Close()
+			server.APIKey = test.serverKey
+			server.RequireAuth = true
+			status, gameData := login(t, server, test.apiKey, "42")
+			if status != test.wantStatus {
+				t.Fatalf("auth answered %d, want %d", status, test.wantStatus)
+			}
+			if status != http.StatusOK {
+				return
+			}
+			header := http.Header{"Authorization": {gameData}}
+			if status, _ := send(t, http.MethodGet, server.GameURL(), header, nil); status != http.StatusOK {
+				t.Errorf("game request with the issued game data answered %d, want 200", status)
+			}
+			requests := server.Requests()
+			if len(requests) != 2 || requests[0].Account != "42" || requests[1].Account != "42" {
+				t.Errorf("Requests() = %+v, want the auth and game requests of account 42", requests)
+			}
+		})
+	}
+	t.Run("invalid body", func(t *testing.T) {
+		server := nexustest.NewServer()
+		defer server.Close()
+		if status, _ := send(t, http.MethodPost, server.NexusAPIURL()+"/telegram/game-data", nil, []byte("{")); status != http.StatusBadRequest {
+			t.Errorf("auth of an invalid body answered %d, want 400", status)
+		}
+	})
+}
+
+func TestGameAuth(t *testing.T) {
+	server := nexustest.NewServer()
+	defer server.Close()
+	server.RequireAuth = true
+	token := server.IssueToken("7")
+	tests := []struct {
+		name        string
+		method      string
+		query       url.Values
+		header      http.Header
+		body        string
+		wantStatus  int
+		wantAccount string
+	}{
+		{"bearer header", http.MethodGet, nil, http.Header{"Authorization": {"Bearer " + token}}, "", http.StatusOK, "7"},
+		{"bare header", http.MethodGet, nil, http.Header{"Authorization": {token}}, "", http.StatusOK, "7"},
+		{"query", http.MethodGet, url.Values{"initData": {token}}, nil, "", http.StatusOK, "7"},
+		{"game-data field", http.MethodPost, nil, nil, `{"game-data": "` + token + `"}`, http.StatusOK, "7"},
+		{"initData field", http.MethodPost, nil, nil, `{"initData": "` + token + `"}`, http.StatusOK, "7"},
+		{"no token", http.MethodGet, nil, nil, "", http.StatusUnauthorized, ""},
+		{"unknown token", http.MethodGet, nil, http.Header{"Authorization": {"forged"}}, "", http.StatusUnauthorized, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rawURL := server.GameURL() + "/claim"
+			if test.query != nil {
+				rawURL += "?" + test.query.Encode()
+			}
+			status, _ := send(t, test.method, rawURL, test.header, []byte(test.body))
+			requests := server.Requests()
+			last := requests[len(requests)-1]
+			if status != test.wantStatus || last.Status != status || last.Account != test.wantAccount {
+				t.Errorf("request answered %d for account %q (recorded %d), want %d for account %q",
+					status, last.Account, last.Status, test.wantStatus, test.wantAccount)
+			}
+		})
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	server := nexustest.NewServer()
+	defer server.Close()
+	server.Handle("claim", func(account string, body []byte) (int, interface{}) {
+		return http.StatusCreated, map[string]interface{}{"account": account, "body": string(body)}
+	})
+	server.Handle("/", func(account string, body []byte) (int, interface{}) {
+		return http.StatusAccepted, map[string]interface{}{"root": true}
+	})
+	token := server.IssueToken("9")
+	tests := []struct {
+		name       string
+		rawURL     string
+		wantStatus int
+		wantBody   map[string]interface{}
+	}{
+		{"registered route", server.GameURL() + "/claim", http.StatusCreated, map[string]interface{}{"account": "9", "body": "{}"}},
+		{"base URL", server.GameURL(), http.StatusAccepted, map[string]interface{}{"root": true}},
+		{"unregistered route", server.GameURL() + "/other", http.StatusOK, map[string]interface{}{"ok": true}},
+		{"outside the APIs", server.URL + "/elsewhere", http.StatusNotFound, map[string]interface{}{"error": "not found"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, body := send(t, http.MethodPost, test.rawURL, http.Header{"Authorization": {token}}, []byte("{}"))
+			if status != test.wantStatus || !equalJSON(body, test.wantBody) {
+				t.Errorf("answered %d %v, want %d %v", status, body, test.wantStatus, test.wantBody)
+			}
+		})
+	}
+}
+
+func TestFailureModes(t *testing.T) {
+	fake := testutil.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	server := nexustest.NewServer()
+	defer server.Close()
+	server.SetNow(fake.Now)
+	server.RequireAuth = true
+	server.TokenTTL = time.Hour
+	server.RateLimit = 2
+	server.RateWindow = time.Minute
+	first, second := server.IssueToken("1"), server.IssueToken("2")
+
+	// Each step optionally changes the server, then sends a game request with a token.
+	steps := []struct {
+		name       string
+		change     func()
+		token      string
+		wantStatus int
+	}{
+		{"first request", nil, first, http.StatusOK},
+		{"second request", nil, first, http.StatusOK},
+		{"rate limited", nil, first, http.StatusTooManyRequests},
+		{"other account not limited", nil, second, http.StatusOK},
+		{"window passed", func() { fake.Advance(time.Minute) }, first, http.StatusOK},
+		{"maintenance", func() { server.SetMaintenance(true) }, first, http.StatusServiceUnavailable},
+		{"maintenance over", func() { server.SetMaintenance(false) }, first, http.StatusOK},
+		{"token expired", func() { fake.Advance(time.Hour) }, first, http.StatusUnauthorized},
+		{"tokens revoked", server.ExpireTokens, second, http.StatusUnauthorized},
+	}
+	for _, step := range steps {
+		if step.change != nil {
+			step.change()
+		}
+		if status, _ := send(t, http.MethodGet, server.GameURL(), http.Header{"Authorization": {step.token}}, nil); status != step.wantStatus {
+			t.Errorf("%s: answered %d, want %d", step.name, status, step.wantStatus)
+		}
+	}
+}
+
+// equalJSON reports whether two decoded JSON objects are equal.
+func equalJSON(a, b map[string]interface{}) bool {
+	encodedA, _ := json.Marshal(a)
+	encodedB, _ := json.Marshal(b)
+	return bytes.Equal(encodedA, encodedB)
+}
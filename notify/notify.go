@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// Notifier delivers human-readable messages to an operator channel.
+type Notifier interface {
+	Notify(ctx context.Context, message string) error
+}
+
+// TelegramMaxMessageLength is the maximum length of a Telegram message text, in characters.
+const TelegramMaxMessageLength = 4096
+
+// Telegram is a Notifier sending messages to a Telegram chat through the Bot API.
+//
+// # Fields:
+//   - BotToken: The token of the bot sending the messages.
+//   - ChatID: The ID (or @username) of the chat receiving the messages.
+//   - APIURL: The Bot API base URL. Defaults to "https://api.telegram.org".
+//   - Client: The HTTP client used for requests. Defaults to a client with a 10 second timeout.
+//
+// # Example:
+//
+//	notifier := &notify.Telegram{BotToken: "123456:ABC-DEF", ChatID: "-1001234567890"}
+//	if err := notifier.Notify(context.Background(), "Farm started"); err != nil {
+//		log.Printf("Failed to notify: %v", err)
+//	}
+type Telegram struct {
+	BotToken string
+	ChatID   string
+	APIURL   string
+	Client   *http.Client
+}
+
+// NewTelegram creates a Telegram notifier for the given bot token and chat.
+func NewTelegram(botToken, chatID string) *Telegram {
+	return &Telegram{BotToken: botToken, ChatID: chatID}
+}
+
+// Notify sends a message to the configured chat, truncating it to the Telegram length limit
+// on a character boundary.
+func (telegram *Telegram) Notify(ctx context.Context, message string) error {
+	message = Truncate(message, TelegramMaxMessageLength)
+	apiURL := telegram.APIURL
+	if apiURL == "" {
+		apiURL = "https://api.telegram.org"
+	}
+	client := telegram.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  telegram.ChatID,
+		"text":                     message,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/bot%s/sendMessage", apiURL, telegram.BotToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Telegram message: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+		}
+	}(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		responseBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to send Telegram message: status %d: %s", resp.StatusCode, responseBody)
+	}
+	return nil
+}
+
+// Truncate returns message cut to at most limit characters, ending in "..." when it was cut.
+// It never splits a multi-byte character, which Telegram would reject as invalid UTF-8.
+func Truncate(message string, limit int) string {
+	if utf8.RuneCountInString(message) <= limit {
+		return message
+	}
+	runes := []rune(message)
+	if limit < 3 {
+		return string(runes[:limit])
+	}
+	return string(runes[:limit-3]) + "..."
+}
@@ -0,0 +1,172 @@
+// Package progress reports the progress of batch runs, such as one-time tasks executed on
+// thousands of accounts, on a terminal or in a log file.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// barWidth is the number of characters of the progress bar.
+const barWidth = 30
+
+// Outcome is the result of one unit of work of a batch.
+type Outcome int
+
+const (
+	Completed Outcome = iota // The unit succeeded
+	Failed                   // The unit failed
+	Skipped                  // The unit was not executed
+)
+
+// Reporter renders the completed, failed, and remaining units of a batch with an ETA.
+//
+// On a terminal, a single line with a progress bar is redrawn at most every Interval. On any
+// other writer, such as a redirected stderr, one line is printed every Interval instead.
+//
+// # Fields:
+//   - Label: The description of the batch (e.g., "claiming welcome bonus").
+//   - Out: The writer the progress is rendered to.
+//   - Interval: The minimum delay between two renderings. Defaults to 200ms on a terminal and
+//     10s otherwise.
+//
+// # Example:
+//
+//	reporter := progress.New(os.Stderr, "claiming welcome bonus", 0)
+//	gameHandler.TrackProgress(reporter)
+//	gameHandler.RunTasks()
+//	reporter.Finish()
+type Reporter struct {
+	Label     string
+	Out       io.Writer
+	Interval  time.Duration
+	mu        sync.Mutex
+	tty       bool
+	total     int
+	completed int
+	failed    int
+	skipped   int
+	started   time.Time
+	rendered  time.Time
+	finished  bool
+}
+
+// New creates a reporter of a batch of total units rendered to out.
+func New(out io.Writer, label string, total int) *Reporter {
+	reporter := &Reporter{Label: label, Out: out, total: total, tty: isTerminal(out), started: time.Now()}
+	if reporter.tty {
+		reporter.Interval = 200 * time.Millisecond
+	} else {
+		reporter.Interval = 10 * time.Second
+	}
+	return reporter
+}
+
+// isTerminal reports whether out is a character device.
+func isTerminal(out io.Writer) bool {
+	file, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// AddTotal adds units to the batch.
+func (reporter *Reporter) AddTotal(units int) {
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	reporter.total += units
+}
+
+// Add records the outcome of one unit and renders the progress if Interval has passed.
+func (reporter *Reporter) Add(outcome Outcome) {
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	now := time.Now()
+	switch outcome {
+	case Completed:
+		reporter.completed++
+	case Failed:
+		reporter.failed++
+	case Skipped:
+		reporter.skipped++
+	}
+	if now.Sub(reporter.rendered) >= reporter.Interval || reporter.done() == reporter.total {
+		reporter.render(now)
+	}
+}
+
+// Finish renders the final progress. Later calls do nothing.
+func (reporter *Reporter) Finish() {
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	if reporter.finished {
+		return
+	}
+	reporter.finished = true
+	reporter.render(time.Now())
+	if reporter.tty {
+		_, _ = io.WriteString(reporter.Out, "\n")
+	}
+}
+
+// done returns the number of units with an outcome. It must be called with mu held.
+func (reporter *Reporter) done() int {
+	return reporter.completed + reporter.failed + reporter.skipped
+}
+
+// render writes the progress line. It must be called with mu held.
+func (reporter *Reporter) render(now time.Time) {
+	reporter.rendered = now
+	done := reporter.done()
+	remaining := max(reporter.total-done, 0)
+	var builder strings.Builder
+	if reporter.tty {
+		builder.WriteString("\r\033[K")
+	}
+	if reporter.Label != "" {
+		fmt.Fprintf(&builder, "%s: ", reporter.Label)
+	}
+	if reporter.tty && reporter.total > 0 {
+		filled := min(barWidth*done/reporter.total, barWidth)
+		fmt.Fprintf(&builder, "[%s%s] ", strings.Repeat("#", filled), strings.Repeat(".", barWidth-filled))
+	}
+	fmt.Fprintf(&builder, "%s/%s", Count(done), Count(reporter.total))
+	fmt.Fprintf(&builder, "  ok %s  failed %s", Count(reporter.completed), Count(reporter.failed))
+	if reporter.skipped > 0 {
+		fmt.Fprintf(&builder, "  skipped %s", Count(reporter.skipped))
+	}
+	fmt.Fprintf(&builder, "  remaining %s", Count(remaining))
+	if done > 0 && remaining > 0 {
+		elapsed := now.Sub(reporter.started)
+		eta := time.Duration(float64(elapsed) / float64(done) * float64(remaining))
+		fmt.Fprintf(&builder, "  ETA %s", eta.Round(time.Second))
+	}
+	if !reporter.tty {
+		builder.WriteString("\n")
+	}
+	_, _ = io.WriteString(reporter.Out, builder.String())
+}
+
+// Count formats n with thousands separators (e.g., "1,240").
+func Count(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var builder strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteRune(digit)
+	}
+	return sign + builder.String()
+}
@@ -0,0 +1,221 @@
+package proxypool
+
+import (
+	"github.com/nexus-telegram/NexusSDK/types"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultHealthWindow is the sliding window of the request outcomes of a proxy, unless the
+// HealthPolicy sets another one.
+const DefaultHealthWindow = 10 * time.Minute
+
+// DefaultMinRequests is the number of requests a proxy must have sent within the window before
+// its error rate is judged, unless the HealthPolicy sets another one.
+const DefaultMinRequests = 10
+
+// HealthPolicy configures when a proxy with a high error rate is retired from its pool.
+//
+// # Fields:
+//   - Threshold: The share of failed requests (0-1) within Window above which the proxy is
+//     retired. Zero disables retirement by error rate; proxies that cannot be reached are
+//     still retired (see IsProxyError).
+//   - MinRequests: The number of requests within Window below which the error rate is not
+//     judged. Defaults to DefaultMinRequests.
+//   - Window: The sliding window of the request outcomes. Defaults to DefaultHealthWindow.
+type HealthPolicy struct {
+	Threshold   float64
+	MinRequests int
+	Window      time.Duration
+}
+
+// ProxyStats is the health of a proxy of a pool.
+//
+// # Fields:
+//   - Proxy: The proxy. It is left out of the JSON encoding, which would reveal its credentials.
+//   - Address: The address of the proxy ("ip:port").
+//   - Requests: The requests sent through the proxy within the window of the HealthPolicy.
+//   - Failures: The requests among them that failed.
+//   - Accounts: The number of accounts assigned to the proxy.
+//   - Dead: Whether the proxy was retired.
+type ProxyStats struct {
+	Proxy    types.Proxy `json:"-"`
+	Address  string      `json:"address"`
+	Requests int         `json:"requests"`
+	Failures int         `json:"failures"`
+	Accounts int         `json:"accounts"`
+	Dead     bool        `json:"dead"`
+}
+
+// Replacement is the retirement of a proxy of a pool, reported so that the operator can
+// replace it.
+//
+// # Fields:
+//   - Proxy: The retired proxy. It is left out of the JSON encoding, which would reveal its
+//     credentials.
+//   - Address: The address of the proxy ("ip:port").
+//   - Requests: The requests sent through the proxy within the window of the HealthPolicy.
+//   - Failures: The requests among them that failed.
+//   - Accounts: The Telegram IDs of the accounts moved to other proxies, sorted.
+//   - At: When the proxy was retired.
+type Replacement struct {
+	Proxy    types.Proxy `json:"-"`
+	Address  string      `json:"address"`
+	Requests int         `json:"requests"`
+	Failures int         `json:"failures"`
+	Accounts []string    `json:"accounts"`
+	At       time.Time   `json:"at"`
+}
+
+// outcome is the result of a request sent through a proxy.
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+// SetHealthPolicy sets when the proxies of the pool are retired because of their error rate.
+func (pool *Pool) SetHealthPolicy(policy HealthPolicy) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.health = policy
+}
+
+// Report records the outcome of a request sent through a proxy, and retires the proxy when
+// its error rate exceeds the threshold of the HealthPolicy.
+//
+// # Parameters:
+//   - proxy: The proxy of the request.
+//   - failed: Whether the request failed because of the proxy or the network, as opposed to
+//     an error response of the destination server.
+//
+// # Returns:
+//   - Replacement: The retirement of the proxy, if it was retired.
+//   - bool: Whether the proxy was retired by this report.
+func (pool *Pool) Report(proxy types.Proxy, failed bool) (Replacement, bool) {
+	now := time.Now()
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	index, ok := pool.index(proxy)
+	if !ok || pool.proxies[index].dead {
+		return Replacement{}, false
+	}
+	candidate := pool.proxies[index]
+	candidate.outcomes = append(pool.recent(candidate, now), outcome{at: now, failed: failed})
+	requests, failures := tally(candidate.outcomes)
+	minRequests := pool.health.MinRequests
+	if minRequests <= 0 {
+		minRequests = DefaultMinRequests
+	}
+	if pool.health.Threshold <= 0 || requests < minRequests || float64(failures) <= pool.health.Threshold*float64(requests) {
+		return Replacement{}, false
+	}
+	return pool.retire(index, now), true
+}
+
+// Retire marks a proxy as dead and moves its accounts to the healthy proxies with the fewest
+// accounts.
+//
+// # Returns:
+//   - Replacement: The retirement of the proxy.
+//   - bool: Whether the proxy was retired, false if it is not in the pool or already dead.
+func (pool *Pool) Retire(proxy types.Proxy) (Replacement, bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	index, ok := pool.index(proxy)
+	if !ok || pool.proxies[index].dead {
+		return Replacement{}, false
+	}
+	return pool.retire(index, time.Now()), true
+}
+
+// Stats returns the health of every proxy of the pool.
+func (pool *Pool) Stats() []ProxyStats {
+	now := time.Now()
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	accounts := make([]int, len(pool.proxies))
+	for _, current := range pool.assignments {
+		if pool.strategy != StrategySticky || now.Before(current.expires) {
+			accounts[current.index]++
+		}
+	}
+	stats := make([]ProxyStats, 0, len(pool.proxies))
+	for i, candidate := range pool.proxies {
+		candidate.outcomes = pool.recent(candidate, now)
+		requests, failures := tally(candidate.outcomes)
+		stats = append(stats, ProxyStats{
+			Proxy:    candidate.proxy,
+			Address:  Address(candidate.proxy),
+			Requests: requests,
+			Failures: failures,
+			Accounts: accounts[i],
+			Dead:     candidate.dead,
+		})
+	}
+	return stats
+}
+
+// retire marks the proxy at index as dead and reassigns its accounts. It must be called with
+// mu held.
+func (pool *Pool) retire(index int, now time.Time) Replacement {
+	candidate := pool.proxies[index]
+	candidate.dead = true
+	requests, failures := tally(candidate.outcomes)
+	candidate.outcomes = nil
+	replacement := Replacement{Proxy: candidate.proxy, Address: Address(candidate.proxy), Requests: requests, Failures: failures, At: now}
+	for account, current := range pool.assignments {
+		if current.index != index {
+			continue
+		}
+		replacement.Accounts = append(replacement.Accounts, account)
+		if next, err := pool.leastAssigned(current.country); err == nil {
+			pool.assignments[account] = &assignment{index: next, expires: now.Add(pool.stickyTTL), country: current.country}
+		} else {
+			delete(pool.assignments, account)
+		}
+	}
+	sort.Strings(replacement.Accounts)
+	return replacement
+}
+
+// recent returns the outcomes of a proxy within the window of the health policy. It must be
+// called with mu held.
+func (pool *Pool) recent(candidate *member, now time.Time) []outcome {
+	window := pool.health.Window
+	if window <= 0 {
+		window = DefaultHealthWindow
+	}
+	outcomes := candidate.outcomes
+	for len(outcomes) > 0 && !outcomes[0].at.After(now.Add(-window)) {
+		outcomes = outcomes[1:]
+	}
+	return outcomes
+}
+
+// index returns the index of a proxy in the pool. It must be called with mu held.
+func (pool *Pool) index(proxy types.Proxy) (int, bool) {
+	key := Key(proxy)
+	for i, candidate := range pool.proxies {
+		if Key(candidate.proxy) == key {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Address returns the address of a proxy ("ip:port"), without its credentials.
+func Address(proxy types.Proxy) string {
+	return net.JoinHostPort(proxy.Ip, strconv.Itoa(proxy.Port))
+}
+
+// tally counts the requests and failures of outcomes.
+func tally(outcomes []outcome) (requests, failures int) {
+	for _, result := range outcomes {
+		if result.failed {
+			failures++
+		}
+	}
+	return len(outcomes), failures
+}
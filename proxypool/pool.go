@@ -0,0 +1,396 @@
+package proxypool
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Strategy selects how proxies from a Pool are assigned to requests.
+type Strategy string
+
+const (
+	// StrategyPerAccount assigns one proxy to each account for the lifetime of the pool.
+	StrategyPerAccount Strategy = "per_account"
+	// StrategyRoundRobin picks the next healthy proxy for every request.
+	StrategyRoundRobin Strategy = "round_robin"
+	// StrategySticky assigns a proxy to each account for a limited time (the sticky TTL).
+	StrategySticky Strategy = "sticky"
+)
+
+// CountryMode selects how strictly a Pool keeps accounts on proxies of their country.
+type CountryMode string
+
+const (
+	// CountryLenient prefers proxies of the account's country and falls back to any healthy
+	// proxy when none is left.
+	CountryLenient CountryMode = "lenient"
+	// CountryStrict only assigns proxies of the account's country, failing with
+	// ErrNoProxyInCountry when none is healthy.
+	CountryStrict CountryMode = "strict"
+)
+
+// ParseCountryMode parses a country mode name. An empty name is CountryLenient.
+func ParseCountryMode(name string) (CountryMode, error) {
+	switch mode := CountryMode(name); mode {
+	case "":
+		return CountryLenient, nil
+	case CountryLenient, CountryStrict:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown proxy country mode: %s", name)
+	}
+}
+
+// ErrNoHealthyProxy is returned when every proxy of a pool has been marked as dead.
+var ErrNoHealthyProxy = errors.New("no healthy proxy available")
+
+// ErrNoProxyInCountry is returned in CountryStrict mode when no healthy proxy of the account's
+// country is left.
+var ErrNoProxyInCountry = errors.New("no healthy proxy available in the account's country")
+
+// Pool distributes a list of proxies across accounts and requests.
+//
+// Proxies marked as dead are skipped, and accounts assigned to a dead proxy are
+// automatically reassigned to a healthy one on their next request.
+//
+// Accounts with a country (see AcquireIn) are only assigned proxies of the same country, as a
+// geo switch between sessions is a common ban cause. Proxies without a country match no
+// country. In CountryLenient mode (the default), accounts fall back to other proxies when no
+// proxy of their country is healthy.
+//
+// # Fields:
+//   - proxies: The proxies of the pool and their health.
+//   - strategy: The assignment strategy.
+//   - stickyTTL: How long an assignment lasts with StrategySticky.
+//   - next: The index of the next proxy for round-robin selection.
+//   - assignments: The current proxy assignment of each account (keyed by Telegram ID).
+//   - health: When proxies are retired because of their error rate.
+//   - countryMode: How strictly accounts are kept on proxies of their country.
+//   - sessionTTL: How long accounts keep the exit IP of rotating gateways.
+//   - sessions: The gateway sessions of the accounts (see Session).
+//   - mu: A mutex for thread-safe operations.
+type Pool struct {
+	proxies     []*member
+	strategy    Strategy
+	stickyTTL   time.Duration
+	next        int
+	assignments map[string]*assignment
+	health      HealthPolicy
+	countryMode CountryMode
+	sessionTTL  time.Duration
+	sessions    map[string]*session
+	mu          sync.Mutex
+}
+
+// member is a proxy of the pool together with its health.
+type member struct {
+	proxy    types.Proxy
+	dead     bool
+	outcomes []outcome // Recent request outcomes, oldest first
+}
+
+// assignment records which proxy an account currently uses.
+type assignment struct {
+	index   int
+	expires time.Time
+	country string // Country of the account
+}
+
+// NewPool creates a proxy pool.
+//
+// # Parameters:
+//   - proxies: The proxies of the pool.
+//   - strategy: The assignment strategy. Defaults to StrategyPerAccount when empty.
+//   - stickyTTL: How long an account keeps its proxy with StrategySticky. Defaults to 30 minutes.
+//
+// # Returns:
+//   - *Pool: The proxy pool.
+//   - error: An error if the pool is empty or the strategy is unknown.
+//
+// # Example:
+//
+//	proxies, err := proxypool.LoadProxies("proxies.txt")
+//	if err != nil {
+//		log.Fatalf("Failed to load proxies: %v", err)
+//	}
+//	pool, err := proxypool.NewPool(proxies, proxypool.StrategySticky, 15*time.Minute)
+func NewPool(proxies []types.Proxy, strategy Strategy, stickyTTL time.Duration) (*Pool, error) {
+	if len(proxies) == 0 {
+		return nil, errors.New("proxy pool is empty")
+	}
+	switch strategy {
+	case "":
+		strategy = StrategyPerAccount
+	case StrategyPerAccount, StrategyRoundRobin, StrategySticky:
+	default:
+		return nil, fmt.Errorf("unknown proxy strategy: %s", strategy)
+	}
+	if stickyTTL <= 0 {
+		stickyTTL = 30 * time.Minute
+	}
+	pool := &Pool{
+		strategy:    strategy,
+		stickyTTL:   stickyTTL,
+		assignments: make(map[string]*assignment),
+	}
+	for _, proxy := range proxies {
+		pool.proxies = append(pool.proxies, &member{proxy: proxy})
+	}
+	return pool, nil
+}
+
+// Key returns the identity of a proxy within a pool ("ip:port:username").
+func Key(proxy types.Proxy) string {
+	return fmt.Sprintf("%s:%d:%s", proxy.Ip, proxy.Port, proxy.Username)
+}
+
+// Acquire returns the proxy to use for the next request of an account without a country.
+// See AcquireIn.
+func (pool *Pool) Acquire(account string) (types.Proxy, error) {
+	return pool.AcquireIn(account, "")
+}
+
+// AcquireIn returns the proxy to use for the next request of an account.
+//
+// # Parameters:
+//   - account: The Telegram ID of the account making the request.
+//   - country: The country of the account (e.g., "DE"), empty if unknown.
+//
+// # Returns:
+//   - types.Proxy: The proxy to use.
+//   - error: ErrNoHealthyProxy if every proxy of the pool is dead, or ErrNoProxyInCountry in
+//     CountryStrict mode if every proxy of the account's country is dead.
+func (pool *Pool) AcquireIn(account, country string) (types.Proxy, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if pool.strategy == StrategyRoundRobin {
+		index, err := pool.nextHealthy(country)
+		if err != nil {
+			return types.Proxy{}, err
+		}
+		return pool.proxies[index].proxy, nil
+	}
+	now := time.Now()
+	current, ok := pool.assignments[account]
+	if ok && !pool.proxies[current.index].dead && pool.fits(current, country) &&
+		(pool.strategy != StrategySticky || now.Before(current.expires)) {
+		return pool.proxies[current.index].proxy, nil
+	}
+	index, err := pool.leastAssigned(country)
+	if err != nil {
+		return types.Proxy{}, err
+	}
+	pool.assignments[account] = &assignment{index: index, expires: now.Add(pool.stickyTTL), country: country}
+	return pool.proxies[index].proxy, nil
+}
+
+// Release drops the proxy assignment and the gateway sessions of an account, so that its
+// proxy counts as free for the least-assigned choice of the other accounts. The account gets a
+// new assignment on its next AcquireIn.
+//
+// # Parameters:
+//   - account: The Telegram ID of the account, e.g. of a handler being closed.
+func (pool *Pool) Release(account string) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	delete(pool.assignments, account)
+	for id := range pool.sessions {
+		if strings.HasPrefix(id, account+"@") {
+			delete(pool.sessions, id)
+		}
+	}
+}
+
+// SetCountryMode sets how strictly accounts are kept on proxies of their country. Defaults to
+// CountryLenient.
+func (pool *Pool) SetCountryMode(mode CountryMode) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.countryMode = mode
+}
+
+// Alternate returns a healthy proxy other than exclude, in round-robin order, without
+// changing the assignments of the accounts. It is used to send a second copy of a request
+// through a different proxy.
+//
+// # Returns:
+//   - types.Proxy: The alternate proxy.
+//   - error: ErrNoHealthyProxy if no healthy proxy other than exclude exists.
+func (pool *Pool) Alternate(exclude types.Proxy) (types.Proxy, error) {
+	return pool.AlternateIn(exclude, "")
+}
+
+// AlternateIn is like Alternate for an account of country, choosing among the proxies of the
+// country as AcquireIn does.
+func (pool *Pool) AlternateIn(exclude types.Proxy, country string) (types.Proxy, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	excluded := Key(exclude)
+	index, err := pool.choose(country, func(allowed func(int) bool) int {
+		for i := 0; i < len(pool.proxies); i++ {
+			index := (pool.next + i) % len(pool.proxies)
+			if allowed(index) && Key(pool.proxies[index].proxy) != excluded {
+				return index
+			}
+		}
+		return -1
+	})
+	if err != nil {
+		return types.Proxy{}, err
+	}
+	pool.next = index + 1
+	return pool.proxies[index].proxy, nil
+}
+
+// MarkDead marks a proxy as dead so that it is no longer handed out.
+func (pool *Pool) MarkDead(proxy types.Proxy) {
+	pool.setDead(proxy, true)
+}
+
+// Revive marks a previously dead proxy as healthy again.
+func (pool *Pool) Revive(proxy types.Proxy) {
+	pool.setDead(proxy, false)
+}
+
+// Healthy returns the number of proxies that are not marked as dead.
+func (pool *Pool) Healthy() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	healthy := 0
+	for _, candidate := range pool.proxies {
+		if !candidate.dead {
+			healthy++
+		}
+	}
+	return healthy
+}
+
+// Proxies returns a copy of all proxies of the pool, healthy or not.
+func (pool *Pool) Proxies() []types.Proxy {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	proxies := make([]types.Proxy, 0, len(pool.proxies))
+	for _, candidate := range pool.proxies {
+		proxies = append(proxies, candidate.proxy)
+	}
+	return proxies
+}
+
+// Dead returns a copy of the proxies of the pool that are marked as dead.
+func (pool *Pool) Dead() []types.Proxy {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	var dead []types.Proxy
+	for _, candidate := range pool.proxies {
+		if candidate.dead {
+			dead = append(dead, candidate.proxy)
+		}
+	}
+	return dead
+}
+
+func (pool *Pool) setDead(proxy types.Proxy, dead bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	key := Key(proxy)
+	for _, candidate := range pool.proxies {
+		if Key(candidate.proxy) == key {
+			candidate.dead = dead
+		}
+	}
+}
+
+// fits reports whether an account of country may keep its assignment. It must be called with
+// mu held.
+func (pool *Pool) fits(current *assignment, country string) bool {
+	if !strings.EqualFold(current.country, country) {
+		return false
+	}
+	return country == "" || pool.countryMode != CountryStrict ||
+		strings.EqualFold(pool.proxies[current.index].proxy.Country, country)
+}
+
+// choose returns the proxy index picked by pick among the healthy proxies of country, falling
+// back to every healthy proxy in CountryLenient mode. It must be called with mu held.
+func (pool *Pool) choose(country string, pick func(allowed func(int) bool) int) (int, error) {
+	healthy := func(index int) bool { return !pool.proxies[index].dead }
+	if country == "" {
+		if index := pick(healthy); index >= 0 {
+			return index, nil
+		}
+		return 0, ErrNoHealthyProxy
+	}
+	index := pick(func(index int) bool {
+		return healthy(index) && strings.EqualFold(pool.proxies[index].proxy.Country, country)
+	})
+	if index >= 0 {
+		return index, nil
+	}
+	if pool.countryMode == CountryStrict {
+		return 0, fmt.Errorf("%w: %s", ErrNoProxyInCountry, country)
+	}
+	if index := pick(healthy); index >= 0 {
+		return index, nil
+	}
+	return 0, ErrNoHealthyProxy
+}
+
+// nextHealthy returns the index of the next healthy proxy of country in round-robin order.
+func (pool *Pool) nextHealthy(country string) (int, error) {
+	index, err := pool.choose(country, func(allowed func(int) bool) int {
+		for i := 0; i < len(pool.proxies); i++ {
+			index := (pool.next + i) % len(pool.proxies)
+			if allowed(index) {
+				return index
+			}
+		}
+		return -1
+	})
+	if err != nil {
+		return 0, err
+	}
+	pool.next = index + 1
+	return index, nil
+}
+
+// leastAssigned returns the index of the healthy proxy of country with the fewest assigned
+// accounts.
+func (pool *Pool) leastAssigned(country string) (int, error) {
+	counts := make([]int, len(pool.proxies))
+	now := time.Now()
+	for _, current := range pool.assignments {
+		if pool.strategy != StrategySticky || now.Before(current.expires) {
+			counts[current.index]++
+		}
+	}
+	best, err := pool.choose(country, func(allowed func(int) bool) int {
+		best := -1
+		for i := 0; i < len(pool.proxies); i++ {
+			index := (pool.next + i) % len(pool.proxies)
+			if allowed(index) && (best < 0 || counts[index] < counts[best]) {
+				best = index
+			}
+		}
+		return best
+	})
+	if err != nil {
+		return 0, err
+	}
+	pool.next = best + 1
+	return best, nil
+}
+
+// IsProxyError reports whether err indicates that a proxy could not be reached or refused
+// to connect, as opposed to an error returned by the destination server.
+func IsProxyError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return strings.HasPrefix(opErr.Op, "socks") || opErr.Op == "dial" || opErr.Op == "proxyconnect"
+	}
+	return false
+}
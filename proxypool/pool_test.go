@@ -0,0 +1,212 @@
+package proxypool
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testProxies returns proxies 10.0.0.1 to 10.0.0.n with the given countries.
+func testProxies(countries ...string) []types.Proxy {
+	proxies := make([]types.Proxy, len(countries))
+	for i, country := range countries {
+		proxies[i] = types.Proxy{Ip: fmt.Sprintf("10.0.0.%d", i+1), Port: 1080, SocksType: 5, Country: country}
+	}
+	return proxies
+}
+
+// acquire returns the last byte of the IP of the proxy of account, failing the test on error.
+func acquire(t *testing.T, pool *Pool, account, country string) string {
+	t.Helper()
+	proxy, err := pool.AcquireIn(account, country)
+	if err != nil {
+		t.Fatalf("AcquireIn(%s, %q) failed: %v", account, country, err)
+	}
+	return proxy.Ip[strings.LastIndex(proxy.Ip, ".")+1:]
+}
+
+func TestNewPool(t *testing.T) {
+	if _, err := NewPool(nil, StrategyPerAccount, 0); err == nil {
+		t.Errorf("NewPool() accepted an empty pool")
+	}
+	if _, err := NewPool(testProxies(""), "random", 0); err == nil {
+		t.Errorf("NewPool() accepted an unknown strategy")
+	}
+	pool, err := NewPool(testProxies(""), "", 0)
+	if err != nil || pool.strategy != StrategyPerAccount || pool.stickyTTL != 30*time.Minute {
+		t.Errorf("NewPool() = %+v, %v, want a per-account pool with a 30 minute TTL", pool, err)
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	tests := []struct {
+		strategy Strategy
+		// accounts acquire a proxy in order; want lists the proxy each one gets.
+		accounts string
+		want     string
+	}{
+		{StrategyPerAccount, "a b c a b d", "1 2 3 1 2 1"},
+		{StrategyRoundRobin, "a a a a", "1 2 3 1"},
+		{StrategySticky, "a b a", "1 2 1"},
+	}
+	for _, test := range tests {
+		pool, err := NewPool(testProxies("", "", ""), test.strategy, time.Hour)
+		if err != nil {
+			t.Fatalf("NewPool(%s) failed: %v", test.strategy, err)
+		}
+		var got []string
+		for _, account := range strings.Fields(test.accounts) {
+			got = append(got, acquire(t, pool, account, ""))
+		}
+		if strings.Join(got, " ") != test.want {
+			t.Errorf("%s: proxies = %s, want %s", test.strategy, strings.Join(got, " "), test.want)
+		}
+	}
+}
+
+func TestStickyAssignmentsExpire(t *testing.T) {
+	pool, err := NewPool(testProxies("", ""), StrategySticky, time.Hour)
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	first := acquire(t, pool, "a", "")
+	pool.assignments["a"].expires = time.Now().Add(-time.Second)
+	if second := acquire(t, pool, "a", ""); second == first {
+		t.Errorf("expired sticky assignment kept proxy %s", first)
+	}
+}
+
+func TestDeadProxies(t *testing.T) {
+	pool, err := NewPool(testProxies("", ""), StrategyPerAccount, 0)
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	if got := acquire(t, pool, "a", ""); got != "1" {
+		t.Fatalf("account a got proxy %s, want 1", got)
+	}
+	first := pool.Proxies()[0]
+	pool.MarkDead(first)
+	if got := acquire(t, pool, "a", ""); got != "2" {
+		t.Errorf("account of a dead proxy got proxy %s, want 2", got)
+	}
+	if pool.Healthy() != 1 || len(pool.Dead()) != 1 || pool.Dead()[0] != first {
+		t.Errorf("Healthy() = %d, Dead() = %v, want the first proxy dead", pool.Healthy(), pool.Dead())
+	}
+	if _, err := pool.Alternate(pool.Proxies()[1]); !errors.Is(err, ErrNoHealthyProxy) {
+		t.Errorf("Alternate() error = %v, want ErrNoHealthyProxy", err)
+	}
+	pool.MarkDead(pool.Proxies()[1])
+	if _, err := pool.Acquire("a"); !errors.Is(err, ErrNoHealthyProxy) {
+		t.Errorf("Acquire() error = %v, want ErrNoHealthyProxy", err)
+	}
+	pool.Revive(first)
+	if got := acquire(t, pool, "a", ""); got != "1" {
+		t.Errorf("account got proxy %s after revival, want 1", got)
+	}
+}
+
+func TestCountries(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    CountryMode
+		dead    []int
+		country string
+		want    string
+		wantErr error
+	}{
+		{"own country", CountryLenient, nil, "de", "2", nil},
+		{"no country", CountryLenient, nil, "", "1", nil},
+		{"lenient fallback", CountryLenient, []int{1}, "DE", "1", nil},
+		{"strict", CountryStrict, []int{1}, "DE", "", ErrNoProxyInCountry},
+		{"unknown country", CountryLenient, nil, "FR", "1", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool, err := NewPool(testProxies("US", "DE"), StrategyPerAccount, 0)
+			if err != nil {
+				t.Fatalf("NewPool failed: %v", err)
+			}
+			pool.SetCountryMode(test.mode)
+			for _, index := range test.dead {
+				pool.MarkDead(pool.Proxies()[index])
+			}
+			if test.wantErr != nil {
+				if _, err := pool.AcquireIn("a", test.country); !errors.Is(err, test.wantErr) {
+					t.Errorf("AcquireIn() error = %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if got := acquire(t, pool, "a", test.country); got != test.want {
+				t.Errorf("account got proxy %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHealthPolicy(t *testing.T) {
+	pool, err := NewPool(testProxies("", ""), StrategyPerAccount, 0)
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	pool.SetHealthPolicy(HealthPolicy{Threshold: 0.5, MinRequests: 4, Window: time.Minute})
+	for _, account := range []string{"b", "a", "c"} {
+		acquire(t, pool, account, "")
+	}
+	first := pool.Proxies()[0]
+	for i, failed := range []bool{true, true, true} {
+		if _, retired := pool.Report(first, failed); retired {
+			t.Fatalf("proxy retired after %d requests, below MinRequests", i+1)
+		}
+	}
+	replacement, retired := pool.Report(first, false)
+	if !retired {
+		t.Fatalf("proxy with 3 failures in 4 requests was not retired")
+	}
+	if replacement.Address != "10.0.0.1:1080" || replacement.Requests != 4 || replacement.Failures != 3 ||
+		strings.Join(replacement.Accounts, " ") != "b c" {
+		t.Errorf("replacement = %+v, want 3 failures in 4 requests and accounts b c", replacement)
+	}
+	if got := acquire(t, pool, "b", ""); got != "2" {
+		t.Errorf("moved account got proxy %s, want 2", got)
+	}
+	if _, retired := pool.Report(first, true); retired {
+		t.Errorf("retired proxy retired again")
+	}
+	stats := pool.Stats()
+	if len(stats) != 2 || !stats[0].Dead || stats[1].Accounts != 3 || stats[0].Requests != 0 {
+		t.Errorf("Stats() = %+v, want the first proxy dead and the accounts on the second", stats)
+	}
+}
+
+func TestSession(t *testing.T) {
+	pool, err := NewPool(testProxies(""), StrategyPerAccount, 0)
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	plain := pool.Proxies()[0]
+	if got, expired := pool.Session("a", plain, 0); got != plain || expired != nil {
+		t.Errorf("Session() changed a proxy that is not a rotating gateway: %+v", got)
+	}
+	gateway := types.Proxy{Ip: "gate.example", Port: 7000, Username: "customer-session-{session}-sesstime-{minutes}"}
+	first, _ := pool.Session("a", gateway, 0)
+	if !strings.HasPrefix(first.Username, "customer-session-") || !strings.HasSuffix(first.Username, "-sesstime-10") {
+		t.Errorf("session username = %s, want the session and the default lifetime filled in", first.Username)
+	}
+	if again, _ := pool.Session("a", gateway, 0); again != first {
+		t.Errorf("account got session %s, then %s", first.Username, again.Username)
+	}
+	if other, _ := pool.Session("b", gateway, 0); other == first {
+		t.Errorf("accounts share the session %s", first.Username)
+	}
+	renewed, expired := pool.Session("a", gateway, 30*time.Minute)
+	if renewed == first || !strings.HasSuffix(renewed.Username, "-sesstime-30") || len(expired) != 1 || expired[0] != Key(first) {
+		t.Errorf("Session() with a new lifetime = %s, expired %v, want a new 30 minute session expiring %s", renewed.Username, expired, Key(first))
+	}
+	pool.Release("a")
+	if released, _ := pool.Session("a", gateway, 30*time.Minute); released == renewed {
+		t.Errorf("released account kept its session")
+	}
+}
@@ -0,0 +1,200 @@
+package proxypool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRefreshInterval is how often the proxy list of a provider is fetched again, unless the
+// provider configuration sets another interval.
+const DefaultRefreshInterval = 15 * time.Minute
+
+// Provider fetches the current proxy list of a proxy provider, so that the pool follows the
+// provider's list instead of a manual export.
+type Provider interface {
+	Fetch(ctx context.Context) ([]types.Proxy, error)
+}
+
+// ProviderOpener creates a Provider from its configuration.
+type ProviderOpener func(config types.ProxyProviderConfig) (Provider, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]ProviderOpener{
+		"webshare": func(config types.ProxyProviderConfig) (Provider, error) {
+			if config.APIKey == "" {
+				return nil, errors.New("proxy provider 'webshare' requires an api_key")
+			}
+			return &Webshare{APIKey: config.APIKey, Country: config.Country}, nil
+		},
+		"proxy6": func(config types.ProxyProviderConfig) (Provider, error) {
+			if config.APIKey == "" {
+				return nil, errors.New("proxy provider 'proxy6' requires an api_key")
+			}
+			return &Proxy6{APIKey: config.APIKey, Country: config.Country}, nil
+		},
+		"url": func(config types.ProxyProviderConfig) (Provider, error) {
+			if config.URL == "" {
+				return nil, errors.New("proxy provider 'url' requires a url")
+			}
+			return &URL{Address: config.URL, APIKey: config.APIKey}, nil
+		},
+	}
+)
+
+// RegisterProvider makes a proxy provider available to ProviderFromConfig under name, replacing
+// any provider of that name.
+//
+// # Example:
+//
+//	proxypool.RegisterProvider("acme", func(config types.ProxyProviderConfig) (proxypool.Provider, error) {
+//		return acme.NewClient(config.APIKey), nil
+//	})
+func RegisterProvider(name string, opener ProviderOpener) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = opener
+}
+
+// ProviderFromConfig creates the provider of a proxy pool configuration.
+//
+// # Providers:
+//   - webshare: The proxy list of a Webshare account, see Webshare.
+//   - proxy6: The active proxies of a Proxy6 account, see Proxy6.
+//   - url: A list in the format of LoadProxies served over HTTP, see URL.
+//
+// # Returns:
+//   - Provider: The provider, or nil if config names none.
+//   - error: An error if the provider is unknown or misconfigured.
+func ProviderFromConfig(config types.ProxyProviderConfig) (Provider, error) {
+	if config.Name == "" {
+		return nil, nil
+	}
+	providersMu.RLock()
+	opener, ok := providers[config.Name]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown proxy provider: %s", config.Name)
+	}
+	return opener(config)
+}
+
+// List is a Provider returning a fixed proxy list, e.g. the proxies of a file.
+type List []types.Proxy
+
+// Fetch returns a copy of the list.
+func (list List) Fetch(context.Context) ([]types.Proxy, error) {
+	return append([]types.Proxy(nil), list...), nil
+}
+
+// Join returns a Provider fetching the proxies of every provider, in order. Nil providers are
+// skipped. The fetch fails if any provider fails.
+func Join(sources ...Provider) Provider {
+	return joined(sources)
+}
+
+// joined is the Provider returned by Join.
+type joined []Provider
+
+func (sources joined) Fetch(ctx context.Context) ([]types.Proxy, error) {
+	var proxies []types.Proxy
+	for _, source := range sources {
+		if source == nil {
+			continue
+		}
+		fetched, err := source.Fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, fetched...)
+	}
+	return proxies, nil
+}
+
+// Update replaces the proxies of the pool, e.g. with a list refreshed from a Provider.
+//
+// Proxies still listed keep their health and their accounts; retired proxies stay retired.
+// Accounts of removed proxies are assigned a new proxy on their next request.
+//
+// # Returns:
+//   - error: An error if proxies is empty. The pool is left unchanged then.
+func (pool *Pool) Update(proxies []types.Proxy) error {
+	if len(proxies) == 0 {
+		return errors.New("proxy pool is empty")
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	existing := make(map[string]*member, len(pool.proxies))
+	for _, candidate := range pool.proxies {
+		existing[Key(candidate.proxy)] = candidate
+	}
+	indexes := make(map[string]int, len(proxies))
+	members := make([]*member, 0, len(proxies))
+	for _, proxy := range proxies {
+		key := Key(proxy)
+		if _, ok := indexes[key]; ok {
+			continue
+		}
+		indexes[key] = len(members)
+		if candidate, ok := existing[key]; ok {
+			candidate.proxy = proxy
+			members = append(members, candidate)
+		} else {
+			members = append(members, &member{proxy: proxy})
+		}
+	}
+	for account, current := range pool.assignments {
+		if index, ok := indexes[Key(pool.proxies[current.index].proxy)]; ok {
+			current.index = index
+		} else {
+			delete(pool.assignments, account)
+		}
+	}
+	pool.proxies = members
+	pool.next %= len(members)
+	return nil
+}
+
+// fetch sends a GET request to a provider API and returns the response body.
+func fetch(ctx context.Context, client *http.Client, address string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			// The URL may carry the API key of the account
+			return nil, urlErr.Err
+		}
+		return nil, err
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+		}
+	}(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("proxy provider returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,131 @@
+package proxypool
+
+import (
+	"context"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	pool, err := NewPool(testProxies("", "", ""), StrategyPerAccount, 0)
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	for _, account := range []string{"a", "b", "c"} {
+		acquire(t, pool, account, "")
+	}
+	proxies := pool.Proxies()
+	pool.MarkDead(proxies[2])
+	if err := pool.Update(nil); err == nil {
+		t.Errorf("Update() accepted an empty list")
+	}
+	added := types.Proxy{Ip: "10.0.0.9", Port: 1080, SocksType: 5}
+	if err := pool.Update([]types.Proxy{added, proxies[1], proxies[2], proxies[1]}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got := pool.Proxies(); len(got) != 3 || got[0] != added || got[1] != proxies[1] || got[2] != proxies[2] {
+		t.Errorf("Proxies() = %+v, want the new list without duplicates", got)
+	}
+	if dead := pool.Dead(); len(dead) != 1 || dead[0] != proxies[2] {
+		t.Errorf("Dead() = %+v, want the retired proxy to stay retired", dead)
+	}
+	if got := acquire(t, pool, "b", ""); got != "2" {
+		t.Errorf("account of a kept proxy got proxy %s, want 2", got)
+	}
+	if got := acquire(t, pool, "a", ""); got != "9" {
+		t.Errorf("account of a removed proxy got proxy %s, want 9", got)
+	}
+}
+
+func TestURLProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "invalid key", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/proxies.txt":
+			_, _ = w.Write([]byte("192.168.1.100:1080 de\n"))
+		case "/export":
+			_, _ = w.Write([]byte(`[{"ip": "192.168.1.101", "port": 1080}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tests := []struct {
+		path    string
+		key     string
+		want    string
+		wantErr string
+	}{
+		{"/proxies.txt", "key", "192.168.1.100 DE", ""},
+		{"/export", "key", "192.168.1.101 ", ""},
+		{"/proxies.txt", "", "", "status 401: invalid key"},
+		{"/missing", "key", "", "status 404"},
+	}
+	for _, test := range tests {
+		provider, err := ProviderFromConfig(types.ProxyProviderConfig{Name: "url", URL: server.URL + test.path, APIKey: test.key})
+		if err != nil {
+			t.Fatalf("ProviderFromConfig failed: %v", err)
+		}
+		proxies, err := provider.Fetch(context.Background())
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("Fetch(%s) error = %v, want %q", test.path, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil || len(proxies) != 1 || proxies[0].Ip+" "+proxies[0].Country != test.want {
+			t.Errorf("Fetch(%s) = %+v, %v, want %s", test.path, proxies, err, test.want)
+		}
+	}
+}
+
+// failingProvider is a Provider failing with err.
+type failingProvider struct {
+	err error
+}
+
+func (provider failingProvider) Fetch(context.Context) ([]types.Proxy, error) {
+	return nil, provider.err
+}
+
+func TestJoin(t *testing.T) {
+	first, second := List(testProxies("US")), List(testProxies("DE", "FR"))
+	proxies, err := Join(first, nil, second).Fetch(context.Background())
+	if err != nil || len(proxies) != 3 || proxies[0].Country != "US" || proxies[2].Country != "FR" {
+		t.Errorf("Fetch() = %+v, %v, want the proxies of both lists in order", proxies, err)
+	}
+	unavailable := errors.New("provider unavailable")
+	if _, err := Join(first, failingProvider{unavailable}).Fetch(context.Background()); !errors.Is(err, unavailable) {
+		t.Errorf("Fetch() error = %v, want the error of the failing provider", err)
+	}
+}
+
+func TestProviderFromConfig(t *testing.T) {
+	tests := []struct {
+		config  types.ProxyProviderConfig
+		wantErr bool
+	}{
+		{types.ProxyProviderConfig{}, false},
+		{types.ProxyProviderConfig{Name: "webshare", APIKey: "key"}, false},
+		{types.ProxyProviderConfig{Name: "webshare"}, true},
+		{types.ProxyProviderConfig{Name: "proxy6"}, true},
+		{types.ProxyProviderConfig{Name: "url"}, true},
+		{types.ProxyProviderConfig{Name: "acme"}, true},
+	}
+	for _, test := range tests {
+		if _, err := ProviderFromConfig(test.config); (err != nil) != test.wantErr {
+			t.Errorf("ProviderFromConfig(%+v) error = %v, want error %t", test.config, err, test.wantErr)
+		}
+	}
+	RegisterProvider("acme", func(types.ProxyProviderConfig) (Provider, error) { return List(testProxies("")), nil })
+	if provider, err := ProviderFromConfig(types.ProxyProviderConfig{Name: "acme"}); err != nil || provider == nil {
+		t.Errorf("ProviderFromConfig() = %v, %v, want the registered provider", provider, err)
+	}
+}
@@ -0,0 +1,188 @@
+package proxypool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Webshare is a Provider fetching the proxy list of a Webshare account through its API.
+//
+// # Fields:
+//   - APIKey: The API key of the account, sent as "Authorization: Token <key>".
+//   - Country: The optional country code (e.g., "DE") the list is restricted to.
+//   - BaseURL: The API address. Defaults to "https://proxy.webshare.io".
+//   - Client: The HTTP client used for requests. Defaults to a client with a 30 second timeout.
+type Webshare struct {
+	APIKey  string
+	Country string
+	BaseURL string
+	Client  *http.Client
+}
+
+// Fetch returns the valid proxies of the account, following the pages of the list.
+func (provider *Webshare) Fetch(ctx context.Context) ([]types.Proxy, error) {
+	base := provider.BaseURL
+	if base == "" {
+		base = "https://proxy.webshare.io"
+	}
+	query := url.Values{"mode": {"direct"}, "page_size": {"100"}}
+	if provider.Country != "" {
+		query.Set("country_code__in", strings.ToUpper(provider.Country))
+	}
+	next := strings.TrimSuffix(base, "/") + "/api/v2/proxy/list/?" + query.Encode()
+	header := http.Header{"Authorization": {"Token " + provider.APIKey}}
+	var proxies []types.Proxy
+	for next != "" {
+		body, err := fetch(ctx, provider.Client, next, header)
+		if err != nil {
+			return nil, fmt.Errorf("webshare: %w", err)
+		}
+		var page struct {
+			Next    *string `json:"next"`
+			Results []struct {
+				Username     string `json:"username"`
+				Password     string `json:"password"`
+				ProxyAddress string `json:"proxy_address"`
+				Port         int    `json:"port"`
+				Valid        bool   `json:"valid"`
+				CountryCode  string `json:"country_code"`
+			} `json:"results"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("webshare: %w", err)
+		}
+		for _, result := range page.Results {
+			if !result.Valid {
+				continue
+			}
+			proxies = append(proxies, types.Proxy{
+				Ip:        result.ProxyAddress,
+				Port:      result.Port,
+				Username:  result.Username,
+				Password:  result.Password,
+				SocksType: 5,
+				Country:   strings.ToUpper(result.CountryCode),
+			})
+		}
+		next = ""
+		if page.Next != nil {
+			next = *page.Next
+		}
+	}
+	return proxies, nil
+}
+
+// Proxy6 is a Provider fetching the active SOCKS5 proxies of a Proxy6 account through its API.
+// Proxies of the account bought as HTTP proxies are left out.
+//
+// # Fields:
+//   - APIKey: The API key of the account.
+//   - Country: The optional country code (e.g., "DE") the list is restricted to.
+//   - BaseURL: The API address. Defaults to "https://px6.link".
+//   - Client: The HTTP client used for requests. Defaults to a client with a 30 second timeout.
+type Proxy6 struct {
+	APIKey  string
+	Country string
+	BaseURL string
+	Client  *http.Client
+}
+
+// Fetch returns the active SOCKS5 proxies of the account, ordered by proxy ID.
+func (provider *Proxy6) Fetch(ctx context.Context) ([]types.Proxy, error) {
+	base := provider.BaseURL
+	if base == "" {
+		base = "https://px6.link"
+	}
+	address := strings.TrimSuffix(base, "/") + "/api/" + url.PathEscape(provider.APIKey) + "/getproxy?state=active"
+	body, err := fetch(ctx, provider.Client, address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("proxy6: %w", err)
+	}
+	var response struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		List   map[string]struct {
+			Host    string `json:"host"`
+			Port    string `json:"port"`
+			User    string `json:"user"`
+			Pass    string `json:"pass"`
+			Type    string `json:"type"`
+			Country string `json:"country"`
+		} `json:"list"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("proxy6: %w", err)
+	}
+	if response.Status != "yes" {
+		return nil, fmt.Errorf("proxy6: %s", response.Error)
+	}
+	ids := make([]string, 0, len(response.List))
+	for id := range response.List {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var proxies []types.Proxy
+	for _, id := range ids {
+		entry := response.List[id]
+		if entry.Type != "socks" {
+			continue
+		}
+		if provider.Country != "" && !strings.EqualFold(entry.Country, provider.Country) {
+			continue
+		}
+		port, err := strconv.Atoi(entry.Port)
+		if err != nil {
+			return nil, fmt.Errorf("proxy6: invalid port of proxy %s: %q", id, entry.Port)
+		}
+		proxies = append(proxies, types.Proxy{
+			Ip:        entry.Host,
+			Port:      port,
+			Username:  entry.User,
+			Password:  entry.Pass,
+			SocksType: 5,
+			Country:   strings.ToUpper(entry.Country),
+		})
+	}
+	return proxies, nil
+}
+
+// URL is a Provider downloading a proxy list in the format of LoadProxies, such as the export
+// link offered by most providers. Lists starting with '[', or whose address ends with ".json",
+// are read as JSON arrays.
+//
+// # Fields:
+//   - Address: The address of the list.
+//   - APIKey: The optional key sent as "Authorization: Bearer <key>".
+//   - Client: The HTTP client used for requests. Defaults to a client with a 30 second timeout.
+type URL struct {
+	Address string
+	APIKey  string
+	Client  *http.Client
+}
+
+// Fetch downloads and parses the list.
+func (provider *URL) Fetch(ctx context.Context) ([]types.Proxy, error) {
+	var header http.Header
+	if provider.APIKey != "" {
+		header = http.Header{"Authorization": {"Bearer " + provider.APIKey}}
+	}
+	body, err := fetch(ctx, provider.Client, provider.Address, header)
+	if err != nil {
+		return nil, err
+	}
+	path := provider.Address
+	if parsed, err := url.Parse(provider.Address); err == nil {
+		path = parsed.Path
+	}
+	trimmed := bytes.TrimSpace(body)
+	isJSON := strings.HasSuffix(strings.ToLower(path), ".json") || (len(trimmed) > 0 && trimmed[0] == '[')
+	return ReadProxies(bytes.NewReader(body), path, isJSON)
+}
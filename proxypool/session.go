@@ -0,0 +1,119 @@
+package proxypool
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionPlaceholder marks the proxy username of a rotating gateway: the pool replaces it with
+// a session ID per account, so that the account keeps its exit IP until the session expires.
+//
+// # Example proxies.txt:
+//
+//	gate.provider.example:7000:customer-acme-session-{session}-sesstime-{minutes}:secret
+const SessionPlaceholder = "{session}"
+
+// SessionMinutesPlaceholder is replaced by the session lifetime in minutes in the username of
+// rotating gateways whose provider takes the lifetime from the username.
+const SessionMinutesPlaceholder = "{minutes}"
+
+// DefaultSessionTTL is how long an account keeps the exit IP of a rotating gateway, unless the
+// pool or the account sets another lifetime.
+const DefaultSessionTTL = 10 * time.Minute
+
+// session is the gateway session of an account.
+type session struct {
+	id      string
+	key     string // Key of the proxy with the session username
+	ttl     time.Duration
+	expires time.Time
+}
+
+// IsRotating reports whether proxy is a rotating gateway whose username carries a session ID
+// (see SessionPlaceholder).
+func IsRotating(proxy types.Proxy) bool {
+	return strings.Contains(proxy.Username, SessionPlaceholder)
+}
+
+// SetSessionTTL sets how long accounts keep the exit IP of the rotating gateways of the pool.
+// Defaults to DefaultSessionTTL.
+func (pool *Pool) SetSessionTTL(ttl time.Duration) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.sessionTTL = ttl
+}
+
+// Session returns proxy with the session ID of an account filled into its username, so that
+// the SDK, rather than the provider, decides when the exit IP of the account rotates. A new
+// session ID is drawn when the previous one expired or its lifetime changed. Proxies that are
+// not rotating gateways are returned unchanged.
+//
+// # Parameters:
+//   - account: The Telegram ID of the account.
+//   - proxy: The proxy returned by AcquireIn or AlternateIn.
+//   - ttl: The session lifetime of the account. Zero uses the lifetime of the pool.
+//
+// # Returns:
+//   - types.Proxy: The proxy with its session username.
+//   - []string: The keys (see Key) of the expired sessions of the pool, whose connections may
+//     be closed.
+func (pool *Pool) Session(account string, proxy types.Proxy, ttl time.Duration) (types.Proxy, []string) {
+	if !IsRotating(proxy) {
+		return proxy, nil
+	}
+	now := time.Now()
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if ttl <= 0 {
+		ttl = pool.sessionTTL
+	}
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	if pool.sessions == nil {
+		pool.sessions = make(map[string]*session)
+	}
+	id := account + "@" + Key(proxy)
+	current, ok := pool.sessions[id]
+	if ok && current.ttl == ttl && now.Before(current.expires) {
+		return withSession(proxy, current), nil
+	}
+	var expired []string
+	if ok {
+		expired = append(expired, current.key)
+	}
+	for other, candidate := range pool.sessions {
+		if !now.Before(candidate.expires) && other != id {
+			expired = append(expired, candidate.key)
+			delete(pool.sessions, other)
+		}
+	}
+	current = &session{id: newSessionID(), ttl: ttl, expires: now.Add(ttl)}
+	sessioned := withSession(proxy, current)
+	current.key = Key(sessioned)
+	pool.sessions[id] = current
+	return sessioned, expired
+}
+
+// withSession returns proxy with the ID and lifetime of current filled into its username.
+func withSession(proxy types.Proxy, current *session) types.Proxy {
+	proxy.Username = fillSession(proxy.Username, current)
+	return proxy
+}
+
+// fillSession fills the ID and lifetime of current into a username template.
+func fillSession(username string, current *session) string {
+	username = strings.ReplaceAll(username, SessionPlaceholder, current.id)
+	return strings.ReplaceAll(username, SessionMinutesPlaceholder, strconv.Itoa(int(current.ttl/time.Minute)))
+}
+
+// newSessionID returns a random session ID of 12 hexadecimal characters.
+func newSessionID() string {
+	buf := make([]byte, 6)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,210 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/internal/sigv4"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Options configures how remote locations are fetched.
+//
+// # Fields:
+//   - Headers: Extra headers sent with http:// and https:// requests (e.g., an Authorization header).
+//   - CacheDir: A directory where fetched documents are cached. When set, unchanged documents are
+//     revalidated with ETags and the cached copy is used if the location is unreachable.
+//   - Client: The HTTP client used for requests. Defaults to a client with a 30 second timeout.
+//   - AWS: The credentials used to sign s3:// requests.
+//   - AWSRegion: The region of the S3 buckets.
+//   - GCSToken: The OAuth2 access token used for gs:// requests.
+type Options struct {
+	Headers   map[string]string
+	CacheDir  string
+	Client    *http.Client
+	AWS       sigv4.Credentials
+	AWSRegion string
+	GCSToken  string
+}
+
+// DefaultOptions returns options read from the environment.
+//
+// # Environment variables:
+//   - NEXUS_REMOTE_AUTHORIZATION: The Authorization header sent with http(s) requests.
+//   - NEXUS_REMOTE_CACHE_DIR: The cache directory.
+//   - AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION: S3 access.
+//   - GOOGLE_OAUTH_ACCESS_TOKEN: GCS access.
+func DefaultOptions() Options {
+	options := Options{
+		CacheDir:  os.Getenv("NEXUS_REMOTE_CACHE_DIR"),
+		AWS:       sigv4.CredentialsFromEnv(),
+		AWSRegion: sigv4.RegionFromEnv(),
+		GCSToken:  os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+	}
+	if authorization := os.Getenv("NEXUS_REMOTE_AUTHORIZATION"); authorization != "" {
+		options.Headers = map[string]string{"Authorization": authorization}
+	}
+	return options
+}
+
+// IsRemote reports whether location is a URL handled by Open rather than a local path.
+func IsRemote(location string) bool {
+	for _, scheme := range []string{"http://", "https://", "s3://", "gs://"} {
+		if strings.HasPrefix(strings.ToLower(location), scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// Open fetches the document at a remote location.
+//
+// # Supported locations:
+//   - http://host/path and https://host/path, sent with options.Headers.
+//   - s3://bucket/key, signed with AWS Signature Version 4.
+//   - gs://bucket/object, authorized with options.GCSToken.
+//
+// # Parameters:
+//   - ctx: The context of the request.
+//   - location: The URL of the document.
+//   - options: The authentication and caching options.
+//
+// # Returns:
+//   - io.ReadCloser: The document content. The caller must close it.
+//   - error: An error if the document cannot be fetched and no cached copy exists.
+//
+// # Example:
+//
+//	reader, err := remote.Open(ctx, "s3://farm-config/prod/config.json", remote.DefaultOptions())
+//	if err != nil {
+//		log.Fatalf("Failed to fetch configuration: %v", err)
+//	}
+//	defer reader.Close()
+func Open(ctx context.Context, location string, options Options) (io.ReadCloser, error) {
+	req, err := newRequest(ctx, location, options)
+	if err != nil {
+		return nil, err
+	}
+	cachePath, etag := cacheEntry(location, options.CacheDir)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	client := options.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if cached, cacheErr := os.Open(cachePath); cachePath != "" && cacheErr == nil {
+			return cached, nil
+		}
+		return nil, fmt.Errorf("failed to fetch %s: %w", location, err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+		}
+	}(resp.Body)
+	if resp.StatusCode == http.StatusNotModified && cachePath != "" {
+		return os.Open(cachePath)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", location, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if cached, cacheErr := os.Open(cachePath); cachePath != "" && resp.StatusCode >= 500 && cacheErr == nil {
+			return cached, nil
+		}
+		return nil, fmt.Errorf("failed to fetch %s: status %d: %s", location, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if cachePath != "" {
+		storeCache(cachePath, body, resp.Header.Get("ETag"))
+	}
+	return io.NopCloser(bytes.NewReader(body)), nil
+}
+
+func newRequest(ctx context.Context, location string, options Options) (*http.Request, error) {
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range options.Headers {
+			req.Header.Set(key, value)
+		}
+		return req, nil
+	case "s3":
+		if !options.AWS.Valid() {
+			return nil, fmt.Errorf("no AWS credentials configured for %s", location)
+		}
+		region := options.AWSRegion
+		if region == "" {
+			region = "us-east-1"
+		}
+		objectURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", parsed.Host, region, strings.TrimPrefix(parsed.EscapedPath(), "/"))
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		sigv4.Sign(req, nil, options.AWS, region, "s3", time.Now())
+		return req, nil
+	case "gs":
+		objectURL := fmt.Sprintf("https://storage.googleapis.com/storage/v1/b/%s/o/%s?alt=media",
+			url.PathEscape(parsed.Host), url.PathEscape(strings.TrimPrefix(parsed.Path, "/")))
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		if options.GCSToken != "" {
+			req.Header.Set("Authorization", "Bearer "+options.GCSToken)
+		}
+		return req, nil
+	default:
+		return nil, fmt.Errorf("unsupported location scheme: %s", parsed.Scheme)
+	}
+}
+
+// cacheEntry returns the cache file of a location and the ETag of its cached copy, if any.
+func cacheEntry(location, cacheDir string) (string, string) {
+	if cacheDir == "" {
+		return "", ""
+	}
+	sum := sha256.Sum256([]byte(location))
+	cachePath := filepath.Join(cacheDir, hex.EncodeToString(sum[:]))
+	if _, err := os.Stat(cachePath); err != nil {
+		return cachePath, ""
+	}
+	etag, _ := os.ReadFile(cachePath + ".etag")
+	return cachePath, strings.TrimSpace(string(etag))
+}
+
+func storeCache(cachePath string, body []byte, etag string) {
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o700); err != nil {
+		return
+	}
+	if err := os.WriteFile(cachePath+".tmp", body, 0o600); err != nil {
+		return
+	}
+	if err := os.Rename(cachePath+".tmp", cachePath); err != nil {
+		return
+	}
+	if etag != "" {
+		_ = os.WriteFile(cachePath+".etag", []byte(etag), 0o600)
+	} else {
+		_ = os.Remove(cachePath + ".etag")
+	}
+}
@@ -0,0 +1,128 @@
+package schema
+
+import (
+	"encoding/json"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Draft is the JSON Schema dialect emitted by this package.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// Generate returns the JSON Schema describing the JSON encoding of v.
+//
+// The schema follows the encoding/json rules: exported fields are described under their
+// json tag names, fields tagged "-" are skipped, untagged embedded structs are flattened,
+// and time.Time values are described as date-time strings.
+//
+// # Parameters:
+//   - v: A value of the type to describe (e.g., types.Config{}).
+//   - title: The title of the schema.
+//
+// # Returns:
+//   - map[string]interface{}: The schema, ready to be encoded as JSON.
+func Generate(v interface{}, title string) map[string]interface{} {
+	result := generate(reflect.TypeOf(v), map[reflect.Type]bool{})
+	result["$schema"] = Draft
+	if title != "" {
+		result["title"] = title
+	}
+	return result
+}
+
+// Config returns the JSON Schema of config.json.
+func Config() map[string]interface{} {
+	return Generate(types.Config{}, "NexusSDK config")
+}
+
+// Accounts returns the JSON Schema of accounts.json.
+func Accounts() map[string]interface{} {
+	return Generate([]types.Account{}, "NexusSDK accounts")
+}
+
+// Tasks returns the JSON Schema of tasks.json.
+func Tasks() map[string]interface{} {
+	return Generate(types.TaskCollection{}, "NexusSDK tasks")
+}
+
+// Marshal encodes a schema as indented JSON.
+func Marshal(schema map[string]interface{}) ([]byte, error) {
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func generate(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]interface{}{"type": "integer", "description": "Duration in nanoseconds"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": generate(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": generate(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		properties := map[string]interface{}{}
+		addStructFields(t, properties, visiting)
+		return map[string]interface{}{"type": "object", "properties": properties}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func addStructFields(t reflect.Type, properties map[string]interface{}, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			addStructFields(fieldType, properties, visiting)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = generate(field.Type, visiting)
+	}
+}
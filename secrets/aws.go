@@ -0,0 +1,81 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/internal/sigv4"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AWSSecretsManager is a Provider reading secrets from AWS Secrets Manager.
+//
+// References have the form "<secret-id>" or "<secret-id>#<key>", where key selects a field of
+// a JSON secret, e.g. "nexus/prod#api_key".
+//
+// # Fields:
+//   - Credentials: The AWS credentials used to sign requests.
+//   - Region: The region of the secrets.
+//   - Client: The HTTP client used for requests. Defaults to a client with a 10 second timeout.
+type AWSSecretsManager struct {
+	Credentials sigv4.Credentials
+	Region      string
+	Client      *http.Client
+}
+
+// AWSSecretsManagerFromEnv returns an AWS Secrets Manager provider configured from the
+// standard AWS environment variables.
+func AWSSecretsManagerFromEnv() *AWSSecretsManager {
+	return &AWSSecretsManager{
+		Credentials: sigv4.CredentialsFromEnv(),
+		Region:      sigv4.RegionFromEnv(),
+	}
+}
+
+// Resolve fetches the current version of the secret and returns it, or its key.
+func (secretsManager *AWSSecretsManager) Resolve(ctx context.Context, reference string) (string, error) {
+	secretId, key := splitKey(reference)
+	body, err := json.Marshal(map[string]string{"SecretId": secretId})
+	if err != nil {
+		return "", err
+	}
+	url := fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", secretsManager.Region)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	sigv4.Sign(req, body, secretsManager.Credentials, secretsManager.Region, "secretsmanager", time.Now())
+	client := secretsManager.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+		}
+	}(resp.Body)
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("secrets manager returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(responseBody)))
+	}
+	var response struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(responseBody, &response); err != nil {
+		return "", err
+	}
+	return selectKey(response.SecretString, key)
+}
@@ -0,0 +1,13 @@
+package secrets
+
+import (
+	"syscall"
+)
+
+// madvDontDump is MADV_DONTDUMP, which the syscall package does not define.
+const madvDontDump = 0x10
+
+// excludeFromDumps keeps a region out of core dumps.
+func excludeFromDumps(region []byte) {
+	_ = syscall.Madvise(region, madvDontDump)
+}
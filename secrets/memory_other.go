@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package secrets
+
+func lockedBuffer(int) (*buffer, error) {
+	return nil, ErrLockedMemoryUnsupported
+}
+
+func release([]byte) {}
@@ -0,0 +1,34 @@
+//go:build linux || darwin
+
+package secrets
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockedBuffer maps size bytes of memory outside the Go heap, locks them into RAM, and
+// excludes them from core dumps where the platform allows it.
+func lockedBuffer(size int) (*buffer, error) {
+	pageSize := os.Getpagesize()
+	length := (size + pageSize - 1) / pageSize * pageSize
+	if length == 0 {
+		length = pageSize
+	}
+	region, err := syscall.Mmap(-1, 0, length, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Mlock(region); err != nil {
+		_ = syscall.Munmap(region)
+		return nil, err
+	}
+	excludeFromDumps(region)
+	return &buffer{bytes: region[:size], region: region}, nil
+}
+
+// release unlocks and unmaps a locked region.
+func release(region []byte) {
+	_ = syscall.Munlock(region)
+	_ = syscall.Munmap(region)
+}
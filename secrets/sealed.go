@@ -0,0 +1,237 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// MemoryMode is how the secrets held by a running handler (session strings and API keys) are
+// kept in memory.
+type MemoryMode string
+
+const (
+	MemoryPlain     MemoryMode = "plain"     // Secrets are kept as plain strings
+	MemoryEncrypted MemoryMode = "encrypted" // Secrets are encrypted and decrypted on use
+	MemoryLocked    MemoryMode = "locked"    // Like MemoryEncrypted, with the decrypted secrets in locked memory
+)
+
+// ErrLockedMemoryUnsupported is returned by NewSealer in MemoryLocked mode on platforms
+// without locked memory.
+var ErrLockedMemoryUnsupported = errors.New("locked memory is not supported on this platform")
+
+// ParseMemoryMode returns the memory mode of name, MemoryPlain when empty.
+func ParseMemoryMode(name string) (MemoryMode, error) {
+	switch mode := MemoryMode(name); mode {
+	case "":
+		return MemoryPlain, nil
+	case MemoryPlain, MemoryEncrypted, MemoryLocked:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown secret memory mode: %s", name)
+}
+
+// Sealer encrypts secrets with a key generated for the process, so that a dump of the process
+// memory does not reveal them in plain text, and scanning it for session strings or API keys
+// finds nothing.
+//
+// The AES-256 key is generated in a wiped buffer and expanded once into the cipher of the
+// sealer. The expanded key schedule lives in the Go heap for the life of the sealer: it is not
+// locked, not excluded from core dumps, and not wiped by Close, and the key can be recovered
+// from it. The sealer therefore does not protect the secrets from an attacker able to read the
+// memory of the process and knowing what to look for.
+//
+// In MemoryLocked mode, the key is generated and the secrets are decrypted in memory locked
+// into RAM (never swapped out) and excluded from core dumps where the platform allows it, and
+// wiped after use, so neither reaches the swap or a core dump in plain form.
+//
+// Secrets still exist in plain text while in use, e.g. in the body of a request, and in the
+// strings they were sealed from: callers should drop the configuration and accounts they
+// loaded once sealed.
+//
+// # Fields:
+//   - mu: Guards aead.
+//   - aead: The AES-GCM cipher of the key, nil once closed.
+//   - locked: Whether the memory of the key and the decrypted secrets is locked.
+type Sealer struct {
+	mu     sync.Mutex
+	aead   cipher.AEAD
+	locked bool
+}
+
+// NewSealer creates a sealer with a random key.
+//
+// # Parameters:
+//   - mode: MemoryEncrypted or MemoryLocked.
+//
+// # Returns:
+//   - *Sealer: The sealer.
+//   - error: ErrLockedMemoryUnsupported, or an error if the memory cannot be locked (e.g., over
+//     RLIMIT_MEMLOCK) or the key cannot be generated.
+func NewSealer(mode MemoryMode) (*Sealer, error) {
+	if mode != MemoryEncrypted && mode != MemoryLocked {
+		return nil, fmt.Errorf("secret memory mode %s does not encrypt", mode)
+	}
+	sealer := &Sealer{locked: mode == MemoryLocked}
+	key, err := sealer.allocate(32)
+	if err != nil {
+		return nil, err
+	}
+	defer key.free()
+	if _, err := rand.Read(key.bytes); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key.bytes)
+	if err != nil {
+		return nil, err
+	}
+	if sealer.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+	return sealer, nil
+}
+
+// Seal encrypts a secret. The empty secret seals to nil, which opens to the empty string.
+// The copy of the secret encrypted is wiped, but secret itself cannot be.
+//
+// # Example:
+//
+//	sealer, err := secrets.NewSealer(secrets.MemoryLocked)
+//	if err != nil {
+//		log.Fatalf("Failed to create sealer: %v", err)
+//	}
+//	apiKey := sealer.Seal(config.APIKey)
+//	config.APIKey = ""
+func (sealer *Sealer) Seal(secret string) *Sealed {
+	if secret == "" {
+		return nil
+	}
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(nonce); err != nil {
+		panic(fmt.Sprintf("secrets: failed to generate nonce: %v", err))
+	}
+	plaintext := []byte(secret)
+	defer Wipe(plaintext)
+	var ciphertext []byte
+	err := sealer.withCipher(func(aead cipher.AEAD) error {
+		ciphertext = aead.Seal(nil, nonce, plaintext, nil)
+		return nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("secrets: failed to seal: %v", err))
+	}
+	return &Sealed{sealer: sealer, nonce: nonce, ciphertext: ciphertext}
+}
+
+// Close drops the cipher of the sealer. Secrets sealed by the sealer can no longer be opened.
+// The key schedule of the cipher is left to the garbage collector, which does not wipe it.
+func (sealer *Sealer) Close() {
+	sealer.mu.Lock()
+	defer sealer.mu.Unlock()
+	sealer.aead = nil
+}
+
+// withCipher calls fn with the cipher of the sealer.
+func (sealer *Sealer) withCipher(fn func(aead cipher.AEAD) error) error {
+	sealer.mu.Lock()
+	aead := sealer.aead
+	sealer.mu.Unlock()
+	if aead == nil {
+		return errors.New("sealer is closed")
+	}
+	return fn(aead)
+}
+
+// allocate returns a buffer of size bytes, locked in MemoryLocked mode.
+func (sealer *Sealer) allocate(size int) (*buffer, error) {
+	if sealer.locked {
+		return lockedBuffer(size)
+	}
+	return &buffer{bytes: make([]byte, size)}, nil
+}
+
+// Sealed is a secret encrypted by a Sealer. The zero value and nil hold the empty secret.
+type Sealed struct {
+	sealer     *Sealer
+	nonce      []byte
+	ciphertext []byte
+}
+
+// Use decrypts the secret, calls fn with it, and wipes the decrypted secret. fn must not keep
+// the slice.
+//
+// # Example:
+//
+//	err := sealed.Use(func(secret []byte) error {
+//		mac := hmac.New(sha256.New, secret)
+//		mac.Write(payload)
+//		signature = mac.Sum(nil)
+//		return nil
+//	})
+func (sealed *Sealed) Use(fn func(secret []byte) error) error {
+	if sealed == nil || sealed.sealer == nil {
+		return fn(nil)
+	}
+	plaintext, err := sealed.sealer.allocate(len(sealed.ciphertext))
+	if err != nil {
+		return err
+	}
+	defer plaintext.free()
+	var opened []byte
+	err = sealed.sealer.withCipher(func(aead cipher.AEAD) error {
+		var err error
+		opened, err = aead.Open(plaintext.bytes[:0], sealed.nonce, sealed.ciphertext, nil)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open sealed secret: %w", err)
+	}
+	return fn(opened)
+}
+
+// Reveal returns the secret as a string, for APIs taking strings. The string is a plain-text
+// copy the garbage collector frees but does not wipe; prefer Use where possible.
+func (sealed *Sealed) Reveal() (string, error) {
+	var secret string
+	err := sealed.Use(func(plaintext []byte) error {
+		secret = string(plaintext)
+		return nil
+	})
+	return secret, err
+}
+
+// String implements fmt.Stringer without revealing the secret.
+func (sealed *Sealed) String() string {
+	return "[sealed]"
+}
+
+// Wipe overwrites b with zeros.
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// buffer is memory holding key material or a decrypted secret.
+//
+// # Fields:
+//   - bytes: The memory.
+//   - region: The mapping of locked buffers, nil for heap buffers.
+type buffer struct {
+	bytes  []byte
+	region []byte
+}
+
+// free wipes the buffer and releases locked memory.
+func (b *buffer) free() {
+	if b.region == nil {
+		Wipe(b.bytes)
+		return
+	}
+	Wipe(b.region)
+	release(b.region)
+	b.bytes, b.region = nil, nil
+}
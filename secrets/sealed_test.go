@@ -0,0 +1,179 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestParseMemoryMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    MemoryMode
+		wantErr bool
+	}{
+		{"", MemoryPlain, false},
+		{"plain", MemoryPlain, false},
+		{"encrypted", MemoryEncrypted, false},
+		{"locked", MemoryLocked, false},
+		{"Locked", "", true},
+		{"swap", "", true},
+	}
+	for _, test := range tests {
+		got, err := ParseMemoryMode(test.name)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("ParseMemoryMode(%q) = %q, %v, want %q, error %t", test.name, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+// newSealer creates a sealer in mode, skipping the test where locked memory is unavailable.
+func newSealer(t *testing.T, mode MemoryMode) *Sealer {
+	t.Helper()
+	sealer, err := NewSealer(mode)
+	if mode == MemoryLocked && err != nil {
+		t.Skipf("locked memory unavailable: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("NewSealer(%s) failed: %v", mode, err)
+	}
+	t.Cleanup(sealer.Close)
+	return sealer
+}
+
+func TestSealer(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"session", "1BVtsOKABu5hZ2Y3n2yVZq0ZpJ9c0v8Xq1e"},
+		{"empty", ""},
+		{"unicode", "clé-секрет-🔑"},
+		{"long", string(bytes.Repeat([]byte("k"), 4096))},
+	}
+	for _, mode := range []MemoryMode{MemoryEncrypted, MemoryLocked} {
+		t.Run(string(mode), func(t *testing.T) {
+			sealer := newSealer(t, mode)
+			for _, test := range tests {
+				t.Run(test.name, func(t *testing.T) {
+					sealed := sealer.Seal(test.secret)
+					if test.secret == "" && sealed != nil {
+						t.Errorf("Seal(\"\") = %v, want nil", sealed)
+					}
+					if sealed != nil && len(test.secret) > 0 && bytes.Contains(sealed.ciphertext, []byte(test.secret)) {
+						t.Errorf("ciphertext contains the secret")
+					}
+					got, err := sealed.Reveal()
+					if err != nil || got != test.secret {
+						t.Errorf("Reveal() = %q, %v, want %q", got, err, test.secret)
+					}
+					if text := fmt.Sprint(sealed); text != "[sealed]" {
+						t.Errorf("sealed secret prints as %q", text)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestSealUsesFreshNonces(t *testing.T) {
+	sealer := newSealer(t, MemoryEncrypted)
+	first, second := sealer.Seal("secret"), sealer.Seal("secret")
+	if bytes.Equal(first.nonce, second.nonce) || bytes.Equal(first.ciphertext, second.ciphertext) {
+		t.Errorf("sealing a secret twice gave the same nonce or ciphertext")
+	}
+}
+
+func TestUseWipesTheSecret(t *testing.T) {
+	sealer := newSealer(t, MemoryEncrypted)
+	var kept []byte
+	if err := sealer.Seal("secret").Use(func(secret []byte) error {
+		if string(secret) != "secret" {
+			t.Errorf("Use passed %q, want the secret", secret)
+		}
+		kept = secret
+		return nil
+	}); err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+	if !bytes.Equal(kept, make([]byte, len(kept))) {
+		t.Errorf("decrypted secret not wiped after Use: %q", kept)
+	}
+	want := errors.New("rejected")
+	if err := sealer.Seal("secret").Use(func([]byte) error { return want }); !errors.Is(err, want) {
+		t.Errorf("Use returned %v, want the error of fn", err)
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	sealer := newSealer(t, MemoryEncrypted)
+	sealed := sealer.Seal("secret")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if got, err := sealed.Reveal(); got != "secret" || err != nil {
+					t.Errorf("Reveal() = %q, %v, want the secret", got, err)
+					return
+				}
+				sealer.Seal("other")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestOpenFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		sealed func(t *testing.T) *Sealed
+	}{
+		{"closed sealer", func(t *testing.T) *Sealed {
+			sealer, err := NewSealer(MemoryEncrypted)
+			if err != nil {
+				t.Fatalf("NewSealer failed: %v", err)
+			}
+			sealed := sealer.Seal("secret")
+			sealer.Close()
+			return sealed
+		}},
+		{"tampered ciphertext", func(t *testing.T) *Sealed {
+			sealed := newSealer(t, MemoryEncrypted).Seal("secret")
+			sealed.ciphertext[0] ^= 1
+			return sealed
+		}},
+		{"other sealer", func(t *testing.T) *Sealed {
+			sealed := newSealer(t, MemoryEncrypted).Seal("secret")
+			other := newSealer(t, MemoryEncrypted).Seal("other")
+			other.nonce, other.ciphertext = sealed.nonce, sealed.ciphertext
+			return other
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if secret, err := test.sealed(t).Reveal(); err == nil {
+				t.Errorf("Reveal() = %q, want an error", secret)
+			}
+		})
+	}
+}
+
+func TestNewSealerRejectsPlainMode(t *testing.T) {
+	for _, mode := range []MemoryMode{MemoryPlain, ""} {
+		if _, err := NewSealer(mode); err == nil {
+			t.Errorf("NewSealer(%q) succeeded, want an error", mode)
+		}
+	}
+}
+
+func TestZeroSealed(t *testing.T) {
+	for name, sealed := range map[string]*Sealed{"nil": nil, "zero": {}} {
+		if got, err := sealed.Reveal(); got != "" || err != nil {
+			t.Errorf("%s sealed reveals %q, %v, want the empty secret", name, got, err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Provider resolves secret references against a secret store.
+//
+// A reference is the part of a configuration value after the provider's scheme, e.g.
+// "secret/data/nexus#api_key" for the value "vault:secret/data/nexus#api_key".
+type Provider interface {
+	Resolve(ctx context.Context, reference string) (string, error)
+}
+
+// Resolver replaces secret references in configuration values with the secrets they point to.
+//
+// A value of the form "<scheme>:<reference>" whose scheme has a registered provider is
+// resolved through that provider. Any other value is returned unchanged, so plaintext
+// configuration keeps working.
+//
+// # Fields:
+//   - providers: The registered providers, keyed by scheme.
+//
+// # Example:
+//
+//	resolver := secrets.NewResolver()
+//	resolver.Register("env", secrets.Env{})
+//	apiKey, err := resolver.Resolve(ctx, "env:NEXUS_API_KEY")
+type Resolver struct {
+	providers map[string]Provider
+}
+
+// NewResolver creates a resolver without any providers.
+func NewResolver() *Resolver {
+	return &Resolver{providers: make(map[string]Provider)}
+}
+
+// DefaultResolver returns a resolver with the providers configurable from the environment.
+//
+// # Schemes:
+//   - env: Always registered, e.g. "env:NEXUS_API_KEY".
+//   - vault: Registered when VAULT_ADDR and VAULT_TOKEN are set, e.g. "vault:secret/data/nexus#api_key".
+//   - awssm: Registered when AWS credentials are set, e.g. "awssm:nexus/prod#api_key".
+func DefaultResolver() *Resolver {
+	resolver := NewResolver()
+	resolver.Register("env", Env{})
+	if vault := VaultFromEnv(); vault.Address != "" && vault.Token != "" {
+		resolver.Register("vault", vault)
+	}
+	if secretsManager := AWSSecretsManagerFromEnv(); secretsManager.Credentials.Valid() {
+		resolver.Register("awssm", secretsManager)
+	}
+	return resolver
+}
+
+// Register adds a provider for a scheme, replacing any previous provider of that scheme.
+func (resolver *Resolver) Register(scheme string, provider Provider) {
+	resolver.providers[scheme] = provider
+}
+
+// Supports reports whether a provider is registered for scheme.
+func (resolver *Resolver) Supports(scheme string) bool {
+	_, ok := resolver.providers[scheme]
+	return ok
+}
+
+// Resolve returns the secret a value refers to, or the value itself if it is not a reference.
+//
+// # Parameters:
+//   - ctx: The context of the provider requests.
+//   - value: The configuration value (e.g., "vault:secret/data/nexus#api_key").
+//
+// # Returns:
+//   - string: The resolved secret, or value unchanged.
+//   - error: An error if the provider fails to resolve the reference.
+func (resolver *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	scheme, reference, ok := strings.Cut(value, ":")
+	if !ok {
+		return value, nil
+	}
+	provider, ok := resolver.providers[scheme]
+	if !ok {
+		return value, nil
+	}
+	secret, err := provider.Resolve(ctx, reference)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s secret '%s': %w", scheme, reference, err)
+	}
+	return secret, nil
+}
+
+// ResolveAll resolves every value in place, stopping at the first error.
+//
+// # Example:
+//
+//	err := resolver.ResolveAll(ctx, &config.APIKey, &config.Proxy.Username, &config.Proxy.Password)
+func (resolver *Resolver) ResolveAll(ctx context.Context, values ...*string) error {
+	for _, value := range values {
+		resolved, err := resolver.Resolve(ctx, *value)
+		if err != nil {
+			return err
+		}
+		*value = resolved
+	}
+	return nil
+}
+
+// Env is a Provider reading secrets from environment variables.
+type Env struct{}
+
+// Resolve returns the value of the environment variable named by reference.
+func (Env) Resolve(_ context.Context, reference string) (string, error) {
+	value, ok := os.LookupEnv(reference)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", reference)
+	}
+	return value, nil
+}
+
+// splitKey splits a reference into the secret name and the optional "#key" selecting a field
+// of a JSON secret.
+func splitKey(reference string) (string, string) {
+	name, key, _ := strings.Cut(reference, "#")
+	return name, key
+}
+
+// selectKey returns the field key of a JSON object secret, or the whole secret when key is empty.
+func selectKey(secret, key string) (string, error) {
+	if key == "" {
+		return secret, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object: %w", err)
+	}
+	return fieldString(fields, key)
+}
+
+func fieldString(fields map[string]interface{}, key string) (string, error) {
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no key '%s'", key)
+	}
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	return fmt.Sprint(value), nil
+}
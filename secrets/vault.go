@@ -0,0 +1,88 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Vault is a Provider reading secrets from HashiCorp Vault over its HTTP API.
+//
+// References have the form "<path>#<key>", e.g. "secret/data/nexus#api_key". Both KV version 1
+// and version 2 engines are supported; version 2 paths include the "data/" segment.
+//
+// # Fields:
+//   - Address: The Vault address (e.g., "https://vault.internal:8200").
+//   - Token: The Vault token sent as X-Vault-Token.
+//   - Namespace: The optional Vault Enterprise namespace.
+//   - Client: The HTTP client used for requests. Defaults to a client with a 10 second timeout.
+type Vault struct {
+	Address   string
+	Token     string
+	Namespace string
+	Client    *http.Client
+}
+
+// VaultFromEnv returns a Vault provider configured from VAULT_ADDR, VAULT_TOKEN, and VAULT_NAMESPACE.
+func VaultFromEnv() *Vault {
+	return &Vault{
+		Address:   os.Getenv("VAULT_ADDR"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+	}
+}
+
+// Resolve reads the secret at the reference's path and returns its key.
+func (vault *Vault) Resolve(ctx context.Context, reference string) (string, error) {
+	path, key := splitKey(reference)
+	if key == "" {
+		return "", fmt.Errorf("vault reference '%s' has no #key", reference)
+	}
+	url := strings.TrimSuffix(vault.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", vault.Token)
+	if vault.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", vault.Namespace)
+	}
+	client := vault.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+		}
+	}(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", err
+	}
+	fields := response.Data
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := fields["metadata"]; hasMetadata {
+			fields = nested
+		}
+	}
+	return fieldString(fields, key)
+}
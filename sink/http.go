@@ -0,0 +1,204 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotRouted is returned by RabbitMQHTTP when the exchange routed an event to no queue.
+var ErrNotRouted = errors.New("event not routed to any queue")
+
+// KafkaREST is a Sink publishing events to a Kafka topic through a Kafka REST proxy (the
+// Confluent REST Proxy, or the HTTP proxy of Redpanda), with the v2 API. The events are keyed
+// by account, so that the events of an account stay in order in one partition.
+//
+// The SDK has no producer speaking the Kafka protocol: publishing to Kafka requires a REST
+// proxy in front of the brokers.
+//
+// # Fields:
+//   - URL: The base URL of the REST proxy (e.g., "http://10.0.0.5:8082").
+//   - Topic: The topic of the events.
+//   - Username: The optional user name of the proxy's basic authentication.
+//   - Password: The optional password of the proxy's basic authentication.
+//   - Client: The HTTP client used for requests. Defaults to a client with a 10 second timeout.
+//
+// # Example:
+//
+//	gameHandler.SetEventSink(&sink.KafkaREST{URL: "http://10.0.0.5:8082", Topic: "farm-events"})
+type KafkaREST struct {
+	URL      string
+	Topic    string
+	Username string
+	Password string
+	Client   *http.Client
+}
+
+// kafkaRecords is the body of a produce request of the REST proxy.
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+// kafkaRecord is a record of a produce request of the REST proxy.
+type kafkaRecord struct {
+	Key   *string `json:"key"`
+	Value Event   `json:"value"`
+}
+
+// Publish produces an event to the topic.
+func (kafka *KafkaREST) Publish(ctx context.Context, event Event) error {
+	record := kafkaRecord{Value: event}
+	if event.Account != "" {
+		record.Key = &event.Account
+	}
+	body, err := json.Marshal(kafkaRecords{Records: []kafkaRecord{record}})
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimSuffix(kafka.URL, "/") + "/topics/" + url.PathEscape(kafka.Topic)
+	response, err := post(ctx, kafka.Client, endpoint, "application/vnd.kafka.json.v2+json", body, kafka.Username, kafka.Password)
+	if err != nil {
+		return fmt.Errorf("kafka: %w", err)
+	}
+	var produced struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if json.Unmarshal(response, &produced) == nil {
+		for _, offset := range produced.Offsets {
+			if offset.ErrorCode != nil {
+				return fmt.Errorf("kafka: error %d: %s", *offset.ErrorCode, offset.Error)
+			}
+		}
+	}
+	return nil
+}
+
+// Close does nothing: the requests to the REST proxy hold no connection of their own.
+func (kafka *KafkaREST) Close() error {
+	return nil
+}
+
+// RabbitMQHTTP is a Sink publishing events to a RabbitMQ exchange through the publish endpoint
+// of the HTTP API of the management plugin, with the routing key returned by Topic with
+// Prefix. The events are persistent, and an event the exchange routes to no queue fails with
+// ErrNotRouted.
+//
+// The SDK has no AMQP client: this sink is the only way it publishes to RabbitMQ. RabbitMQ
+// documents the publish endpoint as a development and monitoring aid, not meant for production
+// traffic; it opens a channel per event and has no publisher confirms.
+//
+// # Fields:
+//   - URL: The base URL of the management API (e.g., "http://10.0.0.5:15672").
+//   - VHost: The virtual host of the exchange. Defaults to "/".
+//   - Exchange: The exchange of the events, e.g. "amq.topic".
+//   - Prefix: The prefix of the routing keys, e.g. "nexus".
+//   - Username: The user name, which needs the "management" tag. Defaults to "guest".
+//   - Password: The password. Defaults to "guest".
+//   - Client: The HTTP client used for requests. Defaults to a client with a 10 second timeout.
+//
+// # Example:
+//
+//	gameHandler.SetEventSink(&sink.RabbitMQHTTP{URL: "http://10.0.0.5:15672", Exchange: "amq.topic",
+//		Prefix: "nexus", Username: "farm", Password: "secret"})
+//	// Queues bound to "amq.topic" with "nexus.#" receive every event.
+//
+// # Notes:
+//   - Farms relying on the events for billing, or publishing more than a few events per
+//     second, should publish to NATS or KafkaREST, or bridge those to RabbitMQ.
+type RabbitMQHTTP struct {
+	URL      string
+	VHost    string
+	Exchange string
+	Prefix   string
+	Username string
+	Password string
+	Client   *http.Client
+}
+
+// Publish publishes an event to the exchange.
+func (rabbit *RabbitMQHTTP) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"properties":       map[string]interface{}{"content_type": "application/json", "delivery_mode": 2},
+		"routing_key":      Topic(rabbit.Prefix, event),
+		"payload":          string(payload),
+		"payload_encoding": "string",
+	})
+	if err != nil {
+		return err
+	}
+	vhost, username, password := rabbit.VHost, rabbit.Username, rabbit.Password
+	if vhost == "" {
+		vhost = "/"
+	}
+	if username == "" && password == "" {
+		username, password = "guest", "guest"
+	}
+	endpoint := fmt.Sprintf("%s/api/exchanges/%s/%s/publish", strings.TrimSuffix(rabbit.URL, "/"),
+		url.PathEscape(vhost), url.PathEscape(rabbit.Exchange))
+	response, err := post(ctx, rabbit.Client, endpoint, "application/json", body, username, password)
+	if err != nil {
+		return fmt.Errorf("rabbitmq: %w", err)
+	}
+	var published struct {
+		Routed bool `json:"routed"`
+	}
+	if err := json.Unmarshal(response, &published); err != nil {
+		return fmt.Errorf("rabbitmq: %w", err)
+	}
+	if !published.Routed {
+		return fmt.Errorf("rabbitmq: %w", ErrNotRouted)
+	}
+	return nil
+}
+
+// Close does nothing: the requests to the management API hold no connection of their own.
+func (rabbit *RabbitMQHTTP) Close() error {
+	return nil
+}
+
+// post sends a POST request with basic authentication, if any, and returns the body of its
+// response.
+func post(ctx context.Context, client *http.Client, endpoint, contentType string, body []byte, username, password string) ([]byte, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if username != "" || password != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+		}
+	}(resp.Body)
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, responseBody)
+	}
+	return responseBody, nil
+}
@@ -0,0 +1,142 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// request is a request received by a test server.
+type request struct {
+	path        string
+	contentType string
+	user        string
+	password    string
+	body        map[string]interface{}
+}
+
+// gateway starts a server recording its requests and answering with status and response.
+func gateway(t *testing.T, status int, response string) (*httptest.Server, *[]request) {
+	t.Helper()
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received := request{path: r.URL.EscapedPath(), contentType: r.Header.Get("Content-Type")}
+		received.user, received.password, _ = r.BasicAuth()
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &received.body); err != nil {
+			t.Errorf("request body is not JSON: %s", data)
+		}
+		requests = append(requests, received)
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestKafkaREST(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		account  string
+		wantKey  interface{}
+		wantErr  string
+	}{
+		{"keyed by account", http.StatusOK, `{"offsets":[{"partition":0,"offset":12}]}`, "987654321", "987654321", ""},
+		{"farm event", http.StatusOK, `{"offsets":[{"partition":0,"offset":13}]}`, "", nil, ""},
+		{"record error", http.StatusOK, `{"offsets":[{"error_code":40403,"error":"topic not found"}]}`, "1", "1", "error 40403: topic not found"},
+		{"proxy error", http.StatusUnauthorized, `{"message":"unauthorized"}`, "1", "1", "status 401"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, requests := gateway(t, test.status, test.response)
+			kafka := &KafkaREST{URL: server.URL + "/", Topic: "farm events", Username: "farm", Password: "secret"}
+			err := kafka.Publish(context.Background(), Event{Type: EventTaskResult, Game: "Blum", Account: test.account})
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("Publish() error = %v, want %q", err, test.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("Publish() failed: %v", err)
+			}
+			if len(*requests) != 1 {
+				t.Fatalf("proxy received %d requests, want 1", len(*requests))
+			}
+			received := (*requests)[0]
+			if received.path != "/topics/farm%20events" || received.contentType != "application/vnd.kafka.json.v2+json" ||
+				received.user != "farm" || received.password != "secret" {
+				t.Errorf("request = %+v, want a produce request to the topic with basic authentication", received)
+			}
+			records, _ := received.body["records"].([]interface{})
+			if len(records) != 1 {
+				t.Fatalf("request body = %v, want one record", received.body)
+			}
+			record := records[0].(map[string]interface{})
+			if record["key"] != test.wantKey || record["value"].(map[string]interface{})["type"] != EventTaskResult {
+				t.Errorf("record = %v, want key %v and the event as value", record, test.wantKey)
+			}
+		})
+	}
+}
+
+func TestRabbitMQHTTP(t *testing.T) {
+	tests := []struct {
+		name         string
+		rabbit       RabbitMQHTTP
+		status       int
+		response     string
+		wantPath     string
+		wantUser     string
+		wantErr      error
+		wantErrMatch string
+	}{
+		{"routed", RabbitMQHTTP{Exchange: "amq.topic", Prefix: "nexus", Username: "farm", Password: "secret"},
+			http.StatusOK, `{"routed":true}`, "/api/exchanges/%2F/amq.topic/publish", "farm", nil, ""},
+		{"virtual host and default credentials", RabbitMQHTTP{VHost: "farm", Exchange: "events"},
+			http.StatusOK, `{"routed":true}`, "/api/exchanges/farm/events/publish", "guest", nil, ""},
+		{"not routed", RabbitMQHTTP{Exchange: "amq.topic"}, http.StatusOK, `{"routed":false}`,
+			"/api/exchanges/%2F/amq.topic/publish", "guest", ErrNotRouted, ""},
+		{"missing exchange", RabbitMQHTTP{Exchange: "missing"}, http.StatusNotFound, `{"error":"Object Not Found"}`,
+			"/api/exchanges/%2F/missing/publish", "guest", nil, "status 404"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, requests := gateway(t, test.status, test.response)
+			rabbit := test.rabbit
+			rabbit.URL = server.URL
+			err := rabbit.Publish(context.Background(), Event{Type: EventAccountCooldown, Game: "Blum", Account: "1"})
+			switch {
+			case test.wantErr != nil:
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("Publish() error = %v, want %v", err, test.wantErr)
+				}
+			case test.wantErrMatch != "":
+				if err == nil || !strings.Contains(err.Error(), test.wantErrMatch) {
+					t.Errorf("Publish() error = %v, want %q", err, test.wantErrMatch)
+				}
+			case err != nil:
+				t.Errorf("Publish() failed: %v", err)
+			}
+			if len(*requests) != 1 {
+				t.Fatalf("management API received %d requests, want 1", len(*requests))
+			}
+			received := (*requests)[0]
+			if received.path != test.wantPath || received.user != test.wantUser {
+				t.Errorf("request to %s as %s, want %s as %s", received.path, received.user, test.wantPath, test.wantUser)
+			}
+			if key := received.body["routing_key"]; key != Topic(test.rabbit.Prefix, Event{Type: EventAccountCooldown, Game: "Blum"}) {
+				t.Errorf("routing key = %v", key)
+			}
+			var payload Event
+			if err := json.Unmarshal([]byte(received.body["payload"].(string)), &payload); err != nil || payload.Account != "1" {
+				t.Errorf("payload = %v, want the event", received.body["payload"])
+			}
+		})
+	}
+}
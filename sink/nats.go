@@ -0,0 +1,171 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATS is a Sink publishing events to a NATS server, under the subject returned by Topic with
+// Prefix. It speaks the NATS text protocol over one connection, opened on the first event and
+// opened again after an error, and waits for the server to acknowledge every event with a
+// PING/PONG exchange, so that the errors of the server (e.g., a denied subject) are returned.
+//
+// # Fields:
+//   - Addr: The address of the NATS server (e.g., "10.0.0.5:4222").
+//   - Prefix: The prefix of the subjects, e.g. "nexus".
+//   - Token: The optional authentication token.
+//   - Username: The optional user name.
+//   - Password: The optional password.
+//
+// # Example:
+//
+//	gameHandler.SetEventSink(&sink.NATS{Addr: "10.0.0.5:4222", Prefix: "nexus"})
+//	// Subscribers of "nexus.*.task.result" receive the task results of every game.
+//
+// # Notes:
+//   - TLS connections are not supported.
+type NATS struct {
+	Addr     string
+	Prefix   string
+	Token    string
+	Username string
+	Password string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// natsConnect is the CONNECT message of the NATS protocol.
+type natsConnect struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Name     string `json:"name"`
+	Lang     string `json:"lang"`
+	Version  string `json:"version"`
+	Token    string `json:"auth_token,omitempty"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+}
+
+// Publish publishes an event and waits for the server to acknowledge it.
+func (nats *NATS) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	nats.mu.Lock()
+	defer nats.mu.Unlock()
+	if nats.conn == nil {
+		if err := nats.connect(ctx); err != nil {
+			return fmt.Errorf("nats: %w", err)
+		}
+	}
+	if err := nats.publish(ctx, Topic(nats.Prefix, event), payload); err != nil {
+		nats.closeConn()
+		return fmt.Errorf("nats: %w", err)
+	}
+	return nil
+}
+
+// connect opens the connection to the server and authenticates. It must be called with mu held.
+func (nats *NATS) connect(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", nats.Addr)
+	if err != nil {
+		return err
+	}
+	nats.conn, nats.reader = conn, bufio.NewReader(conn)
+	nats.setDeadline(ctx)
+	line, err := nats.readLine()
+	if err == nil && !strings.HasPrefix(line, "INFO ") {
+		err = fmt.Errorf("unexpected greeting: %q", line)
+	}
+	if err == nil {
+		var connect []byte
+		connect, err = json.Marshal(natsConnect{Name: "nexus-sdk", Lang: "go", Version: "1.0.0",
+			Token: nats.Token, User: nats.Username, Pass: nats.Password})
+		if err == nil {
+			_, err = fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connect)
+		}
+	}
+	if err == nil {
+		err = nats.awaitPong()
+	}
+	if err != nil {
+		nats.closeConn()
+	}
+	return err
+}
+
+// publish sends a message and waits for the PONG following it. It must be called with mu held.
+func (nats *NATS) publish(ctx context.Context, subject string, payload []byte) error {
+	nats.setDeadline(ctx)
+	if _, err := fmt.Fprintf(nats.conn, "PUB %s %d\r\n%s\r\nPING\r\n", subject, len(payload), payload); err != nil {
+		return err
+	}
+	return nats.awaitPong()
+}
+
+// awaitPong reads the messages of the server until a PONG, answering its PINGs, and returns the
+// first error it sent.
+func (nats *NATS) awaitPong() error {
+	for {
+		line, err := nats.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := nats.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'"))
+		}
+	}
+}
+
+// readLine reads a line of the server without its CRLF.
+func (nats *NATS) readLine() (string, error) {
+	line, err := nats.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// setDeadline bounds the exchange with the server by the deadline of ctx, or 10 seconds.
+func (nats *NATS) setDeadline(ctx context.Context) {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = nats.conn.SetDeadline(deadline)
+	} else {
+		_ = nats.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	}
+}
+
+// closeConn closes the connection, if any. It must be called with mu held.
+func (nats *NATS) closeConn() error {
+	if nats.conn == nil {
+		return nil
+	}
+	err := nats.conn.Close()
+	nats.conn, nats.reader = nil, nil
+	return err
+}
+
+// Close closes the connection to the server.
+func (nats *NATS) Close() error {
+	nats.mu.Lock()
+	defer nats.mu.Unlock()
+	return nats.closeConn()
+}
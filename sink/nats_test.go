@@ -0,0 +1,134 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// natsServer is a NATS server speaking enough of the protocol to receive the publications of
+// a NATS sink. Publications to denied subjects are answered with a permissions error.
+type natsServer struct {
+	listener net.Listener
+	denied   string
+	mu       sync.Mutex
+	connects []natsConnect
+	messages map[string][]string
+}
+
+func newNATSServer(t *testing.T, denied string) *natsServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	server := &natsServer{listener: listener, denied: denied, messages: make(map[string][]string)}
+	go server.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+	return server
+}
+
+func (server *natsServer) serve() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.handle(conn)
+	}
+}
+
+func (server *natsServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"test\"}\r\n")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "CONNECT":
+			var connect natsConnect
+			_ = json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(line), "CONNECT ")), &connect)
+			server.mu.Lock()
+			server.connects = append(server.connects, connect)
+			server.mu.Unlock()
+		case fields[0] == "PING":
+			// A PING of the server first, which the client must answer.
+			fmt.Fprintf(conn, "PING\r\n")
+			if pong, err := reader.ReadString('\n'); err != nil || pong != "PONG\r\n" {
+				return
+			}
+			fmt.Fprintf(conn, "PONG\r\n")
+		case fields[0] == "PUB" && len(fields) == 3:
+			payload, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if fields[1] == server.denied {
+				fmt.Fprintf(conn, "-ERR 'Permissions Violation for Publish to \"%s\"'\r\n", fields[1])
+				continue
+			}
+			server.mu.Lock()
+			server.messages[fields[1]] = append(server.messages[fields[1]], strings.TrimSpace(payload))
+			server.mu.Unlock()
+		}
+	}
+}
+
+func TestNATS(t *testing.T) {
+	server := newNATSServer(t, "nexus.blum.proxy.dead")
+	nats := &NATS{Addr: server.listener.Addr().String(), Prefix: "nexus", Username: "farm", Password: "secret"}
+	defer nats.Close()
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := nats.Publish(ctx, Event{Type: EventTaskResult, Game: "Blum", Account: "1"}); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+	err := nats.Publish(ctx, Event{Type: EventProxyDead, Game: "Blum"})
+	if err == nil || !strings.Contains(err.Error(), "Permissions Violation") {
+		t.Errorf("Publish() to a denied subject returned %v, want the error of the server", err)
+	}
+	if err := nats.Publish(ctx, Event{Type: EventTaskResult, Game: "Blum", Account: "2"}); err != nil {
+		t.Fatalf("Publish after an error failed: %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.connects) != 2 || server.connects[0].User != "farm" || server.connects[0].Pass != "secret" {
+		t.Errorf("server received CONNECT messages %+v, want one per connection with the credentials", server.connects)
+	}
+	results := server.messages["nexus.blum.task.result"]
+	if len(results) != 3 {
+		t.Fatalf("server received %d task results, want 3", len(results))
+	}
+	var event Event
+	if err := json.Unmarshal([]byte(results[2]), &event); err != nil || event.Account != "2" {
+		t.Errorf("last task result = %s, want the event of account 2", results[2])
+	}
+}
+
+func TestNATSUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+	nats := &NATS{Addr: addr}
+	if err := nats.Publish(context.Background(), Event{Type: EventTaskResult}); err == nil || !strings.HasPrefix(err.Error(), "nats: ") {
+		t.Errorf("Publish() to an unreachable server returned %v, want a nats error", err)
+	}
+	if err := nats.Close(); err != nil {
+		t.Errorf("Close() without connection returned %v", err)
+	}
+}
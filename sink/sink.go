@@ -0,0 +1,260 @@
+// Package sink publishes the task results and events of a farm to message queues, so that
+// analytics and billing systems can consume the farm activity in real time.
+//
+// NATS is published to with its own protocol. Kafka and RabbitMQ are only reached through HTTP
+// gateways: a Kafka REST proxy (KafkaREST) and the RabbitMQ management API (RabbitMQHTTP).
+// There are no native Kafka or AMQP producers.
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The types of the events published by a handler.
+const (
+	EventTaskResult      = "task.result"      // A task execution finished, retries included.
+	EventAccountCooldown = "account.cooldown" // An account showed a ban signal and stopped running tasks.
+	EventProxyDead       = "proxy.dead"       // A proxy of the pool was marked as dead.
+	EventMaintenance     = "game.maintenance" // A maintenance response of the game paused its tasks.
+)
+
+// DefaultBuffer is how many events an Async sink holds while they are published.
+const DefaultBuffer = 1024
+
+// publishTimeout bounds the publication of one event by an Async sink.
+const publishTimeout = 10 * time.Second
+
+// ErrBufferFull is passed to the error handler of an Async sink for the events dropped because
+// its buffer was full.
+var ErrBufferFull = errors.New("event buffer full")
+
+// Event is a task result or an event of a farm, published as a JSON object.
+//
+// # Fields:
+//   - Type: The type of the event, e.g. EventTaskResult.
+//   - Time: When the event happened.
+//   - Game: The name of the game.
+//   - Account: The Telegram ID of the account, if the event concerns one.
+//   - Task: The name of the task, for task results.
+//   - Duration: How long the task execution took, retries included, for task results.
+//   - Attempts: How many times the task ran, for task results.
+//   - Success: Whether the task execution succeeded, for task results.
+//   - Error: The error of the event, if any.
+//   - RunID: The ID of the run of the task execution, for task results (see
+//     handler.RunReport).
+//   - Data: The other details of the event (e.g., the proxy of EventProxyDead).
+//
+// # Example JSON:
+//
+//	{"type":"task.result","time":"2024-11-20T10:30:00Z","game":"Blum","account":"987654321","task":"Claim","duration_ms":412,"attempts":1,"success":true,"run_id":"20241120T103000-9f86d081"}
+type Event struct {
+	Type     string                 `json:"type"`
+	Time     time.Time              `json:"time"`
+	Game     string                 `json:"game"`
+	Account  string                 `json:"account,omitempty"`
+	Task     string                 `json:"task,omitempty"`
+	Duration time.Duration          `json:"-"`
+	Attempts int                    `json:"attempts,omitempty"`
+	Success  bool                   `json:"success,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	RunID    string                 `json:"run_id,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// Sink is a destination the events of a farm are published to.
+//
+// # Methods:
+//   - Publish(ctx context.Context, event Event) error: Publishes an event.
+//   - Close() error: Releases the connections of the sink.
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+	Close() error
+}
+
+// Topic returns the subject, topic, or routing key an event is published under: the prefix
+// followed by the game and the event type, separated by dots (e.g., "nexus.blum.task.result").
+// Characters of the game name other than letters, digits, "-", and "_" are replaced by "_".
+func Topic(prefix string, event Event) string {
+	game := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(event.Game))
+	parts := []string{event.Type}
+	if game != "" {
+		parts = append([]string{game}, parts...)
+	}
+	if prefix != "" {
+		parts = append([]string{prefix}, parts...)
+	}
+	return strings.Join(parts, ".")
+}
+
+// MarshalJSON encodes an event with its duration in milliseconds, as "duration_ms".
+func (event Event) MarshalJSON() ([]byte, error) {
+	type plain Event
+	return json.Marshal(struct {
+		plain
+		DurationMs int64 `json:"duration_ms,omitempty"`
+	}{plain(event), event.Duration.Milliseconds()})
+}
+
+// Multi is a Sink publishing every event to several sinks.
+type Multi []Sink
+
+// Publish publishes an event to every sink, and returns their errors joined.
+func (multi Multi) Publish(ctx context.Context, event Event) error {
+	var errs []error
+	for _, sink := range multi {
+		if err := sink.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every sink, and returns their errors joined.
+func (multi Multi) Close() error {
+	var errs []error
+	for _, sink := range multi {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Async is a Sink publishing the events of a buffer in the background, so that a slow or
+// unreachable broker does not slow the tasks down. Events published while the buffer is full
+// are dropped.
+//
+// # Example:
+//
+//	events := sink.NewAsync(&sink.NATS{Addr: "10.0.0.5:4222", Prefix: "nexus"}, sink.DefaultBuffer, func(event sink.Event, err error) {
+//		log.Printf("Failed to publish %s: %v", event.Type, err)
+//	})
+//	defer events.Close()
+type Async struct {
+	sink    Sink
+	events  chan Event
+	onError func(event Event, err error)
+	mu      sync.RWMutex
+	closed  bool
+	done    chan struct{}
+}
+
+// NewAsync starts publishing the events given to the returned sink to sink.
+//
+// # Parameters:
+//   - sink: The sink the events are published to.
+//   - buffer: How many events are held while they are published. Defaults to DefaultBuffer.
+//   - onError: The optional function called with the events that could not be published or
+//     were dropped (see ErrBufferFull).
+func NewAsync(sink Sink, buffer int, onError func(event Event, err error)) *Async {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	async := &Async{sink: sink, events: make(chan Event, buffer), onError: onError, done: make(chan struct{})}
+	go async.run()
+	return async
+}
+
+// run publishes the events of the buffer until it is closed.
+func (async *Async) run() {
+	defer close(async.done)
+	for event := range async.events {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		err := async.sink.Publish(ctx, event)
+		cancel()
+		if err != nil && async.onError != nil {
+			async.onError(event, err)
+		}
+	}
+}
+
+// Publish adds an event to the buffer without waiting for its publication.
+func (async *Async) Publish(ctx context.Context, event Event) error {
+	async.mu.RLock()
+	defer async.mu.RUnlock()
+	if async.closed {
+		return errors.New("event sink closed")
+	}
+	select {
+	case async.events <- event:
+		return nil
+	default:
+		if async.onError != nil {
+			async.onError(event, ErrBufferFull)
+		}
+		return ErrBufferFull
+	}
+}
+
+// Close publishes the events left in the buffer, then closes the sink.
+func (async *Async) Close() error {
+	async.mu.Lock()
+	if async.closed {
+		async.mu.Unlock()
+		return nil
+	}
+	async.closed = true
+	close(async.events)
+	async.mu.Unlock()
+	<-async.done
+	return async.sink.Close()
+}
+
+// FromConfig creates the sink described by the sinks section of config.json, publishing to
+// every configured broker. The types "kafka" and "rabbitmq" are rejected with an error naming
+// the supported gateway, since there are no native producers for them.
+//
+// # Parameters:
+//   - configs: The "sinks" section of config.json.
+//
+// # Returns:
+//   - Sink: The sink, or nil when no sink is configured.
+//   - error: An error if a sink type is unknown or a sink is missing its address.
+func FromConfig(configs []types.SinkConfig) (Sink, error) {
+	var sinks Multi
+	for _, config := range configs {
+		var sink Sink
+		switch strings.ToLower(config.Type) {
+		case "nats":
+			if config.Addr == "" {
+				return nil, fmt.Errorf("nats sink requires an addr")
+			}
+			sink = &NATS{Addr: config.Addr, Prefix: config.Topic, Token: config.Token, Username: config.Username, Password: config.Password}
+		case "kafka_rest":
+			if config.URL == "" || config.Topic == "" {
+				return nil, fmt.Errorf("kafka_rest sink requires a url and a topic")
+			}
+			sink = &KafkaREST{URL: config.URL, Topic: config.Topic, Username: config.Username, Password: config.Password}
+		case "rabbitmq_http":
+			if config.URL == "" || config.Exchange == "" {
+				return nil, fmt.Errorf("rabbitmq_http sink requires a url and an exchange")
+			}
+			sink = &RabbitMQHTTP{URL: config.URL, VHost: config.VHost, Exchange: config.Exchange, Prefix: config.Topic,
+				Username: config.Username, Password: config.Password}
+		case "kafka", "rabbitmq", "amqp":
+			return nil, fmt.Errorf("sink type %q is not supported, publish through kafka_rest or rabbitmq_http", config.Type)
+		default:
+			return nil, fmt.Errorf("unknown sink type: %q", config.Type)
+		}
+		sinks = append(sinks, sink)
+	}
+	switch len(sinks) {
+	case 0:
+		return nil, nil
+	case 1:
+		return sinks[0], nil
+	}
+	return sinks, nil
+}
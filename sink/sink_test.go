@@ -0,0 +1,168 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTopic(t *testing.T) {
+	tests := []struct {
+		prefix string
+		game   string
+		want   string
+	}{
+		{"nexus", "Blum", "nexus.blum.task.result"},
+		{"", "Hamster Kombat", "hamster_kombat.task.result"},
+		{"nexus", "", "nexus.task.result"},
+		{"", "x.y/z-1", "x_y_z-1.task.result"},
+	}
+	for _, test := range tests {
+		if got := Topic(test.prefix, Event{Type: EventTaskResult, Game: test.game}); got != test.want {
+			t.Errorf("Topic(%q, %q) = %q, want %q", test.prefix, test.game, got, test.want)
+		}
+	}
+}
+
+func TestEventJSON(t *testing.T) {
+	event := Event{Type: EventTaskResult, Time: time.Date(2024, 11, 20, 10, 30, 0, 0, time.UTC), Game: "Blum",
+		Account: "987654321", Task: "Claim", Duration: 412 * time.Millisecond, Attempts: 1, Success: true}
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"type":"task.result","time":"2024-11-20T10:30:00Z","game":"Blum","account":"987654321","task":"Claim","attempts":1,"success":true,"duration_ms":412}`
+	if string(data) != want {
+		t.Errorf("event encodes as %s, want %s", data, want)
+	}
+}
+
+// recordingSink is a Sink recording its events, failing with err.
+type recordingSink struct {
+	mu      sync.Mutex
+	events  []Event
+	err     error
+	closed  bool
+	blocked chan struct{} // Publish waits for it to be closed, if set
+}
+
+func (sink *recordingSink) Publish(ctx context.Context, event Event) error {
+	if sink.blocked != nil {
+		<-sink.blocked
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	sink.events = append(sink.events, event)
+	return sink.err
+}
+
+func (sink *recordingSink) Close() error {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	sink.closed = true
+	return sink.err
+}
+
+func (sink *recordingSink) published() int {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	return len(sink.events)
+}
+
+func TestMulti(t *testing.T) {
+	failure := errors.New("broker down")
+	healthy, failing := &recordingSink{}, &recordingSink{err: failure}
+	multi := Multi{healthy, failing}
+	if err := multi.Publish(context.Background(), Event{Type: EventProxyDead}); !errors.Is(err, failure) {
+		t.Errorf("Publish() error = %v, want the error of the failing sink", err)
+	}
+	if healthy.published() != 1 || failing.published() != 1 {
+		t.Errorf("sinks received %d and %d events, want 1 each", healthy.published(), failing.published())
+	}
+	if err := multi.Close(); !errors.Is(err, failure) || !healthy.closed || !failing.closed {
+		t.Errorf("Close() error = %v, want every sink closed and the error of the failing one", err)
+	}
+}
+
+func TestAsync(t *testing.T) {
+	failure := errors.New("broker down")
+	target := &recordingSink{err: failure, blocked: make(chan struct{})}
+	var mu sync.Mutex
+	var failed []error
+	async := NewAsync(target, 2, func(event Event, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, err)
+	})
+	// The first event is taken by the publisher, blocked on the sink; two more fill the buffer.
+	if err := async.Publish(context.Background(), Event{Type: EventTaskResult}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	for len(async.events) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 2; i++ {
+		if err := async.Publish(context.Background(), Event{Type: EventTaskResult}); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+	if err := async.Publish(context.Background(), Event{Type: EventTaskResult}); !errors.Is(err, ErrBufferFull) {
+		t.Errorf("Publish() with a full buffer returned %v, want ErrBufferFull", err)
+	}
+	close(target.blocked)
+	if err := async.Close(); !errors.Is(err, failure) {
+		t.Errorf("Close() error = %v, want the error of the sink", err)
+	}
+	if target.published() != 3 || !target.closed {
+		t.Errorf("sink received %d events, closed %t, want the 3 buffered events and closed", target.published(), target.closed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(failed) != 4 || !errors.Is(failed[0], ErrBufferFull) || !errors.Is(failed[3], failure) {
+		t.Errorf("error handler got %v, want the dropped event then the 3 failed publications", failed)
+	}
+	if err := async.Publish(context.Background(), Event{}); err == nil {
+		t.Errorf("Publish() succeeded after Close")
+	}
+	if err := async.Close(); err != nil {
+		t.Errorf("second Close() returned %v", err)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []types.SinkConfig
+		want    string
+		wantErr bool
+	}{
+		{"none", nil, "<nil>", false},
+		{"nats", []types.SinkConfig{{Type: "NATS", Addr: "10.0.0.5:4222"}}, "*sink.NATS", false},
+		{"several", []types.SinkConfig{
+			{Type: "kafka_rest", URL: "http://10.0.0.5:8082", Topic: "events"},
+			{Type: "rabbitmq_http", URL: "http://10.0.0.5:15672", Exchange: "amq.topic"},
+		}, "sink.Multi", false},
+		{"nats without addr", []types.SinkConfig{{Type: "nats"}}, "", true},
+		{"kafka_rest without topic", []types.SinkConfig{{Type: "kafka_rest", URL: "http://10.0.0.5:8082"}}, "", true},
+		{"rabbitmq_http without exchange", []types.SinkConfig{{Type: "rabbitmq_http", URL: "http://10.0.0.5:15672"}}, "", true},
+		{"native kafka", []types.SinkConfig{{Type: "kafka", URL: "10.0.0.5:9092", Topic: "events"}}, "", true},
+		{"amqp", []types.SinkConfig{{Type: "amqp", URL: "amqp://10.0.0.5"}}, "", true},
+		{"unknown", []types.SinkConfig{{Type: "pulsar"}}, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sink, err := FromConfig(test.configs)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("FromConfig() error = %v, want error %t", err, test.wantErr)
+			}
+			if got := fmt.Sprintf("%T", sink); !test.wantErr && got != test.want {
+				t.Errorf("FromConfig() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
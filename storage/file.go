@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// File is a Storage keeping each bucket in a JSON file "<Dir>/<bucket>.json", rewritten
+// atomically after every change. It suits a single farm instance.
+//
+// # Example:
+//
+//	backend, err := storage.OpenFile("state")
+//	if err != nil {
+//		log.Fatalf("Failed to open storage: %v", err)
+//	}
+type File struct {
+	Dir     string
+	mu      sync.Mutex
+	buckets map[string]map[string]json.RawMessage
+}
+
+// OpenFile opens the storage in dir, creating the directory if needed.
+func OpenFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &File{Dir: dir, buckets: make(map[string]map[string]json.RawMessage)}, nil
+}
+
+// openFile is the Opener of the "file" backend.
+func openFile(config types.StorageConfig) (Storage, error) {
+	if config.Path == "" {
+		return nil, errors.New("storage backend 'file' requires a path")
+	}
+	return OpenFile(config.Path)
+}
+
+// bucket returns the documents of a bucket, loading its file on first access. It must be
+// called with mu held.
+func (file *File) bucket(name string) (map[string]json.RawMessage, error) {
+	if documents, ok := file.buckets[name]; ok {
+		return documents, nil
+	}
+	documents := make(map[string]json.RawMessage)
+	data, err := os.ReadFile(file.path(name))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &documents); err != nil {
+			return nil, fmt.Errorf("failed to parse storage bucket %s: %w", file.path(name), err)
+		}
+	}
+	file.buckets[name] = documents
+	return documents, nil
+}
+
+// path returns the file of a bucket.
+func (file *File) path(bucket string) string {
+	return filepath.Join(file.Dir, bucket+".json")
+}
+
+// save rewrites the file of a bucket. It must be called with mu held.
+func (file *File) save(name string) error {
+	data, err := json.MarshalIndent(file.buckets[name], "", "  ")
+	if err != nil {
+		return err
+	}
+	temp := file.path(name) + ".tmp"
+	if err := os.WriteFile(temp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(temp, file.path(name))
+}
+
+// Get returns the document stored under key.
+func (file *File) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+	documents, err := file.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := documents[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+// Put stores a document under key. The document must be valid JSON.
+func (file *File) Put(ctx context.Context, bucket, key string, value []byte) error {
+	if !json.Valid(value) {
+		return fmt.Errorf("storage: document %s/%s is not valid JSON", bucket, key)
+	}
+	file.mu.Lock()
+	defer file.mu.Unlock()
+	documents, err := file.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	documents[key] = append(json.RawMessage(nil), value...)
+	return file.save(bucket)
+}
+
+// Delete removes the document stored under key.
+func (file *File) Delete(ctx context.Context, bucket, key string) error {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+	documents, err := file.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	if _, ok := documents[key]; !ok {
+		return nil
+	}
+	delete(documents, key)
+	return file.save(bucket)
+}
+
+// List returns the documents of bucket whose key starts with prefix.
+func (file *File) List(ctx context.Context, bucket, prefix string) (map[string][]byte, error) {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+	documents, err := file.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[string][]byte)
+	for key, value := range documents {
+		if strings.HasPrefix(key, prefix) {
+			listed[key] = append([]byte(nil), value...)
+		}
+	}
+	return listed, nil
+}
+
+// Close does nothing; every change is already written.
+func (file *File) Close() error {
+	return nil
+}
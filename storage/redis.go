@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/internal/resp"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"strings"
+)
+
+// Redis is a Storage keeping each bucket in a Redis hash "<Prefix>:<bucket>", so that farm
+// instances on different hosts share their state.
+//
+// # Fields:
+//   - Addr: The Redis address (e.g., "10.0.0.5:6379").
+//   - Password: The optional Redis password.
+//   - Prefix: The prefix of the hash keys.
+type Redis struct {
+	Addr     string
+	Password string
+	Prefix   string
+}
+
+// NewRedis creates a Redis storage.
+func NewRedis(addr, password, prefix string) *Redis {
+	return &Redis{Addr: addr, Password: password, Prefix: prefix}
+}
+
+// openRedis is the Opener of the "redis" backend.
+func openRedis(config types.StorageConfig) (Storage, error) {
+	if config.Addr == "" {
+		return nil, errors.New("storage backend 'redis' requires an address")
+	}
+	prefix := config.Prefix
+	if prefix == "" {
+		prefix = "nexus"
+	}
+	return NewRedis(config.Addr, config.Password, prefix), nil
+}
+
+func (store *Redis) client() resp.Client {
+	return resp.Client{Addr: store.Addr, Password: store.Password}
+}
+
+// hash returns the key of the hash of a bucket.
+func (store *Redis) hash(bucket string) string {
+	return store.Prefix + ":" + bucket
+}
+
+// Get returns the document stored under key.
+func (store *Redis) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	reply, err := store.client().Do(ctx, "HGET", store.hash(bucket), key)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return []byte(value), nil
+}
+
+// Put stores a document under key.
+func (store *Redis) Put(ctx context.Context, bucket, key string, value []byte) error {
+	_, err := store.client().Do(ctx, "HSET", store.hash(bucket), key, string(value))
+	return err
+}
+
+// Delete removes the document stored under key.
+func (store *Redis) Delete(ctx context.Context, bucket, key string) error {
+	_, err := store.client().Do(ctx, "HDEL", store.hash(bucket), key)
+	return err
+}
+
+// List returns the documents of bucket whose key starts with prefix.
+func (store *Redis) List(ctx context.Context, bucket, prefix string) (map[string][]byte, error) {
+	fields, err := store.client().Strings(ctx, "HGETALL", store.hash(bucket))
+	if err != nil {
+		return nil, err
+	}
+	documents := make(map[string][]byte)
+	for i := 0; i+1 < len(fields); i += 2 {
+		if strings.HasPrefix(fields[i], prefix) {
+			documents[fields[i]] = []byte(fields[i+1])
+		}
+	}
+	return documents, nil
+}
+
+// Close does nothing; the storage opens one connection per command.
+func (store *Redis) Close() error {
+	return nil
+}
@@ -0,0 +1,114 @@
+// Package sqlite registers the "sqlite" storage backend, keeping every bucket in one table of
+// a SQLite database file. Import it for its side effect:
+//
+//	import _ "github.com/nexus-telegram/NexusSDK/storage/sqlite"
+//
+// The driver requires cgo.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nexus-telegram/NexusSDK/storage"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+func init() {
+	storage.Register("sqlite", func(config types.StorageConfig) (storage.Storage, error) {
+		if config.Path == "" {
+			return nil, errors.New("storage backend 'sqlite' requires a path")
+		}
+		return Open(config.Path)
+	})
+}
+
+// Storage is a storage.Storage keeping its documents in the table "entries" of a SQLite
+// database.
+type Storage struct {
+	db *sql.DB
+}
+
+// Open opens (or creates) the database at path.
+//
+// # Parameters:
+//   - path: The path of the database file. Parent directories are created if needed.
+//
+// # Returns:
+//   - *Storage: The storage.
+//   - error: An error if the database cannot be opened or initialized.
+func Open(path string) (*Storage, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS entries (
+		bucket TEXT NOT NULL,
+		key    TEXT NOT NULL,
+		value  BLOB NOT NULL,
+		PRIMARY KEY (bucket, key)
+	)`)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &Storage{db: db}, nil
+}
+
+// Get returns the document stored under key.
+func (store *Storage) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	var value []byte
+	err := store.db.QueryRowContext(ctx, `SELECT value FROM entries WHERE bucket = ? AND key = ?`, bucket, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	return value, err
+}
+
+// Put stores a document under key.
+func (store *Storage) Put(ctx context.Context, bucket, key string, value []byte) error {
+	_, err := store.db.ExecContext(ctx, `INSERT INTO entries (bucket, key, value) VALUES (?, ?, ?)
+		ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value`, bucket, key, value)
+	return err
+}
+
+// Delete removes the document stored under key.
+func (store *Storage) Delete(ctx context.Context, bucket, key string) error {
+	_, err := store.db.ExecContext(ctx, `DELETE FROM entries WHERE bucket = ? AND key = ?`, bucket, key)
+	return err
+}
+
+// List returns the documents of bucket whose key starts with prefix.
+func (store *Storage) List(ctx context.Context, bucket, prefix string) (map[string][]byte, error) {
+	rows, err := store.db.QueryContext(ctx, `SELECT key, value FROM entries WHERE bucket = ? AND substr(key, 1, ?) = ?`,
+		bucket, utf8.RuneCountInString(prefix), prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	documents := make(map[string][]byte)
+	for rows.Next() {
+		var key string
+		var value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(key, prefix) {
+			documents[key] = value
+		}
+	}
+	return documents, rows.Err()
+}
+
+// Close closes the database.
+func (store *Storage) Close() error {
+	return store.db.Close()
+}
@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"github.com/nexus-telegram/NexusSDK/storage"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"path/filepath"
+	"testing"
+)
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "farm", "state.db")
+	backend, err := storage.FromConfig(types.StorageConfig{Backend: "sqlite", Path: path})
+	if err != nil {
+		t.Fatalf("FromConfig failed: %v", err)
+	}
+	documents := map[string]string{
+		"blum/1/claim":    `{"ok":true}`,
+		"blum/1/farm":     `{"ok":false}`,
+		"blum/12/claim":   `1`,
+		"blum_1/claim":    `2`,
+		"blum%1/claim":    `3`,
+		"hamster/ü/claim": `4`,
+	}
+	for key, value := range documents {
+		if err := backend.Put(ctx, storage.BucketHistory, key, []byte(value)); err != nil {
+			t.Fatalf("Put(%s) failed: %v", key, err)
+		}
+	}
+	if err := backend.Put(ctx, storage.BucketHistory, "blum/1/farm", []byte(`{"ok":true}`)); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	documents["blum/1/farm"] = `{"ok":true}`
+	if value, err := backend.Get(ctx, storage.BucketHistory, "blum/1/farm"); err != nil || string(value) != `{"ok":true}` {
+		t.Errorf("Get() = %s, %v, want the replaced document", value, err)
+	}
+	if _, err := backend.Get(ctx, storage.BucketState, "blum/1/farm"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Get() from another bucket returned %v, want storage.ErrNotFound", err)
+	}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"blum/1/", []string{"blum/1/claim", "blum/1/farm"}},
+		{"blum_", []string{"blum_1/claim"}},
+		{"blum%", []string{"blum%1/claim"}},
+		{"hamster/ü/", []string{"hamster/ü/claim"}},
+		{"notcoin/", nil},
+	}
+	for _, test := range tests {
+		listed, err := backend.List(ctx, storage.BucketHistory, test.prefix)
+		if err != nil {
+			t.Fatalf("List(%q) failed: %v", test.prefix, err)
+		}
+		if len(listed) != len(test.want) {
+			t.Errorf("List(%q) returned %d documents, want %v", test.prefix, len(listed), test.want)
+		}
+		for _, key := range test.want {
+			if string(listed[key]) != documents[key] {
+				t.Errorf("List(%q)[%s] = %s, want %s", test.prefix, key, listed[key], documents[key])
+			}
+		}
+	}
+
+	if err := backend.Delete(ctx, storage.BucketHistory, "blum/1/claim"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := backend.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer reopened.Close()
+	if _, err := reopened.Get(ctx, storage.BucketHistory, "blum/1/claim"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Get() of a deleted key returned %v, want storage.ErrNotFound", err)
+	}
+	if value, err := reopened.Get(ctx, storage.BucketHistory, "blum/1/farm"); err != nil || string(value) != `{"ok":true}` {
+		t.Errorf("Get() after reopening = %s, %v, want the stored document", value, err)
+	}
+}
+
+func TestRequiresPath(t *testing.T) {
+	if _, err := storage.FromConfig(types.StorageConfig{Backend: "sqlite"}); err == nil {
+		t.Errorf("FromConfig() opened a sqlite storage without path")
+	}
+}
@@ -0,0 +1,178 @@
+// Package storage is the persistence backend shared by the features of a farm that keep state
+// across restarts: handler state and session tokens, scheduled job checkpoints, idempotency
+// records, and task history.
+//
+// A Storage is a set of buckets of JSON documents. The file and Redis backends are built in;
+// the SQLite backend is registered by importing the storage/sqlite package:
+//
+//	import _ "github.com/nexus-telegram/NexusSDK/storage/sqlite"
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The buckets used by the SDK.
+const (
+	BucketState       = "state"       // Handler states, keyed by game (see handler.GameHandler.SaveState)
+	BucketJobs        = "jobs"        // Scheduled jobs, keyed by job ID
+	BucketIdempotency = "idempotency" // Successful non-idempotent executions, keyed by idempotency key
+	BucketHistory     = "history"     // Recent executions, keyed by game, account, and task
+)
+
+// ErrNotFound is returned by Get when a bucket has no document under a key.
+var ErrNotFound = errors.New("storage: not found")
+
+// Storage stores JSON documents in buckets.
+//
+// Implementations are safe for concurrent use. Operations on different keys are independent;
+// read-modify-write sequences are not atomic across processes.
+//
+// # Methods:
+//   - Get(ctx context.Context, bucket, key string) ([]byte, error): Returns the document
+//     stored under key, or ErrNotFound.
+//   - Put(ctx context.Context, bucket, key string, value []byte) error: Stores a document
+//     under key, replacing any previous one.
+//   - Delete(ctx context.Context, bucket, key string) error: Removes the document stored under
+//     key. Deleting a missing key is not an error.
+//   - List(ctx context.Context, bucket, prefix string) (map[string][]byte, error): Returns the
+//     documents of bucket whose key starts with prefix, keyed by key.
+//   - Close() error: Releases the resources of the storage.
+type Storage interface {
+	Get(ctx context.Context, bucket, key string) ([]byte, error)
+	Put(ctx context.Context, bucket, key string, value []byte) error
+	Delete(ctx context.Context, bucket, key string) error
+	List(ctx context.Context, bucket, prefix string) (map[string][]byte, error)
+	Close() error
+}
+
+// Opener opens a storage backend from the storage section of the configuration file.
+type Opener func(config types.StorageConfig) (Storage, error)
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]Opener{
+		"memory": func(types.StorageConfig) (Storage, error) { return NewMemory(), nil },
+		"file":   openFile,
+		"redis":  openRedis,
+	}
+	opened = make(map[types.StorageConfig]Storage)
+)
+
+// Register makes a storage backend available under a name, for selecting it from
+// configuration. The storage/sqlite package registers "sqlite".
+func Register(name string, opener Opener) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(name)] = opener
+}
+
+// FromConfig opens the storage configured in the storage section of the configuration file,
+// or returns nil when no backend is configured.
+//
+// Handlers configured with the same section share one storage, so that several games of a
+// farm can use the same file or database.
+//
+// # Parameters:
+//   - config: The storage section of the configuration file.
+//
+// # Returns:
+//   - Storage: The storage, nil if no backend is configured.
+//   - error: An error if the backend is unknown or cannot be opened.
+//
+// # Example:
+//
+//	backend, err := storage.FromConfig(config.Storage)
+//	if err != nil {
+//		log.Fatalf("Failed to open storage: %v", err)
+//	}
+func FromConfig(config types.StorageConfig) (Storage, error) {
+	if config.Backend == "" {
+		return nil, nil
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if backend, ok := opened[config]; ok {
+		return backend, nil
+	}
+	opener, ok := registry[strings.ToLower(config.Backend)]
+	if !ok {
+		names := make([]string, 0, len(registry))
+		for name := range registry {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown storage backend %q (registered: %s)", config.Backend, strings.Join(names, ", "))
+	}
+	backend, err := opener(config)
+	if err != nil {
+		return nil, err
+	}
+	opened[config] = backend
+	return backend, nil
+}
+
+// Memory is a Storage kept in memory, for tests and farms that do not need to survive restarts.
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]map[string][]byte
+}
+
+// NewMemory returns an empty in-memory storage.
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]map[string][]byte)}
+}
+
+// Get returns the document stored under key.
+func (memory *Memory) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+	value, ok := memory.buckets[bucket][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+// Put stores a document under key.
+func (memory *Memory) Put(ctx context.Context, bucket, key string, value []byte) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+	if memory.buckets[bucket] == nil {
+		memory.buckets[bucket] = make(map[string][]byte)
+	}
+	memory.buckets[bucket][key] = append([]byte(nil), value...)
+	return nil
+}
+
+// Delete removes the document stored under key.
+func (memory *Memory) Delete(ctx context.Context, bucket, key string) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+	delete(memory.buckets[bucket], key)
+	return nil
+}
+
+// List returns the documents of bucket whose key starts with prefix.
+func (memory *Memory) List(ctx context.Context, bucket, prefix string) (map[string][]byte, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+	documents := make(map[string][]byte)
+	for key, value := range memory.buckets[bucket] {
+		if strings.HasPrefix(key, prefix) {
+			documents[key] = append([]byte(nil), value...)
+		}
+	}
+	return documents, nil
+}
+
+// Close does nothing.
+func (memory *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// backends returns the storages under test, each opened empty. The Redis storage is tested
+// when NEXUS_TEST_REDIS_ADDR names a server that may be written to.
+func backends(t *testing.T) map[string]func(t *testing.T) Storage {
+	storages := map[string]func(t *testing.T) Storage{
+		"memory": func(t *testing.T) Storage { return NewMemory() },
+		"file": func(t *testing.T) Storage {
+			file, err := OpenFile(filepath.Join(t.TempDir(), "state"))
+			if err != nil {
+				t.Fatalf("OpenFile failed: %v", err)
+			}
+			return file
+		},
+	}
+	if addr := os.Getenv("NEXUS_TEST_REDIS_ADDR"); addr != "" {
+		storages["redis"] = func(t *testing.T) Storage {
+			return NewRedis(addr, os.Getenv("NEXUS_TEST_REDIS_PASSWORD"), "nexus:test:"+t.Name())
+		}
+	}
+	return storages
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			backend := open(t)
+			defer backend.Close()
+			if _, err := backend.Get(ctx, BucketState, "blum"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of a missing key returned %v, want ErrNotFound", err)
+			}
+			documents := map[string]string{
+				"blum/1/claim":  `{"last":"2024-01-01T12:00:00Z"}`,
+				"blum/1/farm":   `{"last":null}`,
+				"blum/12/claim": `[1,2,3]`,
+				"notcoin/1/tap": `"done"`,
+			}
+			for key, value := range documents {
+				if err := backend.Put(ctx, BucketHistory, key, []byte(value)); err != nil {
+					t.Fatalf("Put(%s) failed: %v", key, err)
+				}
+			}
+			if err := backend.Put(ctx, BucketHistory, "blum/1/farm", []byte(`{"last":"2024-01-02T12:00:00Z"}`)); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+			documents["blum/1/farm"] = `{"last":"2024-01-02T12:00:00Z"}`
+			if value, err := backend.Get(ctx, BucketHistory, "blum/1/farm"); err != nil || string(value) != documents["blum/1/farm"] {
+				t.Errorf("Get() = %s, %v, want the replaced document", value, err)
+			}
+			if _, err := backend.Get(ctx, BucketState, "blum/1/farm"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() from another bucket returned %v, want ErrNotFound", err)
+			}
+
+			tests := []struct {
+				prefix string
+				want   []string
+			}{
+				{"", []string{"blum/1/claim", "blum/1/farm", "blum/12/claim", "notcoin/1/tap"}},
+				{"blum/1/", []string{"blum/1/claim", "blum/1/farm"}},
+				{"blum/1", []string{"blum/1/claim", "blum/1/farm", "blum/12/claim"}},
+				{"hamster/", nil},
+			}
+			for _, test := range tests {
+				listed, err := backend.List(ctx, BucketHistory, test.prefix)
+				if err != nil {
+					t.Fatalf("List(%q) failed: %v", test.prefix, err)
+				}
+				if len(listed) != len(test.want) {
+					t.Errorf("List(%q) returned %d documents, want %v", test.prefix, len(listed), test.want)
+				}
+				for _, key := range test.want {
+					if string(listed[key]) != documents[key] {
+						t.Errorf("List(%q)[%s] = %s, want %s", test.prefix, key, listed[key], documents[key])
+					}
+				}
+			}
+
+			if err := backend.Delete(ctx, BucketHistory, "blum/1/claim"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if err := backend.Delete(ctx, BucketHistory, "blum/1/claim"); err != nil {
+				t.Errorf("Delete() of a missing key returned %v", err)
+			}
+			if _, err := backend.Get(ctx, BucketHistory, "blum/1/claim"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of a deleted key returned %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStorageCopiesDocuments(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			backend := open(t)
+			defer backend.Close()
+			value := []byte(`{"a":1}`)
+			if err := backend.Put(ctx, BucketState, "key", value); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+			value[2] = 'b'
+			got, err := backend.Get(ctx, BucketState, "key")
+			if err != nil || string(got) != `{"a":1}` {
+				t.Fatalf("Get() = %s, %v, want the document as stored", got, err)
+			}
+			got[2] = 'c'
+			if again, _ := backend.Get(ctx, BucketState, "key"); string(again) != `{"a":1}` {
+				t.Errorf("changing a returned document changed the stored one: %s", again)
+			}
+		})
+	}
+}
+
+func TestFilePersists(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "state")
+	file, err := OpenFile(dir)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	if err := file.Put(ctx, BucketJobs, "blum/1/farm", []byte(`"2024-01-01T12:00:00Z"`)); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := file.Put(ctx, BucketJobs, "blum/1/claim", []byte(`{invalid`)); err == nil {
+		t.Errorf("Put() accepted an invalid document")
+	}
+	reopened, err := OpenFile(dir)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	if value, err := reopened.Get(ctx, BucketJobs, "blum/1/farm"); err != nil || string(value) != `"2024-01-01T12:00:00Z"` {
+		t.Errorf("Get() after reopening = %s, %v, want the stored document", value, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "jobs.json.tmp")); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "state.json"), []byte("{"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := reopened.Get(ctx, BucketState, "blum"); err == nil || !strings.Contains(err.Error(), "state.json") {
+		t.Errorf("Get() from a corrupt bucket returned %v, want an error naming its file", err)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		config  types.StorageConfig
+		want    string
+		wantErr bool
+	}{
+		{"none", types.StorageConfig{}, "<nil>", false},
+		{"memory", types.StorageConfig{Backend: "Memory"}, "*storage.Memory", false},
+		{"file", types.StorageConfig{Backend: "file", Path: dir}, "*storage.File", false},
+		{"file without path", types.StorageConfig{Backend: "file"}, "", true},
+		{"redis", types.StorageConfig{Backend: "redis", Addr: "10.0.0.5:6379"}, "*storage.Redis", false},
+		{"redis without address", types.StorageConfig{Backend: "redis"}, "", true},
+		{"unknown", types.StorageConfig{Backend: "etcd"}, "", true},
+	}
+	for _, test := range tests {
+		backend, err := FromConfig(test.config)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: FromConfig() error = %v, want error %t", test.name, err, test.wantErr)
+			continue
+		}
+		if got := fmt.Sprintf("%T", backend); !test.wantErr && got != test.want {
+			t.Errorf("%s: FromConfig() = %s, want %s", test.name, got, test.want)
+		}
+	}
+	first, _ := FromConfig(types.StorageConfig{Backend: "file", Path: dir})
+	second, _ := FromConfig(types.StorageConfig{Backend: "file", Path: dir})
+	if first != second {
+		t.Errorf("FromConfig() opened the same storage twice")
+	}
+	if redis, _ := FromConfig(types.StorageConfig{Backend: "redis", Addr: "10.0.0.5:6379"}); redis.(*Redis).Prefix != "nexus" {
+		t.Errorf("Redis storage prefix = %q, want nexus", redis.(*Redis).Prefix)
+	}
+}
@@ -0,0 +1,151 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/handler"
+	"github.com/nexus-telegram/NexusSDK/notify"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils"
+	"github.com/nexus-telegram/NexusSDK/utils/clock"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Job sends the report of the previous day every day at Hour.
+//
+// # Fields:
+//   - Handlers: Returns the game handlers of the farm, e.g. manager.Handlers.
+//   - Notifier: The destination of the reports.
+//   - Format: The format of the reports, "markdown" or "html". Defaults to "markdown".
+//   - Hour: The hour of the day (0-23) at which the report is sent.
+//   - Location: The time zone of the report days. Defaults to time.Local.
+//   - Clock: The clock of the job. Defaults to clock.Real.
+//
+// # Example:
+//
+//	job := &summary.Job{
+//		Handlers: manager.Handlers,
+//		Notifier: notify.NewTelegram("123456:ABC-DEF", "-1001234567890"),
+//		Hour:     8,
+//	}
+//	go job.Run(ctx)
+type Job struct {
+	Handlers func() []*handler.GameHandler
+	Notifier notify.Notifier
+	Format   string
+	Hour     int
+	Location *time.Location
+	Clock    clock.Clock
+}
+
+// NewJob creates the daily report job of the farm run by manager from the daily_report section
+// of the configuration file, or returns nil when the report is disabled.
+//
+// The report is sent by the bot configured in the section, or by the bot of the Telegram log
+// sink when the section has none.
+//
+// # Parameters:
+//   - manager: The manager of the game handlers of the farm.
+//   - config: The configuration of the farm.
+//
+// # Returns:
+//   - *Job: The job, nil if the report is disabled.
+//   - error: An error if the hour, time zone, or format is invalid, or if no bot is configured.
+//
+// # Example:
+//
+//	job, err := summary.NewJob(manager, config)
+//	if err != nil {
+//		log.Fatalf("Invalid daily report: %v", err)
+//	}
+//	if job != nil {
+//		go job.Run(ctx)
+//	}
+func NewJob(manager *handler.Manager, config types.Config) (*Job, error) {
+	section := config.DailyReport
+	if !section.Enabled {
+		return nil, nil
+	}
+	if section.Hour < 0 || section.Hour > 23 {
+		return nil, fmt.Errorf("invalid daily report hour: %d", section.Hour)
+	}
+	if _, err := (&Report{}).Render(section.Format); err != nil {
+		return nil, err
+	}
+	location := time.Local
+	if section.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(section.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid daily report time zone: %w", err)
+		}
+	}
+	botToken, chatID := section.BotToken, section.ChatID
+	if botToken == "" {
+		botToken, chatID = config.Log.Telegram.BotToken, config.Log.Telegram.ChatID
+	}
+	if botToken == "" || chatID == "" {
+		return nil, errors.New("daily report requires a Telegram bot token and chat ID")
+	}
+	return &Job{
+		Handlers: manager.Handlers,
+		Notifier: notify.NewTelegram(botToken, chatID),
+		Format:   section.Format,
+		Hour:     section.Hour,
+		Location: location,
+	}, nil
+}
+
+// Run sends the report of the previous day every day at Hour until ctx is cancelled. Failures
+// to build or deliver a report are logged and do not stop the job.
+func (job *Job) Run(ctx context.Context) {
+	jobClock := job.clock()
+	for {
+		now := jobClock.Now().In(job.location())
+		next := time.Date(now.Year(), now.Month(), now.Day(), job.Hour, 0, 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		timer := jobClock.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+		today := time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, next.Location())
+		if err := job.Send(ctx, today.AddDate(0, 0, -1), today); err != nil {
+			utils.ModuleLogger("summary").Error("Failed to send the daily report", zap.Error(err))
+		}
+	}
+}
+
+// Send builds the report of the period between from and to and delivers it to Notifier.
+func (job *Job) Send(ctx context.Context, from, to time.Time) error {
+	report, err := Build(job.Handlers(), from, to)
+	if err != nil {
+		return err
+	}
+	message, err := report.Render(job.Format)
+	if err != nil {
+		return err
+	}
+	return job.Notifier.Notify(ctx, message)
+}
+
+// location returns the time zone of the job, defaulting to time.Local.
+func (job *Job) location() *time.Location {
+	if job.Location == nil {
+		return time.Local
+	}
+	return job.Location
+}
+
+// clock returns the clock of the job, defaulting to clock.Real.
+func (job *Job) clock() clock.Clock {
+	if job.Clock == nil {
+		return clock.Real
+	}
+	return job.Clock
+}
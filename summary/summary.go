@@ -0,0 +1,316 @@
+// Package summary aggregates the task results of a farm into a daily report (claims, failures,
+// balance changes, and dead proxies) and delivers it over a notifier.
+package summary
+
+import (
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/handler"
+	"github.com/nexus-telegram/NexusSDK/progress"
+	"github.com/nexus-telegram/NexusSDK/proxypool"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxListed is the number of errors and balance drops listed per game.
+const maxListed = 5
+
+// Report is the summary of the task executions of a farm over a period, usually one day.
+//
+// # Fields:
+//   - From: The start of the period.
+//   - To: The end of the period, excluded.
+//   - Games: The summary of every game, in the order of the handlers.
+//   - DeadProxies: The proxies marked as dead in the proxy pools of the handlers, as
+//     "ip:port:username".
+type Report struct {
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	Games       []GameSummary `json:"games"`
+	DeadProxies []string      `json:"dead_proxies"`
+}
+
+// GameSummary is the summary of the task executions of one game in a Report.
+//
+// # Fields:
+//   - Game: The name of the game.
+//   - Claims: The number of successful task executions.
+//   - Failures: The number of task executions that failed on every attempt.
+//   - Accounts: The number of accounts with at least one execution.
+//   - Tasks: The claims and failures of every task, sorted by name.
+//   - Errors: The most frequent error messages, most frequent first.
+//   - BalanceDelta: The sum of the balance changes of the accounts.
+//   - Balances: The balance change of every account with a recorded balance
+//     (see handler.GameHandler.RecordBalance).
+type GameSummary struct {
+	Game         string                  `json:"game"`
+	Claims       int                     `json:"claims"`
+	Failures     int                     `json:"failures"`
+	Accounts     int                     `json:"accounts"`
+	Tasks        []TaskSummary           `json:"tasks"`
+	Errors       []ErrorCount            `json:"errors"`
+	BalanceDelta float64                 `json:"balance_delta"`
+	Balances     []handler.BalanceChange `json:"balances"`
+}
+
+// TaskSummary is the number of claims and failures of one task in a GameSummary.
+type TaskSummary struct {
+	Task     string `json:"task"`
+	Claims   int    `json:"claims"`
+	Failures int    `json:"failures"`
+}
+
+// ErrorCount is the number of failed executions with the same error message in a GameSummary.
+type ErrorCount struct {
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+// Build aggregates the task history and balances of handlers between from and to.
+//
+// The executions are read from the history store of every handler (see
+// handler.GameHandler.SetHistoryStore), so the store must keep at least a day of executions
+// for the report to be complete.
+//
+// # Parameters:
+//   - handlers: The game handlers of the farm.
+//   - from: The start of the period.
+//   - to: The end of the period, excluded.
+//
+// # Returns:
+//   - *Report: The report of the period.
+//   - error: An error if the history of a handler cannot be read.
+//
+// # Example:
+//
+//	today := time.Now().Truncate(24 * time.Hour)
+//	report, err := summary.Build(manager.Handlers(), today.AddDate(0, 0, -1), today)
+//	if err != nil {
+//		log.Fatalf("Failed to build the daily report: %v", err)
+//	}
+//	fmt.Println(report.Markdown())
+func Build(handlers []*handler.GameHandler, from, to time.Time) (*Report, error) {
+	report := &Report{From: from, To: to}
+	dead := make(map[string]bool)
+	for _, gameHandler := range handlers {
+		game, err := summarize(gameHandler, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize %s: %w", gameHandler.GameName, err)
+		}
+		report.Games = append(report.Games, game)
+		if gameHandler.ProxyPool != nil {
+			for _, proxy := range gameHandler.ProxyPool.Dead() {
+				dead[proxypool.Key(proxy)] = true
+			}
+		}
+	}
+	for key := range dead {
+		report.DeadProxies = append(report.DeadProxies, key)
+	}
+	sort.Strings(report.DeadProxies)
+	return report, nil
+}
+
+// summarize returns the summary of the executions of gameHandler between from and to.
+func summarize(gameHandler *handler.GameHandler, from, to time.Time) (GameSummary, error) {
+	game := GameSummary{Game: gameHandler.GameName}
+	executions, err := gameHandler.GetTaskHistory("", "", 0)
+	if err != nil {
+		return game, err
+	}
+	tasks := make(map[string]*TaskSummary)
+	accounts := make(map[string]bool)
+	errors := make(map[string]int)
+	for _, execution := range executions {
+		if execution.Started.Before(from) || !execution.Started.Before(to) {
+			continue
+		}
+		task, ok := tasks[execution.Task]
+		if !ok {
+			task = &TaskSummary{Task: execution.Task}
+			tasks[execution.Task] = task
+		}
+		accounts[execution.Account] = true
+		if execution.Success {
+			task.Claims++
+			game.Claims++
+		} else {
+			task.Failures++
+			game.Failures++
+			errors[execution.Error]++
+		}
+	}
+	game.Accounts = len(accounts)
+	for _, task := range tasks {
+		game.Tasks = append(game.Tasks, *task)
+	}
+	sort.Slice(game.Tasks, func(i, j int) bool { return game.Tasks[i].Task < game.Tasks[j].Task })
+	for message, count := range errors {
+		game.Errors = append(game.Errors, ErrorCount{Message: message, Count: count})
+	}
+	sort.Slice(game.Errors, func(i, j int) bool {
+		if game.Errors[i].Count != game.Errors[j].Count {
+			return game.Errors[i].Count > game.Errors[j].Count
+		}
+		return game.Errors[i].Message < game.Errors[j].Message
+	})
+	if len(game.Errors) > maxListed {
+		game.Errors = game.Errors[:maxListed]
+	}
+	game.Balances = gameHandler.BalanceChanges(from, to)
+	for _, change := range game.Balances {
+		game.BalanceDelta += change.Delta
+	}
+	return game, nil
+}
+
+// drops returns the largest balance decreases of game, largest first.
+func (game *GameSummary) drops() []handler.BalanceChange {
+	var drops []handler.BalanceChange
+	for _, change := range game.Balances {
+		if change.Delta < 0 {
+			drops = append(drops, change)
+		}
+	}
+	sort.Slice(drops, func(i, j int) bool { return drops[i].Delta < drops[j].Delta })
+	if len(drops) > maxListed {
+		drops = drops[:maxListed]
+	}
+	return drops
+}
+
+// failureRate returns the percentage of failed executions of game.
+func (game *GameSummary) failureRate() float64 {
+	if game.Claims+game.Failures == 0 {
+		return 0
+	}
+	return 100 * float64(game.Failures) / float64(game.Claims+game.Failures)
+}
+
+// title returns the title of the report.
+func (report *Report) title() string {
+	if report.To.Sub(report.From) == 24*time.Hour {
+		return "Daily report " + report.From.Format("2006-01-02")
+	}
+	return fmt.Sprintf("Report %s - %s", report.From.Format("2006-01-02 15:04"), report.To.Format("2006-01-02 15:04"))
+}
+
+// Render returns the report in format, "markdown" (the default when empty) or "html".
+func (report *Report) Render(format string) (string, error) {
+	switch format {
+	case "", "markdown":
+		return report.Markdown(), nil
+	case "html":
+		return report.HTML(), nil
+	default:
+		return "", fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+// Markdown renders the report as Markdown.
+func (report *Report) Markdown() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# %s\n", report.title())
+	for i := range report.Games {
+		game := &report.Games[i]
+		fmt.Fprintf(&builder, "\n## %s\n\n", game.Game)
+		fmt.Fprintf(&builder, "- Claims: %s\n", progress.Count(game.Claims))
+		fmt.Fprintf(&builder, "- Failures: %s (%.1f%%)\n", progress.Count(game.Failures), game.failureRate())
+		fmt.Fprintf(&builder, "- Active accounts: %s\n", progress.Count(game.Accounts))
+		if len(game.Balances) > 0 {
+			fmt.Fprintf(&builder, "- Balance: %s over %s accounts\n", signed(game.BalanceDelta), progress.Count(len(game.Balances)))
+		}
+		if len(game.Tasks) > 0 {
+			builder.WriteString("\n| Task | Claims | Failures |\n|---|---:|---:|\n")
+			for _, task := range game.Tasks {
+				fmt.Fprintf(&builder, "| %s | %s | %s |\n", task.Task, progress.Count(task.Claims), progress.Count(task.Failures))
+			}
+		}
+		if len(game.Errors) > 0 {
+			builder.WriteString("\nTop errors:\n")
+			for _, errorCount := range game.Errors {
+				fmt.Fprintf(&builder, "- %s x %s\n", progress.Count(errorCount.Count), oneLine(errorCount.Message))
+			}
+		}
+		if drops := game.drops(); len(drops) > 0 {
+			builder.WriteString("\nLargest balance drops:\n")
+			for _, change := range drops {
+				fmt.Fprintf(&builder, "- %s: %s\n", change.Account, signed(change.Delta))
+			}
+		}
+	}
+	if len(report.DeadProxies) > 0 {
+		fmt.Fprintf(&builder, "\n## Dead proxies (%d)\n\n", len(report.DeadProxies))
+		for _, proxy := range report.DeadProxies {
+			fmt.Fprintf(&builder, "- %s\n", proxy)
+		}
+	}
+	return builder.String()
+}
+
+// HTML renders the report as an HTML fragment.
+func (report *Report) HTML() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "<h1>%s</h1>\n", html.EscapeString(report.title()))
+	for i := range report.Games {
+		game := &report.Games[i]
+		fmt.Fprintf(&builder, "<h2>%s</h2>\n<ul>\n", html.EscapeString(game.Game))
+		fmt.Fprintf(&builder, "<li>Claims: %s</li>\n", progress.Count(game.Claims))
+		fmt.Fprintf(&builder, "<li>Failures: %s (%.1f%%)</li>\n", progress.Count(game.Failures), game.failureRate())
+		fmt.Fprintf(&builder, "<li>Active accounts: %s</li>\n", progress.Count(game.Accounts))
+		if len(game.Balances) > 0 {
+			fmt.Fprintf(&builder, "<li>Balance: %s over %s accounts</li>\n", signed(game.BalanceDelta), progress.Count(len(game.Balances)))
+		}
+		builder.WriteString("</ul>\n")
+		if len(game.Tasks) > 0 {
+			builder.WriteString("<table>\n<tr><th>Task</th><th>Claims</th><th>Failures</th></tr>\n")
+			for _, task := range game.Tasks {
+				fmt.Fprintf(&builder, "<tr><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+					html.EscapeString(task.Task), progress.Count(task.Claims), progress.Count(task.Failures))
+			}
+			builder.WriteString("</table>\n")
+		}
+		if len(game.Errors) > 0 {
+			builder.WriteString("<p>Top errors:</p>\n<ul>\n")
+			for _, errorCount := range game.Errors {
+				fmt.Fprintf(&builder, "<li>%s x %s</li>\n", progress.Count(errorCount.Count), html.EscapeString(oneLine(errorCount.Message)))
+			}
+			builder.WriteString("</ul>\n")
+		}
+		if drops := game.drops(); len(drops) > 0 {
+			builder.WriteString("<p>Largest balance drops:</p>\n<ul>\n")
+			for _, change := range drops {
+				fmt.Fprintf(&builder, "<li>%s: %s</li>\n", html.EscapeString(change.Account), signed(change.Delta))
+			}
+			builder.WriteString("</ul>\n")
+		}
+	}
+	if len(report.DeadProxies) > 0 {
+		fmt.Fprintf(&builder, "<h2>Dead proxies (%d)</h2>\n<ul>\n", len(report.DeadProxies))
+		for _, proxy := range report.DeadProxies {
+			fmt.Fprintf(&builder, "<li>%s</li>\n", html.EscapeString(proxy))
+		}
+		builder.WriteString("</ul>\n")
+	}
+	return builder.String()
+}
+
+// signed formats a balance change with its sign (e.g., "+1250.5").
+func signed(delta float64) string {
+	formatted := strconv.FormatFloat(delta, 'f', -1, 64)
+	if delta > 0 {
+		return "+" + formatted
+	}
+	return formatted
+}
+
+// oneLine returns message on a single line, shortened to 200 characters.
+func oneLine(message string) string {
+	message = strings.Join(strings.Fields(message), " ")
+	if len(message) > 200 {
+		message = message[:197] + "..."
+	}
+	return message
+}
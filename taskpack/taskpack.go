@@ -0,0 +1,383 @@
+// Package taskpack defines task packs: the tasks and schedules of one game, with the metadata
+// needed to share them, published as a single JSON document and installed by name or URL with
+// "nexusctl pack install".
+//
+// An installed pack is a task file of the game's subdirectory of a tasks.d directory, loaded by
+// handler.LoadTaskDir along with the other task files of the game.
+package taskpack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/remote"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils/resettime"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatVersion is the current version of the task pack format.
+const FormatVersion = 1
+
+// packDir is the subdirectory of a game's task directory keeping the installed packs.
+const packDir = ".packs"
+
+// validName matches the names of packs and games, which are used as file names.
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// Pack is a task pack.
+//
+// # Fields:
+//   - Format: The version of the pack format, FormatVersion.
+//   - Name: The name of the pack (lowercase letters, digits, '.', '_', and '-').
+//   - Version: The version of the pack (e.g., "1.2.0").
+//   - Description: What the pack does.
+//   - Author: Who maintains the pack.
+//   - Homepage: Where the pack is documented.
+//   - Game: The name of the game the pack is for, its task subdirectory once installed.
+//   - Adapter: The game adapter the tasks of the pack require.
+//   - Tasks: The tasks of the pack, in the tasks.json structure.
+//   - Schedules: The schedules of the tasks, keyed by task name.
+//
+// # Example pack:
+//
+//	{
+//		"format": 1,
+//		"name": "hamster-daily",
+//		"version": "1.0.0",
+//		"description": "Daily cipher, combo, and tap tasks",
+//		"game": "hamster",
+//		"adapter": {"module": "github.com/acme/hamster-adapter", "min_version": "v0.4.0"},
+//		"tasks": {
+//			"version": 2,
+//			"one_time_tasks": [{"name": "Daily cipher", "payload": {"kind": "cipher"}}],
+//			"recurrent_tasks": [{"name": "Tap", "payload": {"count": 500}}]
+//		},
+//		"schedules": {
+//			"Tap": {"interval_minutes": 45},
+//			"Daily cipher": {"daily_reset": "08:00", "timezone": "Europe/Moscow", "max_offset_minutes": 60}
+//		}
+//	}
+type Pack struct {
+	Format      int                  `json:"format"`
+	Name        string               `json:"name"`
+	Version     string               `json:"version"`
+	Description string               `json:"description,omitempty"`
+	Author      string               `json:"author,omitempty"`
+	Homepage    string               `json:"homepage,omitempty"`
+	Game        string               `json:"game"`
+	Adapter     Adapter              `json:"adapter"`
+	Tasks       types.TaskCollection `json:"tasks"`
+	Schedules   map[string]Schedule  `json:"schedules,omitempty"`
+}
+
+// Adapter is the game adapter required by a pack.
+//
+// # Fields:
+//   - Module: The Go module path of the adapter.
+//   - MinVersion: The oldest version of the adapter the tasks work with. Empty accepts any.
+type Adapter struct {
+	Module     string `json:"module"`
+	MinVersion string `json:"min_version,omitempty"`
+}
+
+// Schedule is when a task of a pack runs.
+//
+// # Fields:
+//   - IntervalMinutes: The interval of a recurrent task, set on the task when the pack is installed.
+//   - DailyReset: The daily reset ("HH:MM") after which a daily task runs, see Reset.
+//   - Timezone: The IANA timezone of DailyReset. Defaults to UTC.
+//   - MinOffsetMinutes: The start of the run window after the reset, in minutes.
+//   - MaxOffsetMinutes: The end of the run window after the reset, in minutes.
+type Schedule struct {
+	IntervalMinutes  int    `json:"interval_minutes,omitempty"`
+	DailyReset       string `json:"daily_reset,omitempty"`
+	Timezone         string `json:"timezone,omitempty"`
+	MinOffsetMinutes int    `json:"min_offset_minutes,omitempty"`
+	MaxOffsetMinutes int    `json:"max_offset_minutes,omitempty"`
+}
+
+// Reset returns the daily reset of the schedule, for adapters creating a tasks.DailyTask.
+//
+// # Returns:
+//   - resettime.Reset: The daily reset.
+//   - bool: Whether the schedule has a daily reset.
+//   - error: An error if DailyReset or Timezone is invalid.
+//
+// # Example:
+//
+//	if reset, ok, err := schedule.Reset(); err == nil && ok {
+//		task := tasks.NewDailyTask(config.Name, config.Payload, reset,
+//			time.Duration(schedule.MinOffsetMinutes)*time.Minute, time.Duration(schedule.MaxOffsetMinutes)*time.Minute)
+//		handler.AddTask(task)
+//	}
+func (schedule Schedule) Reset() (resettime.Reset, bool, error) {
+	if schedule.DailyReset == "" {
+		return resettime.Reset{}, false, nil
+	}
+	hour, minute, ok := strings.Cut(schedule.DailyReset, ":")
+	h, hourErr := strconv.Atoi(hour)
+	m, minuteErr := strconv.Atoi(minute)
+	if !ok || hourErr != nil || minuteErr != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return resettime.Reset{}, false, fmt.Errorf("invalid daily reset %q, expected HH:MM", schedule.DailyReset)
+	}
+	location := time.UTC
+	if schedule.Timezone != "" {
+		loaded, err := time.LoadLocation(schedule.Timezone)
+		if err != nil {
+			return resettime.Reset{}, false, err
+		}
+		location = loaded
+	}
+	return resettime.Daily(h, m, location), true, nil
+}
+
+// Validate checks that the pack can be installed.
+func (pack *Pack) Validate() error {
+	if pack.Format < 1 || pack.Format > FormatVersion {
+		return fmt.Errorf("unsupported task pack format %d", pack.Format)
+	}
+	if !validName.MatchString(pack.Name) {
+		return fmt.Errorf("invalid task pack name %q", pack.Name)
+	}
+	if !validName.MatchString(pack.Game) {
+		return fmt.Errorf("task pack %s: invalid game name %q", pack.Name, pack.Game)
+	}
+	if pack.Version == "" {
+		return fmt.Errorf("task pack %s has no version", pack.Name)
+	}
+	if pack.Adapter.Module == "" {
+		return fmt.Errorf("task pack %s does not name its game adapter", pack.Name)
+	}
+	if pack.Tasks.Version > types.TasksVersion {
+		return fmt.Errorf("task pack %s: tasks version %d is newer than the supported version %d, upgrade the SDK",
+			pack.Name, pack.Tasks.Version, types.TasksVersion)
+	}
+	if len(pack.Tasks.Include) > 0 {
+		return fmt.Errorf("task pack %s: packs cannot include task files", pack.Name)
+	}
+	names := make(map[string]bool)
+	for _, task := range pack.Tasks.OneTimeTasks {
+		names[task.Name] = true
+	}
+	for _, task := range pack.Tasks.RecurrentTasks {
+		names[task.Name] = true
+	}
+	for name, schedule := range pack.Schedules {
+		if !names[name] {
+			return fmt.Errorf("task pack %s: schedule of unknown task %q", pack.Name, name)
+		}
+		if _, _, err := schedule.Reset(); err != nil {
+			return fmt.Errorf("task pack %s: schedule of %q: %w", pack.Name, name, err)
+		}
+	}
+	return nil
+}
+
+// Parse decodes and validates a pack.
+func Parse(data []byte) (*Pack, error) {
+	var pack Pack
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return nil, fmt.Errorf("invalid task pack: %w", err)
+	}
+	return &pack, pack.Validate()
+}
+
+// Fetch reads a pack from a source.
+//
+// # Parameters:
+//   - ctx: The context of the remote requests.
+//   - source: A local path, a remote location (see remote.Open), or the name of a pack of the
+//     index.
+//   - index: The location of the pack index resolving names, a JSON object mapping pack names
+//     to locations relative to the index: {"packs": {"hamster-daily": "hamster-daily.json"}}.
+//   - options: The options of the remote requests.
+//
+// # Returns:
+//   - *Pack: The validated pack.
+//   - error: An error if the pack cannot be read, the name is not in the index, or the pack is
+//     invalid.
+func Fetch(ctx context.Context, source, index string, options remote.Options) (*Pack, error) {
+	location := source
+	if !remote.IsRemote(source) && validName.MatchString(source) {
+		if _, err := os.Stat(source); os.IsNotExist(err) {
+			if location, err = resolveName(ctx, source, index, options); err != nil {
+				return nil, err
+			}
+		}
+	}
+	data, err := read(ctx, location, options)
+	if err != nil {
+		return nil, err
+	}
+	pack, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", location, err)
+	}
+	return pack, nil
+}
+
+// resolveName returns the location of a pack of the index.
+func resolveName(ctx context.Context, name, index string, options remote.Options) (string, error) {
+	if index == "" {
+		return "", fmt.Errorf("no task pack index configured to resolve %q", name)
+	}
+	data, err := read(ctx, index, options)
+	if err != nil {
+		return "", err
+	}
+	var document struct {
+		Packs map[string]string `json:"packs"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return "", fmt.Errorf("invalid task pack index %s: %w", index, err)
+	}
+	location, ok := document.Packs[name]
+	if !ok {
+		return "", fmt.Errorf("task pack %q is not in the index %s", name, index)
+	}
+	if remote.IsRemote(index) {
+		base, err := url.Parse(index)
+		if err != nil {
+			return "", err
+		}
+		reference, err := url.Parse(location)
+		if err != nil {
+			return "", err
+		}
+		return base.ResolveReference(reference).String(), nil
+	}
+	if !remote.IsRemote(location) && !filepath.IsAbs(location) {
+		location = filepath.Join(filepath.Dir(index), location)
+	}
+	return location, nil
+}
+
+// read returns the document at a local path or remote location.
+func read(ctx context.Context, location string, options remote.Options) ([]byte, error) {
+	if !remote.IsRemote(location) {
+		return os.ReadFile(location)
+	}
+	reader, err := remote.Open(ctx, location, options)
+	if err != nil {
+		return nil, err
+	}
+	defer func(reader io.ReadCloser) {
+		err := reader.Close()
+		if err != nil {
+		}
+	}(reader)
+	return io.ReadAll(reader)
+}
+
+// Install installs a pack into a tasks.d directory, replacing any installed version.
+//
+// The tasks of the pack, with the intervals of their schedules applied, are written to
+// "<dir>/<game>/pack-<name>.json", and the pack itself to "<dir>/<game>/.packs/<name>.json".
+//
+// # Returns:
+//   - string: The path of the task file of the pack.
+//   - error: An error if the pack is invalid or cannot be written.
+func Install(pack *Pack, dir string) (string, error) {
+	if err := pack.Validate(); err != nil {
+		return "", err
+	}
+	tasks := pack.Tasks
+	if tasks.Version == 0 {
+		tasks.Version = types.TasksVersion
+	}
+	tasks.RecurrentTasks = append([]types.RecurrentTaskConfig(nil), tasks.RecurrentTasks...)
+	for i, task := range tasks.RecurrentTasks {
+		if schedule, ok := pack.Schedules[task.Name]; ok && schedule.IntervalMinutes > 0 {
+			tasks.RecurrentTasks[i].IntervalMinutes = schedule.IntervalMinutes
+		}
+	}
+	gameDir := filepath.Join(dir, pack.Game)
+	if err := os.MkdirAll(filepath.Join(gameDir, packDir), 0o755); err != nil {
+		return "", err
+	}
+	if err := writeJSON(filepath.Join(gameDir, packDir, pack.Name+".json"), pack); err != nil {
+		return "", err
+	}
+	tasksPath := filepath.Join(gameDir, "pack-"+pack.Name+".json")
+	return tasksPath, writeJSON(tasksPath, tasks)
+}
+
+// Installed returns the packs installed into a tasks.d directory, ordered by game and name.
+//
+// # Parameters:
+//   - dir: The tasks.d directory.
+//   - game: The game whose packs are listed. Empty lists the packs of every game.
+func Installed(dir, game string) ([]*Pack, error) {
+	pattern := filepath.Join(dir, "*", packDir, "*.json")
+	if game != "" {
+		pattern = filepath.Join(dir, game, packDir, "*.json")
+	}
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var packs []*Pack
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var pack Pack
+		if err := json.Unmarshal(data, &pack); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		packs = append(packs, &pack)
+	}
+	sort.Slice(packs, func(i, j int) bool {
+		if packs[i].Game != packs[j].Game {
+			return packs[i].Game < packs[j].Game
+		}
+		return packs[i].Name < packs[j].Name
+	})
+	return packs, nil
+}
+
+// Uninstall removes an installed pack of a game from a tasks.d directory.
+//
+// # Returns:
+//   - error: An error if the pack is not installed or cannot be removed.
+func Uninstall(dir, game, name string) error {
+	if !validName.MatchString(game) || !validName.MatchString(name) {
+		return fmt.Errorf("invalid task pack %s/%s", game, name)
+	}
+	manifest := filepath.Join(dir, game, packDir, name+".json")
+	if _, err := os.Stat(manifest); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("task pack %s is not installed for %s", name, game)
+		}
+		return err
+	}
+	err := os.Remove(filepath.Join(dir, game, "pack-"+name+".json"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return os.Remove(manifest)
+}
+
+// writeJSON writes value as indented JSON to path through a temporary file.
+func writeJSON(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "\t")
+	if err != nil {
+		return err
+	}
+	temporaryPath := path + ".tmp"
+	if err := os.WriteFile(temporaryPath, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, path)
+}
@@ -0,0 +1,163 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AuthLocation is where the requests of a task carry the game data (the Telegram initData) of
+// the account, for games authenticating every request with it.
+type AuthLocation string
+
+// The locations of the game data in the requests of a task.
+const (
+	AuthNone   AuthLocation = ""       // The game data is not added; the payload may embed it.
+	AuthQuery  AuthLocation = "query"  // The game data is a query parameter of the URL.
+	AuthHeader AuthLocation = "header" // The game data is the value of a header.
+	AuthBody   AuthLocation = "body"   // The game data is a field of the JSON payload.
+)
+
+// The default names of the query parameter, header, and payload field of the game data.
+const (
+	DefaultAuthParam  = "initData"
+	DefaultAuthHeader = "Authorization"
+	DefaultAuthField  = "initData"
+)
+
+// ParseAuthLocation parses the auth_location of a task configuration.
+//
+// # Returns:
+//   - AuthLocation: The location, AuthNone for an empty string.
+//   - error: An error if the location is not "query", "header", or "body".
+func ParseAuthLocation(location string) (AuthLocation, error) {
+	switch AuthLocation(location) {
+	case AuthNone, AuthQuery, AuthHeader, AuthBody:
+		return AuthLocation(location), nil
+	}
+	return AuthNone, fmt.Errorf("invalid auth location %q, expected \"query\", \"header\", or \"body\"", location)
+}
+
+// AppendQuery returns rawURL with query parameters added to its query string, encoded, and
+// keeping its existing parameters and fragment.
+//
+// # Example:
+//
+//	url, err := tasks.AppendQuery(handler.GetBaseURL()+"/tasks", url.Values{"page": {"2"}})
+func AppendQuery(rawURL string, values url.Values) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if len(values) == 0 {
+		return rawURL, nil
+	}
+	encoded := values.Encode()
+	if parsed.RawQuery != "" {
+		encoded = parsed.RawQuery + "&" + encoded
+	}
+	parsed.RawQuery = encoded
+	return parsed.String(), nil
+}
+
+// WithGameData returns rawURL with the game data of an account added as the query parameter
+// param (DefaultAuthParam when empty). The game data, itself a query string, is encoded as a
+// single value.
+//
+// # Example:
+//
+//	url, err := tasks.WithGameData(handler.GetBaseURL()+"/user", account, "tgWebAppData")
+//	if err != nil {
+//		return err
+//	}
+//	body, err := handler.Get(url)
+func WithGameData(rawURL string, account types.Account, param string) (string, error) {
+	if param == "" {
+		param = DefaultAuthParam
+	}
+	return AppendQuery(rawURL, url.Values{param: {account.GameData}})
+}
+
+// Authorize adds the game data of an account to a request of the task, at the task's
+// AuthLocation under its AuthKey (or the default name of the location).
+//
+// # Parameters:
+//   - account: The account the request is sent for.
+//   - rawURL: The URL of the request.
+//   - payload: The JSON payload of the request. It is not modified.
+//
+// # Returns:
+//   - string: The URL of the request.
+//   - interface{}: The payload of the request.
+//   - map[string]string: The headers to add to the request, nil when none.
+//   - error: An error if the URL cannot be parsed, or the game data is to be sent in a payload
+//     that is not a JSON object.
+func (task *BaseTask) Authorize(account types.Account, rawURL string, payload interface{}) (string, interface{}, map[string]string, error) {
+	key := task.AuthKey
+	switch task.AuthLocation {
+	case AuthQuery:
+		authorized, err := WithGameData(rawURL, account, key)
+		return authorized, payload, nil, err
+	case AuthHeader:
+		if key == "" {
+			key = DefaultAuthHeader
+		}
+		return rawURL, payload, map[string]string{key: account.GameData}, nil
+	case AuthBody:
+		if key == "" {
+			key = DefaultAuthField
+		}
+		fields, ok := payload.(map[string]interface{})
+		if !ok && payload != nil {
+			return "", nil, nil, fmt.Errorf("task %s sends the game data in a payload that is not an object", task.Name)
+		}
+		authorized := make(map[string]interface{}, len(fields)+1)
+		for name, value := range fields {
+			authorized[name] = value
+		}
+		authorized[key] = account.GameData
+		return rawURL, authorized, nil, nil
+	}
+	return rawURL, payload, nil, nil
+}
+
+// request sends a request of the task through handler, a GET request or a POST request of the
+// payload encoded as JSON, with per-call headers and the game data of the account added at the
+// task's AuthLocation (see Authorize).
+func (task *BaseTask) request(handler Handler, account types.Account, method, rawURL string, payload interface{}, headers map[string]string) ([]byte, error) {
+	get := strings.EqualFold(method, http.MethodGet)
+	if get && task.AuthLocation == AuthBody {
+		return nil, fmt.Errorf("task %s sends the game data in the body of a GET request", task.Name)
+	}
+	rawURL, payload, authHeaders, err := task.Authorize(account, rawURL, payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(authHeaders) > 0 {
+		merged := make(map[string]string, len(headers)+len(authHeaders))
+		for key, value := range headers {
+			merged[key] = value
+		}
+		for key, value := range authHeaders {
+			merged[key] = value
+		}
+		headers = merged
+	}
+	if get {
+		if len(headers) == 0 {
+			return handler.Get(rawURL)
+		}
+		return GetWithHeaders(handler, rawURL, headers)
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(headers) == 0 {
+		return handler.Post(rawURL, payloadBytes)
+	}
+	return PostWithHeaders(handler, rawURL, payloadBytes, headers)
+}
@@ -0,0 +1,90 @@
+package tasks
+
+import (
+	"github.com/nexus-telegram/NexusSDK/types"
+	"math"
+	"time"
+)
+
+// DefaultBackoffMax is the longest interval stretched by an IntervalBackoff without a Max.
+const DefaultBackoffMax = 12 * time.Hour
+
+// IntervalBackoff stretches the interval of a scheduled task that keeps failing for an
+// account, so that a persistent game-side issue (an event that ended, an endpoint returning
+// errors for hours) does not fill the logs and load the proxies with failures every interval.
+// The interval is restored after the first success.
+//
+// # Fields:
+//   - Factor: The multiplier of the interval for every failure in a row from After on.
+//     Factors of 1 or less disable the backoff.
+//   - Max: The longest stretched interval. Zero means DefaultBackoffMax. Intervals already
+//     longer are left as they are.
+//   - After: The number of failures in a row before the interval is stretched. Zero means 1.
+//
+// # Example:
+//
+//	task := tasks.NewRecurrentTask("SyncTaps", payload, 10*time.Minute)
+//	task.Backoff = &tasks.IntervalBackoff{Factor: 2, Max: 4 * time.Hour, After: 2}
+type IntervalBackoff struct {
+	Factor float64
+	Max    time.Duration
+	After  int
+}
+
+// NewIntervalBackoff creates the interval backoff of an interval_backoff section of
+// config.json or a backoff section of tasks.json, or returns nil when the section is nil.
+func NewIntervalBackoff(config *types.BackoffConfig) *IntervalBackoff {
+	if config == nil {
+		return nil
+	}
+	return &IntervalBackoff{
+		Factor: config.Factor,
+		Max:    time.Duration(config.MaxMinutes) * time.Minute,
+		After:  config.AfterFailures,
+	}
+}
+
+// Stretches reports whether the interval of a task is stretched after failures failures in a
+// row.
+func (backoff *IntervalBackoff) Stretches(failures int) bool {
+	after := backoff.After
+	if after <= 0 {
+		after = 1
+	}
+	return backoff.Factor > 1 && failures >= after
+}
+
+// Stretch returns the interval of a task after failures failures in a row.
+func (backoff *IntervalBackoff) Stretch(interval time.Duration, failures int) time.Duration {
+	if !backoff.Stretches(failures) || interval <= 0 {
+		return interval
+	}
+	after := backoff.After
+	if after <= 0 {
+		after = 1
+	}
+	limit := backoff.Max
+	if limit <= 0 {
+		limit = DefaultBackoffMax
+	}
+	if interval >= limit {
+		return interval
+	}
+	stretched := float64(interval) * math.Pow(backoff.Factor, float64(failures-after+1))
+	if stretched >= float64(limit) {
+		return limit
+	}
+	return time.Duration(stretched)
+}
+
+// FailureBackoff returns the interval backoff of the task, nil when it uses the handler's.
+func (task *BaseTask) FailureBackoff() *IntervalBackoff {
+	return task.Backoff
+}
+
+// BackingOff is implemented by scheduled tasks with their own interval backoff, such as every
+// task embedding BaseTask. The intervals of the tasks without one are stretched with the
+// backoff of the handler, if any.
+type BackingOff interface {
+	FailureBackoff() *IntervalBackoff
+}
@@ -0,0 +1,144 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// BatchRequest is one request of a BatchTask.
+//
+// # Fields:
+//   - Method: "GET" or "POST". Defaults to "POST".
+//   - URL: The URL of the request. URLs starting with "/" are relative to the handler's base URL,
+//     and URLs starting with "@name" to its base URL of that name (see ResolveURL).
+//   - Payload: The value sent as the JSON body of POST requests.
+type BatchRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Payload interface{} `json:"payload"`
+}
+
+// BatchResult is the outcome of one BatchRequest.
+type BatchResult struct {
+	Body []byte
+	Err  error
+}
+
+// Batch sends requests through handler with at most concurrency of them in flight and
+// returns their results in the order of the requests.
+//
+// # Example:
+//
+//	requests := []tasks.BatchRequest{
+//		{Method: "GET", URL: "/quests/1/status"},
+//		{Method: "GET", URL: "/quests/2/status"},
+//	}
+//	for i, result := range tasks.Batch(handler, requests, 4) {
+//		handler.GetLogger().Info("Quest status", zap.Int("quest", i), zap.ByteString("status", result.Body))
+//	}
+func Batch(handler Handler, requests []BatchRequest, concurrency int) []BatchResult {
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	results := make([]BatchResult, len(requests))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, request BatchRequest) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = sendBatchRequest(handler, request)
+		}(i, request)
+	}
+	wg.Wait()
+	return results
+}
+
+// sendBatchRequest sends one request of a batch through handler.
+func sendBatchRequest(handler Handler, request BatchRequest) BatchResult {
+	url, err := ResolveURL(handler, request.URL)
+	if err != nil {
+		return BatchResult{Err: err}
+	}
+	switch strings.ToUpper(request.Method) {
+	case http.MethodGet:
+		body, err := handler.Get(url)
+		return BatchResult{Body: body, Err: err}
+	case "", http.MethodPost:
+		payload, err := json.Marshal(request.Payload)
+		if err != nil {
+			return BatchResult{Err: err}
+		}
+		body, err := handler.Post(url, payload)
+		return BatchResult{Body: body, Err: err}
+	default:
+		return BatchResult{Err: fmt.Errorf("unsupported batch request method: %s", request.Method)}
+	}
+}
+
+// BatchTask represents a task sending a set of requests for every account with bounded
+// concurrency, e.g. fetching the statuses of N quests.
+//
+// # Fields:
+//   - Requests: The requests sent on every run.
+//   - Concurrency: The maximum number of requests in flight. Defaults to 4.
+//   - OnResult: Called with the index and result of each request once the batch is done, in
+//     request order. When nil, the results are logged.
+type BatchTask struct {
+	BaseTask
+	Requests    []BatchRequest                                                   // Requests sent on every run
+	Concurrency int                                                              // Maximum number of requests in flight
+	OnResult    func(account types.Account, index int, result BatchResult) error // Result callback
+}
+
+// NewBatchTask creates a new batch task.
+//
+// # Example:
+//
+//	task := tasks.NewBatchTask("quest-statuses", requests, 8)
+//	task.OnResult = func(account types.Account, index int, result tasks.BatchResult) error {
+//		return result.Err
+//	}
+//	handler.AddTask(task)
+func NewBatchTask(name string, requests []BatchRequest, concurrency int) *BatchTask {
+	return &BatchTask{
+		BaseTask:    BaseTask{Name: name},
+		Requests:    requests,
+		Concurrency: concurrency,
+	}
+}
+
+// Run sends the requests of the task for a given account. It returns the errors of the
+// failed requests, or of OnResult, joined together.
+func (task *BatchTask) Run(account types.Account, handler Handler) error {
+	log := handler.GetLogger().With(zap.String("task", task.Name))
+	log.Info("Running batch task", zap.Int("requests", len(task.Requests)))
+	var errs []error
+	for i, result := range Batch(handler, task.Requests, task.Concurrency) {
+		if task.OnResult != nil {
+			if err := task.OnResult(account, i, result); err != nil {
+				errs = append(errs, fmt.Errorf("request %d: %w", i, err))
+			}
+			continue
+		}
+		if result.Err != nil {
+			log.Warn("Batch request failed", zap.Int("request", i), zap.Error(result.Err))
+			errs = append(errs, fmt.Errorf("request %d: %w", i, result.Err))
+			continue
+		}
+		log.Debug("Batch request succeeded", zap.Int("request", i), zap.ByteString("response", result.Body))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("batch task '%s' for account %s: %w", task.Name, account.TelegramData.TelegramId, errors.Join(errs...))
+	}
+	log.Info("Successfully executed batch task")
+	return nil
+}
@@ -0,0 +1,63 @@
+package tasks
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxCatchUpRuns bounds the number of missed executions replayed by CatchUpAll.
+const MaxCatchUpRuns = 100
+
+// CatchUpPolicy is what the scheduler does with the executions of a scheduled task that were
+// missed while the farm was down or the machine was asleep.
+type CatchUpPolicy string
+
+// The catch-up policies.
+const (
+	CatchUpOnce CatchUpPolicy = "run_once" // Run the task once now, then resume its schedule (default)
+	CatchUpSkip CatchUpPolicy = "skip"     // Skip the missed executions and resume the schedule from now
+	CatchUpAll  CatchUpPolicy = "run_all"  // Run every missed execution, one per poll, up to MaxCatchUpRuns
+)
+
+// ParseCatchUpPolicy parses a catch-up policy name. An empty name is CatchUpOnce.
+func ParseCatchUpPolicy(name string) (CatchUpPolicy, error) {
+	switch policy := CatchUpPolicy(name); policy {
+	case "":
+		return CatchUpOnce, nil
+	case CatchUpOnce, CatchUpSkip, CatchUpAll:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown catch-up policy: %s", name)
+	}
+}
+
+// MissedRunPolicy returns the catch-up policy of the task, CatchUpOnce when unset.
+func (task *BaseTask) MissedRunPolicy() CatchUpPolicy {
+	if task.CatchUp == "" {
+		return CatchUpOnce
+	}
+	return task.CatchUp
+}
+
+// CatchingUp is implemented by scheduled tasks with a catch-up policy, such as every task
+// embedding BaseTask. Scheduled tasks that do not implement it use CatchUpOnce.
+//
+// Skipping can lose a daily claim, while replaying every missed execution of a frequent task
+// can trigger rate limits, so the policy is chosen per task.
+type CatchingUp interface {
+	MissedRunPolicy() CatchUpPolicy
+}
+
+// MissedRuns returns how many executions of schedule were due from due, the time of the first
+// missed execution, to now, at most MaxCatchUpRuns.
+func MissedRuns(schedule Scheduled, due, now time.Time) int {
+	missed := 0
+	for run := due; !run.After(now) && missed < MaxCatchUpRuns; missed++ {
+		next := schedule.Next(run, run)
+		if !next.After(run) {
+			return missed + 1
+		}
+		run = next
+	}
+	return missed
+}
@@ -0,0 +1,77 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+)
+
+// scheduleFunc adapts a function to the Scheduled interface.
+type scheduleFunc func(last, now time.Time) time.Time
+
+func (schedule scheduleFunc) Next(last, now time.Time) time.Time {
+	return schedule(last, now)
+}
+
+func TestParseCatchUpPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    CatchUpPolicy
+		wantErr bool
+	}{
+		{"", CatchUpOnce, false},
+		{"run_once", CatchUpOnce, false},
+		{"skip", CatchUpSkip, false},
+		{"run_all", CatchUpAll, false},
+		{"all", "", true},
+		{"SKIP", "", true},
+	}
+	for _, test := range tests {
+		got, err := ParseCatchUpPolicy(test.name)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("ParseCatchUpPolicy(%q) = %q, %v, want %q, error %t", test.name, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestMissedRunPolicy(t *testing.T) {
+	for _, test := range []struct {
+		policy, want CatchUpPolicy
+	}{
+		{"", CatchUpOnce},
+		{CatchUpSkip, CatchUpSkip},
+		{CatchUpAll, CatchUpAll},
+	} {
+		var task CatchingUp = NewRecurrentTask("task", nil, time.Minute)
+		task.(*RecurrentTask).CatchUp = test.policy
+		if got := task.MissedRunPolicy(); got != test.want {
+			t.Errorf("MissedRunPolicy() with CatchUp %q = %q, want %q", test.policy, got, test.want)
+		}
+	}
+}
+
+func TestMissedRuns(t *testing.T) {
+	due := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	hourly := NewRecurrentTask("hourly", nil, time.Hour)
+	tests := []struct {
+		name     string
+		schedule Scheduled
+		now      time.Time
+		want     int
+	}{
+		{"not due yet", hourly, due.Add(-time.Second), 0},
+		{"due now", hourly, due, 1},
+		{"within the first interval", hourly, due.Add(59 * time.Minute), 1},
+		{"on the next execution", hourly, due.Add(time.Hour), 2},
+		{"a day down", hourly, due.Add(24*time.Hour + time.Minute), 25},
+		{"capped", NewRecurrentTask("frequent", nil, time.Second), due.Add(time.Hour), MaxCatchUpRuns},
+		{"stuck schedule", scheduleFunc(func(last, now time.Time) time.Time { return last }), due.Add(time.Hour), 1},
+		{"backwards schedule", scheduleFunc(func(last, now time.Time) time.Time { return last.Add(-time.Hour) }), due.Add(time.Hour), 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := MissedRuns(test.schedule, due, test.now); got != test.want {
+				t.Errorf("MissedRuns() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package tasks
+
+import (
+	"encoding/json"
+	"github.com/nexus-telegram/NexusSDK/expr"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils/clock"
+)
+
+// ShouldRun evaluates the condition of the task for an account. OneTimeTask and RecurrentTask
+// skip their request when it returns false; the execution still succeeds.
+//
+// # Variables:
+//   - account: The account, by the JSON names of accounts.json (e.g., account.telegram.telegramId).
+//   - profile: The profile stored for the account (see GetProfile), by its JSON names, or null.
+//   - payload: The payload of the task.
+//
+// # Returns:
+//   - bool: Whether the task runs. True when the task has no condition.
+//   - error: An error if the condition cannot be evaluated.
+//
+// # Example:
+//
+//	task := tasks.NewRecurrentTask("Upgrade", payload, 30*time.Minute)
+//	task.Condition = expr.MustCompile("profile.balance >= payload.price")
+func (task *BaseTask) ShouldRun(account types.Account, handler Handler) (bool, error) {
+	if task.Condition == nil {
+		return true, nil
+	}
+	env := ExpressionEnv(account, handler)
+	env["payload"] = jsonValue(task.Payload)
+	return task.Condition.Bool(env)
+}
+
+// RenderPayload returns the payload of the task for an account, with the expressions embedded
+// in its strings evaluated, e.g. "{{account.referral_code}}" for a field of accounts.json
+// captured into the account's Extra. The variables are those of ShouldRun.
+//
+// # Example:
+//
+//	task := tasks.NewOneTimeTask("Register", map[string]interface{}{
+//		"action": "register",
+//		"ref":    "{{account.referral_code}}",
+//	})
+func (task *BaseTask) RenderPayload(account types.Account, handler Handler) (map[string]interface{}, error) {
+	env := ExpressionEnv(account, handler)
+	env["payload"] = jsonValue(task.Payload)
+	rendered, err := renderValue(task.Payload, env)
+	if err != nil {
+		return nil, err
+	}
+	payload, _ := rendered.(map[string]interface{})
+	return payload, nil
+}
+
+// ClockProvider is implemented by handlers with a clock, such as GameHandler and the handler
+// passed to Task.Run. The now() function of the expressions of tasks reads it (see
+// expr.NowKey), so conditions on time follow the fake clock of tests.
+type ClockProvider interface {
+	Clock() clock.Clock
+}
+
+// ExpressionEnv returns the variables the expressions of tasks read about an account: "account"
+// and "profile" (see ShouldRun), along with the clock of the handler, if it provides one (see
+// ClockProvider).
+func ExpressionEnv(account types.Account, handler Handler) expr.Env {
+	env := expr.Env{"account": jsonValue(account), "profile": nil}
+	if provider, ok := handler.(ClockProvider); ok {
+		env[expr.NowKey] = provider.Clock().Now
+	}
+	if profile, ok := handler.LoadProfile(account.TelegramData.TelegramId); ok {
+		env["profile"] = jsonValue(profile)
+	}
+	return env
+}
+
+// jsonValue returns the JSON representation of a Go value, the values expressions read.
+func jsonValue(value interface{}) interface{} {
+	var data []byte
+	if raw, ok := value.(json.RawMessage); ok {
+		data = raw
+	} else {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil
+		}
+		data = encoded
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil
+	}
+	return decoded
+}
@@ -0,0 +1,180 @@
+package tasks
+
+import (
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/expr"
+	"github.com/nexus-telegram/NexusSDK/jsonpath"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"time"
+)
+
+// FromConfig creates the task of a one-time task configuration of tasks.json: a PipelineTask
+// when the configuration has steps, a OneTimeTask otherwise.
+//
+// # Returns:
+//   - Task: The task, with its condition and steps compiled.
+//   - error: An error if the condition, a step condition, an assignment, an embedded
+//     expression, or an extraction path is invalid.
+//
+// # Example:
+//
+//	collection, err := handler.LoadTasks("tasks.json")
+//	if err != nil {
+//		log.Fatalf("Failed to load tasks: %v", err)
+//	}
+//	for _, config := range collection.OneTimeTasks {
+//		task, err := tasks.FromConfig(config)
+//		if err != nil {
+//			log.Fatalf("Invalid task: %v", err)
+//		}
+//		gameHandler.AddTask(task)
+//	}
+func FromConfig(config types.TaskConfig) (Task, error) {
+	condition, err := compileCondition(config.Condition)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", config.Name, err)
+	}
+	extract, err := compileExtract(config.Extract)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", config.Name, err)
+	}
+	auth, err := ParseAuthLocation(config.AuthLocation)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", config.Name, err)
+	}
+	timeout := time.Duration(config.TimeoutSeconds) * time.Second
+	if len(config.Steps) == 0 {
+		task := NewOneTimeTask(config.Name, config.Payload)
+		task.Condition, task.Extract, task.Timeout = condition, extract, timeout
+		task.AuthLocation, task.AuthKey = auth, config.AuthKey
+		return task, checkEmbedded(config.Payload)
+	}
+	steps, err := stepsFromConfig(config.Steps)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", config.Name, err)
+	}
+	task := NewPipelineTask(config.Name, steps)
+	task.Payload = config.Payload
+	task.Headers = config.Headers
+	task.Condition, task.Extract, task.Timeout = condition, extract, timeout
+	task.AuthLocation, task.AuthKey = auth, config.AuthKey
+	return task, checkEmbedded(config.Headers)
+}
+
+// FromRecurrentConfig creates the task of a recurrent task configuration of tasks.json: a
+// RecurrentPipelineTask when the configuration has steps, a RecurrentTask otherwise. See
+// FromConfig.
+//
+// A configuration with a poll section creates a PollingTask, whose action is the task of the
+// rest of the configuration.
+func FromRecurrentConfig(config types.RecurrentTaskConfig) (Task, error) {
+	if config.Poll != nil {
+		return pollingFromConfig(config)
+	}
+	interval := time.Duration(config.IntervalMinutes) * time.Minute
+	condition, err := compileCondition(config.Condition)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", config.Name, err)
+	}
+	extract, err := compileExtract(config.Extract)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", config.Name, err)
+	}
+	auth, err := ParseAuthLocation(config.AuthLocation)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", config.Name, err)
+	}
+	timeout := time.Duration(config.TimeoutSeconds) * time.Second
+	if len(config.Steps) == 0 {
+		task := NewRecurrentTask(config.Name, config.Payload, interval)
+		task.Condition, task.Extract, task.Timeout = condition, extract, timeout
+		task.AuthLocation, task.AuthKey = auth, config.AuthKey
+		task.Backoff = NewIntervalBackoff(config.Backoff)
+		return task, checkEmbedded(config.Payload)
+	}
+	steps, err := stepsFromConfig(config.Steps)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", config.Name, err)
+	}
+	task := NewRecurrentPipelineTask(config.Name, steps, interval)
+	task.Payload = config.Payload
+	task.Headers = config.Headers
+	task.Condition, task.Extract, task.Timeout = condition, extract, timeout
+	task.AuthLocation, task.AuthKey = auth, config.AuthKey
+	task.Backoff = NewIntervalBackoff(config.Backoff)
+	return task, checkEmbedded(config.Headers)
+}
+
+// pollingFromConfig creates the polling task of a recurrent task configuration with a poll
+// section.
+func pollingFromConfig(config types.RecurrentTaskConfig) (Task, error) {
+	poll := *config.Poll
+	config.Poll = nil
+	action, err := FromRecurrentConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	task := NewPollingTask(config.Name, poll.URL, time.Duration(config.IntervalMinutes)*time.Minute, action)
+	task.Headers = poll.Headers
+	task.TriggerOnFirst = poll.TriggerOnFirst
+	task.Timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	task.Backoff = NewIntervalBackoff(config.Backoff)
+	if config.AuthLocation != string(AuthBody) {
+		task.AuthLocation, task.AuthKey = AuthLocation(config.AuthLocation), config.AuthKey
+	}
+	if poll.Watch != "" {
+		if task.Watch, err = jsonpath.Compile(poll.Watch); err != nil {
+			return nil, fmt.Errorf("task %s: poll: %w", config.Name, err)
+		}
+	}
+	return task, nil
+}
+
+// compileCondition compiles a condition, nil when empty.
+func compileCondition(source string) (*expr.Program, error) {
+	if source == "" {
+		return nil, nil
+	}
+	return expr.Compile(source)
+}
+
+// stepsFromConfig compiles the steps of a pipeline task configuration.
+func stepsFromConfig(configs []types.TaskStepConfig) ([]Step, error) {
+	steps := make([]Step, len(configs))
+	for i, config := range configs {
+		name := config.Name
+		if name == "" {
+			name = fmt.Sprintf("step %d", i+1)
+		}
+		condition, err := compileCondition(config.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		assignments := make([]expr.Assignment, len(config.Set))
+		for j, source := range config.Set {
+			if assignments[j], err = expr.CompileAssignment(source); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		extract, err := compileExtract(config.Extract)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, value := range []interface{}{config.URL, config.Payload, config.Headers} {
+			if err := checkEmbedded(value); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		steps[i] = Step{
+			Name:      config.Name,
+			Method:    config.Method,
+			URL:       config.URL,
+			Payload:   config.Payload,
+			Headers:   config.Headers,
+			Condition: condition,
+			Set:       assignments,
+			Extract:   extract,
+		}
+	}
+	return steps, nil
+}
@@ -0,0 +1,64 @@
+package tasks
+
+import (
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils/resettime"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// DailyTask represents a task that runs once per game day, at a random time in a window
+// after the game's daily reset.
+//
+// # Fields:
+//   - Reset: The daily reset of the game.
+//   - MinOffset: The start of the run window after the reset.
+//   - MaxOffset: The end of the run window after the reset.
+type DailyTask struct {
+	BaseTask
+	Reset     resettime.Reset // Daily reset of the game
+	MinOffset time.Duration   // Start of the run window after the reset
+	MaxOffset time.Duration   // End of the run window after the reset
+}
+
+// NewDailyTask creates a new daily task running between minOffset and maxOffset after each reset.
+//
+// # Example:
+//
+//	task := tasks.NewDailyTask("claim-daily", payload, resettime.Daily(0, 0, time.UTC), 5*time.Minute, 30*time.Minute)
+//	handler.AddTask(task)
+func NewDailyTask(name string, payload map[string]interface{}, reset resettime.Reset, minOffset, maxOffset time.Duration) *DailyTask {
+	return &DailyTask{
+		BaseTask:  BaseTask{Name: name, Payload: payload},
+		Reset:     reset,
+		MinOffset: minOffset,
+		MaxOffset: maxOffset,
+	}
+}
+
+// Next returns the time of the next execution: in the window of the current game day if the
+// task has not run yet today, otherwise in the window of the next game day.
+func (task *DailyTask) Next(last, now time.Time) time.Time {
+	if !last.IsZero() && task.Reset.SameDay(last, now) {
+		return task.Reset.NextRun(task.Reset.Next(now), task.MinOffset, task.MaxOffset)
+	}
+	return task.Reset.NextRun(now, task.MinOffset, task.MaxOffset)
+}
+
+// Run executes the task for a given account.
+func (task *DailyTask) Run(account types.Account, handler Handler) error {
+	log := handler.GetLogger().With(zap.String("task", task.Name))
+	log.Info("Running daily task", zap.Any("payload", task.Payload))
+	payload, err := task.RenderPayload(account, handler)
+	if err != nil {
+		return fmt.Errorf("failed to render payload for daily task '%s': %w", task.Name, err)
+	}
+	response, err := task.request(handler, account, http.MethodPost, handler.GetBaseURL(), payload, nil)
+	if err != nil {
+		return fmt.Errorf("failed to execute daily task '%s' for account %s: %w", task.Name, account.TelegramData.TelegramId, err)
+	}
+	log.Info("Successfully executed daily task", zap.ByteString("response", response))
+	return nil
+}
@@ -0,0 +1,128 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/jsonpath"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+)
+
+// ExtractState stores the values the Extract paths of the task select in a response into the
+// state of the account (see StoreState). OneTimeTask, RecurrentTask, and PipelineTask call it
+// after each successful request.
+//
+// Paths selecting no value leave their state key unchanged.
+//
+// # Parameters:
+//   - account: The account the response belongs to.
+//   - handler: The handler of the account, whose profile store keeps the state.
+//   - response: The response body, decoded with Decode.
+//
+// # Returns:
+//   - error: An error if the response cannot be decoded.
+//
+// # Example:
+//
+//	task := tasks.NewRecurrentTask("Sync", payload, 15*time.Minute)
+//	task.Extract = map[string]*jsonpath.Path{
+//		"balance":        jsonpath.MustCompile("$.user.balance"),
+//		"claim.cooldown": jsonpath.MustCompile("$.user.claim.nextClaimIn"),
+//	}
+func (task *BaseTask) ExtractState(account types.Account, handler Handler, response []byte) error {
+	return extractState(task, task.Extract, account, handler, response)
+}
+
+// extractState stores the values selected by paths in a response into the state of the account.
+func extractState(task *BaseTask, paths map[string]*jsonpath.Path, account types.Account, handler Handler, response []byte) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	var document interface{}
+	if err := task.Decode(handler, response, &document); err != nil {
+		return fmt.Errorf("failed to decode the response of task '%s' for extraction: %w", task.Name, err)
+	}
+	values := make(map[string]interface{}, len(paths))
+	for key, path := range paths {
+		if value, ok := path.Get(document); ok {
+			values[key] = value
+		} else {
+			handler.GetLogger().Debug("Extraction path matched nothing", zap.String("task", task.Name),
+				zap.String("key", key), zap.Stringer("path", path))
+		}
+	}
+	if len(values) > 0 {
+		StoreState(handler, account.TelegramData.TelegramId, values)
+	}
+	return nil
+}
+
+// StoreState sets keys of the state of an account, the profile of the account seen as a JSON
+// object. Dotted keys (e.g., "claim.cooldown") set nested members.
+//
+// Typed profiles keep working: the updated profile is stored as JSON, which GetProfile decodes
+// into the profile type on its next access. Profiles that are not JSON objects are replaced.
+//
+// # Example:
+//
+//	tasks.StoreState(handler, account.TelegramData.TelegramId, map[string]interface{}{"balance": 1520.5})
+//	state, _ := tasks.GetProfile[map[string]interface{}](handler, account.TelegramData.TelegramId)
+func StoreState(store ProfileStore, telegramId string, values map[string]interface{}) {
+	state, _ := jsonValue(loadedProfile(store, telegramId)).(map[string]interface{})
+	if state == nil {
+		state = make(map[string]interface{})
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts := strings.Split(key, ".")
+		object := state
+		for _, part := range parts[:len(parts)-1] {
+			nested, ok := object[part].(map[string]interface{})
+			if !ok {
+				nested = make(map[string]interface{})
+				object[part] = nested
+			}
+			object = nested
+		}
+		object[parts[len(parts)-1]] = values[key]
+	}
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return
+	}
+	store.StoreProfile(telegramId, json.RawMessage(encoded))
+}
+
+// loadedProfile returns the stored profile of an account, or nil.
+func loadedProfile(store ProfileStore, telegramId string) interface{} {
+	profile, ok := store.LoadProfile(telegramId)
+	if !ok {
+		return nil
+	}
+	return profile
+}
+
+// compileExtract compiles the extract block of a task configuration.
+func compileExtract(config map[string]string) (map[string]*jsonpath.Path, error) {
+	if len(config) == 0 {
+		return nil, nil
+	}
+	paths := make(map[string]*jsonpath.Path, len(config))
+	for key, source := range config {
+		if key == "" || strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") || strings.Contains(key, "..") {
+			return nil, fmt.Errorf("invalid state key %q", key)
+		}
+		path, err := jsonpath.Compile(source)
+		if err != nil {
+			return nil, fmt.Errorf("state key %s: %w", key, err)
+		}
+		paths[key] = path
+	}
+	return paths, nil
+}
@@ -0,0 +1,68 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrHeadersUnsupported is returned by PostWithHeaders and GetWithHeaders when the handler
+// cannot send per-call headers.
+var ErrHeadersUnsupported = errors.New("handler does not support per-call headers")
+
+// HeaderSender is implemented by handlers that can send a request with headers set for that
+// request only, such as the handler passed to Task.Run by GameHandler.
+//
+// # Methods:
+//   - PostWithHeaders(url string, payload []byte, headers map[string]string) ([]byte, error):
+//     Sends a POST request with the headers merged over the handler's defaults.
+//   - GetWithHeaders(url string, headers map[string]string) ([]byte, error): Sends a GET
+//     request with the headers merged over the handler's defaults.
+type HeaderSender interface {
+	PostWithHeaders(url string, payload []byte, headers map[string]string) ([]byte, error)
+	GetWithHeaders(url string, headers map[string]string) ([]byte, error)
+}
+
+// PostWithHeaders sends a POST request through handler with per-call headers, e.g. an
+// Authorization header obtained by an earlier request of the task.
+//
+// # Returns:
+//   - []byte: The response body.
+//   - error: ErrHeadersUnsupported if handler does not implement HeaderSender, or the error of
+//     the request.
+//
+// # Example:
+//
+//	body, err := tasks.PostWithHeaders(handler, handler.GetBaseURL()+"/claim", payload,
+//		map[string]string{"Authorization": "Bearer " + token})
+func PostWithHeaders(handler Handler, url string, payload []byte, headers map[string]string) ([]byte, error) {
+	sender, ok := handler.(HeaderSender)
+	if !ok {
+		return nil, ErrHeadersUnsupported
+	}
+	return sender.PostWithHeaders(url, payload, headers)
+}
+
+// GetWithHeaders sends a GET request through handler with per-call headers. See PostWithHeaders.
+func GetWithHeaders(handler Handler, url string, headers map[string]string) ([]byte, error) {
+	sender, ok := handler.(HeaderSender)
+	if !ok {
+		return nil, ErrHeadersUnsupported
+	}
+	return sender.GetWithHeaders(url, headers)
+}
+
+// GetJSONWithHeaders sends a GET request through handler with per-call headers and decodes
+// the JSON response body into v.
+//
+// # Example:
+//
+//	var balance Balance
+//	err := tasks.GetJSONWithHeaders(handler, handler.GetBaseURL()+"/balance",
+//		map[string]string{"X-Request-Id": requestId}, &balance)
+func GetJSONWithHeaders(handler Handler, url string, headers map[string]string, v interface{}) error {
+	body, err := GetWithHeaders(handler, url, headers)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
@@ -1,9 +1,10 @@
 package tasks
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/nexus-telegram/NexusSDK/types"
+	"go.uber.org/zap"
+	"net/http"
 )
 
 // OneTimeTask represents a task that runs once per account.
@@ -20,16 +21,27 @@ func NewOneTimeTask(name string, payload map[string]interface{}) *OneTimeTask {
 
 // Run executes the task for a given account.
 func (task *OneTimeTask) Run(account types.Account, handler Handler) error {
-	fmt.Printf("Running one-time task '%s' for account %s with payload %v\n", task.Name, account.TelegramData.TelegramId, task.Payload)
-	payload := task.Payload
-	payloadBytes, err := json.Marshal(payload)
+	log := handler.GetLogger().With(zap.String("task", task.Name))
+	run, err := task.ShouldRun(account, handler)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload for one-time task '%s': %w", task.Name, err)
+		return fmt.Errorf("failed to evaluate the condition of one-time task '%s': %w", task.Name, err)
 	}
-	response, err := handler.Post(handler.GetBaseURL(), payloadBytes)
+	if !run {
+		log.Info("Skipping one-time task, condition not met", zap.Stringer("condition", task.Condition))
+		return nil
+	}
+	log.Info("Running one-time task", zap.Any("payload", task.Payload))
+	payload, err := task.RenderPayload(account, handler)
+	if err != nil {
+		return fmt.Errorf("failed to render payload for one-time task '%s': %w", task.Name, err)
+	}
+	response, err := task.request(handler, account, http.MethodPost, handler.GetBaseURL(), payload, nil)
 	if err != nil {
 		return fmt.Errorf("failed to execute one-time task '%s' for account %s: %w", task.Name, account.TelegramData.TelegramId, err)
 	}
-	fmt.Printf("Successfully executed one-time task '%s' for account %s with response: %v\n", task.Name, account.TelegramData.TelegramId, response)
+	log.Info("Successfully executed one-time task", zap.ByteString("response", response))
+	if err := task.ExtractState(account, handler, response); err != nil {
+		log.Warn("Failed to extract state from the response", zap.Error(err))
+	}
 	return nil
 }
@@ -0,0 +1,297 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/expr"
+	"github.com/nexus-telegram/NexusSDK/jsonpath"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"go.uber.org/zap"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// embeddedExpression matches the expressions embedded in the strings of a step, e.g.
+// "Bearer {{vars.token}}".
+var embeddedExpression = regexp.MustCompile(`\{\{(.*?)\}\}`)
+
+// Step is one request of a PipelineTask.
+//
+// # Fields:
+//   - Name: The name of the step, used in logs and errors.
+//   - Method: "GET" or "POST". Defaults to "POST".
+//   - URL: The URL of the request. URLs starting with "/" are relative to the handler's base URL,
+//     and URLs starting with "@name" to its base URL of that name (see ResolveURL).
+//     Empty sends the request to the base URL.
+//   - Payload: The JSON body of POST requests.
+//   - Headers: The headers of the request, merged over the headers of the task.
+//   - Condition: The condition the step runs under. Nil runs the step always.
+//   - Set: The variables extracted from the response of the step.
+//   - Extract: The state keys set from the response of the step (see BaseTask.ExtractState).
+//
+// Strings of the URL, payload, and headers may embed expressions between "{{" and "}}"; a
+// string made of a single embedded expression takes its value.
+type Step struct {
+	Name      string                    // Name of the step
+	Method    string                    // HTTP method of the request
+	URL       string                    // URL of the request
+	Payload   map[string]interface{}    // JSON body of POST requests
+	Headers   map[string]string         // Headers of the request
+	Condition *expr.Program             // Optional condition the step runs under
+	Set       []expr.Assignment         // Variables extracted from the response
+	Extract   map[string]*jsonpath.Path // State keys set from the response
+}
+
+// PipelineTask represents a task sending a sequence of requests, each step reading the
+// responses of the previous ones, e.g. to log in, read a token, and claim with it.
+//
+// # Variables:
+//   - account, profile, payload: The account, its profile, and the payload of the task (see
+//     ShouldRun).
+//   - vars: The variables set by the previous steps.
+//   - resp: The decoded response of the last step that ran, or its body as a string when it
+//     cannot be decoded. Null before the first request.
+//
+// # Fields:
+//   - Steps: The requests of the task, in order.
+//   - Headers: The headers of every request of the task.
+//
+// The Extract paths of the task apply to the response of the last step that ran, those of a
+// step to the response of the step.
+//
+// # Example:
+//
+//	login, _ := expr.CompileAssignment("token = resp.data.accessToken")
+//	task := tasks.NewPipelineTask("Claim", []tasks.Step{
+//		{Name: "login", URL: "/auth", Payload: map[string]interface{}{"init_data": `{{account["game-data"]}}`}, Set: []expr.Assignment{login}},
+//		{Name: "claim", URL: "/claim", Headers: map[string]string{"Authorization": "Bearer {{vars.token}}"}},
+//	})
+type PipelineTask struct {
+	BaseTask
+	Steps   []Step            // Requests of the task
+	Headers map[string]string // Headers of every request of the task
+}
+
+// NewPipelineTask creates a new pipeline task, running once per account.
+func NewPipelineTask(name string, steps []Step) *PipelineTask {
+	return &PipelineTask{
+		BaseTask: BaseTask{Name: name},
+		Steps:    steps,
+	}
+}
+
+// Run executes the steps of the task for a given account.
+func (task *PipelineTask) Run(account types.Account, handler Handler) error {
+	log := handler.GetLogger().With(zap.String("task", task.Name))
+	run, err := task.ShouldRun(account, handler)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate the condition of pipeline task '%s': %w", task.Name, err)
+	}
+	if !run {
+		log.Info("Skipping pipeline task, condition not met", zap.Stringer("condition", task.Condition))
+		return nil
+	}
+	vars := make(map[string]interface{})
+	env := ExpressionEnv(account, handler)
+	env["payload"] = jsonValue(task.Payload)
+	env["vars"] = vars
+	env["resp"] = nil
+	var last []byte
+	for i, step := range task.Steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("step %d", i+1)
+		}
+		if step.Condition != nil {
+			run, err := step.Condition.Bool(env)
+			if err != nil {
+				return fmt.Errorf("pipeline task '%s', %s: %w", task.Name, name, err)
+			}
+			if !run {
+				log.Info("Skipping pipeline step, condition not met", zap.String("step", name))
+				continue
+			}
+		}
+		body, err := task.send(handler, account, step, env)
+		if err != nil {
+			return fmt.Errorf("failed to execute pipeline task '%s', %s, for account %s: %w", task.Name, name, account.TelegramData.TelegramId, err)
+		}
+		var resp interface{}
+		if err := task.Decode(handler, body, &resp); err != nil {
+			resp = string(body)
+		}
+		env["resp"] = resp
+		last = body
+		for _, assignment := range step.Set {
+			if err := assignment.Apply(env, vars); err != nil {
+				return fmt.Errorf("pipeline task '%s', %s: %w", task.Name, name, err)
+			}
+		}
+		log.Info("Executed pipeline step", zap.String("step", name), zap.ByteString("response", body))
+		if err := extractState(&task.BaseTask, step.Extract, account, handler, body); err != nil {
+			log.Warn("Failed to extract state from the response", zap.String("step", name), zap.Error(err))
+		}
+	}
+	if len(task.Extract) > 0 && last != nil {
+		if err := task.ExtractState(account, handler, last); err != nil {
+			log.Warn("Failed to extract state from the response", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// send sends the request of a step for an account, with its embedded expressions evaluated.
+func (task *PipelineTask) send(handler Handler, account types.Account, step Step, env expr.Env) ([]byte, error) {
+	rendered, err := render(step.URL, env)
+	if err != nil {
+		return nil, err
+	}
+	url, err := ResolveURL(handler, fmt.Sprint(rendered))
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(task.Headers)+len(step.Headers))
+	for key, value := range task.Headers {
+		headers[key] = value
+	}
+	for key, value := range step.Headers {
+		headers[key] = value
+	}
+	for key, value := range headers {
+		rendered, err := render(value, env)
+		if err != nil {
+			return nil, err
+		}
+		headers[key] = fmt.Sprint(rendered)
+	}
+	switch strings.ToUpper(step.Method) {
+	case http.MethodGet:
+		return task.request(handler, account, http.MethodGet, url, nil, headers)
+	case "", http.MethodPost:
+		payload, err := renderValue(step.Payload, env)
+		if err != nil {
+			return nil, err
+		}
+		return task.request(handler, account, http.MethodPost, url, payload, headers)
+	}
+	return nil, fmt.Errorf("unsupported method %q", step.Method)
+}
+
+// RecurrentPipelineTask represents a pipeline task that runs repeatedly at a set interval.
+type RecurrentPipelineTask struct {
+	PipelineTask
+	Interval time.Duration // Interval between executions
+}
+
+// NewRecurrentPipelineTask creates a new recurrent pipeline task.
+func NewRecurrentPipelineTask(name string, steps []Step, interval time.Duration) *RecurrentPipelineTask {
+	return &RecurrentPipelineTask{
+		PipelineTask: *NewPipelineTask(name, steps),
+		Interval:     interval,
+	}
+}
+
+// Next returns the time of the next execution, like RecurrentTask.Next.
+func (task *RecurrentPipelineTask) Next(last, now time.Time) time.Time {
+	if last.IsZero() {
+		return now.Add(task.Interval)
+	}
+	return last.Add(task.Interval)
+}
+
+// render evaluates the expressions embedded in a string. A string made of a single embedded
+// expression is replaced by its value.
+func render(text string, env expr.Env) (interface{}, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	if match := embeddedExpression.FindStringSubmatchIndex(text); match != nil && match[0] == 0 && match[1] == len(text) {
+		return expr.Eval(strings.TrimSpace(text[match[2]:match[3]]), env)
+	}
+	var evalErr error
+	rendered := embeddedExpression.ReplaceAllStringFunc(text, func(embedded string) string {
+		if evalErr != nil {
+			return ""
+		}
+		value, err := expr.Eval(strings.TrimSpace(embedded[2:len(embedded)-2]), env)
+		if err != nil {
+			evalErr = err
+			return ""
+		}
+		if value == nil {
+			return ""
+		}
+		if str, ok := value.(string); ok {
+			return str
+		}
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	})
+	return rendered, evalErr
+}
+
+// renderValue returns a copy of a payload value with the expressions of its strings evaluated.
+func renderValue(value interface{}, env expr.Env) (interface{}, error) {
+	switch typed := value.(type) {
+	case string:
+		return render(typed, env)
+	case map[string]interface{}:
+		if typed == nil {
+			return nil, nil
+		}
+		rendered := make(map[string]interface{}, len(typed))
+		for key, nested := range typed {
+			value, err := renderValue(nested, env)
+			if err != nil {
+				return nil, err
+			}
+			rendered[key] = value
+		}
+		return rendered, nil
+	case []interface{}:
+		rendered := make([]interface{}, len(typed))
+		for i, nested := range typed {
+			value, err := renderValue(nested, env)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = value
+		}
+		return rendered, nil
+	}
+	return value, nil
+}
+
+// checkEmbedded compiles the expressions embedded in the strings of a value, so that invalid
+// expressions are reported when a task is created rather than when it runs.
+func checkEmbedded(value interface{}) error {
+	switch typed := value.(type) {
+	case string:
+		for _, match := range embeddedExpression.FindAllStringSubmatch(typed, -1) {
+			if _, err := expr.Compile(strings.TrimSpace(match[1])); err != nil {
+				return fmt.Errorf("invalid expression in %q: %w", typed, err)
+			}
+		}
+	case map[string]interface{}:
+		for _, nested := range typed {
+			if err := checkEmbedded(nested); err != nil {
+				return err
+			}
+		}
+	case map[string]string:
+		for _, nested := range typed {
+			if err := checkEmbedded(nested); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, nested := range typed {
+			if err := checkEmbedded(nested); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
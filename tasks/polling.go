@@ -0,0 +1,242 @@
+package tasks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/jsonpath"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"go.uber.org/zap"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Change is a change of the value watched by a PollingTask.
+//
+// # Fields:
+//   - Previous: The value seen by the previous poll of the account, nil on the first poll or
+//     after a restart.
+//   - Current: The value seen now.
+//   - First: Whether no value was seen before for the account.
+type Change struct {
+	Previous interface{}
+	Current  interface{}
+	First    bool
+}
+
+// PollingTask represents a task polling an endpoint with GET requests at a set interval, and
+// running its actions only when the response changed since the last poll of the account, e.g.
+// to claim the reward of an event as soon as it starts without sending a claim every interval.
+//
+// The value compared is the decoded response, or the part of it selected by Watch. The
+// fingerprint of the last value seen is kept in the state of the account (see StoreState)
+// under "polls.<task name>", so a restart with persisted profiles does not trigger the actions
+// again. It is only updated once the actions succeeded. Until then, the progress of the change
+// is kept under "poll_progress.<task name>": when an action fails, the task fails, and the
+// retry of the execution or the next poll runs OnChange and the actions again for the same
+// value, except those that already succeeded for it, so a claim is not sent twice. A value
+// changing again before they all succeeded starts over with every action.
+//
+// # Fields:
+//   - URL: The URL polled, resolved with ResolveURL: URLs starting with "/" are relative to
+//     the handler's base URL, "@name" to its base URL of that name; empty polls the base URL.
+//   - Headers: The headers of the requests.
+//   - Interval: The interval between polls.
+//   - Watch: The part of the response compared. Nil compares the whole response.
+//   - TriggerOnFirst: Whether the first value seen for an account counts as a change. By
+//     default, it is only recorded.
+//   - OnChange: The optional hook called on each change, before the actions.
+//   - Actions: The tasks run for the account on each change, in order.
+//
+// # Example:
+//
+//	claim := tasks.NewOneTimeTask("ClaimEvent", map[string]interface{}{"action": "claim_event"})
+//	poll := tasks.NewPollingTask("EventStart", "/events/current", time.Minute, claim)
+//	poll.Watch = jsonpath.MustCompile("$.event.id")
+//	gameHandler.AddTask(poll)
+type PollingTask struct {
+	BaseTask
+	URL            string                                                            // URL polled
+	Headers        map[string]string                                                 // Headers of the requests
+	Interval       time.Duration                                                     // Interval between polls
+	Watch          *jsonpath.Path                                                    // Optional part of the response compared
+	TriggerOnFirst bool                                                              // Whether the first value seen is a change
+	OnChange       func(account types.Account, handler Handler, change Change) error // Optional hook called on each change
+	Actions        []Task                                                            // Tasks run on each change
+	mu             sync.Mutex                                                        // Guards last
+	last           map[string]interface{}                                            // Last value seen, keyed by Telegram ID
+}
+
+// NewPollingTask creates a new polling task running actions when the response of url changes.
+func NewPollingTask(name, url string, interval time.Duration, actions ...Task) *PollingTask {
+	return &PollingTask{
+		BaseTask: BaseTask{Name: name},
+		URL:      url,
+		Interval: interval,
+		Actions:  actions,
+	}
+}
+
+// Next returns the time of the next poll, like RecurrentTask.Next.
+func (task *PollingTask) Next(last, now time.Time) time.Time {
+	if last.IsZero() {
+		return now.Add(task.Interval)
+	}
+	return last.Add(task.Interval)
+}
+
+// Run polls the endpoint of the task for a given account, and runs the actions if the watched
+// value changed.
+func (task *PollingTask) Run(account types.Account, handler Handler) error {
+	log := handler.GetLogger().With(zap.String("task", task.Name))
+	run, err := task.ShouldRun(account, handler)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate the condition of polling task '%s': %w", task.Name, err)
+	}
+	if !run {
+		log.Info("Skipping polling task, condition not met", zap.Stringer("condition", task.Condition))
+		return nil
+	}
+	url, err := ResolveURL(handler, task.URL)
+	if err != nil {
+		return fmt.Errorf("failed to poll for task '%s' for account %s: %w", task.Name, account.TelegramData.TelegramId, err)
+	}
+	response, err := task.request(handler, account, http.MethodGet, url, nil, task.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to poll for task '%s' for account %s: %w", task.Name, account.TelegramData.TelegramId, err)
+	}
+	if err := task.ExtractState(account, handler, response); err != nil {
+		log.Warn("Failed to extract state from the response", zap.Error(err))
+	}
+	var current interface{}
+	if err := task.Decode(handler, response, &current); err != nil {
+		return fmt.Errorf("failed to decode the response of polling task '%s': %w", task.Name, err)
+	}
+	if task.Watch != nil {
+		current, _ = task.Watch.Get(current)
+	}
+	fingerprint, err := fingerprintOf(current)
+	if err != nil {
+		return fmt.Errorf("failed to compare the response of polling task '%s': %w", task.Name, err)
+	}
+	id := account.TelegramData.TelegramId
+	seen := task.seen(handler, id)
+	if seen == fingerprint {
+		log.Debug("Polled value unchanged")
+		return nil
+	}
+	task.mu.Lock()
+	change := Change{Previous: task.last[id], Current: current, First: seen == ""}
+	task.mu.Unlock()
+	if change.First && !task.TriggerOnFirst {
+		log.Info("Recorded first polled value")
+		task.record(handler, id, current, fingerprint)
+		return nil
+	}
+	log.Info("Polled value changed, running actions", zap.Any("value", current))
+	done := task.progress(handler, id, fingerprint)
+	if task.OnChange != nil && !done[progressHook] {
+		if err := task.OnChange(account, handler, change); err != nil {
+			return fmt.Errorf("change hook of polling task '%s' failed: %w", task.Name, err)
+		}
+		done[progressHook] = true
+		task.storeProgress(handler, id, fingerprint, done)
+	}
+	var errs []error
+	for i, action := range task.Actions {
+		step := strconv.Itoa(i)
+		if done[step] {
+			continue
+		}
+		if err := action.Run(account, handler); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		done[step] = true
+		task.storeProgress(handler, id, fingerprint, done)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("actions of polling task '%s' failed: %w", task.Name, err)
+	}
+	task.record(handler, id, current, fingerprint)
+	return nil
+}
+
+// progressHook is the step of OnChange in the progress of a change.
+const progressHook = "hook"
+
+// progress returns the steps of the change to fingerprint that already succeeded for an
+// account: progressHook for OnChange, and the indexes of the actions.
+func (task *PollingTask) progress(store ProfileStore, telegramId, fingerprint string) map[string]bool {
+	state, _ := jsonValue(loadedProfile(store, telegramId)).(map[string]interface{})
+	progress, _ := state["poll_progress"].(map[string]interface{})
+	change, _ := progress[task.stateKey()].(map[string]interface{})
+	done := make(map[string]bool)
+	if change["fingerprint"] != fingerprint {
+		return done
+	}
+	steps, _ := change["done"].([]interface{})
+	for _, step := range steps {
+		if step, ok := step.(string); ok {
+			done[step] = true
+		}
+	}
+	return done
+}
+
+// storeProgress records the steps of the change to fingerprint that succeeded for an account.
+func (task *PollingTask) storeProgress(store ProfileStore, telegramId, fingerprint string, done map[string]bool) {
+	steps := make([]string, 0, len(done))
+	for step := range done {
+		steps = append(steps, step)
+	}
+	sort.Strings(steps)
+	StoreState(store, telegramId, map[string]interface{}{
+		"poll_progress." + task.stateKey(): map[string]interface{}{"fingerprint": fingerprint, "done": steps},
+	})
+}
+
+// seen returns the fingerprint of the last value seen for an account, empty if none.
+func (task *PollingTask) seen(store ProfileStore, telegramId string) string {
+	state, _ := jsonValue(loadedProfile(store, telegramId)).(map[string]interface{})
+	polls, _ := state["polls"].(map[string]interface{})
+	fingerprint, _ := polls[task.stateKey()].(string)
+	return fingerprint
+}
+
+// record records the value seen for an account.
+func (task *PollingTask) record(store ProfileStore, telegramId string, value interface{}, fingerprint string) {
+	task.mu.Lock()
+	if task.last == nil {
+		task.last = make(map[string]interface{})
+	}
+	task.last[telegramId] = value
+	task.mu.Unlock()
+	StoreState(store, telegramId, map[string]interface{}{
+		"polls." + task.stateKey():         fingerprint,
+		"poll_progress." + task.stateKey(): nil,
+	})
+}
+
+// stateKey returns the key of the task in the "polls" state object, the task name with its
+// dots replaced, since dots separate the levels of state keys.
+func (task *PollingTask) stateKey() string {
+	return strings.ReplaceAll(task.Name, ".", "_")
+}
+
+// fingerprintOf returns the SHA-256 of the JSON encoding of value. Object members are encoded
+// in key order, so equal values have equal fingerprints.
+func fingerprintOf(value interface{}) (string, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
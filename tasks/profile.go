@@ -0,0 +1,92 @@
+package tasks
+
+import (
+	"encoding/json"
+)
+
+// ProfileStore keeps one game-specific profile per account.
+//
+// A GameHandler manages a single game, so the profiles it stores are implicitly keyed by
+// game and account. Use GetProfile, SetProfile, and UpdateProfile for typed access.
+type ProfileStore interface {
+	LoadProfile(telegramId string) (interface{}, bool)
+	StoreProfile(telegramId string, profile interface{})
+}
+
+// ProfileLocker is implemented by profile stores serializing the updates of a profile, such as
+// GameHandler and the handler passed to Task.Run. UpdateProfile holds the lock of the account
+// while it reads, updates, and stores its profile, so that concurrent updates of one account,
+// e.g. by two tasks or by a task and a webhook, are not lost.
+type ProfileLocker interface {
+	LockProfile(telegramId string) (unlock func())
+}
+
+// GetProfile returns the typed profile stored for an account.
+//
+// The boolean result is false if no profile is stored for the account or if the stored
+// profile has a different type than T. Profiles restored from a state export (see
+// handler.GameHandler.ImportState) are stored as JSON and decoded into T on first access.
+//
+// # Example:
+//
+//	type HamsterProfile struct {
+//		Level  int
+//		Energy int
+//	}
+//
+//	profile, ok := tasks.GetProfile[HamsterProfile](handler, account.TelegramId)
+//	if ok && profile.Energy < 100 {
+//		return nil
+//	}
+func GetProfile[T any](store ProfileStore, telegramId string) (T, bool) {
+	var zero T
+	value, ok := store.LoadProfile(telegramId)
+	if !ok {
+		return zero, false
+	}
+	if raw, ok := value.(json.RawMessage); ok {
+		var profile T
+		if err := json.Unmarshal(raw, &profile); err != nil {
+			return zero, false
+		}
+		store.StoreProfile(telegramId, profile)
+		return profile, true
+	}
+	profile, ok := value.(T)
+	if !ok {
+		return zero, false
+	}
+	return profile, true
+}
+
+// SetProfile stores the typed profile of an account, replacing any previous profile.
+//
+// # Example:
+//
+//	tasks.SetProfile(handler, account.TelegramId, HamsterProfile{Level: 7, Energy: 1500})
+func SetProfile[T any](store ProfileStore, telegramId string, profile T) {
+	store.StoreProfile(telegramId, profile)
+}
+
+// UpdateProfile applies update to the account's current profile (or the zero value of T if
+// none is stored) and stores the result.
+//
+// The update is atomic when the store implements ProfileLocker; update must then not update
+// the profile of the same account itself. With other stores, concurrent updates of one
+// account may overwrite each other.
+//
+// # Example:
+//
+//	tasks.UpdateProfile(handler, account.TelegramId, func(profile HamsterProfile) HamsterProfile {
+//		profile.Energy -= 100
+//		return profile
+//	})
+func UpdateProfile[T any](store ProfileStore, telegramId string, update func(profile T) T) T {
+	if locker, ok := store.(ProfileLocker); ok {
+		defer locker.LockProfile(telegramId)()
+	}
+	profile, _ := GetProfile[T](store, telegramId)
+	profile = update(profile)
+	store.StoreProfile(telegramId, profile)
+	return profile
+}
@@ -1,9 +1,10 @@
 package tasks
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/nexus-telegram/NexusSDK/types"
+	"go.uber.org/zap"
+	"net/http"
 	"time"
 )
 
@@ -21,18 +22,38 @@ func NewRecurrentTask(name string, payload map[string]interface{}, interval time
 	}
 }
 
+// Next returns the time of the next execution: one interval after the last execution, or
+// after now for the first one.
+func (task *RecurrentTask) Next(last, now time.Time) time.Time {
+	if last.IsZero() {
+		return now.Add(task.Interval)
+	}
+	return last.Add(task.Interval)
+}
+
 // Run executes the task for a given account.
 func (task *RecurrentTask) Run(account types.Account, handler Handler) error {
-	fmt.Printf("Running recurrent task '%s' for account %s with payload %v\n", task.Name, account.TelegramData.TelegramId, task.Payload)
-	payload := task.Payload
-	payloadBytes, err := json.Marshal(payload)
+	log := handler.GetLogger().With(zap.String("task", task.Name))
+	run, err := task.ShouldRun(account, handler)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload for recurrent task '%s': %w", task.Name, err)
+		return fmt.Errorf("failed to evaluate the condition of recurrent task '%s': %w", task.Name, err)
+	}
+	if !run {
+		log.Info("Skipping recurrent task, condition not met", zap.Stringer("condition", task.Condition))
+		return nil
 	}
-	response, err := handler.Post(handler.GetBaseURL(), payloadBytes)
+	log.Info("Running recurrent task", zap.Any("payload", task.Payload))
+	payload, err := task.RenderPayload(account, handler)
+	if err != nil {
+		return fmt.Errorf("failed to render payload for recurrent task '%s': %w", task.Name, err)
+	}
+	response, err := task.request(handler, account, http.MethodPost, handler.GetBaseURL(), payload, nil)
 	if err != nil {
 		return fmt.Errorf("failed to execute recurrent task '%s' for account %s: %w", task.Name, account.TelegramData.TelegramId, err)
 	}
-	fmt.Printf("Successfully executed recurrent task '%s' for account %s with response: %v\n", task.Name, account.TelegramData.TelegramId, response)
+	log.Info("Successfully executed recurrent task", zap.ByteString("response", response))
+	if err := task.ExtractState(account, handler, response); err != nil {
+		log.Warn("Failed to extract state from the response", zap.Error(err))
+	}
 	return nil
 }
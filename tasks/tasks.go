@@ -1,7 +1,12 @@
 package tasks
 
 import (
+	"github.com/nexus-telegram/NexusSDK/codec"
+	"github.com/nexus-telegram/NexusSDK/expr"
+	"github.com/nexus-telegram/NexusSDK/jsonpath"
 	"github.com/nexus-telegram/NexusSDK/types"
+	"go.uber.org/zap"
+	"time"
 )
 
 // Task is the interface implemented by all tasks (one-time and recurrent).
@@ -18,11 +23,29 @@ type Task interface {
 	Run(account types.Account, handler Handler) error // Execute the task for a given account
 }
 
+// Scheduled is implemented by tasks that run repeatedly, such as RecurrentTask and DailyTask.
+//
+// The scheduler calls Next to know when to run the task next. Tasks that do not implement
+// Scheduled run once per account.
+//
+// # Methods:
+//   - Next(last, now time.Time) time.Time: Returns the time of the next execution, given the
+//     time of the last execution (zero before the first one) and the current time.
+type Scheduled interface {
+	Next(last, now time.Time) time.Time
+}
+
 // Handler is an interface that abstracts the GameHandler functionality.
+//
+// The handler passed to Task.Run is scoped to the account being processed, so the
+// logger returned by GetLogger already carries the game, account and attempt fields.
 type Handler interface {
 	Post(url string, payload []byte) ([]byte, error)
+	Get(url string) ([]byte, error)
 	GetBaseURL() string
 	GetAccounts() []types.Account
+	GetLogger() *zap.Logger
+	ProfileStore
 }
 
 // BaseTask provides shared functionality for all tasks.
@@ -33,7 +56,100 @@ type Handler interface {
 // # Fields:
 //   - Name: The name of the task.
 //   - Payload: A map containing the task's payload data.
+//   - Decoder: The decoder of the task's responses (see Decode). Defaults to the handler's.
+//   - Gzip: Whether the request bodies sent by the task are gzip-compressed (see Compressed).
+//   - NonIdempotent: Whether the task must not run again for an account on the day it
+//     succeeded with the same payload, e.g. a purchase (see ReplayProtected).
+//   - CatchUp: What the scheduler does with the executions missed during a downtime (see
+//     CatchingUp). Defaults to CatchUpOnce.
+//   - Condition: The condition the task runs under (see ShouldRun). Nil runs the task always.
+//   - Extract: The state keys set from the task's responses, with the paths of their values
+//     (see ExtractState).
+//   - Timeout: The time budget of an execution, retries and refreshes included (see
+//     TimeLimited). Zero is unlimited.
+//   - AuthLocation: Where the requests of the task carry the game data of the account (see
+//     Authorize). Defaults to AuthNone.
+//   - AuthKey: The name of the query parameter, header, or payload field of the game data.
+//     Defaults to the default name of the location (e.g., DefaultAuthParam).
+//   - Backoff: How the interval of the task is stretched while it keeps failing for an account
+//     (see BackingOff). Nil uses the backoff of the handler.
 type BaseTask struct {
-	Name    string                 // Name of the task
-	Payload map[string]interface{} // Payload for the task
+	Name          string                    // Name of the task
+	Payload       map[string]interface{}    // Payload for the task
+	Decoder       codec.Decoder             // Optional decoder of the task's responses, overriding the handler's
+	Gzip          bool                      // Whether the task's request bodies are gzip-compressed
+	NonIdempotent bool                      // Whether the task is guarded against running twice a day
+	CatchUp       CatchUpPolicy             // Policy of the executions missed during a downtime
+	Condition     *expr.Program             // Optional condition the task runs under
+	Extract       map[string]*jsonpath.Path // State keys set from the task's responses
+	Timeout       time.Duration             // Optional time budget of an execution
+	AuthLocation  AuthLocation              // Where the requests carry the game data
+	AuthKey       string                    // Name of the game data parameter, header, or field
+	Backoff       *IntervalBackoff          // Optional interval backoff while the task keeps failing
+}
+
+// GetName returns the name of the task.
+func (task *BaseTask) GetName() string {
+	return task.Name
+}
+
+// GetPayload returns the payload of the task.
+func (task *BaseTask) GetPayload() map[string]interface{} {
+	return task.Payload
+}
+
+// ReplayProtected reports whether the task is non-idempotent.
+func (task *BaseTask) ReplayProtected() bool {
+	return task.NonIdempotent
+}
+
+// ReplayProtected is implemented by tasks that may be non-idempotent, such as every task
+// embedding BaseTask. When ReplayProtected returns true, the handler records every successful
+// execution and refuses to run the task again for the same account, payload, and day, so a
+// crash-recovery retry cannot send a purchase twice.
+type ReplayProtected interface {
+	ReplayProtected() bool
+}
+
+// CompressRequests reports whether the task's request bodies are gzip-compressed.
+func (task *BaseTask) CompressRequests() bool {
+	return task.Gzip
+}
+
+// Compressed is implemented by tasks whose request bodies may be gzip-compressed, such as
+// every task embedding BaseTask. The handler compresses the bodies of the requests sent
+// during Run when CompressRequests returns true.
+type Compressed interface {
+	CompressRequests() bool
+}
+
+// DecoderProvider is implemented by handlers with a response decoder, such as GameHandler.
+type DecoderProvider interface {
+	GetDecoder() codec.Decoder
+}
+
+// Decode decodes a response body into v with the task's Decoder, falling back to the
+// handler's decoder (see DecoderProvider) and then to JSON.
+//
+// # Example:
+//
+//	body, err := handler.Post(handler.GetBaseURL()+"/sync", payload)
+//	if err != nil {
+//		return err
+//	}
+//	var state GameState
+//	if err := task.Decode(handler, body, &state); err != nil {
+//		return err
+//	}
+func (task *BaseTask) Decode(handler Handler, body []byte, v interface{}) error {
+	decoder := task.Decoder
+	if decoder == nil {
+		if provider, ok := handler.(DecoderProvider); ok {
+			decoder = provider.GetDecoder()
+		}
+	}
+	if decoder == nil {
+		decoder = codec.JSON{}
+	}
+	return decoder.Decode(body, v)
 }
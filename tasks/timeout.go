@@ -0,0 +1,44 @@
+package tasks
+
+import (
+	"context"
+	"time"
+)
+
+// ExecutionTimeout returns the time budget of an execution of the task, zero when unlimited.
+func (task *BaseTask) ExecutionTimeout() time.Duration {
+	return task.Timeout
+}
+
+// TimeLimited is implemented by tasks with a time budget, such as every task embedding
+// BaseTask. The handler bounds each execution of the task, retries and game data refreshes
+// included, by ExecutionTimeout: the requests of the task carry the deadline, and no attempt
+// starts once it has passed. A zero timeout is unlimited.
+type TimeLimited interface {
+	ExecutionTimeout() time.Duration
+}
+
+// ContextProvider is implemented by handlers scoped to a task execution, such as the handler
+// passed to Task.Run by GameHandler. The context carries the deadline of the execution (see
+// TimeLimited).
+type ContextProvider interface {
+	Context() context.Context
+}
+
+// Context returns the context of the execution handler belongs to, or context.Background when
+// handler does not provide one (see ContextProvider). Tasks waiting on their own, e.g. between
+// two requests, should stop when it is done.
+//
+// # Example:
+//
+//	select {
+//	case <-time.After(5 * time.Second):
+//	case <-tasks.Context(handler).Done():
+//		return tasks.Context(handler).Err()
+//	}
+func Context(handler Handler) context.Context {
+	if provider, ok := handler.(ContextProvider); ok {
+		return provider.Context()
+	}
+	return context.Background()
+}
@@ -0,0 +1,67 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownBaseURL is returned by ResolveURL for a URL referencing a base URL the handler
+// does not have.
+var ErrUnknownBaseURL = errors.New("unknown base URL")
+
+// BaseURLProvider is implemented by handlers with named base URLs, such as GameHandler, for
+// games whose endpoints are split across several hosts (e.g., "api", "cdn", and "auth").
+//
+// # Methods:
+//   - GetNamedBaseURL(name string) (string, bool): Returns the base URL of a name, and whether
+//     the handler has one. The empty name is the handler's base URL.
+type BaseURLProvider interface {
+	GetNamedBaseURL(name string) (string, bool)
+}
+
+// ResolveURL returns the absolute URL of a request sent through handler:
+//   - An empty URL is the handler's base URL.
+//   - A URL starting with "/" is relative to the handler's base URL.
+//   - A URL starting with "@" and the name of a base URL of the handler (see
+//     BaseURLProvider), e.g. "@cdn/config.json" or "@auth", is relative to that base URL.
+//   - Any other URL is returned unchanged.
+//
+// # Returns:
+//   - string: The absolute URL.
+//   - error: An error wrapping ErrUnknownBaseURL if the URL references a base URL the handler
+//     does not have.
+//
+// # Example:
+//
+//	url, err := tasks.ResolveURL(handler, "@auth/token")
+//	if err != nil {
+//		return err
+//	}
+//	body, err := handler.Post(url, payload)
+func ResolveURL(handler Handler, url string) (string, error) {
+	switch {
+	case url == "":
+		return handler.GetBaseURL(), nil
+	case strings.HasPrefix(url, "/"):
+		return strings.TrimSuffix(handler.GetBaseURL(), "/") + url, nil
+	case !strings.HasPrefix(url, "@"):
+		return url, nil
+	}
+	name, path := url[1:], ""
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		name, path = name[:i], name[i:]
+	}
+	provider, ok := handler.(BaseURLProvider)
+	if !ok {
+		return "", fmt.Errorf("%w %q: the handler has no named base URLs", ErrUnknownBaseURL, name)
+	}
+	base, ok := provider.GetNamedBaseURL(name)
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownBaseURL, name)
+	}
+	if path == "" {
+		return base, nil
+	}
+	return strings.TrimSuffix(base, "/") + path, nil
+}
@@ -0,0 +1,217 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/expr"
+	"github.com/nexus-telegram/NexusSDK/jsonpath"
+	"github.com/nexus-telegram/NexusSDK/types"
+	"github.com/nexus-telegram/NexusSDK/utils/retry"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrWithdrawalUnconfirmed is returned by WithdrawalTask when the confirmation of a withdrawal
+// does not satisfy the task's Confirmed condition.
+var ErrWithdrawalUnconfirmed = errors.New("withdrawal not confirmed")
+
+// WithdrawalTask represents a task withdrawing the balance of an account to the account's
+// wallet (see types.Wallet) once it reaches a threshold: it checks the balance at a set
+// interval, requests a withdrawal when the balance is high enough, and optionally confirms it.
+//
+// The payloads are rendered like those of pipeline steps (see PipelineTask), with the
+// variables of ShouldRun and:
+//   - balance: The balance read from the balance response.
+//   - wallet: The wallet of the account (address, network, and memo).
+//   - withdrawal: The decoded response of the withdrawal request, for the confirmation.
+//
+// Once the withdrawal request was sent, the errors of the task are permanent, so that the
+// handler does not retry the execution and request the withdrawal twice. New tasks are
+// non-idempotent (see ReplayProtected): they withdraw at most once a day per account, clear
+// NonIdempotent to lift it.
+//
+// # Fields:
+//   - BalanceURL: The URL of the balance, requested with GET. URLs are resolved with
+//     ResolveURL: starting with "/", they are relative to the handler's base URL, and with
+//     "@name" to its base URL of that name; empty requests the base URL.
+//   - Balance: The path of the balance in the balance response, a number or a numeric string.
+//   - Threshold: The minimum balance withdrawn.
+//   - Network: The network of the wallets withdrawn to, "ton" when empty. Accounts without a
+//     wallet, or with a wallet of another network, are skipped.
+//   - RequestURL: The URL the withdrawal is requested at, with a POST of the payload.
+//   - ConfirmURL: The optional URL the withdrawal is confirmed at, with a POST of
+//     ConfirmPayload.
+//   - ConfirmPayload: The payload of the confirmation.
+//   - Confirmed: The optional condition the confirmation response, read as "resp", must
+//     satisfy (e.g., "resp.status == 'pending'").
+//   - Headers: The headers of the requests, with embedded expressions evaluated.
+//   - Interval: The interval between balance checks.
+//
+// # Example:
+//
+//	withdraw := tasks.NewWithdrawalTask("Withdraw", "/user/balance", jsonpath.MustCompile("$.balance.ton"), 1.5, "/withdraw",
+//		map[string]interface{}{"amount": "{{balance}}", "address": "{{wallet.address}}", "memo": "{{wallet.memo}}"}, 6*time.Hour)
+//	withdraw.ConfirmURL = "/withdraw/confirm"
+//	withdraw.ConfirmPayload = map[string]interface{}{"id": "{{withdrawal.id}}"}
+//	withdraw.Confirmed = expr.MustCompile("resp.ok")
+//	gameHandler.AddTask(withdraw)
+type WithdrawalTask struct {
+	BaseTask
+	BalanceURL     string                 // URL of the balance
+	Balance        *jsonpath.Path         // Path of the balance in its response
+	Threshold      float64                // Minimum balance withdrawn
+	Network        string                 // Network of the wallets withdrawn to
+	RequestURL     string                 // URL of the withdrawal request
+	ConfirmURL     string                 // Optional URL of the confirmation
+	ConfirmPayload map[string]interface{} // Payload of the confirmation
+	Confirmed      *expr.Program          // Optional condition on the confirmation response
+	Headers        map[string]string      // Headers of the requests
+	Interval       time.Duration          // Interval between balance checks
+}
+
+// NewWithdrawalTask creates a new withdrawal task requesting a withdrawal with payload at
+// requestURL when the balance at balance of the response of balanceURL reaches threshold.
+func NewWithdrawalTask(name, balanceURL string, balance *jsonpath.Path, threshold float64, requestURL string, payload map[string]interface{}, interval time.Duration) *WithdrawalTask {
+	return &WithdrawalTask{
+		BaseTask:   BaseTask{Name: name, Payload: payload, NonIdempotent: true},
+		BalanceURL: balanceURL,
+		Balance:    balance,
+		Threshold:  threshold,
+		RequestURL: requestURL,
+		Interval:   interval,
+	}
+}
+
+// Next returns the time of the next balance check, like RecurrentTask.Next.
+func (task *WithdrawalTask) Next(last, now time.Time) time.Time {
+	if last.IsZero() {
+		return now.Add(task.Interval)
+	}
+	return last.Add(task.Interval)
+}
+
+// Run checks the balance of a given account, and withdraws it if it reached the threshold.
+func (task *WithdrawalTask) Run(account types.Account, handler Handler) error {
+	log := handler.GetLogger().With(zap.String("task", task.Name))
+	run, err := task.ShouldRun(account, handler)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate the condition of withdrawal task '%s': %w", task.Name, err)
+	}
+	if !run {
+		log.Info("Skipping withdrawal task, condition not met", zap.Stringer("condition", task.Condition))
+		return nil
+	}
+	network := strings.ToLower(task.Network)
+	if network == "" {
+		network = types.WalletNetworkTON
+	}
+	if account.Wallet == nil || account.Wallet.NetworkName() != network {
+		log.Info("Skipping withdrawal task, no wallet for the network", zap.String("network", network))
+		return nil
+	}
+	env := ExpressionEnv(account, handler)
+	env["payload"] = jsonValue(task.Payload)
+	env["wallet"] = jsonValue(account.Wallet)
+	env["withdrawal"] = nil
+	body, err := task.send(handler, account, env, http.MethodGet, task.BalanceURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get the balance for withdrawal task '%s' for account %s: %w", task.Name, account.TelegramData.TelegramId, err)
+	}
+	balance, err := task.balance(handler, body)
+	if err != nil {
+		return fmt.Errorf("failed to read the balance for withdrawal task '%s': %w", task.Name, err)
+	}
+	if balance < task.Threshold {
+		log.Info("Skipping withdrawal, balance below threshold", zap.Float64("balance", balance), zap.Float64("threshold", task.Threshold))
+		return nil
+	}
+	env["balance"] = balance
+	payload, err := renderValue(task.Payload, env)
+	if err != nil {
+		return fmt.Errorf("failed to render payload for withdrawal task '%s': %w", task.Name, err)
+	}
+	log.Info("Requesting withdrawal", zap.Float64("balance", balance), zap.String("address", account.Wallet.Address))
+	body, err = task.send(handler, account, env, http.MethodPost, task.RequestURL, payload)
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("failed to request withdrawal for task '%s' for account %s: %w", task.Name, account.TelegramData.TelegramId, err))
+	}
+	if err := task.ExtractState(account, handler, body); err != nil {
+		log.Warn("Failed to extract state from the response", zap.Error(err))
+	}
+	var withdrawal interface{}
+	if err := task.Decode(handler, body, &withdrawal); err != nil {
+		withdrawal = string(body)
+	}
+	env["withdrawal"] = withdrawal
+	if task.ConfirmURL == "" {
+		log.Info("Successfully requested withdrawal", zap.ByteString("response", body))
+		return nil
+	}
+	payload, err = renderValue(task.ConfirmPayload, env)
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("failed to render confirmation payload for withdrawal task '%s': %w", task.Name, err))
+	}
+	body, err = task.send(handler, account, env, http.MethodPost, task.ConfirmURL, payload)
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("failed to confirm withdrawal for task '%s' for account %s: %w", task.Name, account.TelegramData.TelegramId, err))
+	}
+	if task.Confirmed != nil {
+		var resp interface{}
+		if err := task.Decode(handler, body, &resp); err != nil {
+			resp = string(body)
+		}
+		env["resp"] = resp
+		confirmed, err := task.Confirmed.Bool(env)
+		if err != nil {
+			return retry.Permanent(fmt.Errorf("failed to evaluate the confirmation of withdrawal task '%s': %w", task.Name, err))
+		}
+		if !confirmed {
+			return retry.Permanent(fmt.Errorf("withdrawal task '%s' for account %s: %w: %s", task.Name, account.TelegramData.TelegramId, ErrWithdrawalUnconfirmed, body))
+		}
+	}
+	log.Info("Successfully confirmed withdrawal", zap.ByteString("response", body))
+	return nil
+}
+
+// send sends a request of the task for an account, with the payload as the JSON body of POST
+// requests.
+func (task *WithdrawalTask) send(handler Handler, account types.Account, env expr.Env, method, url string, payload interface{}) ([]byte, error) {
+	url, err := ResolveURL(handler, url)
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(task.Headers))
+	for key, value := range task.Headers {
+		rendered, err := render(value, env)
+		if err != nil {
+			return nil, err
+		}
+		headers[key] = fmt.Sprint(rendered)
+	}
+	return task.request(handler, account, method, url, payload, headers)
+}
+
+// balance reads the balance from the balance response.
+func (task *WithdrawalTask) balance(handler Handler, body []byte) (float64, error) {
+	var decoded interface{}
+	if err := task.Decode(handler, body, &decoded); err != nil {
+		return 0, err
+	}
+	value := decoded
+	if task.Balance != nil {
+		var ok bool
+		if value, ok = task.Balance.Get(decoded); !ok {
+			return 0, fmt.Errorf("no balance at %s", task.Balance)
+		}
+	}
+	switch typed := value.(type) {
+	case float64:
+		return typed, nil
+	case string:
+		return strconv.ParseFloat(typed, 64)
+	}
+	return 0, fmt.Errorf("balance is not a number: %v", value)
+}
@@ -0,0 +1,172 @@
+package testutil
+
+import (
+	"github.com/nexus-telegram/NexusSDK/utils/clock"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FakeClock is a clock.Clock whose time only moves when Advance or Set is called.
+//
+// Timers and tickers created from a FakeClock fire synchronously during Advance, in deadline
+// order, so scheduling logic (intervals, daily resets, windows) can be tested without real sleeps.
+//
+// # Fields:
+//   - now: The current fake time.
+//   - waiters: The pending timers and tickers.
+//   - mu: A mutex guarding now and waiters.
+//   - changed: Signalled whenever a timer or ticker is created or stopped.
+//
+// # Example:
+//
+//	fake := testutil.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	gameHandler.SetClock(fake)
+//	go gameHandler.RunTasks()
+//	fake.BlockUntil(1)           // Wait until the scheduler armed its timer
+//	fake.Advance(5 * time.Minute) // Fire it
+type FakeClock struct {
+	now     time.Time
+	waiters []*fakeTimer
+	mu      sync.Mutex
+	changed *sync.Cond
+}
+
+// NewFakeClock creates a fake clock set to start.
+func NewFakeClock(start time.Time) *FakeClock {
+	fake := &FakeClock{now: start}
+	fake.changed = sync.NewCond(&fake.mu)
+	return fake
+}
+
+// Now returns the current fake time.
+func (fake *FakeClock) Now() time.Time {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return fake.now
+}
+
+// NewTimer creates a timer firing once the fake time reaches Now() + d.
+func (fake *FakeClock) NewTimer(d time.Duration) clock.Timer {
+	return fake.add(d, 0)
+}
+
+// NewTicker creates a ticker firing every d of fake time.
+func (fake *FakeClock) NewTicker(d time.Duration) clock.Ticker {
+	if d <= 0 {
+		panic("testutil: non-positive interval for NewTicker")
+	}
+	return fakeTicker{fake.add(d, d)}
+}
+
+// Advance moves the fake time forward by d, firing every timer and ticker that becomes due.
+func (fake *FakeClock) Advance(d time.Duration) {
+	fake.Set(fake.Now().Add(d))
+}
+
+// Set moves the fake time to t, firing every timer and ticker that becomes due.
+//
+// Like time.Ticker, a ticker that missed several periods fires only once.
+func (fake *FakeClock) Set(t time.Time) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.now = t
+	sort.Slice(fake.waiters, func(i, j int) bool {
+		return fake.waiters[i].deadline.Before(fake.waiters[j].deadline)
+	})
+	pending := fake.waiters[:0]
+	for _, waiter := range fake.waiters {
+		if waiter.deadline.After(t) {
+			pending = append(pending, waiter)
+			continue
+		}
+		select {
+		case waiter.channel <- waiter.deadline:
+		default:
+		}
+		if waiter.period > 0 {
+			for !waiter.deadline.After(t) {
+				waiter.deadline = waiter.deadline.Add(waiter.period)
+			}
+			pending = append(pending, waiter)
+		}
+	}
+	fake.waiters = pending
+	fake.changed.Broadcast()
+}
+
+// Pending returns the number of timers and tickers waiting to fire.
+func (fake *FakeClock) Pending() int {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return len(fake.waiters)
+}
+
+// BlockUntil blocks until at least n timers and tickers are waiting to fire.
+//
+// Use it to wait for the code under test to arm its timers before calling Advance.
+func (fake *FakeClock) BlockUntil(n int) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	for len(fake.waiters) < n {
+		fake.changed.Wait()
+	}
+}
+
+func (fake *FakeClock) add(d, period time.Duration) *fakeTimer {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	waiter := &fakeTimer{
+		clock:    fake,
+		deadline: fake.now.Add(d),
+		period:   period,
+		channel:  make(chan time.Time, 1),
+	}
+	if d <= 0 && period == 0 {
+		waiter.channel <- fake.now
+		return waiter
+	}
+	fake.waiters = append(fake.waiters, waiter)
+	fake.changed.Broadcast()
+	return waiter
+}
+
+func (fake *FakeClock) remove(waiter *fakeTimer) bool {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	for i, pending := range fake.waiters {
+		if pending == waiter {
+			fake.waiters = append(fake.waiters[:i], fake.waiters[i+1:]...)
+			fake.changed.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// fakeTimer is a clock.Timer of a FakeClock. Tickers are timers with a period.
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	period   time.Duration
+	channel  chan time.Time
+}
+
+func (timer *fakeTimer) C() <-chan time.Time {
+	return timer.channel
+}
+
+// Stop prevents the timer from firing, reporting whether it was still pending.
+func (timer *fakeTimer) Stop() bool {
+	return timer.clock.remove(timer)
+}
+
+// fakeTicker is a clock.Ticker of a FakeClock.
+type fakeTicker struct {
+	*fakeTimer
+}
+
+// Stop turns off the ticker.
+func (ticker fakeTicker) Stop() {
+	ticker.fakeTimer.Stop()
+}
@@ -0,0 +1,134 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fired returns the time received from channel, or the zero time when nothing was sent.
+func fired(channel <-chan time.Time) time.Time {
+	select {
+	case t := <-channel:
+		return t
+	default:
+		return time.Time{}
+	}
+}
+
+func TestFakeClockTimer(t *testing.T) {
+	tests := []struct {
+		name     string
+		delay    time.Duration
+		advances []time.Duration
+		want     time.Time
+	}{
+		{"before the deadline", time.Minute, []time.Duration{59 * time.Second}, time.Time{}},
+		{"on the deadline", time.Minute, []time.Duration{time.Minute}, start.Add(time.Minute)},
+		{"past the deadline", time.Minute, []time.Duration{time.Hour}, start.Add(time.Minute)},
+		{"in steps", time.Minute, []time.Duration{30 * time.Second, 30 * time.Second}, start.Add(time.Minute)},
+		{"zero delay", 0, nil, start},
+		{"negative delay", -time.Minute, nil, start},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := NewFakeClock(start)
+			timer := fake.NewTimer(test.delay)
+			for _, advance := range test.advances {
+				fake.Advance(advance)
+			}
+			if got := fired(timer.C()); !got.Equal(test.want) {
+				t.Errorf("timer fired at %v, want %v", got, test.want)
+			}
+			if got := fired(timer.C()); !got.IsZero() {
+				t.Errorf("timer fired twice, again at %v", got)
+			}
+			if fake.Pending() != 0 && !test.want.IsZero() {
+				t.Errorf("Pending() = %d after the timer fired, want 0", fake.Pending())
+			}
+		})
+	}
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	fake := NewFakeClock(start)
+	ticker := fake.NewTicker(time.Minute)
+	steps := []struct {
+		advance time.Duration
+		want    time.Time
+	}{
+		{30 * time.Second, time.Time{}},
+		{30 * time.Second, start.Add(time.Minute)},
+		{time.Minute, start.Add(2 * time.Minute)},
+		// Like time.Ticker, missed periods fire once and the next tick stays on the period.
+		{5*time.Minute + 30*time.Second, start.Add(3 * time.Minute)},
+		{30 * time.Second, start.Add(8 * time.Minute)},
+	}
+	for i, step := range steps {
+		fake.Advance(step.advance)
+		if got := fired(ticker.C()); !got.Equal(step.want) {
+			t.Errorf("step %d: ticker fired at %v, want %v", i, got, step.want)
+		}
+	}
+	ticker.Stop()
+	fake.Advance(time.Hour)
+	if got := fired(ticker.C()); !got.IsZero() || fake.Pending() != 0 {
+		t.Errorf("stopped ticker fired at %v with %d pending", got, fake.Pending())
+	}
+}
+
+func TestFakeClockStop(t *testing.T) {
+	fake := NewFakeClock(start)
+	first, second := fake.NewTimer(time.Minute), fake.NewTimer(2*time.Minute)
+	if fake.Pending() != 2 {
+		t.Fatalf("Pending() = %d, want 2", fake.Pending())
+	}
+	if !first.Stop() {
+		t.Error("Stop() of a pending timer = false, want true")
+	}
+	if first.Stop() {
+		t.Error("second Stop() = true, want false")
+	}
+	fake.Advance(2 * time.Minute)
+	if got := fired(first.C()); !got.IsZero() {
+		t.Errorf("stopped timer fired at %v", got)
+	}
+	if got := fired(second.C()); !got.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("timer fired at %v, want %v", got, start.Add(2*time.Minute))
+	}
+	if second.Stop() {
+		t.Error("Stop() of a fired timer = true, want false")
+	}
+}
+
+func TestFakeClockSet(t *testing.T) {
+	fake := NewFakeClock(start)
+	timer := fake.NewTimer(time.Hour)
+	later := start.Add(90 * time.Minute)
+	fake.Set(later)
+	if !fake.Now().Equal(later) {
+		t.Errorf("Now() = %v, want %v", fake.Now(), later)
+	}
+	if got := fired(timer.C()); !got.Equal(start.Add(time.Hour)) {
+		t.Errorf("timer fired at %v, want its deadline %v", got, start.Add(time.Hour))
+	}
+}
+
+func TestFakeClockBlockUntil(t *testing.T) {
+	fake := NewFakeClock(start)
+	armed := make(chan time.Time)
+	go func() {
+		armed <- <-fake.NewTimer(time.Minute).C()
+	}()
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	select {
+	case got := <-armed:
+		if !got.Equal(start.Add(time.Minute)) {
+			t.Errorf("timer fired at %v, want %v", got, start.Add(time.Minute))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer armed after BlockUntil returned never fired")
+	}
+}
@@ -1,5 +1,15 @@
 package types
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
 // Config represents the structure of the configuration file (config.json).
 // It includes the settings required to configure the application, such as
 // a proxy for HTTP requests and an API key for game authentication.
@@ -7,6 +17,52 @@ package types
 // # Fields:
 //   - Proxy: The residential proxy settings, which include IP, port, and authentication details.
 //   - APIKey: The API key used to refresh game authentication.
+//   - APIKeys: The optional additional API keys reserved for games or accounts, e.g. one per
+//     customer, with failover to the next key when one hits its quota.
+//   - APIKeyCooldown: How long, in minutes, a key that hit its quota is skipped. Defaults to 60.
+//   - Log: The optional logging configuration (sinks, encoding, and levels).
+//   - AuditLog: The optional path of the append-only audit log of all outgoing requests.
+//   - ProxyPool: The optional proxy list distributed across accounts instead of Proxy.
+//   - SequentialAccounts: Whether the tasks of one account never run concurrently.
+//   - Budget: The optional cap on requests per hour and bandwidth per day.
+//   - ErrorBudget: The optional cap on the failed requests of every account, after which the
+//     account backs off.
+//   - Election: The optional leader election between instances started with the same config.
+//   - Hedging: The optional duplication of slow GET requests through a second proxy of the pool.
+//   - TLS: The optional custom root CAs, client certificate, and certificate pins of every request.
+//   - SingleFlight: Whether identical in-flight GET requests of different accounts are sent once.
+//   - IdempotencyFile: The optional path of the file recording the non-idempotent task
+//     executions that succeeded, so they are not repeated on the same day after a restart.
+//   - JobQueue: The optional persistent queue of scheduled task executions.
+//   - History: The optional store of the recent task executions.
+//   - Storage: The optional backend shared by the persistence features whose own section is
+//     not set: handler state, scheduled jobs, idempotency records, and task history.
+//   - Network: The optional local address, interface, and IPv6 preference of outgoing connections.
+//   - AdaptiveThrottle: The optional slowdown of the task dispatch under sustained rate limiting
+//     or proxy saturation.
+//   - Parallelism: The optional sizing of the task dispatch and request rates from the number
+//     of healthy proxies and accounts.
+//   - WarmUp: The optional reduced task frequency and task subset of newly added accounts.
+//   - BanDetection: The optional recognition of ban signals, which puts accounts in cooldown.
+//   - Rotation: The order in which accounts are served in each scheduling cycle ("fixed",
+//     "shuffle", "last_success", or "least_recent"). Defaults to "fixed".
+//   - DailyReport: The optional summary of the previous day sent every day over Telegram.
+//   - Codec: The name of the decoder of the game's responses ("json", "base64", "msgpack",
+//     "protobuf", or one added with codec.Register). Defaults to "json".
+//   - SecretMemory: How the API keys and session strings are kept in memory ("plain",
+//     "encrypted", or "locked"). Defaults to "plain".
+//   - LazyAccounts: Whether the game data and session strings of the accounts are left in a
+//     local accounts file and read on use, for farms too large to hold in memory.
+//   - Sinks: The message queues the task results and events are published to.
+//   - Preconnect: The connection warm-up run before the first scheduled requests.
+//   - ContentTypes: The Content-Type and Accept headers of the requests that do not set them.
+//   - PayloadLimits: The optional limits of the request bodies sent by tasks.
+//   - BaseURLs: The named base URLs of the game's hosts other than its base URL (e.g.,
+//     {"cdn": "https://cdn.example.com"}), referenced by tasks as "@cdn/path".
+//   - Maintenance: The optional known maintenance windows of the game and the recognition of
+//     its maintenance responses, during which the game's tasks are paused.
+//   - IntervalBackoff: The optional stretching of the intervals of the scheduled tasks that
+//     keep failing for an account.
 //
 // # Example config.json:
 //
@@ -37,8 +93,797 @@ package types
 //	}
 //	fmt.Println(config.Proxy.Ip) // Output: 192.168.1.100
 type Config struct {
-	Proxy  Proxy  `json:"proxy"`   // Proxy contains the details of the HTTP/SOCKS proxy configuration.
-	APIKey string `json:"api_key"` // APIKey is the key for authenticating API requests.
+	Proxy              Proxy              `json:"proxy"`               // Proxy contains the details of the HTTP/SOCKS proxy configuration.
+	APIKey             string             `json:"api_key"`             // APIKey is the key for authenticating API requests.
+	APIKeys            []APIKeyConfig     `json:"api_keys"`            // APIKeys are additional keys reserved for games or accounts.
+	APIKeyCooldown     int                `json:"api_key_cooldown"`    // APIKeyCooldown is how long an exhausted key is skipped, in minutes.
+	Log                LogConfig          `json:"log"`                 // Log configures the library logger.
+	AuditLog           string             `json:"audit_log"`           // AuditLog is the path of the request audit trail; auditing is off when empty.
+	ProxyPool          ProxyPoolConfig    `json:"proxy_pool"`          // ProxyPool configures a list of proxies with a rotation strategy.
+	SequentialAccounts bool               `json:"sequential_accounts"` // SequentialAccounts runs the tasks of one account one at a time.
+	Budget             BudgetConfig       `json:"budget"`              // Budget caps the requests and bandwidth of the handler.
+	ErrorBudget        ErrorBudgetConfig  `json:"error_budget"`        // ErrorBudget caps the failed requests of every account.
+	Election           ElectionConfig     `json:"election"`            // Election makes only one instance run tasks.
+	Hedging            HedgingConfig      `json:"hedging"`             // Hedging duplicates slow GET requests through another proxy.
+	TLS                TLSConfig          `json:"tls"`                 // TLS configures custom root CAs and a client certificate.
+	SingleFlight       bool               `json:"single_flight"`       // SingleFlight deduplicates identical in-flight GET requests.
+	Codec              string             `json:"codec"`               // Codec names the decoder of the game's responses.
+	Network            NetworkConfig      `json:"network"`             // Network binds outgoing connections to a local address.
+	IdempotencyFile    string             `json:"idempotency_file"`    // IdempotencyFile records successful non-idempotent tasks.
+	JobQueue           JobQueueConfig     `json:"job_queue"`           // JobQueue persists scheduled task executions.
+	History            HistoryConfig      `json:"history"`             // History stores the recent task executions.
+	Storage            StorageConfig      `json:"storage"`             // Storage is the default backend of persisted state.
+	AdaptiveThrottle   ThrottleConfig     `json:"adaptive_throttle"`   // AdaptiveThrottle slows tasks down under backpressure.
+	Parallelism        ParallelismConfig  `json:"parallelism"`         // Parallelism sizes the task dispatch from the farm size.
+	WarmUp             WarmUpConfig       `json:"warm_up"`             // WarmUp eases newly added accounts into farming.
+	BanDetection       BanDetectionConfig `json:"ban_detection"`       // BanDetection cools down accounts showing ban signals.
+	Rotation           string             `json:"rotation"`            // Rotation is the order in which accounts are served.
+	DailyReport        DailyReportConfig  `json:"daily_report"`        // DailyReport sends a summary of the previous day.
+	SecretMemory       string             `json:"secret_memory"`       // SecretMemory encrypts the secrets held in memory.
+	LazyAccounts       bool               `json:"lazy_accounts"`       // LazyAccounts reads the sessions from the accounts file on use.
+	Sinks              []SinkConfig       `json:"sinks"`               // Sinks publish the task results and events to message queues.
+	Preconnect         PreconnectConfig   `json:"preconnect"`          // Preconnect warms up the connections before the tasks start.
+	ContentTypes       ContentTypesConfig `json:"content_types"`       // ContentTypes are the default Content-Type and Accept headers.
+	PayloadLimits      PayloadLimitConfig `json:"payload_limits"`      // PayloadLimits caps the request bodies sent by tasks.
+	BaseURLs           map[string]string  `json:"base_urls"`           // BaseURLs are the named base URLs of the game's other hosts.
+	Maintenance        MaintenanceConfig  `json:"maintenance"`         // Maintenance pauses the game during its maintenance.
+	IntervalBackoff    BackoffConfig      `json:"interval_backoff"`    // IntervalBackoff stretches the intervals of failing tasks.
+}
+
+// DailyReportConfig represents the daily summary report of a farm (see summary.NewJob).
+//
+// # Fields:
+//   - Enabled: Whether the report is sent.
+//   - Hour: The hour of the day (0-23) at which the report of the previous day is sent.
+//     Defaults to midnight.
+//   - Timezone: The IANA time zone of the report days (e.g., "Europe/Berlin"). Defaults to
+//     the local time zone.
+//   - Format: The format of the report, "markdown" or "html". Defaults to "markdown".
+//   - BotToken: The token of the Telegram bot sending the report. Defaults to the bot of the
+//     Telegram log sink.
+//   - ChatID: The chat receiving the report. Defaults to the chat of the Telegram log sink.
+//
+// # Example config.json section:
+//
+//	"daily_report": {
+//		"enabled": true,
+//		"hour": 8,
+//		"timezone": "Europe/Berlin",
+//		"format": "markdown"
+//	}
+type DailyReportConfig struct {
+	Enabled  bool   `json:"enabled"`   // Enabled turns the daily report on.
+	Hour     int    `json:"hour"`      // Hour is the hour at which the report is sent.
+	Timezone string `json:"timezone"`  // Timezone is the time zone of the report days.
+	Format   string `json:"format"`    // Format is "markdown" or "html".
+	BotToken string `json:"bot_token"` // BotToken is the Telegram bot token of the report.
+	ChatID   string `json:"chat_id"`   // ChatID is the destination chat of the report.
+}
+
+// APIKeyConfig represents a Nexus API key of the api_keys section (see apikeys.FromConfig).
+//
+// A key reserved for accounts is only used by them, a key reserved for games is only used by
+// the accounts of these games without their own key, and a key reserved for nothing is used
+// by every other account. Refreshes fail over between the keys of the same reservation, in
+// configuration order.
+//
+// # Fields:
+//   - Name: The name of the key in logs and statistics (e.g., the customer name).
+//   - Key: The API key.
+//   - Games: The names of the games the key is reserved for.
+//   - Accounts: The Telegram IDs of the accounts the key is reserved for.
+//   - Quota: The number of refreshes allowed per day, used to estimate the remaining quota
+//     when the Nexus API does not report it.
+//
+// # Example config.json section:
+//
+//	"api_keys": [
+//		{"name": "alice", "key": "key-1", "games": ["hamster"], "quota": 5000},
+//		{"name": "bob", "key": "key-2", "accounts": ["987654321", "123456789"]},
+//		{"name": "bob-backup", "key": "key-3", "accounts": ["987654321", "123456789"]}
+//	]
+type APIKeyConfig struct {
+	Name     string   `json:"name"`     // Name is the name of the key.
+	Key      string   `json:"key"`      // Key is the API key.
+	Games    []string `json:"games"`    // Games are the games the key is reserved for.
+	Accounts []string `json:"accounts"` // Accounts are the accounts the key is reserved for.
+	Quota    int      `json:"quota"`    // Quota is the number of refreshes allowed per day.
+}
+
+// BanDetectionConfig represents the ban signals recognized in the responses of a game
+// (see bandetect.FromConfig).
+//
+// # Fields:
+//   - Enabled: Whether accounts showing a ban signal are put in cooldown.
+//   - DisableDefaultRules: Whether bandetect.DefaultRules are left out.
+//   - Rules: The game-specific rules, added to the default ones.
+//   - ZeroRewardStreak: The number of consecutive zero rewards considered a ban signal.
+//     Defaults to 3.
+//   - Cooldown: How long accounts showing a ban signal stop running tasks, and how they resume.
+//
+// # Example config.json section:
+//
+//	"ban_detection": {
+//		"enabled": true,
+//		"rules": [
+//			{"name": "restricted", "status": 200, "patterns": ["\"restricted\":true"]}
+//		],
+//		"cooldown": {
+//			"minutes": 1440,
+//			"signal_minutes": {"captcha": 120},
+//			"probe_after_minutes": 360,
+//			"probe_url": "https://api.game.com/user/me"
+//		}
+//	}
+type BanDetectionConfig struct {
+	Enabled             bool            `json:"enabled"`               // Enabled turns ban detection on.
+	DisableDefaultRules bool            `json:"disable_default_rules"` // DisableDefaultRules keeps only Rules.
+	Rules               []BanRuleConfig `json:"rules"`                 // Rules are the game-specific ban signals.
+	ZeroRewardStreak    int             `json:"zero_reward_streak"`    // ZeroRewardStreak is the zero rewards in a row that signal a ban.
+	Cooldown            CooldownConfig  `json:"cooldown"`              // Cooldown configures the cooldown of banned accounts.
+}
+
+// CooldownConfig represents the cooldown of the accounts showing a ban signal
+// (see handler.CooldownPolicy).
+//
+// # Fields:
+//   - Minutes: The cooldown after a ban signal. Defaults to 720 (12 hours).
+//   - SignalMinutes: The cooldown after the signals of specific rules, keyed by rule name.
+//   - ProbeAfterMinutes: How long after the start of a cooldown (or the last failed probe) a
+//     light request is sent to check whether the account can be restored. Zero disables probing.
+//   - ProbeURL: The URL of the GET probe request. Defaults to the game's base URL.
+type CooldownConfig struct {
+	Minutes           int            `json:"minutes"`             // Minutes is the cooldown after a ban signal.
+	SignalMinutes     map[string]int `json:"signal_minutes"`      // SignalMinutes are the cooldowns per rule.
+	ProbeAfterMinutes int            `json:"probe_after_minutes"` // ProbeAfterMinutes is the delay before probing.
+	ProbeURL          string         `json:"probe_url"`           // ProbeURL is the URL of the probe request.
+}
+
+// BanRuleConfig represents a ban signal of a game (see bandetect.Rule).
+//
+// # Fields:
+//   - Name: The name of the signal.
+//   - Status: The status code of the response. Zero matches any status.
+//   - Patterns: Case-insensitive substrings of the response body, one of which must be present.
+type BanRuleConfig struct {
+	Name     string   `json:"name"`     // Name is the name of the signal.
+	Status   int      `json:"status"`   // Status is the status code of the response.
+	Patterns []string `json:"patterns"` // Patterns are substrings of the response body.
+}
+
+// WarmUpConfig represents the warm-up policy of the newly added accounts of a game
+// (see handler.WarmUpPolicy). An account is new until Days have passed since its created-at date.
+//
+// # Fields:
+//   - Days: The length of the warm-up period in days. Zero disables warm-up.
+//   - IntervalFactor: The factor applied to the intervals of scheduled tasks during warm-up.
+//     Defaults to 3.
+//   - Tasks: The names of the tasks run during warm-up. Empty means every task.
+//
+// # Example config.json section:
+//
+//	"warm_up": {
+//		"days": 3,
+//		"interval_factor": 4,
+//		"tasks": ["daily-checkin", "claim"]
+//	}
+type WarmUpConfig struct {
+	Days           int      `json:"days"`            // Days is the length of the warm-up period.
+	IntervalFactor float64  `json:"interval_factor"` // IntervalFactor widens the intervals of scheduled tasks.
+	Tasks          []string `json:"tasks"`           // Tasks are the tasks run during warm-up.
+}
+
+// ThrottleConfig represents the settings of the adaptive throttling of task dispatch
+// (see handler.Throttle).
+//
+// # Fields:
+//   - Enabled: Whether the intervals and concurrency of tasks adapt to backpressure.
+//   - WindowSeconds: The length of the windows over which requests are counted. Defaults to 60.
+//   - Threshold: The share of rate limited or proxy-failed requests in a window considered as
+//     backpressure. Defaults to 0.1.
+//   - MinSamples: The number of requests a window needs before it can trigger throttling.
+//     Defaults to 10.
+//   - MaxFactor: The maximum factor applied to the intervals of scheduled tasks. Defaults to 8.
+//   - MaxConcurrency: The number of task executions allowed to run at once without
+//     backpressure. Zero means unlimited. Waiting executions are served round-robin across
+//     accounts.
+//
+// # Example config.json section:
+//
+//	"adaptive_throttle": {
+//		"enabled": true,
+//		"threshold": 0.2,
+//		"max_concurrency": 20
+//	}
+type ThrottleConfig struct {
+	Enabled        bool    `json:"enabled"`         // Enabled turns adaptive throttling on.
+	WindowSeconds  int     `json:"window_seconds"`  // WindowSeconds is the length of a window.
+	Threshold      float64 `json:"threshold"`       // Threshold is the share of throttled requests.
+	MinSamples     int     `json:"min_samples"`     // MinSamples is the minimum number of requests of a window.
+	MaxFactor      float64 `json:"max_factor"`      // MaxFactor caps the widening of intervals.
+	MaxConcurrency int     `json:"max_concurrency"` // MaxConcurrency caps concurrent task executions.
+}
+
+// ParallelismConfig represents the sizing of the task dispatch of a handler from the size of its
+// farm (see handler.Parallelism).
+//
+// # Fields:
+//   - Mode: "auto" to size the task dispatch and request rates from the number of healthy
+//     proxies and enabled accounts. Empty leaves them to the other sections.
+//   - RequestsPerMinute: The requests per minute each proxy sends to each host. Defaults to 30.
+//   - WorkersPerProxy: The task executions allowed to run at once per healthy proxy. Defaults
+//     to 2.
+//   - MinWorkers: The minimum number of task executions allowed to run at once. Defaults to 1.
+//   - MaxWorkers: The maximum number of task executions allowed to run at once. Zero means
+//     unlimited.
+//
+// # Example config.json section:
+//
+//	"parallelism": {
+//		"mode": "auto",
+//		"requests_per_minute": 20,
+//		"max_workers": 64
+//	}
+type ParallelismConfig struct {
+	Mode              string  `json:"mode"`                // Mode is "auto" or empty.
+	RequestsPerMinute float64 `json:"requests_per_minute"` // RequestsPerMinute is the request rate per proxy and host.
+	WorkersPerProxy   int     `json:"workers_per_proxy"`   // WorkersPerProxy is the concurrency per healthy proxy.
+	MinWorkers        int     `json:"min_workers"`         // MinWorkers is the minimum concurrency.
+	MaxWorkers        int     `json:"max_workers"`         // MaxWorkers caps the concurrency.
+}
+
+// JobQueueConfig represents the settings of the queue of scheduled task executions
+// (see jobqueue.FromConfig).
+//
+// # Fields:
+//   - Backend: The queue backend, "memory", "file", "bolt", or "redis". Defaults to "memory",
+//     which does not survive restarts.
+//   - Path: The JSON file of the "file" backend, or the BoltDB file of the "bolt" backend.
+//   - Addr: The Redis address of the "redis" backend.
+//   - Password: The optional Redis password.
+//   - Key: The Redis hash holding the jobs. Defaults to "nexus:jobs".
+//
+// # Example config.json section:
+//
+//	"job_queue": {
+//		"backend": "file",
+//		"path": "state/jobs.json"
+//	}
+type JobQueueConfig struct {
+	Backend  string `json:"backend"`  // Backend is "memory", "file", "bolt", or "redis".
+	Path     string `json:"path"`     // Path is the file of the file or bolt backend.
+	Addr     string `json:"addr"`     // Addr is the Redis address.
+	Password string `json:"password"` // Password is the Redis password.
+	Key      string `json:"key"`      // Key is the Redis hash of the jobs.
+}
+
+// HistoryConfig represents the settings of the store of recent task executions
+// (see history.FromConfig).
+//
+// # Fields:
+//   - Backend: The store backend, "memory", "file", or "redis". Defaults to "memory", which
+//     does not survive restarts.
+//   - Path: The JSON lines file of the "file" backend.
+//   - Addr: The Redis address of the "redis" backend.
+//   - Password: The optional Redis password.
+//   - Key: The prefix of the Redis lists. Defaults to "nexus:history".
+//   - Limit: How many executions are kept per game, account, and task. Defaults to 100.
+//
+// # Example config.json section:
+//
+//	"history": {
+//		"backend": "file",
+//		"path": "state/history.jsonl",
+//		"limit": 50
+//	}
+type HistoryConfig struct {
+	Backend  string `json:"backend"`  // Backend is "memory", "file", or "redis".
+	Path     string `json:"path"`     // Path is the file of the file backend.
+	Addr     string `json:"addr"`     // Addr is the Redis address.
+	Password string `json:"password"` // Password is the Redis password.
+	Key      string `json:"key"`      // Key is the prefix of the Redis lists.
+	Limit    int    `json:"limit"`    // Limit is the number of executions kept per task.
+}
+
+// PreconnectConfig represents the connection warm-up run when the tasks of a game start
+// (see handler.NewPreconnect).
+//
+// # Fields:
+//   - Enabled: Whether connections are warmed up.
+//   - Hosts: URLs of the hosts connected to. Defaults to the base URL of the handler.
+//   - Concurrency: How many proxies are connected through at once. Defaults to 16.
+//   - TimeoutSeconds: How long the warm-up may take before the tasks start anyway. Defaults to 10.
+//
+// # Example config.json section:
+//
+//	"preconnect": {
+//		"enabled": true,
+//		"hosts": ["https://api.example.com", "https://cdn.example.com"],
+//		"timeout_seconds": 15
+//	}
+type PreconnectConfig struct {
+	Enabled        bool     `json:"enabled"`         // Enabled warms up the connections.
+	Hosts          []string `json:"hosts"`           // Hosts are the URLs connected to.
+	Concurrency    int      `json:"concurrency"`     // Concurrency is the number of proxies connected through at once.
+	TimeoutSeconds int      `json:"timeout_seconds"` // TimeoutSeconds bounds the warm-up.
+}
+
+// ContentTypesConfig represents the Content-Type and Accept headers added to the requests of
+// the handler that do not set them (see httpclient.ContentTypes). Tasks override them with
+// their own headers.
+//
+// # Fields:
+//   - ContentType: The Content-Type of the requests with a JSON body. Defaults to "application/json".
+//   - Accept: The Accept header of every request. Defaults to "application/json, text/plain, */*".
+//   - Disabled: Whether the requests are sent with their own headers only.
+//
+// # Example config.json section:
+//
+//	"content_types": {
+//		"content_type": "application/json; charset=utf-8",
+//		"accept": "application/json"
+//	}
+type ContentTypesConfig struct {
+	ContentType string `json:"content_type"` // ContentType is the Content-Type of JSON bodies.
+	Accept      string `json:"accept"`       // Accept is the Accept header of every request.
+	Disabled    bool   `json:"disabled"`     // Disabled adds no header.
+}
+
+// BackoffConfig represents the interval backoff of the scheduled tasks that keep failing for
+// an account (see tasks.IntervalBackoff): the interval of the task is multiplied by Factor for
+// every failure in a row from AfterFailures on, up to MaxMinutes, and restored after a success.
+//
+// # Fields:
+//   - Factor: The multiplier of the interval per failure. 0 or 1 disables the backoff.
+//   - MaxMinutes: The longest stretched interval, in minutes. Defaults to 720 (12 hours).
+//     Intervals already longer are left as they are.
+//   - AfterFailures: The number of failures in a row before the interval is stretched.
+//     Defaults to 1.
+//
+// # Example config.json section:
+//
+//	"interval_backoff": {
+//		"factor": 2,
+//		"max_minutes": 240,
+//		"after_failures": 2
+//	}
+type BackoffConfig struct {
+	Factor        float64 `json:"factor"`         // Factor multiplies the interval per failure.
+	MaxMinutes    int     `json:"max_minutes"`    // MaxMinutes caps the stretched interval.
+	AfterFailures int     `json:"after_failures"` // AfterFailures is the failures in a row before stretching.
+}
+
+// MaintenanceConfig represents the maintenance windows of a game, during which its scheduled
+// tasks are paused and resume afterwards (see handler.Maintenance).
+//
+// # Fields:
+//   - Windows: The known maintenance windows of the game.
+//   - Detect: Whether maintenance responses of the game pause it too, for PauseMinutes.
+//   - Statuses: The status codes of the maintenance responses. Defaults to [503]; 0 matches
+//     any status.
+//   - Patterns: Lowercase substrings of the body of the maintenance responses, one of which
+//     must be present. Defaults to handler.DefaultMaintenancePatterns.
+//   - PauseMinutes: How long a detected maintenance pauses the game. Defaults to 15.
+//
+// # Example config.json section:
+//
+//	"maintenance": {
+//		"windows": [
+//			{"days": ["tue"], "start": "03:00", "duration_minutes": 90, "timezone": "Europe/Moscow"},
+//			{"from": "2024-12-01T08:00:00Z", "until": "2024-12-01T12:00:00Z"}
+//		],
+//		"detect": true,
+//		"pause_minutes": 10
+//	}
+type MaintenanceConfig struct {
+	Windows      []MaintenanceWindowConfig `json:"windows"`       // Windows are the known maintenance windows.
+	Detect       bool                      `json:"detect"`        // Detect pauses the game on maintenance responses.
+	Statuses     []int                     `json:"statuses"`      // Statuses are the status codes of maintenance responses.
+	Patterns     []string                  `json:"patterns"`      // Patterns are substrings of the maintenance responses.
+	PauseMinutes int                       `json:"pause_minutes"` // PauseMinutes is the pause after a maintenance response.
+}
+
+// MaintenanceWindowConfig represents a maintenance window of a game: either a recurring window,
+// starting at Start on Days, or a one-off window from From to Until.
+//
+// # Fields:
+//   - Days: The days of the week of a recurring window ("mon" or "monday", ...). Empty means
+//     every day.
+//   - Start: The start time of a recurring window, "HH:MM".
+//   - DurationMinutes: The length of a recurring window, in minutes.
+//   - Timezone: The IANA time zone of Start (e.g., "Asia/Singapore"). Defaults to UTC.
+//   - From: The start of a one-off window, e.g. an announced update.
+//   - Until: The end of a one-off window.
+type MaintenanceWindowConfig struct {
+	Days            []string  `json:"days"`             // Days are the days of a recurring window.
+	Start           string    `json:"start"`            // Start is the start time of a recurring window.
+	DurationMinutes int       `json:"duration_minutes"` // DurationMinutes is the length of a recurring window.
+	Timezone        string    `json:"timezone"`         // Timezone is the time zone of Start.
+	From            time.Time `json:"from"`             // From is the start of a one-off window.
+	Until           time.Time `json:"until"`            // Until is the end of a one-off window.
+}
+
+// PayloadLimitConfig represents the limits of the request bodies sent by tasks (see
+// handler.PayloadLimits).
+//
+// # Fields:
+//   - MaxBytes: The largest body sent by any task, in bytes. Zero means unlimited.
+//   - Tasks: The limits of specific tasks, keyed by task name, overriding MaxBytes.
+//
+// # Example config.json section:
+//
+//	"payload_limits": {
+//		"max_bytes": 65536,
+//		"tasks": {"SyncTaps": 524288}
+//	}
+type PayloadLimitConfig struct {
+	MaxBytes int            `json:"max_bytes"` // MaxBytes is the largest body of any task.
+	Tasks    map[string]int `json:"tasks"`     // Tasks are the limits of specific tasks.
+}
+
+// SinkConfig represents a message queue the task results and events of a farm are published to
+// (see sink.FromConfig).
+//
+// # Fields:
+//   - Type: The broker, "nats", "kafka_rest" (a Kafka REST proxy), or "rabbitmq_http" (the
+//     publish endpoint of the RabbitMQ management API, not meant for production traffic).
+//     Kafka and RabbitMQ are only supported through these HTTP gateways.
+//   - Addr: The address of the NATS server.
+//   - URL: The base URL of the Kafka REST proxy, or of the RabbitMQ management API.
+//   - Topic: The Kafka topic, or the prefix of the NATS subjects and RabbitMQ routing keys.
+//   - Exchange: The RabbitMQ exchange.
+//   - VHost: The RabbitMQ virtual host. Defaults to "/".
+//   - Token: The optional NATS authentication token.
+//   - Username: The optional user name.
+//   - Password: The optional password.
+//
+// # Example config.json section:
+//
+//	"sinks": [
+//		{"type": "nats", "addr": "10.0.0.5:4222", "topic": "nexus"},
+//		{"type": "kafka_rest", "url": "http://10.0.0.5:8082", "topic": "farm-events"}
+//	]
+type SinkConfig struct {
+	Type     string `json:"type"`     // Type is "nats", "kafka_rest", or "rabbitmq_http".
+	Addr     string `json:"addr"`     // Addr is the NATS address.
+	URL      string `json:"url"`      // URL is the Kafka REST proxy or RabbitMQ management API.
+	Topic    string `json:"topic"`    // Topic is the Kafka topic or the subject prefix.
+	Exchange string `json:"exchange"` // Exchange is the RabbitMQ exchange.
+	VHost    string `json:"vhost"`    // VHost is the RabbitMQ virtual host.
+	Token    string `json:"token"`    // Token is the NATS token.
+	Username string `json:"username"` // Username is the broker user.
+	Password string `json:"password"` // Password is the broker password.
+}
+
+// StorageConfig represents the backend shared by the persistence features of a farm
+// (see storage.FromConfig).
+//
+// # Fields:
+//   - Backend: The storage backend, "memory", "file", "sqlite", "redis", or one added with
+//     storage.Register. Storage is disabled when empty. "sqlite" requires importing the
+//     storage/sqlite package.
+//   - Path: The directory of the "file" backend, or the database file of the "sqlite" backend.
+//   - Addr: The Redis address of the "redis" backend.
+//   - Password: The optional Redis password.
+//   - Prefix: The prefix of the Redis hashes. Defaults to "nexus".
+//
+// # Example config.json section:
+//
+//	"storage": {
+//		"backend": "sqlite",
+//		"path": "state/nexus.db"
+//	}
+type StorageConfig struct {
+	Backend  string `json:"backend"`  // Backend is "memory", "file", "sqlite", or "redis".
+	Path     string `json:"path"`     // Path is the directory or database file.
+	Addr     string `json:"addr"`     // Addr is the Redis address.
+	Password string `json:"password"` // Password is the Redis password.
+	Prefix   string `json:"prefix"`   // Prefix is the prefix of the Redis hashes.
+}
+
+// ElectionConfig represents the settings of the leader election between farm instances.
+//
+// # Fields:
+//   - Backend: The election backend, "file", "redis", or "etcd". Election is disabled when
+//     empty.
+//   - Path: The lock file of the "file" backend.
+//   - Addr: The Redis address of the "redis" backend, or the etcd client URL of the "etcd"
+//     backend (e.g., "http://10.0.0.5:2379").
+//   - Username: The optional etcd user.
+//   - Password: The optional Redis password, or the password of the etcd user.
+//   - Key: The leadership key. Defaults to "nexus:leader" with Redis and "nexus/leader" with
+//     etcd.
+//   - TTLSeconds: How long a dead leader keeps the Redis key or the etcd lease. Defaults to 15.
+//
+// # Example config.json section:
+//
+//	"election": {
+//		"backend": "redis",
+//		"addr": "10.0.0.5:6379",
+//		"key": "nexus:leader:hamster",
+//		"ttl_seconds": 15
+//	}
+type ElectionConfig struct {
+	Backend    string `json:"backend"`     // Backend is "file", "redis", or "etcd".
+	Path       string `json:"path"`        // Path is the lock file of the file backend.
+	Addr       string `json:"addr"`        // Addr is the Redis address or the etcd URL.
+	Username   string `json:"username"`    // Username is the etcd user.
+	Password   string `json:"password"`    // Password is the Redis or etcd password.
+	Key        string `json:"key"`         // Key is the leadership key.
+	TTLSeconds int    `json:"ttl_seconds"` // TTLSeconds is the lifetime of the Redis key or etcd lease.
+}
+
+// ErrorBudgetConfig represents the cap on the failed requests of every account
+// (see handler.ErrorBudget).
+//
+// # Fields:
+//   - MaxFailuresPerHour: The number of failed requests an account may have within an hour
+//     before it backs off. Zero disables the budget.
+//   - BackoffMinutes: How long an account over its budget stops running tasks. Defaults to 60.
+//
+// # Example config.json section:
+//
+//	"error_budget": {
+//		"max_failures_per_hour": 20,
+//		"backoff_minutes": 120
+//	}
+type ErrorBudgetConfig struct {
+	MaxFailuresPerHour int `json:"max_failures_per_hour"` // MaxFailuresPerHour is the allowed failures per hour.
+	BackoffMinutes     int `json:"backoff_minutes"`       // BackoffMinutes is the backoff of an account over its budget.
+}
+
+// BudgetConfig represents the settings of a request budget (see handler.Budget).
+//
+// # Fields:
+//   - MaxRequestsPerHour: The maximum number of requests per clock hour. Zero means unlimited.
+//   - MaxBandwidthMBPerDay: The maximum proxy bandwidth in megabytes per UTC day. Zero means unlimited.
+//   - Mode: What happens when the budget is exhausted: "delay" (default) waits for the next
+//     window, "skip" skips the task execution.
+//
+// # Example config.json section:
+//
+//	"budget": {
+//		"max_requests_per_hour": 5000,
+//		"max_bandwidth_mb_per_day": 2048,
+//		"mode": "skip"
+//	}
+type BudgetConfig struct {
+	MaxRequestsPerHour   int    `json:"max_requests_per_hour"`    // MaxRequestsPerHour caps the requests per hour.
+	MaxBandwidthMBPerDay int    `json:"max_bandwidth_mb_per_day"` // MaxBandwidthMBPerDay caps the bandwidth per day.
+	Mode                 string `json:"mode"`                     // Mode is either "delay" or "skip".
+}
+
+// HedgingConfig represents the settings of hedged GET requests (see handler.HedgePolicy).
+//
+// # Fields:
+//   - Enabled: Whether slow GET requests are duplicated through another proxy of the pool.
+//   - Percentile: The latency percentile of the endpoint after which the second request is sent.
+//     Defaults to 95.
+//   - MinDelayMs: The lower bound of the hedging delay in milliseconds. Defaults to 50.
+//   - MaxDelayMs: The upper bound of the hedging delay in milliseconds. Defaults to 5000.
+//
+// # Example config.json section:
+//
+//	"hedging": {
+//		"enabled": true,
+//		"percentile": 90,
+//		"max_delay_ms": 3000
+//	}
+type HedgingConfig struct {
+	Enabled    bool    `json:"enabled"`      // Enabled turns hedged GET requests on.
+	Percentile float64 `json:"percentile"`   // Percentile is the latency percentile of the hedging delay.
+	MinDelayMs int     `json:"min_delay_ms"` // MinDelayMs is the minimum hedging delay.
+	MaxDelayMs int     `json:"max_delay_ms"` // MaxDelayMs is the maximum hedging delay.
+}
+
+// TLSConfig represents the TLS settings of the handler's HTTP clients (see httpclient.TLSOptions).
+//
+// # Fields:
+//   - CAFiles: PEM files of certificate authorities trusted in addition to the system roots.
+//   - CertFile: The PEM file of the client certificate for mutual TLS.
+//   - KeyFile: The PEM file of the private key of the client certificate.
+//   - ServerName: Overrides the server name used to verify certificates.
+//   - Pins: The SPKI pins ("sha256/<base64>") of each host, so that a compromised or
+//     intercepting proxy cannot read the session data sent to it.
+//
+// # Example config.json section:
+//
+//	"tls": {
+//		"ca_files": ["/etc/nexus/ca.pem"],
+//		"cert_file": "/etc/nexus/client.pem",
+//		"key_file": "/etc/nexus/client-key.pem",
+//		"pins": {
+//			"api.example-game.com": ["sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="]
+//		}
+//	}
+type TLSConfig struct {
+	CAFiles    []string            `json:"ca_files"`    // CAFiles are additional trusted certificate authorities.
+	CertFile   string              `json:"cert_file"`   // CertFile is the client certificate.
+	KeyFile    string              `json:"key_file"`    // KeyFile is the private key of the client certificate.
+	ServerName string              `json:"server_name"` // ServerName overrides the verified server name.
+	Pins       map[string][]string `json:"pins"`        // Pins are the SPKI pins of each host.
+}
+
+// NetworkConfig represents the local end of the connections opened by the handler's HTTP
+// clients (see httpclient.NetworkOptions). For proxied clients, it applies to the connections
+// to the proxies.
+//
+// # Fields:
+//   - LocalAddress: The local IP address outgoing connections are bound to.
+//   - Interface: The network interface whose address outgoing connections are bound to.
+//     Ignored when LocalAddress is set.
+//   - PreferIPv6: Whether IPv6 addresses are tried before IPv4 ones.
+//   - DialTimeoutMs: How long opening a connection may take, the SOCKS handshake of a proxy
+//     included, in milliseconds. Defaults to 30000. Unlike the timeout of the proxy, it does not
+//     bound the request itself.
+//   - KeepAliveSeconds: The period of the TCP keep-alive probes. Defaults to 30. Negative
+//     disables them.
+//   - FallbackDelayMs: How long a dual-stack connection waits on the preferred address family
+//     before racing the other one, in milliseconds. Defaults to 300. Negative disables the race.
+//   - TLSHandshakeTimeoutMs: How long a TLS handshake may take, in milliseconds. Zero means no
+//     limit other than the timeout of the proxy.
+//
+// # Example config.json section:
+//
+//	"network": {
+//		"interface": "eth1",
+//		"prefer_ipv6": true,
+//		"dial_timeout_ms": 3000,
+//		"tls_handshake_timeout_ms": 5000
+//	}
+type NetworkConfig struct {
+	LocalAddress          string `json:"local_address"`            // LocalAddress is the local IP address of outgoing connections.
+	Interface             string `json:"interface"`                // Interface is the network interface of outgoing connections.
+	PreferIPv6            bool   `json:"prefer_ipv6"`              // PreferIPv6 tries IPv6 addresses first.
+	DialTimeoutMs         int    `json:"dial_timeout_ms"`          // DialTimeoutMs bounds the opening of a connection.
+	KeepAliveSeconds      int    `json:"keep_alive_seconds"`       // KeepAliveSeconds is the TCP keep-alive period.
+	FallbackDelayMs       int    `json:"fallback_delay_ms"`        // FallbackDelayMs is the dual-stack fallback delay.
+	TLSHandshakeTimeoutMs int    `json:"tls_handshake_timeout_ms"` // TLSHandshakeTimeoutMs bounds TLS handshakes.
+}
+
+// ProxyPoolConfig represents the settings of a proxy list loaded by the handler.
+//
+// # Fields:
+//   - File: The path of the proxies file (.txt or .json). The pool is disabled when neither a
+//     file nor a provider is set.
+//   - Strategy: The assignment strategy: "per_account" (default), "round_robin", or "sticky".
+//   - StickyTTLMinutes: How long an account keeps its proxy with the "sticky" strategy. Defaults to 30.
+//   - BytesPerSecond: The default bandwidth limit of every proxy of the list, in bytes per second.
+//     Proxies with their own "bytesPerSecond" keep it. Zero means unlimited.
+//   - FailureThreshold: The share of failed requests (0-1) above which a proxy is retired and
+//     its accounts are moved to healthy proxies. Zero disables retirement by error rate.
+//   - MinRequests: The number of requests within the window below which the error rate of a
+//     proxy is not judged. Defaults to 10.
+//   - WindowMinutes: The sliding window of the error rate, in minutes. Defaults to 10.
+//   - CountryMode: How accounts with a country are kept on proxies of their country: "lenient"
+//     (default) falls back to other proxies when none of the country is healthy, "strict"
+//     fails their requests instead.
+//   - SessionTTLMinutes: How long an account keeps the exit IP of a rotating gateway, whose
+//     username contains "{session}", before a new session ID is drawn. Defaults to 10. Accounts
+//     may set their own lifetime.
+//   - Provider: The provider API the proxy list is fetched and refreshed from. Its proxies are
+//     added to those of File.
+//   - Name: The name of the pool in the proxy_pool label of the metrics. Defaults to "default".
+//
+// # Example config.json section:
+//
+//	"proxy_pool": {
+//		"file": "proxies.txt",
+//		"strategy": "sticky",
+//		"sticky_ttl_minutes": 15,
+//		"bytes_per_second": 262144,
+//		"failure_threshold": 0.5,
+//		"min_requests": 20,
+//		"country_mode": "strict",
+//		"session_ttl_minutes": 30,
+//		"provider": {
+//			"name": "webshare",
+//			"api_key": "env:WEBSHARE_API_KEY",
+//			"refresh_minutes": 30
+//		}
+//	}
+type ProxyPoolConfig struct {
+	File              string              `json:"file"`                // File is the path of the proxies file.
+	Strategy          string              `json:"strategy"`            // Strategy is the proxy assignment strategy.
+	StickyTTLMinutes  int                 `json:"sticky_ttl_minutes"`  // StickyTTLMinutes is the sticky assignment lifetime.
+	BytesPerSecond    int64               `json:"bytes_per_second"`    // BytesPerSecond is the default bandwidth limit per proxy.
+	FailureThreshold  float64             `json:"failure_threshold"`   // FailureThreshold is the error rate that retires a proxy.
+	MinRequests       int                 `json:"min_requests"`        // MinRequests is the sample size of the error rate.
+	WindowMinutes     int                 `json:"window_minutes"`      // WindowMinutes is the window of the error rate.
+	CountryMode       string              `json:"country_mode"`        // CountryMode is "lenient" or "strict".
+	SessionTTLMinutes int                 `json:"session_ttl_minutes"` // SessionTTLMinutes is the gateway session lifetime.
+	Provider          ProxyProviderConfig `json:"provider"`            // Provider is the proxy provider API.
+	Name              string              `json:"name"`                // Name is the pool name in the metrics.
+}
+
+// ProxyProviderConfig represents the provider API a proxy list is fetched from (see
+// proxypool.ProviderFromConfig).
+//
+// # Fields:
+//   - Name: The provider: "webshare", "proxy6", "url", or a name registered with
+//     proxypool.RegisterProvider. The provider is disabled when empty.
+//   - APIKey: The API key of the provider account. Secret references are resolved.
+//   - URL: The address of the list for the "url" provider.
+//   - Country: The optional country code (e.g., "DE") the list is restricted to.
+//   - RefreshMinutes: How often the list is fetched again while tasks run. Defaults to 15.
+type ProxyProviderConfig struct {
+	Name           string `json:"name"`            // Name is the provider name.
+	APIKey         string `json:"api_key"`         // APIKey is the provider API key.
+	URL            string `json:"url"`             // URL is the list address of the "url" provider.
+	Country        string `json:"country"`         // Country restricts the list to a country.
+	RefreshMinutes int    `json:"refresh_minutes"` // RefreshMinutes is the refresh interval.
+}
+
+// LogConfig represents the logging configuration used by utils.InitLoggerFromConfig.
+//
+// # Fields:
+//   - Level: The default minimum level ("debug", "info", "warn", "error"). Defaults to "info".
+//   - Encoding: The output encoding, either "json" or "console". Defaults to "json".
+//   - Console: Whether log entries are written to stderr. Enabled implicitly when no file is configured.
+//   - File: The rotating file sink settings.
+//   - Modules: Per-module level overrides keyed by module name (e.g., "httpclient", "handler", "tasks").
+//   - Telegram: The optional Telegram sink receiving critical entries.
+//
+// # Example config.json section:
+//
+//	"log": {
+//		"level": "info",
+//		"encoding": "json",
+//		"console": true,
+//		"file": {
+//			"path": "logs/farm.log",
+//			"max_size_mb": 100,
+//			"max_backups": 5,
+//			"max_age_days": 7
+//		},
+//		"modules": {
+//			"httpclient": "debug"
+//		},
+//		"telegram": {
+//			"bot_token": "123456:ABC-DEF",
+//			"chat_id": "-1001234567890",
+//			"level": "error",
+//			"batch_seconds": 10,
+//			"max_per_minute": 10
+//		}
+//	}
+type LogConfig struct {
+	Level    string            `json:"level"`    // Level is the default minimum log level.
+	Encoding string            `json:"encoding"` // Encoding is either "json" or "console".
+	Console  bool              `json:"console"`  // Console enables the stderr sink.
+	File     LogFileConfig     `json:"file"`     // File configures the rotating file sink.
+	Modules  map[string]string `json:"modules"`  // Modules holds per-module level overrides.
+	Telegram TelegramLogConfig `json:"telegram"` // Telegram forwards critical entries to a chat.
+}
+
+// TelegramLogConfig represents the settings of the Telegram log sink.
+//
+// # Fields:
+//   - BotToken: The token of the bot sending the messages. The sink is disabled when empty.
+//   - ChatID: The chat receiving the messages.
+//   - Level: The minimum forwarded level. Defaults to "error".
+//   - BatchSeconds: How long entries are collected into one message. Defaults to 10.
+//   - MaxPerMinute: The maximum number of messages per minute. Defaults to 10.
+type TelegramLogConfig struct {
+	BotToken     string `json:"bot_token"`      // BotToken is the Telegram bot token.
+	ChatID       string `json:"chat_id"`        // ChatID is the destination chat.
+	Level        string `json:"level"`          // Level is the minimum forwarded level.
+	BatchSeconds int    `json:"batch_seconds"`  // BatchSeconds is the batching interval.
+	MaxPerMinute int    `json:"max_per_minute"` // MaxPerMinute is the message rate limit.
+}
+
+// LogFileConfig represents the settings of the rotating file log sink.
+//
+// # Fields:
+//   - Path: The path of the active log file. The file sink is disabled when empty.
+//   - MaxSizeMB: The size in megabytes at which the file is rotated. Defaults to 100.
+//   - MaxBackups: The number of rotated files to keep. Zero keeps all of them.
+//   - MaxAgeDays: The number of days rotated files are kept. Zero keeps them forever.
+type LogFileConfig struct {
+	Path       string `json:"path"`         // Path is the active log file.
+	MaxSizeMB  int    `json:"max_size_mb"`  // MaxSizeMB is the rotation threshold in megabytes.
+	MaxBackups int    `json:"max_backups"`  // MaxBackups is the number of rotated files to keep.
+	MaxAgeDays int    `json:"max_age_days"` // MaxAgeDays is the retention of rotated files in days.
 }
 
 // Proxy represents the settings for configuring an SOCKS proxy server.
@@ -51,6 +896,10 @@ type Config struct {
 //   - Password: The password for proxy authentication (if required).
 //   - SocksType: The SOCKS protocol type (e.g., 4 or 5).
 //   - Timeout: The timeout in seconds for proxy connections.
+//   - BytesPerSecond: The bandwidth limit of the connections through the proxy, in bytes per
+//     second in each direction. Zero means unlimited.
+//   - Country: The ISO 3166-1 alpha-2 code of the country of the proxy's exit IP (e.g., "DE").
+//     Pools only assign it to accounts of the same country.
 //
 // # Example Usage:
 //
@@ -64,12 +913,14 @@ type Config struct {
 //	}
 //	fmt.Printf("Proxy: %s:%d\n", proxy.Ip, proxy.Port) // Output: Proxy: 192.168.1.100:8080
 type Proxy struct {
-	Ip        string `json:"ip"`        // Ip is the proxy server's IP address.
-	Port      int    `json:"port"`      // Port is the proxy server's port.
-	Username  string `json:"username"`  // Username is the username for proxy authentication.
-	Password  string `json:"password"`  // Password is the password for proxy authentication.
-	SocksType int    `json:"socksType"` // SocksType specifies the SOCKS protocol type (4 or 5).
-	Timeout   int    `json:"timeout"`   // Timeout specifies the timeout in seconds for proxy connections.
+	Ip             string `json:"ip"`                       // Ip is the proxy server's IP address.
+	Port           int    `json:"port"`                     // Port is the proxy server's port.
+	Username       string `json:"username"`                 // Username is the username for proxy authentication.
+	Password       string `json:"password"`                 // Password is the password for proxy authentication.
+	SocksType      int    `json:"socksType"`                // SocksType specifies the SOCKS protocol type (4 or 5).
+	Timeout        int    `json:"timeout"`                  // Timeout specifies the timeout in seconds for proxy connections.
+	BytesPerSecond int64  `json:"bytesPerSecond,omitempty"` // BytesPerSecond caps the bandwidth of the proxy connections.
+	Country        string `json:"country,omitempty"`        // Country is the country code of the exit IP.
 }
 
 // Account represents an entry in the accounts file (accounts.json).
@@ -78,6 +929,25 @@ type Proxy struct {
 // # Fields:
 //   - GameData: The game-specific data associated with this account.
 //   - TelegramData: The Telegram session information, including credentials and IDs.
+//   - Enabled: Whether the account is processed by the scheduler. Accounts without the field are enabled.
+//   - Label: A short human-readable name for the account.
+//   - Notes: Free-form operator notes.
+//   - CreatedAt: When the account was added to the farm.
+//   - Device: The device the account presents to games (User-Agent and client hints).
+//     Generated on first load when missing (see device.Ensure).
+//   - Country: The ISO 3166-1 alpha-2 code of the country the account uses (e.g., "DE"). Proxy
+//     pools keep the account on proxies of this country, since switching countries between
+//     sessions is a common ban cause.
+//   - SessionTTLMinutes: How long the account keeps the exit IP of rotating gateways, overriding
+//     the lifetime of the proxy pool. Zero uses the pool's lifetime.
+//   - Wallet: The crypto wallet the rewards of the account are withdrawn to (see
+//     tasks.WithdrawalTask). Its address is checked when the accounts are loaded.
+//   - Cohort: The group of the account in the account_cohort label of the metrics (e.g., the
+//     batch it was bought in). Defaults to the month of CreatedAt.
+//   - Extra: The fields of the account in accounts.json that are none of the above, e.g. a
+//     referral code used by the tasks of one game. Their numbers are decoded as json.Number,
+//     so they are written back as they are, and task expressions read them as
+//     account.<field> (e.g., "{{account.referral_code}}" in a payload).
 //
 // # Example accounts.json:
 //
@@ -98,7 +968,14 @@ type Proxy struct {
 //				"appId": "654321",
 //				"appHash": "fedcba654321",
 //				"telegramId": "123456789"
-//			}
+//			},
+//			"enabled": false,
+//			"label": "backup-07",
+//			"notes": "Soft-banned on 2024-11-02, re-check next week",
+//			"created-at": "2024-10-01T12:00:00Z",
+//			"country": "DE",
+//			"wallet": {"address": "UQBvW8Z5huBkMJYdnfAEM5JqTNkuWX3diqYENkVosgvReHPy"},
+//			"referral_code": "ref_4f2a9c"
 //		}
 //	]
 //
@@ -116,8 +993,133 @@ type Proxy struct {
 //	fmt.Println(account.GameData)             // Output: user=%7B%22id%22%3A78894796...
 //	fmt.Println(account.TelegramId)           // Output: 987654321
 type Account struct {
-	GameData     string            `json:"game-data"` // Game-specific data associated with this account.
-	TelegramData `json:"telegram"` // Telegram session information.
+	GameData          string                 `json:"game-data"` // Game-specific data associated with this account.
+	TelegramData      `json:"telegram"`      // Telegram session information.
+	Enabled           *bool                  `json:"enabled,omitempty"`             // Whether the account is scheduled; nil means enabled.
+	Label             string                 `json:"label,omitempty"`               // Short human-readable name.
+	Notes             string                 `json:"notes,omitempty"`               // Free-form operator notes.
+	CreatedAt         time.Time              `json:"created-at,omitempty"`          // When the account was added to the farm.
+	Device            *DeviceProfile         `json:"device,omitempty"`              // Device presented to games.
+	Country           string                 `json:"country,omitempty"`             // Country of the account's proxies.
+	SessionTTLMinutes int                    `json:"session-ttl-minutes,omitempty"` // Lifetime of the account's gateway sessions.
+	Wallet            *Wallet                `json:"wallet,omitempty"`              // Wallet the account's rewards are withdrawn to.
+	Cohort            string                 `json:"cohort,omitempty"`              // Group of the account in the metrics.
+	Extra             map[string]interface{} `json:"-"`                             // Other fields of the account, kept as they are.
+}
+
+// accountFields are the JSON names of the fields of Account, the fields of accounts.json that
+// are not captured into Extra.
+var accountFields = jsonFieldNames(reflect.TypeOf(Account{}))
+
+// UnmarshalJSON decodes an account of accounts.json, capturing the fields Account does not
+// declare into Extra with their numbers as json.Number.
+func (account *Account) UnmarshalJSON(data []byte) error {
+	type plain Account
+	decoded := plain{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return err
+	}
+	for name := range accountFields {
+		delete(fields, name)
+	}
+	if len(fields) > 0 {
+		decoded.Extra = fields
+	}
+	*account = Account(decoded)
+	return nil
+}
+
+// MarshalJSON encodes an account as in accounts.json, with the fields of Extra after the
+// declared ones. Extra fields named like a declared field are left out, and so is a zero
+// CreatedAt, which omitempty does not leave out of a time.Time.
+func (account Account) MarshalJSON() ([]byte, error) {
+	type plain Account
+	var createdAt *time.Time
+	if !account.CreatedAt.IsZero() {
+		createdAt = &account.CreatedAt
+	}
+	encoded, err := json.Marshal(struct {
+		plain
+		CreatedAt *time.Time `json:"created-at,omitempty"`
+	}{plain(account), createdAt})
+	if err != nil || len(account.Extra) == 0 {
+		return encoded, err
+	}
+	names := make([]string, 0, len(account.Extra))
+	for name := range account.Extra {
+		if !accountFields[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	buffer := bytes.NewBuffer(encoded[:len(encoded)-1])
+	for _, name := range names {
+		key, _ := json.Marshal(name)
+		value, err := json.Marshal(account.Extra[name])
+		if err != nil {
+			return nil, fmt.Errorf("extra field %s: %w", name, err)
+		}
+		buffer.WriteByte(',')
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// jsonFieldNames returns the JSON names of the fields of a struct type.
+func jsonFieldNames(structType reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch {
+		case name == "-", !field.IsExported() && !field.Anonymous:
+		case name != "":
+			names[name] = true
+		case field.Anonymous && field.Type.Kind() == reflect.Struct:
+			for nested := range jsonFieldNames(field.Type) {
+				names[nested] = true
+			}
+		default:
+			names[field.Name] = true
+		}
+	}
+	return names
+}
+
+// DeviceProfile describes the device an account presents to games, so that every request of
+// the account carries the same User-Agent and client hints across runs.
+//
+// # Fields:
+//   - Platform: The operating system, "Android" or "iOS".
+//   - PlatformVersion: The operating system version (e.g., "14").
+//   - Model: The device model (e.g., "SM-S918B" or "iPhone15,3").
+//   - BrowserVersion: The full version of the Chromium WebView on Android (e.g., "124.0.6367.82").
+//   - Language: The preferred language sent in Accept-Language (e.g., "en-US").
+//   - UserAgent: The User-Agent header.
+type DeviceProfile struct {
+	Platform        string `json:"platform"`
+	PlatformVersion string `json:"platform-version"`
+	Model           string `json:"model"`
+	BrowserVersion  string `json:"browser-version,omitempty"`
+	Language        string `json:"language"`
+	UserAgent       string `json:"user-agent"`
+}
+
+// IsEnabled reports whether the account should be processed by the scheduler.
+//
+// Accounts without an explicit "enabled" field are enabled, so existing accounts files
+// keep working unchanged.
+func (account Account) IsEnabled() bool {
+	return account.Enabled == nil || *account.Enabled
 }
 
 // TelegramData represents the Telegram session information, including credentials and IDs.
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAccountExtraRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra string
+	}{
+		{"integer above 2^53", `"referral_id":9007199254740993`},
+		{"unsigned 64-bit integer", `"user_id":18446744073709551615`},
+		{"nested number", `"stats":{"points":123456789012345678901234567890}`},
+		{"number in an array", `"ids":[9007199254740993,1]`},
+		{"decimal", `"ratio":0.30000000000000004`},
+		{"exponent", `"threshold":1e+400`},
+		{"string", `"referral_code":"ref_4f2a9c"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := `{"game-data":"query","telegram":{"telegramId":"1"},` + test.extra + `}`
+			var account Account
+			if err := json.Unmarshal([]byte(data), &account); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			encoded, err := json.Marshal(account)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			if !strings.Contains(string(encoded), test.extra) {
+				t.Errorf("Marshal() = %s, want it to keep %s", encoded, test.extra)
+			}
+		})
+	}
+}
+
+func TestAccountExtraLeavesDeclaredFields(t *testing.T) {
+	account := Account{
+		GameData:     "query",
+		TelegramData: TelegramData{TelegramId: "1"},
+		Extra:        map[string]interface{}{"label": "shadowed", "referral_code": "ref"},
+	}
+	encoded, err := json.Marshal(account)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded Account
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unmarshal of %s failed: %v", encoded, err)
+	}
+	if decoded.Label != "" || decoded.Extra["referral_code"] != "ref" || len(decoded.Extra) != 1 {
+		t.Errorf("round trip of %s gave label %q and extra fields %v", encoded, decoded.Label, decoded.Extra)
+	}
+	if strings.Contains(string(encoded), "created-at") {
+		t.Errorf("Marshal() = %s, want the zero creation time left out", encoded)
+	}
+}
@@ -1,10 +1,35 @@
 package types
 
+import (
+	"fmt"
+	"strings"
+)
+
+// TasksVersion is the current version of the tasks.json structure. Older files are upgraded on
+// load (see handler.MigrateTasks).
+const TasksVersion = 2
+
 // TaskConfig represents the configuration for a one-time task.
 //
 // # Fields:
 //   - Name: The name of the task.
 //   - Payload: A map containing task-specific payload data.
+//   - Extends: The name of the template the task inherits from (see TaskTemplate).
+//   - Headers: The HTTP headers sent with the requests of the task.
+//   - Retry: The retry policy of the task.
+//   - Condition: The expression the task runs under, e.g. "profile.energy > 100" (see
+//     tasks.BaseTask.Condition).
+//   - Steps: The requests of a pipeline task, sent in order instead of the payload (see
+//     tasks.PipelineTask).
+//   - Extract: The state keys of the account set from the task's responses, mapped to the
+//     JSONPath expressions of their values (e.g., {"balance": "$.user.balance"}, see
+//     tasks.BaseTask.ExtractState).
+//   - TimeoutSeconds: The time budget of an execution, retries and game data refreshes
+//     included (see tasks.TimeLimited). Zero is unlimited.
+//   - AuthLocation: Where the requests of the task carry the game data of the account:
+//     "query", "header", or "body" (see tasks.BaseTask.Authorize). Empty adds it nowhere.
+//   - AuthKey: The name of the query parameter, header, or payload field of the game data.
+//     Defaults to "initData" for a parameter or field and "Authorization" for a header.
 //
 // # Example Usage:
 //
@@ -17,8 +42,17 @@ package types
 //	}
 //	fmt.Println(taskConfig.Name) // Output: Example Task
 type TaskConfig struct {
-	Name    string                 `json:"name"`    // Name of the task
-	Payload map[string]interface{} `json:"payload"` // Task-specific payload
+	Name           string                 `json:"name"`                      // Name of the task
+	Payload        map[string]interface{} `json:"payload"`                   // Task-specific payload
+	Extends        string                 `json:"extends,omitempty"`         // Template the task inherits from
+	Headers        map[string]string      `json:"headers,omitempty"`         // HTTP headers of the task's requests
+	Retry          *TaskRetryConfig       `json:"retry,omitempty"`           // Retry policy of the task
+	Condition      string                 `json:"condition,omitempty"`       // Expression the task runs under
+	Steps          []TaskStepConfig       `json:"steps,omitempty"`           // Requests of a pipeline task
+	Extract        map[string]string      `json:"extract,omitempty"`         // State keys set from the responses
+	TimeoutSeconds int                    `json:"timeout_seconds,omitempty"` // Time budget of an execution
+	AuthLocation   string                 `json:"auth_location,omitempty"`   // Where the requests carry the game data
+	AuthKey        string                 `json:"auth_key,omitempty"`        // Name of the game data parameter, header, or field
 }
 
 // RecurrentTaskConfig represents the configuration for a recurrent task.
@@ -27,6 +61,23 @@ type TaskConfig struct {
 //   - Name: The name of the task.
 //   - Payload: A map containing task-specific payload data.
 //   - IntervalMinutes: The interval in minutes between task executions.
+//   - Extends: The name of the template the task inherits from (see TaskTemplate).
+//   - Headers: The HTTP headers sent with the requests of the task.
+//   - Retry: The retry policy of the task.
+//   - Condition: The expression each execution runs under (see tasks.BaseTask.Condition).
+//   - Steps: The requests of a pipeline task, sent in order instead of the payload (see
+//     tasks.PipelineTask).
+//   - Extract: The state keys of the account set from the task's responses, mapped to the
+//     JSONPath expressions of their values (see TaskConfig).
+//   - TimeoutSeconds: The time budget of an execution (see TaskConfig).
+//   - AuthLocation: Where the requests of the task carry the game data of the account (see
+//     TaskConfig). The requests of Poll carry it too, unless it is sent in the body.
+//   - AuthKey: The name of the query parameter, header, or payload field of the game data.
+//   - Poll: The optional endpoint polled every interval, the task then running only when the
+//     response changes (see tasks.PollingTask).
+//   - Backoff: How the interval of the task is stretched while it keeps failing for an account,
+//     overriding the interval_backoff section of config.json (see BackoffConfig). A factor of 1
+//     disables it for the task.
 //
 // # Example Usage:
 //
@@ -40,20 +91,143 @@ type TaskConfig struct {
 //	}
 //	fmt.Println(recurrentTaskConfig.Name) // Output: Recurrent Task
 type RecurrentTaskConfig struct {
-	Name            string                 `json:"name"`             // Name of the task
-	Payload         map[string]interface{} `json:"payload"`          // Task-specific payload
-	IntervalMinutes int                    `json:"interval_minutes"` // Interval in minutes between executions
+	Name            string                 `json:"name"`                      // Name of the task
+	Payload         map[string]interface{} `json:"payload"`                   // Task-specific payload
+	IntervalMinutes int                    `json:"interval_minutes"`          // Interval in minutes between executions
+	Extends         string                 `json:"extends,omitempty"`         // Template the task inherits from
+	Headers         map[string]string      `json:"headers,omitempty"`         // HTTP headers of the task's requests
+	Retry           *TaskRetryConfig       `json:"retry,omitempty"`           // Retry policy of the task
+	Condition       string                 `json:"condition,omitempty"`       // Expression each execution runs under
+	Steps           []TaskStepConfig       `json:"steps,omitempty"`           // Requests of a pipeline task
+	Extract         map[string]string      `json:"extract,omitempty"`         // State keys set from the responses
+	TimeoutSeconds  int                    `json:"timeout_seconds,omitempty"` // Time budget of an execution
+	AuthLocation    string                 `json:"auth_location,omitempty"`   // Where the requests carry the game data
+	AuthKey         string                 `json:"auth_key,omitempty"`        // Name of the game data parameter, header, or field
+	Poll            *TaskPollConfig        `json:"poll,omitempty"`            // Endpoint polled for changes
+	Backoff         *BackoffConfig         `json:"backoff,omitempty"`         // Interval backoff while the task fails
+}
+
+// TaskPollConfig represents the endpoint polled by a recurrent task that runs only when the
+// response changes (see tasks.PollingTask).
+//
+// # Fields:
+//   - URL: The URL polled with GET requests. URLs starting with "/" are relative to the
+//     handler's base URL, and URLs starting with "@name" to its base URL of that name.
+//   - Headers: The HTTP headers of the requests.
+//   - Watch: The JSONPath expression of the part of the response compared, e.g. "$.event.id".
+//     Empty compares the whole response.
+//   - TriggerOnFirst: Whether the first response seen for an account counts as a change.
+//
+// # Example tasks.json entry:
+//
+//	{
+//		"name": "ClaimEvent",
+//		"payload": {"action": "claim_event"},
+//		"interval_minutes": 1,
+//		"poll": {"url": "/events/current", "watch": "$.event.id"}
+//	}
+type TaskPollConfig struct {
+	URL            string            `json:"url"`                        // URL polled
+	Headers        map[string]string `json:"headers,omitempty"`          // HTTP headers of the requests
+	Watch          string            `json:"watch,omitempty"`            // Part of the response compared
+	TriggerOnFirst bool              `json:"trigger_on_first,omitempty"` // Whether the first response is a change
+}
+
+// TaskRetryConfig represents the retry policy of a task (see retry.Policy).
+//
+// # Fields:
+//   - MaxAttempts: The maximum number of attempts, including the first one.
+//   - MinDelayMs: The delay before the second attempt, in milliseconds.
+//   - MaxDelayMs: The upper bound of any delay, in milliseconds. Zero means unbounded.
+type TaskRetryConfig struct {
+	MaxAttempts int `json:"max_attempts"` // Maximum number of attempts
+	MinDelayMs  int `json:"min_delay_ms"` // Delay before the second attempt
+	MaxDelayMs  int `json:"max_delay_ms"` // Upper bound of any delay
+}
+
+// TaskStepConfig represents one request of a pipeline task (see tasks.Step).
+//
+// # Fields:
+//   - Name: The name of the step, used in logs and errors.
+//   - Method: "GET" or "POST". Defaults to "POST".
+//   - URL: The URL of the request. URLs starting with "/" are relative to the handler's base URL,
+//     and URLs starting with "@name" to its base URL of that name (see tasks.ResolveURL).
+//     Empty sends the request to the base URL.
+//   - Payload: The JSON body of POST requests.
+//   - Headers: The HTTP headers of the request, merged over the headers of the task.
+//   - Condition: The expression the step runs under, e.g. "resp.energy > 100". Skipped steps
+//     leave the variables and the last response unchanged.
+//   - Set: The variables extracted from the response, as "name = expression" assignments, e.g.
+//     "token = resp.data.accessToken".
+//   - Extract: The state keys of the account set from the response, mapped to the JSONPath
+//     expressions of their values.
+//
+// Strings of the URL, payload, and headers may embed expressions between "{{" and "}}", e.g.
+// "Bearer {{vars.token}}". A string made of a single embedded expression takes its value.
+type TaskStepConfig struct {
+	Name      string                 `json:"name,omitempty"`      // Name of the step
+	Method    string                 `json:"method,omitempty"`    // HTTP method of the request
+	URL       string                 `json:"url,omitempty"`       // URL of the request
+	Payload   map[string]interface{} `json:"payload,omitempty"`   // JSON body of POST requests
+	Headers   map[string]string      `json:"headers,omitempty"`   // HTTP headers of the request
+	Condition string                 `json:"condition,omitempty"` // Expression the step runs under
+	Set       []string               `json:"set,omitempty"`       // Variables extracted from the response
+	Extract   map[string]string      `json:"extract,omitempty"`   // State keys set from the response
+}
+
+// TaskTemplate is a reusable task fragment of tasks.json, which tasks and other templates
+// extend through their "extends" field.
+//
+// A task extending a template inherits its payload, headers, retry policy, and interval, and
+// overrides them with its own:
+//   - Payload: Merged key by key, nested objects included. A null value removes the inherited key.
+//   - Headers: Merged header by header.
+//   - Extract: Merged state key by state key.
+//   - Retry: Replaced as a whole.
+//   - IntervalMinutes: Inherited by recurrent tasks without their own interval.
+//   - TimeoutSeconds: Inherited by tasks without their own timeout.
+//   - AuthLocation, AuthKey: Inherited by tasks without their own location.
+//   - Backoff: Inherited by recurrent tasks without their own backoff.
+//
+// # Fields:
+//   - Extends: The name of the template this template inherits from.
+//   - Payload: The payload fragment shared by the tasks.
+//   - Headers: The HTTP headers shared by the tasks.
+//   - Extract: The state extraction shared by the tasks.
+//   - Retry: The retry policy shared by the tasks.
+//   - IntervalMinutes: The interval shared by recurrent tasks.
+//   - TimeoutSeconds: The execution time budget shared by the tasks.
+//   - AuthLocation: Where the requests of the tasks carry the game data.
+//   - AuthKey: The name of the game data parameter, header, or field.
+//   - Backoff: The interval backoff shared by recurrent tasks.
+type TaskTemplate struct {
+	Extends         string                 `json:"extends,omitempty"`          // Template this template inherits from
+	Payload         map[string]interface{} `json:"payload,omitempty"`          // Shared payload fragment
+	Headers         map[string]string      `json:"headers,omitempty"`          // Shared HTTP headers
+	Extract         map[string]string      `json:"extract,omitempty"`          // Shared state extraction
+	Retry           *TaskRetryConfig       `json:"retry,omitempty"`            // Shared retry policy
+	IntervalMinutes int                    `json:"interval_minutes,omitempty"` // Shared interval of recurrent tasks
+	TimeoutSeconds  int                    `json:"timeout_seconds,omitempty"`  // Shared execution time budget
+	AuthLocation    string                 `json:"auth_location,omitempty"`    // Shared location of the game data
+	AuthKey         string                 `json:"auth_key,omitempty"`         // Shared name of the game data
+	Backoff         *BackoffConfig         `json:"backoff,omitempty"`          // Shared interval backoff
 }
 
 // TaskCollection groups all tasks, both one-time and recurrent, for easier loading and management.
 //
 // # Fields:
+//   - Version: The version of the file structure, TasksVersion once loaded.
 //   - OneTimeTasks: A list of one-time tasks.
 //   - RecurrentTasks: A list of recurrent tasks.
+//   - Templates: The templates the tasks extend, keyed by name (see ResolveTemplates).
+//   - Include: The task files merged before the tasks of this file, as paths or glob patterns
+//     relative to this file (see Merge).
+//   - Remove: The names of tasks of the included files to drop, e.g. in a per-game override.
 //
 // # Example Usage:
 //
 //	taskCollection := TaskCollection{
+//		Version: TasksVersion,
 //		OneTimeTasks: []TaskConfig{
 //			{
 //				Name: "One-Time Task",
@@ -74,6 +248,220 @@ type RecurrentTaskConfig struct {
 //	}
 //	fmt.Println(taskCollection.OneTimeTasks[0].Name) // Output: One-Time Task
 type TaskCollection struct {
-	OneTimeTasks   []TaskConfig          `json:"one_time_tasks"`  // List of one-time tasks
-	RecurrentTasks []RecurrentTaskConfig `json:"recurrent_tasks"` // List of recurrent tasks
+	Version        int                     `json:"version"`             // Version of the file structure
+	OneTimeTasks   []TaskConfig            `json:"one_time_tasks"`      // List of one-time tasks
+	RecurrentTasks []RecurrentTaskConfig   `json:"recurrent_tasks"`     // List of recurrent tasks
+	Templates      map[string]TaskTemplate `json:"templates,omitempty"` // Templates the tasks extend
+	Include        []string                `json:"include,omitempty"`   // Task files merged before this one
+	Remove         []string                `json:"remove,omitempty"`    // Names of included tasks to drop
+}
+
+// Merge overrides the collection with other, as a task file overrides the files it includes:
+//   - The tasks named in other.Remove are dropped.
+//   - A task of other replaces the task of the same name, keeping its position. Other tasks of
+//     other are appended.
+//   - A template of other replaces the template of the same name.
+//
+// The version, includes, and removals of the collection are left unchanged.
+func (collection *TaskCollection) Merge(other TaskCollection) {
+	removed := make(map[string]bool, len(other.Remove))
+	for _, name := range other.Remove {
+		removed[name] = true
+	}
+	oneTime := collection.OneTimeTasks[:0:0]
+	for _, task := range collection.OneTimeTasks {
+		if !removed[task.Name] {
+			oneTime = append(oneTime, task)
+		}
+	}
+	for _, task := range other.OneTimeTasks {
+		replaced := false
+		for i := range oneTime {
+			if oneTime[i].Name == task.Name {
+				oneTime[i], replaced = task, true
+				break
+			}
+		}
+		if !replaced {
+			oneTime = append(oneTime, task)
+		}
+	}
+	recurrent := collection.RecurrentTasks[:0:0]
+	for _, task := range collection.RecurrentTasks {
+		if !removed[task.Name] {
+			recurrent = append(recurrent, task)
+		}
+	}
+	for _, task := range other.RecurrentTasks {
+		replaced := false
+		for i := range recurrent {
+			if recurrent[i].Name == task.Name {
+				recurrent[i], replaced = task, true
+				break
+			}
+		}
+		if !replaced {
+			recurrent = append(recurrent, task)
+		}
+	}
+	collection.OneTimeTasks, collection.RecurrentTasks = oneTime, recurrent
+	if len(other.Templates) > 0 && collection.Templates == nil {
+		collection.Templates = make(map[string]TaskTemplate, len(other.Templates))
+	}
+	for name, template := range other.Templates {
+		collection.Templates[name] = template
+	}
+}
+
+// ResolveTemplates applies the templates of the collection to the tasks extending them, so that
+// every task carries its complete payload, headers, and retry policy. The "extends" fields are
+// cleared afterwards.
+//
+// # Returns:
+//   - error: An error if a task or template extends an unknown template, or templates extend
+//     each other in a cycle. The collection may be partially resolved then.
+func (collection *TaskCollection) ResolveTemplates() error {
+	resolved := make(map[string]TaskTemplate, len(collection.Templates))
+	for i := range collection.OneTimeTasks {
+		task := &collection.OneTimeTasks[i]
+		if task.Extends == "" {
+			continue
+		}
+		template, err := collection.template(task.Extends, resolved, nil)
+		if err != nil {
+			return fmt.Errorf("task %s: %w", task.Name, err)
+		}
+		task.Payload = mergePayload(template.Payload, task.Payload)
+		task.Headers = mergeStrings(template.Headers, task.Headers)
+		task.Extract = mergeStrings(template.Extract, task.Extract)
+		if task.Retry == nil && template.Retry != nil {
+			retry := *template.Retry
+			task.Retry = &retry
+		}
+		if task.TimeoutSeconds == 0 {
+			task.TimeoutSeconds = template.TimeoutSeconds
+		}
+		if task.AuthLocation == "" {
+			task.AuthLocation, task.AuthKey = template.AuthLocation, template.AuthKey
+		}
+		task.Extends = ""
+	}
+	for i := range collection.RecurrentTasks {
+		task := &collection.RecurrentTasks[i]
+		if task.Extends == "" {
+			continue
+		}
+		template, err := collection.template(task.Extends, resolved, nil)
+		if err != nil {
+			return fmt.Errorf("task %s: %w", task.Name, err)
+		}
+		task.Payload = mergePayload(template.Payload, task.Payload)
+		task.Headers = mergeStrings(template.Headers, task.Headers)
+		task.Extract = mergeStrings(template.Extract, task.Extract)
+		if task.Retry == nil && template.Retry != nil {
+			retry := *template.Retry
+			task.Retry = &retry
+		}
+		if task.TimeoutSeconds == 0 {
+			task.TimeoutSeconds = template.TimeoutSeconds
+		}
+		if task.AuthLocation == "" {
+			task.AuthLocation, task.AuthKey = template.AuthLocation, template.AuthKey
+		}
+		if task.IntervalMinutes == 0 {
+			task.IntervalMinutes = template.IntervalMinutes
+		}
+		if task.Backoff == nil && template.Backoff != nil {
+			backoff := *template.Backoff
+			task.Backoff = &backoff
+		}
+		task.Extends = ""
+	}
+	return nil
+}
+
+// template returns the template name with the templates it extends applied, caching it in
+// resolved. chain holds the templates being resolved, to detect cycles.
+func (collection *TaskCollection) template(name string, resolved map[string]TaskTemplate, chain []string) (TaskTemplate, error) {
+	if template, ok := resolved[name]; ok {
+		return template, nil
+	}
+	for _, pending := range chain {
+		if pending == name {
+			return TaskTemplate{}, fmt.Errorf("templates extend each other in a cycle: %s -> %s", strings.Join(chain, " -> "), name)
+		}
+	}
+	template, ok := collection.Templates[name]
+	if !ok {
+		return TaskTemplate{}, fmt.Errorf("unknown task template: %s", name)
+	}
+	if template.Extends != "" {
+		parent, err := collection.template(template.Extends, resolved, append(chain, name))
+		if err != nil {
+			return TaskTemplate{}, err
+		}
+		template.Payload = mergePayload(parent.Payload, template.Payload)
+		template.Headers = mergeStrings(parent.Headers, template.Headers)
+		template.Extract = mergeStrings(parent.Extract, template.Extract)
+		if template.Retry == nil {
+			template.Retry = parent.Retry
+		}
+		if template.IntervalMinutes == 0 {
+			template.IntervalMinutes = parent.IntervalMinutes
+		}
+		if template.TimeoutSeconds == 0 {
+			template.TimeoutSeconds = parent.TimeoutSeconds
+		}
+		if template.AuthLocation == "" {
+			template.AuthLocation, template.AuthKey = parent.AuthLocation, parent.AuthKey
+		}
+		if template.Backoff == nil {
+			template.Backoff = parent.Backoff
+		}
+		template.Extends = ""
+	}
+	resolved[name] = template
+	return template, nil
+}
+
+// mergePayload returns base overridden by override. Nested objects are merged recursively, and
+// null values of override remove the key. Neither map is modified.
+func mergePayload(base, override map[string]interface{}) map[string]interface{} {
+	if base == nil {
+		return override
+	}
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		nested, isMap := value.(map[string]interface{})
+		inherited, inheritedMap := merged[key].(map[string]interface{})
+		if isMap && inheritedMap {
+			merged[key] = mergePayload(inherited, nested)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// mergeStrings returns base overridden by override, e.g. the headers of a template by the headers
+// of a task. Neither map is modified.
+func mergeStrings(base, override map[string]string) map[string]string {
+	if base == nil {
+		return override
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+	return merged
 }
@@ -0,0 +1,91 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WalletNetworkTON is the network of TON wallets, the default network of a Wallet.
+const WalletNetworkTON = "ton"
+
+// Wallet represents the crypto wallet the rewards of an account are withdrawn to.
+//
+// # Fields:
+//   - Address: The address of the wallet. TON addresses are accepted in user-friendly
+//     (e.g., "UQBvW8Z5huBkMJYdnfAEM5JqTNkuWX3diqYENkVosgvReHPy") or raw ("0:6f5b...") form.
+//   - Network: The network of the wallet, "ton" when empty.
+//   - Memo: The comment sent with transfers, required by the deposit addresses of exchanges.
+//
+// # Example accounts.json:
+//
+//	"wallet": {
+//		"address": "UQBvW8Z5huBkMJYdnfAEM5JqTNkuWX3diqYENkVosgvReHPy",
+//		"memo": "104958221"
+//	}
+type Wallet struct {
+	Address string `json:"address"`
+	Network string `json:"network,omitempty"`
+	Memo    string `json:"memo,omitempty"`
+}
+
+// NetworkName returns the network of the wallet, WalletNetworkTON when unset.
+func (wallet Wallet) NetworkName() string {
+	if wallet.Network == "" {
+		return WalletNetworkTON
+	}
+	return strings.ToLower(wallet.Network)
+}
+
+// Validate checks the address of the wallet. Only the addresses of TON wallets are checked
+// beyond being set; the checksum of user-friendly TON addresses is verified.
+func (wallet Wallet) Validate() error {
+	if wallet.Address == "" {
+		return fmt.Errorf("wallet address is empty")
+	}
+	if wallet.NetworkName() == WalletNetworkTON && !ValidTONAddress(wallet.Address) {
+		return fmt.Errorf("invalid TON address: %s", wallet.Address)
+	}
+	return nil
+}
+
+// ValidTONAddress reports whether address is a TON address, in raw form ("<workchain>:<hex
+// account ID>") or in user-friendly form (base64 or base64url, with a valid checksum).
+func ValidTONAddress(address string) bool {
+	if workchain, id, ok := strings.Cut(address, ":"); ok {
+		if _, err := strconv.ParseInt(workchain, 10, 32); err != nil {
+			return false
+		}
+		decoded, err := hex.DecodeString(id)
+		return err == nil && len(decoded) == 32
+	}
+	if len(address) != 48 {
+		return false
+	}
+	normalized := strings.NewReplacer("-", "+", "_", "/").Replace(address)
+	decoded, err := base64.StdEncoding.DecodeString(normalized)
+	if err != nil || len(decoded) != 36 {
+		return false
+	}
+	checksum := crc16(decoded[:34])
+	return decoded[34] == byte(checksum>>8) && decoded[35] == byte(checksum)
+}
+
+// crc16 returns the CRC-16/XMODEM checksum of data, the checksum of user-friendly TON
+// addresses.
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
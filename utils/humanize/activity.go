@@ -0,0 +1,71 @@
+package humanize
+
+import (
+	"time"
+)
+
+// ActivityCurve is the relative activity of a human for each hour of the day (0-23), from 0
+// (asleep) to 1 (most active).
+//
+// Curves are evaluated in the location of the time passed to them, so an account can be
+// given its own timezone with time.In.
+type ActivityCurve [24]float64
+
+// DefaultActivityCurve is a typical daily rhythm: asleep at night, active in the morning,
+// a lunch break, and the most active in the evening.
+var DefaultActivityCurve = ActivityCurve{
+	0.15, 0.05, 0.02, 0.01, 0.01, 0.03, // 00:00-05:59
+	0.15, 0.40, 0.60, 0.65, 0.60, 0.55, // 06:00-11:59
+	0.70, 0.60, 0.50, 0.50, 0.55, 0.65, // 12:00-17:59
+	0.80, 0.95, 1.00, 0.90, 0.65, 0.35, // 18:00-23:59
+}
+
+// Weight returns the activity at t, interpolated linearly between hours.
+func (curve ActivityCurve) Weight(t time.Time) float64 {
+	hour := t.Hour()
+	fraction := (float64(t.Minute()) + float64(t.Second())/60) / 60
+	return curve[hour]*(1-fraction) + curve[(hour+1)%24]*fraction
+}
+
+// Scale stretches a delay by the inverse of the activity at t, so actions are sparse while
+// the account "sleeps" and dense at its peak hours.
+//
+// The activity is floored at 0.05, so a delay is stretched at most twenty times.
+//
+// # Example:
+//
+//	delay := humanize.DefaultActivityCurve.Scale(humanize.LogNormal(10*time.Minute, 0.4), time.Now().In(location))
+func (curve ActivityCurve) Scale(delay time.Duration, t time.Time) time.Duration {
+	weight := curve.Weight(t)
+	if weight < 0.05 {
+		weight = 0.05
+	}
+	return time.Duration(float64(delay) / weight)
+}
+
+// ShouldAct reports, at random, whether a human would act at t: the probability is the activity at t.
+func (curve ActivityCurve) ShouldAct(t time.Time) bool {
+	return defaultGenerator.float64() < curve.Weight(t)
+}
+
+// NextActive returns a random time within the next 24 hours after t, distributed according
+// to the curve, e.g. to pick when an account opens the game today.
+func (curve ActivityCurve) NextActive(t time.Time) time.Time {
+	var total float64
+	for _, weight := range curve {
+		total += weight
+	}
+	if total <= 0 {
+		return t
+	}
+	pick := defaultGenerator.float64() * total
+	start := t.Truncate(time.Hour)
+	for offset := 1; offset <= 24; offset++ {
+		candidate := start.Add(time.Duration(offset) * time.Hour)
+		pick -= curve[candidate.Hour()]
+		if pick <= 0 {
+			return candidate.Add(defaultGenerator.Between(0, time.Hour))
+		}
+	}
+	return start.Add(24 * time.Hour)
+}
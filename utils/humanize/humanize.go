@@ -0,0 +1,148 @@
+package humanize
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Generator produces randomized, human-like delays.
+//
+// A Generator is safe for concurrent use. Adapters normally use the package-level functions,
+// which share a default generator; tests can create a seeded generator with New for
+// reproducible delays.
+//
+// # Fields:
+//   - rng: The random source of the generator.
+//   - mu: A mutex guarding rng.
+type Generator struct {
+	rng *rand.Rand
+	mu  sync.Mutex
+}
+
+// New creates a generator seeded with seed.
+func New(seed int64) *Generator {
+	return &Generator{rng: rand.New(rand.NewSource(seed))}
+}
+
+var defaultGenerator = New(time.Now().UnixNano())
+
+// Gaussian returns a normally distributed delay, truncated at zero.
+//
+// # Example:
+//
+//	time.Sleep(humanize.Gaussian(3*time.Second, 800*time.Millisecond))
+func Gaussian(mean, stddev time.Duration) time.Duration {
+	return defaultGenerator.Gaussian(mean, stddev)
+}
+
+// LogNormal returns a log-normally distributed delay with the given median. See Generator.LogNormal.
+func LogNormal(median time.Duration, sigma float64) time.Duration {
+	return defaultGenerator.LogNormal(median, sigma)
+}
+
+// Between returns a uniformly distributed delay in [min, max).
+func Between(min, max time.Duration) time.Duration {
+	return defaultGenerator.Between(min, max)
+}
+
+// TypingDuration returns how long a human takes to type text. See Generator.TypingDuration.
+func TypingDuration(text string, wordsPerMinute float64) time.Duration {
+	return defaultGenerator.TypingDuration(text, wordsPerMinute)
+}
+
+// Gaussian returns a normally distributed delay, truncated at zero.
+func (generator *Generator) Gaussian(mean, stddev time.Duration) time.Duration {
+	delay := float64(mean) + generator.normFloat64()*float64(stddev)
+	if delay < 0 {
+		return 0
+	}
+	return time.Duration(delay)
+}
+
+// LogNormal returns a log-normally distributed delay with the given median.
+//
+// Log-normal delays are always positive and have a long right tail, which matches human
+// reaction and think times better than a normal distribution: most actions are quick, and a
+// few take much longer. A sigma of 0.5 is a good starting point.
+//
+// # Example:
+//
+//	time.Sleep(humanize.LogNormal(2*time.Second, 0.5)) // Usually 1-4s, occasionally 6s+
+func (generator *Generator) LogNormal(median time.Duration, sigma float64) time.Duration {
+	return time.Duration(float64(median) * math.Exp(generator.normFloat64()*sigma))
+}
+
+// Between returns a uniformly distributed delay in [min, max).
+func (generator *Generator) Between(min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+	return min + time.Duration(generator.float64()*float64(max-min))
+}
+
+// TypingDuration returns how long a human takes to type text.
+//
+// Each character takes a log-normally distributed time derived from the typing speed, word
+// boundaries and punctuation add short think pauses, and occasional typos add a correction
+// delay, so message tasks can wait a realistic time before sending.
+//
+// # Parameters:
+//   - text: The text being typed.
+//   - wordsPerMinute: The typing speed, assuming five characters per word. Defaults to 40.
+//
+// # Example:
+//
+//	time.Sleep(humanize.TypingDuration("gm everyone!", 45))
+func (generator *Generator) TypingDuration(text string, wordsPerMinute float64) time.Duration {
+	if wordsPerMinute <= 0 {
+		wordsPerMinute = 40
+	}
+	perCharacter := time.Duration(float64(time.Minute) / (wordsPerMinute * 5))
+	var total time.Duration
+	for _, character := range text {
+		total += generator.LogNormal(perCharacter, 0.35)
+		switch {
+		case unicode.IsSpace(character):
+			if generator.float64() < 0.15 {
+				total += generator.LogNormal(400*time.Millisecond, 0.5)
+			}
+		case unicode.IsPunct(character):
+			total += generator.LogNormal(250*time.Millisecond, 0.5)
+		}
+		if generator.float64() < 0.02 {
+			total += generator.LogNormal(3*perCharacter, 0.4)
+		}
+	}
+	return total
+}
+
+// Sleep waits for d or until ctx is done, returning the context error in the latter case.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (generator *Generator) normFloat64() float64 {
+	generator.mu.Lock()
+	defer generator.mu.Unlock()
+	return generator.rng.NormFloat64()
+}
+
+func (generator *Generator) float64() float64 {
+	generator.mu.Lock()
+	defer generator.mu.Unlock()
+	return generator.rng.Float64()
+}
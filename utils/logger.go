@@ -1,25 +1,311 @@
 package utils
 
 import (
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/notify"
+	"github.com/nexus-telegram/NexusSDK/types"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-var logger *zap.Logger
+var (
+	loggerMu   sync.RWMutex
+	logger     *zap.Logger               // logger is filtered by the default level
+	baseLogger *zap.Logger               // baseLogger is the unfiltered logger module loggers derive from
+	levelsMu   sync.Mutex                // levelsMu serializes the changes of levels
+	levels     atomic.Pointer[logLevels] // levels is read without locking on every entry
+)
+
+// logLevels are the levels of the loggers. The map of a logLevels is never modified once
+// stored: adding or removing a module override stores a copy, so that entries are filtered
+// without taking a lock. The levels themselves are changed in place through their AtomicLevel.
+//
+// # Fields:
+//   - defaultLevel: The level of GetLogger and of the modules without an override.
+//   - modules: The level overrides of the modules, keyed by module.
+type logLevels struct {
+	defaultLevel zap.AtomicLevel
+	modules      map[string]zap.AtomicLevel
+}
+
+func init() {
+	levels.Store(&logLevels{defaultLevel: zap.NewAtomicLevelAt(zap.DebugLevel), modules: map[string]zap.AtomicLevel{}})
+}
+
+// Modules lists the library modules that obtain their logger through ModuleLogger.
+var Modules = []string{"handler", "httpclient", "tasks"}
 
 // InitLogger initializes the logger instance for the library
 func InitLogger(log *zap.Logger) {
-	logger = log
+	levelsMu.Lock()
+	levels.Store(&logLevels{defaultLevel: zap.NewAtomicLevelAt(zap.DebugLevel), modules: map[string]zap.AtomicLevel{}})
+	levelsMu.Unlock()
+
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	baseLogger = log
+	logger = log.WithOptions(withLevel(moduleEnabler("")))
 }
 
-// GetLogger returns the library's logger instance
+// GetLogger returns the library's logger instance, or a no-op logger if none was initialized
 func GetLogger() *zap.Logger {
+	loggerMu.RLock()
+	defer loggerMu.RUnlock()
+	if logger == nil {
+		return zap.NewNop()
+	}
 	return logger
 }
 
 // DefaultInit initializes a default logger if none is provided
 func DefaultInit() {
-	if logger == nil {
+	loggerMu.RLock()
+	initialized := logger != nil
+	loggerMu.RUnlock()
+	if !initialized {
 		log, _ := zap.NewProduction()
-		logger = log
+		InitLogger(log)
+	}
+}
+
+// InitLoggerFromConfig initializes the library logger from a types.LogConfig.
+//
+// The logger writes to every configured sink (stderr, a size-rotated file, and/or a Telegram
+// chat for critical entries) using the configured encoding, and applies the per-module level overrides to loggers obtained
+// through ModuleLogger.
+//
+// # Parameters:
+//   - config: The logging configuration, usually the "log" section of config.json.
+//
+// # Returns:
+//   - error: An error if a level or encoding is invalid or the log file cannot be opened.
+//
+// # Example:
+//
+//	config, err := handler.LoadConfig("config.json")
+//	if err != nil {
+//		log.Fatalf("Failed to load configuration: %v", err)
+//	}
+//	if err := utils.InitLoggerFromConfig(config.Log); err != nil {
+//		log.Fatalf("Failed to initialize logger: %v", err)
+//	}
+func InitLoggerFromConfig(config types.LogConfig) error {
+	level, err := parseLevel(config.Level, zap.InfoLevel)
+	if err != nil {
+		return err
+	}
+	modules := make(map[string]zap.AtomicLevel, len(config.Modules))
+	for module, name := range config.Modules {
+		moduleLevel, err := parseLevel(name, level)
+		if err != nil {
+			return fmt.Errorf("invalid level for module '%s': %w", module, err)
+		}
+		modules[module] = zap.NewAtomicLevelAt(moduleLevel)
+	}
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	var encoder zapcore.Encoder
+	switch strings.ToLower(config.Encoding) {
+	case "", "json":
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case "console":
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return fmt.Errorf("invalid log encoding: %s", config.Encoding)
+	}
+
+	var cores []zapcore.Core
+	if config.File.Path != "" {
+		file, err := NewRotatingFile(config.File.Path, config.File.MaxSizeMB, config.File.MaxBackups, config.File.MaxAgeDays)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		cores = append(cores, zapcore.NewCore(encoder.Clone(), zapcore.AddSync(file), zap.DebugLevel))
+	}
+	if config.Console || len(cores) == 0 {
+		cores = append(cores, zapcore.NewCore(encoder.Clone(), zapcore.Lock(os.Stderr), zap.DebugLevel))
+	}
+	if config.Telegram.BotToken != "" {
+		telegramLevel, err := parseLevel(config.Telegram.Level, zap.ErrorLevel)
+		if err != nil {
+			return fmt.Errorf("invalid level for Telegram sink: %w", err)
+		}
+		cores = append(cores, NewNotifierCore(notify.NewTelegram(config.Telegram.BotToken, config.Telegram.ChatID), NotifierCoreOptions{
+			Level:         telegramLevel,
+			BatchInterval: time.Duration(config.Telegram.BatchSeconds) * time.Second,
+			MaxPerMinute:  config.Telegram.MaxPerMinute,
+		}))
+	}
+
+	base := zap.New(zapcore.NewTee(cores...), zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel))
+
+	levelsMu.Lock()
+	levels.Store(&logLevels{defaultLevel: zap.NewAtomicLevelAt(level), modules: modules})
+	levelsMu.Unlock()
+
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	baseLogger = base
+	logger = base.WithOptions(withLevel(moduleEnabler("")))
+	return nil
+}
+
+// ModuleLogger returns the library logger for a module (e.g., "httpclient", "handler", "tasks").
+//
+// Entries are annotated with a "module" field and filtered by the module's level override
+// when one is configured, or by the default level otherwise. Levels are evaluated on every
+// entry, so changes made through SetLogLevel apply to loggers that were already obtained.
+func ModuleLogger(module string) *zap.Logger {
+	loggerMu.RLock()
+	defer loggerMu.RUnlock()
+	if baseLogger == nil {
+		return zap.NewNop()
+	}
+	return baseLogger.WithOptions(withLevel(moduleEnabler(module))).With(zap.String("module", module))
+}
+
+// SetLogLevel changes the minimum level of a module at runtime.
+//
+// An empty module changes the default level, which applies to GetLogger and to every
+// module without an override.
+//
+// # Parameters:
+//   - module: The module name (e.g., "httpclient"), or "" for the default level.
+//   - level: The new level ("debug", "info", "warn", "error", ...).
+//
+// # Returns:
+//   - error: An error if the level is invalid.
+//
+// # Example:
+//
+//	if err := utils.SetLogLevel("httpclient", "debug"); err != nil {
+//		log.Printf("Failed to change log level: %v", err)
+//	}
+func SetLogLevel(module, level string) error {
+	parsed, err := parseLevel(level, zap.InfoLevel)
+	if err != nil {
+		return err
+	}
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	current := levels.Load()
+	if module == "" {
+		current.defaultLevel.SetLevel(parsed)
+		return nil
+	}
+	if atomicLevel, ok := current.modules[module]; ok {
+		atomicLevel.SetLevel(parsed)
+		return nil
+	}
+	modules := make(map[string]zap.AtomicLevel, len(current.modules)+1)
+	for name, atomicLevel := range current.modules {
+		modules[name] = atomicLevel
+	}
+	modules[module] = zap.NewAtomicLevelAt(parsed)
+	levels.Store(&logLevels{defaultLevel: current.defaultLevel, modules: modules})
+	return nil
+}
+
+// ResetLogLevel removes a module's level override so that it follows the default level again.
+func ResetLogLevel(module string) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	current := levels.Load()
+	if _, ok := current.modules[module]; !ok {
+		return
+	}
+	modules := make(map[string]zap.AtomicLevel, len(current.modules))
+	for name, atomicLevel := range current.modules {
+		if name != module {
+			modules[name] = atomicLevel
+		}
+	}
+	levels.Store(&logLevels{defaultLevel: current.defaultLevel, modules: modules})
+}
+
+// LogLevels returns the current levels keyed by module, with the default level under "".
+func LogLevels() map[string]string {
+	current := levels.Load()
+	names := map[string]string{"": current.defaultLevel.Level().String()}
+	for _, module := range Modules {
+		names[module] = current.defaultLevel.Level().String()
+	}
+	for module, atomicLevel := range current.modules {
+		names[module] = atomicLevel.Level().String()
+	}
+	return names
+}
+
+// moduleEnabler is a zapcore.LevelEnabler resolving a module's level on every call, without
+// locking (see logLevels).
+type moduleEnabler string
+
+func (module moduleEnabler) Enabled(level zapcore.Level) bool {
+	current := levels.Load()
+	if atomicLevel, ok := current.modules[string(module)]; ok && module != "" {
+		return atomicLevel.Enabled(level)
+	}
+	return current.defaultLevel.Enabled(level)
+}
+
+// WithAccount returns a child logger annotated with the game name and the account's Telegram ID.
+//
+// # Example:
+//
+//	log := utils.WithAccount(utils.GetLogger(), "hamster", account)
+//	log.Info("Claimed daily reward") // {"game":"hamster","account":"987654321",...}
+func WithAccount(log *zap.Logger, game string, account types.Account) *zap.Logger {
+	if log == nil {
+		log = GetLogger()
+	}
+	return log.With(
+		zap.String("game", game),
+		zap.String("account", account.TelegramData.TelegramId),
+	)
+}
+
+func parseLevel(name string, fallback zapcore.Level) (zapcore.Level, error) {
+	if name == "" {
+		return fallback, nil
+	}
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(strings.ToLower(name))); err != nil {
+		return fallback, fmt.Errorf("invalid log level: %s", name)
+	}
+	return level, nil
+}
+
+// withLevel wraps a logger's core so that entries are additionally filtered by level.
+func withLevel(level zapcore.LevelEnabler) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelFilterCore{Core: core, level: level}
+	})
+}
+
+// levelFilterCore is a zapcore.Core that drops entries below a (possibly changing) level.
+type levelFilterCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (core *levelFilterCore) Enabled(level zapcore.Level) bool {
+	return core.level.Enabled(level) && core.Core.Enabled(level)
+}
+
+func (core *levelFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelFilterCore{Core: core.Core.With(fields), level: core.level}
+}
+
+func (core *levelFilterCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !core.level.Enabled(entry.Level) {
+		return checked
 	}
+	return core.Core.Check(entry, checked)
 }
@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"github.com/nexus-telegram/NexusSDK/notify"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// NotifierCoreOptions configures a core created by NewNotifierCore.
+//
+// # Fields:
+//   - Level: The levels forwarded to the notifier. Defaults to zapcore.ErrorLevel (Error and above).
+//   - BatchInterval: How long entries are collected before being sent as one message. Defaults to 10 seconds.
+//   - MaxPerMinute: The maximum number of messages sent per minute; extra entries are dropped and counted. Defaults to 10.
+type NotifierCoreOptions struct {
+	Level         zapcore.LevelEnabler
+	BatchInterval time.Duration
+	MaxPerMinute  int
+}
+
+// NewNotifierCore returns a zapcore.Core forwarding entries at or above the configured level to a notifier.
+//
+// Entries are batched into a single message per interval and rate limited, so a burst of
+// identical failures results in a handful of messages instead of thousands. Fatal and panic
+// entries are flushed synchronously before the process exits.
+//
+// # Parameters:
+//   - notifier: The destination of the messages (e.g., a notify.Telegram).
+//   - options: The level, batching, and rate limiting options.
+//
+// # Returns:
+//   - zapcore.Core: A core to be combined with other cores using zapcore.NewTee.
+//
+// # Example:
+//
+//	telegram := notify.NewTelegram("123456:ABC-DEF", "-1001234567890")
+//	core := utils.NewNotifierCore(telegram, utils.NotifierCoreOptions{Level: zapcore.ErrorLevel})
+//	logger := zap.New(zapcore.NewTee(existingCore, core))
+//	utils.InitLogger(logger)
+func NewNotifierCore(notifier notify.Notifier, options NotifierCoreOptions) zapcore.Core {
+	if options.Level == nil {
+		options.Level = zapcore.ErrorLevel
+	}
+	if options.BatchInterval <= 0 {
+		options.BatchInterval = 10 * time.Second
+	}
+	if options.MaxPerMinute <= 0 {
+		options.MaxPerMinute = 10
+	}
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		MessageKey:     "msg",
+		CallerKey:      "caller",
+		EncodeLevel:    zapcore.CapitalLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+	return &notifierCore{
+		LevelEnabler: options.Level,
+		encoder:      zapcore.NewConsoleEncoder(encoderConfig),
+		batcher: &notifyBatcher{
+			notifier:     notifier,
+			interval:     options.BatchInterval,
+			maxPerMinute: options.MaxPerMinute,
+		},
+	}
+}
+
+// notifierCore is the zapcore.Core returned by NewNotifierCore.
+type notifierCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	batcher *notifyBatcher
+}
+
+func (core *notifierCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := core.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	return &notifierCore{LevelEnabler: core.LevelEnabler, encoder: encoder, batcher: core.batcher}
+}
+
+func (core *notifierCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if core.Enabled(entry.Level) {
+		return checked.AddCore(entry, core)
+	}
+	return checked
+}
+
+func (core *notifierCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buffer, err := core.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	core.batcher.add(strings.TrimSpace(buffer.String()))
+	buffer.Free()
+	if entry.Level > zapcore.ErrorLevel {
+		return core.Sync()
+	}
+	return nil
+}
+
+func (core *notifierCore) Sync() error {
+	return core.batcher.flush()
+}
+
+// notifyBatcher collects messages and sends them in batches, respecting a per-minute limit.
+type notifyBatcher struct {
+	notifier     notify.Notifier
+	interval     time.Duration
+	maxPerMinute int
+	mu           sync.Mutex
+	pending      []string
+	dropped      int
+	sent         []time.Time
+	scheduled    bool
+}
+
+func (batcher *notifyBatcher) add(message string) {
+	batcher.mu.Lock()
+	defer batcher.mu.Unlock()
+	batcher.pending = append(batcher.pending, message)
+	if !batcher.scheduled {
+		batcher.scheduled = true
+		time.AfterFunc(batcher.interval, func() {
+			_ = batcher.flush()
+		})
+	}
+}
+
+// flush sends the pending messages, splitting them to respect the notifier message size.
+func (batcher *notifyBatcher) flush() error {
+	batcher.mu.Lock()
+	pending := batcher.pending
+	batcher.pending = nil
+	batcher.scheduled = false
+	var batches []notifyBatch
+	var current notifyBatch
+	for _, entry := range pending {
+		length := utf8.RuneCountInString(entry)
+		if len(current.entries) > 0 && current.length+len(batchSeparator)+length > notify.TelegramMaxMessageLength {
+			batches = append(batches, current)
+			current = notifyBatch{}
+		}
+		current.add(entry, length)
+	}
+	if len(current.entries) > 0 {
+		batches = append(batches, current)
+	}
+	now := time.Now()
+	var allowed []string
+	for _, batch := range batches {
+		if !batcher.allow(now) {
+			batcher.dropped += len(batch.entries)
+			continue
+		}
+		message := strings.Join(batch.entries, batchSeparator)
+		if batcher.dropped > 0 {
+			message = fmt.Sprintf("(%d earlier entries dropped by rate limit)%s%s", batcher.dropped, batchSeparator, message)
+			batcher.dropped = 0
+		}
+		allowed = append(allowed, message)
+	}
+	batcher.mu.Unlock()
+
+	var firstErr error
+	for _, message := range allowed {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := batcher.notifier.Notify(ctx, message); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		cancel()
+	}
+	return firstErr
+}
+
+// batchSeparator separates the entries of a notification.
+const batchSeparator = "\n\n"
+
+// notifyBatch is a notification being built from log entries: the entries, kept apart since
+// they can contain blank lines themselves, and the length of the notification in characters.
+type notifyBatch struct {
+	entries []string
+	length  int
+}
+
+// add appends an entry of length characters to the batch.
+func (batch *notifyBatch) add(entry string, length int) {
+	if len(batch.entries) > 0 {
+		batch.length += len(batchSeparator)
+	}
+	batch.entries = append(batch.entries, entry)
+	batch.length += length
+}
+
+// allow reports whether another message may be sent now. It must be called with mu held.
+func (batcher *notifyBatcher) allow(now time.Time) bool {
+	cutoff := now.Add(-time.Minute)
+	kept := batcher.sent[:0]
+	for _, sentAt := range batcher.sent {
+		if sentAt.After(cutoff) {
+			kept = append(kept, sentAt)
+		}
+	}
+	batcher.sent = kept
+	if len(batcher.sent) >= batcher.maxPerMinute {
+		return false
+	}
+	batcher.sent = append(batcher.sent, now)
+	return true
+}
@@ -0,0 +1,106 @@
+package resettime
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Reset describes the daily reset of a game, e.g. "every day at 00:00 UTC" or "every day at
+// 04:00 Europe/Moscow".
+//
+// # Fields:
+//   - Hour: The hour of the reset (0-23).
+//   - Minute: The minute of the reset (0-59).
+//   - Location: The timezone of the reset. Defaults to UTC.
+//
+// # Example:
+//
+//	moscow, _ := time.LoadLocation("Europe/Moscow")
+//	reset := resettime.Daily(4, 0, moscow)
+//	fmt.Println(reset.Next(time.Now())) // The next 04:00 in Moscow
+type Reset struct {
+	Hour     int
+	Minute   int
+	Location *time.Location
+}
+
+// Daily returns a daily reset at hour:minute in location (UTC when nil).
+func Daily(hour, minute int, location *time.Location) Reset {
+	return Reset{Hour: hour, Minute: minute, Location: location}
+}
+
+// Previous returns the latest reset at or before t.
+func (reset Reset) Previous(t time.Time) time.Time {
+	local := t.In(reset.location())
+	candidate := time.Date(local.Year(), local.Month(), local.Day(), reset.Hour, reset.Minute, 0, 0, reset.location())
+	if candidate.After(t) {
+		candidate = time.Date(local.Year(), local.Month(), local.Day()-1, reset.Hour, reset.Minute, 0, 0, reset.location())
+	}
+	return candidate
+}
+
+// Next returns the earliest reset strictly after t.
+//
+// Resets are computed on the calendar of the reset's timezone, so they stay at the same
+// wall-clock time across daylight saving changes.
+func (reset Reset) Next(t time.Time) time.Time {
+	previous := reset.Previous(t)
+	return time.Date(previous.Year(), previous.Month(), previous.Day()+1, reset.Hour, reset.Minute, 0, 0, reset.location())
+}
+
+// Until returns the time left from t until the next reset.
+func (reset Reset) Until(t time.Time) time.Duration {
+	return reset.Next(t).Sub(t)
+}
+
+// SameDay reports whether a and b fall in the same game day, i.e. no reset happened between them.
+//
+// # Example:
+//
+//	if reset.SameDay(profile.LastClaim, time.Now()) {
+//		return nil // Already claimed today
+//	}
+func (reset Reset) SameDay(a, b time.Time) bool {
+	return reset.Previous(a).Equal(reset.Previous(b))
+}
+
+// NextRun returns a random time between minOffset and maxOffset after a reset, no earlier than t.
+//
+// If t falls inside the window of the latest reset, the run is picked in the rest of that
+// window; otherwise it is picked in the window of the next reset. This spreads daily claims of
+// many accounts over a window (e.g., 5-30 minutes after reset) instead of hitting the game
+// at the exact reset time.
+//
+// # Parameters:
+//   - t: The earliest allowed time.
+//   - minOffset: The start of the window after the reset.
+//   - maxOffset: The end of the window after the reset.
+//
+// # Example:
+//
+//	run := reset.NextRun(time.Now(), 5*time.Minute, 30*time.Minute)
+func (reset Reset) NextRun(t time.Time, minOffset, maxOffset time.Duration) time.Time {
+	if maxOffset < minOffset {
+		maxOffset = minOffset
+	}
+	previous := reset.Previous(t)
+	start, end := previous.Add(minOffset), previous.Add(maxOffset)
+	if !end.After(t) {
+		next := reset.Next(t)
+		start, end = next.Add(minOffset), next.Add(maxOffset)
+	}
+	if start.Before(t) {
+		start = t
+	}
+	if !end.After(start) {
+		return start
+	}
+	return start.Add(time.Duration(rand.Int63n(int64(end.Sub(start)))))
+}
+
+func (reset Reset) location() *time.Location {
+	if reset.Location == nil {
+		return time.UTC
+	}
+	return reset.Location
+}
@@ -0,0 +1,233 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+)
+
+// Jitter selects how the computed backoff delay is randomized.
+type Jitter int
+
+const (
+	// JitterNone uses the exponential delay as is.
+	JitterNone Jitter = iota
+	// JitterFull picks a random delay between zero and the exponential delay.
+	JitterFull
+	// JitterEqual keeps half of the exponential delay and randomizes the other half.
+	JitterEqual
+	// JitterDecorrelated picks a random delay between InitialDelay and three times the previous delay.
+	JitterDecorrelated
+)
+
+// Policy describes how an operation is retried.
+//
+// # Fields:
+//   - MaxAttempts: The maximum number of attempts, including the first one. Values below 1 mean a single attempt.
+//   - InitialDelay: The delay before the second attempt.
+//   - MaxDelay: The upper bound of any delay. Zero means unbounded.
+//   - Multiplier: The growth factor of the delay between attempts. Defaults to 2.
+//   - Jitter: The randomization strategy applied to the delay.
+//   - Classify: Reports whether an error is worth retrying. Defaults to IsRetryable.
+//   - OnRetry: An optional callback invoked before waiting for the next attempt.
+//
+// # Example:
+//
+//	policy := retry.Policy{
+//		MaxAttempts:  5,
+//		InitialDelay: 500 * time.Millisecond,
+//		MaxDelay:     30 * time.Second,
+//		Jitter:       retry.JitterFull,
+//	}
+type Policy struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	Jitter       Jitter
+	Classify     func(err error) bool
+	OnRetry      func(attempt int, err error, delay time.Duration)
+}
+
+// DefaultPolicy returns a policy of 3 attempts with full-jitter exponential backoff starting
+// at 500ms and capped at 30s, retrying only errors accepted by IsRetryable.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:  3,
+		InitialDelay: 500 * time.Millisecond,
+		MaxDelay:     30 * time.Second,
+		Multiplier:   2,
+		Jitter:       JitterFull,
+	}
+}
+
+// attemptContextKey is the context key under which the current attempt number is stored.
+type attemptContextKey struct{}
+
+// Attempt returns the 1-based attempt number of the operation running with ctx, or 0 when
+// ctx does not come from Do.
+func Attempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptContextKey{}).(int)
+	return attempt
+}
+
+// Do runs fn until it succeeds, returns a non-retryable error, or the policy's attempts are exhausted.
+//
+// Each call of fn receives a context carrying the attempt number (see Attempt). Waiting between
+// attempts stops early when ctx is done.
+//
+// # Parameters:
+//   - ctx: The context bounding all attempts.
+//   - fn: The operation to run.
+//   - policy: The retry policy.
+//
+// # Returns:
+//   - error: nil on success, otherwise the error of the last attempt (unwrapped from Permanent),
+//     or the context error if ctx was done while waiting.
+//
+// # Example:
+//
+//	err := retry.Do(ctx, func(ctx context.Context) error {
+//		_, err := client.PostContext(ctx, url, payload)
+//		return err
+//	}, retry.DefaultPolicy())
+func Do(ctx context.Context, fn func(ctx context.Context) error, policy Policy) error {
+	classify := policy.Classify
+	if classify == nil {
+		classify = IsRetryable
+	}
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		err := fn(context.WithValue(ctx, attemptContextKey{}, attempt))
+		if err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if attempt >= policy.MaxAttempts || !classify(err) {
+			return err
+		}
+		delay = policy.Delay(attempt, delay)
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err, delay)
+		}
+		if delay <= 0 {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Delay returns the delay to wait after a failed attempt.
+//
+// # Parameters:
+//   - attempt: The 1-based number of the attempt that failed.
+//   - previous: The previous delay, used by JitterDecorrelated.
+func (policy Policy) Delay(attempt int, previous time.Duration) time.Duration {
+	if policy.InitialDelay <= 0 {
+		return 0
+	}
+	multiplier := policy.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	delay := float64(policy.InitialDelay) * math.Pow(multiplier, float64(attempt-1))
+	if policy.MaxDelay > 0 && delay > float64(policy.MaxDelay) {
+		delay = float64(policy.MaxDelay)
+	}
+	switch policy.Jitter {
+	case JitterFull:
+		delay = rand.Float64() * delay
+	case JitterEqual:
+		delay = delay/2 + rand.Float64()*delay/2
+	case JitterDecorrelated:
+		if previous < policy.InitialDelay {
+			previous = policy.InitialDelay
+		}
+		low := float64(policy.InitialDelay)
+		delay = low + rand.Float64()*(3*float64(previous)-low)
+		if policy.MaxDelay > 0 && delay > float64(policy.MaxDelay) {
+			delay = float64(policy.MaxDelay)
+		}
+	}
+	return time.Duration(delay)
+}
+
+// permanentError marks an error that must not be retried.
+type permanentError struct {
+	err error
+}
+
+func (permanent *permanentError) Error() string {
+	return permanent.err.Error()
+}
+
+func (permanent *permanentError) Unwrap() error {
+	return permanent.err
+}
+
+// Permanent wraps err so that Do returns it immediately without further attempts.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// IsRetryable reports whether err is a transient failure worth retrying.
+//
+// Network timeouts, refused or reset connections, unexpected EOFs, and errors implementing
+// Temporary() bool with a true result are retryable. Permanent errors and context
+// cancellation are not.
+func IsRetryable(err error) bool {
+	if err == nil || IsPermanent(err) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) {
+		if _, isNetError := temporary.(net.Error); !isNetError {
+			return temporary.Temporary()
+		}
+	}
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return true
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var opError *net.OpError
+	return errors.As(err, &opError)
+}
+
+// Always is a Policy.Classify function retrying every error.
+func Always(error) bool {
+	return true
+}
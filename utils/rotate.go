@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotatingFile is an io.Writer that writes to a file and rotates it once it reaches a maximum size.
+//
+// Rotated files are renamed with a timestamp suffix next to the active file
+// (e.g., "farm-2024-11-20T10-30-00.000.log") and pruned according to MaxBackups and MaxAge.
+//
+// # Fields:
+//   - path: The path of the active log file.
+//   - maxSize: The size in bytes at which the file is rotated.
+//   - maxBackups: The number of rotated files to keep (zero keeps all).
+//   - maxAge: How long rotated files are kept (zero keeps them forever).
+//   - file: The currently open file.
+//   - size: The current size of the open file.
+//   - mu: A mutex for thread-safe writes.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	file       *os.File
+	size       int64
+	mu         sync.Mutex
+}
+
+const rotatedTimeFormat = "2006-01-02T15-04-05.000"
+
+// NewRotatingFile opens (or creates) the log file at path and returns a RotatingFile writing to it.
+//
+// # Parameters:
+//   - path: The path of the active log file. Parent directories are created if needed.
+//   - maxSizeMB: The rotation threshold in megabytes. Defaults to 100 when not positive.
+//   - maxBackups: The number of rotated files to keep. Zero keeps all of them.
+//   - maxAgeDays: The number of days rotated files are kept. Zero keeps them forever.
+//
+// # Returns:
+//   - *RotatingFile: The rotating writer.
+//   - error: An error if the file or its directory cannot be created.
+func NewRotatingFile(path string, maxSizeMB, maxBackups, maxAgeDays int) (*RotatingFile, error) {
+	if maxSizeMB <= 0 {
+		maxSizeMB = 100
+	}
+	rotating := &RotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+	}
+	if err := rotating.open(); err != nil {
+		return nil, err
+	}
+	return rotating, nil
+}
+
+// Write writes p to the active file, rotating it first if the write would exceed the maximum size.
+func (rotating *RotatingFile) Write(p []byte) (int, error) {
+	rotating.mu.Lock()
+	defer rotating.mu.Unlock()
+	if rotating.file == nil {
+		if err := rotating.open(); err != nil {
+			return 0, err
+		}
+	}
+	if rotating.size+int64(len(p)) > rotating.maxSize && rotating.size > 0 {
+		if err := rotating.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rotating.file.Write(p)
+	rotating.size += int64(n)
+	return n, err
+}
+
+// Sync commits the active file's contents to stable storage.
+func (rotating *RotatingFile) Sync() error {
+	rotating.mu.Lock()
+	defer rotating.mu.Unlock()
+	if rotating.file == nil {
+		return nil
+	}
+	return rotating.file.Sync()
+}
+
+// Close closes the active file.
+func (rotating *RotatingFile) Close() error {
+	rotating.mu.Lock()
+	defer rotating.mu.Unlock()
+	if rotating.file == nil {
+		return nil
+	}
+	err := rotating.file.Close()
+	rotating.file = nil
+	return err
+}
+
+func (rotating *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rotating.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(rotating.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	rotating.file = file
+	rotating.size = info.Size()
+	return nil
+}
+
+func (rotating *RotatingFile) rotate() error {
+	if err := rotating.file.Close(); err != nil {
+		return err
+	}
+	rotating.file = nil
+	extension := filepath.Ext(rotating.path)
+	prefix := strings.TrimSuffix(rotating.path, extension)
+	backup := fmt.Sprintf("%s-%s%s", prefix, time.Now().Format(rotatedTimeFormat), extension)
+	if err := os.Rename(rotating.path, backup); err != nil {
+		return err
+	}
+	if err := rotating.open(); err != nil {
+		return err
+	}
+	rotating.prune(prefix, extension)
+	return nil
+}
+
+// prune removes rotated files exceeding the configured backup count or age.
+func (rotating *RotatingFile) prune(prefix, extension string) {
+	if rotating.maxBackups <= 0 && rotating.maxAge <= 0 {
+		return
+	}
+	matches, err := filepath.Glob(prefix + "-*" + extension)
+	if err != nil {
+		return
+	}
+	type backup struct {
+		path    string
+		created time.Time
+	}
+	var backups []backup
+	for _, match := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(match, prefix+"-"), extension)
+		created, err := time.ParseInLocation(rotatedTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: match, created: created})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].created.After(backups[j].created)
+	})
+	for i, entry := range backups {
+		expired := rotating.maxAge > 0 && time.Since(entry.created) > rotating.maxAge
+		excess := rotating.maxBackups > 0 && i >= rotating.maxBackups
+		if expired || excess {
+			_ = os.Remove(entry.path)
+		}
+	}
+}